@@ -144,3 +144,14 @@ func TestCheckDuplicates_ForceSkipsDuplicateCheck(t *testing.T) {
 		t.Errorf("checkDuplicates with force=true should not return error, got: %v", err)
 	}
 }
+
+func TestCheckDuplicates_IgnoresTrashed(t *testing.T) {
+	books := []catalog.Book{
+		{ID: "book1", Checksum: catalog.Checksum{SHA256: "abc123"}, Trashed: &catalog.Trashed{At: "2026-01-01T00:00:00Z"}},
+	}
+
+	// A trashed book with the same content is not a duplicate
+	if err := checkDuplicates(books, "abc123", false); err != nil {
+		t.Errorf("checkDuplicates matched a trashed book: %v", err)
+	}
+}
@@ -0,0 +1,346 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	ghclient "github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/util"
+	"github.com/charmbracelet/x/term"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// authUser returns the account of a token. Tests replace it.
+var authUser = func(token, apiBase string) (*ghclient.User, error) {
+	return ghclient.New(token, apiBase).CurrentUser()
+}
+
+// authProfile is the --profile flag of the auth commands.
+var authProfile string
+
+func newAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage the GitHub token",
+		Long: `Manage where shelfctl gets its GitHub token.
+
+The token is looked up along a chain of sources, configured under
+github.credentials and tried in order:
+
+  env       an environment variable (github.token_env, then SHELFCTL_GITHUB_TOKEN)
+  file      a file holding the token
+  gh        'gh auth token' from the GitHub CLI
+  keyring   the Secret Service keyring (via secret-tool)
+  command   any command that prints the token
+
+Without github.credentials only the environment is used. The token itself
+is never written to the config file.
+
+Profiles (see 'profiles' in the config) have credentials of their own;
+pass --profile to manage them.`,
+	}
+
+	cmd.PersistentFlags().StringVar(&authProfile, "profile", "", "Manage the token of this profile instead of github")
+	cmd.AddCommand(
+		newAuthLoginCmd(),
+		newAuthStatusCmd(),
+		newAuthLogoutCmd(),
+	)
+	return cmd
+}
+
+type authLoginOptions struct {
+	withToken bool
+	store     string
+	path      string
+	gh        bool
+	command   string
+	profile   string
+}
+
+func newAuthLoginCmd() *cobra.Command {
+	var opts authLoginOptions
+
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Store a token or pick a credential source",
+		Long: `Store a GitHub token for shelfctl, or point shelfctl at a token source.
+
+By default the token is read from a prompt (or stdin with --with-token),
+checked against the API and stored in the keyring, or in a token file next
+to the config when no keyring is available. The source is then added to
+github.credentials.
+
+With --gh or --command, nothing is stored: shelfctl asks the GitHub CLI or
+the command for the token each time.`,
+		Example: `  shelfctl auth login
+  echo "$TOKEN" | shelfctl auth login --with-token --store file
+  shelfctl auth login --gh
+  shelfctl auth login --command "pass show github/shelfctl"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.profile = authProfile
+			return runAuthLogin(opts, os.Stdin)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.withToken, "with-token", false, "Read the token from stdin")
+	cmd.Flags().StringVar(&opts.store, "store", "", "Where to store the token: keyring or file (default: keyring if available)")
+	cmd.Flags().StringVar(&opts.path, "path", "", "Token file for --store file (default: next to the config)")
+	cmd.Flags().BoolVar(&opts.gh, "gh", false, "Use the GitHub CLI's token ('gh auth token')")
+	cmd.Flags().StringVar(&opts.command, "command", "", "Use the output of this command as the token")
+	cmd.MarkFlagsMutuallyExclusive("gh", "command", "store")
+	cmd.MarkFlagsMutuallyExclusive("gh", "command", "with-token")
+	return cmd
+}
+
+func runAuthLogin(opts authLoginOptions, stdin io.Reader) error {
+	g, err := cfg.Profile(opts.profile)
+	if err != nil {
+		return err
+	}
+	var src config.CredentialSource
+	var token string
+
+	switch {
+	case opts.gh:
+		src = config.CredentialSource{Type: config.CredentialGH}
+	case opts.command != "":
+		src = config.CredentialSource{Type: config.CredentialCommand, Command: opts.command}
+	default:
+		switch opts.store {
+		case "":
+			src.Type = config.CredentialFile
+			if _, err := exec.LookPath("secret-tool"); err == nil {
+				src.Type = config.CredentialKeyring
+			}
+		case config.CredentialKeyring, config.CredentialFile:
+			src.Type = opts.store
+		default:
+			return fmt.Errorf("--store must be keyring or file, got %q", opts.store)
+		}
+		if src.Type == config.CredentialFile {
+			src.Path = opts.path
+		}
+		if token, err = readToken(stdin, opts.withToken); err != nil {
+			return err
+		}
+	}
+
+	if token == "" {
+		// Nothing to store: the source must already yield a token.
+		if token, err = src.Token(g); err != nil {
+			return fmt.Errorf("%s: %w", src.Describe(g), err)
+		}
+	}
+	user, err := authUser(token, g.APIBase)
+	if err != nil {
+		return fmt.Errorf("checking token: %w", err)
+	}
+
+	if src.Stores() {
+		if err := src.StoreToken(g, token); err != nil {
+			return fmt.Errorf("storing token: %w", err)
+		}
+	}
+	addCredentialSource(g, src)
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("saving config: %w", err)
+	}
+
+	ok("Logged in to %s as %s", g.Host(), user.Login)
+	fmt.Printf("  token source: %s\n", src.Describe(g))
+	if src.Type == config.CredentialFile {
+		fmt.Printf("  %s\n", color.YellowString("The token file is only protected by its permissions (0600)."))
+	}
+	return nil
+}
+
+// readToken reads a token from stdin, or from a hidden prompt on a terminal.
+func readToken(stdin io.Reader, fromStdin bool) (string, error) {
+	var token string
+	if !fromStdin && util.IsTTY() {
+		fmt.Print("Paste your GitHub token: ")
+		data, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Println()
+		if err != nil {
+			return "", fmt.Errorf("reading token: %w", err)
+		}
+		token = string(data)
+	} else {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("reading token: %w", err)
+		}
+		token = line
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("no token given")
+	}
+	return token, nil
+}
+
+// addCredentialSource puts src first in the credential chain, after the
+// environment, unless an equal source is already there. An environment
+// token still takes precedence, as it always has.
+func addCredentialSource(g *config.GitHubConfig, src config.CredentialSource) {
+	chain := g.CredentialChain()
+	for _, s := range chain {
+		if s == src {
+			g.Credentials = chain
+			return
+		}
+	}
+	var out []config.CredentialSource
+	added := false
+	for _, s := range chain {
+		out = append(out, s)
+		if !added && s.Type == config.CredentialEnv {
+			out = append(out, src)
+			added = true
+		}
+	}
+	if !added {
+		out = append([]config.CredentialSource{src}, out...)
+	}
+	g.Credentials = out
+}
+
+func newAuthStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the credential chain and the account in use",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthStatus(authProfile)
+		},
+	}
+}
+
+// runAuthStatus shows the credentials of a profile, or of github and every
+// profile if profile is empty.
+func runAuthStatus(profile string) error {
+	names := []string{profile}
+	if profile == "" {
+		names = append(names, cfg.ProfileNames()...)
+	}
+	var firstErr error
+	for i, name := range names {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := showAuthStatus(name); err != nil {
+			if len(names) > 1 {
+				warn("%v", err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func showAuthStatus(profile string) error {
+	g, err := cfg.Profile(profile)
+	if err != nil {
+		return err
+	}
+	if profile != "" {
+		header("%s: %s (%s)", profile, g.Host(), apiBaseOrDefault(g.APIBase))
+	} else {
+		header("%s (%s)", g.Host(), apiBaseOrDefault(g.APIBase))
+	}
+
+	var token string
+	for _, src := range g.CredentialChain() {
+		t, err := src.Token(g)
+		switch {
+		case err == nil && token == "":
+			token = t
+			fmt.Printf("  %s %s %s\n", color.GreenString("✓"), src.Describe(g), color.GreenString("(in use)"))
+		case err == nil:
+			fmt.Printf("  %s %s %s\n", color.GreenString("✓"), src.Describe(g), color.HiBlackString("(not used)"))
+		case err == config.ErrNoCredential:
+			fmt.Printf("  %s %s %s\n", color.HiBlackString("-"), src.Describe(g), color.HiBlackString("(no token)"))
+		default:
+			fmt.Printf("  %s %s: %v\n", color.RedString("✗"), src.Describe(g), err)
+		}
+	}
+	fmt.Println()
+
+	if token == "" {
+		if profile != "" {
+			return fmt.Errorf("no token found for profile %s — run 'shelfctl auth login --profile %s'", profile, profile)
+		}
+		return fmt.Errorf("no GitHub token found — run 'shelfctl auth login'")
+	}
+	user, err := authUser(token, g.APIBase)
+	if err != nil {
+		return fmt.Errorf("token rejected: %w", err)
+	}
+	ok("Logged in as %s", user.Login)
+	if len(user.Scopes) > 0 {
+		fmt.Printf("  scopes: %s\n", strings.Join(user.Scopes, ", "))
+	}
+	return nil
+}
+
+func newAuthLogoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Remove stored tokens",
+		Long: `Remove the tokens shelfctl stored (keyring entries and token files) and
+drop those sources from github.credentials. Tokens from the environment, the
+GitHub CLI or a command are managed outside shelfctl and are left alone.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthLogout(authProfile)
+		},
+	}
+}
+
+func runAuthLogout(profile string) error {
+	g, err := cfg.Profile(profile)
+	if err != nil {
+		return err
+	}
+	var kept []config.CredentialSource
+	removed := 0
+	for _, src := range g.Credentials {
+		if !src.Stores() {
+			kept = append(kept, src)
+			continue
+		}
+		if err := src.DeleteToken(g); err != nil {
+			return fmt.Errorf("%s: %w", src.Describe(g), err)
+		}
+		ok("Removed token from %s", src.Describe(g))
+		removed++
+	}
+	if removed == 0 {
+		warn("No stored token to remove")
+		return nil
+	}
+	g.Credentials = kept
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("saving config: %w", err)
+	}
+	for _, src := range kept {
+		if _, err := src.Token(g); err == nil {
+			fmt.Printf("  A token is still available from %s\n", src.Describe(g))
+		}
+	}
+	return nil
+}
+
+func apiBaseOrDefault(base string) string {
+	if base == "" {
+		return "https://api.github.com"
+	}
+	return base
+}
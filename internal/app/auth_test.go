@@ -0,0 +1,96 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	ghpkg "github.com/blackwell-systems/shelfctl/internal/github"
+)
+
+func TestAuthLoginStatusLogout(t *testing.T) {
+	origStdout, origCfg, origUser := os.Stdout, cfg, authUser
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg, authUser = origStdout, origCfg, origUser })
+	dir := t.TempDir()
+	t.Setenv("SHELFCTL_CONFIG", filepath.Join(dir, "config.yml"))
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("SHELFCTL_GITHUB_TOKEN", "")
+
+	var checked []string
+	authUser = func(token, apiBase string) (*ghpkg.User, error) {
+		checked = append(checked, token)
+		if token != "ghp_good" {
+			return nil, ghpkg.ErrUnauthorized
+		}
+		return &ghpkg.User{Login: "octocat"}, nil
+	}
+	cfg = &config.Config{GitHub: config.GitHubConfig{TokenEnv: "GITHUB_TOKEN"}}
+	tokenFile := filepath.Join(dir, "token")
+
+	// A rejected token is not stored.
+	err := runAuthLogin(authLoginOptions{withToken: true, store: "file", path: tokenFile}, strings.NewReader("ghp_bad\n"))
+	if err == nil {
+		t.Fatal("expected bad token to be rejected")
+	}
+	if _, err := os.Stat(tokenFile); !os.IsNotExist(err) {
+		t.Error("rejected token was stored")
+	}
+
+	if err := runAuthLogin(authLoginOptions{withToken: true, store: "file", path: tokenFile}, strings.NewReader("ghp_good\n")); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "config.yml"))
+	if strings.Contains(string(data), "ghp_good") {
+		t.Fatalf("token written to config:\n%s", data)
+	}
+	saved, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := saved.GitHub.Credentials
+	if len(chain) != 2 || chain[0].Type != config.CredentialEnv || chain[1].Type != config.CredentialFile {
+		t.Fatalf("credentials = %+v", chain)
+	}
+	if saved.GitHub.Token != "ghp_good" {
+		t.Errorf("resolved token = %q", saved.GitHub.Token)
+	}
+
+	cfg = saved
+	if err := runAuthStatus(""); err != nil {
+		t.Errorf("status: %v", err)
+	}
+
+	// Logging in again does not duplicate the source.
+	if err := runAuthLogin(authLoginOptions{withToken: true, store: "file", path: tokenFile}, strings.NewReader("ghp_good")); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.GitHub.Credentials) != 2 {
+		t.Errorf("credentials = %+v", cfg.GitHub.Credentials)
+	}
+
+	if err := runAuthLogout(""); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	if _, err := os.Stat(tokenFile); !os.IsNotExist(err) {
+		t.Error("token file not removed")
+	}
+	saved, _ = config.Load()
+	if saved.GitHub.Token != "" || len(saved.GitHub.Credentials) != 1 {
+		t.Errorf("after logout: token %q, credentials %+v", saved.GitHub.Token, saved.GitHub.Credentials)
+	}
+	cfg = saved
+	if err := runAuthStatus(""); err == nil {
+		t.Error("status should fail without a token")
+	}
+}
+
+func TestAddCredentialSource(t *testing.T) {
+	g := &config.GitHubConfig{Credentials: []config.CredentialSource{{Type: config.CredentialGH}}}
+	addCredentialSource(g, config.CredentialSource{Type: config.CredentialKeyring})
+	if len(g.Credentials) != 2 || g.Credentials[0].Type != config.CredentialKeyring {
+		t.Errorf("no env in chain: %+v", g.Credentials)
+	}
+}
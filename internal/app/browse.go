@@ -11,7 +11,11 @@ import (
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/history"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/blackwell-systems/shelfctl/internal/util"
 	"github.com/fatih/color"
@@ -22,33 +26,52 @@ import (
 type browserDownloader struct {
 	gh    *github.Client
 	cache *cache.Manager
+	// partSize is the size above which synced files are uploaded in parts.
+	partSize int64
 }
 
-func (d *browserDownloader) Download(owner, repo, bookID, release, asset, sha256 string) (bool, error) {
-	err := d.DownloadWithProgress(owner, repo, bookID, release, asset, sha256, nil)
+// bookHistoryCommits is how many catalog commits the history pane looks at.
+const bookHistoryCommits = 50
+
+// BookHistory implements tui.HistoryProvider.
+func (d *browserDownloader) BookHistory(owner, repo, catalogPath, bookID string) ([]tui.HistoryEntry, error) {
+	if catalogPath == "" {
+		catalogPath = "catalog.yml"
+	}
+	entries, err := history.Log(d.gh, owner, repo, catalogPath, bookHistoryCommits, bookID)
+	if err != nil {
+		return nil, err
+	}
+	var out []tui.HistoryEntry
+	for _, e := range entries {
+		for _, ch := range e.Changes {
+			out = append(out, tui.HistoryEntry{Date: e.Commit.Date, Author: e.Commit.Author, Summary: history.Describe(ch)})
+		}
+	}
+	return out, nil
+}
+
+func (d *browserDownloader) Download(owner, repo string, b catalog.Book) (bool, error) {
+	err := d.DownloadWithProgress(owner, repo, b, nil)
 	return err == nil, err
 }
 
-func (d *browserDownloader) DownloadWithProgress(owner, repo, bookID, release, asset, sha256 string, progressCh chan<- float64) error {
-	// Get release
-	rel, err := d.gh.GetReleaseByTag(owner, repo, release)
-	if err != nil {
-		return fmt.Errorf("release %q: %w", release, err)
+func (d *browserDownloader) DownloadWithProgress(owner, repo string, b catalog.Book, progressCh chan<- float64) error {
+	// Reuse a copy cached for another book, such as a duplicate
+	if d.cache.Link(owner, repo, b.ID, b.Source.Asset, b.Checksum.SHA256) {
+		return nil
 	}
 
-	// Find asset
-	assetObj, err := d.gh.FindAsset(owner, repo, rel.ID, asset)
+	// Get release
+	rel, err := d.gh.GetReleaseByTag(owner, repo, b.Source.Release)
 	if err != nil {
-		return fmt.Errorf("finding asset: %w", err)
-	}
-	if assetObj == nil {
-		return fmt.Errorf("asset %q not found", asset)
+		return fmt.Errorf("release %q: %w", b.Source.Release, err)
 	}
 
-	// Download
-	rc, err := d.gh.DownloadAsset(owner, repo, assetObj.ID)
+	// Find and download the asset, or its parts
+	rc, size, err := parts.Open(d.gh, owner, repo, rel.ID, b)
 	if err != nil {
-		return fmt.Errorf("download: %w", err)
+		return err
 	}
 	defer func() { _ = rc.Close() }()
 
@@ -57,13 +80,13 @@ func (d *browserDownloader) DownloadWithProgress(owner, repo, bookID, release, a
 	if progressCh != nil {
 		reader = &progressReader{
 			reader:     rc,
-			total:      assetObj.Size,
+			total:      size,
 			progressCh: progressCh,
 		}
 	}
 
 	// Store in cache
-	_, err = d.cache.Store(owner, repo, bookID, asset, reader, sha256)
+	_, err = d.cache.Store(owner, repo, b.ID, b.Source.Asset, reader, b.Checksum.SHA256)
 	if err != nil {
 		return fmt.Errorf("cache: %w", err)
 	}
@@ -87,7 +110,7 @@ func (d *browserDownloader) Sync(owner, repo, bookID, release, asset, catalogPat
 
 	// Get cached file path, hash, and size
 	cachedPath := d.cache.Path(owner, repo, bookID, asset)
-	cachedSHA, cachedSize, err := computeFileHash(cachedPath)
+	cachedSHA, cachedSize, err := d.cache.SHA256(owner, repo, bookID, asset)
 	if err != nil {
 		return false, fmt.Errorf("computing hash: %w", err)
 	}
@@ -98,15 +121,26 @@ func (d *browserDownloader) Sync(owner, repo, bookID, release, asset, catalogPat
 		return false, fmt.Errorf("release %q: %w", release, err)
 	}
 
-	// Find and delete old asset
-	oldAsset, err := d.gh.FindAsset(owner, repo, rel.ID, asset)
+	// Load the catalog, which lists the parts of multi-part assets
+	mgr := catalog.NewManager(d.gh, owner, repo, catalogPath)
+	books, err := mgr.Load()
 	if err != nil {
-		return false, fmt.Errorf("finding asset: %w", err)
+		return false, fmt.Errorf("loading catalog: %w", err)
 	}
-	if oldAsset != nil {
-		if err := d.gh.DeleteAsset(owner, repo, oldAsset.ID); err != nil {
-			return false, fmt.Errorf("deleting old asset: %w", err)
-		}
+	before, _ := catalog.Marshal(books)
+	bookToUpdate := catalog.ByID(books, bookID)
+	old := catalog.Book{ID: bookID, Checksum: catalog.Checksum{SHA256: catalogSHA256},
+		Source: catalog.Source{Release: release, Asset: asset}}
+	if bookToUpdate != nil {
+		old = *bookToUpdate
+	}
+
+	op := beginOp("sync", "sync "+bookID)
+	defer finishOp(op)
+
+	// Find and delete old asset, or its parts
+	if err := deleteBookAssets(op, d.gh, owner, repo, rel.ID, &old); err != nil {
+		return false, fmt.Errorf("deleting old asset: %w", err)
 	}
 
 	// Upload modified file
@@ -116,29 +150,31 @@ func (d *browserDownloader) Sync(owner, repo, bookID, release, asset, catalogPat
 	}
 	defer func() { _ = f.Close() }()
 
-	_, err = d.gh.UploadAsset(owner, repo, rel.ID, asset, f, cachedSize, "application/octet-stream")
+	manifest, err := parts.Upload(d.gh, owner, repo, rel.ID, asset, f, cachedSize, d.partSize)
 	if err != nil {
 		return false, fmt.Errorf("uploading: %w", err)
 	}
-
-	// Update catalog with new SHA256
-	mgr := catalog.NewManager(d.gh, owner, repo, catalogPath)
-	books, err := mgr.Load()
-	if err != nil {
-		return false, fmt.Errorf("loading catalog: %w", err)
+	uploaded := old.Source
+	uploaded.Parts = manifest
+	for _, loc := range journal.Locations(owner, repo, uploaded) {
+		op.Added(loc)
 	}
 
-	// Find and update the book
-	bookToUpdate := catalog.ByID(books, bookID)
+	// Update catalog with new SHA256
 	if bookToUpdate != nil {
 		bookToUpdate.Checksum.SHA256 = cachedSHA
 		bookToUpdate.SizeBytes = cachedSize
+		bookToUpdate.Encryption = d.gh.EncryptionScheme(owner, repo)
+		bookToUpdate.Source.Parts = manifest
 
 		commitMsg := fmt.Sprintf("sync: update %s with local changes", bookID)
 		if err := mgr.Save(books, commitMsg); err != nil {
 			return false, fmt.Errorf("saving catalog: %w", err)
 		}
+		after, _ := catalog.Marshal(books)
+		op.Catalog(owner, repo, catalogPath, before, after)
 	}
+	_ = d.cache.MarkSynced(owner, repo, bookID, asset, cachedSHA)
 
 	return true, nil
 }
@@ -243,7 +279,6 @@ For non-interactive (text) output, use --no-interactive flag.`,
 						shelf := &shelves[idx]
 						owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
 						catalogPath := shelf.EffectiveCatalogPath()
-						releaseTag := shelf.EffectiveRelease(cfg.Defaults.Release)
 
 						data, _, err := gh.GetFileContent(owner, shelf.Repo, catalogPath, "")
 						if err != nil {
@@ -257,7 +292,7 @@ For non-interactive (text) output, use --no-interactive flag.`,
 						}
 
 						var sr shelfResult
-						matched := f.Apply(books)
+						matched := f.Apply(catalog.Live(books))
 						for _, b := range matched {
 							cached := cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset)
 
@@ -274,7 +309,7 @@ For non-interactive (text) output, use --no-interactive flag.`,
 								Cached:      cached,
 								Owner:       owner,
 								Repo:        shelf.Repo,
-								Release:     releaseTag,
+								Release:     b.Source.Release,
 								CatalogPath: catalogPath,
 							})
 						}
@@ -317,8 +352,9 @@ For non-interactive (text) output, use --no-interactive flag.`,
 
 				// Create downloader for background downloads
 				dl := &browserDownloader{
-					gh:    gh,
-					cache: cacheMgr,
+					gh:       gh,
+					cache:    cacheMgr,
+					partSize: cfg.Defaults.EffectivePartSize(),
 				}
 
 				result, err := tui.RunListBrowser(allItems, dl)
@@ -356,7 +392,7 @@ For non-interactive (text) output, use --no-interactive flag.`,
 					continue
 				}
 
-				matched := f.Apply(books)
+				matched := f.Apply(catalog.Live(books))
 				if len(matched) == 0 {
 					continue
 				}
@@ -452,17 +488,9 @@ func handleBrowserAction(cmd *cobra.Command, result *tui.BrowserResult) error {
 			if err != nil {
 				return fmt.Errorf("release %q: %w", b.Source.Release, err)
 			}
-			asset, err := gh.FindAsset(item.Owner, item.Repo, rel.ID, b.Source.Asset)
+			rc, size, err := parts.Open(gh, item.Owner, item.Repo, rel.ID, *b)
 			if err != nil {
-				return fmt.Errorf("finding asset: %w", err)
-			}
-			if asset == nil {
-				return fmt.Errorf("asset %q not found in release %q", b.Source.Asset, b.Source.Release)
-			}
-
-			rc, err := gh.DownloadAsset(item.Owner, item.Repo, asset.ID)
-			if err != nil {
-				return fmt.Errorf("download: %w", err)
+				return err
 			}
 			defer func() { _ = rc.Close() }()
 
@@ -476,15 +504,15 @@ func handleBrowserAction(cmd *cobra.Command, result *tui.BrowserResult) error {
 
 				// Start download in goroutine
 				go func() {
-					pr := tui.NewProgressReader(rc, asset.Size, progressCh)
+					pr := tui.NewProgressReader(rc, size, progressCh)
 					_, err := cacheMgr.Store(item.Owner, item.Repo, b.ID, b.Source.Asset, pr, b.Checksum.SHA256)
 					close(progressCh)
 					errCh <- err
 				}()
 
 				// Show progress UI
-				label := fmt.Sprintf("Downloading %s (%s)", b.ID, humanBytes(asset.Size))
-				if err := tui.ShowProgress(label, asset.Size, progressCh); err != nil {
+				label := fmt.Sprintf("Downloading %s (%s)", b.ID, humanBytes(size))
+				if err := tui.ShowProgress(label, size, progressCh); err != nil {
 					// User cancelled - wait for goroutine to exit
 					<-errCh
 					return err
@@ -496,7 +524,7 @@ func handleBrowserAction(cmd *cobra.Command, result *tui.BrowserResult) error {
 				}
 			} else {
 				// Non-interactive mode: just print and download
-				fmt.Printf("Downloading %s (%s) …\n", b.ID, humanBytes(asset.Size))
+				fmt.Printf("Downloading %s (%s) …\n", b.ID, humanBytes(size))
 				_, err = cacheMgr.Store(item.Owner, item.Repo, b.ID, b.Source.Asset, rc, b.Checksum.SHA256)
 				if err != nil {
 					return fmt.Errorf("cache: %w", err)
@@ -510,6 +538,9 @@ func handleBrowserAction(cmd *cobra.Command, result *tui.BrowserResult) error {
 
 		// Open the file
 		path := cacheMgr.Path(item.Owner, item.Repo, b.ID, b.Source.Asset)
+		if err := cacheMgr.MarkOpened(item.Owner, item.Repo, b.ID, b.Source.Asset); err != nil {
+			warn("%v", err)
+		}
 		return openFile(path, "")
 
 	case tui.ActionEdit:
@@ -575,12 +606,15 @@ func handleBrowserAction(cmd *cobra.Command, result *tui.BrowserResult) error {
 		if err := gh.CommitFile(owner, shelf.Repo, catalogPath, updatedData, commitMsg); err != nil {
 			return fmt.Errorf("committing catalog: %w", err)
 		}
+		op := beginOp("edit-book", "edit "+b.ID)
+		op.Catalog(owner, shelf.Repo, catalogPath, data, updatedData)
+		finishOp(op)
 
 		// Update README with new metadata
 		readmeData, _, readmeErr := gh.GetFileContent(owner, shelf.Repo, "README.md", "")
 		if readmeErr == nil {
 			originalContent := string(readmeData)
-			readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(books))
+			readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(catalog.Live(books)))
 			readmeContent = operations.AppendToShelfREADME(readmeContent, updatedBook)
 
 			// Only commit if content actually changed
@@ -637,6 +671,13 @@ func handleBrowserAction(cmd *cobra.Command, result *tui.BrowserResult) error {
 		targetRelease := targetShelf.EffectiveRelease(cfg.Defaults.Release)
 		targetCatalogPath := targetShelf.EffectiveCatalogPath()
 
+		ids := make([]string, len(result.BookItems))
+		for i, item := range result.BookItems {
+			ids[i] = item.Book.ID
+		}
+		op := beginOp("move", "move "+bookList(ids))
+		defer finishOp(op)
+
 		// Move each book
 		successCount := 0
 		for _, bookItem := range result.BookItems {
@@ -693,6 +734,11 @@ func handleBrowserAction(cmd *cobra.Command, result *tui.BrowserResult) error {
 				continue
 			}
 
+			if err := trash.CheckID(destBooks, movedBook.ID); err != nil {
+				warn("Cannot move %s: %v", bookItem.Book.ID, err)
+				continue
+			}
+
 			// Append book to destination (replaces if ID exists)
 			destBooks = catalog.Append(destBooks, movedBook)
 
@@ -707,6 +753,7 @@ func handleBrowserAction(cmd *cobra.Command, result *tui.BrowserResult) error {
 				warn("Failed to commit destination catalog for %s: %v", bookItem.Book.ID, err)
 				continue
 			}
+			op.Catalog(targetOwner, targetShelf.Repo, targetCatalogPath, destData, destCatalogData)
 
 			// Commit source catalog after destination is safe
 			sourceCatalogData, err := catalog.Marshal(sourceBooks)
@@ -719,6 +766,7 @@ func handleBrowserAction(cmd *cobra.Command, result *tui.BrowserResult) error {
 				warn("Failed to commit source catalog for %s: %v", bookItem.Book.ID, err)
 				continue
 			}
+			op.Catalog(sourceOwner, sourceShelf.Repo, sourceCatalogPath, sourceData, sourceCatalogData)
 
 			// Clear from cache if exists (path will be invalid after move)
 			if bookItem.Cached {
@@ -62,6 +62,14 @@ func (f *fakeGitHubClient) CommitFile(owner, repo, filePath string, content []by
 	return nil
 }
 
+func (f *fakeGitHubClient) CommitFiles(owner, repo string, files map[string][]byte, message string) error {
+	return nil
+}
+
+func (f *fakeGitHubClient) ReplaceBranch(owner, repo, branch string, files map[string][]byte, message string) (bool, error) {
+	return false, nil
+}
+
 // testableHandleAssetCollision wraps handleAssetCollision using a provided client
 func testableHandleAssetCollision(client GitHubClient, owner, repo string, releaseID int64, assetName, releaseTag string, force bool) error {
 	existingAsset, err := client.FindAsset(owner, repo, releaseID, assetName)
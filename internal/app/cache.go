@@ -9,11 +9,23 @@ import (
 
 	"github.com/blackwell-systems/shelfctl/internal/cache"
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+// newCacheManager returns the cache of c, with automatic GC if it sets a
+// cache quota.
+func newCacheManager(c *config.Config) *cache.Manager {
+	m := cache.New(c.Defaults.CacheDir)
+	m.Logf = warn
+	if quota, err := c.Defaults.CacheQuota(); err == nil {
+		m.SetMaxSize(quota)
+	}
+	return m
+}
+
 func newCacheCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "cache",
@@ -24,6 +36,11 @@ func newCacheCmd() *cobra.Command {
 	cmd.AddCommand(
 		newCacheClearCmd(),
 		newCacheInfoCmd(),
+		newCacheGCCmd(),
+		newCachePinCmd(),
+		newCacheUnpinCmd(),
+		newCacheRefreshCmd(),
+		newCacheMigrateCmd(),
 	)
 
 	return cmd
@@ -448,8 +465,26 @@ func showAllCacheInfo() error {
 		printField("modified", fmt.Sprintf("%d (annotations/highlights)", modifiedCount))
 	}
 	printField("cache_size", humanBytes(totalSize))
+	pinned := 0
+	for _, r := range cacheMgr.Records() {
+		if r.Pinned {
+			pinned++
+		}
+	}
+	if pinned > 0 {
+		printField("pinned", fmt.Sprintf("%d", pinned))
+	}
+	if limit := cacheMgr.MaxSize(); limit > 0 {
+		printField("cache_limit", humanBytes(limit))
+	}
 	printField("cache_dir", cacheMgr.Path("", "", "", ""))
 
+	if cachedCount > 0 && cacheMgr.NeedsMigration() {
+		fmt.Println()
+		fmt.Printf("%s Some cached books are in an older cache layout or have their own copy instead of sharing the blob store\n", color.CyanString("ℹ"))
+		fmt.Printf("  Run 'shelfctl cache migrate' to move and deduplicate them\n")
+	}
+
 	uncachedCount := totalBooks - cachedCount
 	if uncachedCount > 0 {
 		fmt.Println()
@@ -605,7 +640,7 @@ func clearOrphans() error {
 	// Display orphans
 	fmt.Printf("Found %d orphaned cache entries (%s):\n\n", report.TotalCount, humanBytes(report.TotalSize))
 	for _, entry := range report.Entries {
-		fmt.Printf("  %s/%s (%s)\n", entry.Repo, entry.Filename, humanBytes(entry.Size))
+		fmt.Printf("  %s/%s/%s (%s)\n", entry.Owner, entry.Repo, entry.Filename, humanBytes(entry.Size))
 	}
 	fmt.Printf("\nThese files are no longer referenced in any shelf catalog.\n")
 
@@ -650,7 +685,7 @@ func loadAllBooksAcrossShelves() []tui.BookItem {
 			continue
 		}
 
-		for _, b := range books {
+		for _, b := range catalog.Live(books) {
 			cached := cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset)
 
 			// Download catalog cover if specified and not already cached
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/blackwell-systems/shelfctl/internal/cache"
+	"github.com/blackwell-systems/shelfctl/internal/util"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func newCacheGCCmd() *cobra.Command {
+	var (
+		maxSize string
+		dryRun  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Shrink the cache to its size limit",
+		Long: `Evict the least recently opened books from the cache until it fits in
+its size limit (defaults.cache_max_size, or --max-size).
+
+Books with local changes (annotations/highlights) are never evicted; sync
+them first. Pinned books (see 'cache pin') are never evicted either.
+Evicted books are downloaded again when opened.
+
+With a configured limit, the cache is also shrunk automatically after each
+download.
+
+Examples:
+  shelfctl cache gc                      Shrink to defaults.cache_max_size
+  shelfctl cache gc --max-size 20G       Shrink to 20 GiB
+  shelfctl cache gc --dry-run            Show what would be evicted`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit := cacheMgr.MaxSize()
+			if maxSize != "" {
+				var err error
+				if limit, err = util.ParseSize(maxSize); err != nil {
+					return err
+				}
+			}
+			if limit <= 0 {
+				return fmt.Errorf("no cache size limit: set defaults.cache_max_size or pass --max-size")
+			}
+			return runCacheGC(limit, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Size to shrink the cache to, such as 20G (default: defaults.cache_max_size)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be evicted without deleting anything")
+
+	return cmd
+}
+
+// runCacheGC evicts books until the cache fits in limit. The catalogs tell
+// which cached files are unchanged.
+func runCacheGC(limit int64, dryRun bool) error {
+	checksums := map[string]string{}
+	for _, item := range loadAllBooksAcrossShelves() {
+		checksums[cache.Key(item.Owner, item.Repo, item.Book.Source.Asset)] = item.Book.Checksum.SHA256
+	}
+
+	plan, err := cacheMgr.PlanGC(cache.GCOptions{MaxSize: limit, Checksums: checksums})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cache: %s, limit %s\n", humanBytes(plan.Size), humanBytes(limit))
+	if len(plan.Evict) == 0 && !plan.Over(limit) {
+		ok("Cache is within its limit")
+		return nil
+	}
+
+	if len(plan.Evict) > 0 {
+		verb := "Evicting"
+		if dryRun {
+			verb = "Would evict"
+		}
+		fmt.Printf("\n%s %d books (%s), least recently opened first:\n", verb, len(plan.Evict), humanBytes(plan.Freed))
+		for _, e := range plan.Evict {
+			fmt.Printf("  %s/%s/%s (%s, last opened %s)\n", e.Owner, e.Repo, e.Filename, humanBytes(e.Size), e.LastOpened.Local().Format("2006-01-02"))
+		}
+	}
+	for _, e := range plan.Modified {
+		fmt.Printf("%s Kept %s/%s/%s (local changes; run 'shelfctl sync' first)\n", color.CyanString("ℹ"), e.Owner, e.Repo, e.Filename)
+	}
+	if len(plan.Pinned) > 0 {
+		fmt.Printf("%s Kept %d pinned books (%s)\n", color.CyanString("ℹ"), len(plan.Pinned), humanBytes(entriesSize(plan.Pinned)))
+	}
+	for _, e := range plan.Unknown {
+		fmt.Printf("%s Kept %s/%s/%s (checksum unknown, such as books no longer in a catalog)\n", color.CyanString("ℹ"), e.Owner, e.Repo, e.Filename)
+	}
+
+	if !dryRun && len(plan.Evict) > 0 {
+		deleted, err := cacheMgr.Evict(plan.Evict)
+		if err != nil {
+			warn("%v", err)
+		}
+		fmt.Println()
+		if deleted < len(plan.Evict) {
+			if err == nil {
+				warn("Kept %d books that were pinned or changed meanwhile", len(plan.Evict)-deleted)
+			}
+			ok("Evicted %d books", deleted)
+		} else {
+			ok("Evicted %d books (%s)", deleted, humanBytes(plan.Freed))
+		}
+	}
+	if plan.Over(limit) {
+		warn("Cache is still %s over its limit", humanBytes(plan.Size-plan.Freed-limit))
+	}
+	return nil
+}
+
+func entriesSize(entries []cache.Entry) int64 {
+	var n int64
+	for _, e := range entries {
+		n += e.Size
+	}
+	return n
+}
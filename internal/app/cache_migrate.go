@@ -0,0 +1,61 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newCacheMigrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Move cached files into the shared blob store",
+		Long: `Move books cached by older versions of shelfctl into the cache's blob
+store, where files are kept by SHA256 and each book's path links to its
+file. Books that are cached on several shelves then share one copy, and
+cached books survive moves between shelves.
+
+Books cached before the cache was laid out by owner are moved under the
+owner of their shelf. Those whose repo is not a configured shelf, or is
+configured under several owners, stay where they are.
+
+Each cached file is hashed once. Running it again only picks up files that
+still have their own copy.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println("Migrating cache …")
+			report, err := cacheMgr.Migrate(shelfOwners())
+			if report.Moved > 0 {
+				ok("Moved %d cached files under their shelf's owner", report.Moved)
+			}
+			if report.Files > 0 {
+				ok("Moved %d cached files into the blob store", report.Files)
+			} else if err == nil {
+				ok("Cache is already migrated")
+			}
+			if report.Merged > 0 {
+				ok("%d duplicates now share storage (%s freed)", report.Merged, humanBytes(report.Saved))
+			}
+			return err
+		},
+	}
+}
+
+// shelfOwners maps the repo of each configured shelf to its owner, leaving
+// out repos configured under several owners.
+func shelfOwners() map[string]string {
+	owners := map[string]string{}
+	seen := map[string]bool{}
+	for i := range cfg.Shelves {
+		shelf := &cfg.Shelves[i]
+		owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+		if seen[shelf.Repo] && owners[shelf.Repo] != owner {
+			delete(owners, shelf.Repo)
+			continue
+		}
+		if !seen[shelf.Repo] {
+			owners[shelf.Repo] = owner
+		}
+		seen[shelf.Repo] = true
+	}
+	return owners
+}
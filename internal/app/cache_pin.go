@@ -0,0 +1,253 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/blackwell-systems/shelfctl/internal/cache"
+	"github.com/blackwell-systems/shelfctl/internal/tui"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// defaultPrefetchJobs is how many books pin and refresh download at once.
+const defaultPrefetchJobs = 4
+
+func newCachePinCmd() *cobra.Command {
+	var (
+		shelfName string
+		tag       string
+		query     string
+		jobs      int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "pin [book-id...]",
+		Short: "Keep books in the cache for offline reading",
+		Long: `Pin books, tags or search queries so their books stay in the cache.
+
+Pinned books are downloaded right away and are never evicted by 'cache gc'
+or the cache size limit. Tag and query pins also cover books added later;
+run 'shelfctl cache refresh' to download them.
+
+Without arguments, lists the pins.
+
+Examples:
+  shelfctl cache pin sicp gopl                     Pin two books
+  shelfctl cache pin --tag distributed-systems     Pin every book with a tag
+  shelfctl cache pin --query "raft" --shelf papers Pin search results on a shelf
+  shelfctl cache pin                               List pins`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pins := pinsFromArgs(args, shelfName, tag, query)
+			if len(pins) == 0 {
+				return listPins()
+			}
+			if shelfName != "" && cfg.ShelfByName(shelfName) == nil {
+				return fmt.Errorf("shelf %q not found in config", shelfName)
+			}
+			for _, p := range pins {
+				added, err := cacheMgr.AddPin(p)
+				if err != nil {
+					return err
+				}
+				if added {
+					ok("Pinned %s", p)
+				} else {
+					fmt.Printf("%s %s is already pinned\n", color.CyanString("ℹ"), p)
+				}
+			}
+			return refreshPins(jobs, true)
+		},
+	}
+
+	cmd.Flags().StringVar(&shelfName, "shelf", "", "Only pin books on this shelf")
+	cmd.Flags().StringVar(&tag, "tag", "", "Pin every book with this tag")
+	cmd.Flags().StringVar(&query, "query", "", "Pin every book matching this search (title, author, tags)")
+	cmd.Flags().IntVar(&jobs, "jobs", defaultPrefetchJobs, "Number of books to download at once")
+
+	return cmd
+}
+
+func newCacheUnpinCmd() *cobra.Command {
+	var (
+		shelfName string
+		tag       string
+		query     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "unpin [book-id...]",
+		Short: "Remove pins",
+		Long: `Remove pins added with 'cache pin'. Books stay cached, but may be
+evicted again.
+
+Examples:
+  shelfctl cache unpin sicp
+  shelfctl cache unpin --tag distributed-systems`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pins := pinsFromArgs(args, shelfName, tag, query)
+			if len(pins) == 0 {
+				return fmt.Errorf("specify book IDs, --tag or --query")
+			}
+			for _, p := range pins {
+				removed, err := cacheMgr.RemovePin(p)
+				if err != nil {
+					return err
+				}
+				if removed {
+					ok("Unpinned %s", p)
+				} else {
+					warn("%s is not pinned", p)
+				}
+			}
+			return refreshPins(0, false)
+		},
+	}
+
+	cmd.Flags().StringVar(&shelfName, "shelf", "", "Shelf the pin was limited to")
+	cmd.Flags().StringVar(&tag, "tag", "", "Tag pin to remove")
+	cmd.Flags().StringVar(&query, "query", "", "Query pin to remove")
+
+	return cmd
+}
+
+func newCacheRefreshCmd() *cobra.Command {
+	var jobs int
+
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Download pinned books that are not cached",
+		Long: `Match the pins against the current catalogs and download pinned books
+that are missing from the cache, such as books added under a pinned tag
+since it was pinned, or books removed with 'cache clear'.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return refreshPins(jobs, true)
+		},
+	}
+
+	cmd.Flags().IntVar(&jobs, "jobs", defaultPrefetchJobs, "Number of books to download at once")
+
+	return cmd
+}
+
+// pinsFromArgs builds the pins named on the command line.
+func pinsFromArgs(ids []string, shelf, tag, query string) []cache.Pin {
+	var pins []cache.Pin
+	for _, id := range ids {
+		pins = append(pins, cache.Pin{Shelf: shelf, ID: id})
+	}
+	if tag != "" {
+		pins = append(pins, cache.Pin{Shelf: shelf, Tag: tag})
+	}
+	if query != "" {
+		pins = append(pins, cache.Pin{Shelf: shelf, Query: query})
+	}
+	return pins
+}
+
+func listPins() error {
+	pins, err := cacheMgr.Pins()
+	if err != nil {
+		return err
+	}
+	if len(pins) == 0 {
+		fmt.Println("No pins. Add one with 'shelfctl cache pin <id|--tag|--query>'.")
+		return nil
+	}
+	for _, p := range pins {
+		fmt.Printf("  %s\n", p)
+	}
+	return nil
+}
+
+// refreshPins matches the pins against all shelves, marks the selected
+// books as pinned in the cache and, if fetch is set, downloads the missing
+// ones, jobs at a time.
+func refreshPins(jobs int, fetch bool) error {
+	pins, err := cacheMgr.Pins()
+	if err != nil {
+		return err
+	}
+
+	var selected []tui.BookItem
+	keys := map[string]bool{}
+	if len(pins) > 0 {
+		matched := make([]int, len(pins))
+		for _, item := range loadAllBooksAcrossShelves() {
+			hit := false
+			for i, p := range pins {
+				if p.Matches(item.ShelfName, item.Book) {
+					matched[i]++
+					hit = true
+				}
+			}
+			if hit {
+				selected = append(selected, item)
+				keys[cache.Key(item.Owner, item.Repo, item.Book.Source.Asset)] = true
+			}
+		}
+		for i, p := range pins {
+			if matched[i] == 0 {
+				warn("%s matches no books", p)
+			}
+		}
+	}
+	if err := cacheMgr.SetPinned(keys); err != nil {
+		return fmt.Errorf("recording pins: %w", err)
+	}
+	if !fetch {
+		return nil
+	}
+
+	var missing []tui.BookItem
+	for _, item := range selected {
+		if !item.Cached {
+			missing = append(missing, item)
+		}
+	}
+	if len(missing) == 0 {
+		ok("All %d pinned books are cached", len(selected))
+		return nil
+	}
+
+	fmt.Printf("Downloading %d of %d pinned books …\n", len(missing), len(selected))
+	fetched, failed := prefetchBooks(missing, jobs)
+	ok("Downloaded %d pinned books", fetched)
+	if failed > 0 {
+		return fmt.Errorf("%d pinned books failed to download; run 'shelfctl cache refresh' to retry", failed)
+	}
+	return nil
+}
+
+// prefetchBooks downloads books into the cache, jobs at a time, and
+// returns how many were downloaded and how many failed.
+func prefetchBooks(items []tui.BookItem, jobs int) (fetched, failed int) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	sem := make(chan struct{}, jobs)
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(item tui.BookItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			_, err := cacheBookWithProgress(gh, item.Owner, item.Repo, item.Book, nil)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				warn("%s: %v", item.Book.ID, err)
+				failed++
+				return
+			}
+			fetched++
+		}(item)
+	}
+	wg.Wait()
+	return fetched, failed
+}
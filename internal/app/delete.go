@@ -161,7 +161,7 @@ Examples:
 				ok("Repository deleted: %s/%s", owner, shelf.Repo)
 			} else {
 				fmt.Println()
-				fmt.Println(color.GreenString("GitHub repository preserved: https://github.com/%s/%s", owner, shelf.Repo))
+				fmt.Println(color.GreenString("GitHub repository preserved: https://%s/%s/%s", gh.For(owner, shelf.Repo).Host(), owner, shelf.Repo))
 				fmt.Println()
 				fmt.Println("To delete the repository later, use:")
 				fmt.Printf("  %s\n", color.CyanString(fmt.Sprintf("shelfctl delete-shelf %s --delete-repo", shelfName)))
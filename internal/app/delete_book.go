@@ -5,9 +5,14 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/blackwell-systems/shelfctl/internal/util"
 	"github.com/fatih/color"
@@ -18,6 +23,7 @@ func newDeleteBookCmd() *cobra.Command {
 	var (
 		shelfName   string
 		skipConfirm bool
+		permanent   bool
 	)
 
 	cmd := &cobra.Command{
@@ -26,11 +32,16 @@ func newDeleteBookCmd() *cobra.Command {
 		Long: `Remove one or more books from your library by deleting metadata and files.
 
 This command:
-  • Removes book entries from catalog.yml
-  • Deletes PDF/EPUB files from GitHub Release assets
+  • Moves the PDF/EPUB files into the shelf's "trash" release
+  • Marks the book entries in catalog.yml as trashed
   • Pushes the updated catalog
 
-This action is DESTRUCTIVE and cannot be easily undone.
+Trashed books are hidden everywhere except 'shelfctl trash list', and can
+be brought back with 'shelfctl trash restore' until the trash is emptied.
+
+With --permanent the files are deleted from the release and the entries
+removed from catalog.yml right away. A copy of each file is kept in the
+undo journal, so 'shelfctl undo' can still bring the books back.
 
 In TUI mode (no ID provided), you can select multiple books using checkboxes:
   • Spacebar to toggle selection
@@ -45,7 +56,10 @@ Examples:
   shelfctl delete-book sicp --shelf programming
 
   # Skip confirmation prompt
-  shelfctl delete-book sicp --shelf programming --yes`,
+  shelfctl delete-book sicp --shelf programming --yes
+
+  # Delete for good instead of moving to the trash
+  shelfctl delete-book sicp --permanent`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var booksToDelete []tui.BookItem
@@ -76,7 +90,7 @@ Examples:
 						continue
 					}
 
-					for _, b := range books {
+					for _, b := range catalog.Live(books) {
 						cached := cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset)
 						allItems = append(allItems, tui.BookItem{
 							Book:      b,
@@ -134,7 +148,7 @@ Examples:
 					}
 
 					for j := range books {
-						if books[j].ID == bookID {
+						if books[j].ID == bookID && !books[j].IsTrashed() {
 							foundBook = &books[j]
 							foundShelf = s
 							break
@@ -182,10 +196,17 @@ Examples:
 
 			fmt.Println()
 			fmt.Println(color.RedString("This will:"))
-			fmt.Println("  • Remove books from catalog.yml")
-			fmt.Println("  • " + color.RedString("DELETE") + " the files from GitHub Release assets")
-			fmt.Println()
-			fmt.Println(color.RedString("THIS CANNOT BE UNDONE"))
+			if permanent {
+				fmt.Println("  • Remove books from catalog.yml")
+				fmt.Println("  • " + color.RedString("DELETE") + " the files from GitHub Release assets")
+				fmt.Println()
+				fmt.Println("Run 'shelfctl undo' afterwards to bring them back.")
+			} else {
+				fmt.Println("  • Move the files into the shelf's trash release")
+				fmt.Println("  • Mark the books as trashed in catalog.yml")
+				fmt.Println()
+				fmt.Println("Run 'shelfctl trash restore' to bring them back.")
+			}
 			fmt.Println()
 
 			// Confirm
@@ -211,13 +232,26 @@ Examples:
 				}
 			}
 
+			ids := make([]string, len(booksToDelete))
+			for i, item := range booksToDelete {
+				ids[i] = item.Book.ID
+			}
+			op := beginOp("delete-book", "delete "+bookList(ids))
+			defer finishOp(op)
+
 			// Process each book deletion
 			for i, item := range booksToDelete {
 				if len(booksToDelete) > 1 {
 					fmt.Printf("\n[%d/%d] Deleting %s …\n", i+1, len(booksToDelete), item.Book.ID)
 				}
 
-				if err := deleteSingleBook(item); err != nil {
+				var err error
+				if permanent {
+					err = deleteSingleBook(item, op)
+				} else {
+					err = trashSingleBook(item, op, gh)
+				}
+				if err != nil {
 					warn("Failed to delete %s: %v", item.Book.ID, err)
 					failCount++
 					continue
@@ -229,12 +263,16 @@ Examples:
 			// Summary
 			fmt.Println()
 			if len(booksToDelete) == 1 {
-				if successCount == 1 {
+				if successCount == 1 && permanent {
 					ok("Book successfully deleted: %s", booksToDelete[0].Book.ID)
+				} else if successCount == 1 {
+					ok("Book moved to the trash: %s", booksToDelete[0].Book.ID)
 				}
 			} else {
-				if successCount > 0 {
+				if successCount > 0 && permanent {
 					ok("Successfully deleted %d books", successCount)
+				} else if successCount > 0 {
+					ok("Moved %d books to the trash", successCount)
 				}
 				if failCount > 0 {
 					warn("%d books failed to delete", failCount)
@@ -247,33 +285,46 @@ Examples:
 
 	cmd.Flags().StringVar(&shelfName, "shelf", "", "Shelf containing the book (if ID is ambiguous)")
 	cmd.Flags().BoolVar(&skipConfirm, "yes", false, "Skip confirmation prompt")
+	cmd.Flags().BoolVar(&permanent, "permanent", false, "Delete for good instead of moving to the trash")
 
 	return cmd
 }
 
-// deleteSingleBook deletes a single book: removes asset, updates catalog, clears cache
-func deleteSingleBook(item tui.BookItem) error {
+// deleteSingleBook deletes a single book: removes asset, updates catalog,
+// clears cache. The catalog and a copy of the asset are recorded in op.
+func deleteSingleBook(item tui.BookItem, op *journal.Op) error {
 	shelf := cfg.ShelfByName(item.ShelfName)
 	if shelf == nil {
 		return fmt.Errorf("shelf %q not found", item.ShelfName)
 	}
+	if err := requireWritable(shelf); err != nil {
+		return err
+	}
 
 	catalogPath := shelf.EffectiveCatalogPath()
-	releaseTag := shelf.EffectiveRelease(cfg.Defaults.Release)
 
 	// Get the release
-	rel, err := gh.GetReleaseByTag(item.Owner, item.Repo, releaseTag)
+	rel, err := gh.GetReleaseByTag(item.Owner, item.Repo, item.Book.Source.Release)
 	if err != nil {
 		return fmt.Errorf("could not get release: %w", err)
 	}
 
-	// Find the asset (to get asset.ID for later deletion)
-	asset, err := gh.FindAsset(item.Owner, item.Repo, rel.ID, item.Book.Source.Asset)
-	if err != nil {
-		return fmt.Errorf("could not find asset: %w", err)
-	}
-	if asset == nil {
-		return fmt.Errorf("asset %q not found in release", item.Book.Source.Asset)
+	// Find the asset, or its parts (to get the IDs for later deletion).
+	// Each is recorded in the journal as a book with just that asset.
+	var assets []*github.Asset
+	var recorded []catalog.Book
+	for _, name := range item.Book.Source.AssetNames() {
+		asset, err := gh.FindAsset(item.Owner, item.Repo, rel.ID, name)
+		if err != nil {
+			return fmt.Errorf("could not find asset: %w", err)
+		}
+		if asset == nil {
+			return fmt.Errorf("asset %q not found in release", name)
+		}
+		assets = append(assets, asset)
+		one := item.Book
+		one.Source.Asset, one.Source.Parts = name, nil
+		recorded = append(recorded, one)
 	}
 
 	// Load catalog
@@ -298,13 +349,21 @@ func deleteSingleBook(item tui.BookItem) error {
 		return fmt.Errorf("could not marshal catalog: %w", err)
 	}
 	commitMsg := fmt.Sprintf("delete: %s", item.Book.ID)
+	copyNames := make([]string, len(assets))
+	for i, asset := range assets {
+		copyNames[i] = keepCopy(op, gh, item.Owner, item.Repo, &recorded[i], asset.ID)
+	}
 	if err := gh.CommitFile(item.Owner, item.Repo, catalogPath, updatedData, commitMsg); err != nil {
 		return fmt.Errorf("could not commit catalog: %w", err)
 	}
+	op.Catalog(item.Owner, item.Repo, catalogPath, data, updatedData)
 
-	// Delete the asset from GitHub AFTER catalog is safely committed
-	if err := gh.DeleteAsset(item.Owner, item.Repo, asset.ID); err != nil {
-		return fmt.Errorf("could not delete asset: %w", err)
+	// Delete the assets from GitHub AFTER catalog is safely committed
+	for i, asset := range assets {
+		if err := gh.DeleteAsset(item.Owner, item.Repo, asset.ID); err != nil {
+			return fmt.Errorf("could not delete asset: %w", err)
+		}
+		op.Deleted(assetLocation(item.Owner, item.Repo, &recorded[i]), copyNames[i])
 	}
 
 	// Clear from cache
@@ -319,3 +378,65 @@ func deleteSingleBook(item tui.BookItem) error {
 
 	return nil
 }
+
+// trashSingleBook moves a book's asset into the shelf's trash release and
+// marks it as trashed in the catalog. Both are recorded in op.
+func trashSingleBook(item tui.BookItem, op *journal.Op, client GitHubClient) error {
+	shelf := cfg.ShelfByName(item.ShelfName)
+	if shelf == nil {
+		return fmt.Errorf("shelf %q not found", item.ShelfName)
+	}
+	if err := requireWritable(shelf); err != nil {
+		return err
+	}
+	catalogPath := shelf.EffectiveCatalogPath()
+
+	data, _, err := client.GetFileContent(item.Owner, item.Repo, catalogPath, "")
+	if err != nil {
+		return fmt.Errorf("could not load catalog: %w", err)
+	}
+	books, err := catalog.Parse(data)
+	if err != nil {
+		return fmt.Errorf("could not parse catalog: %w", err)
+	}
+	b := catalog.ByID(books, item.Book.ID)
+	if b == nil {
+		return fmt.Errorf("book %q not found in catalog", item.Book.ID)
+	}
+
+	from := journal.Locations(item.Owner, item.Repo, b.Source)
+	if err := trash.Put(client, item.Owner, item.Repo, b, time.Now()); err != nil {
+		return err
+	}
+	// The assets were uploaded again, encrypted (or not) for the shelf.
+	b.Encryption = shelf.EncryptionScheme()
+	op.MovedAll(from, journal.Locations(item.Owner, item.Repo, b.Source))
+
+	updatedData, err := catalog.Marshal(books)
+	if err != nil {
+		return fmt.Errorf("could not marshal catalog: %w", err)
+	}
+	commitMsg := fmt.Sprintf("trash: %s", item.Book.ID)
+	if err := client.CommitFile(item.Owner, item.Repo, catalogPath, updatedData, commitMsg); err != nil {
+		return fmt.Errorf("could not commit catalog: %w", err)
+	}
+	op.Catalog(item.Owner, item.Repo, catalogPath, data, updatedData)
+
+	if cacheMgr.Exists(item.Owner, item.Repo, item.Book.ID, item.Book.Source.Asset) {
+		if err := cacheMgr.Remove(item.Owner, item.Repo, item.Book.ID, item.Book.Source.Asset); err != nil {
+			warn("Could not clear cache: %v", err)
+		}
+	}
+
+	if readmeData, _, err := client.GetFileContent(item.Owner, item.Repo, "README.md", ""); err == nil {
+		original := string(readmeData)
+		content := operations.UpdateShelfREADMEStats(original, len(catalog.Live(books)))
+		content = operations.RemoveFromShelfREADME(content, item.Book.ID)
+		if content != original {
+			if err := client.CommitFile(item.Owner, item.Repo, "README.md", []byte(content), "Update README: remove "+item.Book.ID); err != nil {
+				warn("Could not update README.md: %v", err)
+			}
+		}
+	}
+	return nil
+}
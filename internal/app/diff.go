@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/blackwell-systems/shelfctl/internal/history"
+	"github.com/spf13/cobra"
+)
+
+type diffOptions struct {
+	shelfName string
+	from, to  string
+}
+
+func newDiffCmd() *cobra.Command {
+	var opts diffOptions
+
+	cmd := &cobra.Command{
+		Use:   "diff <rev1> [rev2]",
+		Short: "Compare a shelf's catalog between two revisions",
+		Long: `Show which books were added, removed, edited or moved between two
+revisions of a shelf's catalog. Revisions are commit SHAs (as shown by
+'shelfctl log'), branches or tags; rev2 defaults to the current catalog.`,
+		Example: `  shelfctl diff a1b2c3d --shelf programming
+  shelfctl diff a1b2c3d 9f8e7d6 --shelf programming`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.from = args[0]
+			if len(args) == 2 {
+				opts.to = args[1]
+			}
+			return runDiffWithClient(opts, gh)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.shelfName, "shelf", "", "Shelf to compare (required with more than one shelf)")
+	return cmd
+}
+
+func runDiffWithClient(opts diffOptions, client history.Client) error {
+	shelfName := opts.shelfName
+	if shelfName == "" {
+		if len(cfg.Shelves) != 1 {
+			return fmt.Errorf("--shelf is required")
+		}
+		shelfName = cfg.Shelves[0].Name
+	}
+	shelf := cfg.ShelfByName(shelfName)
+	if shelf == nil {
+		return fmt.Errorf("shelf %q not found in config", shelfName)
+	}
+
+	owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+	changes, err := history.Diff(client, owner, shelf.Repo, shelf.EffectiveCatalogPath(), revision(opts.from), revision(opts.to))
+	if err != nil {
+		return err
+	}
+	to := opts.to
+	if to == "" {
+		to = "HEAD"
+	}
+	header("%s: %s..%s", shelf.Name, opts.from, to)
+	if len(changes) == 0 {
+		fmt.Println("No book changes.")
+		return nil
+	}
+	printChanges(changes)
+	return nil
+}
+
+// revision maps "HEAD" to the default branch.
+func revision(rev string) string {
+	if rev == "HEAD" {
+		return ""
+	}
+	return rev
+}
@@ -22,6 +22,8 @@ func newEditBookCmd() *cobra.Command {
 		year      int
 		addTags   string
 		rmTags    string
+		public    bool
+		private   bool
 	)
 
 	cmd := &cobra.Command{
@@ -29,7 +31,8 @@ func newEditBookCmd() *cobra.Command {
 		Short: "Edit metadata for a book",
 		Long: `Edit metadata for a book in your library.
 
-You can edit: title, author, year, and tags.
+You can edit: title, author, year, tags, and whether the book is listed
+by 'shelfctl publish' (--public / --private).
 You cannot edit: ID, format, checksum, or asset (these are tied to the file).
 
 In TUI mode (no ID provided), you can select multiple books using checkboxes:
@@ -43,8 +46,12 @@ Examples:
   shelfctl edit-book design-patterns --title "New Title"
   shelfctl edit-book gopl --author "Donovan & Kernighan" --year 2015
   shelfctl edit-book sicp --add-tag favorites --add-tag classics
-  shelfctl edit-book sicp --rm-tag draft`,
+  shelfctl edit-book sicp --rm-tag draft
+  shelfctl edit-book sicp --public                  # List on the published site`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if public && private {
+				return fmt.Errorf("--public and --private cannot be used together")
+			}
 			var booksToEdit []tui.BookItem
 
 			// Interactive mode: pick book(s) with multi-select
@@ -73,6 +80,7 @@ Examples:
 						continue
 					}
 
+					books = catalog.Live(books)
 					for j := range books {
 						b := &books[j]
 						cached := cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset)
@@ -140,7 +148,7 @@ Examples:
 			}
 
 			// Determine which mode: interactive form or CLI flags
-			useTUI := tui.ShouldUseTUI(cmd) && title == "" && author == "" && year == 0 && addTags == "" && rmTags == ""
+			useTUI := tui.ShouldUseTUI(cmd) && title == "" && author == "" && year == 0 && addTags == "" && rmTags == "" && !public && !private
 
 			// Group books by shelf for batch commit optimization
 			booksByShelf := make(map[string][]tui.BookItem)
@@ -152,6 +160,13 @@ Examples:
 			failCount := 0
 			allUpdatedBooks := make(map[string]catalog.Book) // Track updates by book ID
 
+			ids := make([]string, len(booksToEdit))
+			for i, item := range booksToEdit {
+				ids[i] = item.Book.ID
+			}
+			op := beginOp("edit-book", "edit "+bookList(ids))
+			defer finishOp(op)
+
 			// Process each shelf's books together
 			for _, shelfBooks := range booksByShelf {
 				shelf := cfg.ShelfByName(shelfBooks[0].ShelfName)
@@ -160,6 +175,11 @@ Examples:
 					failCount += len(shelfBooks)
 					continue
 				}
+				if err := requireWritable(shelf); err != nil {
+					warn("%v, skipping %d books", err, len(shelfBooks))
+					failCount += len(shelfBooks)
+					continue
+				}
 
 				owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
 				catalogPath := shelf.EffectiveCatalogPath()
@@ -275,6 +295,9 @@ Examples:
 							sort.Strings(tags)
 							updatedBook.Tags = tags
 						}
+						if public || private {
+							updatedBook.Public = public
+						}
 					}
 
 					// Update book in catalog
@@ -302,12 +325,13 @@ Examples:
 						warn("Could not commit catalog for shelf %s: %v", shelf.Name, err)
 						continue
 					}
+					op.Catalog(owner, shelf.Repo, catalogPath, catalogData, updatedData)
 
 					// Update README with new metadata
 					readmeData, _, readmeErr := gh.GetFileContent(owner, shelf.Repo, "README.md", "")
 					if readmeErr == nil {
 						originalContent := string(readmeData)
-						readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(books))
+						readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(catalog.Live(books)))
 
 						// Update all modified books in README
 						for _, book := range updatedBooks {
@@ -348,6 +372,9 @@ Examples:
 						if len(updatedBook.Tags) > 0 {
 							printField("tags", strings.Join(updatedBook.Tags, ", "))
 						}
+						if updatedBook.Public {
+							printField("public", "yes")
+						}
 						printField("shelf", booksToEdit[0].ShelfName)
 						fmt.Println()
 					}
@@ -372,6 +399,8 @@ Examples:
 	cmd.Flags().IntVar(&year, "year", 0, "Publication year")
 	cmd.Flags().StringVar(&addTags, "add-tag", "", "Add tags (comma-separated)")
 	cmd.Flags().StringVar(&rmTags, "rm-tag", "", "Remove tags (comma-separated)")
+	cmd.Flags().BoolVar(&public, "public", false, "List the book on the published site")
+	cmd.Flags().BoolVar(&private, "private", false, "Keep the book off the published site")
 
 	return cmd
 }
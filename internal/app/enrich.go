@@ -0,0 +1,253 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/enrich"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/util"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func newEnrichCmd() *cobra.Command {
+	var (
+		shelfName string
+		isbn      string
+		yes       bool
+		dryRun    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "enrich [id...]",
+		Short: "Fill in book metadata from Open Library",
+		Long: `Look up books by ISBN (or title and author) against an Open Library-compatible
+API and propose author, year, publisher, subjects, ISBN and cover.
+
+Each proposal is shown as a diff. Accepted changes for a shelf are written
+in a single catalog commit, together with any downloaded cover images.
+
+The endpoint is configured under 'enrich.api_base' and 'enrich.covers_base'.
+
+Examples:
+  shelfctl enrich                          # All books on all shelves
+  shelfctl enrich --shelf programming      # One shelf
+  shelfctl enrich sicp --isbn 9780262510875
+  shelfctl enrich --shelf programming --yes
+  shelfctl enrich --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if isbn != "" && len(args) != 1 {
+				return fmt.Errorf("--isbn requires exactly one book ID")
+			}
+
+			shelves := writableShelves(cfg.Shelves)
+			if shelfName != "" {
+				s := cfg.ShelfByName(shelfName)
+				if s == nil {
+					return fmt.Errorf("shelf %q not found in config", shelfName)
+				}
+				if err := requireWritable(s); err != nil {
+					return err
+				}
+				shelves = []config.ShelfConfig{*s}
+			}
+			if len(shelves) == 0 {
+				warn("No shelves configured")
+				return nil
+			}
+
+			if !yes && !dryRun && !util.IsTTY() {
+				return fmt.Errorf("use --yes or --dry-run in non-interactive mode")
+			}
+
+			client := enrich.New(cfg.Enrich.APIBase, cfg.Enrich.CoversBase)
+			wanted := make(map[string]bool, len(args))
+			for _, id := range args {
+				wanted[id] = true
+			}
+
+			reader := bufio.NewReader(os.Stdin)
+			acceptAll := yes
+			total := 0
+			op := beginOp("enrich", "enrich books")
+			defer finishOp(op)
+
+			for i := range shelves {
+				shelf := &shelves[i]
+				n, quit, err := enrichShelf(client, shelf, wanted, isbn, dryRun, &acceptAll, reader, op)
+				if err != nil {
+					warn("Shelf %s: %v", shelf.Name, err)
+				}
+				total += n
+				if quit {
+					break
+				}
+			}
+
+			fmt.Println()
+			switch {
+			case dryRun:
+				fmt.Printf("%d books would be updated (dry run — no changes made)\n", total)
+			case total == 0:
+				fmt.Println("No changes applied.")
+			default:
+				ok("Enriched %d books", total)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&shelfName, "shelf", "", "Only enrich books on this shelf")
+	cmd.Flags().StringVar(&isbn, "isbn", "", "ISBN to look up (single book only)")
+	cmd.Flags().BoolVar(&yes, "yes", false, "Apply all proposals without prompting")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show proposals without saving")
+
+	return cmd
+}
+
+// enrichShelf looks up every selected book on a shelf, asks about each
+// proposal, and commits the accepted ones, recording the catalog in op.
+// Returns the number of books changed and whether the user asked to stop.
+func enrichShelf(client *enrich.Client, shelf *config.ShelfConfig, wanted map[string]bool,
+	isbn string, dryRun bool, acceptAll *bool, reader *bufio.Reader, op *journal.Op) (int, bool, error) {
+
+	owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+	catalogPath := shelf.EffectiveCatalogPath()
+	mgr := catalog.NewManager(gh, owner, shelf.Repo, catalogPath)
+
+	books, err := mgr.Load()
+	if err != nil {
+		return 0, false, err
+	}
+	before, _ := catalog.Marshal(books)
+
+	covers := make(map[string][]byte)
+	changed := 0
+	quit := false
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	for j := range books {
+		b := books[j]
+		if len(wanted) > 0 && !wanted[b.ID] {
+			continue
+		}
+
+		q := enrich.Query{ISBN: b.ISBN, Title: b.Title, Author: b.Author}
+		if isbn != "" {
+			q.ISBN = isbn
+		}
+		rec, err := client.Lookup(q)
+		if err != nil {
+			warn("%s: lookup failed: %v", b.ID, err)
+			continue
+		}
+		p := enrich.Propose(b, rec)
+		if len(p.Changes) == 0 {
+			continue
+		}
+
+		printProposal(shelf.Name, p)
+		if dryRun {
+			changed++
+			continue
+		}
+
+		if !*acceptAll {
+			answer := promptProposal(reader)
+			switch answer {
+			case "a":
+				*acceptAll = true
+			case "q":
+				quit = true
+			case "y":
+			default:
+				continue
+			}
+			if quit {
+				break
+			}
+		}
+
+		if enrich.HasCover(p.Changes) {
+			data, err := client.FetchCover(rec)
+			if err != nil {
+				warn("%s: could not fetch cover: %v", b.ID, err)
+				p.Changes = dropField(p.Changes, "cover")
+			} else {
+				covers[enrich.CoverPath(b.ID)] = data
+			}
+		}
+
+		books[j] = enrich.Apply(b, p.Changes)
+		books[j].Meta.EnrichedAt = now
+		changed++
+	}
+
+	if dryRun || changed == 0 {
+		return changed, quit, nil
+	}
+
+	data, err := catalog.Marshal(books)
+	if err != nil {
+		return 0, quit, err
+	}
+	files := map[string][]byte{catalogPath: data}
+	for path, img := range covers {
+		files[path] = img
+	}
+
+	msg := fmt.Sprintf("enrich: %d books", changed)
+	if changed == 1 {
+		msg = "enrich: 1 book"
+	}
+	if err := gh.CommitFiles(owner, shelf.Repo, files, msg); err != nil {
+		return 0, quit, fmt.Errorf("committing catalog: %w", err)
+	}
+	op.Catalog(owner, shelf.Repo, catalogPath, before, data)
+	ok("Shelf %s: updated %d books", shelf.Name, changed)
+	return changed, quit, nil
+}
+
+func printProposal(shelfName string, p enrich.Proposal) {
+	fmt.Println()
+	fmt.Printf("%s %s [%s]\n", color.WhiteString(p.Book.ID), p.Book.Title, color.CyanString(shelfName))
+	for _, c := range p.Changes {
+		fmt.Printf("  %-10s", c.Field+":")
+		if c.Old != "" {
+			fmt.Printf(" %s", color.RedString("- %s", c.Old))
+		}
+		fmt.Printf(" %s\n", color.GreenString("+ %s", c.New))
+	}
+}
+
+// promptProposal asks whether to apply a proposal. Returns "y", "n", "a" or "q".
+func promptProposal(reader *bufio.Reader) string {
+	fmt.Print("Apply? [y/N/a(ll)/q(uit)]: ")
+	response, _ := reader.ReadString('\n')
+	response = strings.ToLower(strings.TrimSpace(response))
+	switch response {
+	case "y", "yes":
+		return "y"
+	case "a", "all":
+		return "a"
+	case "q", "quit":
+		return "q"
+	}
+	return "n"
+}
+
+func dropField(changes []enrich.Change, field string) []enrich.Change {
+	out := changes[:0]
+	for _, c := range changes {
+		if c.Field != field {
+			out = append(out, c)
+		}
+	}
+	return out
+}
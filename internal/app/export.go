@@ -0,0 +1,388 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/calibre"
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
+	"github.com/spf13/cobra"
+)
+
+type calibreExportOptions struct {
+	libraryDir  string
+	shelfName   string
+	filter      catalog.Filter
+	noCalibredb bool
+	dryRun      bool
+}
+
+// exportBook is a book selected for export together with its shelf.
+type exportBook struct {
+	book  catalog.Book
+	shelf *config.ShelfConfig
+	owner string
+}
+
+func newExportCmd() *cobra.Command {
+	var (
+		opts  calibreExportOptions
+		query string
+		tag   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export books into a Calibre library",
+		Long: `Export books into a Calibre library directory.
+
+Assets are taken from the local cache, downloading any that are missing.
+If calibredb is installed, books are added through it so Calibre's database
+stays current. Otherwise they are written in Calibre's layout
+(<Author>/<Title>/) with a metadata.opf sidecar and cover.jpg; use
+"Add books > Add from folders" or "calibredb restore_database" to pick them up.
+
+Every exported book gets a "shelf:<name>" tag recording where it came from.`,
+		Example: `  shelfctl export --to-calibre ~/Calibre\ Library
+  shelfctl export --to-calibre ~/Calibre\ Library --shelf programming
+  shelfctl export --to-calibre ./out --query "knuth" --tag algorithms --no-calibredb`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.filter = catalog.Filter{Search: query, Tag: tag}
+			return runCalibreExport(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.libraryDir, "to-calibre", "", "Calibre library directory to export into")
+	cmd.Flags().StringVar(&opts.shelfName, "shelf", "", "Only export books from this shelf")
+	cmd.Flags().StringVar(&query, "query", "", "Only export books matching this search")
+	cmd.Flags().StringVar(&tag, "tag", "", "Only export books with this tag")
+	cmd.Flags().BoolVar(&opts.noCalibredb, "no-calibredb", false, "Write files directly even if calibredb is installed")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Show what would be exported without writing anything")
+	_ = cmd.MarkFlagRequired("to-calibre")
+	return cmd
+}
+
+func runCalibreExport(opts calibreExportOptions) error {
+	return runCalibreExportWithClient(opts, gh)
+}
+
+func runCalibreExportWithClient(opts calibreExportOptions, client GitHubClient) error {
+	shelves := cfg.Shelves
+	if opts.shelfName != "" {
+		s := cfg.ShelfByName(opts.shelfName)
+		if s == nil {
+			return fmt.Errorf("shelf %q not found in config", opts.shelfName)
+		}
+		shelves = []config.ShelfConfig{*s}
+	}
+	if len(shelves) == 0 {
+		warn("No shelves configured")
+		return nil
+	}
+
+	var selected []exportBook
+	for i := range shelves {
+		shelf := &shelves[i]
+		owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+		data, _, err := client.GetFileContent(owner, shelf.Repo, shelf.EffectiveCatalogPath(), "")
+		if err != nil {
+			warn("Could not load catalog for shelf %q: %v", shelf.Name, err)
+			continue
+		}
+		books, err := catalog.Parse(data)
+		if err != nil {
+			warn("Could not parse catalog for shelf %q: %v", shelf.Name, err)
+			continue
+		}
+		for _, b := range opts.filter.Apply(catalog.Live(books)) {
+			selected = append(selected, exportBook{book: b, shelf: shelf, owner: owner})
+		}
+	}
+	if len(selected) == 0 {
+		warn("No books match")
+		return nil
+	}
+
+	calibredb := ""
+	if !opts.noCalibredb {
+		calibredb = calibre.FindCalibredb()
+	}
+	if calibredb != "" {
+		fmt.Printf("Exporting %d book(s) to %s via calibredb\n", len(selected), opts.libraryDir)
+	} else {
+		fmt.Printf("Exporting %d book(s) to %s\n", len(selected), opts.libraryDir)
+	}
+
+	exported, skipped, failed := 0, 0, 0
+	for _, eb := range selected {
+		b := eb.book
+		authors := splitAuthors(b.Author)
+		dir := filepath.Join(opts.libraryDir, calibre.BookDir(b.Title, authors))
+		if opts.dryRun {
+			fmt.Printf("  %s → %s\n", b.ID, dir)
+			continue
+		}
+
+		src, err := ensureCachedWithClient(client, eb.owner, eb.shelf.Repo, b)
+		if err != nil {
+			warn("%s: %v", b.ID, err)
+			failed++
+			continue
+		}
+		cover := exportCover(client, eb.owner, eb.shelf.Repo, b)
+
+		if calibredb != "" {
+			err = addWithCalibredb(calibredb, opts.libraryDir, src, b, eb.shelf.Name, cover)
+		} else {
+			var wrote bool
+			wrote, err = writeCalibreBook(dir, src, b, eb, cover)
+			if err == nil && !wrote {
+				skipped++
+				continue
+			}
+		}
+		if err != nil {
+			warn("%s: %v", b.ID, err)
+			failed++
+			continue
+		}
+		ok("%s → %s", b.ID, b.Title)
+		exported++
+	}
+
+	if opts.dryRun {
+		return nil
+	}
+	fmt.Printf("\nExported %d, skipped %d unchanged, %d failed\n", exported, skipped, failed)
+	if exported > 0 && calibredb == "" {
+		fmt.Println(`Run "calibredb restore_database --really-do-it" or add the folders from Calibre to index them.`)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d book(s) failed to export", failed)
+	}
+	return nil
+}
+
+// ensureCachedWithClient returns the cached path of a book's asset,
+// downloading it first if needed.
+func ensureCachedWithClient(client GitHubClient, owner, repo string, b catalog.Book) (string, error) {
+	return cacheBookWithProgress(client, owner, repo, b, nil)
+}
+
+// cacheBookWithProgress is ensureCachedWithClient with an optional progress
+// callback, called with the bytes received so far and the asset size.
+func cacheBookWithProgress(client GitHubClient, owner, repo string, b catalog.Book, progress func(done, total int64)) (string, error) {
+	if cacheMgr.Link(owner, repo, b.ID, b.Source.Asset, b.Checksum.SHA256) {
+		return cacheMgr.Path(owner, repo, b.ID, b.Source.Asset), nil
+	}
+	rel, err := client.GetReleaseByTag(owner, repo, b.Source.Release)
+	if err != nil {
+		return "", fmt.Errorf("release %q: %w", b.Source.Release, err)
+	}
+	rc, size, err := parts.Open(client, owner, repo, rel.ID, b)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = rc.Close() }()
+
+	var r io.Reader = rc
+	if progress != nil {
+		r = &callbackReader{r: rc, total: size, fn: progress}
+	}
+
+	fmt.Printf("Downloading %s (%s) …\n", b.ID, humanBytes(size))
+	path, err := cacheMgr.Store(owner, repo, b.ID, b.Source.Asset, r, b.Checksum.SHA256)
+	if err != nil {
+		return "", fmt.Errorf("cache: %w", err)
+	}
+	return path, nil
+}
+
+// callbackReader reports the running byte count to fn after each read.
+type callbackReader struct {
+	r     io.Reader
+	done  int64
+	total int64
+	fn    func(done, total int64)
+}
+
+func (p *callbackReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	p.fn(p.done, p.total)
+	return n, err
+}
+
+// exportCover returns cover image bytes for a book: the catalog cover from
+// the shelf repo, else one already cached locally. It returns nil if there
+// is none.
+func exportCover(client GitHubClient, owner, repo string, b catalog.Book) []byte {
+	if b.Cover != "" {
+		if data, _, err := client.GetFileContent(owner, repo, b.Cover, ""); err == nil && len(data) > 0 {
+			return data
+		}
+	}
+	if p := cacheMgr.GetCoverPath(repo, b.ID); p != "" {
+		if data, err := os.ReadFile(p); err == nil {
+			return data
+		}
+	}
+	return nil
+}
+
+// exportTags returns the book's tags plus the shelf provenance tag.
+func exportTags(b catalog.Book, shelfName string) []string {
+	tags := append([]string{}, b.Tags...)
+	return append(tags, "shelf:"+shelfName)
+}
+
+// writeCalibreBook writes a book, its metadata.opf and cover.jpg into dir.
+// It reports false when an identical file is already there.
+func writeCalibreBook(dir, src string, b catalog.Book, eb exportBook, cover []byte) (bool, error) {
+	ext := strings.TrimPrefix(filepath.Ext(b.Source.Asset), ".")
+	if ext == "" {
+		ext = b.Format
+	}
+	dest := filepath.Join(dir, calibre.BookFile(b.Title, splitAuthors(b.Author), ext))
+
+	if b.Checksum.SHA256 != "" {
+		if sha, _, err := hashFile(dest); err == nil && sha == b.Checksum.SHA256 {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, err
+	}
+	if err := copyFile(src, dest); err != nil {
+		return false, err
+	}
+
+	opf := calibre.OPF{
+		Identifier: fmt.Sprintf("%s/%s/%s", eb.owner, eb.shelf.Repo, b.ID),
+		Scheme:     "shelfctl",
+		Title:      b.Title,
+		Authors:    splitAuthors(b.Author),
+		Tags:       exportTags(b, eb.shelf.Name),
+		Publisher:  b.Publisher,
+		ISBN:       b.ISBN,
+		Year:       b.Year,
+	}
+	if len(cover) > 0 {
+		name := "cover" + coverExt(cover)
+		if err := os.WriteFile(filepath.Join(dir, name), cover, 0644); err != nil {
+			return false, err
+		}
+		opf.CoverHref = name
+	}
+	if err := os.WriteFile(filepath.Join(dir, "metadata.opf"), opf.Marshal(), 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// addWithCalibredb adds one book through calibredb, which keeps the library
+// database in sync. Calibre skips books whose title already exists.
+func addWithCalibredb(calibredb, libraryDir, src string, b catalog.Book, shelfName string, cover []byte) error {
+	args := []string{"add", "--library-path", libraryDir,
+		"--title", b.Title,
+		"--tags", strings.Join(exportTags(b, shelfName), ","),
+	}
+	if b.Author != "" {
+		args = append(args, "--authors", strings.Join(splitAuthors(b.Author), " & "))
+	}
+	if b.ISBN != "" {
+		args = append(args, "--isbn", b.ISBN)
+	}
+	if len(cover) > 0 {
+		f, err := os.CreateTemp("", "shelfctl-cover-*"+coverExt(cover))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = os.Remove(f.Name()) }()
+		_, err = f.Write(cover)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+		args = append(args, "--cover", f.Name())
+	}
+	args = append(args, src)
+
+	var stderr bytes.Buffer
+	c := exec.Command(calibredb, args...)
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("calibredb add: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// coverExt is the file extension for cover image data, .jpg if the format
+// is not recognized.
+func coverExt(cover []byte) string {
+	if ext := imageExt(cover); ext != "" {
+		return ext
+	}
+	return ".jpg"
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// splitAuthors splits a catalog author string into individual names.
+// "Kernighan, Ritchie"-style lists are split on commas, but a single
+// "Last, First" name is kept whole: a comma only separates authors when
+// every part is itself a multi-word name.
+func splitAuthors(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	for _, sep := range []string{" & ", " and ", ";"} {
+		if strings.Contains(s, sep) {
+			return trimAll(strings.Split(s, sep))
+		}
+	}
+	parts := trimAll(strings.Split(s, ","))
+	if len(parts) < 2 {
+		return []string{s}
+	}
+	for _, p := range parts {
+		if !strings.Contains(p, " ") {
+			return []string{s}
+		}
+	}
+	return parts
+}
+
+func trimAll(parts []string) []string {
+	var out []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/cache"
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+// setupExport configures one shelf with a single cached book.
+func setupExport(t *testing.T) *testutil.GitHub {
+	t.Helper()
+	origStdout, origCfg, origCache := os.Stdout, cfg, cacheMgr
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() {
+		os.Stdout, cfg, cacheMgr = origStdout, origCfg, origCache
+	})
+
+	cfg = &config.Config{
+		GitHub:   config.GitHubConfig{Owner: "me"},
+		Defaults: config.DefaultsConfig{Release: "library"},
+		Shelves:  []config.ShelfConfig{{Name: "books", Repo: "shelf-books"}},
+	}
+	cacheMgr = cache.New(t.TempDir())
+
+	b := catalog.Book{
+		ID: "sicp", Title: "SICP", Author: "Harold Abelson, Gerald Sussman",
+		Year: 1985, Format: "pdf", Tags: []string{"lisp"}, Cover: "covers/sicp.jpg",
+		Source: catalog.Source{Type: "github_release", Owner: "me", Repo: "shelf-books", Release: "library", Asset: "sicp.pdf"},
+	}
+	if _, err := cacheMgr.Store("me", "shelf-books", b.ID, b.Source.Asset, strings.NewReader("%PDF-1.4 test"), ""); err != nil {
+		t.Fatal(err)
+	}
+	data, err := catalog.Marshal([]catalog.Book{b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := testutil.NewGitHub()
+	shelf := client.Repo("me", "shelf-books")
+	shelf.Files["catalog.yml"] = data
+	shelf.Files["covers/sicp.jpg"] = []byte("jpeg")
+	return client
+}
+
+func TestCalibreExport_WritesLibraryLayout(t *testing.T) {
+	client := setupExport(t)
+	lib := t.TempDir()
+
+	opts := calibreExportOptions{libraryDir: lib, noCalibredb: true}
+	if err := runCalibreExportWithClient(opts, client); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	dir := filepath.Join(lib, "Harold Abelson", "SICP")
+	if _, err := os.Stat(filepath.Join(dir, "SICP - Harold Abelson.pdf")); err != nil {
+		t.Errorf("book file missing: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "cover.jpg")); string(data) != "jpeg" {
+		t.Errorf("cover = %q", data)
+	}
+	opf, err := os.ReadFile(filepath.Join(dir, "metadata.opf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<dc:subject>shelf:books</dc:subject>", "<dc:creator opf:role=\"aut\">Gerald Sussman</dc:creator>", "me/shelf-books/sicp"} {
+		if !strings.Contains(string(opf), want) {
+			t.Errorf("metadata.opf missing %q", want)
+		}
+	}
+}
+
+func TestCalibreExport_PNGCover(t *testing.T) {
+	client := setupExport(t)
+	client.Repo("me", "shelf-books").Files["covers/sicp.jpg"] = []byte("\x89PNG\r\n\x1a\n png data")
+	lib := t.TempDir()
+
+	if err := runCalibreExportWithClient(calibreExportOptions{libraryDir: lib, noCalibredb: true}, client); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	dir := filepath.Join(lib, "Harold Abelson", "SICP")
+	if _, err := os.Stat(filepath.Join(dir, "cover.png")); err != nil {
+		t.Errorf("cover.png missing: %v", err)
+	}
+	if opf, _ := os.ReadFile(filepath.Join(dir, "metadata.opf")); !strings.Contains(string(opf), `href="cover.png"`) {
+		t.Errorf("metadata.opf does not reference cover.png:\n%s", opf)
+	}
+}
+
+func TestCalibreExport_UsesCalibredb(t *testing.T) {
+	client := setupExport(t)
+	bin := t.TempDir()
+	argsFile := filepath.Join(bin, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "calibredb"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	lib := t.TempDir()
+	if err := runCalibreExportWithClient(calibreExportOptions{libraryDir: lib}, client); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("calibredb not invoked: %v", err)
+	}
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	joined := strings.Join(args, "|")
+	for _, want := range []string{"add|--library-path|" + lib, "--tags|lisp,shelf:books", "--authors|Harold Abelson & Gerald Sussman"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("calibredb args %v missing %q", args, want)
+		}
+	}
+	if !strings.HasSuffix(args[len(args)-1], "sicp.pdf") {
+		t.Errorf("last arg = %q, want the cached book", args[len(args)-1])
+	}
+}
+
+func TestSplitAuthors(t *testing.T) {
+	tests := map[string][]string{
+		"Harold Abelson, Gerald Sussman": {"Harold Abelson", "Gerald Sussman"},
+		"Knuth, Donald":                  {"Knuth, Donald"},
+		"A. Author & B. Author":          {"A. Author", "B. Author"},
+		"":                               nil,
+	}
+	for in, want := range tests {
+		got := splitAuthors(in)
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("splitAuthors(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -18,6 +18,9 @@ import (
 //   - Non-TTY without flag: returns an error with a hint
 func resolveOrCreateShelf(name string) (*config.ShelfConfig, error) {
 	if shelf := cfg.ShelfByName(name); shelf != nil {
+		if err := requireWritable(shelf); err != nil {
+			return nil, err
+		}
 		return shelf, nil
 	}
 
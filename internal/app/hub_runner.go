@@ -17,7 +17,7 @@ import (
 // runUnifiedTUI launches the unified TUI with seamless view switching
 func runUnifiedTUI() error {
 	// Check configuration status
-	hasToken := cfg != nil && cfg.GitHub.Token != ""
+	hasToken := cfg != nil && cfg.HasToken() || followsOnly(cfg)
 	hasShelves := cfg != nil && len(cfg.Shelves) > 0
 
 	// If not fully configured, show welcome/setup message
@@ -241,7 +241,7 @@ func runImportFromRepo() error {
 // DEPRECATED: This is the legacy implementation. Use runUnifiedTUI() for new code.
 func runHub() error {
 	// Check configuration status
-	hasToken := cfg != nil && cfg.GitHub.Token != ""
+	hasToken := cfg != nil && cfg.HasToken() || followsOnly(cfg)
 	hasShelves := cfg != nil && len(cfg.Shelves) > 0
 
 	// If not fully configured, show welcome/setup message
@@ -270,9 +270,12 @@ func runHub() error {
 		// Show specific next steps based on what's missing
 		if !hasToken {
 			fmt.Println("Next step: Set your GitHub token")
-			fmt.Printf("  %s\n\n", color.CyanString("export GITHUB_TOKEN=ghp_your_token_here"))
+			fmt.Printf("  %s\n", color.CyanString("shelfctl auth login"))
+			fmt.Printf("  %s\n\n", color.CyanString("export GITHUB_TOKEN=ghp_your_token_here   # or"))
 			fmt.Println("Then run 'shelfctl' again.")
 			fmt.Println()
+			fmt.Println("Or follow a public shelf without a token:")
+			fmt.Printf("  %s\n\n", color.CyanString("shelfctl init --owner OWNER --repo shelf-books --read-only"))
 			fmt.Println("For more details, see docs/guides/tutorial.md or run 'shelfctl init --help'")
 			return nil
 		} else if !hasShelves {
@@ -350,7 +353,7 @@ func runHub() error {
 
 		// Determine if this action is a TUI command (no "Press Enter" needed)
 		isTUIAction := action == "browse" || action == "shelve" || action == "edit-book" ||
-			action == "move" || action == "delete-book" || action == "cache-clear"
+			action == "move" || action == "delete-book" || action == "trash" || action == "cache-clear"
 
 		// Route to the appropriate command based on action
 		var cmdErr error
@@ -376,6 +379,10 @@ func runHub() error {
 			cmdErr = newMoveCmd().Execute()
 		case "delete-book":
 			cmdErr = newDeleteBookCmd().Execute()
+		case "trash":
+			cmd := newTrashCmd()
+			cmd.SetArgs([]string{"restore"})
+			cmdErr = cmd.Execute()
 		case "delete-shelf":
 			cmdErr = newDeleteShelfCmd().Execute()
 		case "cache-info":
@@ -6,10 +6,14 @@ import (
 	"os"
 	"time"
 
+	"github.com/blackwell-systems/shelfctl/internal/calibre"
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
 	ghclient "github.com/blackwell-systems/shelfctl/internal/github"
 	"github.com/blackwell-systems/shelfctl/internal/ingest"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/spf13/cobra"
 )
 
@@ -20,23 +24,63 @@ func newImportCmd() *cobra.Command {
 		dryRun     bool
 		maxN       int
 		noPush     bool
+		tags       []string
+		authors    []string
+		formats    []string
+		profile    string
 	)
 
 	cmd := &cobra.Command{
-		Use:   "import --from <owner/repo>",
-		Short: "Import books from another shelfctl shelf",
+		Use:   "import (--from <owner/repo> | --from-calibre <library>)",
+		Short: "Import books from another shelfctl shelf or a Calibre library",
 		Long: `Reads the catalog.yml from a source shelf repo and re-uploads each asset
 to your local shelf. Useful for absorbing another user's shelf or a second
 account's shelf into your own.
 
+With --from-calibre, reads a Calibre library's metadata.db instead and
+uploads one format file per book, plus its cover. Title, authors, tags,
+series (as a "series:<name>" tag), publisher, ISBN and publication year are
+carried over. Select books with --tag, --author and --format; --format also
+sets the preferred format when a book has several (default: epub, pdf,
+azw3, mobi). The catalog is committed every 20 books and progress is
+recorded, so rerunning an interrupted import picks up where it stopped.
+
 Skips duplicates by sha256.
 
+A source on another account or host, such as a GitHub Enterprise Server,
+is read through the profile given with --from-profile. Configured shelves
+already use their own profile.
+
 If the destination shelf doesn't exist, you'll be prompted to create it
 (or use --create-shelf to auto-create in scripts).`,
+		Example: `  shelfctl import --from alice/shelf-books --shelf books
+  shelfctl import --from team/shelf-papers --from-profile work --shelf papers
+  shelfctl import --from-calibre ~/Calibre\ Library --shelf books --tag fantasy
+  shelfctl import --from-calibre ~/Calibre\ Library --shelf papers --format pdf --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fromFlag, _ := cmd.Flags().GetString("from")
+			fromCalibre, _ := cmd.Flags().GetString("from-calibre")
+			if fromFlag != "" && fromCalibre != "" {
+				return fmt.Errorf("--from and --from-calibre are mutually exclusive")
+			}
+			if fromCalibre != "" {
+				prefer := formats
+				if len(prefer) == 0 {
+					prefer = calibre.DefaultFormatPreference
+				}
+				return runCalibreImport(calibreImportOptions{
+					libraryDir: fromCalibre,
+					shelfName:  shelfName,
+					releaseTag: releaseTag,
+					filter:     calibre.Filter{Tags: tags, Authors: authors, Formats: formats},
+					prefer:     prefer,
+					maxN:       maxN,
+					dryRun:     dryRun,
+					noPush:     noPush,
+				})
+			}
 			if fromFlag == "" {
-				return fmt.Errorf("--from owner/repo is required")
+				return fmt.Errorf("--from owner/repo or --from-calibre <library> is required")
 			}
 
 			// Parse "owner/repo".
@@ -44,6 +88,13 @@ If the destination shelf doesn't exist, you'll be prompted to create it
 			if err != nil {
 				return err
 			}
+			if profile != "" {
+				client, _, err := profileClient(profile)
+				if err != nil {
+					return err
+				}
+				gh.Route(srcOwner, srcRepo, client)
+			}
 
 			// Setup import configuration
 			importCtx, err := setupImportContext(shelfName, releaseTag, srcOwner, srcRepo)
@@ -51,6 +102,9 @@ If the destination shelf doesn't exist, you'll be prompted to create it
 				return err
 			}
 
+			importCtx.op = beginOp("import", fmt.Sprintf("import from %s/%s", importCtx.srcOwner, importCtx.srcRepo))
+			defer finishOp(importCtx.op)
+
 			// Perform the import
 			imported, skipped, err := performImport(importCtx, maxN, dryRun)
 			if err != nil {
@@ -62,12 +116,17 @@ If the destination shelf doesn't exist, you'll be prompted to create it
 		},
 	}
 
-	cmd.Flags().String("from", "", "Source shelf as owner/repo (required)")
+	cmd.Flags().String("from", "", "Source shelf as owner/repo")
+	cmd.Flags().StringVar(&profile, "from-profile", "", "Profile of the --from repo's account or host (default: github)")
+	cmd.Flags().String("from-calibre", "", "Source Calibre library directory (containing metadata.db)")
 	cmd.Flags().StringVar(&shelfName, "shelf", "", "Destination shelf name (required)")
 	cmd.Flags().StringVar(&releaseTag, "release", "", "Destination release (default: shelf's default_release)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print what would be imported without doing it")
 	cmd.Flags().IntVarP(&maxN, "n", "n", 0, "Limit per run (0 = unlimited)")
 	cmd.Flags().BoolVar(&noPush, "no-push", false, "Update catalog locally only")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Calibre: only books with any of these tags")
+	cmd.Flags().StringSliceVar(&authors, "author", nil, "Calibre: only books by authors matching any of these")
+	cmd.Flags().StringSliceVar(&formats, "format", nil, "Calibre: only these formats, in order of preference")
 
 	_ = cmd.MarkFlagRequired("shelf")
 	return cmd
@@ -102,8 +161,10 @@ type importContext struct {
 	catalogPath  string
 	srcBooks     []catalog.Book
 	dstBooks     []catalog.Book
+	dstData      []byte
 	existingSHAs map[string]bool
 	dstRel       *ghclient.Release
+	op           *journal.Op
 }
 
 func setupImportContext(shelfName, releaseTag, srcOwner, srcRepo string) (*importContext, error) {
@@ -131,9 +192,10 @@ func setupImportContext(shelfName, releaseTag, srcOwner, srcRepo string) (*impor
 	dstData, _, _ := gh.GetFileContent(dstOwner, shelf.Repo, catalogPath, "")
 	dstBooks, _ := catalog.Parse(dstData)
 
-	// Build sha256 index of existing books to detect duplicates.
+	// Build sha256 index of existing books to detect duplicates. Trashed
+	// books don't count: their assets are no longer on the shelf.
 	existingSHAs := map[string]bool{}
-	for _, b := range dstBooks {
+	for _, b := range catalog.Live(dstBooks) {
 		if b.Checksum.SHA256 != "" {
 			existingSHAs[b.Checksum.SHA256] = true
 		}
@@ -151,8 +213,9 @@ func setupImportContext(shelfName, releaseTag, srcOwner, srcRepo string) (*impor
 		dstOwner:     dstOwner,
 		releaseTag:   releaseTag,
 		catalogPath:  catalogPath,
-		srcBooks:     srcBooks,
+		srcBooks:     catalog.Live(srcBooks),
 		dstBooks:     dstBooks,
+		dstData:      dstData,
 		existingSHAs: existingSHAs,
 		dstRel:       dstRel,
 	}, nil
@@ -176,6 +239,12 @@ func performImport(ctx *importContext, maxN int, dryRun bool) (int, int, error)
 			continue
 		}
 
+		if err := trash.CheckID(ctx.dstBooks, b.ID); err != nil {
+			warn("skipping %v", err)
+			skipped++
+			continue
+		}
+
 		if dryRun {
 			fmt.Printf("  would import: %s — %s\n", b.ID, b.Title)
 			imported++
@@ -204,47 +273,48 @@ func importSingleBook(ctx *importContext, b *catalog.Book) (*catalog.Book, error
 	if err != nil {
 		return nil, fmt.Errorf("skipping %s: release %s not found: %v", b.ID, b.Source.Release, err)
 	}
-	srcAsset, err := gh.FindAsset(b.Source.Owner, b.Source.Repo, srcRel.ID, b.Source.Asset)
-	if err != nil || srcAsset == nil {
-		return nil, fmt.Errorf("skipping %s: asset not found", b.ID)
+	rc, _, err := parts.Open(gh, b.Source.Owner, b.Source.Repo, srcRel.ID, *b)
+	if err != nil {
+		return nil, fmt.Errorf("skipping %s: %v", b.ID, err)
 	}
 
 	fmt.Printf("  importing %s — %s …\n", b.ID, b.Title)
 
 	// Download and upload the asset
-	hr, err := downloadAndUploadAsset(ctx, b, srcAsset.ID)
+	hr, manifest, err := downloadAndUploadAsset(ctx, b, rc)
 	if err != nil {
 		return nil, err
 	}
 
 	// Build new entry for destination.
 	newBook := *b
+	newBook.Trashed = nil
 	newBook.Source = catalog.Source{
 		Type:    "github_release",
 		Owner:   ctx.dstOwner,
 		Repo:    ctx.shelf.Repo,
 		Release: ctx.releaseTag,
 		Asset:   b.Source.Asset,
+		Parts:   manifest,
+	}
+	for _, loc := range journal.Locations(ctx.dstOwner, ctx.shelf.Repo, newBook.Source) {
+		ctx.op.Added(loc)
 	}
 	newBook.Checksum.SHA256 = hr.SHA256()
 	newBook.SizeBytes = hr.Size()
+	newBook.Encryption = ctx.shelf.EncryptionScheme()
 	newBook.Meta.AddedAt = time.Now().UTC().Format(time.RFC3339)
 	newBook.Meta.MigratedFrom = fmt.Sprintf("%s/%s", ctx.srcOwner, ctx.srcRepo)
 
 	return &newBook, nil
 }
 
-func downloadAndUploadAsset(ctx *importContext, b *catalog.Book, assetID int64) (*ingest.Reader, error) {
-	rc, err := gh.DownloadAsset(b.Source.Owner, b.Source.Repo, assetID)
-	if err != nil {
-		return nil, fmt.Errorf("download failed for %s: %v", b.ID, err)
-	}
-
+func downloadAndUploadAsset(ctx *importContext, b *catalog.Book, rc io.ReadCloser) (*ingest.Reader, []catalog.Part, error) {
 	// Buffer to temp file.
 	tmp, err := os.CreateTemp("", "shelfctl-import-*")
 	if err != nil {
 		_ = rc.Close()
-		return nil, err
+		return nil, nil, err
 	}
 	tmpPath := tmp.Name()
 
@@ -253,41 +323,42 @@ func downloadAndUploadAsset(ctx *importContext, b *catalog.Book, assetID int64)
 		_ = tmp.Close()
 		_ = rc.Close()
 		_ = os.Remove(tmpPath)
-		return nil, fmt.Errorf("buffer failed for %s: %v", b.ID, err)
+		return nil, nil, fmt.Errorf("download failed for %s: %v", b.ID, err)
 	}
 	_ = tmp.Close()
 	_ = rc.Close()
 
-	if err := uploadTempFile(ctx, b, tmpPath); err != nil {
-		return nil, err
+	manifest, err := uploadTempFile(ctx, b, tmpPath)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return hr, nil
+	return hr, manifest, nil
 }
 
-func uploadTempFile(ctx *importContext, b *catalog.Book, tmpPath string) error {
+func uploadTempFile(ctx *importContext, b *catalog.Book, tmpPath string) ([]catalog.Part, error) {
 	fi, err := os.Stat(tmpPath)
 	if err != nil {
 		_ = os.Remove(tmpPath)
-		return fmt.Errorf("stat failed for %s: %v", b.ID, err)
+		return nil, fmt.Errorf("stat failed for %s: %v", b.ID, err)
 	}
 
 	uploadFile, err := os.Open(tmpPath)
 	if err != nil {
 		_ = os.Remove(tmpPath)
-		return fmt.Errorf("open failed for %s: %v", b.ID, err)
+		return nil, fmt.Errorf("open failed for %s: %v", b.ID, err)
 	}
 
-	_, err = gh.UploadAsset(ctx.dstOwner, ctx.shelf.Repo, ctx.dstRel.ID, b.Source.Asset,
-		uploadFile, fi.Size(), "application/octet-stream")
+	manifest, err := parts.Upload(gh, ctx.dstOwner, ctx.shelf.Repo, ctx.dstRel.ID, b.Source.Asset,
+		uploadFile, fi.Size(), cfg.Defaults.EffectivePartSize())
 	_ = uploadFile.Close()
 	_ = os.Remove(tmpPath)
 
 	if err != nil {
-		return fmt.Errorf("upload failed for %s: %v", b.ID, err)
+		return nil, fmt.Errorf("upload failed for %s: %v", b.ID, err)
 	}
 
-	return nil
+	return manifest, nil
 }
 
 func saveImportResults(ctx *importContext, imported, skipped int, dryRun, noPush bool) error {
@@ -311,6 +382,7 @@ func saveImportResults(ctx *importContext, imported, skipped int, dryRun, noPush
 		if err := gh.CommitFile(ctx.dstOwner, ctx.shelf.Repo, ctx.catalogPath, newData, msg); err != nil {
 			return err
 		}
+		ctx.op.Catalog(ctx.dstOwner, ctx.shelf.Repo, ctx.catalogPath, ctx.dstData, newData)
 		ok("Catalog committed (%d imported, %d skipped)", imported, skipped)
 	} else {
 		ok("Done (not pushed): imported=%d skipped=%d", imported, skipped)
@@ -0,0 +1,395 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/calibre"
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/encrypt"
+	"github.com/blackwell-systems/shelfctl/internal/enrich"
+	ghclient "github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/ingest"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/migrate"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
+)
+
+// calibreCommitBatch is how many imported books are committed to the
+// catalog at a time. Progress is recorded after each commit, so at most one
+// batch is redone after an interruption.
+const calibreCommitBatch = 20
+
+type calibreImportOptions struct {
+	libraryDir string
+	shelfName  string
+	releaseTag string
+	filter     calibre.Filter
+	prefer     []string
+	maxN       int
+	dryRun     bool
+	noPush     bool
+	ledgerPath string
+}
+
+// calibreImport holds the state of one import run.
+type calibreImport struct {
+	opts        calibreImportOptions
+	client      GitHubClient
+	lib         *calibre.Library
+	shelf       *config.ShelfConfig
+	owner       string
+	releaseTag  string
+	catalogPath string
+	before      []byte
+	books       []catalog.Book
+	shas        map[string]bool
+	ids         map[string]bool
+	rel         *ghclient.Release
+	ledger      *migrate.Ledger
+	op          *journal.Op
+
+	// Imported since the last commit.
+	pending        int
+	pendingEntries []migrate.LedgerEntry
+	covers         map[string][]byte
+}
+
+// defaultCalibreLedgerPath returns where calibre import progress is kept.
+func defaultCalibreLedgerPath() string {
+	return filepath.Join(filepath.Dir(migrate.DefaultLedgerPath()), "calibre-imported.jsonl")
+}
+
+func runCalibreImport(opts calibreImportOptions) error {
+	return runCalibreImportWithClient(opts, gh)
+}
+
+func runCalibreImportWithClient(opts calibreImportOptions, client GitHubClient) error {
+	lib, err := calibre.Open(opts.libraryDir)
+	if err != nil {
+		return err
+	}
+	if calibre.WALPending(opts.libraryDir) {
+		warn("Calibre seems to be running; close it so recent changes are included")
+	}
+
+	var selected []*calibre.Book
+	for i := range lib.Books {
+		b := &lib.Books[i]
+		if len(b.Formats) > 0 && opts.filter.Match(b) {
+			selected = append(selected, b)
+		}
+	}
+	fmt.Printf("Calibre library: %d books, %d match\n", len(lib.Books), len(selected))
+	if len(selected) == 0 {
+		return nil
+	}
+
+	imp, err := setupCalibreImport(opts, lib, client)
+	if err != nil {
+		return err
+	}
+	if !opts.dryRun {
+		imp.op = beginOp("import", "import from calibre")
+		defer finishOp(imp.op)
+	}
+
+	imported, skipped := 0, 0
+	for _, b := range selected {
+		if opts.maxN > 0 && imported >= opts.maxN {
+			fmt.Printf("Limit of %d reached.\n", opts.maxN)
+			break
+		}
+
+		key := calibreLedgerKey(imp.shelf.Name, b)
+		if done, err := imp.ledger.Contains(key); err != nil {
+			return err
+		} else if done {
+			skipped++
+			continue
+		}
+
+		format := b.PickFormat(opts.prefer)
+		if opts.dryRun {
+			fmt.Printf("  would import: %s — %s [%s]\n", imp.uniqueID(b.Title), b.Title, format.Ext())
+			imported++
+			continue
+		}
+
+		book, err := imp.importBook(b, format)
+		if err != nil {
+			warn("%s: %v", b.Title, err)
+			skipped++
+			continue
+		}
+		if book == nil {
+			fmt.Printf("  skip (duplicate sha256): %s\n", b.Title)
+			imp.pendingEntries = append(imp.pendingEntries, migrate.LedgerEntry{Source: key, Shelf: imp.shelf.Name})
+			skipped++
+			continue
+		}
+
+		imp.pendingEntries = append(imp.pendingEntries, migrate.LedgerEntry{Source: key, BookID: book.ID, Shelf: imp.shelf.Name})
+		imp.pending++
+		imported++
+		ok("Imported: %s", book.ID)
+
+		if imp.pending >= calibreCommitBatch {
+			if err := imp.commit(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.dryRun {
+		fmt.Printf("\n(dry run) would import=%d skipped=%d\n", imported, skipped)
+		return nil
+	}
+	if err := imp.commit(); err != nil {
+		return err
+	}
+	if imported == 0 {
+		fmt.Printf("Nothing new to import (skipped=%d).\n", skipped)
+	} else if opts.noPush {
+		ok("Done (not pushed): imported=%d skipped=%d", imported, skipped)
+	} else {
+		ok("Calibre import complete (%d imported, %d skipped)", imported, skipped)
+	}
+	return nil
+}
+
+func setupCalibreImport(opts calibreImportOptions, lib *calibre.Library, client GitHubClient) (*calibreImport, error) {
+	shelf, err := resolveOrCreateShelf(opts.shelfName)
+	if err != nil {
+		return nil, err
+	}
+	owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+	releaseTag := opts.releaseTag
+	if releaseTag == "" {
+		releaseTag = shelf.EffectiveRelease(cfg.Defaults.Release)
+	}
+
+	catalogPath := shelf.EffectiveCatalogPath()
+	data, _, _ := client.GetFileContent(owner, shelf.Repo, catalogPath, "")
+	books, _ := catalog.Parse(data)
+
+	ledgerPath := opts.ledgerPath
+	if ledgerPath == "" {
+		ledgerPath = defaultCalibreLedgerPath()
+	}
+	ledger, err := migrate.OpenLedger(ledgerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &calibreImport{
+		opts:        opts,
+		client:      client,
+		lib:         lib,
+		shelf:       shelf,
+		owner:       owner,
+		releaseTag:  releaseTag,
+		catalogPath: catalogPath,
+		before:      data,
+		books:       books,
+		shas:        map[string]bool{},
+		ids:         map[string]bool{},
+		ledger:      ledger,
+		covers:      map[string][]byte{},
+	}
+	for _, b := range books {
+		imp.ids[b.ID] = true
+		if b.Checksum.SHA256 != "" && !b.IsTrashed() {
+			imp.shas[b.Checksum.SHA256] = true
+		}
+	}
+
+	if !opts.dryRun {
+		imp.rel, err = client.EnsureRelease(owner, shelf.Repo, releaseTag)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return imp, nil
+}
+
+// calibreLedgerKey identifies a Calibre book imported into a shelf. The
+// UUID survives Calibre library moves; the numeric ID is a fallback for
+// libraries that lack one.
+func calibreLedgerKey(shelf string, b *calibre.Book) string {
+	id := b.UUID
+	if id == "" {
+		id = fmt.Sprintf("%d", b.ID)
+	}
+	return "calibre:" + id + "@" + shelf
+}
+
+// uniqueID derives a catalog ID from a title, adding a numeric suffix if
+// the slug is already taken on the shelf.
+func (imp *calibreImport) uniqueID(title string) string {
+	base := slugify(title)
+	if len(base) > 56 {
+		base = strings.TrimRight(base[:56], "-")
+	}
+	if len(base) < 2 {
+		base = "book"
+	}
+	id := base
+	for n := 2; imp.ids[id]; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	return id
+}
+
+// importBook uploads one format file and returns its catalog entry, or nil
+// if the file is already on the shelf.
+func (imp *calibreImport) importBook(b *calibre.Book, format *calibre.Format) (*catalog.Book, error) {
+	sha, size, err := hashFile(format.File)
+	if err != nil {
+		return nil, err
+	}
+	if imp.shas[sha] {
+		return nil, nil
+	}
+
+	id := imp.uniqueID(b.Title)
+	if err := trash.CheckID(imp.books, id); err != nil {
+		return nil, err
+	}
+	assetName := id + "." + format.Ext()
+	if cfg.Defaults.AssetNaming == "original" {
+		assetName = filepath.Base(format.File)
+	}
+
+	fmt.Printf("  importing %s — %s …\n", id, b.Title)
+	if err := imp.upload(format.File, assetName, size); err != nil {
+		return nil, err
+	}
+
+	book := calibreToCatalog(b, id, format.Ext())
+	book.Checksum.SHA256 = sha
+	book.SizeBytes = size
+	book.Encryption = imp.shelf.EncryptionScheme()
+	book.Source = catalog.Source{
+		Type:    "github_release",
+		Owner:   imp.owner,
+		Repo:    imp.shelf.Repo,
+		Release: imp.releaseTag,
+		Asset:   assetName,
+	}
+
+	if cover := imp.lib.CoverPath(b); cover != "" {
+		if data, err := os.ReadFile(cover); err == nil {
+			book.Cover = enrich.CoverPath(id)
+			imp.covers[book.Cover] = data
+		}
+	}
+
+	imp.books = catalog.Append(imp.books, book)
+	imp.ids[id] = true
+	imp.shas[sha] = true
+	return &book, nil
+}
+
+// upload sends a file to the shelf release. An asset of the same name and
+// size left behind by an interrupted run is reused instead.
+func (imp *calibreImport) upload(path, assetName string, size int64) error {
+	existing, err := imp.client.FindAsset(imp.owner, imp.shelf.Repo, imp.rel.ID, assetName)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		want := size
+		if imp.shelf.Encrypted() {
+			want = encrypt.EncryptedSize(size)
+		}
+		if existing.Size == want {
+			return nil
+		}
+		return fmt.Errorf("asset %q already exists in release %s", assetName, imp.releaseTag)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := imp.client.UploadAsset(imp.owner, imp.shelf.Repo, imp.rel.ID, assetName, f, size, "application/octet-stream"); err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	imp.op.Added(journal.Location{Owner: imp.owner, Repo: imp.shelf.Repo, Release: imp.releaseTag, Asset: assetName})
+	return nil
+}
+
+// commit writes the catalog and any covers in one commit, then records the
+// committed books in the ledger.
+func (imp *calibreImport) commit() error {
+	if len(imp.pendingEntries) == 0 || imp.opts.noPush {
+		return nil
+	}
+	if imp.pending > 0 {
+		data, err := catalog.Marshal(imp.books)
+		if err != nil {
+			return err
+		}
+		files := map[string][]byte{imp.catalogPath: data}
+		for path, cover := range imp.covers {
+			files[path] = cover
+		}
+		msg := fmt.Sprintf("import: %d books from calibre", imp.pending)
+		if err := imp.client.CommitFiles(imp.owner, imp.shelf.Repo, files, msg); err != nil {
+			return err
+		}
+		imp.op.Catalog(imp.owner, imp.shelf.Repo, imp.catalogPath, imp.before, data)
+	}
+	for _, e := range imp.pendingEntries {
+		if err := imp.ledger.Append(e); err != nil {
+			return err
+		}
+	}
+	imp.pending = 0
+	imp.pendingEntries = nil
+	imp.covers = map[string][]byte{}
+	return nil
+}
+
+// calibreToCatalog maps Calibre metadata onto a catalog entry. Series
+// become a "series:<name>" tag since the catalog has no series field.
+func calibreToCatalog(b *calibre.Book, id, format string) catalog.Book {
+	tags := append([]string(nil), b.Tags...)
+	if b.Series != "" {
+		tags = append(tags, "series:"+b.Series)
+	}
+	return catalog.Book{
+		ID:        id,
+		Title:     b.Title,
+		Author:    b.Author(),
+		Year:      b.Year(),
+		Publisher: b.Publisher,
+		ISBN:      b.ISBN(),
+		Tags:      tags,
+		Format:    format,
+		Meta: catalog.Meta{
+			AddedAt:      time.Now().UTC().Format(time.RFC3339),
+			MigratedFrom: "calibre:" + b.UUID,
+		},
+	}
+}
+
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = f.Close() }()
+	hr := ingest.NewReader(f)
+	if _, err := io.Copy(io.Discard, hr); err != nil {
+		return "", 0, err
+	}
+	return hr.SHA256(), hr.Size(), nil
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/calibre"
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+func TestCalibreImport_ResumesAfterInterruption(t *testing.T) {
+	origStdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	origCfg := cfg
+	t.Cleanup(func() {
+		os.Stdout = origStdout
+		cfg = origCfg
+	})
+
+	cfg = &config.Config{
+		GitHub:   config.GitHubConfig{Owner: "me"},
+		Defaults: config.DefaultsConfig{Release: "library"},
+		Shelves:  []config.ShelfConfig{{Name: "books", Repo: "shelf-books"}},
+	}
+	client := testutil.NewGitHub()
+	shelf := client.Repo("me", "shelf-books")
+
+	opts := calibreImportOptions{
+		libraryDir: filepath.Join("..", "calibre", "testdata", "library"),
+		shelfName:  "books",
+		filter:     calibre.Filter{Formats: []string{"epub", "pdf"}},
+		prefer:     []string{"epub", "pdf"},
+		maxN:       1,
+		ledgerPath: filepath.Join(t.TempDir(), "calibre.jsonl"),
+	}
+
+	// First run stops after one book, as if interrupted.
+	if err := runCalibreImportWithClient(opts, client); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	books, err := catalog.Parse(shelf.Files["catalog.yml"])
+	if err != nil || len(books) != 1 {
+		t.Fatalf("after first run: %d books, err %v", len(books), err)
+	}
+
+	earthsea := books[0]
+	if earthsea.ID != "a-wizard-of-earthsea" || earthsea.Format != "epub" {
+		t.Errorf("imported %s (%s)", earthsea.ID, earthsea.Format)
+	}
+	if earthsea.Author != "Ursula K. Le Guin" || earthsea.Year != 1968 || earthsea.ISBN != "9780547773742" {
+		t.Errorf("metadata not mapped: %+v", earthsea)
+	}
+	if !strings.Contains(strings.Join(earthsea.Tags, ","), "series:Earthsea") {
+		t.Errorf("tags = %v, want series tag", earthsea.Tags)
+	}
+	if earthsea.Cover == "" || shelf.Files[earthsea.Cover] == nil {
+		t.Errorf("cover %q not committed", earthsea.Cover)
+	}
+
+	// The rerun skips the recorded book and imports the rest.
+	opts.maxN = 0
+	if err := runCalibreImportWithClient(opts, client); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	books, _ = catalog.Parse(shelf.Files["catalog.yml"])
+	if len(books) != 2 {
+		t.Fatalf("after second run: %d books, want 2", len(books))
+	}
+	if books[1].ID != "structure-and-interpretation-of-computer-programs" || books[1].Format != "pdf" {
+		t.Errorf("second book = %s (%s)", books[1].ID, books[1].Format)
+	}
+	if len(shelf.Messages) != 2 {
+		t.Errorf("commits = %v, want one per run", shelf.Messages)
+	}
+
+	// A third run has nothing left to do.
+	if err := runCalibreImportWithClient(opts, client); err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	if len(shelf.Messages) != 2 {
+		t.Errorf("unexpected commit on a completed import: %v", shelf.Messages)
+	}
+}
+
+func TestCalibreImport_ReusesUploadedAsset(t *testing.T) {
+	origStdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	origCfg := cfg
+	t.Cleanup(func() {
+		os.Stdout = origStdout
+		cfg = origCfg
+	})
+
+	cfg = &config.Config{
+		GitHub:   config.GitHubConfig{Owner: "me"},
+		Defaults: config.DefaultsConfig{Release: "library"},
+		Shelves:  []config.ShelfConfig{{Name: "books", Repo: "shelf-books"}},
+	}
+	client := testutil.NewGitHub()
+	shelf := client.Repo("me", "shelf-books")
+	// Left over from a run that uploaded but never committed.
+	client.PutAsset("me", "shelf-books", "library", "untitled-notes.mobi", "mobi bytes")
+
+	err := runCalibreImportWithClient(calibreImportOptions{
+		libraryDir: filepath.Join("..", "calibre", "testdata", "library"),
+		shelfName:  "books",
+		filter:     calibre.Filter{Authors: []string{"unknown"}},
+		prefer:     calibre.DefaultFormatPreference,
+		ledgerPath: filepath.Join(t.TempDir(), "calibre.jsonl"),
+	}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	books, _ := catalog.Parse(shelf.Files["catalog.yml"])
+	if len(books) != 1 || books[0].Source.Asset != "untitled-notes.mobi" {
+		t.Errorf("books = %+v", books)
+	}
+}
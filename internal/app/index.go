@@ -46,13 +46,13 @@ to browse your library without running shelfctl.`,
 					continue
 				}
 
-				for _, b := range books {
+				for _, b := range catalog.Live(books) {
 					isCached := cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset)
 					var filePath string
 					if isCached {
 						filePath = cacheMgr.Path(owner, shelf.Repo, b.ID, b.Source.Asset)
 					}
-					coverPath := cacheMgr.GetCoverPath(shelf.Repo, b.ID)
+					coverPath := cacheMgr.GetCoverPathOrPlaceholder(shelf.Repo, b)
 
 					indexBooks = append(indexBooks, cache.IndexBook{
 						Book:      b,
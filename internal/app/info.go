@@ -6,6 +6,7 @@ import (
 
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/ingest"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -35,17 +36,35 @@ func newInfoCmd() *cobra.Command {
 			if b.Year != 0 {
 				printField("year", fmt.Sprintf("%d", b.Year))
 			}
+			if b.Publisher != "" {
+				printField("publisher", b.Publisher)
+			}
+			if b.ISBN != "" {
+				printField("isbn", b.ISBN)
+			}
 			printField("format", b.Format)
+			if b.Pages > 0 {
+				printField("pages", fmt.Sprintf("%d", b.Pages))
+			}
 			if len(b.Tags) > 0 {
 				printField("tags", strings.Join(b.Tags, ", "))
 			}
+			if len(b.Subjects) > 0 {
+				printField("subjects", strings.Join(b.Subjects, ", "))
+			}
 			if b.SizeBytes > 0 {
 				printField("size", humanBytes(b.SizeBytes))
 			}
 			if b.Checksum.SHA256 != "" {
 				printField("sha256", b.Checksum.SHA256)
 			}
+			if b.Encryption != "" {
+				printField("encryption", b.Encryption)
+			}
 			printField("shelf", shelf.Name)
+			if b.IsTrashed() {
+				printField("trashed", fmt.Sprintf("%s (from %s, 'shelfctl trash restore %s' brings it back)", b.Trashed.At, b.Trashed.Release, b.ID))
+			}
 			printField("release", b.Source.Release)
 			printField("asset", b.Source.Asset)
 			if b.Meta.AddedAt != "" {
@@ -54,6 +73,9 @@ func newInfoCmd() *cobra.Command {
 			if b.Meta.MigratedFrom != "" {
 				printField("migrated_from", b.Meta.MigratedFrom)
 			}
+			if b.Meta.EnrichedAt != "" {
+				printField("enriched_at", b.Meta.EnrichedAt)
+			}
 
 			cacheStatus := color.RedString("not cached")
 			if cached {
@@ -61,6 +83,21 @@ func newInfoCmd() *cobra.Command {
 				cacheStatus = color.GreenString("cached") + "  " + path
 			}
 			printField("cache", cacheStatus)
+
+			if cached && b.Format == "pdf" {
+				path := cacheMgr.Path(owner, shelf.Repo, b.ID, b.Source.Asset)
+				if meta, err := ingest.ExtractPDFMetadata(path); err == nil {
+					if b.Pages == 0 && meta.PageCount > 0 {
+						printField("pages", fmt.Sprintf("%d", meta.PageCount))
+					}
+					if meta.Version != "" {
+						printField("pdf_version", meta.Version)
+					}
+					if meta.Encrypted {
+						printField("encrypted", color.YellowString("yes"))
+					}
+				}
+			}
 			return nil
 		},
 	}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/blackwell-systems/shelfctl/internal/config"
+	ghclient "github.com/blackwell-systems/shelfctl/internal/github"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -17,6 +18,8 @@ func newInitCmd() *cobra.Command {
 		shelfName  string
 		createRepo bool
 		private    bool
+		readOnly   bool
+		profile    string
 	)
 
 	cmd := &cobra.Command{
@@ -37,6 +40,13 @@ Key Concepts:
 
 This command creates or registers a shelf repo in your config.
 
+With --read-only, a public shelf someone else owns is added for browsing,
+searching and downloading only. No GitHub token is needed for that, and
+commands that would change the shelf refuse to run.
+
+With --profile, the shelf lives on the account or host of that profile
+(see 'profiles' in the config), such as a GitHub Enterprise Server.
+
 Quick start:
   1. Run: shelfctl init --repo shelf-books --name books --create-repo
   2. Then: shelfctl shelve (launches interactive workflow)
@@ -47,13 +57,44 @@ For more details, see: shelfctl --help or docs/guides/tutorial.md`,
   shelfctl init --repo shelf-programming --name programming --create-repo
 
   # Register an existing repo as a shelf
-  shelfctl init --repo shelf-history --name history`,
+  shelfctl init --repo shelf-history --name history
+
+  # Follow a colleague's public shelf (no token needed)
+  shelfctl init --owner colleague --repo shelf-papers --name papers --read-only
+
+  # A shelf on GitHub Enterprise, using the "work" profile
+  shelfctl init --profile work --repo shelf-specs --create-repo`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// A shelf of a profile is set up through the profile's client.
+			var client *ghclient.Client
+			if profile != "" {
+				var account *config.GitHubConfig
+				var err error
+				if client, account, err = profileClient(profile); err != nil {
+					return err
+				}
+				if owner == "" {
+					owner = account.Owner
+				}
+			}
+
 			// Validate and resolve parameters
 			effectiveOwner, effectiveShelfName, err := validateInitParams(owner, repoName, shelfName)
 			if err != nil {
 				return err
 			}
+			if client != nil {
+				gh.Route(effectiveOwner, repoName, client)
+			}
+
+			if readOnly {
+				if createRepo {
+					return fmt.Errorf("--read-only cannot be combined with --create-repo")
+				}
+				if _, _, err := gh.GetFileContent(effectiveOwner, repoName, "catalog.yml", ""); err != nil {
+					warn("Could not read catalog.yml from %s/%s: %v (is the repo public?)", effectiveOwner, repoName, err)
+				}
+			}
 
 			// Create repo and release if requested
 			if err := createRepoAndRelease(effectiveOwner, repoName, createRepo, private); err != nil {
@@ -66,12 +107,12 @@ For more details, see: shelfctl --help or docs/guides/tutorial.md`,
 			}
 
 			// Update config file
-			if err := addShelfToConfig(effectiveShelfName, effectiveOwner, repoName); err != nil {
+			if err := addShelfToConfig(effectiveShelfName, effectiveOwner, repoName, readOnly, profile); err != nil {
 				return err
 			}
 
 			// Display success message and next steps
-			displayInitSuccess(effectiveShelfName, effectiveOwner, repoName, createRepo)
+			displayInitSuccess(effectiveShelfName, effectiveOwner, repoName, createRepo, readOnly)
 			return nil
 		},
 	}
@@ -81,6 +122,8 @@ For more details, see: shelfctl --help or docs/guides/tutorial.md`,
 	cmd.Flags().StringVar(&shelfName, "name", "", "Local shelf name (default: repo without 'shelf-' prefix)")
 	cmd.Flags().BoolVar(&createRepo, "create-repo", false, "Create the GitHub repo via API (recommended for first-time setup)")
 	cmd.Flags().BoolVar(&private, "private", true, "Make repo private (default: true, use --private=false for public)")
+	cmd.Flags().StringVar(&profile, "profile", "", "Profile of the account or host the shelf lives on (default: github)")
+	cmd.Flags().BoolVar(&readOnly, "read-only", false, "Follow an existing public shelf without writing to it (no token needed)")
 
 	return cmd
 }
@@ -123,12 +166,15 @@ func createRepoAndRelease(owner, repoName string, createRepo, private bool) erro
 			ok("Using existing repo: %s", existingRepo.HTMLURL)
 		} else {
 			// Repo doesn't exist, create it
-			repo, err := gh.CreateRepo(repoName, private)
+			repo, err := gh.For(owner, repoName).CreateRepo(repoName, private)
 			if err != nil {
 				return fmt.Errorf("create repo: %w", err)
 			}
 			ok("Created %s", repo.HTMLURL)
 		}
+		if err := gh.AddTopic(owner, repoName, ghclient.ShelfTopic); err != nil {
+			warn("Could not add topic %q: %v", ghclient.ShelfTopic, err)
+		}
 	}
 
 	if createRepo {
@@ -164,7 +210,7 @@ func createShelfREADME(shelfName, repoName, owner string) {
 	}
 }
 
-func addShelfToConfig(shelfName, owner, repoName string) error {
+func addShelfToConfig(shelfName, owner, repoName string, readOnly bool, profile string) error {
 	currentCfg, err := config.Load()
 	if err != nil {
 		currentCfg = &config.Config{}
@@ -179,11 +225,17 @@ func addShelfToConfig(shelfName, owner, repoName string) error {
 	}
 
 	currentCfg.Shelves = append(currentCfg.Shelves, config.ShelfConfig{
-		Name:  shelfName,
-		Owner: owner,
-		Repo:  repoName,
+		Name:     shelfName,
+		Owner:    owner,
+		Repo:     repoName,
+		ReadOnly: readOnly,
+		Profile:  profile,
 	})
 
+	if profile != "" {
+		// The owner belongs to the profile's account, not github.owner.
+		owner = ""
+	}
 	setConfigDefaults(currentCfg, owner)
 
 	return config.Save(currentCfg)
@@ -207,13 +259,23 @@ func setConfigDefaults(cfg *config.Config, owner string) {
 	}
 }
 
-func displayInitSuccess(shelfName, owner, repoName string, createRepo bool) {
+func displayInitSuccess(shelfName, owner, repoName string, createRepo, readOnly bool) {
 	configPath := config.DefaultPath()
 	ok("Added shelf %q to config", shelfName)
 	fmt.Printf("  config: %s\n", color.CyanString(configPath))
 	fmt.Printf("  owner:  %s\n", owner)
 	fmt.Printf("  repo:   %s\n", repoName)
 
+	if readOnly {
+		fmt.Printf("  mode:   %s\n", "read-only")
+		fmt.Println()
+		fmt.Println("Next steps:")
+		fmt.Printf("  %s\n", color.CyanString(fmt.Sprintf("shelfctl browse --shelf %s", shelfName)))
+		fmt.Printf("  %s\n", color.CyanString("shelfctl search <query>"))
+		fmt.Printf("  %s\n", color.CyanString("shelfctl open <id>"))
+		return
+	}
+
 	if !createRepo {
 		hint := fmt.Sprintf("Make sure %s/%s exists on GitHub.", owner, repoName)
 		fmt.Fprintln(os.Stderr, color.YellowString("hint:"), hint)
@@ -17,6 +17,8 @@ type GitHubClient interface {
 	DeleteAsset(owner, repo string, assetID int64) error
 	GetFileContent(owner, repo, path, ref string) ([]byte, string, error)
 	CommitFile(owner, repo, filePath string, content []byte, message string) error
+	CommitFiles(owner, repo string, files map[string][]byte, message string) error
+	ReplaceBranch(owner, repo, branch string, files map[string][]byte, message string) (bool, error)
 }
 
 // Compile-time check that *github.Client satisfies GitHubClient.
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+)
+
+// undoJournal returns the journal commands that change shelves record to.
+func undoJournal() *journal.Journal {
+	return journal.Open(cfg.Defaults.JournalDir)
+}
+
+// beginOp starts a journal entry for a command that changes shelves.
+func beginOp(command, summary string) *journal.Op {
+	return undoJournal().Begin(command, summary)
+}
+
+// finishOp saves a journal entry. The command has already made its
+// changes, so a journal that cannot be written only warns.
+func finishOp(op *journal.Op) {
+	if err := undoJournal().Save(op); err != nil {
+		warn("Could not write the undo journal: %v", err)
+	}
+}
+
+// assetLocation returns where a book's asset lives.
+func assetLocation(owner, repo string, b *catalog.Book) journal.Location {
+	return journal.Location{Owner: owner, Repo: repo, Release: b.Source.Release, Asset: b.Source.Asset}
+}
+
+// keepCopy stores the content of an asset about to be deleted in the
+// journal: the cached file if it is unmodified, otherwise a download. It
+// returns "" if no copy could be kept, in which case the deletion cannot be
+// undone.
+func keepCopy(op *journal.Op, client GitHubClient, owner, repo string, b *catalog.Book, assetID int64) string {
+	if !op.Recording() {
+		return ""
+	}
+	loc := assetLocation(owner, repo, b)
+	var r io.ReadCloser
+	if cacheMgr != nil && cacheMgr.Exists(owner, repo, b.ID, b.Source.Asset) &&
+		!cacheMgr.HasBeenModified(owner, repo, b.ID, b.Source.Asset, b.Checksum.SHA256) {
+		r, _ = os.Open(cacheMgr.Path(owner, repo, b.ID, b.Source.Asset))
+	}
+	if r == nil {
+		var err error
+		if r, err = client.DownloadAsset(owner, repo, assetID); err != nil {
+			warn("Could not keep a copy of %s, its deletion cannot be undone: %v", b.Source.Asset, err)
+			return ""
+		}
+	}
+	defer func() { _ = r.Close() }()
+
+	name, err := op.SaveCopy(loc, r)
+	if err != nil {
+		warn("Could not keep a copy of %s, its deletion cannot be undone: %v", b.Source.Asset, err)
+		return ""
+	}
+	return name
+}
+
+// bookList names books in a journal summary, shortened past a few.
+func bookList(ids []string) string {
+	const shown = 3
+	if len(ids) <= shown {
+		return strings.Join(ids, ", ")
+	}
+	return strings.Join(ids[:shown], ", ") + ", …"
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/history"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+type logOptions struct {
+	shelfName string
+	id        string
+	limit     int
+}
+
+func newLogCmd() *cobra.Command {
+	var opts logOptions
+
+	cmd := &cobra.Command{
+		Use:   "log [id]",
+		Short: "Show the history of the catalog, book by book",
+		Long: `Show the commits that changed the catalog of each shelf, newest first,
+decoded into book-level events: books added, removed, edited (with the
+fields that changed) and moved to another release or shelf.
+
+With an ID, only that book's history is shown, across all shelves so moves
+between shelves are followed.`,
+		Example: `  shelfctl log
+  shelfctl log --shelf programming -n 50
+  shelfctl log sicp`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				opts.id = args[0]
+			}
+			return runLog(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.shelfName, "shelf", "", "Only this shelf")
+	cmd.Flags().IntVarP(&opts.limit, "n", "n", 20, "Number of commits per shelf to look at (0 = all)")
+	return cmd
+}
+
+// shelfEntry is a history entry and the shelf it belongs to.
+type shelfEntry struct {
+	shelf string
+	history.Entry
+}
+
+func runLog(opts logOptions) error {
+	return runLogWithClient(opts, gh)
+}
+
+func runLogWithClient(opts logOptions, client history.Client) error {
+	shelves := cfg.Shelves
+	if opts.shelfName != "" {
+		s := cfg.ShelfByName(opts.shelfName)
+		if s == nil {
+			return fmt.Errorf("shelf %q not found in config", opts.shelfName)
+		}
+		shelves = []config.ShelfConfig{*s}
+	}
+	if len(shelves) == 0 {
+		warn("No shelves configured")
+		return nil
+	}
+
+	var all []shelfEntry
+	for _, s := range shelves {
+		owner := s.EffectiveOwner(cfg.GitHub.Owner)
+		entries, err := history.Log(client, owner, s.Repo, s.EffectiveCatalogPath(), opts.limit, opts.id)
+		if err != nil {
+			if len(shelves) == 1 {
+				return fmt.Errorf("shelf %s: %w", s.Name, err)
+			}
+			warn("shelf %s: %v", s.Name, err)
+			continue
+		}
+		for _, e := range entries {
+			all = append(all, shelfEntry{shelf: s.Name, Entry: e})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Commit.Date.After(all[j].Commit.Date)
+	})
+
+	if len(all) == 0 {
+		if opts.id != "" {
+			fmt.Printf("No history for %s.\n", opts.id)
+		} else {
+			fmt.Println("No catalog history.")
+		}
+		return nil
+	}
+	for i, e := range all {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s  %s  %s  %s  %s\n",
+			color.YellowString(history.Short(e.Commit.SHA)),
+			e.Commit.Date.Local().Format("2006-01-02 15:04"),
+			e.Commit.Author,
+			color.CyanString("["+e.shelf+"]"),
+			e.Subject())
+		printChanges(e.Changes)
+	}
+	return nil
+}
+
+// printChanges prints book-level changes, one per line.
+func printChanges(changes []catalog.Change) {
+	if len(changes) == 0 {
+		fmt.Printf("    %s\n", color.HiBlackString("(no book changes)"))
+		return
+	}
+	for _, ch := range changes {
+		fmt.Printf("    %s %-24s %s\n", changeMark(ch.Kind), ch.ID, history.Describe(ch))
+	}
+}
+
+func changeMark(kind catalog.ChangeKind) string {
+	switch kind {
+	case catalog.ChangeAdded:
+		return color.GreenString("+")
+	case catalog.ChangeRemoved:
+		return color.RedString("-")
+	case catalog.ChangeEdited:
+		return color.YellowString("~")
+	case catalog.ChangeMoved:
+		return color.CyanString("→")
+	}
+	return " "
+}
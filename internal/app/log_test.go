@@ -0,0 +1,72 @@
+package app
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	ghpkg "github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+// newHistoryClient returns a GitHub where both shelves have the same
+// two commits: sicp added, then deleted.
+func newHistoryClient() *testutil.GitHub {
+	c := testutil.NewGitHub()
+	for _, r := range []*testutil.Repo{c.Repo("me", "shelf-books"), c.Repo("team", "shelf-papers")} {
+		r.Commits = []ghpkg.Commit{
+			{SHA: "c2", Message: "delete: sicp"},
+			{SHA: "c1", Message: "add: sicp", Parents: []string{"c0"}},
+		}
+		r.Revisions["c1"] = map[string][]byte{
+			"catalog.yml": []byte("- id: sicp\n  title: SICP\n  format: pdf\n  source: {owner: me, repo: shelf-books, release: library}\n"),
+		}
+		r.Revisions["c2"] = map[string][]byte{"catalog.yml": []byte("[]")}
+		r.Files["catalog.yml"] = []byte("[]")
+	}
+	return c
+}
+
+func TestLogAndDiff(t *testing.T) {
+	origStdout, origCfg := os.Stdout, cfg
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg = origStdout, origCfg })
+
+	cfg = &config.Config{
+		GitHub: config.GitHubConfig{Owner: "me"},
+		Shelves: []config.ShelfConfig{
+			{Name: "books", Repo: "shelf-books"},
+			{Name: "papers", Owner: "team", Repo: "shelf-papers"},
+		},
+	}
+
+	client := newHistoryClient()
+	if err := runLogWithClient(logOptions{id: "sicp"}, client); err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if strings.Join(client.Listed, ",") != "me/shelf-books,team/shelf-papers" {
+		t.Errorf("log read %v, want every shelf", client.Listed)
+	}
+
+	client = newHistoryClient()
+	if err := runLogWithClient(logOptions{shelfName: "papers"}, client); err != nil {
+		t.Fatalf("log --shelf: %v", err)
+	}
+	if strings.Join(client.Listed, ",") != "team/shelf-papers" {
+		t.Errorf("log --shelf read %v", client.Listed)
+	}
+	if err := runLogWithClient(logOptions{shelfName: "nope"}, client); err == nil {
+		t.Error("log of unknown shelf should fail")
+	}
+
+	if err := runDiffWithClient(diffOptions{from: "c1"}, client); err == nil || !strings.Contains(err.Error(), "--shelf") {
+		t.Errorf("diff without --shelf: %v", err)
+	}
+	if err := runDiffWithClient(diffOptions{shelfName: "books", from: "c1", to: "HEAD"}, client); err != nil {
+		t.Errorf("diff: %v", err)
+	}
+	if err := runDiffWithClient(diffOptions{shelfName: "books", from: "bogus"}, client); err != nil {
+		t.Errorf("diff from a revision without a catalog: %v", err)
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/ingest"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/migrate"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -180,15 +182,18 @@ func migrateOneFile(oldPath string, sources []config.MigrationSource, ledger *mi
 		return err
 	}
 
+	op := beginOp("migrate", "migrate "+oldPath)
+	defer finishOp(op)
+
 	// Upload to destination
-	suggestedID, assetName, err := uploadMigratedFile(shelf, oldPath, fileData, size)
+	suggestedID, assetName, err := uploadMigratedFile(op, shelf, oldPath, fileData, size)
 	if err != nil {
 		return err
 	}
 
 	// Update catalog
 	book := buildMigratedBook(suggestedID, assetName, oldPath, sha256sum, size, shelf, src)
-	if err := updateCatalogWithBook(shelf, book, src, noPush); err != nil {
+	if err := updateCatalogWithBook(op, shelf, book, src, noPush); err != nil {
 		return err
 	}
 
@@ -234,7 +239,7 @@ func fetchSourceFile(src config.MigrationSource, oldPath string) ([]byte, string
 	return fileData, hr.SHA256(), hr.Size(), nil
 }
 
-func uploadMigratedFile(shelf *config.ShelfConfig, oldPath string, fileData []byte, size int64) (string, string, error) {
+func uploadMigratedFile(op *journal.Op, shelf *config.ShelfConfig, oldPath string, fileData []byte, size int64) (string, string, error) {
 	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(oldPath), "."))
 	baseName := filepath.Base(oldPath)
 	suggestedID := slugify(strings.TrimSuffix(baseName, filepath.Ext(baseName)))
@@ -243,6 +248,13 @@ func uploadMigratedFile(shelf *config.ShelfConfig, oldPath string, fileData []by
 	releaseTag := shelf.EffectiveRelease(cfg.Defaults.Release)
 	assetName := suggestedID + "." + ext
 
+	// A trashed book of the same ID would be replaced by the new entry.
+	catalogData, _, _ := gh.GetFileContent(owner, shelf.Repo, shelf.EffectiveCatalogPath(), "")
+	existing, _ := catalog.Parse(catalogData)
+	if err := trash.CheckID(existing, suggestedID); err != nil {
+		return "", "", err
+	}
+
 	rel, err := gh.EnsureRelease(owner, shelf.Repo, releaseTag)
 	if err != nil {
 		return "", "", err
@@ -253,6 +265,7 @@ func uploadMigratedFile(shelf *config.ShelfConfig, oldPath string, fileData []by
 	if err != nil {
 		return "", "", fmt.Errorf("uploading: %w", err)
 	}
+	op.Added(journal.Location{Owner: owner, Repo: shelf.Repo, Release: releaseTag, Asset: assetName})
 	ok("Uploaded %s", assetName)
 
 	return suggestedID, assetName, nil
@@ -266,11 +279,12 @@ func buildMigratedBook(suggestedID, assetName, oldPath, sha256sum string, size i
 	baseName := filepath.Base(oldPath)
 
 	return catalog.Book{
-		ID:        suggestedID,
-		Title:     strings.TrimSuffix(baseName, filepath.Ext(baseName)),
-		Format:    ext,
-		SizeBytes: size,
-		Checksum:  catalog.Checksum{SHA256: sha256sum},
+		ID:         suggestedID,
+		Title:      strings.TrimSuffix(baseName, filepath.Ext(baseName)),
+		Format:     ext,
+		SizeBytes:  size,
+		Checksum:   catalog.Checksum{SHA256: sha256sum},
+		Encryption: shelf.EncryptionScheme(),
 		Source: catalog.Source{
 			Type:    "github_release",
 			Owner:   owner,
@@ -285,12 +299,15 @@ func buildMigratedBook(suggestedID, assetName, oldPath, sha256sum string, size i
 	}
 }
 
-func updateCatalogWithBook(shelf *config.ShelfConfig, book catalog.Book, src config.MigrationSource, noPush bool) error {
+func updateCatalogWithBook(op *journal.Op, shelf *config.ShelfConfig, book catalog.Book, src config.MigrationSource, noPush bool) error {
 	owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
 	catalogPath := shelf.EffectiveCatalogPath()
 
 	data, _, _ := gh.GetFileContent(owner, shelf.Repo, catalogPath, "")
 	books, _ := catalog.Parse(data)
+	if err := trash.CheckID(books, book.ID); err != nil {
+		return err
+	}
 	books = catalog.Append(books, book)
 	newData, err := catalog.Marshal(books)
 	if err != nil {
@@ -302,6 +319,7 @@ func updateCatalogWithBook(shelf *config.ShelfConfig, book catalog.Book, src con
 		if err := gh.CommitFile(owner, shelf.Repo, catalogPath, newData, msg); err != nil {
 			return err
 		}
+		op.Catalog(owner, shelf.Repo, catalogPath, data, newData)
 		ok("Catalog updated")
 	}
 
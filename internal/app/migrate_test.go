@@ -535,7 +535,7 @@ func TestUpdateCatalogWithBook(t *testing.T) {
 
 	// Test updateCatalogWithBook with noPush=true (local only)
 	// This should work even though the mock server has the catalog
-	err = updateCatalogWithBook(shelf, newBook, src, true)
+	err = updateCatalogWithBook(nil, shelf, newBook, src, true)
 	if err != nil {
 		t.Errorf("updateCatalogWithBook with noPush failed: %v", err)
 	}
@@ -696,7 +696,7 @@ func TestUploadMigratedFile(t *testing.T) {
 	fileData := []byte("test PDF content")
 
 	// Test upload (mockserver implements asset upload)
-	suggestedID, assetName, err := uploadMigratedFile(shelf, "books/Go Programming.pdf", fileData, int64(len(fileData)))
+	suggestedID, assetName, err := uploadMigratedFile(nil, shelf, "books/Go Programming.pdf", fileData, int64(len(fileData)))
 	if err != nil {
 		t.Errorf("uploadMigratedFile failed: %v", err)
 	}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +20,8 @@ type moveParams struct {
 	toShelfName string
 	dryRun      bool
 	keepOld     bool
+	// op is the journal entry the move is recorded in.
+	op *journal.Op
 }
 
 type moveDestination struct {
@@ -74,7 +78,7 @@ Interactive mode (no arguments) with multi-select:
 						continue
 					}
 
-					for _, b := range books {
+					for _, b := range catalog.Live(books) {
 						cached := cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset)
 						allItems = append(allItems, tui.BookItem{
 							Book:      b,
@@ -132,7 +136,7 @@ Interactive mode (no arguments) with multi-select:
 					}
 
 					for j := range books {
-						if books[j].ID == bookID {
+						if books[j].ID == bookID && !books[j].IsTrashed() {
 							foundBook = &books[j]
 							foundShelf = s
 							break
@@ -421,6 +425,13 @@ func runBatchMove(booksToMove []tui.BookItem, params *moveParams) error {
 	successCount := 0
 	failCount := 0
 
+	ids := make([]string, len(booksToMove))
+	for i, item := range booksToMove {
+		ids[i] = item.Book.ID
+	}
+	params.op = beginOp("move", "move "+bookList(ids))
+	defer finishOp(params.op)
+
 	for i, item := range booksToMove {
 		if len(booksToMove) > 1 {
 			fmt.Printf("\n[%d/%d] Moving %s …\n", i+1, len(booksToMove), item.Book.ID)
@@ -461,12 +472,19 @@ func runMove(id string, params *moveParams) error {
 	if params.toRelease == "" && params.toShelfName == "" {
 		return fmt.Errorf("one of --to-release or --to-shelf is required")
 	}
+	if params.op == nil {
+		params.op = beginOp("move", "move "+id)
+		defer finishOp(params.op)
+	}
 
 	// Find source book
 	b, srcShelf, err := findBook(id, params.shelfName)
 	if err != nil {
 		return err
 	}
+	if err := requireWritable(srcShelf); err != nil {
+		return err
+	}
 	srcOwner := srcShelf.EffectiveOwner(cfg.GitHub.Owner)
 
 	// Determine destination
@@ -479,6 +497,9 @@ func runMove(id string, params *moveParams) error {
 	if srcOwner == dst.owner && srcShelf.Repo == dst.repo && b.Source.Release == dst.release {
 		return fmt.Errorf("book is already at %s/%s@%s - nothing to move", dst.owner, dst.repo, dst.release)
 	}
+	if err := checkDestinationID(dst, id); err != nil {
+		return err
+	}
 
 	fmt.Printf("Moving %s: %s/%s@%s → %s/%s@%s\n",
 		id, srcOwner, srcShelf.Repo, b.Source.Release,
@@ -490,20 +511,28 @@ func runMove(id string, params *moveParams) error {
 	}
 
 	// Transfer asset
+	src := journal.Locations(srcOwner, srcShelf.Repo, b.Source)
 	if err := transferAsset(b, srcShelf, srcOwner, dst); err != nil {
 		return err
 	}
+	dstSource := b.Source
+	dstSource.Release = dst.release
+	moved := journal.Locations(dst.owner, dst.repo, dstSource)
 
 	// Delete old asset unless keeping
-	if !params.keepOld {
-		deleteOldAsset(srcOwner, srcShelf.Repo, b, srcShelf)
+	if !params.keepOld && deleteOldAsset(srcOwner, srcShelf.Repo, b, srcShelf) {
+		params.op.MovedAll(src, moved)
+	} else {
+		for _, loc := range moved {
+			params.op.Added(loc)
+		}
 	}
 
 	// Update catalogs
 	if params.toShelfName != "" {
-		return updateCatalogsForCrossShelfMove(id, b, srcShelf, srcOwner, dst)
+		return updateCatalogsForCrossShelfMove(id, b, srcShelf, srcOwner, dst, params.op)
 	}
-	return updateCatalogForSameShelfMove(id, b, srcShelf, srcOwner, dst)
+	return updateCatalogForSameShelfMove(id, b, srcShelf, srcOwner, dst, params.op)
 }
 
 func determineDestination(srcShelf *config.ShelfConfig, srcOwner string, params *moveParams) (*moveDestination, error) {
@@ -531,6 +560,19 @@ func determineDestination(srcShelf *config.ShelfConfig, srcOwner string, params
 	return dst, nil
 }
 
+// checkDestinationID refuses a move onto another shelf whose trash holds a
+// book with the same ID, before any asset is copied.
+func checkDestinationID(dst *moveDestination, id string) error {
+	if dst.shelf == nil {
+		return nil
+	}
+	data, _, _ := gh.GetFileContent(dst.owner, dst.repo, dst.shelf.EffectiveCatalogPath(), "")
+	books, _ := catalog.Parse(data)
+	return trash.CheckID(books, id)
+}
+
+// transferAsset copies a book's asset, or each of its parts, to the
+// destination release.
 func transferAsset(b *catalog.Book, srcShelf *config.ShelfConfig, srcOwner string, dst *moveDestination) error {
 	// Ensure destination release exists
 	dstRel, err := gh.EnsureRelease(dst.owner, dst.repo, dst.release)
@@ -538,41 +580,56 @@ func transferAsset(b *catalog.Book, srcShelf *config.ShelfConfig, srcOwner strin
 		return fmt.Errorf("ensuring destination release: %w", err)
 	}
 
-	// Get source asset
 	srcRel, err := gh.GetReleaseByTag(srcOwner, srcShelf.Repo, b.Source.Release)
 	if err != nil {
 		return err
 	}
-	srcAsset, err := gh.FindAsset(srcOwner, srcShelf.Repo, srcRel.ID, b.Source.Asset)
-	if err != nil {
-		return err
-	}
-	if srcAsset == nil {
-		return fmt.Errorf("source asset %q not found", b.Source.Asset)
+	for _, name := range b.Source.AssetNames() {
+		// Get source asset
+		srcAsset, err := gh.FindAsset(srcOwner, srcShelf.Repo, srcRel.ID, name)
+		if err != nil {
+			return err
+		}
+		if srcAsset == nil {
+			return fmt.Errorf("source asset %q not found", name)
+		}
+
+		// Download and buffer
+		tmpPath, size, err := downloadAndBuffer(srcOwner, srcShelf.Repo, srcAsset.ID)
+		if err != nil {
+			return err
+		}
+
+		// Upload to destination
+		err = uploadBuffered(dst, dstRel.ID, name, tmpPath, size)
+		_ = os.Remove(tmpPath)
+		if err != nil {
+			return fmt.Errorf("uploading to destination: %w", err)
+		}
 	}
 
-	// Download and buffer
-	tmpPath, size, err := downloadAndBuffer(srcOwner, srcShelf.Repo, srcAsset.ID)
-	if err != nil {
-		return err
+	ok("Uploaded to %s/%s@%s", dst.owner, dst.repo, dst.release)
+
+	// The upload was encrypted (or not) for the destination shelf.
+	if dst.shelf != nil {
+		b.Encryption = dst.shelf.EncryptionScheme()
+	} else {
+		b.Encryption = srcShelf.EncryptionScheme()
 	}
-	defer func() { _ = os.Remove(tmpPath) }()
+	return nil
+}
 
-	// Upload to destination
+// uploadBuffered uploads a file buffered by downloadAndBuffer.
+func uploadBuffered(dst *moveDestination, releaseID int64, name, tmpPath string, size int64) error {
 	uploadFile, err := os.Open(tmpPath)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = uploadFile.Close() }()
 
-	_, err = gh.UploadAsset(dst.owner, dst.repo, dstRel.ID, b.Source.Asset,
+	_, err = gh.UploadAsset(dst.owner, dst.repo, releaseID, name,
 		uploadFile, size, "application/octet-stream")
-	if err != nil {
-		return fmt.Errorf("uploading to destination: %w", err)
-	}
-
-	ok("Uploaded to %s/%s@%s", dst.owner, dst.repo, dst.release)
-	return nil
+	return err
 }
 
 func downloadAndBuffer(owner, repo string, assetID int64) (string, int64, error) {
@@ -606,32 +663,37 @@ func downloadAndBuffer(owner, repo string, assetID int64) (string, int64, error)
 	return tmpPath, fi.Size(), nil
 }
 
-func deleteOldAsset(srcOwner, srcRepo string, b *catalog.Book, _ *config.ShelfConfig) {
+// deleteOldAsset deletes the source asset of a move, or all its parts, and
+// reports whether it did.
+func deleteOldAsset(srcOwner, srcRepo string, b *catalog.Book, _ *config.ShelfConfig) bool {
 	srcRel, err := gh.GetReleaseByTag(srcOwner, srcRepo, b.Source.Release)
 	if err != nil {
 		warn("Could not get source release: %v", err)
-		return
+		return false
 	}
 
-	srcAsset, err := gh.FindAsset(srcOwner, srcRepo, srcRel.ID, b.Source.Asset)
-	if err != nil {
-		warn("Could not find source asset: %v", err)
-		return
-	}
+	for _, name := range b.Source.AssetNames() {
+		srcAsset, err := gh.FindAsset(srcOwner, srcRepo, srcRel.ID, name)
+		if err != nil {
+			warn("Could not find source asset: %v", err)
+			return false
+		}
 
-	if srcAsset == nil {
-		warn("Source asset not found")
-		return
-	}
+		if srcAsset == nil {
+			warn("Source asset not found")
+			return false
+		}
 
-	if err := gh.DeleteAsset(srcOwner, srcRepo, srcAsset.ID); err != nil {
-		warn("Could not delete old asset: %v", err)
-	} else {
-		ok("Deleted old asset from %s@%s", srcRepo, b.Source.Release)
+		if err := gh.DeleteAsset(srcOwner, srcRepo, srcAsset.ID); err != nil {
+			warn("Could not delete old asset: %v", err)
+			return false
+		}
 	}
+	ok("Deleted old asset from %s@%s", srcRepo, b.Source.Release)
+	return true
 }
 
-func updateCatalogsForCrossShelfMove(id string, b *catalog.Book, srcShelf *config.ShelfConfig, srcOwner string, dst *moveDestination) error {
+func updateCatalogsForCrossShelfMove(id string, b *catalog.Book, srcShelf *config.ShelfConfig, srcOwner string, dst *moveDestination, op *journal.Op) error {
 	// Load and update source catalog
 	srcCatalogPath := srcShelf.EffectiveCatalogPath()
 	data, _, err := gh.GetFileContent(srcOwner, srcShelf.Repo, srcCatalogPath, "")
@@ -649,6 +711,9 @@ func updateCatalogsForCrossShelfMove(id string, b *catalog.Book, srcShelf *confi
 	dstBooks, _ := catalog.Parse(dstData)
 
 	// Check for ID conflict in destination
+	if err := trash.CheckID(dstBooks, id); err != nil {
+		return err
+	}
 	for _, existing := range dstBooks {
 		if existing.ID == id {
 			warn("Book with ID %q already exists in destination shelf %q", id, dst.shelf.Name)
@@ -657,12 +722,10 @@ func updateCatalogsForCrossShelfMove(id string, b *catalog.Book, srcShelf *confi
 		}
 	}
 
-	// Clear local cache for this book (path will be invalid after move)
+	// Move the cached copy along with the book
 	if cacheMgr.Exists(srcOwner, srcShelf.Repo, b.ID, b.Source.Asset) {
-		if err := cacheMgr.Remove(srcOwner, srcShelf.Repo, b.ID, b.Source.Asset); err != nil {
-			warn("Could not clear cache: %v", err)
-		} else {
-			ok("Cleared from local cache (path will change after move)")
+		if err := cacheMgr.Move(srcOwner, srcShelf.Repo, b.ID, b.Source.Asset, dst.owner, dst.repo, b.Source.Asset); err != nil {
+			warn("Could not move cached copy: %v", err)
 		}
 	}
 
@@ -681,6 +744,7 @@ func updateCatalogsForCrossShelfMove(id string, b *catalog.Book, srcShelf *confi
 		fmt.Sprintf("move: add %s (from %s)", id, srcShelf.Name)); err != nil {
 		return err
 	}
+	op.Catalog(dst.owner, dst.repo, dstCatalogPath, dstData, dstCatalogData)
 
 	// Remove from source catalog AFTER destination is safely written
 	books, _ = catalog.Remove(books, id)
@@ -692,6 +756,7 @@ func updateCatalogsForCrossShelfMove(id string, b *catalog.Book, srcShelf *confi
 		fmt.Sprintf("move: remove %s (moved to %s)", id, dst.shelf.Name)); err != nil {
 		return err
 	}
+	op.Catalog(srcOwner, srcShelf.Repo, srcCatalogPath, data, srcData)
 
 	ok("Catalog updated")
 
@@ -707,7 +772,7 @@ func updateCatalogsForCrossShelfMove(id string, b *catalog.Book, srcShelf *confi
 	return nil
 }
 
-func updateCatalogForSameShelfMove(id string, _ *catalog.Book, srcShelf *config.ShelfConfig, srcOwner string, dst *moveDestination) error {
+func updateCatalogForSameShelfMove(id string, b *catalog.Book, srcShelf *config.ShelfConfig, srcOwner string, dst *moveDestination, op *journal.Op) error {
 	srcCatalogPath := srcShelf.EffectiveCatalogPath()
 	data, _, err := gh.GetFileContent(srcOwner, srcShelf.Repo, srcCatalogPath, "")
 	if err != nil {
@@ -722,6 +787,7 @@ func updateCatalogForSameShelfMove(id string, _ *catalog.Book, srcShelf *config.
 	for i := range books {
 		if books[i].ID == id {
 			books[i].Source.Release = dst.release
+			books[i].Encryption = b.Encryption
 			break
 		}
 	}
@@ -734,6 +800,7 @@ func updateCatalogForSameShelfMove(id string, _ *catalog.Book, srcShelf *config.
 		fmt.Sprintf("move: %s → release/%s", id, dst.release)); err != nil {
 		return err
 	}
+	op.Catalog(srcOwner, srcShelf.Repo, srcCatalogPath, data, newData)
 
 	ok("Catalog updated")
 	return nil
@@ -766,7 +833,7 @@ func updateREADMEAfterRemove(owner, repo string, remainingBooks []catalog.Book,
 	}
 
 	originalContent := string(readmeData)
-	readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(remainingBooks))
+	readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(catalog.Live(remainingBooks)))
 	readmeContent = operations.RemoveFromShelfREADME(readmeContent, removedBookID)
 
 	// Only commit if content actually changed
@@ -790,7 +857,7 @@ func updateREADMEAfterAdd(owner, repo string, books []catalog.Book, book catalog
 	}
 
 	originalContent := string(readmeData)
-	readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(books))
+	readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(catalog.Live(books)))
 	readmeContent = operations.AppendToShelfREADME(readmeContent, book)
 
 	// Only commit if content actually changed
@@ -10,6 +10,7 @@ import (
 
 	"github.com/blackwell-systems/shelfctl/internal/cache"
 	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/blackwell-systems/shelfctl/internal/util"
 	"github.com/spf13/cobra"
@@ -51,24 +52,16 @@ Examples:
 			}
 			owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
 
-			// Ensure cached.
-			if !cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset) {
+			// Ensure cached, from a copy cached for another book if there is one.
+			if !cacheMgr.Link(owner, shelf.Repo, b.ID, b.Source.Asset, b.Checksum.SHA256) {
 				// Find the release and asset.
 				rel, err := gh.GetReleaseByTag(owner, shelf.Repo, b.Source.Release)
 				if err != nil {
 					return fmt.Errorf("release %q: %w", b.Source.Release, err)
 				}
-				asset, err := gh.FindAsset(owner, shelf.Repo, rel.ID, b.Source.Asset)
+				rc, size, err := parts.Open(gh, owner, shelf.Repo, rel.ID, *b)
 				if err != nil {
-					return fmt.Errorf("finding asset: %w", err)
-				}
-				if asset == nil {
-					return fmt.Errorf("asset %q not found in release %q", b.Source.Asset, b.Source.Release)
-				}
-
-				rc, err := gh.DownloadAsset(owner, shelf.Repo, asset.ID)
-				if err != nil {
-					return fmt.Errorf("download: %w", err)
+					return err
 				}
 				defer func() { _ = rc.Close() }()
 
@@ -82,15 +75,15 @@ Examples:
 
 					// Start download in goroutine
 					go func() {
-						pr := tui.NewProgressReader(rc, asset.Size, progressCh)
+						pr := tui.NewProgressReader(rc, size, progressCh)
 						_, err := cacheMgr.Store(owner, shelf.Repo, b.ID, b.Source.Asset, pr, b.Checksum.SHA256)
 						close(progressCh)
 						errCh <- err
 					}()
 
 					// Show progress UI
-					label := fmt.Sprintf("Downloading %s (%s)", b.ID, humanBytes(asset.Size))
-					if err := tui.ShowProgress(label, asset.Size, progressCh); err != nil {
+					label := fmt.Sprintf("Downloading %s (%s)", b.ID, humanBytes(size))
+					if err := tui.ShowProgress(label, size, progressCh); err != nil {
 						// User cancelled - wait for goroutine to exit
 						<-errCh
 						return err
@@ -102,7 +95,7 @@ Examples:
 					}
 				} else {
 					// Non-interactive mode: just print and download
-					fmt.Printf("Downloading %s (%s) …\n", b.ID, humanBytes(asset.Size))
+					fmt.Printf("Downloading %s (%s) …\n", b.ID, humanBytes(size))
 					_, err = cacheMgr.Store(owner, shelf.Repo, b.ID, b.Source.Asset, rc, b.Checksum.SHA256)
 					if err != nil {
 						return fmt.Errorf("cache: %w", err)
@@ -115,6 +108,9 @@ Examples:
 			}
 
 			path := cacheMgr.Path(owner, shelf.Repo, b.ID, b.Source.Asset)
+			if err := cacheMgr.MarkOpened(owner, shelf.Repo, b.ID, b.Source.Asset); err != nil {
+				warn("%v", err)
+			}
 			return openFile(path, app)
 		},
 	}
@@ -0,0 +1,292 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/site"
+	"github.com/spf13/cobra"
+)
+
+// siteRelease is the release publish uploads cover images to, so the site's
+// branch only holds the pages.
+const siteRelease = "site"
+
+type publishOptions struct {
+	shelfName string
+	branch    string
+	outDir    string
+}
+
+func newPublishCmd() *cobra.Command {
+	var opts publishOptions
+
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish a shelf as a static website on GitHub Pages",
+		Long: `Publish a shelf as a browsable static website.
+
+The site has an index page with search and tag filters and a page per book.
+Download links point straight at the release assets, so no book files are
+copied into the repository. Covers are uploaded as assets of a "site"
+release and linked the same way.
+
+Only books marked public are listed; mark them with
+'shelfctl edit-book <id> --public'. Everything else stays off the site, and
+so do encrypted books. Publishing again after making books private removes
+them, and their covers, from the site.
+
+The site is committed to the shelf repository's gh-pages branch as a single
+commit that replaces the previous version. Enable GitHub Pages for that branch
+under the repository's Settings > Pages. Download links only work for
+visitors if the repository itself is public.
+
+Use --out to write the site to a local directory instead, e.g. to preview it.`,
+		Example: `  shelfctl publish --shelf programming
+  shelfctl publish --shelf programming --out ./site`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPublish(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.shelfName, "shelf", "", "Shelf to publish")
+	cmd.Flags().StringVar(&opts.branch, "branch", "gh-pages", "Branch to publish the site to")
+	cmd.Flags().StringVar(&opts.outDir, "out", "", "Write the site to this directory instead of committing it")
+	_ = cmd.MarkFlagRequired("shelf")
+	return cmd
+}
+
+func runPublish(opts publishOptions) error {
+	return runPublishWithClient(opts, gh)
+}
+
+func runPublishWithClient(opts publishOptions, client GitHubClient) error {
+	shelf := cfg.ShelfByName(opts.shelfName)
+	if shelf == nil {
+		return fmt.Errorf("shelf %q not found in config", opts.shelfName)
+	}
+	if opts.outDir == "" {
+		if err := requireWritable(shelf); err != nil {
+			return err
+		}
+	}
+	owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+
+	data, _, err := client.GetFileContent(owner, shelf.Repo, shelf.EffectiveCatalogPath(), "")
+	if err != nil {
+		return fmt.Errorf("loading catalog: %w", err)
+	}
+	books, err := catalog.Parse(data)
+	if err != nil {
+		return fmt.Errorf("parsing catalog: %w", err)
+	}
+
+	var public []catalog.Book
+	for _, b := range books {
+		if !b.Public || b.IsTrashed() {
+			continue
+		}
+		if b.Encryption != "" {
+			// Its download link would only serve ciphertext.
+			warn("%s: encrypted, leaving it off the site", b.ID)
+			continue
+		}
+		public = append(public, b)
+	}
+	if len(public) == 0 {
+		// Still publish, so books made private since leave the site.
+		warn("No books on shelf %q are marked public (use 'shelfctl edit-book <id> --public'); the site will be empty", shelf.Name)
+	}
+
+	urls := newAssetURLs(client)
+	s := site.Site{Title: shelf.Name, Owner: owner, Repo: shelf.Repo, Generated: time.Now()}
+	for _, b := range public {
+		sb := site.Book{Book: b}
+		srcOwner, srcRepo := b.Source.Owner, b.Source.Repo
+		if srcOwner == "" || srcRepo == "" {
+			srcOwner, srcRepo = owner, shelf.Repo
+		}
+		var links []string
+		for _, name := range b.Source.AssetNames() {
+			u, err := urls.lookup(srcOwner, srcRepo, b.Source.Release, name)
+			if err != nil {
+				warn("%s: %v", b.ID, err)
+				links = nil
+				break
+			}
+			links = append(links, u)
+		}
+		if b.Source.IsMultiPart() {
+			sb.PartURLs = links
+		} else if len(links) == 1 {
+			sb.DownloadURL = links[0]
+		}
+		if cover := exportCover(client, owner, shelf.Repo, b); cover != nil {
+			if ext := imageExt(cover); ext != "" {
+				sb.Cover, sb.CoverExt = cover, ext
+			}
+		}
+		s.Books = append(s.Books, sb)
+	}
+
+	if opts.outDir == "" && !shelf.Encrypted() {
+		// Assets of encrypted shelves are encrypted on upload, so their
+		// covers stay in the branch instead.
+		if err := uploadCovers(client, owner, shelf.Repo, s.Books); err != nil {
+			return err
+		}
+	}
+
+	files, err := site.Render(s)
+	if err != nil {
+		return err
+	}
+
+	if opts.outDir != "" {
+		for p, data := range files {
+			dest := filepath.Join(opts.outDir, filepath.FromSlash(p))
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(dest, data, 0644); err != nil {
+				return err
+			}
+		}
+		ok("Wrote site for %d book(s) to %s", len(s.Books), opts.outDir)
+		return nil
+	}
+
+	msg := fmt.Sprintf("publish: update site (%d books)", len(s.Books))
+	changed, err := client.ReplaceBranch(owner, shelf.Repo, opts.branch, files, msg)
+	if err != nil {
+		return fmt.Errorf("publishing to %s: %w", opts.branch, err)
+	}
+	if !changed {
+		ok("Site is already up to date")
+	} else {
+		ok("Published %d book(s) to %s/%s@%s", len(s.Books), owner, shelf.Repo, opts.branch)
+	}
+	fmt.Printf("  %s\n", site.PagesURL(owner, shelf.Repo))
+	return nil
+}
+
+// uploadCovers uploads the books' covers as assets of the site release and
+// points the books at their download URLs. Assets are named by content, so
+// unchanged covers are not uploaded again; covers no longer used, such as
+// those of books made private, are deleted.
+func uploadCovers(client GitHubClient, owner, repo string, books []site.Book) error {
+	var rel *github.Release
+	var err error
+	if slices.ContainsFunc(books, func(b site.Book) bool { return len(b.Cover) > 0 }) {
+		if rel, err = client.EnsureRelease(owner, repo, siteRelease); err != nil {
+			return fmt.Errorf("ensuring release %q: %w", siteRelease, err)
+		}
+	} else {
+		// Nothing to upload, but old covers may still need deleting.
+		rel, err = client.GetReleaseByTag(owner, repo, siteRelease)
+		if errors.Is(err, github.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("finding release %q: %w", siteRelease, err)
+		}
+	}
+	assets, err := client.ListReleaseAssets(owner, repo, rel.ID)
+	if err != nil {
+		return fmt.Errorf("listing assets of %q: %w", siteRelease, err)
+	}
+	existing := make(map[string]github.Asset, len(assets))
+	for _, a := range assets {
+		existing[a.Name] = a
+	}
+
+	used := map[string]bool{}
+	for i := range books {
+		b := &books[i]
+		if len(b.Cover) == 0 {
+			continue
+		}
+		sum := sha256.Sum256(b.Cover)
+		name := fmt.Sprintf("%s-cover-%x%s", b.ID, sum[:6], b.CoverExt)
+		used[name] = true
+		a, ok := existing[name]
+		if !ok {
+			up, err := client.UploadAsset(owner, repo, rel.ID, name, bytes.NewReader(b.Cover), int64(len(b.Cover)), http.DetectContentType(b.Cover))
+			if err != nil {
+				return fmt.Errorf("uploading cover of %s: %w", b.ID, err)
+			}
+			a = *up
+		}
+		b.CoverURL = a.BrowserDownloadURL
+	}
+
+	for name, a := range existing {
+		if !used[name] {
+			if err := client.DeleteAsset(owner, repo, a.ID); err != nil {
+				warn("could not delete old cover %s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// assetURLs looks up browser_download_url for release assets, listing each
+// release once.
+type assetURLs struct {
+	client   GitHubClient
+	releases map[string]map[string]string
+}
+
+func newAssetURLs(client GitHubClient) *assetURLs {
+	return &assetURLs{client: client, releases: map[string]map[string]string{}}
+}
+
+func (a *assetURLs) lookup(owner, repo, tag, asset string) (string, error) {
+	key := owner + "/" + repo + "@" + tag
+	byName, ok := a.releases[key]
+	if !ok {
+		byName = map[string]string{}
+		rel, err := a.client.GetReleaseByTag(owner, repo, tag)
+		if err != nil {
+			return "", fmt.Errorf("release %q: %w", tag, err)
+		}
+		assets, err := a.client.ListReleaseAssets(owner, repo, rel.ID)
+		if err != nil {
+			return "", fmt.Errorf("listing assets of %q: %w", tag, err)
+		}
+		for _, as := range assets {
+			byName[as.Name] = as.BrowserDownloadURL
+		}
+		a.releases[key] = byName
+	}
+	u := byName[asset]
+	if u == "" {
+		return "", fmt.Errorf("asset %q not found in release %q", asset, tag)
+	}
+	return u, nil
+}
+
+// imageExt returns the file extension for cover image data, or "" if the
+// data is not an image browsers can show.
+func imageExt(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	}
+	return ""
+}
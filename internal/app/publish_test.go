@@ -0,0 +1,173 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/cache"
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+// assetURL is the download URL of an asset of me/shelf-books.
+func assetURL(release, name string) string {
+	return "https://github.com/me/shelf-books/releases/download/" + release + "/" + name
+}
+
+func setupPublish(t *testing.T) *testutil.GitHub {
+	t.Helper()
+	origStdout, origCfg, origCache := os.Stdout, cfg, cacheMgr
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() {
+		os.Stdout, cfg, cacheMgr = origStdout, origCfg, origCache
+	})
+	cfg = &config.Config{
+		GitHub:  config.GitHubConfig{Owner: "me"},
+		Shelves: []config.ShelfConfig{{Name: "books", Repo: "shelf-books"}},
+	}
+	cacheMgr = cache.New(t.TempDir())
+
+	src := func(asset string) catalog.Source {
+		return catalog.Source{Type: "github_release", Owner: "me", Repo: "shelf-books", Release: "library", Asset: asset}
+	}
+	books := []catalog.Book{
+		{ID: "sicp", Title: "SICP", Format: "pdf", Cover: "covers/sicp.jpg", Public: true, Source: src("sicp.pdf")},
+		{ID: "diary", Title: "My Diary", Format: "pdf", Source: src("diary.pdf")},
+	}
+	data, err := catalog.Marshal(books)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := testutil.NewGitHub()
+	shelf := client.Repo("me", "shelf-books")
+	shelf.Files["catalog.yml"] = data
+	shelf.Files["covers/sicp.jpg"] = []byte("\xff\xd8\xff\xe0 jpeg")
+	client.PutAsset("me", "shelf-books", "library", "sicp.pdf", "sicp")
+	client.PutAsset("me", "shelf-books", "library", "diary.pdf", "diary")
+	return client
+}
+
+func TestPublish_OnlyPublicBooks(t *testing.T) {
+	client := setupPublish(t)
+
+	if err := runPublishWithClient(publishOptions{shelfName: "books", branch: "gh-pages"}, client); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	published, ok := client.Repo("me", "shelf-books").Branches["gh-pages"]
+	if !ok {
+		t.Fatal("gh-pages not published")
+	}
+	for _, name := range []string{"index.html", "books/sicp.html"} {
+		if _, ok := published[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+	if !strings.Contains(string(published["index.html"]), assetURL("library", "sicp.pdf")) {
+		t.Error("index.html does not link the release asset")
+	}
+	for name, data := range published {
+		if strings.Contains(name, "diary") || strings.Contains(string(data), "Diary") {
+			t.Errorf("private book leaked into %s", name)
+		}
+	}
+}
+
+func TestPublish_CoversAsAssets(t *testing.T) {
+	client := setupPublish(t)
+	client.PutAsset("me", "shelf-books", siteRelease, "sicp-cover-000000000000.jpg", "old cover")
+
+	if err := runPublishWithClient(publishOptions{shelfName: "books", branch: "gh-pages"}, client); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	published := client.Repo("me", "shelf-books").Branches["gh-pages"]
+	if _, ok := published["covers/sicp.jpg"]; ok {
+		t.Error("cover was committed to the branch")
+	}
+	siteAssets := client.Assets("me", "shelf-books", siteRelease)
+	var cover string
+	for name := range siteAssets {
+		if strings.HasPrefix(name, "sicp-cover-") && name != "sicp-cover-000000000000.jpg" {
+			cover = name
+		}
+	}
+	if cover == "" {
+		t.Fatalf("cover not uploaded: %v", siteAssets)
+	}
+	if !strings.Contains(string(published["books/sicp.html"]), assetURL(siteRelease, cover)) {
+		t.Error("book page does not link the cover asset")
+	}
+	if len(siteAssets) != 1 {
+		t.Errorf("site assets = %v, want the old cover deleted", siteAssets)
+	}
+
+	// Publishing again does not upload the unchanged cover.
+	if err := runPublishWithClient(publishOptions{shelfName: "books", branch: "gh-pages"}, client); err != nil {
+		t.Fatal(err)
+	}
+	if siteAssets := client.Assets("me", "shelf-books", siteRelease); len(siteAssets) != 1 || siteAssets[cover] == nil {
+		t.Errorf("site assets after publishing again = %v", siteAssets)
+	}
+
+	// A local preview keeps the cover next to the pages.
+	out := t.TempDir()
+	if err := runPublishWithClient(publishOptions{shelfName: "books", outDir: out}, client); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "covers", "sicp.jpg")); err != nil {
+		t.Errorf("preview cover: %v", err)
+	}
+}
+
+func TestPublish_MultiPartBook(t *testing.T) {
+	client := setupPublish(t)
+	b := catalog.Book{ID: "big", Title: "Big Book", Format: "pdf", Public: true, Source: catalog.Source{
+		Type: "github_release", Owner: "me", Repo: "shelf-books", Release: "library", Asset: "big.pdf",
+		Parts: []catalog.Part{{Asset: "big.pdf.part001"}, {Asset: "big.pdf.part002"}},
+	}}
+	data, err := catalog.Marshal([]catalog.Book{b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Repo("me", "shelf-books").Files["catalog.yml"] = data
+	client.PutAsset("me", "shelf-books", "library", "big.pdf.part001", "part 1")
+	client.PutAsset("me", "shelf-books", "library", "big.pdf.part002", "part 2")
+
+	if err := runPublishWithClient(publishOptions{shelfName: "books", branch: "gh-pages"}, client); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	published := client.Repo("me", "shelf-books").Branches["gh-pages"]
+	page := string(published["books/big.html"])
+	for _, want := range []string{assetURL("library", "big.pdf.part001"), assetURL("library", "big.pdf.part002")} {
+		if !strings.Contains(page, want) {
+			t.Errorf("book page does not link %s", want)
+		}
+	}
+	if !strings.Contains(string(published["index.html"]), "2 parts") {
+		t.Error("index.html does not offer the parts")
+	}
+}
+
+func TestPublish_NoPublicBooks(t *testing.T) {
+	client := setupPublish(t)
+	data, _ := catalog.Marshal([]catalog.Book{{ID: "diary", Title: "My Diary", Format: "pdf"}})
+	client.Repo("me", "shelf-books").Files["catalog.yml"] = data
+	client.PutAsset("me", "shelf-books", siteRelease, "sicp-cover-000000000000.jpg", "old cover") // from when sicp was public
+
+	// The site is emptied rather than left listing books now private.
+	if err := runPublishWithClient(publishOptions{shelfName: "books", branch: "gh-pages"}, client); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	published := client.Repo("me", "shelf-books").Branches["gh-pages"]
+	if !strings.Contains(string(published["index.html"]), "0 books") {
+		t.Error("index.html still lists books")
+	}
+	if _, ok := published["books/sicp.html"]; ok {
+		t.Error("page of a private book published")
+	}
+	if client.HasAsset("me", "shelf-books", siteRelease, "sicp-cover-000000000000.jpg") {
+		t.Error("old cover not deleted")
+	}
+}
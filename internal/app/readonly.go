@@ -0,0 +1,143 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	ghclient "github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/spf13/cobra"
+)
+
+// readOnlyCommands only read shelves, so they also run without a GitHub
+// token against public shelves. The listed flags make them write.
+var readOnlyCommands = map[string][]string{
+	"browse":           nil,
+	"cache clear":      nil,
+	"cache gc":         nil,
+	"cache info":       nil,
+	"cache migrate":    nil,
+	"cache pin":        nil,
+	"cache refresh":    nil,
+	"cache unpin":      nil,
+	"delete-shelf":     {"delete-repo"},
+	"diff":             nil,
+	"export":           nil,
+	"index":            nil,
+	"info":             nil,
+	"log":              nil,
+	"open":             nil,
+	"search":           nil,
+	"serve":            nil,
+	"shelves":          {"fix"},
+	"shelves discover": nil, // writes only the local config
+	"status":           nil,
+	"subscribe":        nil, // reads the manifest, writes only the local config
+	"tags list":        nil,
+	"trash list":       nil,
+	"verify":           {"fix"},
+}
+
+// readOnlyWith are commands that change shelves unless the flag is given.
+var readOnlyWith = map[string]string{
+	"undo": "list",
+}
+
+// checkTokenless returns an error if cmd needs a token to run.
+func checkTokenless(cmd *cobra.Command) error {
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if f, ok := readOnlyWith[path]; ok && cmd.Flags().Changed(f) {
+		return nil
+	}
+	writeFlags, ok := readOnlyCommands[path]
+	if !ok {
+		return fmt.Errorf("'%s' makes changes and needs a GitHub token — run 'shelfctl auth login' or set %s\n\nWithout a token, public shelves can still be browsed, searched and downloaded",
+			path, tokenEnvName())
+	}
+	for _, f := range writeFlags {
+		if cmd.Flags().Changed(f) {
+			return fmt.Errorf("--%s makes changes and needs a GitHub token — run 'shelfctl auth login' or set %s",
+				f, tokenEnvName())
+		}
+	}
+	return nil
+}
+
+func tokenEnvName() string {
+	if cfg != nil && cfg.GitHub.TokenEnv != "" {
+		return cfg.GitHub.TokenEnv
+	}
+	return "GITHUB_TOKEN"
+}
+
+// newGitHubClient creates the client for c. Without a token it is
+// anonymous; either way it refuses writes to read-only shelves and
+// encrypts and decrypts the assets of encrypted shelves. Each profile gets
+// its own client, and the shelves of a profile are routed to it.
+func newGitHubClient(c *config.Config) *ghclient.Client {
+	client := ghclient.New(c.GitHub.Token, c.GitHub.APIBase)
+	profiles := map[string]*ghclient.Client{}
+	for _, s := range c.Shelves {
+		owner := s.EffectiveOwner(c.GitHub.Owner)
+		target := client
+		if s.Profile != "" {
+			key := strings.ToLower(s.Profile)
+			if profiles[key] == nil {
+				p := c.GitHubFor(&s)
+				profiles[key] = ghclient.New(p.Token, p.APIBase)
+			}
+			target = profiles[key]
+			client.Route(owner, s.Repo, target)
+		}
+		if s.ReadOnly {
+			target.SetReadOnly(owner, s.Repo)
+		}
+		if s.Encrypted() {
+			target.SetEncryption(owner, s.Repo, sync.OnceValues(s.Encryption.LoadKey))
+		}
+	}
+	return client
+}
+
+// profileClient returns a client for a profile, or for github if name is
+// empty, for commands that work on an account rather than a shelf.
+func profileClient(name string) (*ghclient.Client, *config.GitHubConfig, error) {
+	if name == "" {
+		return gh, &cfg.GitHub, nil
+	}
+	p, err := cfg.Profile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ghclient.New(p.Token, p.APIBase), p, nil
+}
+
+// followsOnly reports whether every configured shelf is read-only, so the
+// library is usable without a token.
+func followsOnly(c *config.Config) bool {
+	if c == nil || len(c.Shelves) == 0 {
+		return false
+	}
+	return len(writableShelves(c.Shelves)) == 0
+}
+
+// requireWritable returns an error if shelf is marked read-only.
+func requireWritable(shelf *config.ShelfConfig) error {
+	if shelf.ReadOnly {
+		return fmt.Errorf("shelf %q is read-only (read_only: true in config)", shelf.Name)
+	}
+	return nil
+}
+
+// writableShelves drops read-only shelves, for commands that change every
+// shelf unless one is named.
+func writableShelves(shelves []config.ShelfConfig) []config.ShelfConfig {
+	out := make([]config.ShelfConfig, 0, len(shelves))
+	for _, s := range shelves {
+		if !s.ReadOnly {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	ghpkg "github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/spf13/cobra"
+)
+
+func TestCheckTokenless(t *testing.T) {
+	root := &cobra.Command{Use: "shelfctl"}
+	browse := &cobra.Command{Use: "browse"}
+	shelve := &cobra.Command{Use: "shelve"}
+	verify := &cobra.Command{Use: "verify"}
+	verify.Flags().Bool("fix", false, "")
+	tags := &cobra.Command{Use: "tags"}
+	tagsList := &cobra.Command{Use: "list"}
+	tagsRename := &cobra.Command{Use: "rename"}
+	tags.AddCommand(tagsList, tagsRename)
+	root.AddCommand(browse, shelve, verify, tags)
+
+	for _, cmd := range []*cobra.Command{browse, verify, tagsList} {
+		if err := checkTokenless(cmd); err != nil {
+			t.Errorf("%s: %v", cmd.CommandPath(), err)
+		}
+	}
+	for _, cmd := range []*cobra.Command{shelve, tagsRename} {
+		if err := checkTokenless(cmd); err == nil || !strings.Contains(err.Error(), "needs a GitHub token") {
+			t.Errorf("%s: err = %v", cmd.CommandPath(), err)
+		}
+	}
+
+	_ = verify.Flags().Set("fix", "true")
+	if err := checkTokenless(verify); err == nil || !strings.Contains(err.Error(), "--fix") {
+		t.Errorf("verify --fix: err = %v", err)
+	}
+}
+
+func TestCheckTokenless_LocalOnlyCommands(t *testing.T) {
+	for _, path := range []string{
+		"cache gc",
+		"cache pin",
+		"cache unpin",
+		"cache refresh",
+		"cache migrate",
+		"trash list",
+	} {
+		t.Run(path, func(t *testing.T) {
+			cmd, _, err := rootCmd.Find(strings.Fields(path))
+			if err != nil || cmd.CommandPath() != "shelfctl "+path {
+				t.Fatalf("find %q: %v, %v", path, cmd, err)
+			}
+			if err := checkTokenless(cmd); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCheckTokenless_UndoList(t *testing.T) {
+	undo, _, err := rootCmd.Find([]string{"undo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := undo.Flags().Lookup("list")
+	t.Cleanup(func() { list.Changed = false; _ = list.Value.Set("false") })
+
+	if err := checkTokenless(undo); err == nil || !strings.Contains(err.Error(), "needs a GitHub token") {
+		t.Errorf("undo: err = %v", err)
+	}
+	_ = undo.Flags().Set("list", "true")
+	if err := checkTokenless(undo); err != nil {
+		t.Errorf("undo --list: %v", err)
+	}
+}
+
+func TestNewGitHubClient_MarksReadOnlyShelves(t *testing.T) {
+	c := newGitHubClient(&config.Config{
+		GitHub: config.GitHubConfig{Owner: "me", Token: "t", APIBase: "http://127.0.0.1:0"},
+		Shelves: []config.ShelfConfig{
+			{Name: "team", Owner: "colleague", Repo: "shelf-papers", ReadOnly: true},
+		},
+	})
+	err := c.CommitFile("colleague", "shelf-papers", "catalog.yml", nil, "x")
+	if !errors.Is(err, ghpkg.ErrReadOnly) {
+		t.Errorf("err = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestPublish_RefusesReadOnlyShelf(t *testing.T) {
+	client := setupPublish(t)
+	cfg.Shelves[0].ReadOnly = true
+
+	err := runPublishWithClient(publishOptions{shelfName: "books", branch: "gh-pages"}, client)
+	if err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("err = %v", err)
+	}
+	if len(client.Repo("me", "shelf-books").Branches) != 0 {
+		t.Error("published to a read-only shelf")
+	}
+
+	// Rendering locally is still fine.
+	if err := runPublishWithClient(publishOptions{shelfName: "books", outDir: t.TempDir()}, client); err != nil {
+		t.Errorf("--out on read-only shelf: %v", err)
+	}
+}
+
+func TestWritableShelves(t *testing.T) {
+	got := writableShelves([]config.ShelfConfig{{Name: "a"}, {Name: "b", ReadOnly: true}, {Name: "c"}})
+	if len(got) != 2 || got[0].Name != "a" || got[1].Name != "c" {
+		t.Errorf("got %+v", got)
+	}
+	if followsOnly(&config.Config{Shelves: []config.ShelfConfig{{Name: "a"}, {Name: "b", ReadOnly: true}}}) {
+		t.Error("followsOnly with a writable shelf")
+	}
+	if !followsOnly(&config.Config{Shelves: []config.ShelfConfig{{Name: "b", ReadOnly: true}}}) {
+		t.Error("followsOnly with only read-only shelves")
+	}
+}
+
+func TestNewGitHubClient_RoutesProfileShelves(t *testing.T) {
+	var hits []string
+	server := func(name string) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, name+" "+r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"id":1}`))
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+	c := newGitHubClient(&config.Config{
+		GitHub: config.GitHubConfig{Owner: "me", Token: "public", APIBase: server("public")},
+		Profiles: map[string]*config.GitHubConfig{
+			"work": {Token: "work", APIBase: server("ghe")},
+		},
+		Shelves: []config.ShelfConfig{
+			{Name: "books", Repo: "shelf-books"},
+			{Name: "specs", Owner: "platform", Repo: "shelf-specs", Profile: "work", ReadOnly: true},
+		},
+	})
+
+	for _, repo := range [][2]string{{"me", "shelf-books"}, {"platform", "shelf-specs"}} {
+		if _, err := c.GetReleaseByTag(repo[0], repo[1], "library"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Join(hits, ",") != "public Bearer public,ghe Bearer work" {
+		t.Errorf("requests = %v", hits)
+	}
+	// Shelf settings apply to the profile's client.
+	if err := c.CommitFile("platform", "shelf-specs", "catalog.yml", nil, "x"); !errors.Is(err, ghpkg.ErrReadOnly) {
+		t.Errorf("err = %v, want ErrReadOnly", err)
+	}
+}
@@ -81,6 +81,14 @@ func init() {
 			return nil
 		}
 
+		// auth sets up the token, so it runs without one and without a
+		// config file.
+		if cmd.Parent() != nil && cmd.Parent().Name() == "auth" {
+			var err error
+			cfg, err = config.Load()
+			return err
+		}
+
 		// Allow init and root (hub) to run without an existing config.
 		if (cmd.Name() == "init" || cmd.Name() == "shelfctl") && cmd.Parent() == nil {
 			var err error
@@ -88,27 +96,27 @@ func init() {
 			if err != nil {
 				cfg = &config.Config{}
 			}
-			// For root command (hub), still try to initialize clients if possible
-			if cfg != nil && cfg.GitHub.Token != "" {
-				gh = ghclient.New(cfg.GitHub.Token, cfg.GitHub.APIBase)
-				cacheMgr = cache.New(cfg.Defaults.CacheDir)
-			}
+			// For root command (hub), still initialize clients; without a
+			// token the client is anonymous and can only read public shelves.
+			gh = newGitHubClient(cfg)
+			cacheMgr = newCacheManager(cfg)
 			return nil
 		}
 
 		var err error
 		cfg, err = config.Load()
 		if err != nil {
-			return fmt.Errorf("config file not found. Run 'shelfctl init --help' to get started, or create %s manually", config.DefaultPath())
+			return err
 		}
 
-		if cfg.GitHub.Token == "" {
-			return fmt.Errorf("no GitHub token found — set %s or SHELFCTL_GITHUB_TOKEN",
-				cfg.GitHub.TokenEnv)
+		if !cfg.HasToken() {
+			if err := checkTokenless(cmd); err != nil {
+				return err
+			}
 		}
 
-		gh = ghclient.New(cfg.GitHub.Token, cfg.GitHub.APIBase)
-		cacheMgr = cache.New(cfg.Defaults.CacheDir)
+		gh = newGitHubClient(cfg)
+		cacheMgr = newCacheManager(cfg)
 		return nil
 	}
 
@@ -117,8 +125,10 @@ func init() {
 		newVersionCmd(),
 		newInitCmd(),
 		newShelvesCmd(),
+		newAuthCmd(),
 		newDeleteShelfCmd(),
 		newDeleteBookCmd(),
+		newTrashCmd(),
 		newEditBookCmd(),
 		newBrowseCmd(),
 		newInfoCmd(),
@@ -129,12 +139,20 @@ func init() {
 		newMigrateCmd(),
 		newImportCmd(),
 		newIndexCmd(),
+		newLogCmd(),
+		newDiffCmd(),
+		newUndoCmd(),
 		newVerifyCmd(),
 		newSyncCmd(),
 		newCacheCmd(),
 		newStatusCmd(),
 		newSearchCmd(),
 		newTagsCmd(),
+		newEnrichCmd(),
+		newExportCmd(),
+		newServeCmd(),
+		newPublishCmd(),
+		newSubscribeCmd(),
 		newCompletionCmd(),
 	)
 
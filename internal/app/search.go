@@ -91,7 +91,7 @@ Examples:
 					continue
 				}
 
-				matched := f.Apply(books)
+				matched := f.Apply(catalog.Live(books))
 				if len(matched) == 0 {
 					continue
 				}
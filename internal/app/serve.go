@@ -0,0 +1,293 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/opds"
+	"github.com/blackwell-systems/shelfctl/internal/web"
+	"github.com/spf13/cobra"
+)
+
+// servePasswordEnv is read when --password is not given, so the password
+// does not have to appear in the process list.
+const servePasswordEnv = "SHELFCTL_SERVE_PASSWORD"
+
+type serveOptions struct {
+	addr     string
+	opds     bool
+	web      bool
+	shelf    string
+	user     string
+	password string
+	refresh  time.Duration
+}
+
+func newServeCmd() *cobra.Command {
+	var opts serveOptions
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve your shelves over HTTP (OPDS feeds, web UI)",
+		Long: `Serve your shelves over HTTP.
+
+With --opds, every shelf is published as an OPDS catalog that e-reader apps
+such as KOReader, Moon+ Reader or Thorium can browse:
+
+  /opds       OPDS 1.2 (Atom)
+  /opds/v2    OPDS 2.0 (JSON)
+
+Books can be browsed by shelf, tag and author, and searched.
+
+With --web, the library page from 'shelfctl index' is served live at /:
+every book is shown, including ones not cached yet, which download (with
+progress) when clicked. Cards also offer editing metadata and syncing local
+changes back to GitHub.
+
+Downloads are served from the local cache; books that are not cached yet are
+fetched from their GitHub release first. Catalogs are reloaded every --refresh
+interval.
+
+By default the server listens on localhost only. To reach it from a phone or
+tablet, listen on all interfaces (--addr :8080) and set --user so requests
+need a password (taken from --password or ` + servePasswordEnv + `).`,
+		Example: `  shelfctl serve --opds
+  shelfctl serve --web
+  shelfctl serve --opds --shelf fiction
+  SHELFCTL_SERVE_PASSWORD=secret shelfctl serve --opds --addr :8080 --user reader`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.password == "" {
+				opts.password = os.Getenv(servePasswordEnv)
+			}
+			return runServe(opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.opds, "opds", false, "Serve OPDS catalog feeds")
+	cmd.Flags().BoolVar(&opts.web, "web", false, "Serve the live library web UI")
+	cmd.Flags().StringVar(&opts.addr, "addr", "localhost:8080", "Address to listen on")
+	cmd.Flags().StringVar(&opts.shelf, "shelf", "", "Only serve this shelf")
+	cmd.Flags().StringVar(&opts.user, "user", "", "Require HTTP basic auth with this username")
+	cmd.Flags().StringVar(&opts.password, "password", "", "Basic auth password (default $"+servePasswordEnv+")")
+	cmd.Flags().DurationVar(&opts.refresh, "refresh", 5*time.Minute, "How often to reload shelf catalogs")
+	return cmd
+}
+
+func runServe(opts serveOptions) error {
+	if !opts.opds && !opts.web {
+		return fmt.Errorf("nothing to serve: use --opds and/or --web")
+	}
+	if opts.user != "" && opts.password == "" {
+		return fmt.Errorf("--user needs a password (--password or $%s)", servePasswordEnv)
+	}
+
+	shelves := cfg.Shelves
+	if opts.shelf != "" {
+		s := cfg.ShelfByName(opts.shelf)
+		if s == nil {
+			return fmt.Errorf("shelf %q not found in config", opts.shelf)
+		}
+		shelves = []config.ShelfConfig{*s}
+	}
+	if len(shelves) == 0 {
+		return fmt.Errorf("no shelves configured")
+	}
+
+	lib := newServeLibrary(gh, shelves, opts.refresh)
+	if _, err := lib.Shelves(); err != nil {
+		return err
+	}
+
+	logf := func(format string, args ...interface{}) { warn(format, args...) }
+	mux := http.NewServeMux()
+	files := &opds.Server{Library: lib, Logf: logf}
+	if opts.opds {
+		files.Register(mux)
+	} else {
+		files.RegisterBooks(mux)
+	}
+	if opts.web {
+		ws := &web.Server{Backend: lib, Cache: cacheMgr, Logf: logf}
+		ws.Register(mux)
+	}
+
+	var handler http.Handler = mux
+	if opts.user != "" {
+		handler = opds.BasicAuth(mux, opts.user, opts.password)
+	}
+
+	httpSrv := &http.Server{Addr: opts.addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpSrv.Shutdown(shutdownCtx)
+	}()
+
+	ok("Serving %d shelf(s) on http://%s", len(shelves), displayAddr(opts.addr))
+	base := "http://" + displayAddr(opts.addr)
+	if opts.web {
+		printField("Web UI", base+"/")
+	}
+	if opts.opds {
+		printField("OPDS 1.2", base+opds.AtomRoot)
+		printField("OPDS 2.0", base+opds.JSONRoot)
+	}
+	if opts.user == "" && !isLoopbackAddr(opts.addr) {
+		warn("Listening beyond localhost without --user; anyone on the network can download your books")
+	}
+	fmt.Println("Press Ctrl+C to stop")
+
+	if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// displayAddr turns ":8080" into "localhost:8080" for printing.
+func displayAddr(addr string) string {
+	if len(addr) > 0 && addr[0] == ':' {
+		return "localhost" + addr
+	}
+	return addr
+}
+
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// serveLibrary is the opds.Library backed by the configured shelves, the
+// GitHub client and the local cache.
+type serveLibrary struct {
+	client  GitHubClient
+	shelves []config.ShelfConfig
+	refresh time.Duration
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	catalogs map[string][]catalog.Book
+
+	// downloads serializes downloads per book (by shelf/id), so two readers
+	// asking for the same book do not fetch it twice, while other books
+	// download alongside. The cache's own lock would fail the second
+	// request as busy instead of making it wait.
+	downloadMu sync.Mutex
+	downloads  map[string]*sync.Mutex
+}
+
+func newServeLibrary(client GitHubClient, shelves []config.ShelfConfig, refresh time.Duration) *serveLibrary {
+	return &serveLibrary{client: client, shelves: shelves, refresh: refresh, catalogs: map[string][]catalog.Book{}}
+}
+
+// Shelves returns the catalogs, reloading them when older than the refresh
+// interval. A shelf that fails to reload keeps its previous catalog.
+func (l *serveLibrary) Shelves() ([]opds.Shelf, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.loadedAt.IsZero() || time.Since(l.loadedAt) >= l.refresh {
+		loaded := 0
+		for i := range l.shelves {
+			shelf := &l.shelves[i]
+			owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+			data, _, err := l.client.GetFileContent(owner, shelf.Repo, shelf.EffectiveCatalogPath(), "")
+			if err == nil {
+				var books []catalog.Book
+				if books, err = catalog.Parse(data); err == nil {
+					l.catalogs[shelf.Name] = catalog.Live(books)
+					loaded++
+					continue
+				}
+			}
+			warn("Could not load catalog for shelf %q: %v", shelf.Name, err)
+		}
+		if loaded == 0 && len(l.catalogs) == 0 {
+			return nil, fmt.Errorf("could not load any shelf catalog")
+		}
+		l.loadedAt = time.Now()
+	}
+
+	out := make([]opds.Shelf, 0, len(l.shelves))
+	for _, s := range l.shelves {
+		out = append(out, opds.Shelf{Name: s.Name, Books: l.catalogs[s.Name]})
+	}
+	return out, nil
+}
+
+func (l *serveLibrary) shelfConfig(name string) (*config.ShelfConfig, error) {
+	for i := range l.shelves {
+		if l.shelves[i].Name == name {
+			return &l.shelves[i], nil
+		}
+	}
+	return nil, fmt.Errorf("shelf %q not served", name)
+}
+
+// BookFile returns the cached asset, downloading it from the release first
+// if needed.
+func (l *serveLibrary) BookFile(shelfName string, b catalog.Book) (string, error) {
+	shelf, err := l.shelfConfig(shelfName)
+	if err != nil {
+		return "", err
+	}
+	owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+	if cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset) {
+		return cacheMgr.Path(owner, shelf.Repo, b.ID, b.Source.Asset), nil
+	}
+	defer l.lockDownload(shelfName, b.ID)()
+	return ensureCachedWithClient(l.client, owner, shelf.Repo, b)
+}
+
+// lockDownload takes the download lock of a book and returns the unlock
+// function.
+func (l *serveLibrary) lockDownload(shelfName, id string) func() {
+	key := shelfName + "/" + id
+	l.downloadMu.Lock()
+	if l.downloads == nil {
+		l.downloads = map[string]*sync.Mutex{}
+	}
+	mu, ok := l.downloads[key]
+	if !ok {
+		mu = &sync.Mutex{}
+		l.downloads[key] = mu
+	}
+	l.downloadMu.Unlock()
+	mu.Lock()
+	return mu.Unlock
+}
+
+// CoverFile returns the catalog cover (fetched into the cache on first
+// use), an extracted thumbnail, or a generated placeholder.
+func (l *serveLibrary) CoverFile(shelfName string, b catalog.Book) (string, error) {
+	shelf, err := l.shelfConfig(shelfName)
+	if err != nil {
+		return "", err
+	}
+	if b.Cover != "" && !cacheMgr.HasCatalogCover(shelf.Repo, b.ID) {
+		owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+		if data, _, err := l.client.GetFileContent(owner, shelf.Repo, b.Cover, ""); err == nil && len(data) > 0 {
+			cacheMgr.StoreCatalogCover(shelf.Repo, b.ID, bytes.NewReader(data))
+		}
+	}
+	return cacheMgr.GetCoverPathOrPlaceholder(shelf.Repo, b), nil
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/web"
+)
+
+func TestServeLibrary_KeepsCatalogWhenReloadFails(t *testing.T) {
+	client := setupExport(t)
+	lib := newServeLibrary(client, cfg.Shelves, 0)
+
+	shelves, err := lib.Shelves()
+	if err != nil || len(shelves) != 1 || len(shelves[0].Books) != 1 {
+		t.Fatalf("first load: %+v, %v", shelves, err)
+	}
+
+	failing := &fakeGitHubClient{getFileContentFn: func(owner, repo, path, ref string) ([]byte, string, error) {
+		return nil, "", errors.New("offline")
+	}}
+	lib.client = failing
+	shelves, err = lib.Shelves()
+	if err != nil || len(shelves[0].Books) != 1 {
+		t.Errorf("after failed reload: %+v, %v", shelves, err)
+	}
+}
+
+func TestServeLibrary_BookFileUsesCache(t *testing.T) {
+	client := setupExport(t)
+	lib := newServeLibrary(client, cfg.Shelves, time.Minute)
+	shelves, err := lib.Shelves()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := lib.BookFile("books", shelves[0].Books[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "%PDF-1.4 test" {
+		t.Errorf("served %q", data)
+	}
+
+	cover, err := lib.CoverFile("books", shelves[0].Books[0])
+	if err != nil || cover != cacheMgr.CatalogCoverPath("shelf-books", "sicp") {
+		t.Errorf("cover = %q, %v", cover, err)
+	}
+	if _, err := lib.BookFile("other", shelves[0].Books[0]); err == nil {
+		t.Error("expected error for a shelf that is not served")
+	}
+}
+
+func TestServeLibrary_DownloadsDoNotBlockOtherBooks(t *testing.T) {
+	client := setupExport(t)
+	lib := newServeLibrary(client, cfg.Shelves, time.Minute)
+	shelves, err := lib.Shelves()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A long download of another book is in progress.
+	unlock := lib.lockDownload("books", "huge")
+	defer unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := lib.BookFile("books", shelves[0].Books[0])
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serving a cached book waited for another book's download")
+	}
+
+	go func() {
+		lib.lockDownload("books", "other")()
+		done <- nil
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("downloads of different books are serialized")
+	}
+}
+
+func TestServeLibrary_EditAndSync(t *testing.T) {
+	client := setupExport(t)
+	cfg.Defaults.JournalDir = t.TempDir()
+	lib := newServeLibrary(client, cfg.Shelves, time.Hour)
+
+	books, err := lib.Books()
+	if err != nil || len(books) != 1 || !books[0].IsCached {
+		t.Fatalf("Books() = %+v, %v", books, err)
+	}
+
+	err = lib.Edit("books", "sicp", web.Edit{Title: "Structure and Interpretation", Author: "Abelson", Year: 1996, Tags: []string{"lisp", "classic"}})
+	if err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	saved, _ := catalog.Parse(client.Repo("me", "shelf-books").Files["catalog.yml"])
+	if saved[0].Title != "Structure and Interpretation" || saved[0].Year != 1996 || len(saved[0].Tags) != 2 {
+		t.Errorf("catalog after edit = %+v", saved[0])
+	}
+	// The edit is visible without waiting for the refresh interval.
+	shelves, _ := lib.Shelves()
+	if shelves[0].Books[0].Year != 1996 {
+		t.Error("edit not reloaded")
+	}
+	if op, _ := undoJournal().Latest(); op == nil || op.Command != "edit-book" || len(op.Catalogs) != 1 {
+		t.Errorf("journal after edit = %+v", op)
+	}
+
+	client.PutAsset("me", "shelf-books", "library", "sicp.pdf", "old!")
+	synced, err := lib.Sync("books", "sicp")
+	if err != nil || !synced {
+		t.Fatalf("sync: %v, %v", synced, err)
+	}
+	if got := client.Assets("me", "shelf-books", "library")["sicp.pdf"]; string(got) != "%PDF-1.4 test" {
+		t.Errorf("uploaded asset = %q", got)
+	}
+	saved, _ = catalog.Parse(client.Repo("me", "shelf-books").Files["catalog.yml"])
+	if saved[0].Checksum.SHA256 == "" || saved[0].SizeBytes != int64(len("%PDF-1.4 test")) {
+		t.Errorf("catalog after sync = %+v", saved[0])
+	}
+	op, _ := undoJournal().Latest()
+	if op == nil || op.Command != "sync" || len(op.Catalogs) != 1 || len(op.Assets) != 2 {
+		t.Fatalf("journal after sync = %+v", op)
+	}
+	if op.Assets[0].Kind != journal.AssetDeleted || op.Assets[1].Kind != journal.AssetAdded || op.Assets[1].To.Asset != "sicp.pdf" {
+		t.Errorf("journal assets after sync = %+v", op.Assets)
+	}
+
+	if n, err := lib.SyncAll(); err != nil || n != 0 {
+		t.Errorf("SyncAll after sync = %d, %v; want nothing left", n, err)
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"localhost:8080": true,
+		"127.0.0.1:80":   true,
+		"[::1]:8080":     true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+	} {
+		if got := isLoopbackAddr(addr); got != want {
+			t.Errorf("isLoopbackAddr(%q) = %v", addr, got)
+		}
+	}
+}
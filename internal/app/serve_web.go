@@ -0,0 +1,220 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/cache"
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
+	"github.com/blackwell-systems/shelfctl/internal/web"
+)
+
+// serveLibrary also backs the serve --web page (web.Backend).
+
+// Books lists every served book with its cache state.
+func (l *serveLibrary) Books() ([]cache.IndexBook, error) {
+	shelves, err := l.Shelves()
+	if err != nil {
+		return nil, err
+	}
+	var out []cache.IndexBook
+	for _, s := range shelves {
+		shelf, err := l.shelfConfig(s.Name)
+		if err != nil {
+			continue
+		}
+		owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+		for _, b := range s.Books {
+			ib := cache.IndexBook{Book: b, ShelfName: s.Name, Repo: shelf.Repo}
+			if cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset) {
+				ib.IsCached = true
+				ib.FilePath = cacheMgr.Path(owner, shelf.Repo, b.ID, b.Source.Asset)
+			}
+			out = append(out, ib)
+		}
+	}
+	return out, nil
+}
+
+// book finds a served book by shelf and ID.
+func (l *serveLibrary) book(shelfName, id string) (*config.ShelfConfig, catalog.Book, error) {
+	shelf, err := l.shelfConfig(shelfName)
+	if err != nil {
+		return nil, catalog.Book{}, err
+	}
+	shelves, err := l.Shelves()
+	if err != nil {
+		return nil, catalog.Book{}, err
+	}
+	for _, s := range shelves {
+		if s.Name != shelfName {
+			continue
+		}
+		if b := catalog.ByID(s.Books, id); b != nil {
+			return shelf, *b, nil
+		}
+	}
+	return nil, catalog.Book{}, fmt.Errorf("book %q not found on shelf %q", id, shelfName)
+}
+
+// Download caches a book, reporting progress.
+func (l *serveLibrary) Download(shelfName, id string, progress func(done, total int64)) error {
+	shelf, b, err := l.book(shelfName, id)
+	if err != nil {
+		return err
+	}
+	owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+	if cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset) {
+		return nil
+	}
+	defer l.lockDownload(shelfName, b.ID)()
+	_, err = cacheBookWithProgress(l.client, owner, shelf.Repo, b, progress)
+	return err
+}
+
+// Sync uploads a cached book that was modified locally (annotations,
+// highlights), replacing the release asset and updating the catalog
+// checksum, like `shelfctl sync`.
+func (l *serveLibrary) Sync(shelfName, id string) (bool, error) {
+	shelf, b, err := l.book(shelfName, id)
+	if err != nil {
+		return false, err
+	}
+	return l.syncBook(shelf, b)
+}
+
+// SyncAll syncs every modified cached book on the served shelves.
+func (l *serveLibrary) SyncAll() (int, error) {
+	shelves, err := l.Shelves()
+	if err != nil {
+		return 0, err
+	}
+	synced := 0
+	for _, s := range shelves {
+		shelf, err := l.shelfConfig(s.Name)
+		if err != nil {
+			continue
+		}
+		for _, b := range s.Books {
+			if !cacheMgr.Exists(shelf.EffectiveOwner(cfg.GitHub.Owner), shelf.Repo, b.ID, b.Source.Asset) {
+				continue
+			}
+			ok, err := l.syncBook(shelf, b)
+			if err != nil {
+				return synced, fmt.Errorf("%s: %w", b.ID, err)
+			}
+			if ok {
+				synced++
+			}
+		}
+	}
+	return synced, nil
+}
+
+func (l *serveLibrary) syncBook(shelf *config.ShelfConfig, b catalog.Book) (bool, error) {
+	if err := requireWritable(shelf); err != nil {
+		return false, err
+	}
+	owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+	if !cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset) {
+		return false, fmt.Errorf("book %s is not cached", b.ID)
+	}
+	path := cacheMgr.Path(owner, shelf.Repo, b.ID, b.Source.Asset)
+	sha, size, err := cacheMgr.SHA256(owner, shelf.Repo, b.ID, b.Source.Asset)
+	if err != nil {
+		return false, err
+	}
+	if sha == b.Checksum.SHA256 {
+		return false, nil
+	}
+
+	rel, err := l.client.EnsureRelease(owner, shelf.Repo, b.Source.Release)
+	if err != nil {
+		return false, fmt.Errorf("release %q: %w", b.Source.Release, err)
+	}
+	op := beginOp("sync", "sync "+b.ID)
+	defer finishOp(op)
+	if err := deleteBookAssets(op, l.client, owner, shelf.Repo, rel.ID, &b); err != nil {
+		return false, fmt.Errorf("deleting old asset: %w", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	manifest, err := parts.Upload(l.client, owner, shelf.Repo, rel.ID, b.Source.Asset, f, size, cfg.Defaults.EffectivePartSize())
+	_ = f.Close()
+	if err != nil {
+		return false, fmt.Errorf("upload: %w", err)
+	}
+	uploaded := b.Source
+	uploaded.Parts = manifest
+	for _, loc := range journal.Locations(owner, shelf.Repo, uploaded) {
+		op.Added(loc)
+	}
+
+	err = l.updateBook(op, shelf, b.ID, fmt.Sprintf("sync: update %s with local changes", b.ID), func(e *catalog.Book) {
+		e.Checksum.SHA256 = sha
+		e.SizeBytes = size
+		e.Encryption = shelf.EncryptionScheme()
+		e.Source.Parts = manifest
+	})
+	if err == nil {
+		_ = cacheMgr.MarkSynced(owner, shelf.Repo, b.ID, b.Source.Asset, sha)
+	}
+	return err == nil, err
+}
+
+// Edit updates a book's title, author, year and tags.
+func (l *serveLibrary) Edit(shelfName, id string, e web.Edit) error {
+	shelf, _, err := l.book(shelfName, id)
+	if err != nil {
+		return err
+	}
+	op := beginOp("edit-book", "edit "+id)
+	defer finishOp(op)
+	return l.updateBook(op, shelf, id, fmt.Sprintf("edit: update metadata for %s", id), func(b *catalog.Book) {
+		b.Title = e.Title
+		b.Author = e.Author
+		b.Year = e.Year
+		b.Tags = e.Tags
+	})
+}
+
+// updateBook applies fn to one catalog entry, commits the catalog,
+// records it in op and drops the in-memory copy so the change shows up
+// right away.
+func (l *serveLibrary) updateBook(op *journal.Op, shelf *config.ShelfConfig, id, msg string, fn func(*catalog.Book)) error {
+	if err := requireWritable(shelf); err != nil {
+		return err
+	}
+	owner, catalogPath := shelf.EffectiveOwner(cfg.GitHub.Owner), shelf.EffectiveCatalogPath()
+	mgr := catalog.NewManager(l.client, owner, shelf.Repo, catalogPath)
+	books, err := mgr.Load()
+	if err != nil {
+		return err
+	}
+	before, _ := catalog.Marshal(books)
+	b := catalog.ByID(books, id)
+	if b == nil {
+		return fmt.Errorf("book %q not found in catalog", id)
+	}
+	fn(b)
+	if err := mgr.Save(books, msg); err != nil {
+		return err
+	}
+	after, _ := catalog.Marshal(books)
+	op.Catalog(owner, shelf.Repo, catalogPath, before, after)
+	return l.Reload()
+}
+
+// Reload makes the next Shelves call read the catalogs again.
+func (l *serveLibrary) Reload() error {
+	l.mu.Lock()
+	l.loadedAt = time.Time{}
+	l.mu.Unlock()
+	return nil
+}
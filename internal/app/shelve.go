@@ -14,7 +14,11 @@ import (
 	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/github"
 	"github.com/blackwell-systems/shelfctl/internal/ingest"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
+	"github.com/blackwell-systems/shelfctl/internal/releases"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/blackwell-systems/shelfctl/internal/util"
 	"github.com/fatih/color"
@@ -36,15 +40,22 @@ type shelveParams struct {
 	useSHA12   bool
 	force      bool
 	cache      bool
+	// op is the journal entry the uploads and catalog commit are recorded in.
+	op *journal.Op
+	// policy picks the release of each book when --release is not given;
+	// releases holds the releases ensured so far, by tag.
+	policy   config.ReleasePolicy
+	releases map[string]*github.Release
 }
 
 type ingestedFile struct {
-	tmpPath     string
-	sha256      string
-	size        int64
-	format      string
-	srcName     string
-	pdfMetadata *ingest.PDFMetadata
+	tmpPath      string
+	sha256       string
+	size         int64
+	format       string
+	srcName      string
+	pdfMetadata  *ingest.PDFMetadata
+	epubMetadata *ingest.EPUBMetadata
 }
 
 func newShelveCmd() *cobra.Command {
@@ -75,7 +86,7 @@ Examples:
 	}
 
 	cmd.Flags().StringVar(&params.shelfName, "shelf", "", "Target shelf name (required in non-interactive mode)")
-	cmd.Flags().StringVar(&params.releaseTag, "release", "", "Target release tag (default: shelf's default_release)")
+	cmd.Flags().StringVar(&params.releaseTag, "release", "", "Target release tag (default: picked by the shelf's release policy)")
 	cmd.Flags().StringVar(&params.bookID, "id", "", "Book ID (default: prompt / slugified title)")
 	cmd.Flags().BoolVar(&params.useSHA12, "id-sha12", false, "Use first 12 chars of sha256 as ID")
 	cmd.Flags().StringVar(&params.title, "title", "", "Book title (prompt if not provided)")
@@ -113,7 +124,9 @@ func runShelve(cmd *cobra.Command, args []string, params *shelveParams) error {
 	owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
 	if params.releaseTag == "" {
 		params.releaseTag = shelf.EffectiveRelease(cfg.Defaults.Release)
+		params.policy = shelf.EffectiveReleasePolicy(cfg.Defaults.Releases)
 	}
+	params.releases = map[string]*github.Release{}
 
 	// Step 3: Create catalog manager
 	catalogPath := shelf.EffectiveCatalogPath()
@@ -123,20 +136,17 @@ func runShelve(cmd *cobra.Command, args []string, params *shelveParams) error {
 	if err != nil {
 		return err
 	}
+	before, _ := catalog.Marshal(existingBooks)
+	params.op = beginOp("shelve", "add books")
+	defer finishOp(params.op)
 
-	// Step 4: Ensure release once
-	rel, err := gh.EnsureRelease(owner, shelf.Repo, params.releaseTag)
-	if err != nil {
-		return fmt.Errorf("ensuring release: %w", err)
-	}
-
-	// Step 5: Process each file
+	// Step 4: Process each file
 	var newBooks []catalog.Book
 	successCount := 0
 	failCount := 0
 
 	for i, input := range inputs {
-		book, err := processSingleFile(cmd, params, input, i+1, len(inputs), owner, shelf, rel, &existingBooks, useTUIWorkflow)
+		book, err := processSingleFile(cmd, params, input, i+1, len(inputs), owner, shelf, &existingBooks, useTUIWorkflow)
 		if err != nil {
 			warn("Failed to process %s: %v", input, err)
 			failCount++
@@ -148,11 +158,21 @@ func runShelve(cmd *cobra.Command, args []string, params *shelveParams) error {
 		successCount++
 	}
 
-	// Step 6: Batch commit catalog and README
+	ids := make([]string, len(newBooks))
+	for i, b := range newBooks {
+		ids[i] = b.ID
+	}
+	params.op.Summary = "add " + bookList(ids)
+
+	// Step 5: Batch commit catalog and README
 	if successCount > 0 {
 		if err := batchCommitCatalog(cmd, catalogMgr, owner, shelf.Repo, existingBooks, newBooks, params.noPush); err != nil {
 			return err
 		}
+		if !params.noPush {
+			after, _ := catalog.Marshal(existingBooks)
+			params.op.Catalog(owner, shelf.Repo, catalogPath, before, after)
+		}
 	}
 
 	// Print summary
@@ -176,7 +196,7 @@ func runShelve(cmd *cobra.Command, args []string, params *shelveParams) error {
 // processSingleFile handles the complete workflow for one file in a batch.
 // Returns the catalog book entry or an error.
 func processSingleFile(cmd *cobra.Command, params *shelveParams, input string, fileNum, totalFiles int,
-	owner string, shelf *config.ShelfConfig, rel *github.Release, existingBooks *[]catalog.Book, useTUI bool) (*catalog.Book, error) {
+	owner string, shelf *config.ShelfConfig, existingBooks *[]catalog.Book, useTUI bool) (*catalog.Book, error) {
 
 	// Resolve and ingest source
 	src, err := ingest.Resolve(input, cfg.GitHub.Token, cfg.GitHub.APIBase)
@@ -196,23 +216,46 @@ func processSingleFile(cmd *cobra.Command, params *shelveParams, input string, f
 		return nil, fmt.Errorf("metadata: %w", err)
 	}
 
-	// Check duplicates
+	// Check duplicates, and that the ID is not taken by a trashed book
 	if err := checkDuplicates(*existingBooks, ingested.sha256, params.force); err != nil {
 		return nil, err
 	}
+	if err := trash.CheckID(*existingBooks, metadata.bookID); err != nil {
+		return nil, err
+	}
+
+	// Handle asset collisions, including with the parts of large files
+	plan := parts.Plan(metadata.assetName, ingested.size, cfg.Defaults.EffectivePartSize())
+	names := []string{metadata.assetName}
+	for _, p := range plan {
+		names = append(names, p.Asset)
+	}
 
-	// Handle asset collisions
-	if err := handleAssetCollision(owner, shelf.Repo, rel.ID, metadata.assetName, params.releaseTag, params.force); err != nil {
+	// Pick the release, rolling over to a new one if the policy says so
+	releaseTag, rel, err := pickRelease(params, owner, shelf.Repo, metadata, max(1, len(plan)), ingested.size)
+	if err != nil {
 		return nil, err
 	}
 
+	for _, name := range names {
+		if err := handleAssetCollision(owner, shelf.Repo, rel.ID, name, releaseTag, params.force, params.op); err != nil {
+			return nil, err
+		}
+	}
+
 	// Upload asset
-	if err := uploadAsset(cmd, owner, shelf.Repo, rel.ID, metadata.assetName, ingested.tmpPath, ingested.size, params.releaseTag); err != nil {
+	manifest, err := uploadAsset(cmd, owner, shelf.Repo, rel.ID, metadata.assetName, ingested.tmpPath, ingested.size, releaseTag)
+	if err != nil {
 		return nil, fmt.Errorf("upload: %w", err)
 	}
 
 	// Build catalog entry
-	book := buildCatalogEntry(metadata, ingested, owner, shelf.Repo, params.releaseTag)
+	book := buildCatalogEntry(metadata, ingested, owner, shelf.Repo, releaseTag)
+	book.Encryption = shelf.EncryptionScheme()
+	book.Source.Parts = manifest
+	for _, name := range book.Source.AssetNames() {
+		params.op.Added(journal.Location{Owner: owner, Repo: shelf.Repo, Release: releaseTag, Asset: name})
+	}
 
 	// Cache locally if requested
 	if params.cache {
@@ -315,12 +358,20 @@ func ingestFile(src *ingest.Source, input string) (*ingestedFile, error) {
 		srcName: src.Name,
 	}
 
-	// Extract PDF metadata if it's a PDF
-	if ext == "pdf" {
+	// Extract embedded metadata for PDFs and EPUBs.
+	// Silently ignore errors - not all files have metadata
+	switch ext {
+	case "pdf":
 		if pdfMeta, err := ingest.ExtractPDFMetadata(tmpPath); err == nil {
 			result.pdfMetadata = pdfMeta
+			if pdfMeta.Encrypted {
+				warn("%s is encrypted; title and author must be entered manually", src.Name)
+			}
+		}
+	case "epub":
+		if epubMeta, err := ingest.ExtractEPUBMetadata(tmpPath); err == nil {
+			result.epubMetadata = epubMeta
 		}
-		// Silently ignore errors - not all PDFs have metadata
 	}
 
 	return result, nil
@@ -338,6 +389,8 @@ type bookMetadata struct {
 func collectMetadata(cmd *cobra.Command, params *shelveParams, srcName string, ingested *ingestedFile, useTUI bool, fileNum, totalFiles int) (*bookMetadata, error) {
 	defaultTitle := strings.TrimSuffix(srcName, filepath.Ext(srcName))
 	defaultAuthor := ""
+	defaultYear := params.year
+	defaultTags := params.tagsCSV
 
 	// Use PDF metadata if available
 	if ingested.pdfMetadata != nil {
@@ -349,6 +402,22 @@ func collectMetadata(cmd *cobra.Command, params *shelveParams, srcName string, i
 		}
 	}
 
+	// Use EPUB package metadata if available
+	if ingested.epubMetadata != nil {
+		if ingested.epubMetadata.Title != "" {
+			defaultTitle = ingested.epubMetadata.Title
+		}
+		if author := ingested.epubMetadata.Author(); author != "" {
+			defaultAuthor = author
+		}
+		if defaultYear == 0 {
+			defaultYear = ingested.epubMetadata.Year()
+		}
+		if defaultTags == "" {
+			defaultTags = strings.Join(ingested.epubMetadata.Tags(), ",")
+		}
+	}
+
 	defaultID := slugify(defaultTitle)
 
 	useTUIForm := useTUI && params.title == "" && params.bookID == "" && !params.useSHA12
@@ -360,11 +429,14 @@ func collectMetadata(cmd *cobra.Command, params *shelveParams, srcName string, i
 	}
 
 	var title, author, bookID, tagsCSV string
+	year := params.year
 	if useTUIForm {
 		formData, err := tui.RunShelveForm(tui.ShelveFormDefaults{
 			Filename: displayName,
 			Title:    defaultTitle,
 			Author:   defaultAuthor,
+			Year:     defaultYear,
+			Tags:     defaultTags,
 			ID:       defaultID,
 		})
 		if err != nil {
@@ -373,6 +445,7 @@ func collectMetadata(cmd *cobra.Command, params *shelveParams, srcName string, i
 
 		title = formData.Title
 		author = formData.Author
+		year = formData.Year
 		tagsCSV = formData.Tags
 		bookID = formData.ID
 		// Use form's cache checkbox value (defaults to true in TUI)
@@ -380,12 +453,18 @@ func collectMetadata(cmd *cobra.Command, params *shelveParams, srcName string, i
 	} else {
 		title = params.title
 		author = params.author
-		tagsCSV = params.tagsCSV
+		tagsCSV = defaultTags
 		bookID = params.bookID
 
 		if title == "" {
 			title = promptOrDefault("Title", defaultTitle)
 		}
+		if author == "" {
+			author = defaultAuthor
+		}
+		if year == 0 {
+			year = defaultYear
+		}
 	}
 
 	// Determine asset filename
@@ -429,18 +508,42 @@ func collectMetadata(cmd *cobra.Command, params *shelveParams, srcName string, i
 		bookID:    bookID,
 		title:     title,
 		author:    author,
-		year:      params.year,
+		year:      year,
 		tags:      tags,
 		assetName: assetName,
 	}, nil
 }
 
+// pickRelease returns the release a book of n assets and size bytes goes
+// into under params.policy, creating it if needed.
+func pickRelease(params *shelveParams, owner, repo string, metadata *bookMetadata, n int, size int64) (string, *github.Release, error) {
+	tag := releases.Tag(params.policy, params.releaseTag, metadata.year, metadata.tags)
+	tag, err := releases.Pick(gh, owner, repo, params.policy, tag, n, size)
+	if err != nil {
+		return "", nil, fmt.Errorf("picking release: %w", err)
+	}
+	if rel, ok := params.releases[tag]; ok {
+		return tag, rel, nil
+	}
+	rel, err := gh.EnsureRelease(owner, repo, tag)
+	if err != nil {
+		return "", nil, fmt.Errorf("ensuring release: %w", err)
+	}
+	if tag != params.releaseTag {
+		fmt.Printf("Using release %s\n", tag)
+	}
+	params.releases[tag] = rel
+	return tag, rel, nil
+}
+
+// checkDuplicates fails if a book on the shelf has the same content. Books
+// in the trash do not count.
 func checkDuplicates(existingBooks []catalog.Book, sha256 string, force bool) error {
 	if force {
 		return nil
 	}
 
-	for _, b := range existingBooks {
+	for _, b := range catalog.Live(existingBooks) {
 		if b.Checksum.SHA256 == sha256 {
 			warn("File with same SHA256 already exists: %s (%s)", b.ID, b.Title)
 			fmt.Printf("Use --force to add anyway, or skip.\n")
@@ -450,7 +553,7 @@ func checkDuplicates(existingBooks []catalog.Book, sha256 string, force bool) er
 	return nil
 }
 
-func handleAssetCollision(owner, repo string, releaseID int64, assetName, releaseTag string, force bool) error {
+func handleAssetCollision(owner, repo string, releaseID int64, assetName, releaseTag string, force bool, op *journal.Op) error {
 	existingAsset, err := gh.FindAsset(owner, repo, releaseID, assetName)
 	if err != nil {
 		return fmt.Errorf("checking existing assets: %w", err)
@@ -467,21 +570,31 @@ func handleAssetCollision(owner, repo string, releaseID int64, assetName, releas
 	}
 
 	warn("Deleting existing asset %q", assetName)
+	replaced := &catalog.Book{Source: catalog.Source{Release: releaseTag, Asset: assetName}}
+	copyName := keepCopy(op, gh, owner, repo, replaced, existingAsset.ID)
 	if err := gh.DeleteAsset(owner, repo, existingAsset.ID); err != nil {
 		return fmt.Errorf("deleting existing asset: %w", err)
 	}
+	op.Deleted(assetLocation(owner, repo, replaced), copyName)
 	return nil
 }
 
-func uploadAsset(cmd *cobra.Command, owner, repo string, releaseID int64, assetName, tmpPath string, size int64, releaseTag string) error {
+// uploadAsset uploads the file at tmpPath, in parts if it is larger than
+// the configured part size, and returns the parts (nil for one asset).
+func uploadAsset(cmd *cobra.Command, owner, repo string, releaseID int64, assetName, tmpPath string, size int64, releaseTag string) ([]catalog.Part, error) {
 	uploadFile, err := os.Open(tmpPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() { _ = uploadFile.Close() }()
 
+	partSize := cfg.Defaults.EffectivePartSize()
+	if n := len(parts.Plan(assetName, size, partSize)); n > 0 && tui.ShouldUseTUI(cmd) {
+		fmt.Printf("%s is larger than %s, uploading it in %d parts\n", assetName, humanBytes(partSize), n)
+	}
+
 	// Use progress bar in TTY mode
-	var asset *github.Asset
+	var manifest []catalog.Part
 	if util.IsTTY() && tui.ShouldUseTUI(cmd) {
 		progressCh := make(chan int64, 50)
 		errCh := make(chan error, 1)
@@ -494,9 +607,9 @@ func uploadAsset(cmd *cobra.Command, owner, repo string, releaseID int64, assetN
 			// Send initial progress to unblock UI
 			progressCh <- 0
 			pr := tui.NewProgressReader(uploadFile, size, progressCh)
-			a, err := gh.UploadAsset(owner, repo, releaseID, assetName, pr, size, "application/octet-stream")
+			m, err := parts.Upload(gh, owner, repo, releaseID, assetName, pr, size, partSize)
 			close(progressCh)
-			asset = a
+			manifest = m
 			errCh <- err
 		}()
 
@@ -505,26 +618,30 @@ func uploadAsset(cmd *cobra.Command, owner, repo string, releaseID int64, assetN
 		if err := tui.ShowProgress(label, size, progressCh); err != nil {
 			// User cancelled - wait for goroutine to exit
 			<-errCh
-			return err
+			return nil, err
 		}
 
 		// Get result
 		if err := <-errCh; err != nil {
-			return fmt.Errorf("uploading: %w", err)
+			return nil, fmt.Errorf("uploading: %w", err)
 		}
 	} else {
 		// Non-interactive mode: just print and upload
 		fmt.Printf("Uploading %s → %s/%s/%s …\n", assetName, owner, repo, releaseTag)
-		asset, err = gh.UploadAsset(owner, repo, releaseID, assetName, uploadFile, size, "application/octet-stream")
+		manifest, err = parts.Upload(gh, owner, repo, releaseID, assetName, uploadFile, size, partSize)
 		if err != nil {
-			return fmt.Errorf("uploading: %w", err)
+			return nil, fmt.Errorf("uploading: %w", err)
 		}
 	}
 
 	if tui.ShouldUseTUI(cmd) {
-		ok("Uploaded: %s", asset.BrowserDownloadURL)
+		if len(manifest) > 0 {
+			ok("Uploaded %d parts to %s/%s@%s", len(manifest), owner, repo, releaseTag)
+		} else {
+			ok("Uploaded %s to %s/%s@%s", assetName, owner, repo, releaseTag)
+		}
 	}
-	return nil
+	return manifest, nil
 }
 
 func cacheUploadedFile(cmd *cobra.Command, tmpPath, owner, repo, bookID, assetFilename, expectedSHA256, format string) error {
@@ -545,11 +662,17 @@ func cacheUploadedFile(cmd *cobra.Command, tmpPath, owner, repo, bookID, assetFi
 }
 
 func buildCatalogEntry(metadata *bookMetadata, ingested *ingestedFile, owner, repo, releaseTag string) catalog.Book {
+	pages := 0
+	if ingested.pdfMetadata != nil {
+		pages = ingested.pdfMetadata.PageCount
+	}
+
 	return catalog.Book{
 		ID:        metadata.bookID,
 		Title:     metadata.title,
 		Author:    metadata.author,
 		Year:      metadata.year,
+		Pages:     pages,
 		Tags:      metadata.tags,
 		Format:    ingested.format,
 		SizeBytes: ingested.size,
@@ -603,7 +726,7 @@ func updateREADME(cmd *cobra.Command, owner, repo string, books []catalog.Book,
 	}
 
 	originalContent := string(readmeData)
-	readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(books))
+	readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(catalog.Live(books)))
 	readmeContent = operations.AppendToShelfREADME(readmeContent, book)
 
 	// Only commit if content actually changed
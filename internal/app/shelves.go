@@ -27,16 +27,23 @@ type shelfStatus struct {
 func newShelvesCmd() *cobra.Command {
 	var fix bool
 	var tableMode bool
+	var capacity bool
 
 	cmd := &cobra.Command{
 		Use:   "shelves",
 		Short: "Validate all configured shelves",
-		Long:  "Checks that each shelf repo exists, has a catalog.yml, and has the required release.",
+		Long: `Checks that each shelf repo exists, has a catalog.yml, and has the required release.
+
+With --capacity, lists the assets and bytes in each release of each shelf
+instead, measured against the limits of the shelf's release policy.`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			if len(cfg.Shelves) == 0 {
 				warn("No shelves configured. Run: shelfctl init --repo shelf-<topic> --name <topic>")
 				return nil
 			}
+			if capacity {
+				return renderShelfCapacity(gh, cfg.Shelves)
+			}
 
 			// Collect all shelf statuses
 			statuses := make([]shelfStatus, 0, len(cfg.Shelves))
@@ -75,6 +82,8 @@ func newShelvesCmd() *cobra.Command {
 
 	cmd.Flags().BoolVar(&fix, "fix", false, "Automatically repair missing catalog.yml or release")
 	cmd.Flags().BoolVar(&tableMode, "table", false, "Display as formatted table (default: simple list)")
+	cmd.Flags().BoolVar(&capacity, "capacity", false, "Show assets and bytes per release")
+	cmd.AddCommand(newShelvesDiscoverCmd())
 	return cmd
 }
 
@@ -121,7 +130,7 @@ func collectShelfStatus(shelf config.ShelfConfig, fix bool) shelfStatus {
 		status.catalogOK = true
 		// Count books
 		if books, err := catalog.Parse(catalogData); err == nil {
-			status.bookCount = len(books)
+			status.bookCount = len(catalog.Live(books))
 		}
 	}
 
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/releases"
+	"github.com/fatih/color"
+)
+
+// renderShelfCapacity prints the assets and bytes in each release of the
+// given shelves, measured against the shelves' release policy limits.
+func renderShelfCapacity(c releases.Lister, shelves []config.ShelfConfig) error {
+	gray := color.New(color.FgHiBlack).SprintFunc()
+	bold := color.New(color.Bold).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	failed := 0
+	for i, shelf := range shelves {
+		if i > 0 {
+			fmt.Println()
+		}
+		owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+		policy := shelf.EffectiveReleasePolicy(cfg.Defaults.Releases)
+
+		fmt.Printf("%s %s\n", bold(shelf.Name), gray(fmt.Sprintf("(%s/%s%s)", owner, shelf.Repo, formatLimits(policy))))
+		usage, err := releases.Capacity(c, owner, shelf.Repo)
+		if err != nil {
+			warn("%s: %v", shelf.Name, err)
+			failed++
+			continue
+		}
+		if len(usage) == 0 {
+			fmt.Println(gray("  no releases"))
+			continue
+		}
+
+		width := 0
+		for _, u := range usage {
+			width = max(width, len(u.Tag))
+		}
+		for _, u := range usage {
+			line := fmt.Sprintf("  %-*s  %5d assets  %10s", width, u.Tag, u.Assets, humanBytes(u.Bytes))
+			if pct, ok := fillPercent(policy, u); ok {
+				line += fmt.Sprintf("  %3d%%", pct)
+			}
+			if u.Full(policy) {
+				line += "  " + yellow("full")
+			}
+			fmt.Println(line)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not measure %d shelves", failed)
+	}
+	return nil
+}
+
+// formatLimits describes a release policy's limits, if it has any.
+func formatLimits(p config.ReleasePolicy) string {
+	var limits []string
+	if p.MaxAssets > 0 {
+		limits = append(limits, fmt.Sprintf("%d assets", p.MaxAssets))
+	}
+	if p.MaxBytes > 0 {
+		limits = append(limits, humanBytes(p.MaxBytes))
+	}
+	if len(limits) == 0 {
+		return ""
+	}
+	return ", limit " + strings.Join(limits, " / ") + " per release"
+}
+
+// fillPercent returns how full a release is against the nearer of the
+// policy's limits, or false if the policy has none.
+func fillPercent(p config.ReleasePolicy, u releases.Usage) (int, bool) {
+	var frac float64
+	if p.MaxAssets > 0 {
+		frac = max(frac, float64(u.Assets)/float64(p.MaxAssets))
+	}
+	if p.MaxBytes > 0 {
+		frac = max(frac, float64(u.Bytes)/float64(p.MaxBytes))
+	}
+	if p.MaxAssets == 0 && p.MaxBytes == 0 {
+		return 0, false
+	}
+	return int(frac * 100), true
+}
@@ -0,0 +1,256 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	ghclient "github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/tui"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// repoLister is the part of the GitHub client that discover needs on top
+// of GitHubClient.
+type repoLister interface {
+	GitHubClient
+	ListRepos(owner string) ([]ghclient.Repo, error)
+}
+
+type discoverOptions struct {
+	owner     string
+	add       []string
+	all       bool
+	readOnly  bool
+	topicOnly bool
+	profile   string
+	useTUI    bool
+}
+
+// discoveredShelf is a repo that looks like a shelf.
+type discoveredShelf struct {
+	repo      ghclient.Repo
+	shelfName string
+	books     int // -1 if the catalog could not be read
+	installed bool
+}
+
+func newShelvesDiscoverCmd() *cobra.Command {
+	var opts discoverOptions
+
+	cmd := &cobra.Command{
+		Use:   "discover",
+		Short: "Find shelf repos owned by a user or organization",
+		Long: `List the repos of a user or organization and find the ones that are
+shelves: repos tagged with the '` + ghclient.ShelfTopic + `' topic or holding a catalog.yml.
+
+Found shelves can be added to your config: pick them interactively, name them
+with --add or take all of them with --all. Repos created with
+'shelfctl init --create-repo' are tagged automatically.
+
+With --profile, the account or host of that profile is searched and the
+shelves are added with it.
+
+Probing every repo for a catalog takes one request per repo; use
+--topic-only on large organizations to look at tagged repos only.`,
+		Example: `  shelfctl shelves discover
+  shelfctl shelves discover --owner my-team
+  shelfctl shelves discover --owner my-team --add shelf-papers,shelf-talks
+  shelfctl shelves discover --owner my-team --all --read-only
+  shelfctl shelves discover --profile work --owner platform`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.useTUI = tui.ShouldUseTUI(cmd) && len(opts.add) == 0 && !opts.all
+			return runShelvesDiscover(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.owner, "owner", "", "User or organization to search (default: github.owner)")
+	cmd.Flags().StringSliceVar(&opts.add, "add", nil, "Add these repos as shelves (comma-separated repo names)")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Add every discovered shelf")
+	cmd.Flags().BoolVar(&opts.readOnly, "read-only", false, "Add the shelves read-only")
+	cmd.Flags().StringVar(&opts.profile, "profile", "", "Search the account or host of this profile")
+	cmd.Flags().BoolVar(&opts.topicOnly, "topic-only", false, "Only consider repos tagged with the "+ghclient.ShelfTopic+" topic")
+	return cmd
+}
+
+func runShelvesDiscover(opts discoverOptions) error {
+	client, _, err := profileClient(opts.profile)
+	if err != nil {
+		return err
+	}
+	return runShelvesDiscoverWithClient(opts, client)
+}
+
+func runShelvesDiscoverWithClient(opts discoverOptions, client repoLister) error {
+	account, err := cfg.Profile(opts.profile)
+	if err != nil {
+		return err
+	}
+	owner := opts.owner
+	if owner == "" {
+		owner = account.Owner
+	}
+	if owner == "" {
+		owner = cfg.GitHub.Owner
+	}
+	if owner == "" {
+		return fmt.Errorf("--owner is required (or set github.owner in config)")
+	}
+
+	header("Searching %s …", owner)
+	repos, err := client.ListRepos(owner)
+	if err != nil {
+		return fmt.Errorf("listing repos of %s: %w", owner, err)
+	}
+	found := discoverShelves(client, owner, repos, opts.topicOnly)
+	if len(found) == 0 {
+		warn("No shelves found among %d repo(s) of %s", len(repos), owner)
+		return nil
+	}
+	renderDiscovered(found)
+
+	var picked []discoveredShelf
+	switch {
+	case opts.all:
+		for _, d := range found {
+			if !d.installed {
+				picked = append(picked, d)
+			}
+		}
+	case len(opts.add) > 0:
+		for _, name := range opts.add {
+			d := findDiscovered(found, name)
+			if d == nil {
+				return fmt.Errorf("%s/%s is not a discovered shelf", owner, name)
+			}
+			if d.installed {
+				warn("%s is already in your config — skipping", d.repo.Name)
+				continue
+			}
+			picked = append(picked, *d)
+		}
+	case opts.useTUI:
+		options := make([]tui.RepoOption, len(found))
+		for i, d := range found {
+			options[i] = tui.RepoOption{
+				Repo: d.repo.Name, Shelf: d.shelfName, Books: d.books,
+				Private: d.repo.Private, Tagged: d.repo.HasTopic(ghclient.ShelfTopic), Installed: d.installed,
+			}
+		}
+		selected, err := tui.RunRepoPicker(options)
+		if err != nil {
+			return err
+		}
+		for _, opt := range selected {
+			picked = append(picked, *findDiscovered(found, opt.Repo))
+		}
+	default:
+		fmt.Println()
+		fmt.Println("Add shelves with --add <repo>[,<repo>…] or --all")
+		return nil
+	}
+	if len(picked) == 0 {
+		return nil
+	}
+
+	readOnly := opts.readOnly
+	if account.Token == "" && !readOnly {
+		// Without a token nothing but read-only shelves can be used.
+		readOnly = true
+		warn("No GitHub token: adding the shelves read-only")
+	}
+	fmt.Println()
+	for _, d := range picked {
+		name := d.shelfName
+		if cfg.ShelfByName(name) != nil {
+			name = owner + "-" + name
+			if cfg.ShelfByName(name) != nil {
+				warn("A shelf named %q is already in your config — skipping %s", d.shelfName, d.repo.Name)
+				continue
+			}
+		}
+		cfg.Shelves = append(cfg.Shelves, config.ShelfConfig{
+			Name:     name,
+			Owner:    owner,
+			Repo:     d.repo.Name,
+			ReadOnly: readOnly,
+			Profile:  opts.profile,
+		})
+		ok("Added shelf %s (%s/%s)", name, owner, d.repo.Name)
+	}
+	return config.Save(cfg)
+}
+
+// discoverShelves picks the shelf repos out of repos and counts their books.
+func discoverShelves(client GitHubClient, owner string, repos []ghclient.Repo, topicOnly bool) []discoveredShelf {
+	var found []discoveredShelf
+	for _, r := range repos {
+		tagged := r.HasTopic(ghclient.ShelfTopic)
+		if topicOnly && !tagged {
+			continue
+		}
+		d := discoveredShelf{repo: r, shelfName: defaultShelfName(r.Name), books: -1}
+		data, _, err := client.GetFileContent(owner, r.Name, "catalog.yml", "")
+		if err == nil {
+			if books, err := catalog.Parse(data); err == nil {
+				d.books = len(catalog.Live(books))
+			}
+		}
+		if !tagged && d.books < 0 {
+			continue
+		}
+		for _, s := range cfg.Shelves {
+			if strings.EqualFold(s.EffectiveOwner(cfg.GitHub.Owner), owner) && strings.EqualFold(s.Repo, r.Name) {
+				d.installed = true
+				d.shelfName = s.Name
+			}
+		}
+		found = append(found, d)
+	}
+	return found
+}
+
+func findDiscovered(found []discoveredShelf, repo string) *discoveredShelf {
+	for i := range found {
+		if strings.EqualFold(found[i].repo.Name, repo) {
+			return &found[i]
+		}
+	}
+	return nil
+}
+
+// defaultShelfName derives a shelf name from a repo: shelf-<name> → <name>.
+func defaultShelfName(repo string) string {
+	if name := strings.TrimPrefix(repo, "shelf-"); name != "" {
+		return name
+	}
+	return repo
+}
+
+func renderDiscovered(found []discoveredShelf) {
+	fmt.Println()
+	fmt.Printf("  %s %s %s %s\n", padRight("REPO", 28), padRight("SHELF", 18), padRight("BOOKS", 10), "STATUS")
+	for _, d := range found {
+		books := color.YellowString("no catalog")
+		if d.books >= 0 {
+			books = formatBookCount(d.books)
+		}
+		var notes []string
+		if d.installed {
+			notes = append(notes, color.GreenString("added"))
+		}
+		if d.repo.HasTopic(ghclient.ShelfTopic) {
+			notes = append(notes, "tagged")
+		}
+		if d.repo.Private {
+			notes = append(notes, "private")
+		}
+		if d.repo.Archived {
+			notes = append(notes, "archived")
+		}
+		fmt.Printf("  %s %s %s %s\n", padRightColored(d.repo.Name, 28), padRightColored(d.shelfName, 18),
+			padRightColored(books, 10), strings.Join(notes, ", "))
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	ghpkg "github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+func TestShelvesDiscover_FindsAndAddsShelves(t *testing.T) {
+	origStdout, origCfg := os.Stdout, cfg
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg = origStdout, origCfg })
+	t.Setenv("SHELFCTL_CONFIG", filepath.Join(t.TempDir(), "config.yml"))
+	cfg = &config.Config{
+		GitHub:  config.GitHubConfig{Owner: "me", Token: "t"},
+		Shelves: []config.ShelfConfig{{Name: "papers", Repo: "shelf-papers"}},
+	}
+
+	client := testutil.NewGitHub()
+	client.Owners["me"] = []ghpkg.Repo{
+		{Name: "shelf-novels"},
+		{Name: "shelf-papers"},
+		{Name: "dotfiles"},
+		{Name: "empty-shelf", Topics: []string{ghpkg.ShelfTopic}},
+	}
+	client.Repo("me", "shelf-novels").Files["catalog.yml"] = []byte("- id: a\n  title: A\n- id: b\n  title: B\n")
+	client.Repo("me", "shelf-papers").Files["catalog.yml"] = []byte("[]\n")
+
+	found := discoverShelves(client, "me", client.Owners["me"], false)
+	if len(found) != 3 {
+		t.Fatalf("found %d shelves, want 3: %+v", len(found), found)
+	}
+	if found[0].books != 2 || found[0].shelfName != "novels" || found[0].installed {
+		t.Errorf("novels = %+v", found[0])
+	}
+	if !found[1].installed {
+		t.Errorf("papers should be marked installed")
+	}
+	if found[2].books != -1 {
+		t.Errorf("tagged repo without catalog = %+v", found[2])
+	}
+	if got := discoverShelves(client, "me", client.Owners["me"], true); len(got) != 1 {
+		t.Errorf("topic-only found %d shelves, want 1", len(got))
+	}
+
+	if err := runShelvesDiscoverWithClient(discoverOptions{all: true}, client); err != nil {
+		t.Fatalf("discover --all: %v", err)
+	}
+	saved, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Shelves) != 3 {
+		t.Fatalf("shelves = %+v", saved.Shelves)
+	}
+	if s := saved.ShelfByName("novels"); s == nil || s.Repo != "shelf-novels" || s.Owner != "me" || s.ReadOnly {
+		t.Errorf("novels = %+v", s)
+	}
+
+	err = runShelvesDiscoverWithClient(discoverOptions{add: []string{"dotfiles"}}, client)
+	if err == nil {
+		t.Error("expected error adding a repo that is not a shelf")
+	}
+}
+
+func TestShelvesDiscover_RenamesClashingShelf(t *testing.T) {
+	origStdout, origCfg := os.Stdout, cfg
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg = origStdout, origCfg })
+	t.Setenv("SHELFCTL_CONFIG", filepath.Join(t.TempDir(), "config.yml"))
+	cfg = &config.Config{
+		GitHub:  config.GitHubConfig{Owner: "me"},
+		Shelves: []config.ShelfConfig{{Name: "papers", Repo: "shelf-papers"}},
+	}
+	client := testutil.NewGitHub()
+	client.Owners["team"] = []ghpkg.Repo{{Name: "shelf-papers", Topics: []string{ghpkg.ShelfTopic}}}
+
+	if err := runShelvesDiscoverWithClient(discoverOptions{owner: "team", add: []string{"shelf-papers"}}, client); err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	s := cfg.ShelfByName("team-papers")
+	if s == nil || s.Owner != "team" || !s.ReadOnly {
+		t.Errorf("team-papers = %+v (no token, so it should be read-only)", s)
+	}
+}
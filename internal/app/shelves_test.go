@@ -3,6 +3,9 @@ package app
 import (
 	"strings"
 	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/releases"
 )
 
 // --- stripAnsi ---
@@ -148,3 +151,27 @@ func TestFormatStatus_Healthy(t *testing.T) {
 		t.Errorf("got %q, want it to contain %q", got, "Healthy")
 	}
 }
+
+// --- capacity ---
+
+func TestFormatLimits(t *testing.T) {
+	if got := formatLimits(config.ReleasePolicy{}); got != "" {
+		t.Errorf("got %q for no limits, want empty", got)
+	}
+	got := formatLimits(config.ReleasePolicy{MaxAssets: 500, MaxBytes: 10 << 30})
+	if want := ", limit 500 assets / 10.0 GiB per release"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFillPercent(t *testing.T) {
+	u := releases.Usage{Assets: 250, Bytes: 9 << 30}
+	if _, ok := fillPercent(config.ReleasePolicy{}, u); ok {
+		t.Error("fillPercent without limits should report no percentage")
+	}
+	// The byte limit is nearer, so it decides.
+	got, _ := fillPercent(config.ReleasePolicy{MaxAssets: 1000, MaxBytes: 10 << 30}, u)
+	if got != 90 {
+		t.Errorf("got %d%%, want 90%%", got)
+	}
+}
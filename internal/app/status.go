@@ -119,6 +119,7 @@ func collectStatus(shelves []config.ShelfConfig) statusOutput {
 				shelfStatuses[idx] = ss
 				return
 			}
+			books = catalog.Live(books)
 
 			ss.Books = len(books)
 
@@ -0,0 +1,211 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+type subscribeOptions struct {
+	repo     string
+	path     string
+	readOnly bool
+	update   bool
+	list     bool
+	remove   string
+}
+
+func newSubscribeCmd() *cobra.Command {
+	var opts subscribeOptions
+
+	cmd := &cobra.Command{
+		Use:   "subscribe [owner/library-repo]",
+		Short: "Add the shelves listed in a shared library manifest",
+		Long: `Subscribe to a library manifest: a repo holding a list of shelves, so a
+whole team library can be added in one step.
+
+The manifest (library.yml at the repo root unless --path says otherwise)
+looks like:
+
+  name: Team library
+  shelves:
+    - name: papers
+      owner: team            # default: the manifest repo's owner
+      repo: shelf-papers
+      catalog_path: catalog.yml
+      default_release: library
+      read_only: true
+
+Subscribing adds those shelves to your config and remembers the
+subscription. 'shelfctl subscribe --update' re-reads every manifest: new
+shelves are added, changed entries updated and dropped ones removed. Shelves
+you added yourself are never changed; a manifest shelf whose name clashes
+with one of them is skipped.`,
+		Example: `  shelfctl subscribe team/library
+  shelfctl subscribe team/library --read-only
+  shelfctl subscribe --update
+  shelfctl subscribe --list
+  shelfctl subscribe --remove team/library`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				opts.repo = args[0]
+			}
+			return runSubscribe(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.path, "path", "", "Manifest path in the repo (default: "+config.DefaultManifestPath+")")
+	cmd.Flags().BoolVar(&opts.readOnly, "read-only", false, "Mark every subscribed shelf read-only")
+	cmd.Flags().BoolVar(&opts.update, "update", false, "Re-read all subscribed manifests and apply changes")
+	cmd.Flags().BoolVar(&opts.list, "list", false, "List subscriptions")
+	cmd.Flags().StringVar(&opts.remove, "remove", "", "Unsubscribe and remove the shelves it added")
+	return cmd
+}
+
+func runSubscribe(opts subscribeOptions) error {
+	return runSubscribeWithClient(opts, gh)
+}
+
+func runSubscribeWithClient(opts subscribeOptions, client GitHubClient) error {
+	switch {
+	case opts.list:
+		listSubscriptions()
+		return nil
+
+	case opts.remove != "":
+		removed, found := cfg.Unsubscribe(opts.remove)
+		if !found {
+			return fmt.Errorf("not subscribed to %s", opts.remove)
+		}
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("saving config: %w", err)
+		}
+		ok("Unsubscribed from %s", opts.remove)
+		for _, name := range removed {
+			fmt.Printf("  - %s\n", name)
+		}
+		return nil
+
+	case opts.update:
+		if len(cfg.Subscriptions) == 0 {
+			warn("No subscriptions")
+			return nil
+		}
+		changed := false
+		for _, sub := range append([]config.Subscription(nil), cfg.Subscriptions...) {
+			c, err := refreshSubscription(client, sub)
+			if err != nil {
+				warn("%s: %v", sub.Repo, err)
+				continue
+			}
+			changed = changed || c
+		}
+		if !changed {
+			return nil
+		}
+		return config.Save(cfg)
+
+	case opts.repo == "":
+		return fmt.Errorf("owner/library-repo is required (or use --update / --list / --remove)")
+	}
+
+	if _, _, err := splitRepo(opts.repo); err != nil {
+		return err
+	}
+	sub := config.Subscription{Repo: opts.repo, Path: opts.path, ReadOnly: opts.readOnly}
+	if existing := cfg.SubscriptionByRepo(opts.repo); existing != nil {
+		sub = *existing
+		if opts.path != "" {
+			sub.Path = opts.path
+		}
+		sub.ReadOnly = sub.ReadOnly || opts.readOnly
+		// Re-apply even if the manifest is unchanged, so flags take effect.
+		sub.SHA = ""
+	}
+	if _, err := refreshSubscription(client, sub); err != nil {
+		return err
+	}
+	return config.Save(cfg)
+}
+
+// refreshSubscription reads sub's manifest and merges it into cfg. It
+// reports false when the manifest has not changed since it was last applied.
+func refreshSubscription(client GitHubClient, sub config.Subscription) (bool, error) {
+	owner, repo, err := splitRepo(sub.Repo)
+	if err != nil {
+		return false, err
+	}
+	data, sha, err := client.GetFileContent(owner, repo, sub.EffectivePath(), "")
+	if err != nil {
+		return false, fmt.Errorf("reading %s from %s: %w", sub.EffectivePath(), sub.Repo, err)
+	}
+	if sha != "" && sha == sub.SHA {
+		ok("%s: up to date", sub.Repo)
+		return false, nil
+	}
+	m, err := config.ParseManifest(data, owner)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", sub.Repo, err)
+	}
+
+	sub.SHA = sha
+	ch := cfg.ApplyManifest(sub, m)
+
+	title := sub.Repo
+	if m.Name != "" {
+		title = fmt.Sprintf("%s (%s)", m.Name, sub.Repo)
+	}
+	if ch.Empty() {
+		ok("%s: no shelf changes", title)
+	} else {
+		ok("%s: %d added, %d updated, %d removed", title, len(ch.Added), len(ch.Updated), len(ch.Removed))
+	}
+	for _, name := range ch.Added {
+		fmt.Printf("  %s %s\n", color.GreenString("+"), name)
+	}
+	for _, name := range ch.Updated {
+		fmt.Printf("  %s %s\n", color.YellowString("~"), name)
+	}
+	for _, name := range ch.Removed {
+		fmt.Printf("  %s %s\n", color.RedString("-"), name)
+	}
+	for _, name := range ch.Skipped {
+		warn("Skipped %q: a shelf with that name is already in your config", name)
+	}
+	return true, nil
+}
+
+func listSubscriptions() {
+	if len(cfg.Subscriptions) == 0 {
+		fmt.Println("No subscriptions")
+		return
+	}
+	for _, sub := range cfg.Subscriptions {
+		var shelves []string
+		for _, s := range cfg.Shelves {
+			if strings.EqualFold(s.Subscription, sub.Repo) {
+				shelves = append(shelves, s.Name)
+			}
+		}
+		mode := ""
+		if sub.ReadOnly {
+			mode = " (read-only)"
+		}
+		fmt.Printf("%s%s\n", color.CyanString(sub.Repo), mode)
+		fmt.Printf("  manifest: %s\n", sub.EffectivePath())
+		fmt.Printf("  shelves:  %s\n", strings.Join(shelves, ", "))
+	}
+}
+
+// splitRepo splits "owner/repo".
+func splitRepo(s string) (string, string, error) {
+	parts := splitOwnerRepo(s)
+	if parts == nil || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("expected owner/repo, got %q", s)
+	}
+	return parts[0], parts[1], nil
+}
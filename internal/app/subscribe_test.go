@@ -0,0 +1,85 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	ghpkg "github.com/blackwell-systems/shelfctl/internal/github"
+)
+
+func TestSubscribe_AddsAndUpdatesShelves(t *testing.T) {
+	origStdout, origCfg := os.Stdout, cfg
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg = origStdout, origCfg })
+	t.Setenv("SHELFCTL_CONFIG", filepath.Join(t.TempDir(), "config.yml"))
+	cfg = &config.Config{Shelves: []config.ShelfConfig{{Name: "mine", Repo: "shelf-mine"}}}
+
+	manifest, sha := "", ""
+	reads := 0
+	client := &fakeGitHubClient{getFileContentFn: func(owner, repo, path, ref string) ([]byte, string, error) {
+		if owner != "team" || repo != "library" || path != "library.yml" {
+			return nil, "", ghpkg.ErrNotFound
+		}
+		reads++
+		return []byte(manifest), sha, nil
+	}}
+
+	manifest, sha = "shelves:\n  - {name: papers, repo: shelf-papers}\n  - {name: talks, repo: shelf-talks}\n", "sha1"
+	if err := runSubscribeWithClient(subscribeOptions{repo: "team/library", readOnly: true}, client); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	saved, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	papers := saved.ShelfByName("papers")
+	if papers == nil || papers.Owner != "team" || !papers.ReadOnly || papers.Subscription != "team/library" {
+		t.Fatalf("papers = %+v", papers)
+	}
+	if saved.ShelfByName("mine") == nil || saved.ShelfByName("talks") == nil {
+		t.Errorf("shelves = %+v", saved.Shelves)
+	}
+	if sub := saved.SubscriptionByRepo("team/library"); sub == nil || sub.SHA != "sha1" {
+		t.Errorf("subscription = %+v", sub)
+	}
+
+	// Unchanged manifest: nothing to do.
+	if err := runSubscribeWithClient(subscribeOptions{update: true}, client); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(cfg.Shelves) != 3 {
+		t.Errorf("shelves = %+v", cfg.Shelves)
+	}
+
+	// The manifest drops talks.
+	manifest, sha = "shelves:\n  - {name: papers, repo: shelf-papers}\n", "sha2"
+	if err := runSubscribeWithClient(subscribeOptions{update: true}, client); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	saved, _ = config.Load()
+	if saved.ShelfByName("talks") != nil || saved.ShelfByName("papers") == nil {
+		t.Errorf("after update: %+v", saved.Shelves)
+	}
+
+	if err := runSubscribeWithClient(subscribeOptions{remove: "team/library"}, client); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	saved, _ = config.Load()
+	if len(saved.Shelves) != 1 || saved.Shelves[0].Name != "mine" || len(saved.Subscriptions) != 0 {
+		t.Errorf("after remove: %+v %+v", saved.Shelves, saved.Subscriptions)
+	}
+	if reads != 3 {
+		t.Errorf("manifest read %d times, want 3", reads)
+	}
+}
+
+func TestSubscribe_RejectsBadRepo(t *testing.T) {
+	origCfg := cfg
+	t.Cleanup(func() { cfg = origCfg })
+	cfg = &config.Config{}
+	if err := runSubscribeWithClient(subscribeOptions{repo: "library"}, &fakeGitHubClient{}); err == nil {
+		t.Error("expected error for repo without owner")
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -48,12 +50,15 @@ Examples:
 
 func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) error {
 	// Collect shelves to process
-	shelves := cfg.Shelves
+	shelves := writableShelves(cfg.Shelves)
 	if shelfName != "" {
 		shelf := cfg.ShelfByName(shelfName)
 		if shelf == nil {
 			return fmt.Errorf("shelf %q not found", shelfName)
 		}
+		if err := requireWritable(shelf); err != nil {
+			return err
+		}
 		shelves = []config.ShelfConfig{*shelf}
 	}
 
@@ -96,7 +101,7 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 
 			// Check if modified
 			cachedPath := cacheMgr.Path(owner, shelf.Repo, b.ID, b.Source.Asset)
-			cachedSHA, cachedSize, err := computeFileHash(cachedPath)
+			cachedSHA, cachedSize, err := cacheMgr.SHA256(owner, shelf.Repo, b.ID, b.Source.Asset)
 			if err != nil {
 				warn("Could not read cached file for %s: %v", b.ID, err)
 				continue
@@ -136,11 +141,19 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 	// Second pass: sync books with progress indicators
 	// Cache catalogs and managers per shelf to avoid redundant API calls
 	type shelfState struct {
-		mgr   *catalog.Manager
-		books []catalog.Book
+		mgr    *catalog.Manager
+		books  []catalog.Book
+		before []byte
 	}
 	shelfCache := make(map[string]*shelfState)
 
+	ids := make([]string, len(booksToSync))
+	for i, item := range booksToSync {
+		ids[i] = item.book.ID
+	}
+	op := beginOp("sync", "sync "+bookList(ids))
+	defer finishOp(op)
+
 	totalSynced := 0
 	totalErrors := 0
 
@@ -149,7 +162,7 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 		b := item.book
 		owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
 		catalogPath := shelf.EffectiveCatalogPath()
-		releaseTag := shelf.EffectiveRelease(cfg.Defaults.Release)
+		releaseTag := b.Source.Release
 
 		// Show progress counter
 		progressPrefix := fmt.Sprintf("[%d/%d]", idx+1, len(booksToSync))
@@ -168,7 +181,8 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 				totalErrors++
 				continue
 			}
-			state = &shelfState{mgr: mgr, books: books}
+			before, _ := catalog.Marshal(books)
+			state = &shelfState{mgr: mgr, books: books, before: before}
 			shelfCache[cacheKey] = state
 		}
 
@@ -180,20 +194,12 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 			continue
 		}
 
-		// Find and delete old asset
-		oldAsset, err := gh.FindAsset(owner, shelf.Repo, rel.ID, b.Source.Asset)
-		if err != nil {
-			warn("Could not find asset for %s: %v", b.ID, err)
+		// Find and delete old asset, or its parts
+		if err := deleteBookAssets(op, gh, owner, shelf.Repo, rel.ID, b); err != nil {
+			warn("Could not delete old asset for %s: %v", b.ID, err)
 			totalErrors++
 			continue
 		}
-		if oldAsset != nil {
-			if err := gh.DeleteAsset(owner, shelf.Repo, oldAsset.ID); err != nil {
-				warn("Could not delete old asset for %s: %v", b.ID, err)
-				totalErrors++
-				continue
-			}
-		}
 
 		// Upload modified file with progress bar
 		f, err := os.Open(item.path)
@@ -204,6 +210,8 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 		}
 
 		var uploadErr error
+		var manifest []catalog.Part
+		partSize := cfg.Defaults.EffectivePartSize()
 		if tui.ShouldUseTUI(cmd) {
 			// Show progress bar during upload
 			progressCh := make(chan int64, 100)
@@ -212,8 +220,9 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 			go func() {
 				progressCh <- 0
 				pr := tui.NewProgressReader(f, item.size, progressCh)
-				_, err := gh.UploadAsset(owner, shelf.Repo, rel.ID, b.Source.Asset, pr, item.size, "application/octet-stream")
+				m, err := parts.Upload(gh, owner, shelf.Repo, rel.ID, b.Source.Asset, pr, item.size, partSize)
 				close(progressCh)
+				manifest = m
 				errCh <- err
 			}()
 
@@ -228,7 +237,7 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 			uploadErr = <-errCh
 		} else {
 			// Non-interactive: direct upload
-			_, uploadErr = gh.UploadAsset(owner, shelf.Repo, rel.ID, b.Source.Asset, f, item.size, "application/octet-stream")
+			manifest, uploadErr = parts.Upload(gh, owner, shelf.Repo, rel.ID, b.Source.Asset, f, item.size, partSize)
 		}
 
 		_ = f.Close()
@@ -238,12 +247,19 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 			totalErrors++
 			continue
 		}
+		uploaded := b.Source
+		uploaded.Parts = manifest
+		for _, loc := range journal.Locations(owner, shelf.Repo, uploaded) {
+			op.Added(loc)
+		}
 
 		// Update catalog entry
 		bookToUpdate := catalog.ByID(state.books, b.ID)
 		if bookToUpdate != nil {
 			bookToUpdate.Checksum.SHA256 = item.newSHA
 			bookToUpdate.SizeBytes = item.size
+			bookToUpdate.Encryption = shelf.EncryptionScheme()
+			bookToUpdate.Source.Parts = manifest
 
 			commitMsg := fmt.Sprintf("sync: update %s with local changes", b.ID)
 			if err := state.mgr.Save(state.books, commitMsg); err != nil {
@@ -251,6 +267,9 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 				totalErrors++
 				continue
 			}
+			after, _ := catalog.Marshal(state.books)
+			op.Catalog(owner, shelf.Repo, catalogPath, state.before, after)
+			_ = cacheMgr.MarkSynced(owner, shelf.Repo, b.ID, b.Source.Asset, item.newSHA)
 
 			totalSynced++
 			if tui.ShouldUseTUI(cmd) {
@@ -276,6 +295,16 @@ func runSync(cmd *cobra.Command, bookIDs []string, shelfName string, all bool) e
 	return nil
 }
 
+// deleteBookAssets deletes a book's asset, or its parts, before a new
+// version is uploaded, keeping copies in the journal.
+func deleteBookAssets(op *journal.Op, client GitHubClient, owner, repo string, releaseID int64, b *catalog.Book) error {
+	lost, err := op.DeleteAssets(client, owner, repo, releaseID, b.Source)
+	for _, name := range lost {
+		warn("Could not keep a copy of %s, its deletion cannot be undone", name)
+	}
+	return err
+}
+
 func computeFileHash(path string) (string, int64, error) {
 	f, err := os.Open(path)
 	if err != nil {
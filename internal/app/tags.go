@@ -121,12 +121,15 @@ Examples:
 			oldTag := args[0]
 			newTag := args[1]
 
-			shelves := cfg.Shelves
+			shelves := writableShelves(cfg.Shelves)
 			if shelfName != "" {
 				s := cfg.ShelfByName(shelfName)
 				if s == nil {
 					return fmt.Errorf("shelf %q not found in config", shelfName)
 				}
+				if err := requireWritable(s); err != nil {
+					return err
+				}
 				shelves = []config.ShelfConfig{*s}
 			}
 
@@ -136,6 +139,8 @@ Examples:
 			}
 
 			totalRenamed := 0
+			op := beginOp("tags rename", fmt.Sprintf("rename tag %q → %q", oldTag, newTag))
+			defer finishOp(op)
 
 			for i := range shelves {
 				shelf := &shelves[i]
@@ -148,6 +153,7 @@ Examples:
 					warn("Could not load catalog for shelf %q: %v", shelf.Name, err)
 					continue
 				}
+				before, _ := catalog.Marshal(books)
 
 				renamed := 0
 				for j := range books {
@@ -172,6 +178,8 @@ Examples:
 						warn("Could not save catalog for shelf %q: %v", shelf.Name, err)
 						continue
 					}
+					after, _ := catalog.Marshal(books)
+					op.Catalog(owner, shelf.Repo, catalogPath, before, after)
 					ok("Shelf %s: renamed %d occurrences", shelf.Name, renamed)
 				} else {
 					fmt.Printf("  Shelf %s: %d books would be updated\n", shelf.Name, renamed)
@@ -215,7 +223,7 @@ func collectTagCounts(shelves []config.ShelfConfig) map[string]int {
 			continue
 		}
 
-		for _, b := range books {
+		for _, b := range catalog.Live(books) {
 			for _, t := range b.Tags {
 				counts[strings.ToLower(t)]++
 			}
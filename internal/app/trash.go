@@ -0,0 +1,445 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
+	"github.com/blackwell-systems/shelfctl/internal/tui"
+	"github.com/blackwell-systems/shelfctl/internal/util"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+type trashOptions struct {
+	shelfName   string
+	olderThan   string
+	skipConfirm bool
+}
+
+func newTrashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List, restore or empty deleted books",
+		Long: `delete-book moves books into the trash: their files go to the "trash"
+release of the shelf and their catalog entries are marked as trashed.
+Trashed books are hidden from browse, search and the other listings, and
+can be restored until the trash is emptied.`,
+	}
+
+	cmd.AddCommand(
+		newTrashListCmd(),
+		newTrashRestoreCmd(),
+		newTrashEmptyCmd(),
+	)
+
+	return cmd
+}
+
+func newTrashListCmd() *cobra.Command {
+	var opts trashOptions
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List trashed books",
+		Example: `  shelfctl trash list
+  shelfctl trash list --shelf programming`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTrashListWithClient(opts, gh)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.shelfName, "shelf", "", "Only this shelf")
+	return cmd
+}
+
+func newTrashRestoreCmd() *cobra.Command {
+	var opts trashOptions
+
+	cmd := &cobra.Command{
+		Use:   "restore [id...]",
+		Short: "Bring trashed books back",
+		Long: `Move the files of trashed books back to the release they were deleted
+from and clear the trash mark in the catalog. Without IDs, a picker of the
+trashed books is shown.`,
+		Example: `  shelfctl trash restore sicp
+  shelfctl trash restore sicp taocp --shelf programming
+  shelfctl trash restore`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTrashRestoreWithClient(args, opts, gh)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.shelfName, "shelf", "", "Shelf containing the books (if an ID is ambiguous)")
+	return cmd
+}
+
+func newTrashEmptyCmd() *cobra.Command {
+	var opts trashOptions
+
+	cmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Delete trashed books for good",
+		Long: `Delete the files of trashed books from the trash release and remove
+their entries from the catalog. This cannot be undone.
+
+With --older-than, only books trashed longer ago than that are deleted,
+such as 30d, 2w or 12h.`,
+		Example: `  shelfctl trash empty
+  shelfctl trash empty --older-than 30d
+  shelfctl trash empty --shelf programming --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTrashEmptyWithClient(opts, gh, time.Now())
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.shelfName, "shelf", "", "Only this shelf")
+	cmd.Flags().StringVar(&opts.olderThan, "older-than", "", "Only books trashed longer ago than this (e.g. 30d)")
+	cmd.Flags().BoolVar(&opts.skipConfirm, "yes", false, "Skip confirmation prompt")
+	return cmd
+}
+
+// trashShelves returns the shelves a trash command works on.
+func trashShelves(shelfName string) ([]config.ShelfConfig, error) {
+	if shelfName == "" {
+		return cfg.Shelves, nil
+	}
+	s := cfg.ShelfByName(shelfName)
+	if s == nil {
+		return nil, fmt.Errorf("shelf %q not found in config", shelfName)
+	}
+	return []config.ShelfConfig{*s}, nil
+}
+
+// loadTrash returns the trashed books of the given shelves, most recently
+// trashed first.
+func loadTrash(client GitHubClient, shelves []config.ShelfConfig) []tui.BookItem {
+	var items []tui.BookItem
+	for i := range shelves {
+		shelf := &shelves[i]
+		owner := shelf.EffectiveOwner(cfg.GitHub.Owner)
+		data, _, err := client.GetFileContent(owner, shelf.Repo, shelf.EffectiveCatalogPath(), "")
+		if err != nil {
+			warn("Could not load catalog for shelf %q: %v", shelf.Name, err)
+			continue
+		}
+		books, err := catalog.Parse(data)
+		if err != nil {
+			warn("Could not parse catalog for shelf %q: %v", shelf.Name, err)
+			continue
+		}
+		for _, b := range catalog.InTrash(books) {
+			items = append(items, tui.BookItem{
+				Book:        b,
+				ShelfName:   shelf.Name,
+				Owner:       owner,
+				Repo:        shelf.Repo,
+				Release:     trash.Release,
+				CatalogPath: shelf.EffectiveCatalogPath(),
+			})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Book.Trashed.At > items[j].Book.Trashed.At
+	})
+	return items
+}
+
+func runTrashListWithClient(opts trashOptions, client GitHubClient) error {
+	shelves, err := trashShelves(opts.shelfName)
+	if err != nil {
+		return err
+	}
+	items := loadTrash(client, shelves)
+	if len(items) == 0 {
+		fmt.Println("The trash is empty.")
+		return nil
+	}
+
+	for _, item := range items {
+		when := item.Book.Trashed.At
+		if at, err := trash.Since(&item.Book); err == nil {
+			when = at.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%-24s %s  %s  %s\n",
+			color.WhiteString(item.Book.ID),
+			when,
+			color.CyanString("["+item.ShelfName+"]"),
+			item.Book.Title)
+	}
+	fmt.Println()
+	fmt.Printf("%d books in the trash. Restore with 'shelfctl trash restore <id>'.\n", len(items))
+	return nil
+}
+
+func runTrashRestoreWithClient(ids []string, opts trashOptions, client GitHubClient) error {
+	shelves, err := trashShelves(opts.shelfName)
+	if err != nil {
+		return err
+	}
+	items := loadTrash(client, shelves)
+	if len(items) == 0 {
+		fmt.Println("The trash is empty.")
+		return nil
+	}
+
+	var selected []tui.BookItem
+	if len(ids) == 0 {
+		if !util.IsTTY() {
+			return fmt.Errorf("book ID required in non-interactive mode")
+		}
+		selected, err = tui.RunBookPickerMulti(items, "Select books to restore")
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, id := range ids {
+			var found []tui.BookItem
+			for _, item := range items {
+				if item.Book.ID == id {
+					found = append(found, item)
+				}
+			}
+			switch len(found) {
+			case 0:
+				return fmt.Errorf("book %q is not in the trash", id)
+			case 1:
+				selected = append(selected, found[0])
+			default:
+				return fmt.Errorf("book %q is in the trash of several shelves, use --shelf", id)
+			}
+		}
+	}
+
+	restored := make([]string, len(selected))
+	for i, item := range selected {
+		restored[i] = item.Book.ID
+	}
+	op := beginOp("trash restore", "restore "+bookList(restored))
+	defer finishOp(op)
+
+	count := 0
+	for _, group := range groupByShelf(selected) {
+		count += restoreFromTrash(client, group, op)
+	}
+	if count > 0 {
+		ok("Restored %d books", count)
+	}
+	return nil
+}
+
+// groupByShelf groups books by shelf, keeping the order shelves are first
+// seen in.
+func groupByShelf(items []tui.BookItem) [][]tui.BookItem {
+	var order []string
+	groups := make(map[string][]tui.BookItem)
+	for _, item := range items {
+		if _, seen := groups[item.ShelfName]; !seen {
+			order = append(order, item.ShelfName)
+		}
+		groups[item.ShelfName] = append(groups[item.ShelfName], item)
+	}
+	out := make([][]tui.BookItem, len(order))
+	for i, name := range order {
+		out[i] = groups[name]
+	}
+	return out
+}
+
+// restoreFromTrash restores books of one shelf in a single catalog commit
+// and returns how many were restored.
+func restoreFromTrash(client GitHubClient, items []tui.BookItem, op *journal.Op) int {
+	shelf := cfg.ShelfByName(items[0].ShelfName)
+	if shelf == nil {
+		warn("Shelf %q not found in config", items[0].ShelfName)
+		return 0
+	}
+	if err := requireWritable(shelf); err != nil {
+		warn("%v", err)
+		return 0
+	}
+	owner, repo, catalogPath := items[0].Owner, items[0].Repo, items[0].CatalogPath
+
+	data, _, err := client.GetFileContent(owner, repo, catalogPath, "")
+	if err != nil {
+		warn("Could not load catalog for shelf %q: %v", shelf.Name, err)
+		return 0
+	}
+	books, err := catalog.Parse(data)
+	if err != nil {
+		warn("Could not parse catalog for shelf %q: %v", shelf.Name, err)
+		return 0
+	}
+
+	var restored []catalog.Book
+	for _, item := range items {
+		b := catalog.ByID(books, item.Book.ID)
+		if b == nil || !b.IsTrashed() {
+			warn("%s is no longer in the trash", item.Book.ID)
+			continue
+		}
+		from := journal.Locations(owner, repo, b.Source)
+		if err := trash.Restore(client, owner, repo, b); err != nil {
+			warn("Could not restore %s: %v", item.Book.ID, err)
+			continue
+		}
+		// The assets were uploaded again, encrypted (or not) for the shelf.
+		b.Encryption = shelf.EncryptionScheme()
+		op.MovedAll(from, journal.Locations(owner, repo, b.Source))
+		ok("Restored %s to %s@%s", b.ID, repo, b.Source.Release)
+		restored = append(restored, *b)
+	}
+	if len(restored) == 0 {
+		return 0
+	}
+
+	ids := make([]string, len(restored))
+	for i, b := range restored {
+		ids[i] = b.ID
+	}
+	updatedData, err := catalog.Marshal(books)
+	if err != nil {
+		warn("Could not marshal catalog: %v", err)
+		return 0
+	}
+	if err := client.CommitFile(owner, repo, catalogPath, updatedData, "restore: "+strings.Join(ids, ", ")); err != nil {
+		warn("Could not commit catalog for shelf %q: %v", shelf.Name, err)
+		return 0
+	}
+	op.Catalog(owner, repo, catalogPath, data, updatedData)
+
+	if readmeData, _, err := client.GetFileContent(owner, repo, "README.md", ""); err == nil {
+		original := string(readmeData)
+		content := operations.UpdateShelfREADMEStats(original, len(catalog.Live(books)))
+		for _, b := range restored {
+			content = operations.AppendToShelfREADME(content, b)
+		}
+		if content != original {
+			if err := client.CommitFile(owner, repo, "README.md", []byte(content), "Update README: restore "+strings.Join(ids, ", ")); err != nil {
+				warn("Could not update README.md: %v", err)
+			}
+		}
+	}
+	return len(restored)
+}
+
+func runTrashEmptyWithClient(opts trashOptions, client GitHubClient, now time.Time) error {
+	shelves, err := trashShelves(opts.shelfName)
+	if err != nil {
+		return err
+	}
+	var minAge time.Duration
+	if opts.olderThan != "" {
+		if minAge, err = trash.ParseAge(opts.olderThan); err != nil {
+			return err
+		}
+	}
+
+	var expired []tui.BookItem
+	for _, item := range loadTrash(client, shelves) {
+		at, err := trash.Since(&item.Book)
+		if err != nil {
+			warn("%s has an unreadable trash date, skipping: %v", item.Book.ID, err)
+			continue
+		}
+		if now.Sub(at) >= minAge {
+			expired = append(expired, item)
+		}
+	}
+	if len(expired) == 0 {
+		if opts.olderThan != "" {
+			fmt.Printf("Nothing in the trash older than %s.\n", opts.olderThan)
+		} else {
+			fmt.Println("The trash is empty.")
+		}
+		return nil
+	}
+
+	fmt.Println(color.YellowString("⚠ These books will be deleted for good:"))
+	for _, item := range expired {
+		fmt.Printf("  • %s - %s [%s]\n", color.WhiteString(item.Book.ID), item.Book.Title, color.CyanString(item.ShelfName))
+	}
+	fmt.Println()
+	if !opts.skipConfirm {
+		sc := bufio.NewScanner(os.Stdin)
+		fmt.Printf("Delete %d books? This cannot be undone. [y/N]: ", len(expired))
+		if !sc.Scan() || strings.ToLower(strings.TrimSpace(sc.Text())) != "y" {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	count := 0
+	for _, group := range groupByShelf(expired) {
+		count += purgeFromTrash(client, group)
+	}
+	if count > 0 {
+		ok("Deleted %d books from the trash", count)
+	}
+	return nil
+}
+
+// purgeFromTrash deletes trashed books of one shelf for good, in a single
+// catalog commit, and returns how many were deleted.
+func purgeFromTrash(client GitHubClient, items []tui.BookItem) int {
+	shelf := cfg.ShelfByName(items[0].ShelfName)
+	if shelf == nil {
+		warn("Shelf %q not found in config", items[0].ShelfName)
+		return 0
+	}
+	if err := requireWritable(shelf); err != nil {
+		warn("%v", err)
+		return 0
+	}
+	owner, repo, catalogPath := items[0].Owner, items[0].Repo, items[0].CatalogPath
+
+	data, _, err := client.GetFileContent(owner, repo, catalogPath, "")
+	if err != nil {
+		warn("Could not load catalog for shelf %q: %v", shelf.Name, err)
+		return 0
+	}
+	books, err := catalog.Parse(data)
+	if err != nil {
+		warn("Could not parse catalog for shelf %q: %v", shelf.Name, err)
+		return 0
+	}
+
+	purged := 0
+	for _, item := range items {
+		b := catalog.ByID(books, item.Book.ID)
+		if b == nil || !b.IsTrashed() {
+			continue
+		}
+		if err := trash.Purge(client, owner, repo, b); err != nil {
+			warn("Could not delete %s: %v", item.Book.ID, err)
+			continue
+		}
+		books, _ = catalog.Remove(books, item.Book.ID)
+		purged++
+	}
+	if purged == 0 {
+		return 0
+	}
+
+	updatedData, err := catalog.Marshal(books)
+	if err != nil {
+		warn("Could not marshal catalog: %v", err)
+		return 0
+	}
+	msg := fmt.Sprintf("trash: empty %d books", purged)
+	if err := client.CommitFile(owner, repo, catalogPath, updatedData, msg); err != nil {
+		warn("Could not commit catalog for shelf %q: %v", shelf.Name, err)
+		return 0
+	}
+	return purged
+}
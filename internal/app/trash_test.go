@@ -0,0 +1,137 @@
+package app
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/cache"
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/encrypt"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+	"github.com/blackwell-systems/shelfctl/internal/tui"
+)
+
+// shelfBooks returns the catalog of me/shelf-books.
+func shelfBooks(t *testing.T, c *testutil.GitHub) []catalog.Book {
+	t.Helper()
+	books, err := catalog.Parse(c.Repo("me", "shelf-books").Files["catalog.yml"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return books
+}
+
+func TestTrash(t *testing.T) {
+	origStdout, origCfg, origGh, origCache := os.Stdout, cfg, gh, cacheMgr
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg, gh, cacheMgr = origStdout, origCfg, origGh, origCache })
+
+	cfg = &config.Config{
+		GitHub:   config.GitHubConfig{Owner: "me"},
+		Defaults: config.DefaultsConfig{Release: "library"},
+		Shelves:  []config.ShelfConfig{{Name: "books", Repo: "shelf-books"}},
+	}
+	client := testutil.NewGitHub()
+	client.PutAsset("me", "shelf-books", "library", "sicp.pdf", "sicp")
+	client.PutAsset("me", "shelf-books", "library", "taocp.pdf", "taocp")
+	client.Repo("me", "shelf-books").Files["catalog.yml"] = []byte(`- id: sicp
+  title: SICP
+  format: pdf
+  source: {type: github_release, owner: me, repo: shelf-books, release: library, asset: sicp.pdf}
+- id: taocp
+  title: TAOCP
+  format: pdf
+  source: {type: github_release, owner: me, repo: shelf-books, release: library, asset: taocp.pdf}
+`)
+
+	cacheMgr = cache.New(t.TempDir())
+	gh = nil // everything goes through client
+	for _, id := range []string{"sicp", "taocp"} {
+		item := tui.BookItem{Book: *catalog.ByID(shelfBooks(t, client), id), ShelfName: "books", Owner: "me", Repo: "shelf-books"}
+		if err := trashSingleBook(item, nil, client); err != nil {
+			t.Fatalf("trash %s: %v", id, err)
+		}
+	}
+	if !client.HasAsset("me", "shelf-books", "trash", "sicp.pdf") || client.HasAsset("me", "shelf-books", "library", "sicp.pdf") {
+		t.Fatal("sicp not moved into the trash release")
+	}
+
+	if err := runTrashListWithClient(trashOptions{}, client); err != nil {
+		t.Errorf("trash list: %v", err)
+	}
+
+	if err := runTrashRestoreWithClient([]string{"nope"}, trashOptions{}, client); err == nil {
+		t.Error("restoring a book not in the trash should fail")
+	}
+	if err := runTrashRestoreWithClient([]string{"sicp"}, trashOptions{}, client); err != nil {
+		t.Fatalf("trash restore: %v", err)
+	}
+	if b := catalog.ByID(shelfBooks(t, client), "sicp"); b.IsTrashed() || b.Source.Release != "library" {
+		t.Errorf("sicp after restore = %+v", b)
+	}
+	if !client.HasAsset("me", "shelf-books", "library", "sicp.pdf") || client.HasAsset("me", "shelf-books", "trash", "sicp.pdf") {
+		t.Error("sicp asset not moved back")
+	}
+
+	// Forty days on, nothing was trashed 50 days ago.
+	later := time.Now().Add(40 * 24 * time.Hour)
+	if err := runTrashEmptyWithClient(trashOptions{olderThan: "50d", skipConfirm: true}, client, later); err != nil {
+		t.Fatalf("trash empty --older-than 50d: %v", err)
+	}
+	if catalog.ByID(shelfBooks(t, client), "taocp") == nil {
+		t.Fatal("taocp emptied too early")
+	}
+	if err := runTrashEmptyWithClient(trashOptions{olderThan: "30d", skipConfirm: true}, client, later); err != nil {
+		t.Fatalf("trash empty --older-than 30d: %v", err)
+	}
+	if catalog.ByID(shelfBooks(t, client), "taocp") != nil || client.HasAsset("me", "shelf-books", "trash", "taocp.pdf") {
+		t.Error("taocp not emptied from the trash")
+	}
+	if books := shelfBooks(t, client); len(books) != 1 || books[0].ID != "sicp" {
+		t.Errorf("catalog after empty = %v", books)
+	}
+}
+
+func TestTrash_MarksReencryptedAssets(t *testing.T) {
+	origStdout, origCfg, origGh, origCache := os.Stdout, cfg, gh, cacheMgr
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg, gh, cacheMgr = origStdout, origCfg, origGh, origCache })
+
+	cfg = &config.Config{
+		GitHub:   config.GitHubConfig{Owner: "me"},
+		Defaults: config.DefaultsConfig{Release: "library"},
+		Shelves: []config.ShelfConfig{{Name: "books", Repo: "shelf-books",
+			Encryption: config.EncryptionConfig{PassphraseEnv: "SHELF_PASSPHRASE"}}},
+	}
+	// Uploaded before the shelf was encrypted: moving it encrypts it.
+	client := testutil.NewGitHub()
+	client.PutAsset("me", "shelf-books", "library", "sicp.pdf", "sicp")
+	client.Repo("me", "shelf-books").Files["catalog.yml"] = []byte(`- id: sicp
+  title: SICP
+  format: pdf
+  source: {type: github_release, owner: me, repo: shelf-books, release: library, asset: sicp.pdf}
+`)
+	cacheMgr = cache.New(t.TempDir())
+	gh = nil
+
+	item := tui.BookItem{Book: *catalog.ByID(shelfBooks(t, client), "sicp"), ShelfName: "books", Owner: "me", Repo: "shelf-books"}
+	if err := trashSingleBook(item, nil, client); err != nil {
+		t.Fatal(err)
+	}
+	if b := catalog.ByID(shelfBooks(t, client), "sicp"); b.Encryption != encrypt.Scheme {
+		t.Errorf("encryption after trash = %q, want %q", b.Encryption, encrypt.Scheme)
+	}
+
+	// A book trashed by an older version is marked plain too.
+	books := shelfBooks(t, client)
+	books[0].Encryption = ""
+	client.Repo("me", "shelf-books").Files["catalog.yml"], _ = catalog.Marshal(books)
+	if err := runTrashRestoreWithClient([]string{"sicp"}, trashOptions{}, client); err != nil {
+		t.Fatal(err)
+	}
+	if b := catalog.ByID(shelfBooks(t, client), "sicp"); b.Encryption != encrypt.Scheme {
+		t.Errorf("encryption after restore = %q, want %q", b.Encryption, encrypt.Scheme)
+	}
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+type undoOptions struct {
+	id          string
+	list        bool
+	skipConfirm bool
+	force       bool
+}
+
+func newUndoCmd() *cobra.Command {
+	var opts undoOptions
+
+	cmd := &cobra.Command{
+		Use:   "undo [id]",
+		Short: "Undo the last change to your shelves",
+		Long: `Undo an operation recorded in the undo journal: the catalogs are put back
+as they were, deleted files are uploaded again from the copies kept in the
+journal, moved files are moved back and added files are deleted.
+
+Without an ID the newest operation not undone yet is undone. Use --list to
+see the recorded operations and their IDs.
+
+Commands that change shelves record an operation: delete-book, trash
+restore, edit-book, move, shelve, import (including --from-calibre), migrate,
+sync, enrich, tags rename and verify --fix, as well as the same changes
+made in the TUI and on the serve --web page. If a catalog was changed
+again since, undo refuses unless --force is given, since the later change
+would be lost.`,
+		Example: `  shelfctl undo
+  shelfctl undo --list
+  shelfctl undo 20260301-101500 --yes`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				opts.id = args[0]
+			}
+			if opts.list {
+				return listUndo()
+			}
+			return runUndoWithClient(opts, gh)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.list, "list", false, "List the recorded operations")
+	cmd.Flags().BoolVar(&opts.skipConfirm, "yes", false, "Skip confirmation prompt")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Undo even if a catalog was changed since")
+	return cmd
+}
+
+func listUndo() error {
+	if cfg.Defaults.JournalDir == "" {
+		return fmt.Errorf("the undo journal is disabled (defaults.journal_dir)")
+	}
+	ops, err := undoJournal().List()
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		fmt.Println("No operations recorded.")
+		return nil
+	}
+	for _, o := range ops {
+		state := ""
+		if o.Undone != nil {
+			state = color.HiBlackString("  (undone %s)", o.Undone.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Printf("%s  %s  %-12s %s%s\n",
+			color.YellowString(o.ID),
+			o.Time.Local().Format("2006-01-02 15:04"),
+			o.Command,
+			o.Summary,
+			state)
+	}
+	return nil
+}
+
+func runUndoWithClient(opts undoOptions, client journal.Client) error {
+	if cfg.Defaults.JournalDir == "" {
+		return fmt.Errorf("the undo journal is disabled (defaults.journal_dir)")
+	}
+	j := undoJournal()
+
+	var op *journal.Op
+	var err error
+	if opts.id != "" {
+		op, err = j.Get(opts.id)
+	} else {
+		op, err = j.Latest()
+	}
+	if err != nil {
+		return err
+	}
+	if op == nil {
+		fmt.Println("Nothing to undo.")
+		return nil
+	}
+	if op.Undone != nil {
+		return fmt.Errorf("operation %s was already undone", op.ID)
+	}
+
+	header("Undo %s", op.ID)
+	fmt.Printf("  %s  %s: %s\n", op.Time.Local().Format("2006-01-02 15:04"), op.Command, op.Summary)
+	for _, cc := range op.Catalogs {
+		fmt.Printf("  • restore catalog of %s/%s\n", cc.Owner, cc.Repo)
+	}
+	for _, a := range op.Assets {
+		switch a.Kind {
+		case journal.AssetAdded:
+			fmt.Printf("  • delete %s\n", a.To)
+		case journal.AssetDeleted:
+			if a.Copy == "" {
+				fmt.Printf("  • %s (no copy kept)\n", color.YellowString("cannot restore %s", a.From))
+			} else {
+				fmt.Printf("  • upload %s again\n", a.From)
+			}
+		case journal.AssetMoved:
+			fmt.Printf("  • move %s back to %s/%s@%s\n", a.To, a.From.Owner, a.From.Repo, a.From.Release)
+		}
+	}
+	fmt.Println()
+
+	conflicts, err := journal.Conflicts(client, op)
+	if err != nil {
+		return fmt.Errorf("checking catalogs: %w", err)
+	}
+	if len(conflicts) > 0 {
+		for _, cc := range conflicts {
+			warn("The catalog of %s/%s was changed since", cc.Owner, cc.Repo)
+		}
+		if !opts.force {
+			return fmt.Errorf("undoing would overwrite later changes; use --force to undo anyway")
+		}
+	}
+
+	if !opts.skipConfirm {
+		sc := bufio.NewScanner(os.Stdin)
+		fmt.Print("Proceed? [y/N]: ")
+		if !sc.Scan() || strings.ToLower(strings.TrimSpace(sc.Text())) != "y" {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	if err := j.Undo(client, op, func(s string) { ok("%s", s) }); err != nil {
+		return fmt.Errorf("undo %s: %w", op.ID, err)
+	}
+	ok("Undid %s", op.Summary)
+	return nil
+}
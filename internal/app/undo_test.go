@@ -0,0 +1,61 @@
+package app
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+func TestUndo(t *testing.T) {
+	origStdout, origCfg := os.Stdout, cfg
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg = origStdout, origCfg })
+
+	cfg = &config.Config{
+		GitHub:   config.GitHubConfig{Owner: "me"},
+		Defaults: config.DefaultsConfig{JournalDir: t.TempDir()},
+		Shelves:  []config.ShelfConfig{{Name: "books", Repo: "shelf-books"}},
+	}
+	before := "- id: sicp\n  title: SICP\n  tags: [lisp]\n"
+	after := "- id: sicp\n  title: SICP\n  tags: [scheme]\n"
+	client := testutil.NewGitHub()
+	shelf := client.Repo("me", "shelf-books")
+	shelf.Files["catalog.yml"] = []byte(after)
+
+	op := beginOp("tags rename", "rename tag lisp to scheme")
+	op.Catalog("me", "shelf-books", "catalog.yml", []byte(before), []byte(after))
+	finishOp(op)
+
+	// A later change blocks undo unless forced.
+	shelf.Files["catalog.yml"] = []byte("[]")
+	err := runUndoWithClient(undoOptions{skipConfirm: true}, client)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("undo over a later change: %v", err)
+	}
+	shelf.Files["catalog.yml"] = []byte(after)
+
+	if err := runUndoWithClient(undoOptions{skipConfirm: true}, client); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if got := string(shelf.Files["catalog.yml"]); got != before {
+		t.Errorf("catalog after undo:\n%s", got)
+	}
+	if err := runUndoWithClient(undoOptions{id: op.ID, skipConfirm: true}, client); err == nil {
+		t.Error("undoing an undone operation should fail")
+	}
+	if err := listUndo(); err != nil {
+		t.Errorf("undo --list: %v", err)
+	}
+
+	// Nothing left to undo.
+	if err := runUndoWithClient(undoOptions{skipConfirm: true}, client); err != nil {
+		t.Errorf("undo with nothing to undo: %v", err)
+	}
+	if latest, _ := journal.Open(cfg.Defaults.JournalDir).Latest(); latest != nil {
+		t.Errorf("latest after undo = %s", latest.ID)
+	}
+}
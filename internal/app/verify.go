@@ -1,13 +1,18 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/blackwell-systems/shelfctl/internal/cache"
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/encrypt"
 	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -22,7 +27,10 @@ func newVerifyCmd() *cobra.Command {
 		Use:   "verify",
 		Short: "Detect catalog vs release mismatches",
 		Long: `Check for orphaned catalog entries (in catalog but asset missing)
-and orphaned assets (in release but not in catalog).
+and orphaned assets (in release but not in catalog). For encrypted books
+the asset size is checked against the catalog too. Each book is checked
+against the release it is in, such as a rolled-over release or, for
+trashed books, the shelf's trash release.
 Use --fix to automatically clean up issues.
 
 Examples:
@@ -49,7 +57,12 @@ Examples:
 			totalIssues := 0
 			for i := range shelves {
 				shelf := &shelves[i]
-				issues := verifySingleShelf(shelf, fix)
+				shelfFix := fix
+				if fix && shelf.ReadOnly {
+					warn("Shelf %q is read-only; checking without --fix", shelf.Name)
+					shelfFix = false
+				}
+				issues := verifySingleShelf(shelf, shelfFix)
 				totalIssues += len(issues)
 			}
 
@@ -69,12 +82,21 @@ Examples:
 }
 
 type verifyIssue struct {
-	Type        string // "orphaned_catalog" or "orphaned_asset"
+	Type        string // "orphaned_catalog", "orphaned_asset" or "size_mismatch"
 	BookID      string
 	AssetName   string
+	Release     string
 	Description string
 }
 
+// verifyRelease is a release of a shelf as verify sees it: its assets,
+// by name too, and the catalog entries that refer to each name.
+type verifyRelease struct {
+	assets     []github.Asset
+	byName     map[string]*github.Asset
+	referenced map[string]*catalog.Book
+}
+
 func verifySingleShelf(shelf *config.ShelfConfig, fix bool) []verifyIssue {
 	return verifySingleShelfWithClient(shelf, fix, gh, cacheMgr)
 }
@@ -101,31 +123,63 @@ func verifySingleShelfWithClient(shelf *config.ShelfConfig, fix bool, client Git
 		return nil
 	}
 
-	// 2. Get release and all assets
-	rel, err := client.GetReleaseByTag(owner, shelf.Repo, releaseTag)
-	if err != nil {
-		warn("Could not get release for %s: %v", shelf.Name, err)
-		return nil
+	var op *journal.Op
+	if fix {
+		op = beginOp("verify", "verify --fix "+shelf.Name)
+		defer finishOp(op)
 	}
 
-	assets, err := client.ListReleaseAssets(owner, shelf.Repo, rel.ID)
-	if err != nil {
-		warn("Could not list assets for %s: %v", shelf.Name, err)
-		return nil
+	// 2. Get the default release, and every release the catalog refers
+	// to (rolled-over releases, the trash), with their assets
+	tags := []string{releaseTag}
+	for i := range books {
+		if !slices.Contains(tags, books[i].Source.Release) {
+			tags = append(tags, books[i].Source.Release)
+		}
 	}
-
-	fmt.Printf("  Catalog: %d entries\n", len(books))
-	fmt.Printf("  Release: %d assets\n", len(assets))
-
-	// 3. Build maps for comparison
-	assetNames := make(map[string]*github.Asset)
-	for i := range assets {
-		assetNames[assets[i].Name] = &assets[i]
+	byTag := make(map[string]*verifyRelease, len(tags))
+	for _, tag := range tags {
+		vr := &verifyRelease{
+			byName:     make(map[string]*github.Asset),
+			referenced: make(map[string]*catalog.Book),
+		}
+		rel, err := client.GetReleaseByTag(owner, shelf.Repo, tag)
+		switch {
+		case err == nil:
+			if vr.assets, err = client.ListReleaseAssets(owner, shelf.Repo, rel.ID); err != nil {
+				warn("Could not list assets of release %s for %s: %v", tag, shelf.Name, err)
+				return nil
+			}
+		case tag == releaseTag || !errors.Is(err, github.ErrNotFound):
+			// A missing release other than the default one just leaves
+			// its books without assets.
+			warn("Could not get release %s for %s: %v", tag, shelf.Name, err)
+			return nil
+		}
+		for i := range vr.assets {
+			vr.byName[vr.assets[i].Name] = &vr.assets[i]
+		}
+		byTag[tag] = vr
 	}
 
-	catalogAssets := make(map[string]*catalog.Book)
+	trashed := 0
 	for i := range books {
-		catalogAssets[books[i].Source.Asset] = &books[i]
+		if books[i].IsTrashed() {
+			trashed++
+		}
+		for _, name := range books[i].Source.AssetNames() {
+			byTag[books[i].Source.Release].referenced[name] = &books[i]
+		}
+	}
+
+	fmt.Printf("  Catalog: %d entries\n", len(books))
+	fmt.Printf("  Release: %d assets\n", len(byTag[releaseTag].assets))
+	for _, tag := range tags[1:] {
+		if tag == trash.Release {
+			fmt.Printf("  Trash: %d entries, %d assets\n", trashed, len(byTag[tag].assets))
+		} else {
+			fmt.Printf("  Release %s: %d assets\n", tag, len(byTag[tag].assets))
+		}
 	}
 
 	var issues []verifyIssue
@@ -136,11 +190,20 @@ func verifySingleShelfWithClient(shelf *config.ShelfConfig, fix bool, client Git
 	var toRemove []string
 	for i := range books {
 		b := &books[i]
-		if _, exists := assetNames[b.Source.Asset]; !exists {
+		names := byTag[b.Source.Release].byName
+		missing := ""
+		for _, name := range b.Source.AssetNames() {
+			if _, exists := names[name]; !exists {
+				missing = name
+				break
+			}
+		}
+		if missing != "" {
 			issues = append(issues, verifyIssue{
 				Type:        "orphaned_catalog",
 				BookID:      b.ID,
-				AssetName:   b.Source.Asset,
+				AssetName:   missing,
+				Release:     b.Source.Release,
 				Description: "In catalog but asset missing from release",
 			})
 
@@ -165,26 +228,75 @@ func verifySingleShelfWithClient(shelf *config.ShelfConfig, fix bool, client Git
 		catalogModified = len(toRemove) > 0
 	}
 
+	// Encrypted assets are bigger than the plaintext the catalog describes
+	// by a fixed amount, so their size still shows whether the right file
+	// was uploaded. Their checksum is checked on download. The parts of
+	// multi-part assets all have their size in the catalog.
+	for i := range books {
+		b := &books[i]
+		names := byTag[b.Source.Release].byName
+		for _, p := range b.Source.Parts {
+			asset, exists := names[p.Asset]
+			if !exists {
+				continue
+			}
+			want := p.Size
+			if b.Encryption != "" {
+				want = encrypt.EncryptedSize(p.Size)
+			}
+			if asset.Size != want {
+				issues = append(issues, verifyIssue{
+					Type:        "size_mismatch",
+					BookID:      b.ID,
+					AssetName:   p.Asset,
+					Description: fmt.Sprintf("Part %s is %d bytes, expected %d", p.Asset, asset.Size, want),
+				})
+			}
+		}
+		asset, exists := names[b.Source.Asset]
+		if b.Source.IsMultiPart() || !exists || b.Encryption == "" || b.SizeBytes == 0 {
+			continue
+		}
+		if want := encrypt.EncryptedSize(b.SizeBytes); asset.Size != want {
+			issues = append(issues, verifyIssue{
+				Type:        "size_mismatch",
+				BookID:      b.ID,
+				AssetName:   b.Source.Asset,
+				Description: fmt.Sprintf("Encrypted asset is %d bytes, expected %d", asset.Size, want),
+			})
+		}
+	}
+
 	// 5. Find orphaned assets (in release but not in catalog)
-	for i := range assets {
-		asset := &assets[i]
-		if _, exists := catalogAssets[asset.Name]; !exists {
+	findOrphans := func(assets []github.Asset, release string, referenced map[string]*catalog.Book) {
+		for i := range assets {
+			asset := &assets[i]
+			if _, exists := referenced[asset.Name]; exists {
+				continue
+			}
 			issues = append(issues, verifyIssue{
 				Type:        "orphaned_asset",
 				AssetName:   asset.Name,
+				Release:     release,
 				Description: "In release but not referenced in catalog",
 			})
 
 			if fix {
 				// Delete asset from release
+				orphan := &catalog.Book{Source: catalog.Source{Release: release, Asset: asset.Name}}
+				copyName := keepCopy(op, client, owner, shelf.Repo, orphan, asset.ID)
 				if err := client.DeleteAsset(owner, shelf.Repo, asset.ID); err != nil {
 					warn("Could not delete asset %s: %v", asset.Name, err)
 				} else {
-					ok("Deleted %s from release", asset.Name)
+					ok("Deleted %s from release %s", asset.Name, release)
+					op.Deleted(assetLocation(owner, shelf.Repo, orphan), copyName)
 				}
 			}
 		}
 	}
+	for _, tag := range tags {
+		findOrphans(byTag[tag].assets, tag, byTag[tag].referenced)
+	}
 
 	// 6. Commit catalog if modified
 	if fix && catalogModified {
@@ -197,12 +309,13 @@ func verifySingleShelfWithClient(shelf *config.ShelfConfig, fix bool, client Git
 				warn("Could not commit catalog: %v", err)
 			} else {
 				ok("Catalog committed")
+				op.Catalog(owner, shelf.Repo, catalogPath, catalogData, updatedData)
 
 				// Update README with new count
 				readmeData, _, readmeErr := client.GetFileContent(owner, shelf.Repo, "README.md", "")
 				if readmeErr == nil {
 					originalContent := string(readmeData)
-					readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(books))
+					readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(catalog.Live(books)))
 
 					if readmeContent != originalContent {
 						readmeMsg := "Update README: verify cleanup"
@@ -224,9 +337,13 @@ func verifySingleShelfWithClient(shelf *config.ShelfConfig, fix bool, client Git
 				fmt.Printf("  %s Orphaned catalog entry: %s\n", color.RedString("✗"), color.WhiteString(issue.BookID))
 				fmt.Printf("    - Asset %q missing from release\n", issue.AssetName)
 				fmt.Printf("    - Fix: Remove from catalog\n")
+			} else if issue.Type == "size_mismatch" {
+				fmt.Printf("  %s Size mismatch: %s\n", color.RedString("✗"), color.WhiteString(issue.BookID))
+				fmt.Printf("    - %s\n", issue.Description)
+				fmt.Printf("    - Fix: Re-upload the book (not fixed automatically)\n")
 			} else {
 				fmt.Printf("  %s Orphaned release asset: %s\n", color.RedString("✗"), color.WhiteString(issue.AssetName))
-				fmt.Printf("    - In release but not referenced in catalog\n")
+				fmt.Printf("    - In release %s but not referenced in catalog\n", issue.Release)
 				fmt.Printf("    - Fix: Delete from release\n")
 			}
 			fmt.Println()
@@ -9,6 +9,7 @@ import (
 	"github.com/blackwell-systems/shelfctl/internal/cache"
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/encrypt"
 	ghpkg "github.com/blackwell-systems/shelfctl/internal/github"
 )
 
@@ -112,6 +113,19 @@ func (f *fakeGitHubClientForVerify) CommitFile(owner, repo, filePath string, con
 	return nil
 }
 
+func (f *fakeGitHubClientForVerify) CommitFiles(owner, repo string, files map[string][]byte, message string) error {
+	for path, content := range files {
+		if err := f.CommitFile(owner, repo, path, content, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeGitHubClientForVerify) ReplaceBranch(owner, repo, branch string, files map[string][]byte, message string) (bool, error) {
+	return false, nil
+}
+
 // TestVerifySingleShelf_OrphanedCatalogEntry_NoFix verifies detect mode
 func TestVerifySingleShelf_OrphanedCatalogEntry_NoFix(t *testing.T) {
 	// Redirect stdout to suppress verify output
@@ -526,3 +540,212 @@ func TestVerifySingleShelf_Clean(t *testing.T) {
 		t.Errorf("should not delete assets when clean, got %d calls", len(fake.deleteAssetCalls))
 	}
 }
+
+func TestVerifySingleShelf_EncryptedSizeMismatch(t *testing.T) {
+	origStdout, origCfg := os.Stdout, cfg
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg = origStdout, origCfg })
+	cfg = &config.Config{GitHub: config.GitHubConfig{Owner: "test-owner"}}
+
+	catalogYAML := `- id: good
+  title: Good
+  format: pdf
+  size_bytes: 1000
+  encryption: aes-256-gcm-stream-v1
+  source: {release: library, asset: good.pdf}
+- id: bad
+  title: Bad
+  format: pdf
+  size_bytes: 1000
+  encryption: aes-256-gcm-stream-v1
+  source: {release: library, asset: bad.pdf}
+- id: plain
+  title: Plain
+  format: pdf
+  size_bytes: 1000
+  source: {release: library, asset: plain.pdf}
+`
+	fake := &fakeGitHubClientForVerify{
+		getFileContentFn: func(owner, repo, path, ref string) ([]byte, string, error) {
+			return []byte(catalogYAML), "", nil
+		},
+		listReleaseAssetsFn: func(owner, repo string, releaseID int64) ([]ghpkg.Asset, error) {
+			return []ghpkg.Asset{
+				{ID: 1, Name: "good.pdf", Size: encrypt.EncryptedSize(1000)},
+				{ID: 2, Name: "bad.pdf", Size: 1000}, // uploaded unencrypted
+				{ID: 3, Name: "plain.pdf", Size: 1000},
+			}, nil
+		},
+	}
+
+	shelf := &config.ShelfConfig{Name: "s", Repo: "r", Owner: "test-owner"}
+	issues := verifySingleShelfWithClient(shelf, false, fake, cache.New(t.TempDir()))
+	if len(issues) != 1 || issues[0].Type != "size_mismatch" || issues[0].BookID != "bad" {
+		t.Errorf("issues = %+v", issues)
+	}
+}
+
+func TestVerifySingleShelf_Trash(t *testing.T) {
+	origStdout, origCfg := os.Stdout, cfg
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg = origStdout, origCfg })
+	cfg = &config.Config{GitHub: config.GitHubConfig{Owner: "test-owner"}}
+
+	catalogYAML := `- id: kept
+  title: Kept
+  format: pdf
+  source: {release: library, asset: kept.pdf}
+- id: trashed
+  title: Trashed
+  format: pdf
+  source: {release: trash, asset: trashed.pdf}
+  trashed: {at: "2026-03-01T10:00:00Z", release: library, asset: trashed.pdf}
+- id: lost
+  title: Lost
+  format: pdf
+  source: {release: trash, asset: lost.pdf}
+  trashed: {at: "2026-03-01T10:00:00Z", release: library, asset: lost.pdf}
+`
+	fake := &fakeGitHubClientForVerify{
+		getFileContentFn: func(owner, repo, path, ref string) ([]byte, string, error) {
+			return []byte(catalogYAML), "", nil
+		},
+		getReleaseByTagFn: func(owner, repo, tag string) (*ghpkg.Release, error) {
+			if tag == "trash" {
+				return &ghpkg.Release{ID: 2, TagName: tag}, nil
+			}
+			return &ghpkg.Release{ID: 1, TagName: tag}, nil
+		},
+		listReleaseAssetsFn: func(owner, repo string, releaseID int64) ([]ghpkg.Asset, error) {
+			if releaseID == 2 {
+				return []ghpkg.Asset{{ID: 2, Name: "trashed.pdf"}, {ID: 3, Name: "stray.pdf"}}, nil
+			}
+			return []ghpkg.Asset{{ID: 1, Name: "kept.pdf"}}, nil
+		},
+	}
+
+	shelf := &config.ShelfConfig{Name: "s", Repo: "r", Owner: "test-owner"}
+	issues := verifySingleShelfWithClient(shelf, false, fake, cache.New(t.TempDir()))
+
+	// The trashed book is not an orphan; the one whose trash asset is
+	// missing is, and so is the unreferenced asset in the trash.
+	if len(issues) != 2 {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if issues[0].Type != "orphaned_catalog" || issues[0].BookID != "lost" {
+		t.Errorf("issues[0] = %+v", issues[0])
+	}
+	if issues[1].Type != "orphaned_asset" || issues[1].AssetName != "stray.pdf" || issues[1].Release != "trash" {
+		t.Errorf("issues[1] = %+v", issues[1])
+	}
+}
+
+func TestVerifySingleShelf_MultiPart(t *testing.T) {
+	origStdout, origCfg := os.Stdout, cfg
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg = origStdout, origCfg })
+	cfg = &config.Config{GitHub: config.GitHubConfig{Owner: "test-owner"}}
+
+	catalogYAML := `- id: whole
+  title: Whole
+  format: pdf
+  source:
+    release: library
+    asset: whole.pdf
+    parts:
+      - {asset: whole.pdf.part001, size: 100, sha256: aa}
+      - {asset: whole.pdf.part002, size: 40, sha256: bb}
+- id: torn
+  title: Torn
+  format: pdf
+  source:
+    release: library
+    asset: torn.pdf
+    parts:
+      - {asset: torn.pdf.part001, size: 100, sha256: cc}
+      - {asset: torn.pdf.part002, size: 40, sha256: dd}
+`
+	fake := &fakeGitHubClientForVerify{
+		getFileContentFn: func(owner, repo, path, ref string) ([]byte, string, error) {
+			return []byte(catalogYAML), "", nil
+		},
+		listReleaseAssetsFn: func(owner, repo string, releaseID int64) ([]ghpkg.Asset, error) {
+			return []ghpkg.Asset{
+				{ID: 1, Name: "whole.pdf.part001", Size: 100},
+				{ID: 2, Name: "whole.pdf.part002", Size: 41},
+				{ID: 3, Name: "torn.pdf.part001", Size: 100},
+			}, nil
+		},
+	}
+
+	shelf := &config.ShelfConfig{Name: "s", Repo: "r", Owner: "test-owner"}
+	issues := verifySingleShelfWithClient(shelf, false, fake, cache.New(t.TempDir()))
+
+	// Parts are not orphans; a missing part orphans the entry, and a part
+	// of the wrong size is reported.
+	if len(issues) != 2 {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if issues[0].Type != "orphaned_catalog" || issues[0].BookID != "torn" || issues[0].AssetName != "torn.pdf.part002" {
+		t.Errorf("issues[0] = %+v", issues[0])
+	}
+	if issues[1].Type != "size_mismatch" || issues[1].AssetName != "whole.pdf.part002" {
+		t.Errorf("issues[1] = %+v", issues[1])
+	}
+}
+
+func TestVerifySingleShelf_RolledOverReleases(t *testing.T) {
+	origStdout, origCfg := os.Stdout, cfg
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout, cfg = origStdout, origCfg })
+	cfg = &config.Config{GitHub: config.GitHubConfig{Owner: "test-owner"}}
+
+	catalogYAML := `- id: first
+  title: First
+  format: pdf
+  source: {release: library, asset: first.pdf}
+- id: second
+  title: Second
+  format: pdf
+  source: {release: library-2, asset: second.pdf}
+- id: gone
+  title: Gone
+  format: pdf
+  source: {release: library-3, asset: gone.pdf}
+`
+	ids := map[string]int64{"library": 1, "library-2": 2}
+	fake := &fakeGitHubClientForVerify{
+		getFileContentFn: func(owner, repo, path, ref string) ([]byte, string, error) {
+			return []byte(catalogYAML), "", nil
+		},
+		getReleaseByTagFn: func(owner, repo, tag string) (*ghpkg.Release, error) {
+			id, ok := ids[tag]
+			if !ok {
+				return nil, ghpkg.ErrNotFound
+			}
+			return &ghpkg.Release{ID: id, TagName: tag}, nil
+		},
+		listReleaseAssetsFn: func(owner, repo string, releaseID int64) ([]ghpkg.Asset, error) {
+			if releaseID == 2 {
+				return []ghpkg.Asset{{ID: 2, Name: "second.pdf"}, {ID: 3, Name: "first.pdf"}}, nil
+			}
+			return []ghpkg.Asset{{ID: 1, Name: "first.pdf"}}, nil
+		},
+	}
+
+	shelf := &config.ShelfConfig{Name: "s", Repo: "r", Owner: "test-owner"}
+	issues := verifySingleShelfWithClient(shelf, false, fake, cache.New(t.TempDir()))
+
+	// Each book is checked against its own release: the one in a missing
+	// release is orphaned, and a copy of first.pdf in library-2 is an
+	// orphan there even though library has one that is referenced.
+	if len(issues) != 2 {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if issues[0].Type != "orphaned_catalog" || issues[0].BookID != "gone" {
+		t.Errorf("issues[0] = %+v", issues[0])
+	}
+	if issues[1].Type != "orphaned_asset" || issues[1].AssetName != "first.pdf" || issues[1].Release != "library-2" {
+		t.Errorf("issues[1] = %+v", issues[1])
+	}
+}
@@ -0,0 +1,474 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/util"
+)
+
+// blobsDir holds the cache's file contents, named by SHA256:
+// <baseDir>/.blobs/<first two hex digits>/<sha256>. The per-book paths
+// returned by Path are hard links to these blobs, so a file cached for two
+// books, or for a book that moved, is stored once.
+const blobsDir = ".blobs"
+
+func (m *Manager) blobPath(sum string) string {
+	return filepath.Join(m.baseDir, blobsDir, sum[:2], sum)
+}
+
+// validBlob reports whether the blob of sum exists and still holds that
+// content. A reader that saved annotations into a linked file changed its
+// blob too; such a blob is dropped.
+func (m *Manager) validBlob(sum string) bool {
+	blob := m.blobPath(sum)
+	if _, err := os.Stat(blob); err != nil {
+		return false
+	}
+	if got, err := util.SHA256File(blob); err != nil || got != sum {
+		_ = os.Remove(blob)
+		return false
+	}
+	return true
+}
+
+// linkBlob replaces dest with a hard link to the blob of sum. Where hard
+// links are not supported, dest gets its own copy instead and linkBlob
+// returns false.
+func (m *Manager) linkBlob(sum, dest string) (bool, error) {
+	blob := m.blobPath(sum)
+	tmp := tempPath(dest, ".link")
+	if err := os.Link(blob, tmp); err != nil {
+		if err := copyFile(blob, tmp); err != nil {
+			_ = os.Remove(tmp)
+			return false, err
+		}
+		if err := os.Rename(tmp, dest); err != nil {
+			_ = os.Remove(tmp)
+			return false, err
+		}
+		return false, nil
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		_ = os.Remove(tmp)
+		return false, err
+	}
+	// Renaming onto a link to the same blob does nothing and leaves tmp.
+	_ = os.Remove(tmp)
+	return true, nil
+}
+
+// addBlob moves the file at src into the blob store as sum, unless the
+// store already has it, in which case src is deleted.
+func (m *Manager) addBlob(src, sum string) error {
+	if m.validBlob(sum) {
+		return os.Remove(src)
+	}
+	blob := m.blobPath(sum)
+	if err := os.MkdirAll(filepath.Dir(blob), 0750); err != nil {
+		return err
+	}
+	return os.Rename(src, blob)
+}
+
+// blobShared reports whether a cached file other than key links to the
+// blob of sum. Callers hold indexMu and have called refreshIndex.
+func (m *Manager) blobShared(sum, key string) bool {
+	for k, e := range m.index.entries {
+		if k != key && e.Blob == sum {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseBlob deletes the blob of sum once no cached file links to it.
+// Callers hold indexMu and have called refreshIndex.
+func (m *Manager) releaseBlob(sum string) {
+	if sum == "" || m.blobShared(sum, "") {
+		return
+	}
+	_ = os.Remove(m.blobPath(sum))
+}
+
+// unshare gives a cached file its own copy if it shares its blob with
+// other books, so that annotations saved into it do not show up in the
+// other books' files. Callers hold indexMu and have called refreshIndex.
+func (m *Manager) unshare(key, path string, e *indexEntry) error {
+	if e.Blob == "" || !m.blobShared(e.Blob, key) {
+		return nil
+	}
+	tmp := tempPath(path, ".link")
+	if err := copyFile(path, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	e.Blob = ""
+	if info, err := os.Stat(path); err == nil {
+		e.Size, e.ModTime = info.Size(), info.ModTime().UTC()
+	}
+	return nil
+}
+
+// rehome links a cached file whose content is now sum, such as after a
+// sync, to the blob of sum, and lets go of its old blob.
+// Callers hold indexMu and have called refreshIndex.
+func (m *Manager) rehome(key, path, sum string, e *indexEntry) {
+	old := e.Blob
+	if old == sum {
+		return
+	}
+	if old != "" {
+		if info, err := os.Stat(m.blobPath(old)); err == nil {
+			if pinfo, err := os.Stat(path); err == nil && os.SameFile(info, pinfo) {
+				// Saved in place: the old blob is this file, with new content.
+				_ = os.Remove(m.blobPath(old))
+			}
+		}
+	}
+	e.Blob = ""
+	blob := m.blobPath(sum)
+	if _, err := os.Stat(blob); err != nil {
+		if os.MkdirAll(filepath.Dir(blob), 0750) == nil && os.Link(path, blob) == nil {
+			e.Blob = sum
+		}
+	} else if linked, err := m.linkBlob(sum, path); err == nil && linked {
+		e.Blob = sum
+	}
+	if old != "" && !m.blobShared(old, key) {
+		_ = os.Remove(m.blobPath(old))
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Link makes a book's cached file from the blob with its catalog checksum,
+// if the cache has that content under another book, such as a duplicate
+// on another shelf. It returns whether the book is cached afterwards, so
+// callers can skip the download.
+func (m *Manager) Link(owner, repo, bookID, assetFilename, sha256 string) bool {
+	if m.Exists(owner, repo, bookID, assetFilename) {
+		return true
+	}
+	if sha256 == "" {
+		return false
+	}
+	lock, err := m.lockBooks(indexKey(owner, repo, assetFilename))
+	if err != nil {
+		return false
+	}
+	defer lock.release()
+	defer m.lockIndex()()
+	m.refreshIndex()
+	if !m.validBlob(sha256) {
+		return false
+	}
+	if err := m.EnsureDir(owner, repo, bookID); err != nil {
+		return false
+	}
+	dest := m.Path(owner, repo, bookID, assetFilename)
+	linked, err := m.linkBlob(sha256, dest)
+	if err != nil {
+		return false
+	}
+	key := indexKey(owner, repo, assetFilename)
+	e := m.index.entries[key]
+	if info, err := os.Stat(dest); err == nil {
+		recordFile(&e, owner, bookID, info, sha256)
+	}
+	e.SHA256 = sha256
+	e.Blob = ""
+	if linked {
+		e.Blob = sha256
+	}
+	_ = m.writeIndex(map[string]*indexEntry{key: &e})
+	return true
+}
+
+// Move renames a book's cached file, its covers and its record, such as
+// when the book moves to another shelf, so it need not be downloaded
+// again. Local changes move along with it. Moving a book that is not
+// cached does nothing.
+func (m *Manager) Move(owner, repo, bookID, assetFilename, toOwner, toRepo, toAsset string) error {
+	from, to := indexKey(owner, repo, assetFilename), indexKey(toOwner, toRepo, toAsset)
+	lock, err := m.lockBooks(from, to)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	src := m.Path(owner, repo, bookID, assetFilename)
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	if err := m.EnsureDir(toOwner, toRepo, bookID); err != nil {
+		return err
+	}
+	if err := os.Rename(src, m.Path(toOwner, toRepo, bookID, toAsset)); err != nil {
+		return fmt.Errorf("moving cached file: %w", err)
+	}
+	if toRepo != repo {
+		for _, cover := range []string{m.CoverPath(repo, bookID), m.CatalogCoverPath(repo, bookID)} {
+			if _, err := os.Stat(cover); err != nil {
+				continue
+			}
+			dst := filepath.Join(m.baseDir, toRepo, ".covers", filepath.Base(cover))
+			if os.MkdirAll(filepath.Dir(dst), 0750) == nil {
+				_ = os.Rename(cover, dst)
+			}
+		}
+	}
+
+	defer m.lockIndex()()
+	e, ok := m.refreshIndex()[from]
+	if !ok {
+		return nil
+	}
+	e.Owner = toOwner
+	return m.writeIndex(map[string]*indexEntry{from: nil, to: &e})
+}
+
+// MigrateReport is what Migrate did.
+type MigrateReport struct {
+	Moved  int   // Cached files moved from the layout before owners
+	Files  int   // Cached files moved into the blob store
+	Merged int   // Of those, files that duplicated another and now share it
+	Saved  int64 // Bytes freed by sharing
+}
+
+// NeedsMigration reports whether the cache has files that are not linked
+// to a blob, such as files cached before the blob store existed, or files
+// in the layout before owners.
+func (m *Manager) NeedsMigration() bool {
+	if legacy, err := m.legacyEntries(); err == nil && len(legacy) > 0 {
+		return true
+	}
+	entries, err := m.Entries()
+	if err != nil {
+		return false
+	}
+	idx := m.loadIndex()
+	for _, e := range entries {
+		if idx[indexKey(e.Owner, e.Repo, e.Filename)].Blob == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Migrate moves cached files of the layout before owners,
+// <baseDir>/<repo>/<asset>, under their owner, and cached files that have
+// their own copy, as in caches from before the blob store, into it, so
+// that duplicates share storage. owners maps repo names to the owner of
+// the shelf, for files cached before the database recorded owners; files
+// whose owner is unknown stay where they are. Migrate hashes each file
+// once and is safe to run again.
+func (m *Manager) Migrate(owners map[string]string) (MigrateReport, error) {
+	var report MigrateReport
+	lock, err := m.lockCache(true)
+	if err != nil {
+		return report, err
+	}
+	defer lock.release()
+
+	legacy, err := m.legacyEntries()
+	if err != nil {
+		return report, err
+	}
+	var lastErr error
+	for _, e := range legacy {
+		if err := m.relocate(e, owners[e.Repo]); err != nil {
+			lastErr = err
+			continue
+		}
+		report.Moved++
+	}
+	m.dropLegacyKeys()
+
+	entries, err := m.Entries()
+	if err != nil {
+		return report, err
+	}
+	for _, e := range entries {
+		if m.lookupIndex(e.Owner, e.Repo, e.Filename).Blob != "" {
+			continue
+		}
+		sum, _, err := m.SHA256(e.Owner, e.Repo, "", e.Filename)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		merged, err := m.adopt(e, sum)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		report.Files++
+		if merged {
+			report.Merged++
+			report.Saved += e.Size
+		}
+	}
+	if lastErr != nil {
+		return report, fmt.Errorf("some files could not be migrated: %w", lastErr)
+	}
+	return report, nil
+}
+
+// adopt links a cached file with content sum to its blob: the file becomes
+// the blob, or is replaced by a link to an existing blob with the same
+// content, in which case adopt returns true.
+func (m *Manager) adopt(e Entry, sum string) (bool, error) {
+	defer m.lockIndex()()
+	key := indexKey(e.Owner, e.Repo, e.Filename)
+	ie := m.refreshIndex()[key]
+
+	merged, linked := false, false
+	if m.validBlob(sum) {
+		var err error
+		if linked, err = m.linkBlob(sum, e.Path); err != nil {
+			return false, err
+		}
+		merged = linked
+	} else {
+		blob := m.blobPath(sum)
+		if err := os.MkdirAll(filepath.Dir(blob), 0750); err != nil {
+			return false, err
+		}
+		linked = os.Link(e.Path, blob) == nil
+	}
+	if !linked {
+		return false, nil
+	}
+
+	ie.Blob = sum
+	if info, err := os.Stat(e.Path); err == nil {
+		recordFile(&ie, "", "", info, sum)
+	}
+	return merged, m.writeIndex(map[string]*indexEntry{key: &ie})
+}
+
+// legacyEntries returns the cached files of the layout before owners,
+// <baseDir>/<repo>/<asset>, which Entries leaves out.
+func (m *Manager) legacyEntries() ([]Entry, error) {
+	dirs, err := os.ReadDir(m.baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []Entry
+	for _, d := range dirs {
+		if !d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(m.baseDir, d.Name()))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, f := range files {
+			name := f.Name()
+			if f.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".link") {
+				continue
+			}
+			info, err := f.Info()
+			if err != nil {
+				continue
+			}
+			entries = append(entries, Entry{
+				Path: filepath.Join(m.baseDir, d.Name(), name), Repo: d.Name(), Filename: name,
+				Size: info.Size(), LastOpened: info.ModTime(),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// relocate moves a file of the layout before owners, with its record, to
+// its path under the owner its record names, or under owner if it has
+// none. If the book was cached again under its owner meanwhile, the old
+// file is deleted when it has the same content, and kept otherwise.
+func (m *Manager) relocate(e Entry, owner string) error {
+	defer m.lockIndex()()
+	from := e.Repo + "/" + e.Filename
+	ie, known := m.refreshIndex()[from]
+	if ie.Owner != "" {
+		owner = ie.Owner
+	}
+	if owner == "" {
+		return fmt.Errorf("%s: no shelf with repo %q is configured", from, e.Repo)
+	}
+	dest := m.Path(owner, e.Repo, "", e.Filename)
+	if _, err := os.Stat(dest); err == nil {
+		a, err := util.SHA256File(e.Path)
+		if err != nil {
+			return err
+		}
+		if b, err := util.SHA256File(dest); err != nil || a != b {
+			return fmt.Errorf("%s: cached again as %s with other content, remove one of them", from, dest)
+		}
+		if err := os.Remove(e.Path); err != nil {
+			return err
+		}
+		if known {
+			m.forget(from)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+		return err
+	}
+	if err := os.Rename(e.Path, dest); err != nil {
+		return fmt.Errorf("moving %s: %w", from, err)
+	}
+	if !known {
+		return nil
+	}
+	ie.Owner = owner
+	return m.writeIndex(map[string]*indexEntry{from: nil, indexKey(owner, e.Repo, e.Filename): &ie})
+}
+
+// dropLegacyKeys forgets records of the layout before owners whose file is
+// gone, such as pins of books that were never downloaded; cache refresh
+// pins those again under their owner.
+func (m *Manager) dropLegacyKeys() {
+	defer m.lockIndex()()
+	changes := map[string]*indexEntry{}
+	for key := range m.refreshIndex() {
+		if _, _, _, ok := splitKey(key); ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(m.baseDir, filepath.FromSlash(key))); os.IsNotExist(err) {
+			changes[key] = nil
+		}
+	}
+	if len(changes) > 0 {
+		_ = m.writeIndex(changes)
+	}
+}
@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/util"
+)
+
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+	ia, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return os.SameFile(ia, ib)
+}
+
+func blobCount(t *testing.T, m *Manager) int {
+	t.Helper()
+	n := 0
+	_ = filepath.Walk(filepath.Join(m.baseDir, blobsDir), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+func TestStore_DuplicatesShareBlob(t *testing.T) {
+	m := New(t.TempDir())
+	a, err := m.Store("o", "shelf-a", "sicp", "sicp.pdf", strings.NewReader("same book"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := m.Store("o", "shelf-b", "sicp", "sicp-copy.pdf", strings.NewReader("same book"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameFile(t, a, b) || blobCount(t, m) != 1 {
+		t.Fatalf("duplicates are stored separately (%d blobs)", blobCount(t, m))
+	}
+
+	if err := m.Remove("o", "shelf-a", "sicp", "sicp.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(b); err != nil || string(data) != "same book" {
+		t.Fatalf("other copy after Remove: %q, %v", data, err)
+	}
+	if blobCount(t, m) != 1 {
+		t.Error("Remove deleted a blob still in use")
+	}
+	if err := m.Remove("o", "shelf-b", "sicp", "sicp-copy.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if n := blobCount(t, m); n != 0 {
+		t.Errorf("%d blobs left after removing every book", n)
+	}
+}
+
+func TestLink_ReusesBlob(t *testing.T) {
+	m := New(t.TempDir())
+	sum, _ := util.SHA256Reader(strings.NewReader("paper"))
+	if _, err := m.Store("o", "papers", "raft", "raft.pdf", strings.NewReader("paper"), sum); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Link("o", "archive", "raft", "raft.pdf", "0000"+sum[4:]) {
+		t.Error("Link succeeded for unknown content")
+	}
+	if !m.Link("o", "archive", "raft", "raft.pdf", sum) {
+		t.Fatal("Link did not reuse the cached blob")
+	}
+	if !sameFile(t, m.Path("o", "papers", "raft", "raft.pdf"), m.Path("o", "archive", "raft", "raft.pdf")) {
+		t.Error("linked book does not share the blob")
+	}
+	if m.HasBeenModified("o", "archive", "raft", "raft.pdf", sum) {
+		t.Error("linked book counts as modified")
+	}
+}
+
+func TestMove_KeepsFileAndRecord(t *testing.T) {
+	m := New(t.TempDir())
+	storeAged(t, m, "src", "book.pdf", "annotated", time.Hour)
+	opened := m.LastOpened("o", "src", "book", "book.pdf")
+
+	if err := m.Move("o", "src", "book", "book.pdf", "o", "dst", "book.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Exists("o", "src", "book", "book.pdf") || !m.Exists("o", "dst", "book", "book.pdf") {
+		t.Fatal("Move did not move the file")
+	}
+	if got := m.LastOpened("o", "dst", "book", "book.pdf"); !got.Equal(opened) {
+		t.Errorf("LastOpened after Move = %v, want %v", got, opened)
+	}
+	if !m.LastOpened("o", "src", "book", "book.pdf").IsZero() {
+		t.Error("Move kept the old record")
+	}
+}
+
+func TestMarkOpened_UnsharesDuplicates(t *testing.T) {
+	m := New(t.TempDir())
+	a, _ := m.Store("o", "shelf-a", "b", "b.pdf", strings.NewReader("book"), "")
+	b, _ := m.Store("o", "shelf-b", "b", "b.pdf", strings.NewReader("book"), "")
+
+	if err := m.MarkOpened("o", "shelf-a", "b", "b.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if sameFile(t, a, b) {
+		t.Fatal("opened book still shares its file")
+	}
+	// A reader saving annotations in place leaves the other copy alone.
+	if err := os.WriteFile(a, []byte("annotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(b); string(data) != "book" {
+		t.Errorf("other copy = %q", data)
+	}
+}
+
+func TestMarkOpened_BusySharedBook(t *testing.T) {
+	dir := t.TempDir()
+	m, other := New(dir), New(dir)
+	a, _ := m.Store("o", "shelf-a", "b", "b.pdf", strings.NewReader("book"), "")
+	b, _ := m.Store("o", "shelf-b", "b", "b.pdf", strings.NewReader("book"), "")
+
+	lock, err := other.lockBooks(indexKey("o", "shelf-a", "b.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := m.LastOpened("o", "shelf-a", "b", "b.pdf")
+	if err := m.MarkOpened("o", "shelf-a", "b", "b.pdf"); !errors.Is(err, ErrBusy) {
+		t.Errorf("MarkOpened of a held shared book = %v, want busy", err)
+	}
+	if !sameFile(t, a, b) {
+		t.Error("held book was unshared")
+	}
+	if !m.LastOpened("o", "shelf-a", "b", "b.pdf").After(before) {
+		t.Error("open not recorded")
+	}
+	// A book with a file of its own needs no lock.
+	if err := m.MarkOpened("o", "shelf-b", "b", "b.pdf"); err != nil {
+		t.Errorf("MarkOpened of an unshared book: %v", err)
+	}
+	lock.release()
+}
+
+func TestMarkSynced_RehomesEditedFile(t *testing.T) {
+	m := New(t.TempDir())
+	orig, _ := util.SHA256Reader(strings.NewReader("book"))
+	path, err := m.Store("o", "r", "b", "b.pdf", strings.NewReader("book"), orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saved in place: the blob's content changes along with the file.
+	if err := os.WriteFile(path, []byte("annotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sum, _, err := m.SHA256("o", "r", "b", "b.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MarkSynced("o", "r", "b", "b.pdf", sum); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(m.blobPath(orig)); !os.IsNotExist(err) {
+		t.Error("the stale blob is still there")
+	}
+	if !sameFile(t, path, m.blobPath(sum)) {
+		t.Error("synced file is not filed under its new checksum")
+	}
+	if m.Link("o", "other", "b", "b.pdf", orig) {
+		t.Error("Link used a blob whose content changed")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	m := New(t.TempDir())
+	for _, f := range []struct{ repo, name, content string }{
+		{"a", "x.pdf", "dup"},
+		{"b", "x.pdf", "dup"},
+		{"b", "y.pdf", "unique"},
+	} {
+		dir := filepath.Join(m.baseDir, f.repo)
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, f.name), []byte(f.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !m.NeedsMigration() {
+		t.Fatal("NeedsMigration = false for an old cache")
+	}
+
+	report, err := m.Migrate(map[string]string{"a": "o", "b": "o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Moved != 3 || report.Files != 3 || report.Merged != 1 || report.Saved != 3 {
+		t.Errorf("report = %+v", report)
+	}
+	if !sameFile(t, m.Path("o", "a", "", "x.pdf"), m.Path("o", "b", "", "x.pdf")) {
+		t.Error("duplicates do not share storage after Migrate")
+	}
+	if m.NeedsMigration() {
+		t.Error("NeedsMigration = true after Migrate")
+	}
+	if report, _ := m.Migrate(nil); report.Files != 0 {
+		t.Errorf("second Migrate moved %d files", report.Files)
+	}
+}
+
+func TestMigrate_MovesUnderOwners(t *testing.T) {
+	m := New(t.TempDir())
+	for _, f := range []struct{ repo, name string }{
+		{"recorded", "a.pdf"},
+		{"configured", "b.pdf"},
+		{"unknown", "c.pdf"},
+	} {
+		dir := filepath.Join(m.baseDir, f.repo)
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, f.name), []byte(f.name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A record from before owners were part of the key.
+	unlock := m.lockIndex()
+	m.refreshIndex()
+	err := m.writeIndex(map[string]*indexEntry{"recorded/a.pdf": {Owner: "alice", Pinned: true}})
+	unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := m.Migrate(map[string]string{"recorded": "bob", "configured": "bob"})
+	if err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("Migrate = %v, want an error for the repo without owner", err)
+	}
+	if report.Moved != 2 {
+		t.Errorf("report = %+v", report)
+	}
+	if !m.Exists("alice", "recorded", "", "a.pdf") || !m.IsPinned("alice", "recorded", "", "a.pdf") {
+		t.Error("recorded owner not used, or record not moved along")
+	}
+	if !m.Exists("bob", "configured", "", "b.pdf") {
+		t.Error("configured owner not used")
+	}
+	if _, err := os.Stat(filepath.Join(m.baseDir, "unknown", "c.pdf")); err != nil {
+		t.Error("file without owner was not left in place")
+	}
+	if !m.NeedsMigration() {
+		t.Error("NeedsMigration = false with a file left to move")
+	}
+}
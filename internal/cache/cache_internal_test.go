@@ -179,7 +179,7 @@ func TestRemove_NonExistent(t *testing.T) {
 
 func TestGenerateHTML_Empty(t *testing.T) {
 	m := New("/tmp")
-	html := m.generateHTML(nil)
+	html := m.generateHTML(nil, false)
 	if !strings.Contains(html, "<!DOCTYPE html>") {
 		t.Error("should contain DOCTYPE")
 	}
@@ -201,7 +201,7 @@ func TestGenerateHTML_WithBooks(t *testing.T) {
 		},
 	}
 	m := New("/tmp")
-	html := m.generateHTML(books)
+	html := m.generateHTML(books, false)
 	if !strings.Contains(html, "sicp") {
 		t.Error("should contain book ID")
 	}
@@ -227,7 +227,7 @@ func TestGenerateHTML_UncachedSection(t *testing.T) {
 		},
 	}
 	m := New("/tmp")
-	html := m.generateHTML(books)
+	html := m.generateHTML(books, false)
 	if !strings.Contains(html, "Not yet downloaded") {
 		t.Error("should contain uncached section title")
 	}
@@ -236,6 +236,45 @@ func TestGenerateHTML_UncachedSection(t *testing.T) {
 	}
 }
 
+func TestGenerateLiveHTML(t *testing.T) {
+	books := []IndexBook{
+		{
+			Book:      catalog.Book{ID: "cached-book", Title: "Cached"},
+			ShelfName: "my shelf",
+			FilePath:  "/cache/cached.pdf",
+			IsCached:  true,
+		},
+		{
+			Book:      catalog.Book{ID: "uncached-book", Title: "Uncached"},
+			ShelfName: "my shelf",
+		},
+	}
+	m := New("/tmp")
+
+	live := m.GenerateLiveHTML(books)
+	for _, want := range []string{
+		`href="/books/my%20shelf/cached-book/file?inline=1"`,
+		`class="book-card uncached live" data-shelf="my shelf"`,
+		`src="/books/my%20shelf/uncached-book/cover"`,
+		`data-action="sync"`,
+		`id="edit-dialog"`,
+	} {
+		if !strings.Contains(live, want) {
+			t.Errorf("live page missing %q", want)
+		}
+	}
+	if strings.Contains(live, "file:///cache/cached.pdf") || strings.Contains(live, "shelfctl open uncached-book") {
+		t.Error("live page should not use static links or hints")
+	}
+
+	static := m.generateHTML(books, false)
+	for _, unwanted := range []string{"data-action", "edit-dialog", "/books/"} {
+		if strings.Contains(static, unwanted) {
+			t.Errorf("static index contains live-only %q", unwanted)
+		}
+	}
+}
+
 // --- renderUncachedCard ---
 
 func TestRenderUncachedCard(t *testing.T) {
@@ -249,7 +288,7 @@ func TestRenderUncachedCard(t *testing.T) {
 		},
 	}
 	m := New("")
-	m.renderUncachedCard(&s, book, 0)
+	m.renderUncachedCard(&s, book, 0, false)
 	html := s.String()
 
 	if !strings.Contains(html, "uncached") {
@@ -309,7 +348,7 @@ func TestRenderBookCard_WithCover(t *testing.T) {
 		IsCached:  true,
 	}
 	m := New("/tmp")
-	m.renderBookCard(&s, book, 0)
+	m.renderBookCard(&s, book, 0, false)
 	html := s.String()
 
 	if !strings.Contains(html, "<img") {
@@ -329,7 +368,7 @@ func TestRenderBookCard_NoCover(t *testing.T) {
 		IsCached: true,
 	}
 	m := New("/tmp")
-	m.renderBookCard(&s, book, 0)
+	m.renderBookCard(&s, book, 0, false)
 	html := s.String()
 
 	if !strings.Contains(html, "no-cover") {
@@ -350,7 +389,7 @@ func TestGenerateHTML_TagFilters(t *testing.T) {
 		},
 	}
 	m := New("/tmp")
-	html := m.generateHTML(books)
+	html := m.generateHTML(books, false)
 	if !strings.Contains(html, "tag-filter") {
 		t.Error("should contain tag filter buttons")
 	}
@@ -368,7 +407,7 @@ func TestGenerateHTML_CachedCountSubtitle(t *testing.T) {
 		{Book: catalog.Book{ID: "b"}, IsCached: false},
 	}
 	m := New("/tmp")
-	html := m.generateHTML(books)
+	html := m.generateHTML(books, false)
 	if !strings.Contains(html, "2 books (1 cached)") {
 		t.Errorf("subtitle should show cached count, got html containing subtitle")
 	}
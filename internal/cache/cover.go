@@ -5,6 +5,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+
+	"github.com/blackwell-systems/shelfctl/internal/ingest"
 )
 
 // IsPopplerInstalled checks if pdftoppm is available on the system.
@@ -13,15 +15,43 @@ func IsPopplerInstalled() bool {
 	return err == nil
 }
 
-// ExtractCover extracts the first page of a PDF as a JPEG thumbnail.
-// Returns the path to the generated cover image, or empty string on failure.
-// Only one cover exists per book - overwrites if already present.
+// ExtractCover writes a JPEG thumbnail of a PDF's cover to CoverPath.
+// The first page is rendered with pdftoppm when poppler is installed;
+// otherwise, or if rendering fails, the largest image embedded in the first
+// page is used. Returns the path to the generated cover image, or empty
+// string on failure. Only one cover exists per book - overwrites if already
+// present.
 func (m *Manager) ExtractCover(repo, bookID, pdfPath string) string {
-	// Check if pdftoppm is available
-	if !IsPopplerInstalled() {
-		return "" // Not installed
+	if IsPopplerInstalled() {
+		if path := m.renderCover(repo, bookID, pdfPath); path != "" {
+			return path
+		}
+	}
+	return m.extractEmbeddedCover(repo, bookID, pdfPath)
+}
+
+// extractEmbeddedCover thumbnails the largest image on the first page.
+func (m *Manager) extractEmbeddedCover(repo, bookID, pdfPath string) string {
+	data, _, err := ingest.ExtractPDFCoverImage(pdfPath)
+	if err != nil {
+		return ""
+	}
+
+	coversDir := filepath.Join(m.baseDir, repo, ".covers")
+	if err := os.MkdirAll(coversDir, 0750); err != nil {
+		return ""
+	}
+
+	coverPath := m.CoverPath(repo, bookID)
+	if err := writeThumbnail(data, coverPath); err != nil {
+		return ""
 	}
+	return coverPath
+}
 
+// renderCover extracts the first page of a PDF as a JPEG thumbnail using
+// pdftoppm.
+func (m *Manager) renderCover(repo, bookID, pdfPath string) string {
 	// Ensure .covers directory exists
 	coversDir := filepath.Join(m.baseDir, repo, ".covers")
 	if err := os.MkdirAll(coversDir, 0750); err != nil {
@@ -89,11 +119,11 @@ func (m *Manager) ExtractCover(repo, bookID, pdfPath string) string {
 func GetPopplerInstallHint() string {
 	switch runtime.GOOS {
 	case "darwin":
-		return "Install poppler for PDF cover thumbnails:\n  brew install poppler"
+		return "Install poppler for rendered PDF cover thumbnails:\n  brew install poppler"
 	case "linux":
-		return "Install poppler for PDF cover thumbnails:\n  See: https://github.com/blackwell-systems/shelfctl#install"
+		return "Install poppler for rendered PDF cover thumbnails:\n  See: https://github.com/blackwell-systems/shelfctl#install"
 	default:
-		return "Install poppler-utils to enable PDF cover thumbnails"
+		return "Install poppler-utils to enable rendered PDF cover thumbnails"
 	}
 }
 
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractCover_EmbeddedImageWithoutPoppler(t *testing.T) {
+	t.Setenv("PATH", "") // hide pdftoppm
+
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, image.NewGray(image.Rect(0, 0, 400, 600)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reader rebuilds the xref by scanning, so no offsets are needed.
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	pdf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	pdf.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Resources << /XObject << /Im0 4 0 R >> >> >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Subtype /Image /Width 400 /Height 600 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n", jpg.Len())
+	pdf.Write(jpg.Bytes())
+	pdf.WriteString("\nendstream\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+
+	pdfPath := filepath.Join(t.TempDir(), "scan.pdf")
+	if err := os.WriteFile(pdfPath, pdf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(t.TempDir())
+	coverPath := m.ExtractCover("shelf-books", "scan", pdfPath)
+	if coverPath != m.CoverPath("shelf-books", "scan") {
+		t.Fatalf("coverPath = %q", coverPath)
+	}
+	f, err := os.Open(coverPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	thumb, err := jpeg.Decode(f)
+	if err != nil {
+		t.Fatalf("cover is not a JPEG: %v", err)
+	}
+	if thumb.Bounds().Dy() != thumbnailHeight {
+		t.Errorf("thumbnail height = %d, want %d", thumb.Bounds().Dy(), thumbnailHeight)
+	}
+}
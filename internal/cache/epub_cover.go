@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/ingest"
+)
+
+// ExtractEPUBCover writes a JPEG thumbnail of the cover image declared in
+// an EPUB's package document. It shares the <book-id>.jpg slot used by PDF
+// thumbnails. Returns the cover path, or empty string on failure.
+func (m *Manager) ExtractEPUBCover(repo, bookID, epubPath string) string {
+	data, _, err := ingest.ExtractEPUBCover(epubPath)
+	if err != nil {
+		return ""
+	}
+
+	coversDir := filepath.Join(m.baseDir, repo, ".covers")
+	if err := os.MkdirAll(coversDir, 0750); err != nil {
+		return ""
+	}
+
+	coverPath := m.CoverPath(repo, bookID)
+	if err := writeThumbnail(data, coverPath); err != nil {
+		return ""
+	}
+	return coverPath
+}
+
+// isEPUB checks if the filename indicates an EPUB file
+func isEPUB(filename string) bool {
+	return filepath.Ext(strings.ToLower(filename)) == ".epub"
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScaleToHeight(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 600, 900))
+	got := scaleToHeight(src, 300)
+	if got.Bounds().Dx() != 200 || got.Bounds().Dy() != 300 {
+		t.Errorf("scaled bounds = %v, want 200x300", got.Bounds())
+	}
+
+	small := image.NewRGBA(image.Rect(0, 0, 10, 20))
+	if scaleToHeight(small, 300) != image.Image(small) {
+		t.Error("small images should not be scaled")
+	}
+}
+
+func TestExtractEPUBCover_WritesThumbnail(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 600))
+	for y := 0; y < 600; y++ {
+		for x := 0; x < 400; x++ {
+			img.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	epubPath := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(epubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	files := map[string][]byte{
+		"META-INF/container.xml": []byte(`<container><rootfiles><rootfile full-path="content.opf"/></rootfiles></container>`),
+		"content.opf":            []byte(`<package><metadata><title>T</title></metadata><manifest><item id="c" href="cover.png" media-type="image/png" properties="cover-image"/></manifest></package>`),
+		"cover.png":              pngBuf.Bytes(),
+	}
+	for name, data := range files {
+		w, _ := zw.Create(name)
+		_, _ = w.Write(data)
+	}
+	_ = zw.Close()
+	_ = f.Close()
+
+	m := New(t.TempDir())
+	coverPath := m.ExtractEPUBCover("shelf-books", "earthsea", epubPath)
+	if coverPath == "" {
+		t.Fatal("ExtractEPUBCover() returned empty path")
+	}
+	if coverPath != m.CoverPath("shelf-books", "earthsea") {
+		t.Errorf("coverPath = %q", coverPath)
+	}
+
+	out, err := os.Open(coverPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = out.Close() }()
+	thumb, err := jpeg.Decode(out)
+	if err != nil {
+		t.Fatalf("cover is not a JPEG: %v", err)
+	}
+	if thumb.Bounds().Dy() != thumbnailHeight {
+		t.Errorf("thumbnail height = %d, want %d", thumb.Bounds().Dy(), thumbnailHeight)
+	}
+}
+
+func TestExtractEPUBCover_InvalidFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bad.epub")
+	_ = os.WriteFile(p, []byte("nope"), 0600)
+	m := New(t.TempDir())
+	if got := m.ExtractEPUBCover("r", "b", p); got != "" {
+		t.Errorf("expected empty path, got %q", got)
+	}
+}
+
+func TestIsEPUB(t *testing.T) {
+	if !isEPUB("Book.EPUB") || isEPUB("book.pdf") {
+		t.Error("isEPUB misclassified")
+	}
+}
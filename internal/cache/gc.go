@@ -0,0 +1,246 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is a cached book file.
+type Entry struct {
+	Path     string // Full path to cached file
+	Owner    string // Repository owner
+	Repo     string // Repository name
+	Filename string // Asset filename
+	Size     int64  // File size in bytes
+	// LastOpened is when the book was last opened or downloaded, or the
+	// file's modification time if the cache has no record of it.
+	LastOpened time.Time
+
+	sum string // Checksum PlanGC found the file unchanged against
+}
+
+// GCOptions says how far GC shrinks the cache and what it must keep.
+type GCOptions struct {
+	// MaxSize is the size in bytes the cache is shrunk to.
+	MaxSize int64
+	// Checksums maps Key of each book to the book's checksum in its catalog.
+	// A file that differs from it has local changes and is kept. Files
+	// without a checksum here or from their download are kept too, since
+	// GC cannot tell whether they were changed.
+	Checksums map[string]string
+	// Keep lists files, as Key keys, that are never evicted, in
+	// addition to pinned files.
+	Keep map[string]bool
+}
+
+// GCPlan is what GC found and would evict.
+type GCPlan struct {
+	Size     int64   // Cache size before eviction
+	Evict    []Entry // Least recently opened first
+	Freed    int64   // Bytes freed by evicting Evict
+	Modified []Entry // Files kept because they have local changes
+	Unknown  []Entry // Files kept because their checksum is unknown
+	Pinned   []Entry // Files kept because a pin selects them
+}
+
+// Over reports whether the cache is still larger than maxSize after the
+// plan's evictions.
+func (p GCPlan) Over(maxSize int64) bool {
+	return p.Size-p.Freed > maxSize
+}
+
+// SetMaxSize turns on automatic GC after each Store, keeping the cache
+// within maxSize bytes. 0 turns it off.
+func (m *Manager) SetMaxSize(maxSize int64) {
+	m.maxSize = maxSize
+}
+
+// MaxSize returns the limit set with SetMaxSize, or 0.
+func (m *Manager) MaxSize() int64 {
+	return m.maxSize
+}
+
+// Entries returns the cached book files, skipping covers, temp files and
+// the cache's own bookkeeping.
+func (m *Manager) Entries() ([]Entry, error) {
+	idx := m.loadIndex()
+	var entries []Entry
+	err := filepath.Walk(m.baseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Other processes delete files, such as their temp files,
+			// while the walk runs.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != m.baseDir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || strings.HasSuffix(path, ".tmp") || strings.HasSuffix(path, ".link") {
+			return nil
+		}
+		rel, err := filepath.Rel(m.baseDir, path)
+		if err != nil {
+			return nil
+		}
+		// Files of the layout before owners are left to Migrate.
+		owner, repo, filename, ok := splitKey(filepath.ToSlash(rel))
+		if !ok {
+			return nil
+		}
+		e := Entry{Path: path, Owner: owner, Repo: repo, Filename: filename, Size: info.Size()}
+		e.LastOpened = idx[indexKey(e.Owner, e.Repo, e.Filename)].LastOpened
+		if e.LastOpened.IsZero() {
+			e.LastOpened = info.ModTime()
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking cache directory: %w", err)
+	}
+	return entries, nil
+}
+
+// PlanGC picks the least recently opened files to evict until the cache
+// fits in opts.MaxSize, passing over modified and pinned files and those in
+// opts.Keep.
+// Nothing is deleted; see Evict.
+func (m *Manager) PlanGC(opts GCOptions) (GCPlan, error) {
+	entries, err := m.Entries()
+	if err != nil {
+		return GCPlan{}, err
+	}
+	idx := m.loadIndex()
+	// Books sharing a blob are links to one file, which takes space once
+	// and is only freed when the last of them is evicted.
+	links := map[string]int{}
+	var plan GCPlan
+	for _, e := range entries {
+		if blob := idx[indexKey(e.Owner, e.Repo, e.Filename)].Blob; blob != "" {
+			links[blob]++
+			if links[blob] > 1 {
+				continue
+			}
+		}
+		plan.Size += e.Size
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastOpened.Before(entries[j].LastOpened)
+	})
+
+	for _, e := range entries {
+		if !plan.Over(opts.MaxSize) {
+			break
+		}
+		key := indexKey(e.Owner, e.Repo, e.Filename)
+		if opts.Keep[key] {
+			continue
+		}
+		if idx[key].Pinned {
+			plan.Pinned = append(plan.Pinned, e)
+			continue
+		}
+		sum := opts.Checksums[key]
+		if sum == "" {
+			sum = idx[key].SHA256
+		}
+		if sum == "" {
+			plan.Unknown = append(plan.Unknown, e)
+			continue
+		}
+		if got, _, err := m.SHA256(e.Owner, e.Repo, "", e.Filename); err != nil || got != sum {
+			plan.Modified = append(plan.Modified, e)
+			continue
+		}
+		e.sum = sum
+		plan.Evict = append(plan.Evict, e)
+		if blob := idx[key].Blob; blob != "" {
+			if links[blob]--; links[blob] > 0 {
+				continue
+			}
+		}
+		plan.Freed += e.Size
+	}
+	return plan, nil
+}
+
+// Evict deletes the files of a plan and forgets them. Covers are kept, as
+// browse shows them for books that are not cached too. Files pinned or
+// changed since the plan was made are kept. Returns the number of files
+// deleted.
+func (m *Manager) Evict(entries []Entry) (int, error) {
+	lock, err := m.lockCache(true)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.release()
+
+	// With the cache locked no shelfctl process changes the files, so
+	// check them again: a reader may have saved annotations since.
+	var evict []Entry
+	for _, e := range entries {
+		if m.evictable(e) {
+			evict = append(evict, e)
+		}
+	}
+
+	defer m.lockIndex()()
+	m.refreshIndex()
+	deleted := 0
+	var lastErr error
+	for _, e := range evict {
+		if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+			lastErr = err
+			continue
+		}
+		m.forget(indexKey(e.Owner, e.Repo, e.Filename))
+		deleted++
+	}
+	if lastErr != nil {
+		return deleted, fmt.Errorf("some files failed to delete: %w", lastErr)
+	}
+	return deleted, nil
+}
+
+// evictable reports whether a planned file is still unpinned and matches
+// the checksum it was planned with.
+func (m *Manager) evictable(e Entry) bool {
+	ie := m.lookupIndex(e.Owner, e.Repo, e.Filename)
+	if ie.Pinned {
+		return false
+	}
+	sum := e.sum
+	if sum == "" {
+		sum = ie.SHA256
+	}
+	if sum == "" {
+		return false
+	}
+	got, _, err := m.SHA256(e.Owner, e.Repo, "", e.Filename)
+	return err == nil && got == sum
+}
+
+// autoGC shrinks the cache to the limit set with SetMaxSize after a file
+// was stored, never evicting that file. It is best-effort.
+func (m *Manager) autoGC(owner, repo, assetFilename string) {
+	if m.maxSize <= 0 {
+		return
+	}
+	plan, err := m.PlanGC(GCOptions{
+		MaxSize: m.maxSize,
+		Keep:    map[string]bool{indexKey(owner, repo, assetFilename): true},
+	})
+	if err != nil || len(plan.Evict) == 0 {
+		return
+	}
+	_, _ = m.Evict(plan.Evict)
+}
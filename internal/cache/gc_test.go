@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/util"
+)
+
+// storeAged stores content and backdates its last-open time by age.
+func storeAged(t *testing.T, m *Manager, repo, name, content string, age time.Duration) string {
+	t.Helper()
+	sum, _ := util.SHA256Reader(strings.NewReader(content))
+	path, err := m.Store("o", repo, name, name, strings.NewReader(content), sum)
+	if err != nil {
+		t.Fatalf("Store(%s): %v", name, err)
+	}
+	if err := m.updateIndex("o", repo, name, func(e *indexEntry) bool {
+		e.LastOpened = time.Now().Add(-age)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func names(entries []Entry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Repo+"/"+e.Filename)
+	}
+	return out
+}
+
+func TestPlanGC_EvictsLeastRecentlyOpened(t *testing.T) {
+	m := New(t.TempDir())
+	storeAged(t, m, "r", "old.pdf", strings.Repeat("a", 100), 3*time.Hour)
+	storeAged(t, m, "r", "mid.pdf", strings.Repeat("b", 100), 2*time.Hour)
+	storeAged(t, m, "r", "new.pdf", strings.Repeat("c", 100), time.Hour)
+
+	plan, err := m.PlanGC(GCOptions{MaxSize: 150})
+	if err != nil {
+		t.Fatalf("PlanGC: %v", err)
+	}
+	if plan.Size != 300 || plan.Freed != 200 {
+		t.Errorf("Size, Freed = %d, %d; want 300, 200", plan.Size, plan.Freed)
+	}
+	if got := strings.Join(names(plan.Evict), ","); got != "r/old.pdf,r/mid.pdf" {
+		t.Errorf("Evict = %s", got)
+	}
+
+	if n, err := m.Evict(plan.Evict); err != nil || n != 2 {
+		t.Fatalf("Evict = %d, %v", n, err)
+	}
+	if m.Exists("o", "r", "", "old.pdf") || !m.Exists("o", "r", "", "new.pdf") {
+		t.Error("Evict removed the wrong files")
+	}
+	if !m.LastOpened("o", "r", "", "old.pdf").IsZero() {
+		t.Error("Evict kept the index entry of an evicted file")
+	}
+}
+
+func TestPlanGC_SharedBlob(t *testing.T) {
+	m := New(t.TempDir())
+	storeAged(t, m, "a", "dup.pdf", strings.Repeat("x", 100), 3*time.Hour)
+	storeAged(t, m, "b", "dup.pdf", strings.Repeat("x", 100), 2*time.Hour)
+	storeAged(t, m, "c", "new.pdf", strings.Repeat("y", 100), time.Hour)
+
+	if plan, _ := m.PlanGC(GCOptions{MaxSize: 250}); plan.Size != 200 || len(plan.Evict) != 0 {
+		t.Errorf("Size, Evict = %d, %v; want 200 and nothing", plan.Size, names(plan.Evict))
+	}
+
+	// Evicting one of the books frees nothing; both have to go.
+	plan, err := m.PlanGC(GCOptions{MaxSize: 150})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names(plan.Evict), ","); got != "a/dup.pdf,b/dup.pdf" || plan.Freed != 100 {
+		t.Errorf("Evict, Freed = %s, %d; want a/dup.pdf,b/dup.pdf, 100", got, plan.Freed)
+	}
+}
+
+func TestPlanGC_KeepsModifiedAndUnknown(t *testing.T) {
+	m := New(t.TempDir())
+	changed := storeAged(t, m, "r", "changed.pdf", "original", 3*time.Hour)
+	if err := os.WriteFile(changed, []byte("annotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A file cached before the database existed has no recorded checksum.
+	if err := os.WriteFile(filepath.Join(m.baseDir, "o", "r", "nosum.pdf"), []byte("no checksum"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	storeAged(t, m, "r", "kept.pdf", "pinned", 2*time.Hour)
+	storeAged(t, m, "r", "plain.pdf", "plain", time.Hour)
+
+	plan, err := m.PlanGC(GCOptions{MaxSize: 0, Keep: map[string]bool{"o/r/kept.pdf": true}})
+	if err != nil {
+		t.Fatalf("PlanGC: %v", err)
+	}
+	if got := strings.Join(names(plan.Evict), ","); got != "r/plain.pdf" {
+		t.Errorf("Evict = %s", got)
+	}
+	if got := strings.Join(names(plan.Modified), ","); got != "r/changed.pdf" {
+		t.Errorf("Modified = %s", got)
+	}
+	if got := strings.Join(names(plan.Unknown), ","); got != "r/nosum.pdf" {
+		t.Errorf("Unknown = %s", got)
+	}
+
+	// A catalog checksum makes the unknown file evictable.
+	sum, _ := util.SHA256Reader(strings.NewReader("no checksum"))
+	plan, _ = m.PlanGC(GCOptions{MaxSize: 0, Checksums: map[string]string{"o/r/nosum.pdf": sum}})
+	if got := strings.Join(names(plan.Evict), ","); !strings.Contains(got, "r/nosum.pdf") {
+		t.Errorf("Evict = %s, want it to include r/nosum.pdf", got)
+	}
+}
+
+func TestStore_AutoGC(t *testing.T) {
+	m := New(t.TempDir())
+	storeAged(t, m, "r", "a.pdf", strings.Repeat("a", 100), 2*time.Hour)
+	storeAged(t, m, "r", "b.pdf", strings.Repeat("b", 100), time.Hour)
+	m.SetMaxSize(250)
+
+	storeAged(t, m, "r", "c.pdf", strings.Repeat("c", 100), 0)
+	if m.Exists("o", "r", "", "a.pdf") {
+		t.Error("the least recently opened book should have been evicted")
+	}
+	if !m.Exists("o", "r", "", "b.pdf") || !m.Exists("o", "r", "", "c.pdf") {
+		t.Error("books within the limit should stay")
+	}
+
+	// The book just stored is never evicted, even if it alone is too big.
+	m.SetMaxSize(10)
+	storeAged(t, m, "r", "d.pdf", strings.Repeat("d", 100), 0)
+	if !m.Exists("o", "r", "", "d.pdf") {
+		t.Error("the book just stored was evicted")
+	}
+}
+
+func TestEvict_RechecksPlan(t *testing.T) {
+	m := New(t.TempDir())
+	annotated := storeAged(t, m, "r", "annotated.pdf", "a", 3*time.Hour)
+	storeAged(t, m, "r", "pinned.pdf", "b", 2*time.Hour)
+	storeAged(t, m, "r", "plain.pdf", "c", time.Hour)
+
+	plan, err := m.PlanGC(GCOptions{MaxSize: 0})
+	if err != nil || len(plan.Evict) != 3 {
+		t.Fatalf("PlanGC = %v, %v", names(plan.Evict), err)
+	}
+
+	// Between planning and eviction, a reader saves annotations and a
+	// pin is added.
+	if err := os.WriteFile(annotated, []byte("annotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetPinned(map[string]bool{"o/r/pinned.pdf": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := m.Evict(plan.Evict); err != nil || n != 1 {
+		t.Fatalf("Evict = %d, %v; want 1", n, err)
+	}
+	if !m.Exists("o", "r", "", "annotated.pdf") || !m.Exists("o", "r", "", "pinned.pdf") {
+		t.Error("Evict deleted a file that changed or was pinned after planning")
+	}
+	if m.Exists("o", "r", "", "plain.pdf") {
+		t.Error("Evict kept the unchanged file")
+	}
+}
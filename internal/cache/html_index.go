@@ -25,7 +25,7 @@ type IndexBook struct {
 func (m *Manager) GenerateHTMLIndex(books []IndexBook) error {
 	indexPath := filepath.Join(m.baseDir, "index.html")
 
-	html := m.generateHTML(books)
+	html := m.generateHTML(books, false)
 
 	if err := os.WriteFile(indexPath, []byte(html), 0644); err != nil {
 		return fmt.Errorf("writing index.html: %w", err)
@@ -34,7 +34,16 @@ func (m *Manager) GenerateHTMLIndex(books []IndexBook) error {
 	return nil
 }
 
-func (m *Manager) generateHTML(books []IndexBook) string {
+// GenerateLiveHTML renders the library page for `shelfctl serve --web`.
+// Files and covers are served over HTTP, uncached books download when
+// clicked, and each card gets edit and sync actions.
+func (m *Manager) GenerateLiveHTML(books []IndexBook) string {
+	return m.generateHTML(books, true)
+}
+
+// generateHTML renders the library page. The static index links to files in
+// the cache directory; the live page links to the serve --web endpoints.
+func (m *Manager) generateHTML(books []IndexBook, live bool) string {
 	var s strings.Builder
 
 	// Collect all unique tags
@@ -339,7 +348,7 @@ func (m *Manager) generateHTML(books []IndexBook) string {
                 grid-template-columns: repeat(auto-fill, minmax(200px, 1fr));
             }
         }
-    </style>
+` + liveStyle(live) + `    </style>
 </head>
 <body>
     <div class="sticky-nav">
@@ -357,7 +366,7 @@ func (m *Manager) generateHTML(books []IndexBook) string {
 		}
 		return fmt.Sprintf("%d books (%d cached)", len(books), cachedCount)
 	}() + `</div>
-        </header>
+` + liveToolbar(live) + `        </header>
 
         <div class="controls">
             <div class="search-box">
@@ -441,7 +450,7 @@ func (m *Manager) generateHTML(books []IndexBook) string {
 `, html.EscapeString(shelfName), html.EscapeString(shelfName), len(shelfBookList))
 
 		for _, book := range shelfBookList {
-			m.renderBookCard(&s, book, bookIndex)
+			m.renderBookCard(&s, book, bookIndex, live)
 			bookIndex++
 		}
 
@@ -460,7 +469,7 @@ func (m *Manager) generateHTML(books []IndexBook) string {
 `, len(uncached))
 
 		for _, book := range uncached {
-			m.renderUncachedCard(&s, book, bookIndex)
+			m.renderUncachedCard(&s, book, bookIndex, live)
 			bookIndex++
 		}
 
@@ -611,22 +620,28 @@ func (m *Manager) generateHTML(books []IndexBook) string {
             }
         }
     </script>
-</body>
+` + liveScript(live) + `</body>
 </html>
 `)
 
 	return s.String()
 }
 
-func (m *Manager) renderBookCard(s *strings.Builder, book IndexBook, index int) {
+func (m *Manager) renderBookCard(s *strings.Builder, book IndexBook, index int, live bool) {
 	// Convert tags to lowercase for search
 	tags := strings.Join(book.Book.Tags, ", ")
 
+	href := "file://" + book.FilePath
+	if live {
+		href = liveFileHref(book)
+	}
+
 	fmt.Fprintf(s, `
-                <a href="file://%s" class="book-card" data-id="%s" data-tags="%s" data-title="%s" data-author="%s" data-year="%d" data-index="%d">
+                <a href="%s" class="book-card"%s data-id="%s" data-tags="%s" data-title="%s" data-author="%s" data-year="%d" data-index="%d">
                     <div class="book-cover%s">
 `,
-		html.EscapeString(book.FilePath),
+		html.EscapeString(href),
+		liveCardAttrs(book, live),
 		html.EscapeString(book.Book.ID),
 		html.EscapeString(tags),
 		html.EscapeString(book.Book.Title),
@@ -641,7 +656,9 @@ func (m *Manager) renderBookCard(s *strings.Builder, book IndexBook, index int)
 		}(),
 	)
 
-	if book.HasCover {
+	if live {
+		fmt.Fprintf(s, `<img src="%s" alt="Cover" loading="lazy">`, html.EscapeString(liveCoverSrc(book)))
+	} else if book.HasCover {
 		// Make cover path relative to index.html location (baseDir/index.html)
 		relCoverPath, err := filepath.Rel(m.baseDir, book.CoverPath)
 		if err != nil {
@@ -675,26 +692,42 @@ func (m *Manager) renderBookCard(s *strings.Builder, book IndexBook, index int)
 `)
 	}
 
+	if live {
+		writeLiveActions(s, true)
+	}
+
 	s.WriteString(`                </a>
 `)
 }
 
-func (m *Manager) renderUncachedCard(s *strings.Builder, book IndexBook, index int) {
+func (m *Manager) renderUncachedCard(s *strings.Builder, book IndexBook, index int, live bool) {
 	tags := strings.Join(book.Book.Tags, ", ")
 
+	class, coverClass := "book-card uncached", "book-cover no-cover"
+	if live {
+		class, coverClass = "book-card uncached live", "book-cover"
+	}
+
 	fmt.Fprintf(s, `
-                <div class="book-card uncached" data-id="%s" data-tags="%s" data-title="%s" data-author="%s" data-year="%d" data-index="%d">
-                    <div class="book-cover no-cover">
+                <div class="%s"%s data-id="%s" data-tags="%s" data-title="%s" data-author="%s" data-year="%d" data-index="%d">
+                    <div class="%s">
 `,
+		class,
+		liveCardAttrs(book, live),
 		html.EscapeString(book.Book.ID),
 		html.EscapeString(tags),
 		html.EscapeString(book.Book.Title),
 		html.EscapeString(book.Book.Author),
 		book.Book.Year,
 		index,
+		coverClass,
 	)
 
-	s.WriteString("📚")
+	if live {
+		fmt.Fprintf(s, `<img src="%s" alt="Cover" loading="lazy">`, html.EscapeString(liveCoverSrc(book)))
+	} else {
+		s.WriteString("📚")
+	}
 
 	s.WriteString(`
                     </div>
@@ -718,8 +751,15 @@ func (m *Manager) renderUncachedCard(s *strings.Builder, book IndexBook, index i
 `)
 	}
 
-	fmt.Fprintf(s, `                    <div class="uncached-hint">shelfctl open %s</div>
+	if live {
+		s.WriteString(`                    <div class="uncached-hint">Click to download</div>
+                    <div class="progress"><div class="progress-bar"></div></div>
+`)
+		writeLiveActions(s, false)
+	} else {
+		fmt.Fprintf(s, `                    <div class="uncached-hint">shelfctl open %s</div>
 `, html.EscapeString(book.Book.ID))
+	}
 
 	s.WriteString(`                </div>
 `)
@@ -0,0 +1,325 @@
+package cache
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// Live-page additions to the index. Everything here renders to an empty
+// string for the static index, so index.html is unchanged.
+
+// liveBookPath returns the serve path of a book resource ("file", "cover").
+func liveBookPath(book IndexBook, what string) string {
+	return "/books/" + url.PathEscape(book.ShelfName) + "/" + url.PathEscape(book.Book.ID) + "/" + what
+}
+
+func liveFileHref(book IndexBook) string {
+	return liveBookPath(book, "file") + "?inline=1"
+}
+
+func liveCoverSrc(book IndexBook) string {
+	return liveBookPath(book, "cover")
+}
+
+// liveCardAttrs returns the extra card attributes the live script needs.
+func liveCardAttrs(book IndexBook, live bool) string {
+	if !live {
+		return ""
+	}
+	return fmt.Sprintf(` data-shelf="%s" data-file="%s"`,
+		html.EscapeString(book.ShelfName), html.EscapeString(liveFileHref(book)))
+}
+
+// writeLiveActions writes the edit (and, for cached books, sync) buttons.
+func writeLiveActions(s *strings.Builder, cached bool) {
+	s.WriteString(`                    <div class="card-actions">
+                        <button class="card-action" data-action="edit">Edit</button>
+`)
+	if cached {
+		s.WriteString(`                        <button class="card-action" data-action="sync">Sync</button>
+`)
+	}
+	s.WriteString(`                    </div>
+`)
+}
+
+func liveStyle(live bool) string {
+	if !live {
+		return ""
+	}
+	return `        .book-card.uncached.live {
+            opacity: 0.6;
+            cursor: pointer;
+            pointer-events: auto;
+        }
+        .book-card.uncached.live:hover {
+            opacity: 1;
+        }
+        .progress {
+            display: none;
+            height: 4px;
+            background: #333;
+            border-radius: 2px;
+            margin-top: 8px;
+            overflow: hidden;
+        }
+        .downloading .progress {
+            display: block;
+        }
+        .progress-bar {
+            width: 0;
+            height: 100%;
+            background: var(--teal-light);
+            transition: width 0.3s;
+        }
+        .card-actions {
+            display: flex;
+            gap: 8px;
+            margin-top: 10px;
+        }
+        .card-action, .toolbar-button {
+            background: #2a2a2a;
+            border: 1px solid #444;
+            color: #e0e0e0;
+            padding: 4px 10px;
+            border-radius: 4px;
+            cursor: pointer;
+            font-size: 0.8rem;
+        }
+        .card-action:hover, .toolbar-button:hover {
+            border-color: var(--orange);
+        }
+        .toolbar {
+            display: flex;
+            gap: 10px;
+            align-items: center;
+            margin-top: 10px;
+        }
+        .toolbar-button {
+            padding: 6px 14px;
+            font-size: 0.9rem;
+        }
+        #toast {
+            position: fixed;
+            bottom: 20px;
+            right: 20px;
+            background: var(--teal-dim);
+            border: 1px solid var(--teal-light);
+            color: #fff;
+            padding: 10px 16px;
+            border-radius: 6px;
+            display: none;
+            z-index: 2000;
+        }
+        #toast.error {
+            background: #3a2020;
+            border-color: #e07070;
+        }
+        #edit-dialog {
+            background: var(--teal-card);
+            color: #e0e0e0;
+            border: 1px solid var(--teal-border);
+            border-radius: 8px;
+            padding: 20px;
+            width: min(420px, 90vw);
+        }
+        #edit-dialog label {
+            display: block;
+            font-size: 0.85rem;
+            color: #aaa;
+            margin-top: 10px;
+        }
+        #edit-dialog input {
+            width: 100%;
+            padding: 8px;
+            background: #2a2a2a;
+            border: 1px solid #444;
+            border-radius: 4px;
+            color: #e0e0e0;
+        }
+        #edit-dialog menu {
+            display: flex;
+            justify-content: flex-end;
+            gap: 10px;
+            margin-top: 16px;
+            padding: 0;
+        }
+`
+}
+
+func liveToolbar(live bool) string {
+	if !live {
+		return ""
+	}
+	return `            <div class="toolbar">
+                <button class="toolbar-button" id="sync-all">Sync modified books</button>
+                <button class="toolbar-button" id="reload">Reload catalogs</button>
+            </div>
+`
+}
+
+func liveScript(live bool) string {
+	if !live {
+		return ""
+	}
+	return `    <div id="toast"></div>
+    <dialog id="edit-dialog">
+        <form method="dialog" id="edit-form">
+            <label>Title <input name="title" required></label>
+            <label>Author <input name="author"></label>
+            <label>Year <input name="year" type="number" min="0"></label>
+            <label>Tags (comma separated) <input name="tags"></label>
+            <menu>
+                <button class="toolbar-button" value="cancel" formnovalidate>Cancel</button>
+                <button class="toolbar-button" value="save" id="edit-save">Save</button>
+            </menu>
+        </form>
+    </dialog>
+    <script>
+        const toast = document.getElementById('toast');
+        let toastTimer;
+        function showToast(msg, isError) {
+            toast.textContent = msg;
+            toast.className = isError ? 'error' : '';
+            toast.style.display = 'block';
+            clearTimeout(toastTimer);
+            toastTimer = setTimeout(() => { toast.style.display = 'none'; }, 4000);
+        }
+
+        function bookURL(card, what) {
+            return '/api/' + what + '/' + encodeURIComponent(card.dataset.shelf) + '/' + encodeURIComponent(card.dataset.id);
+        }
+
+        async function post(url, body) {
+            const resp = await fetch(url, {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(body || {})
+            });
+            const data = await resp.json().catch(() => ({}));
+            if (!resp.ok) {
+                throw new Error(data.error || resp.statusText);
+            }
+            return data;
+        }
+
+        async function download(card) {
+            if (card.classList.contains('downloading')) {
+                return;
+            }
+            // Open the window now, while we still have the click, so popup
+            // blockers allow it; it is pointed at the book once cached.
+            const win = window.open('', '_blank');
+            const bar = card.querySelector('.progress-bar');
+            const hint = card.querySelector('.uncached-hint');
+            card.classList.add('downloading');
+            try {
+                await post(bookURL(card, 'download'));
+                for (;;) {
+                    const job = await (await fetch(bookURL(card, 'jobs'))).json();
+                    if (job.total > 0) {
+                        bar.style.width = Math.round(100 * job.done / job.total) + '%';
+                        hint.textContent = Math.round(100 * job.done / job.total) + '%';
+                    }
+                    if (job.state === 'done') {
+                        break;
+                    }
+                    if (job.state === 'failed') {
+                        throw new Error(job.error);
+                    }
+                    await new Promise(r => setTimeout(r, 500));
+                }
+                card.classList.remove('uncached', 'live', 'downloading');
+                hint.textContent = 'Downloaded';
+                if (win) {
+                    win.location = card.dataset.file;
+                }
+            } catch (err) {
+                card.classList.remove('downloading');
+                hint.textContent = 'Click to download';
+                if (win) {
+                    win.close();
+                }
+                showToast('Download failed: ' + err.message, true);
+            }
+        }
+
+        const editDialog = document.getElementById('edit-dialog');
+        const editForm = document.getElementById('edit-form');
+        let editing = null;
+        function openEditor(card) {
+            editing = card;
+            editForm.title.value = card.dataset.title;
+            editForm.author.value = card.dataset.author;
+            editForm.year.value = card.dataset.year === '0' ? '' : card.dataset.year;
+            editForm.tags.value = card.dataset.tags;
+            editDialog.showModal();
+        }
+        editDialog.addEventListener('close', async () => {
+            if (editDialog.returnValue !== 'save' || !editing) {
+                return;
+            }
+            const tags = editForm.tags.value.split(',').map(t => t.trim()).filter(t => t);
+            try {
+                await post(bookURL(editing, 'books'), {
+                    title: editForm.title.value,
+                    author: editForm.author.value,
+                    year: parseInt(editForm.year.value || '0', 10),
+                    tags: tags
+                });
+                location.reload();
+            } catch (err) {
+                showToast('Save failed: ' + err.message, true);
+            }
+        });
+
+        async function sync(card) {
+            try {
+                const res = await post(bookURL(card, 'sync'));
+                showToast(res.synced ? 'Uploaded local changes to ' + card.dataset.id : card.dataset.id + ' has no local changes');
+            } catch (err) {
+                showToast('Sync failed: ' + err.message, true);
+            }
+        }
+
+        document.addEventListener('click', e => {
+            const card = e.target.closest('.book-card');
+            const action = e.target.closest('[data-action]');
+            if (action && card) {
+                e.preventDefault();
+                e.stopPropagation();
+                if (action.dataset.action === 'edit') {
+                    openEditor(card);
+                } else if (action.dataset.action === 'sync') {
+                    sync(card);
+                }
+                return;
+            }
+            if (card && card.classList.contains('live')) {
+                e.preventDefault();
+                download(card);
+            }
+        });
+
+        document.getElementById('sync-all').addEventListener('click', async () => {
+            showToast('Syncing…');
+            try {
+                const res = await post('/api/sync');
+                showToast(res.synced === 0 ? 'No modified books' : 'Synced ' + res.synced + ' book(s)');
+            } catch (err) {
+                showToast('Sync failed: ' + err.message, true);
+            }
+        });
+        document.getElementById('reload').addEventListener('click', async () => {
+            try {
+                await post('/api/reload');
+                location.reload();
+            } catch (err) {
+                showToast('Reload failed: ' + err.message, true);
+            }
+        });
+    </script>
+`
+}
@@ -0,0 +1,379 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/util"
+)
+
+// indexFile is the cache's metadata database. It sits in the cache dir and
+// records, per cached file, where it came from, the checksum it was
+// downloaded with, its size and mtime when last hashed, when it was last
+// opened and whether a pin selects it.
+//
+// The file is a log of JSON lines, one per change, so updates append a
+// line instead of rewriting the file; it is compacted when most lines are
+// superseded.
+const indexFile = ".index.db"
+
+// indexEntry is what the database knows about one cached file.
+type indexEntry struct {
+	Owner  string `json:"owner,omitempty"`
+	BookID string `json:"book_id,omitempty"`
+	// SHA256 is the checksum of the file as downloaded or last synced;
+	// a file that no longer matches it has local changes.
+	SHA256 string `json:"sha256,omitempty"`
+	// Size, ModTime and FileSHA256 describe the file when it was last
+	// hashed. While its size and mtime still match, FileSHA256 is its
+	// checksum and the file need not be read again.
+	Size       int64     `json:"size,omitempty"`
+	ModTime    time.Time `json:"mtime,omitempty"`
+	FileSHA256 string    `json:"file_sha256,omitempty"`
+	// Blob is the checksum of the blob the file is a hard link to, if any.
+	Blob       string    `json:"blob,omitempty"`
+	LastOpened time.Time `json:"last_opened,omitempty"`
+	// Pinned files are never evicted; see SetPinned.
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// indexLine is one line of the database: the new entry of a key, or its
+// removal when Entry is nil.
+type indexLine struct {
+	Key   string      `json:"key"`
+	Entry *indexEntry `json:"entry,omitempty"`
+}
+
+// indexState is the database as last read, so lookups need not parse the
+// file again. offset is how far the file was read; lines written by other
+// processes after it are read on the next lookup.
+type indexState struct {
+	entries map[string]indexEntry
+	file    os.FileInfo
+	offset  int64
+	lines   int
+}
+
+// indexKey is the key of a cached file in the database: its path relative
+// to the cache dir, owner/repo/asset.
+func indexKey(owner, repo, assetFilename string) string {
+	return owner + "/" + repo + "/" + assetFilename
+}
+
+// Key is how GCOptions and SetPinned name a cached book.
+func Key(owner, repo, assetFilename string) string {
+	return indexKey(owner, repo, assetFilename)
+}
+
+// splitKey is the reverse of indexKey. It fails on keys of the layout
+// before owners, repo/asset, which Migrate moves.
+func splitKey(key string) (owner, repo, assetFilename string, ok bool) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+func (m *Manager) indexPath() string {
+	return filepath.Join(m.baseDir, indexFile)
+}
+
+// refreshIndex brings the in-memory database up to date with the file.
+// A missing or damaged database is empty: it only saves work, and files it
+// does not know are treated with care. Callers hold indexMu, and
+// lockIndex if they write.
+func (m *Manager) refreshIndex() map[string]indexEntry {
+	info, err := os.Stat(m.indexPath())
+	if err != nil {
+		m.index = indexState{entries: map[string]indexEntry{}}
+		return m.index.entries
+	}
+	if m.index.entries == nil || m.index.file == nil || !os.SameFile(m.index.file, info) || info.Size() < m.index.offset {
+		m.index = indexState{entries: map[string]indexEntry{}}
+	}
+	if info.Size() == m.index.offset {
+		return m.index.entries
+	}
+
+	f, err := os.Open(m.indexPath())
+	if err != nil {
+		return m.index.entries
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Seek(m.index.offset, io.SeekStart); err != nil {
+		return m.index.entries
+	}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break // EOF, or a line still being written
+		}
+		m.index.offset += int64(len(line))
+		m.index.lines++
+		var l indexLine
+		if json.Unmarshal(line, &l) != nil || l.Key == "" {
+			continue
+		}
+		if l.Entry == nil {
+			delete(m.index.entries, l.Key)
+		} else {
+			m.index.entries[l.Key] = *l.Entry
+		}
+	}
+	m.index.file = info
+	return m.index.entries
+}
+
+// writeIndex applies changes to the database, nil entries removing their
+// keys. Callers hold indexMu and have called refreshIndex.
+func (m *Manager) writeIndex(changes map[string]*indexEntry) error {
+	for key, e := range changes {
+		if e == nil {
+			delete(m.index.entries, key)
+		} else {
+			m.index.entries[key] = *e
+		}
+	}
+	if err := os.MkdirAll(m.baseDir, 0750); err != nil {
+		return err
+	}
+	if m.index.lines+len(changes) > 2*len(m.index.entries)+64 {
+		return m.compactIndex()
+	}
+
+	var buf bytes.Buffer
+	for key, e := range changes {
+		line, err := json.Marshal(indexLine{Key: key, Entry: e})
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	f, err := os.OpenFile(m.indexPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	// The offset stays put: the next refresh reads these lines back,
+	// along with any another process appended meanwhile.
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// compactIndex rewrites the database with one line per entry.
+func (m *Manager) compactIndex() error {
+	var buf bytes.Buffer
+	for key, e := range m.index.entries {
+		line, err := json.Marshal(indexLine{Key: key, Entry: &e})
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	path := m.indexPath()
+	tmp := tempPath(path, ".tmp")
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		m.index.file = info
+		m.index.offset = info.Size()
+		m.index.lines = len(m.index.entries)
+	}
+	return nil
+}
+
+// loadIndex returns a copy of the database.
+func (m *Manager) loadIndex() map[string]indexEntry {
+	m.indexMu.Lock()
+	defer m.indexMu.Unlock()
+	idx := make(map[string]indexEntry, len(m.index.entries))
+	for k, e := range m.refreshIndex() {
+		idx[k] = e
+	}
+	return idx
+}
+
+// lookupIndex returns the entry of a cached file.
+func (m *Manager) lookupIndex(owner, repo, assetFilename string) indexEntry {
+	m.indexMu.Lock()
+	defer m.indexMu.Unlock()
+	return m.refreshIndex()[indexKey(owner, repo, assetFilename)]
+}
+
+// updateIndex applies fn to the entry of a cached file and saves it.
+// fn returns false to drop the entry.
+func (m *Manager) updateIndex(owner, repo, assetFilename string, fn func(e *indexEntry) bool) error {
+	defer m.lockIndex()()
+	key := indexKey(owner, repo, assetFilename)
+	e := m.refreshIndex()[key]
+	if !fn(&e) {
+		return m.writeIndex(map[string]*indexEntry{key: nil})
+	}
+	return m.writeIndex(map[string]*indexEntry{key: &e})
+}
+
+// recordFile stores the size and mtime of a cached file with its checksum,
+// along with the book it belongs to.
+func recordFile(e *indexEntry, owner, bookID string, info os.FileInfo, sum string) {
+	if owner != "" {
+		e.Owner = owner
+	}
+	if bookID != "" {
+		e.BookID = bookID
+	}
+	e.Size = info.Size()
+	e.ModTime = info.ModTime().UTC()
+	e.FileSHA256 = sum
+}
+
+// forgetFile returns what is left of an entry once its file is deleted:
+// nothing, unless a pin selects it, as pins outlive the file and a pinned
+// book is fetched again on refresh.
+func forgetFile(e indexEntry) *indexEntry {
+	if !e.Pinned {
+		return nil
+	}
+	return &indexEntry{Owner: e.Owner, BookID: e.BookID, Pinned: true}
+}
+
+// forget drops the record of a deleted file, keeping its pin mark, and
+// deletes its blob if no other file links to it. Callers hold indexMu and
+// have called refreshIndex.
+func (m *Manager) forget(key string) {
+	e, ok := m.index.entries[key]
+	if !ok {
+		return
+	}
+	_ = m.writeIndex(map[string]*indexEntry{key: forgetFile(e)})
+	m.releaseBlob(e.Blob)
+}
+
+// SHA256 returns the checksum and size of a cached file. The file is only
+// read if its size or mtime changed since it was last hashed.
+func (m *Manager) SHA256(owner, repo, bookID, assetFilename string) (string, int64, error) {
+	path := m.Path(owner, repo, bookID, assetFilename)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	e := m.lookupIndex(owner, repo, assetFilename)
+	if e.FileSHA256 != "" && e.Size == info.Size() && e.ModTime.Equal(info.ModTime()) {
+		return e.FileSHA256, info.Size(), nil
+	}
+
+	sum, err := util.SHA256File(path)
+	if err != nil {
+		return "", 0, err
+	}
+	_ = m.updateIndex(owner, repo, assetFilename, func(e *indexEntry) bool {
+		recordFile(e, owner, bookID, info, sum)
+		return true
+	})
+	return sum, info.Size(), nil
+}
+
+// MarkOpened records that a cached book was opened now, which keeps it
+// from being evicted before books opened longer ago. Call it before
+// handing the file to a reader: a file shared with other books gets its
+// own copy first, as readers may save annotations into it. If the book
+// cannot be locked for that, such as while another process holds it, the
+// file is left as it is and the error says so; the open is still recorded.
+func (m *Manager) MarkOpened(owner, repo, bookID, assetFilename string) error {
+	path := m.Path(owner, repo, bookID, assetFilename)
+	key := indexKey(owner, repo, assetFilename)
+	lock, lockErr := m.lockBooks(key)
+	defer lock.release()
+	var unshareErr error
+	err := m.updateIndex(owner, repo, assetFilename, func(e *indexEntry) bool {
+		switch {
+		case lockErr == nil:
+			unshareErr = m.unshare(key, path, e)
+		case e.Blob != "" && m.blobShared(e.Blob, key):
+			unshareErr = fmt.Errorf("%s shares its file with other books and could not get its own copy, annotations saved into it show up in those too: %w", key, lockErr)
+		}
+		e.LastOpened = time.Now().UTC()
+		return true
+	})
+	if unshareErr != nil {
+		return unshareErr
+	}
+	return err
+}
+
+// MarkSynced records the checksum a cached book was uploaded with, so it
+// no longer counts as modified, and files the new content under it.
+func (m *Manager) MarkSynced(owner, repo, bookID, assetFilename, sha256 string) error {
+	lock, err := m.lockBooks(indexKey(owner, repo, assetFilename))
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	path := m.Path(owner, repo, bookID, assetFilename)
+	return m.updateIndex(owner, repo, assetFilename, func(e *indexEntry) bool {
+		e.SHA256 = sha256
+		m.rehome(indexKey(owner, repo, assetFilename), path, sha256, e)
+		if info, err := os.Stat(path); err == nil {
+			recordFile(e, owner, bookID, info, sha256)
+		}
+		return true
+	})
+}
+
+// LastOpened returns when a cached book was last opened or downloaded, or
+// the zero time if the cache has no record of it.
+func (m *Manager) LastOpened(owner, repo, bookID, assetFilename string) time.Time {
+	return m.lookupIndex(owner, repo, assetFilename).LastOpened
+}
+
+// Record is what the cache database knows about a cached book file.
+type Record struct {
+	Owner      string
+	Repo       string
+	BookID     string
+	Asset      string
+	SHA256     string    // Checksum as downloaded or last synced
+	Size       int64     // Size when last hashed
+	ModTime    time.Time // Modification time when last hashed
+	LastOpened time.Time
+	Pinned     bool
+}
+
+// Records returns the database's records of downloaded files, without
+// touching the files themselves. Pins of books that are not downloaded
+// are left out.
+func (m *Manager) Records() []Record {
+	var out []Record
+	for key, e := range m.loadIndex() {
+		if e.ModTime.IsZero() {
+			continue
+		}
+		owner, repo, asset, ok := splitKey(key)
+		if !ok {
+			continue
+		}
+		out = append(out, Record{
+			Owner: owner, Repo: repo, BookID: e.BookID, Asset: asset,
+			SHA256: e.SHA256, Size: e.Size, ModTime: e.ModTime,
+			LastOpened: e.LastOpened, Pinned: e.Pinned,
+		})
+	}
+	return out
+}
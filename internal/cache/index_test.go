@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/util"
+)
+
+func TestSHA256_SkipsUnchangedFiles(t *testing.T) {
+	m := New(t.TempDir())
+	path, err := m.Store("o", "r", "book", "book.pdf", strings.NewReader("original"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := util.SHA256Reader(strings.NewReader("original"))
+	if got, size, err := m.SHA256("o", "r", "book", "book.pdf"); err != nil || got != want || size != 8 {
+		t.Fatalf("SHA256 = %s, %d, %v", got, size, err)
+	}
+
+	// Same size and mtime: the recorded checksum is trusted, the file is
+	// not read.
+	info, _ := os.Stat(path)
+	if err := os.WriteFile(path, []byte("ORIGINAL"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := m.SHA256("o", "r", "book", "book.pdf"); got != want {
+		t.Errorf("SHA256 re-read an unchanged file")
+	}
+
+	// A new mtime means the file is hashed again.
+	later := info.ModTime().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	changed, _ := util.SHA256Reader(strings.NewReader("ORIGINAL"))
+	if got, _, _ := m.SHA256("o", "r", "book", "book.pdf"); got != changed {
+		t.Errorf("SHA256 = %s, want the new checksum", got)
+	}
+	if !m.HasBeenModified("o", "r", "book", "book.pdf", want) {
+		t.Error("HasBeenModified = false for a changed file")
+	}
+}
+
+func TestRecords_SharedAcrossManagers(t *testing.T) {
+	dir := t.TempDir()
+	a, b := New(dir), New(dir)
+
+	if _, err := a.Store("o", "r", "one", "one.pdf", strings.NewReader("one"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if a.Records() == nil {
+		t.Fatal("no records after Store")
+	}
+	// b sees what a wrote, and a sees b's changes in turn.
+	if err := b.MarkOpened("o", "r", "one", "one.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Store("o", "r", "two", "two.pdf", strings.NewReader("two"), ""); err != nil {
+		t.Fatal(err)
+	}
+	recs := a.Records()
+	if len(recs) != 2 {
+		t.Fatalf("Records = %+v, want 2", recs)
+	}
+	for _, r := range recs {
+		if r.Owner != "o" || r.BookID == "" || r.SHA256 == "" || r.Size != 3 || r.LastOpened.IsZero() {
+			t.Errorf("incomplete record %+v", r)
+		}
+	}
+
+	if err := a.Remove("o", "r", "one", "one.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if recs := b.Records(); len(recs) != 1 || recs[0].Asset != "two.pdf" {
+		t.Errorf("Records after Remove = %+v", recs)
+	}
+}
+
+func TestRecords_SameRepoOfTwoOwners(t *testing.T) {
+	m := New(t.TempDir())
+	for _, owner := range []string{"alice", "org"} {
+		if _, err := m.Store(owner, "shelf-books", "sicp", "sicp.pdf", strings.NewReader(owner), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Path("alice", "shelf-books", "", "sicp.pdf") == m.Path("org", "shelf-books", "", "sicp.pdf") {
+		t.Fatal("two owners' books share a path")
+	}
+	if err := m.Remove("alice", "shelf-books", "sicp", "sicp.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Exists("org", "shelf-books", "sicp", "sicp.pdf") {
+		t.Error("Remove of one owner's book removed the other's")
+	}
+	if recs := m.Records(); len(recs) != 1 || recs[0].Owner != "org" || recs[0].Repo != "shelf-books" || recs[0].Asset != "sicp.pdf" {
+		t.Errorf("Records = %+v", recs)
+	}
+}
+
+func TestIndex_Compacts(t *testing.T) {
+	m := New(t.TempDir())
+	if _, err := m.Store("o", "r", "book", "book.pdf", strings.NewReader("x"), ""); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		if err := m.MarkOpened("o", "r", "book", "book.pdf"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(m.indexPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines > 100 {
+		t.Errorf("database has %d lines for one entry; want it compacted", lines)
+	}
+	if recs := New(m.baseDir).Records(); len(recs) != 1 {
+		t.Errorf("Records after compaction = %s", fmt.Sprint(recs))
+	}
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// locksDir holds the lock files that keep shelfctl processes sharing a
+// cache out of each other's way: one per book, taken while the book's file
+// is written or deleted, one for the whole cache, shared by those and
+// taken alone by operations that sweep the cache (gc, orphan clearing,
+// migration), and one for the metadata database.
+const locksDir = ".locks"
+
+// ErrBusy is matched, with errors.Is, by the errors returned when another
+// process holds a lock the operation needs.
+var ErrBusy = errors.New("busy")
+
+// BusyError reports that a book or the cache is in use by another
+// shelfctl process.
+type BusyError struct {
+	What string // "the cache", or the book's owner/repo/asset
+}
+
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("%s is busy: another shelfctl process is using it, try again when it finishes", e.What)
+}
+
+// Is makes errors.Is(err, ErrBusy) match.
+func (e *BusyError) Is(target error) bool {
+	return target == ErrBusy
+}
+
+// errLocked is returned by lockFile when another holder has the lock.
+var errLocked = errors.New("locked")
+
+// fileLock is a set of held lock files.
+type fileLock struct {
+	files []*os.File
+}
+
+func (l *fileLock) release() {
+	if l == nil {
+		return
+	}
+	for i := len(l.files) - 1; i >= 0; i-- {
+		_ = unlockFile(l.files[i])
+		_ = l.files[i].Close()
+	}
+	l.files = nil
+}
+
+// acquire takes the lock file at path, shared or exclusive. Without wait
+// it fails with errLocked if the lock is held.
+func (l *fileLock) acquire(path string, exclusive, wait bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if err := lockFile(f, exclusive, wait); err != nil {
+		_ = f.Close()
+		return err
+	}
+	l.files = append(l.files, f)
+	return nil
+}
+
+func (m *Manager) lockPath(name string) string {
+	return filepath.Join(m.baseDir, locksDir, name)
+}
+
+// lockCache takes the cache lock: exclusive for sweeping the cache, or
+// shared while working on single books.
+func (m *Manager) lockCache(exclusive bool) (*fileLock, error) {
+	l := &fileLock{}
+	if err := l.acquire(m.lockPath("cache.lock"), exclusive, false); err != nil {
+		if errors.Is(err, errLocked) {
+			return nil, &BusyError{What: "the cache"}
+		}
+		return nil, fmt.Errorf("locking cache: %w", err)
+	}
+	return l, nil
+}
+
+// lockBooks takes the shared cache lock and the locks of the given books,
+// as repo/asset keys.
+func (m *Manager) lockBooks(keys ...string) (*fileLock, error) {
+	l, err := m.lockCache(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if err := l.acquire(m.lockPath(filepath.FromSlash(key)+".lock"), true, false); err != nil {
+			l.release()
+			if errors.Is(err, errLocked) {
+				return nil, &BusyError{What: key}
+			}
+			return nil, fmt.Errorf("locking %s: %w", key, err)
+		}
+	}
+	return l, nil
+}
+
+// lockIndex serializes changes to the metadata database, within this
+// process and across processes, and returns the unlock function. The
+// database only saves work, so if the lock file cannot be taken the change
+// goes ahead under the in-process lock alone, and Logf is told.
+func (m *Manager) lockIndex() func() {
+	m.indexMu.Lock()
+	l := &fileLock{}
+	if err := l.acquire(m.lockPath("index.lock"), true, true); err != nil {
+		m.logf("Cache database not locked, other shelfctl processes may overwrite this change: %v", err)
+	}
+	return func() {
+		l.release()
+		m.indexMu.Unlock()
+	}
+}
+
+// tempPath returns a name next to path for a file being written, unique so
+// that concurrent writers do not collide.
+func tempPath(path, suffix string) string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return path + "." + hex.EncodeToString(b) + suffix
+}
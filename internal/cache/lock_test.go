@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStore_BusyBook(t *testing.T) {
+	dir := t.TempDir()
+	a, b := New(dir), New(dir) // two processes sharing a cache
+
+	lock, err := a.lockBooks(indexKey("o", "r", "b.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Store("o", "r", "b", "b.pdf", strings.NewReader("book"), ""); !errors.Is(err, ErrBusy) {
+		t.Fatalf("Store of a locked book = %v, want busy", err)
+	}
+	if err := b.Remove("o", "r", "b", "b.pdf"); !errors.Is(err, ErrBusy) {
+		t.Errorf("Remove of a locked book = %v, want busy", err)
+	}
+	if _, err := b.Store("o", "r", "other", "other.pdf", strings.NewReader("other"), ""); err != nil {
+		t.Errorf("Store of another book: %v", err)
+	}
+
+	lock.release()
+	if _, err := b.Store("o", "r", "b", "b.pdf", strings.NewReader("book"), ""); err != nil {
+		t.Errorf("Store after release: %v", err)
+	}
+}
+
+func TestClearOrphans_BusyCache(t *testing.T) {
+	dir := t.TempDir()
+	a, b := New(dir), New(dir)
+	if _, err := a.Store("o", "r", "b", "b.pdf", strings.NewReader("book"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// A book being downloaded keeps sweeps out.
+	lock, err := a.lockBooks(indexKey("o", "r", "new.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := b.DetectOrphans(nil)
+	if err != nil || report.TotalCount != 1 {
+		t.Fatalf("DetectOrphans = %+v, %v", report, err)
+	}
+	if _, err := b.ClearOrphans(report); !errors.Is(err, ErrBusy) {
+		t.Errorf("ClearOrphans during a download = %v, want busy", err)
+	}
+	entries, _ := b.Entries()
+	if _, err := b.Evict(entries); !errors.Is(err, ErrBusy) {
+		t.Errorf("Evict during a download = %v, want busy", err)
+	}
+	lock.release()
+
+	// And a sweep keeps downloads out.
+	lock, err = a.lockCache(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var busy *BusyError
+	if _, err := b.Store("o", "r", "new", "new.pdf", strings.NewReader("new"), ""); !errors.As(err, &busy) || busy.What != "the cache" {
+		t.Errorf("Store during a sweep = %v, want the cache busy", err)
+	}
+	lock.release()
+
+	if n, err := b.ClearOrphans(report); err != nil || n != 1 {
+		t.Errorf("ClearOrphans = %d, %v", n, err)
+	}
+}
+
+func TestLockIndex_ReportsFailure(t *testing.T) {
+	m := New(t.TempDir())
+	var logged []string
+	m.Logf = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	if _, err := m.Store("o", "r", "b", "b.pdf", strings.NewReader("book"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 0 {
+		t.Fatalf("logged %q", logged)
+	}
+
+	// A directory in the lock file's place cannot be opened.
+	lockFile := m.lockPath("index.lock")
+	if err := os.Remove(lockFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(lockFile, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MarkOpened("o", "r", "b", "b.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) == 0 || !strings.Contains(logged[0], "not locked") {
+		t.Errorf("logged %q, want the lock failure", logged)
+	}
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	managers := []*Manager{New(dir), New(dir)}
+
+	const books = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, books)
+	for i := 0; i < books; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m := managers[i%len(managers)]
+			name := fmt.Sprintf("book-%d.pdf", i)
+			if _, err := m.Store("o", "r", "b", name, strings.NewReader(name), ""); err != nil {
+				errs <- fmt.Errorf("%s: %w", name, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if got := len(New(dir).Records()); got != books {
+		t.Errorf("%d records after storing %d books", got, books)
+	}
+	for i := 0; i < books; i++ {
+		name := fmt.Sprintf("book-%d.pdf", i)
+		if data, err := os.ReadFile(filepath.Join(dir, "o", "r", name)); err != nil || string(data) != name {
+			t.Errorf("%s = %q, %v", name, data, err)
+		}
+	}
+}
+
+func TestStore_ConcurrentSameBook(t *testing.T) {
+	dir := t.TempDir()
+	managers := []*Manager{New(dir), New(dir)}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	stored := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(m *Manager) {
+			defer wg.Done()
+			_, err := m.Store("o", "r", "b", "b.pdf", strings.NewReader("book"), "")
+			if err != nil && !errors.Is(err, ErrBusy) {
+				t.Errorf("Store: %v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				stored++
+			}
+		}(managers[i%len(managers)])
+	}
+	wg.Wait()
+
+	if stored == 0 {
+		t.Fatal("no Store succeeded")
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "o", "r", "b.pdf")); err != nil || string(data) != "book" {
+		t.Errorf("cached file = %q, %v", data, err)
+	}
+	files, _ := os.ReadDir(filepath.Join(dir, "o", "r"))
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".tmp") || strings.HasSuffix(f.Name(), ".link") {
+			t.Errorf("left behind %s", f.Name())
+		}
+	}
+}
+
+// processHelperEnv names the cache a helper process of
+// TestCache_Processes works on.
+const processHelperEnv = "SHELFCTL_CACHE_TEST_DIR"
+
+// TestCache_Processes races real processes on one cache: the test binary
+// runs itself several times, and each run stores, evicts and removes the
+// same books. Every operation must succeed or fail as busy, and the cache
+// must be consistent afterwards.
+func TestCache_Processes(t *testing.T) {
+	if dir := os.Getenv(processHelperEnv); dir != "" {
+		churn(t, New(dir))
+		return
+	}
+	dir := t.TempDir()
+	const procs = 4
+	var cmds []*exec.Cmd
+	var outs []*strings.Builder
+	for i := 0; i < procs; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCache_Processes$")
+		cmd.Env = append(os.Environ(), processHelperEnv+"="+dir)
+		out := &strings.Builder{}
+		cmd.Stdout, cmd.Stderr = out, out
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds, outs = append(cmds, cmd), append(outs, out)
+	}
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("process %d: %v\n%s", i, err, outs[i])
+		}
+	}
+
+	m := New(dir)
+	for i := 0; i < churnBooks; i++ {
+		name := fmt.Sprintf("book-%d.pdf", i)
+		if _, err := m.Store("o", "r", "b", name, strings.NewReader(name), ""); err != nil {
+			t.Errorf("Store %s after the race: %v", name, err)
+		}
+	}
+	entries, err := m.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != churnBooks {
+		t.Errorf("%d cached files, want %d", len(entries), churnBooks)
+	}
+	for _, e := range entries {
+		if data, err := os.ReadFile(e.Path); err != nil || string(data) != e.Filename {
+			t.Errorf("%s = %q, %v", e.Filename, data, err)
+		}
+	}
+	if recs := m.Records(); len(recs) != churnBooks {
+		t.Errorf("%d records, want %d", len(recs), churnBooks)
+	}
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && (strings.HasSuffix(path, ".tmp") || strings.HasSuffix(path, ".link")) {
+			t.Errorf("left behind %s", path)
+		}
+		return nil
+	})
+}
+
+const churnBooks = 4
+
+// churn stores, evicts and removes the books of TestCache_Processes.
+func churn(t *testing.T, m *Manager) {
+	busy := func(what string, err error) {
+		if err != nil && !errors.Is(err, ErrBusy) {
+			t.Errorf("%s: %v", what, err)
+		}
+	}
+	for round := 0; round < 20; round++ {
+		for i := 0; i < churnBooks; i++ {
+			name := fmt.Sprintf("book-%d.pdf", i)
+			_, err := m.Store("o", "r", "b", name, strings.NewReader(name), "")
+			busy("Store "+name, err)
+		}
+		if plan, err := m.PlanGC(GCOptions{MaxSize: 0}); err != nil {
+			t.Errorf("PlanGC: %v", err)
+		} else {
+			_, err := m.Evict(plan.Evict)
+			busy("Evict", err)
+		}
+		name := fmt.Sprintf("book-%d.pdf", round%churnBooks)
+		busy("Remove "+name, m.Remove("o", "r", "b", name))
+	}
+}
@@ -12,6 +12,7 @@ import (
 // OrphanEntry represents a cached file with no corresponding catalog entry
 type OrphanEntry struct {
 	Path     string // Full path to cached file
+	Owner    string // Repository owner
 	Repo     string // Repository name
 	Filename string // Asset filename
 	Size     int64  // File size in bytes
@@ -37,15 +38,12 @@ func (m *Manager) DetectOrphans(shelves []ShelfCatalog) (OrphanReport, error) {
 		Entries: []OrphanEntry{},
 	}
 
-	// Build a map of all known assets: repo -> {assetFilename -> true}
-	knownAssets := make(map[string]map[string]bool)
+	// Build a set of all known assets, by Key
+	knownAssets := make(map[string]bool)
 	for _, shelf := range shelves {
-		if _, exists := knownAssets[shelf.Repo]; !exists {
-			knownAssets[shelf.Repo] = make(map[string]bool)
-		}
 		for _, book := range shelf.Books {
 			if book.Source.Asset != "" {
-				knownAssets[shelf.Repo][book.Source.Asset] = true
+				knownAssets[indexKey(shelf.Owner, shelf.Repo, book.Source.Asset)] = true
 			}
 		}
 	}
@@ -59,16 +57,23 @@ func (m *Manager) DetectOrphans(shelves []ShelfCatalog) (OrphanReport, error) {
 
 	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // Deleted by another process during the walk
+			}
 			return err
 		}
 
-		// Skip directories
+		// Skip directories, and the cache's own ones (blob store, locks):
+		// blobs are only reached through the books' links
 		if info.IsDir() {
+			if filepath.Dir(path) == baseDir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
 		// Skip temporary files
-		if strings.HasSuffix(path, ".tmp") {
+		if strings.HasSuffix(path, ".tmp") || strings.HasSuffix(path, ".link") {
 			return nil
 		}
 
@@ -77,31 +82,29 @@ func (m *Manager) DetectOrphans(shelves []ShelfCatalog) (OrphanReport, error) {
 			return nil
 		}
 
-		// Determine repo and filename from path
-		// Path structure: <baseDir>/<repo>/<assetFilename>
+		// Determine owner, repo and filename from path
+		// Path structure: <baseDir>/<owner>/<repo>/<assetFilename>
 		relPath, err := filepath.Rel(baseDir, path)
 		if err != nil {
 			return nil // Skip files we can't parse
 		}
 
-		parts := strings.SplitN(relPath, string(filepath.Separator), 2)
-		if len(parts) != 2 {
-			return nil // Skip files not in expected structure
+		// Skip files not in expected structure, such as the layout
+		// before owners, which cache migrate moves
+		owner, repo, filename, ok := splitKey(filepath.ToSlash(relPath))
+		if !ok {
+			return nil
 		}
 
-		repo := parts[0]
-		filename := parts[1]
-
 		// Check if this asset is known in any catalog
-		if repoAssets, exists := knownAssets[repo]; exists {
-			if repoAssets[filename] {
-				return nil // Asset is referenced, not an orphan
-			}
+		if knownAssets[indexKey(owner, repo, filename)] {
+			return nil // Asset is referenced, not an orphan
 		}
 
 		// This is an orphan
 		report.Entries = append(report.Entries, OrphanEntry{
 			Path:     path,
+			Owner:    owner,
 			Repo:     repo,
 			Filename: filename,
 			Size:     info.Size(),
@@ -122,6 +125,12 @@ func (m *Manager) DetectOrphans(shelves []ShelfCatalog) (OrphanReport, error) {
 // ClearOrphans deletes all files in the provided orphan report
 // Returns the number of files successfully deleted
 func (m *Manager) ClearOrphans(report OrphanReport) (int, error) {
+	lock, err := m.lockCache(true)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.release()
+
 	deleted := 0
 	var lastErr error
 
@@ -130,6 +139,10 @@ func (m *Manager) ClearOrphans(report OrphanReport) (int, error) {
 			lastErr = err
 			continue
 		}
+		unlock := m.lockIndex()
+		m.refreshIndex()
+		m.forget(indexKey(entry.Owner, entry.Repo, entry.Filename))
+		unlock()
 		deleted++
 	}
 
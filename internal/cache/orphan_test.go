@@ -37,7 +37,7 @@ func TestDetectOrphans_NoOrphans(t *testing.T) {
 	mgr := New(tmpDir)
 
 	// Create cache structure
-	repoDir := filepath.Join(tmpDir, "shelf-tech")
+	repoDir := filepath.Join(tmpDir, "user", "shelf-tech")
 	if err := os.MkdirAll(repoDir, 0750); err != nil {
 		t.Fatal(err)
 	}
@@ -73,7 +73,7 @@ func TestDetectOrphans_FindsOrphans(t *testing.T) {
 	mgr := New(tmpDir)
 
 	// Create cache structure with two files
-	repoDir := filepath.Join(tmpDir, "shelf-tech")
+	repoDir := filepath.Join(tmpDir, "user", "shelf-tech")
 	if err := os.MkdirAll(repoDir, 0750); err != nil {
 		t.Fatal(err)
 	}
@@ -126,7 +126,7 @@ func TestDetectOrphans_SkipsTempFiles(t *testing.T) {
 	tmpDir := t.TempDir()
 	mgr := New(tmpDir)
 
-	repoDir := filepath.Join(tmpDir, "shelf-tech")
+	repoDir := filepath.Join(tmpDir, "user", "shelf-tech")
 	if err := os.MkdirAll(repoDir, 0750); err != nil {
 		t.Fatal(err)
 	}
@@ -160,8 +160,8 @@ func TestDetectOrphans_MultipleRepos(t *testing.T) {
 	mgr := New(tmpDir)
 
 	// Create two repos with orphans in each
-	repo1Dir := filepath.Join(tmpDir, "shelf-tech")
-	repo2Dir := filepath.Join(tmpDir, "shelf-fiction")
+	repo1Dir := filepath.Join(tmpDir, "user", "shelf-tech")
+	repo2Dir := filepath.Join(tmpDir, "user", "shelf-fiction")
 	if err := os.MkdirAll(repo1Dir, 0750); err != nil {
 		t.Fatal(err)
 	}
@@ -206,7 +206,7 @@ func TestClearOrphans_Success(t *testing.T) {
 	tmpDir := t.TempDir()
 	mgr := New(tmpDir)
 
-	repoDir := filepath.Join(tmpDir, "shelf-tech")
+	repoDir := filepath.Join(tmpDir, "user", "shelf-tech")
 	if err := os.MkdirAll(repoDir, 0750); err != nil {
 		t.Fatal(err)
 	}
@@ -248,7 +248,7 @@ func TestClearOrphans_PartialFailure(t *testing.T) {
 	tmpDir := t.TempDir()
 	mgr := New(tmpDir)
 
-	repoDir := filepath.Join(tmpDir, "shelf-tech")
+	repoDir := filepath.Join(tmpDir, "user", "shelf-tech")
 	if err := os.MkdirAll(repoDir, 0750); err != nil {
 		t.Fatal(err)
 	}
@@ -3,11 +3,20 @@ package cache
 import (
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 // Manager handles the local file cache.
 type Manager struct {
 	baseDir string
+	maxSize int64 // see SetMaxSize
+
+	indexMu sync.Mutex // guards index
+	index   indexState // see refreshIndex
+
+	// Logf, if set, receives problems that do not stop an operation,
+	// such as a lock on the metadata database that could not be taken.
+	Logf func(format string, args ...interface{})
 }
 
 // New creates a cache Manager rooted at baseDir.
@@ -15,10 +24,17 @@ func New(baseDir string) *Manager {
 	return &Manager{baseDir: baseDir}
 }
 
+func (m *Manager) logf(format string, args ...interface{}) {
+	if m.Logf != nil {
+		m.Logf(format, args...)
+	}
+}
+
 // Path returns the full cache path for a given shelf repo and book.
-// Layout: <baseDir>/<repo>/<assetFilename>
+// Layout: <baseDir>/<owner>/<repo>/<assetFilename>, a hard link to the
+// file's blob (see blobsDir).
 func (m *Manager) Path(owner, repo, bookID, assetFilename string) string {
-	return filepath.Join(m.baseDir, repo, assetFilename)
+	return filepath.Join(m.baseDir, owner, repo, assetFilename)
 }
 
 // Exists reports whether the cached file exists.
@@ -29,21 +45,29 @@ func (m *Manager) Exists(owner, repo, bookID, assetFilename string) bool {
 
 // EnsureDir creates all intermediate directories for a cache path.
 func (m *Manager) EnsureDir(owner, repo, bookID string) error {
-	dir := filepath.Join(m.baseDir, repo)
+	dir := filepath.Join(m.baseDir, owner, repo)
 	return os.MkdirAll(dir, 0750)
 }
 
 // Remove deletes the cached file and its covers if they exist.
 func (m *Manager) Remove(owner, repo, bookID, assetFilename string) error {
+	lock, err := m.lockBooks(indexKey(owner, repo, assetFilename))
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
 	path := m.Path(owner, repo, bookID, assetFilename)
-	err := os.Remove(path)
-	if err != nil && !os.IsNotExist(err) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
 	// Remove both cover types if they exist
 	_ = m.RemoveCover(repo, bookID)
 	_ = m.RemoveCatalogCover(repo, bookID)
+	defer m.lockIndex()()
+	m.refreshIndex()
+	m.forget(indexKey(owner, repo, assetFilename))
 
 	return nil
 }
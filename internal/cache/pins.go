@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+// pinsFile sits in the cache dir and lists what the user pinned.
+const pinsFile = ".pins.json"
+
+// Pin selects books to keep in the cache: one book by ID, every book with
+// a tag, or every book matching a search query. Tag and query pins also
+// select books added to the shelves later.
+type Pin struct {
+	Shelf string `json:"shelf,omitempty"` // Limits the pin to one shelf
+	ID    string `json:"id,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+	Query string `json:"query,omitempty"`
+}
+
+// String describes the pin as the user would write it.
+func (p Pin) String() string {
+	var s string
+	switch {
+	case p.ID != "":
+		s = p.ID
+	case p.Tag != "":
+		s = "tag:" + p.Tag
+	default:
+		s = fmt.Sprintf("query:%q", p.Query)
+	}
+	if p.Shelf != "" {
+		s += " (shelf " + p.Shelf + ")"
+	}
+	return s
+}
+
+// Matches reports whether the pin selects book b on the given shelf.
+func (p Pin) Matches(shelf string, b catalog.Book) bool {
+	if p.Shelf != "" && p.Shelf != shelf {
+		return false
+	}
+	if p.ID != "" {
+		return b.ID == p.ID
+	}
+	f := catalog.Filter{Tag: p.Tag, Search: p.Query}
+	return len(f.Apply([]catalog.Book{b})) == 1
+}
+
+func (p Pin) same(q Pin) bool {
+	return p.Shelf == q.Shelf && p.ID == q.ID &&
+		strings.EqualFold(p.Tag, q.Tag) && strings.EqualFold(p.Query, q.Query)
+}
+
+// Pins returns the recorded pins.
+func (m *Manager) Pins() ([]Pin, error) {
+	data, err := os.ReadFile(filepath.Join(m.baseDir, pinsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pins []Pin
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", pinsFile, err)
+	}
+	return pins, nil
+}
+
+func (m *Manager) savePins(pins []Pin) error {
+	if err := os.MkdirAll(m.baseDir, 0750); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(m.baseDir, pinsFile)
+	tmp := tempPath(path, ".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// AddPin records a pin. It returns false if the pin was already recorded.
+func (m *Manager) AddPin(p Pin) (bool, error) {
+	defer m.lockIndex()()
+	pins, err := m.Pins()
+	if err != nil {
+		return false, err
+	}
+	for _, q := range pins {
+		if q.same(p) {
+			return false, nil
+		}
+	}
+	return true, m.savePins(append(pins, p))
+}
+
+// RemovePin forgets a pin. It returns false if the pin was not recorded.
+func (m *Manager) RemovePin(p Pin) (bool, error) {
+	defer m.lockIndex()()
+	pins, err := m.Pins()
+	if err != nil {
+		return false, err
+	}
+	kept := pins[:0]
+	for _, q := range pins {
+		if !q.same(p) {
+			kept = append(kept, q)
+		}
+	}
+	if len(kept) == len(pins) {
+		return false, nil
+	}
+	return true, m.savePins(kept)
+}
+
+// SetPinned marks the cached files selected by the pins, as Key keys, so GC never evicts them, and unmarks every other file. Files not
+// downloaded yet are marked too, ahead of their download.
+func (m *Manager) SetPinned(keys map[string]bool) error {
+	defer m.lockIndex()()
+	idx := m.refreshIndex()
+	changes := map[string]*indexEntry{}
+	for key, e := range idx {
+		if e.Pinned && !keys[key] {
+			e.Pinned = false
+			changes[key] = &e
+		}
+	}
+	for key := range keys {
+		if e := idx[key]; !e.Pinned {
+			e.Pinned = true
+			changes[key] = &e
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return m.writeIndex(changes)
+}
+
+// IsPinned reports whether a book is selected by a pin.
+func (m *Manager) IsPinned(owner, repo, bookID, assetFilename string) bool {
+	return m.lookupIndex(owner, repo, assetFilename).Pinned
+}
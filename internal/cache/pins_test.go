@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+func TestPin_Matches(t *testing.T) {
+	b := catalog.Book{ID: "raft", Title: "In Search of an Understandable Consensus Algorithm", Tags: []string{"Distributed-Systems"}}
+
+	tests := []struct {
+		pin   Pin
+		shelf string
+		want  bool
+	}{
+		{Pin{ID: "raft"}, "papers", true},
+		{Pin{ID: "paxos"}, "papers", false},
+		{Pin{Tag: "distributed-systems"}, "papers", true},
+		{Pin{Tag: "databases"}, "papers", false},
+		{Pin{Query: "consensus"}, "papers", true},
+		{Pin{Query: "consensus", Shelf: "books"}, "papers", false},
+		{Pin{Tag: "distributed-systems", Shelf: "papers"}, "papers", true},
+	}
+	for _, tt := range tests {
+		if got := tt.pin.Matches(tt.shelf, b); got != tt.want {
+			t.Errorf("%s.Matches(%s) = %v, want %v", tt.pin, tt.shelf, got, tt.want)
+		}
+	}
+}
+
+func TestAddRemovePin(t *testing.T) {
+	m := New(t.TempDir())
+
+	if added, err := m.AddPin(Pin{Tag: "go"}); err != nil || !added {
+		t.Fatalf("AddPin = %v, %v", added, err)
+	}
+	if added, _ := m.AddPin(Pin{Tag: "Go"}); added {
+		t.Error("AddPin recorded a duplicate pin")
+	}
+	if _, err := m.AddPin(Pin{ID: "sicp", Shelf: "books"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pins, err := m.Pins()
+	if err != nil || len(pins) != 2 {
+		t.Fatalf("Pins = %v, %v", pins, err)
+	}
+
+	if removed, _ := m.RemovePin(Pin{ID: "sicp"}); removed {
+		t.Error("RemovePin matched a pin limited to another shelf")
+	}
+	if removed, err := m.RemovePin(Pin{Tag: "go"}); err != nil || !removed {
+		t.Fatalf("RemovePin = %v, %v", removed, err)
+	}
+	if pins, _ := m.Pins(); len(pins) != 1 || pins[0].ID != "sicp" {
+		t.Errorf("Pins after remove = %v", pins)
+	}
+}
+
+func TestPlanGC_KeepsPinned(t *testing.T) {
+	m := New(t.TempDir())
+	storeAged(t, m, "r", "pinned.pdf", strings.Repeat("a", 100), 3*time.Hour)
+	storeAged(t, m, "r", "plain.pdf", strings.Repeat("b", 100), time.Hour)
+	if err := m.SetPinned(map[string]bool{"o/r/pinned.pdf": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := m.PlanGC(GCOptions{MaxSize: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names(plan.Evict), ","); got != "r/plain.pdf" {
+		t.Errorf("Evict = %s", got)
+	}
+	if got := strings.Join(names(plan.Pinned), ","); got != "r/pinned.pdf" {
+		t.Errorf("Pinned = %s", got)
+	}
+
+	// Removing a pinned file keeps the mark for its next download.
+	if err := m.Remove("o", "r", "pinned", "pinned.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if !m.IsPinned("o", "r", "pinned", "pinned.pdf") {
+		t.Error("Remove dropped the pin mark")
+	}
+
+	// Re-marking with fewer keys unpins the rest.
+	if err := m.SetPinned(map[string]bool{}); err != nil {
+		t.Fatal(err)
+	}
+	if m.IsPinned("o", "r", "pinned", "pinned.pdf") {
+		t.Error("SetPinned kept a stale pin mark")
+	}
+}
@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Placeholder cover geometry, in pixels. The 2:3 ratio matches a typical
+// book cover so placeholders line up with real covers in the HTML index.
+const (
+	placeholderWidth  = 200
+	placeholderHeight = 300
+	placeholderMargin = 16
+	titleScale        = 2
+	maxTitleLines     = 7
+	maxAuthorLines    = 3
+)
+
+// placeholderPalette holds muted background colors; one is picked per book
+// so neighbouring placeholders are easy to tell apart.
+var placeholderPalette = []color.RGBA{
+	{0x2e, 0x4a, 0x62, 0xff},
+	{0x5b, 0x3a, 0x52, 0xff},
+	{0x3d, 0x5a, 0x3e, 0xff},
+	{0x6b, 0x4a, 0x2b, 0xff},
+	{0x4a, 0x4e, 0x69, 0xff},
+	{0x70, 0x3b, 0x3b, 0xff},
+	{0x2f, 0x5d, 0x5d, 0xff},
+	{0x55, 0x55, 0x55, 0xff},
+}
+
+// GetCoverPathOrPlaceholder returns the best available cover for a book
+// like GetCoverPath, generating a typographic placeholder (title and author
+// on a colored background) when the book has no real cover. Returns empty
+// string only if the placeholder cannot be written.
+func (m *Manager) GetCoverPathOrPlaceholder(repo string, b catalog.Book) string {
+	if path := m.GetCoverPath(repo, b.ID); path != "" {
+		return path
+	}
+
+	key := placeholderKey(b.Title, b.Author)
+	path := m.PlaceholderCoverPath(repo, b.ID, key)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	coversDir := filepath.Join(m.baseDir, repo, ".covers")
+	if err := os.MkdirAll(coversDir, 0750); err != nil {
+		return ""
+	}
+	// Drop placeholders rendered for an older title or author.
+	if stale, err := filepath.Glob(filepath.Join(coversDir, b.ID+"-placeholder-*.png")); err == nil {
+		for _, p := range stale {
+			_ = os.Remove(p)
+		}
+	}
+
+	if err := writePNG(renderPlaceholder(b.Title, b.Author, key), path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// PlaceholderCoverPath returns the path of a generated placeholder cover.
+// The key changes with the title and author so edits produce a new image.
+func (m *Manager) PlaceholderCoverPath(repo, bookID, key string) string {
+	return filepath.Join(m.baseDir, repo, ".covers", bookID+"-placeholder-"+key+".png")
+}
+
+func placeholderKey(title, author string) string {
+	sum := sha256.Sum256([]byte(title + "\x00" + author))
+	return hex.EncodeToString(sum[:4])
+}
+
+func renderPlaceholder(title, author, key string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, placeholderWidth, placeholderHeight))
+
+	bg := placeholderPalette[int(key[0])%len(placeholderPalette)]
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	// Inner frame, like a printed paperback cover.
+	fg := color.RGBA{0xf4, 0xee, 0xe0, 0xff}
+	frame := image.Rect(8, 8, placeholderWidth-8, placeholderHeight-8)
+	for x := frame.Min.X; x < frame.Max.X; x++ {
+		img.Set(x, frame.Min.Y, fg)
+		img.Set(x, frame.Max.Y-1, fg)
+	}
+	for y := frame.Min.Y; y < frame.Max.Y; y++ {
+		img.Set(frame.Min.X, y, fg)
+		img.Set(frame.Max.X-1, y, fg)
+	}
+
+	face := basicfont.Face7x13
+	glyphW := face.Advance
+	lineH := face.Height
+
+	textWidth := placeholderWidth - 2*placeholderMargin
+	y := placeholderMargin + 8
+	for _, line := range wrapText(title, textWidth/(glyphW*titleScale), maxTitleLines) {
+		drawTextLine(img, line, y, titleScale, fg)
+		y += lineH * titleScale
+	}
+
+	authorLines := wrapText(author, textWidth/glyphW, maxAuthorLines)
+	y = placeholderHeight - placeholderMargin - 8 - len(authorLines)*lineH
+	for _, line := range authorLines {
+		drawTextLine(img, line, y, 1, fg)
+		y += lineH
+	}
+	return img
+}
+
+// drawTextLine renders one centered line with its top edge at y, scaled up
+// by an integer factor with nearest-neighbour sampling.
+func drawTextLine(dst *image.RGBA, text string, y, scale int, c color.Color) {
+	face := basicfont.Face7x13
+	w := len(text) * face.Advance
+	if w == 0 {
+		return
+	}
+	src := image.NewRGBA(image.Rect(0, 0, w, face.Height))
+	d := font.Drawer{
+		Dst:  src,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(0, face.Ascent),
+	}
+	d.DrawString(text)
+
+	x := (dst.Bounds().Dx() - w*scale) / 2
+	r := image.Rect(x, y, x+w*scale, y+face.Height*scale)
+	draw.NearestNeighbor.Scale(dst, r, src, src.Bounds(), draw.Over, nil)
+}
+
+// wrapText folds text to ASCII and word-wraps it to width columns. Text
+// beyond maxLines is cut off with an ellipsis.
+func wrapText(text string, width, maxLines int) []string {
+	words := strings.Fields(toASCII(text))
+	var lines []string
+	var cur string
+	for _, w := range words {
+		if len(w) > width {
+			w = w[:width-1] + "-"
+		}
+		switch {
+		case cur == "":
+			cur = w
+		case len(cur)+1+len(w) <= width:
+			cur += " " + w
+		default:
+			lines = append(lines, cur)
+			cur = w
+		}
+	}
+	if cur != "" {
+		lines = append(lines, cur)
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := lines[maxLines-1]
+		if len(last)+3 > width {
+			last = last[:width-3]
+		}
+		lines[maxLines-1] = strings.TrimSpace(last) + "..."
+	}
+	return lines
+}
+
+// toASCII strips diacritics and replaces anything the built-in bitmap font
+// cannot draw.
+func toASCII(s string) string {
+	s = sanitizeText(s)
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// sanitizeText maps typographic punctuation to ASCII before folding.
+var sanitizeText = strings.NewReplacer(
+	"‘", "'", "’", "'", "“", "\"", "”", "\"",
+	"–", "-", "—", "--", "…", "...",
+).Replace
+
+func writePNG(img image.Image, dest string) error {
+	tmp := tempPath(dest, ".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+func TestGetCoverPathOrPlaceholder(t *testing.T) {
+	m := New(t.TempDir())
+	b := catalog.Book{ID: "sicp", Title: "Structure and Interpretation of Computer Programs", Author: "Abelson & Sussman"}
+
+	path := m.GetCoverPathOrPlaceholder("shelf-books", b)
+	if path == "" {
+		t.Fatal("no placeholder generated")
+	}
+	if !strings.HasSuffix(path, ".png") || !strings.Contains(path, "sicp-placeholder-") {
+		t.Errorf("unexpected placeholder path %q", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(f)
+	_ = f.Close()
+	if err != nil {
+		t.Fatalf("placeholder is not a PNG: %v", err)
+	}
+	if img.Bounds().Dx() != placeholderWidth || img.Bounds().Dy() != placeholderHeight {
+		t.Errorf("bounds = %v", img.Bounds())
+	}
+
+	if again := m.GetCoverPathOrPlaceholder("shelf-books", b); again != path {
+		t.Errorf("placeholder regenerated under a new path: %q", again)
+	}
+
+	// A title change replaces the old placeholder.
+	b.Title = "SICP"
+	renamed := m.GetCoverPathOrPlaceholder("shelf-books", b)
+	if renamed == path {
+		t.Error("placeholder path should change with the title")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("stale placeholder was not removed")
+	}
+
+	// A real cover wins over the placeholder.
+	if err := os.WriteFile(m.CoverPath("shelf-books", "sicp"), []byte("jpg"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetCoverPathOrPlaceholder("shelf-books", b); got != m.CoverPath("shelf-books", "sicp") {
+		t.Errorf("got %q, want extracted cover", got)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("The Wizard of Earthsea", 10, 5)
+	want := []string{"The Wizard", "of", "Earthsea"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapText = %q, want %q", got, want)
+	}
+
+	got = wrapText("one two three four five six", 5, 2)
+	if len(got) != 2 || !strings.HasSuffix(got[1], "...") {
+		t.Errorf("overflow not truncated: %q", got)
+	}
+
+	if got := toASCII("Café — “Noël”"); got != `Cafe -- "Noel"` {
+		t.Errorf("toASCII = %q", got)
+	}
+}
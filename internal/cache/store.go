@@ -1,30 +1,52 @@
 package cache
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Store writes r to the cache path for the given coordinates, verifying
 // the sha256 checksum after write if expectedSHA256 is non-empty.
-// Returns the final file path.
+// Returns the final file path. If another shelfctl process is storing or
+// removing the same book, or sweeping the cache, Store fails with a
+// BusyError.
 func (m *Manager) Store(owner, repo, bookID, assetFilename string, r io.Reader, expectedSHA256 string) (string, error) {
+	lock, err := m.lockBooks(indexKey(owner, repo, assetFilename))
+	if err != nil {
+		return "", err
+	}
+	destPath, err := m.store(owner, repo, bookID, assetFilename, r, expectedSHA256)
+	// The size limit is enforced with the cache lock held alone, so the
+	// book's locks go first.
+	lock.release()
+	if err != nil {
+		return "", err
+	}
+	m.autoGC(owner, repo, assetFilename)
+	return destPath, nil
+}
+
+func (m *Manager) store(owner, repo, bookID, assetFilename string, r io.Reader, expectedSHA256 string) (string, error) {
 	if err := m.EnsureDir(owner, repo, bookID); err != nil {
 		return "", fmt.Errorf("create cache dir: %w", err)
 	}
 
 	destPath := m.Path(owner, repo, bookID, assetFilename)
-	tmpPath := destPath + ".tmp"
+	tmpPath := tempPath(destPath, ".tmp")
 
 	f, err := os.Create(tmpPath)
 	if err != nil {
 		return "", fmt.Errorf("create temp file: %w", err)
 	}
 
-	if _, err := io.Copy(f, r); err != nil {
+	h := sha256.New()
+	if _, err := io.Copy(f, io.TeeReader(r, h)); err != nil {
 		_ = f.Close()
 		_ = os.Remove(tmpPath)
 		return "", fmt.Errorf("writing to cache: %w", err)
@@ -34,24 +56,67 @@ func (m *Manager) Store(owner, repo, bookID, assetFilename string, r io.Reader,
 		return "", fmt.Errorf("closing temp file: %w", err)
 	}
 
-	if err := VerifyFile(tmpPath, expectedSHA256); err != nil {
+	sum := hex.EncodeToString(h.Sum(nil))
+	if expectedSHA256 != "" && sum != expectedSHA256 {
 		_ = os.Remove(tmpPath)
-		return "", err
+		return "", fmt.Errorf("checksum mismatch: expected %s, got %s", expectedSHA256, sum)
 	}
 
-	if err := os.Rename(tmpPath, destPath); err != nil {
+	// File the content under its checksum and link the book's path to it.
+	if err := m.place(owner, repo, bookID, assetFilename, tmpPath, sum); err != nil {
 		_ = os.Remove(tmpPath)
 		return "", err
 	}
 
-	// Extract cover thumbnail for PDFs (best-effort, silently skips on failure)
-	if isPDF(assetFilename) {
+	// Extract cover thumbnail (best-effort, silently skips on failure)
+	switch {
+	case isPDF(assetFilename):
 		_ = m.ExtractCover(repo, bookID, destPath)
+	case isEPUB(assetFilename):
+		_ = m.ExtractEPUBCover(repo, bookID, destPath)
 	}
 
 	return destPath, nil
 }
 
+// place moves a downloaded file into the blob store, links the book's
+// path to it and records the download, which counts as opening the book.
+func (m *Manager) place(owner, repo, bookID, assetFilename, tmpPath, sum string) error {
+	defer m.lockIndex()()
+	idx := m.refreshIndex()
+
+	if err := m.addBlob(tmpPath, sum); err != nil {
+		return err
+	}
+	destPath := m.Path(owner, repo, bookID, assetFilename)
+	linked, err := m.linkBlob(sum, destPath)
+	if err != nil {
+		m.releaseBlob(sum)
+		return err
+	}
+
+	key := indexKey(owner, repo, assetFilename)
+	e := idx[key]
+	old := e.Blob
+	if info, err := os.Stat(destPath); err == nil {
+		recordFile(&e, owner, bookID, info, sum)
+	}
+	e.LastOpened = time.Now().UTC()
+	e.SHA256 = sum
+	e.Blob = ""
+	if linked {
+		e.Blob = sum
+	}
+	_ = m.writeIndex(map[string]*indexEntry{key: &e})
+	if old != e.Blob {
+		m.releaseBlob(old)
+	}
+	if !linked {
+		m.releaseBlob(sum)
+	}
+	return nil
+}
+
 // isPDF checks if the filename indicates a PDF file
 func isPDF(filename string) bool {
 	ext := filepath.Ext(strings.ToLower(filename))
@@ -65,10 +130,12 @@ func (m *Manager) HasBeenModified(owner, repo, bookID, assetFilename, expectedSH
 		return false
 	}
 
-	path := m.Path(owner, repo, bookID, assetFilename)
-	if err := VerifyFile(path, expectedSHA256); err != nil {
-		return true // Checksum mismatch means modified
+	if expectedSHA256 == "" {
+		return false
 	}
-
-	return false
+	sum, _, err := m.SHA256(owner, repo, bookID, assetFilename)
+	if err != nil {
+		return true // Unreadable counts as modified, to keep it safe
+	}
+	return sum != expectedSHA256
 }
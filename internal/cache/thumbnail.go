@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif" // register decoders for embedded cover images
+	"image/jpeg"
+	_ "image/png"
+	"os"
+)
+
+// thumbnailHeight matches the pdftoppm -scale-to setting used for PDF covers.
+const thumbnailHeight = 300
+
+// writeThumbnail decodes an image, scales it down to thumbnailHeight and
+// writes it to dest as a JPEG. Images already small enough are re-encoded
+// without scaling.
+func writeThumbnail(data []byte, dest string) error {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return writeJPEG(scaleToHeight(src, thumbnailHeight), dest)
+}
+
+func writeJPEG(img image.Image, dest string) error {
+	tmp := tempPath(dest, ".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 85}); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// scaleToHeight downsamples src with a box filter so its height is at most h.
+func scaleToHeight(src image.Image, h int) image.Image {
+	b := src.Bounds()
+	if b.Dy() <= h || b.Dy() == 0 {
+		return src
+	}
+	w := b.Dx() * h / b.Dy()
+	if w < 1 {
+		w = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy0 := b.Min.Y + y*b.Dy()/h
+		sy1 := b.Min.Y + (y+1)*b.Dy()/h
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		for x := 0; x < w; x++ {
+			sx0 := b.Min.X + x*b.Dx()/w
+			sx1 := b.Min.X + (x+1)*b.Dx()/w
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,52 @@
+package calibre
+
+import "strings"
+
+// DefaultFormatPreference is used when no --format flag is given.
+var DefaultFormatPreference = []string{"EPUB", "PDF", "AZW3", "MOBI"}
+
+// Filter selects books for import. Empty fields match everything.
+type Filter struct {
+	// Tags matches books carrying any of these tags (case-insensitive).
+	Tags []string
+	// Authors matches books where any author contains any of these
+	// substrings (case-insensitive).
+	Authors []string
+	// Formats matches books stored in any of these formats.
+	Formats []string
+}
+
+// Match reports whether b passes the filter.
+func (f Filter) Match(b *Book) bool {
+	if len(f.Tags) > 0 && !anyMatch(b.Tags, f.Tags, strings.EqualFold) {
+		return false
+	}
+	if len(f.Authors) > 0 && !anyMatch(b.Authors, f.Authors, containsFold) {
+		return false
+	}
+	if len(f.Formats) > 0 {
+		var formats []string
+		for _, fm := range b.Formats {
+			formats = append(formats, fm.Format)
+		}
+		if !anyMatch(formats, f.Formats, strings.EqualFold) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyMatch(have, want []string, eq func(a, b string) bool) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if eq(h, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,274 @@
+// Package calibre reads Calibre ebook libraries.
+package calibre
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MetadataDB is the name of Calibre's library database.
+const MetadataDB = "metadata.db"
+
+// Book is one book in a Calibre library.
+type Book struct {
+	ID          int64
+	UUID        string
+	Title       string
+	Authors     []string
+	Tags        []string
+	Series      string
+	SeriesIndex float64
+	Publisher   string
+	PubDate     string
+	Identifiers map[string]string
+	Comments    string
+	HasCover    bool
+	// Path is the book's directory relative to the library root.
+	Path    string
+	Formats []Format
+}
+
+// Format is one stored file of a book.
+type Format struct {
+	// Format is Calibre's upper-case format name, e.g. "EPUB".
+	Format string
+	// File is the absolute path of the file.
+	File string
+	Size int64
+}
+
+// Ext returns the lower-case file extension for the format.
+func (f Format) Ext() string {
+	return strings.ToLower(f.Format)
+}
+
+// Author returns the authors joined for display.
+func (b *Book) Author() string {
+	return strings.Join(b.Authors, ", ")
+}
+
+// Year returns the publication year, or 0 when Calibre has none. Calibre
+// stores an undefined date as year 101.
+func (b *Book) Year() int {
+	if len(b.PubDate) < 4 {
+		return 0
+	}
+	y, err := strconv.Atoi(b.PubDate[:4])
+	if err != nil || y < 1000 {
+		return 0
+	}
+	return y
+}
+
+// ISBN returns the isbn identifier, if any.
+func (b *Book) ISBN() string {
+	return b.Identifiers["isbn"]
+}
+
+// PickFormat returns the first available format in preference order, or
+// the first stored format if none of the preferred ones exist.
+func (b *Book) PickFormat(prefer []string) *Format {
+	for _, p := range prefer {
+		for i := range b.Formats {
+			if strings.EqualFold(b.Formats[i].Format, p) {
+				return &b.Formats[i]
+			}
+		}
+	}
+	if len(b.Formats) > 0 {
+		return &b.Formats[0]
+	}
+	return nil
+}
+
+// Library is an opened Calibre library.
+type Library struct {
+	Dir   string
+	Books []Book
+}
+
+// CoverPath returns the path of the book's cover.jpg, or "" if it has none.
+func (l *Library) CoverPath(b *Book) string {
+	if !b.HasCover {
+		return ""
+	}
+	return filepath.Join(l.Dir, filepath.FromSlash(b.Path), "cover.jpg")
+}
+
+// WALPending reports whether the library has a non-empty write-ahead log,
+// meaning Calibre is running or exited without checkpointing. Recent
+// changes may then be missing from metadata.db.
+func WALPending(dir string) bool {
+	fi, err := os.Stat(filepath.Join(dir, MetadataDB+"-wal"))
+	return err == nil && fi.Size() > 0
+}
+
+// Open reads all books from the library at dir. The database is only read,
+// never modified.
+func Open(dir string) (*Library, error) {
+	db, err := openSQLite(filepath.Join(dir, MetadataDB))
+	if err != nil {
+		return nil, fmt.Errorf("opening calibre library: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if !db.HasTable("books") {
+		return nil, fmt.Errorf("%s has no books table; is this a Calibre library?", dir)
+	}
+
+	bookRows, err := db.Rows("books")
+	if err != nil {
+		return nil, fmt.Errorf("reading books: %w", err)
+	}
+	books := make([]Book, 0, len(bookRows))
+	byID := map[int64]*Book{}
+	for _, r := range bookRows {
+		books = append(books, Book{
+			ID:          intVal(r["id"]),
+			UUID:        strVal(r["uuid"]),
+			Title:       strVal(r["title"]),
+			SeriesIndex: floatVal(r["series_index"]),
+			PubDate:     strVal(r["pubdate"]),
+			Path:        strVal(r["path"]),
+			HasCover:    intVal(r["has_cover"]) != 0,
+			Identifiers: map[string]string{},
+		})
+	}
+	for i := range books {
+		byID[books[i].ID] = &books[i]
+	}
+
+	// Many-to-many links resolve through a name table.
+	links := []struct {
+		table, linkTable, column string
+		add                      func(b *Book, name string)
+	}{
+		{"authors", "books_authors_link", "author", func(b *Book, v string) { b.Authors = append(b.Authors, v) }},
+		{"tags", "books_tags_link", "tag", func(b *Book, v string) { b.Tags = append(b.Tags, v) }},
+		{"series", "books_series_link", "series", func(b *Book, v string) { b.Series = v }},
+		{"publishers", "books_publishers_link", "publisher", func(b *Book, v string) { b.Publisher = v }},
+	}
+	for _, lk := range links {
+		if !db.HasTable(lk.table) || !db.HasTable(lk.linkTable) {
+			continue
+		}
+		names, err := nameMap(db, lk.table)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := db.Rows(lk.linkTable)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", lk.linkTable, err)
+		}
+		for _, r := range rows {
+			b := byID[intVal(r["book"])]
+			name, ok := names[intVal(r[lk.column])]
+			if b != nil && ok {
+				lk.add(b, name)
+			}
+		}
+	}
+
+	if db.HasTable("identifiers") {
+		rows, err := db.Rows("identifiers")
+		if err != nil {
+			return nil, fmt.Errorf("reading identifiers: %w", err)
+		}
+		for _, r := range rows {
+			if b := byID[intVal(r["book"])]; b != nil {
+				b.Identifiers[strings.ToLower(strVal(r["type"]))] = strVal(r["val"])
+			}
+		}
+	}
+
+	if db.HasTable("comments") {
+		rows, err := db.Rows("comments")
+		if err != nil {
+			return nil, fmt.Errorf("reading comments: %w", err)
+		}
+		for _, r := range rows {
+			if b := byID[intVal(r["book"])]; b != nil {
+				b.Comments = strVal(r["text"])
+			}
+		}
+	}
+
+	if db.HasTable("data") {
+		rows, err := db.Rows("data")
+		if err != nil {
+			return nil, fmt.Errorf("reading data: %w", err)
+		}
+		for _, r := range rows {
+			b := byID[intVal(r["book"])]
+			if b == nil {
+				continue
+			}
+			format := strings.ToUpper(strVal(r["format"]))
+			file := strVal(r["name"]) + "." + strings.ToLower(format)
+			b.Formats = append(b.Formats, Format{
+				Format: format,
+				File:   filepath.Join(dir, filepath.FromSlash(b.Path), file),
+				Size:   intVal(r["uncompressed_size"]),
+			})
+		}
+	}
+
+	for i := range books {
+		sort.SliceStable(books[i].Formats, func(a, c int) bool {
+			return books[i].Formats[a].Format < books[i].Formats[c].Format
+		})
+	}
+
+	return &Library{Dir: dir, Books: books}, nil
+}
+
+func nameMap(db *sqliteDB, table string) (map[int64]string, error) {
+	rows, err := db.Rows(table)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", table, err)
+	}
+	m := make(map[int64]string, len(rows))
+	for _, r := range rows {
+		m[intVal(r["id"])] = strVal(r["name"])
+	}
+	return m, nil
+}
+
+func strVal(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case int64:
+		return strconv.FormatInt(s, 10)
+	}
+	return ""
+}
+
+func intVal(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	case string:
+		i, _ := strconv.ParseInt(n, 10, 64)
+		return i
+	}
+	return 0
+}
+
+func floatVal(v interface{}) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,150 @@
+package calibre
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/library is generated by testdata/gen_library.py.
+const testLibrary = "testdata/library"
+
+func findBook(t *testing.T, lib *Library, title string) *Book {
+	t.Helper()
+	for i := range lib.Books {
+		if lib.Books[i].Title == title {
+			return &lib.Books[i]
+		}
+	}
+	t.Fatalf("book %q not found", title)
+	return nil
+}
+
+func TestOpen(t *testing.T) {
+	lib, err := Open(testLibrary)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if len(lib.Books) != 153 {
+		t.Errorf("got %d books, want 153 (interior b-tree pages not walked?)", len(lib.Books))
+	}
+
+	b := findBook(t, lib, "A Wizard of Earthsea")
+	if b.ID != 1 || b.UUID != "9b1c0b7e-earthsea" {
+		t.Errorf("ID/UUID = %d/%q", b.ID, b.UUID)
+	}
+	if b.Author() != "Ursula K. Le Guin" {
+		t.Errorf("Author = %q", b.Author())
+	}
+	if strings.Join(b.Tags, ",") != "Fantasy,Classics" {
+		t.Errorf("Tags = %v", b.Tags)
+	}
+	if b.Series != "Earthsea" || b.SeriesIndex != 1 {
+		t.Errorf("Series = %q #%v", b.Series, b.SeriesIndex)
+	}
+	if b.Publisher != "Parnassus Press" || b.ISBN() != "9780547773742" || b.Year() != 1968 {
+		t.Errorf("Publisher/ISBN/Year = %q/%q/%d", b.Publisher, b.ISBN(), b.Year())
+	}
+	if len(b.Comments) < 3000 {
+		t.Errorf("comment has %d bytes; overflow pages not followed", len(b.Comments))
+	}
+	if len(b.Formats) != 2 || b.Formats[0].Format != "EPUB" || b.Formats[1].Format != "PDF" {
+		t.Fatalf("Formats = %+v", b.Formats)
+	}
+	wantFile := filepath.Join(testLibrary, "Ursula K. Le Guin", "A Wizard of Earthsea (1)", "A Wizard of Earthsea - Ursula K. Le Guin.epub")
+	if b.Formats[0].File != wantFile || b.Formats[0].Size != int64(len("epub bytes")) {
+		t.Errorf("EPUB format = %+v", b.Formats[0])
+	}
+	if lib.CoverPath(b) == "" {
+		t.Error("expected a cover path")
+	}
+
+	sicp := findBook(t, lib, "Structure and Interpretation of Computer Programs")
+	if sicp.Author() != "Harold Abelson, Gerald Jay Sussman" {
+		t.Errorf("multi-author order = %q", sicp.Author())
+	}
+	if lib.CoverPath(sicp) != "" {
+		t.Error("book without cover returned a cover path")
+	}
+
+	notes := findBook(t, lib, "Untitled Notes")
+	if notes.Year() != 0 {
+		t.Errorf("undefined pubdate gave year %d", notes.Year())
+	}
+}
+
+func TestOpen_NotALibrary(t *testing.T) {
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Error("expected error for a directory without metadata.db")
+	}
+}
+
+func TestFilterAndPickFormat(t *testing.T) {
+	lib, err := Open(testLibrary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	earthsea := findBook(t, lib, "A Wizard of Earthsea")
+	sicp := findBook(t, lib, "Structure and Interpretation of Computer Programs")
+	notes := findBook(t, lib, "Untitled Notes")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		book   *Book
+		want   bool
+	}{
+		{"empty filter", Filter{}, notes, true},
+		{"tag match", Filter{Tags: []string{"fantasy"}}, earthsea, true},
+		{"tag miss", Filter{Tags: []string{"fantasy"}}, sicp, false},
+		{"author substring", Filter{Authors: []string{"sussman"}}, sicp, true},
+		{"format match", Filter{Formats: []string{"pdf"}}, earthsea, true},
+		{"format miss", Filter{Formats: []string{"pdf"}}, notes, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(tt.book); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if f := earthsea.PickFormat([]string{"pdf", "epub"}); f == nil || f.Format != "PDF" {
+		t.Errorf("PickFormat(pdf first) = %+v", f)
+	}
+	if f := notes.PickFormat(DefaultFormatPreference); f == nil || f.Format != "MOBI" {
+		t.Errorf("PickFormat fallback = %+v", f)
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	cols, rowid := parseColumns(`CREATE TABLE t ( id INTEGER PRIMARY KEY, "name" TEXT DEFAULT (lower('x')), n REAL, UNIQUE(name, n))`)
+	if strings.Join(cols, ",") != "id,name,n" || rowid != 0 {
+		t.Errorf("cols = %v, rowid = %d", cols, rowid)
+	}
+}
+
+func TestDecodeRecord_Damaged(t *testing.T) {
+	for name, rec := range map[string][]byte{
+		// Header of one column whose serial type is a 9-byte varint near 2^64.
+		"huge serial type": {0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 'x'},
+		"huge header":      {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00},
+		"truncated text":   {0x02, 0x21, 'a'},
+	} {
+		if _, err := decodeRecord(rec); err == nil {
+			t.Errorf("%s: decodeRecord succeeded", name)
+		}
+	}
+	if v, err := decodeRecord([]byte{0x02, 0x13, 'a', 'b', 'c'}); err != nil || v[0] != "abc" {
+		t.Errorf("decodeRecord = %v, %v", v, err)
+	}
+}
+
+func TestLeafCell_HugePayload(t *testing.T) {
+	db := &sqliteDB{pageSize: 4096, usable: 4096, pages: 2}
+	// A payload size of 2^40 bytes and rowid 1, in a full page so that
+	// the local part of the payload fits.
+	page := make([]byte, 4096)
+	copy(page, []byte{0xa0, 0x80, 0x80, 0x80, 0x80, 0x00, 0x01})
+	if _, _, err := db.leafCell(page, 0); err == nil {
+		t.Error("leafCell accepted a payload larger than the file")
+	}
+}
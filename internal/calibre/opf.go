@@ -0,0 +1,128 @@
+package calibre
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// OPF is the metadata written to a metadata.opf sidecar, the file Calibre
+// keeps next to every book and uses to rebuild its database.
+type OPF struct {
+	// Identifier is a stable ID for the book, written with Scheme.
+	Identifier string
+	Scheme     string
+	Title      string
+	Authors    []string
+	Tags       []string
+	Publisher  string
+	ISBN       string
+	Year       int
+	// CoverHref is the cover file name relative to the OPF, if any.
+	CoverHref string
+}
+
+// Marshal renders the OPF 2.0 package document.
+func (o OPF) Marshal() []byte {
+	var b bytes.Buffer
+	b.WriteString("<?xml version='1.0' encoding='utf-8'?>\n")
+	b.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="book_id" version="2.0">` + "\n")
+	b.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">` + "\n")
+
+	elem := func(format string, args ...interface{}) {
+		for i, a := range args {
+			if s, ok := a.(string); ok {
+				args[i] = escapeXML(s)
+			}
+		}
+		fmt.Fprintf(&b, "    "+format+"\n", args...)
+	}
+
+	elem(`<dc:identifier opf:scheme="%s" id="book_id">%s</dc:identifier>`, o.Scheme, o.Identifier)
+	if o.ISBN != "" {
+		elem(`<dc:identifier opf:scheme="ISBN">%s</dc:identifier>`, o.ISBN)
+	}
+	elem(`<dc:title>%s</dc:title>`, o.Title)
+	for _, a := range o.Authors {
+		elem(`<dc:creator opf:role="aut">%s</dc:creator>`, a)
+	}
+	if o.Year > 0 {
+		elem(`<dc:date>%04d-01-01T00:00:00+00:00</dc:date>`, o.Year)
+	}
+	if o.Publisher != "" {
+		elem(`<dc:publisher>%s</dc:publisher>`, o.Publisher)
+	}
+	for _, t := range o.Tags {
+		elem(`<dc:subject>%s</dc:subject>`, t)
+	}
+	b.WriteString("  </metadata>\n")
+	if o.CoverHref != "" {
+		b.WriteString("  <guide>\n")
+		elem(`  <reference type="cover" title="Cover" href="%s"/>`, o.CoverHref)
+		b.WriteString("  </guide>\n")
+	}
+	b.WriteString("</package>\n")
+	return b.Bytes()
+}
+
+func escapeXML(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// BookDir returns the directory Calibre would use for a book, relative to
+// the library root: "<first author>/<title>".
+func BookDir(title string, authors []string) string {
+	author := "Unknown"
+	if len(authors) > 0 && strings.TrimSpace(authors[0]) != "" {
+		author = authors[0]
+	}
+	return filepath.Join(safeFilename(author, 100), safeFilename(title, 100))
+}
+
+// BookFile returns the file name Calibre would use for a book's format:
+// "<title> - <first author>.<ext>".
+func BookFile(title string, authors []string, ext string) string {
+	author := "Unknown"
+	if len(authors) > 0 && strings.TrimSpace(authors[0]) != "" {
+		author = authors[0]
+	}
+	return safeFilename(title, 80) + " - " + safeFilename(author, 40) + "." + strings.ToLower(ext)
+}
+
+// safeFilename replaces characters that are invalid on common filesystems
+// and trims the result to max runes, as Calibre does for library paths.
+func safeFilename(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max])
+	}
+	s = strings.TrimRight(strings.TrimSpace(s), ".")
+	if s == "" {
+		return "Unknown"
+	}
+	return s
+}
+
+// FindCalibredb returns the path of the calibredb tool, or "" if it is not
+// on PATH.
+func FindCalibredb() string {
+	path, err := exec.LookPath("calibredb")
+	if err != nil {
+		return ""
+	}
+	return path
+}
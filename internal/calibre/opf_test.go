@@ -0,0 +1,71 @@
+package calibre
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOPFMarshal(t *testing.T) {
+	o := OPF{
+		Identifier: "me/shelf-books/sicp",
+		Scheme:     "shelfctl",
+		Title:      "Structure & Interpretation",
+		Authors:    []string{"Harold Abelson", "Gerald Sussman"},
+		Tags:       []string{"lisp", "shelf:books"},
+		Publisher:  "MIT Press",
+		ISBN:       "9780262510875",
+		Year:       1985,
+		CoverHref:  "cover.jpg",
+	}
+	data := o.Marshal()
+
+	var pkg struct {
+		Metadata struct {
+			Title    string   `xml:"title"`
+			Creators []string `xml:"creator"`
+			Subjects []string `xml:"subject"`
+			Date     string   `xml:"date"`
+			IDs      []string `xml:"identifier"`
+		} `xml:"metadata"`
+		Guide []struct {
+			Href string `xml:"href,attr"`
+		} `xml:"guide>reference"`
+	}
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		t.Fatalf("OPF is not well-formed: %v\n%s", err, data)
+	}
+	md := pkg.Metadata
+	if md.Title != o.Title {
+		t.Errorf("title = %q", md.Title)
+	}
+	if len(md.Creators) != 2 || md.Creators[1] != "Gerald Sussman" {
+		t.Errorf("creators = %v", md.Creators)
+	}
+	if strings.Join(md.Subjects, ",") != "lisp,shelf:books" {
+		t.Errorf("subjects = %v", md.Subjects)
+	}
+	if !strings.HasPrefix(md.Date, "1985-") {
+		t.Errorf("date = %q", md.Date)
+	}
+	if len(md.IDs) != 2 || md.IDs[1] != o.ISBN {
+		t.Errorf("identifiers = %v", md.IDs)
+	}
+	if len(pkg.Guide) != 1 || pkg.Guide[0].Href != "cover.jpg" {
+		t.Errorf("guide = %v", pkg.Guide)
+	}
+}
+
+func TestBookDirAndFile(t *testing.T) {
+	dir := BookDir("What/Why: A Story?", []string{"Ann Author"})
+	if dir != filepath.Join("Ann Author", "What_Why_ A Story_") {
+		t.Errorf("BookDir = %q", dir)
+	}
+	if got := BookDir("Title", nil); got != filepath.Join("Unknown", "Title") {
+		t.Errorf("BookDir without author = %q", got)
+	}
+	if got := BookFile("Title", []string{"Ann Author"}, "EPUB"); got != "Title - Ann Author.epub" {
+		t.Errorf("BookFile = %q", got)
+	}
+}
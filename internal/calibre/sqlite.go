@@ -0,0 +1,399 @@
+package calibre
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// sqliteDB is a minimal read-only reader for SQLite 3 database files. It
+// walks table b-trees and decodes records, which is all that is needed to
+// read Calibre's metadata.db without a cgo or third-party SQL driver.
+// Indexes, WITHOUT ROWID tables and UTF-16 databases are not supported.
+type sqliteDB struct {
+	f        *os.File
+	pageSize int
+	usable   int
+	pages    int
+	tables   map[string]sqliteTable
+}
+
+type sqliteTable struct {
+	rootPage int
+	columns  []string
+	// rowidCol is the index of an INTEGER PRIMARY KEY column, whose value
+	// is stored as the rowid instead of in the record, or -1.
+	rowidCol int
+}
+
+// row is one table row keyed by column name. Values are int64, float64,
+// string, []byte or nil.
+type row map[string]interface{}
+
+func openSQLite(path string) (*sqliteDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hdr := make([]byte, 100)
+	if _, err := f.ReadAt(hdr, 0); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("reading sqlite header: %w", err)
+	}
+	if !bytes.HasPrefix(hdr, []byte("SQLite format 3\x00")) {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s is not a SQLite database", path)
+	}
+	if enc := binary.BigEndian.Uint32(hdr[56:60]); enc > 1 {
+		_ = f.Close()
+		return nil, fmt.Errorf("unsupported sqlite text encoding %d", enc)
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(hdr[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		_ = f.Close()
+		return nil, fmt.Errorf("bad sqlite page size %d", pageSize)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	db := &sqliteDB{
+		f:        f,
+		pageSize: pageSize,
+		usable:   pageSize - int(hdr[20]),
+		pages:    int(stat.Size() / int64(pageSize)),
+		tables:   map[string]sqliteTable{},
+	}
+	if err := db.loadSchema(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *sqliteDB) Close() error {
+	return db.f.Close()
+}
+
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	if n < 1 || n > db.pages {
+		return nil, fmt.Errorf("sqlite page %d out of range", n)
+	}
+	buf := make([]byte, db.pageSize)
+	if _, err := db.f.ReadAt(buf, int64(n-1)*int64(db.pageSize)); err != nil {
+		return nil, fmt.Errorf("reading sqlite page %d: %w", n, err)
+	}
+	return buf, nil
+}
+
+// loadSchema reads sqlite_master, which is always rooted at page 1.
+func (db *sqliteDB) loadSchema() error {
+	master := sqliteTable{rootPage: 1, columns: []string{"type", "name", "tbl_name", "rootpage", "sql"}, rowidCol: -1}
+	return db.scan(master, func(r row) error {
+		if r["type"] != "table" {
+			return nil
+		}
+		name, _ := r["name"].(string)
+		root, _ := r["rootpage"].(int64)
+		sql, _ := r["sql"].(string)
+		if name == "" || root == 0 || strings.Contains(strings.ToUpper(sql), "WITHOUT ROWID") {
+			return nil
+		}
+		cols, rowidCol := parseColumns(sql)
+		db.tables[strings.ToLower(name)] = sqliteTable{rootPage: int(root), columns: cols, rowidCol: rowidCol}
+		return nil
+	})
+}
+
+// HasTable reports whether the database defines the named table.
+func (db *sqliteDB) HasTable(name string) bool {
+	_, ok := db.tables[strings.ToLower(name)]
+	return ok
+}
+
+// Rows returns every row of a table in rowid order.
+func (db *sqliteDB) Rows(table string) ([]row, error) {
+	t, ok := db.tables[strings.ToLower(table)]
+	if !ok {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+	var rows []row
+	err := db.scan(t, func(r row) error {
+		rows = append(rows, r)
+		return nil
+	})
+	return rows, err
+}
+
+func (db *sqliteDB) scan(t sqliteTable, fn func(row) error) error {
+	return db.walk(t.rootPage, 0, func(rowid int64, payload []byte) error {
+		values, err := decodeRecord(payload)
+		if err != nil {
+			return err
+		}
+		r := row{"rowid": rowid}
+		for i, col := range t.columns {
+			var v interface{}
+			if i < len(values) {
+				v = values[i]
+			}
+			if i == t.rowidCol {
+				v = rowid
+			}
+			r[col] = v
+		}
+		return fn(r)
+	})
+}
+
+// walk visits the cells of a table b-tree in order.
+func (db *sqliteDB) walk(pageNum, depth int, fn func(rowid int64, payload []byte) error) error {
+	if depth > 64 {
+		return fmt.Errorf("sqlite b-tree too deep")
+	}
+	buf, err := db.page(pageNum)
+	if err != nil {
+		return err
+	}
+	hdr := 0
+	if pageNum == 1 {
+		hdr = 100
+	}
+	if hdr+8 > len(buf) {
+		return fmt.Errorf("sqlite page %d truncated", pageNum)
+	}
+
+	kind := buf[hdr]
+	ncells := int(binary.BigEndian.Uint16(buf[hdr+3:]))
+	ptrs := hdr + 8
+	if kind == 0x05 {
+		ptrs = hdr + 12
+	}
+	if ptrs+2*ncells > len(buf) {
+		return fmt.Errorf("sqlite page %d has a bad cell count", pageNum)
+	}
+
+	for i := 0; i < ncells; i++ {
+		off := int(binary.BigEndian.Uint16(buf[ptrs+2*i:]))
+		if off >= len(buf) {
+			return fmt.Errorf("sqlite page %d has a bad cell pointer", pageNum)
+		}
+		switch kind {
+		case 0x05: // interior table page
+			if off+4 > len(buf) {
+				return fmt.Errorf("sqlite page %d truncated", pageNum)
+			}
+			child := int(binary.BigEndian.Uint32(buf[off:]))
+			if err := db.walk(child, depth+1, fn); err != nil {
+				return err
+			}
+		case 0x0d: // leaf table page
+			rowid, payload, err := db.leafCell(buf, off)
+			if err != nil {
+				return err
+			}
+			if err := fn(rowid, payload); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("sqlite page %d is not a table page (type %d)", pageNum, kind)
+		}
+	}
+	if kind == 0x05 {
+		right := int(binary.BigEndian.Uint32(buf[hdr+8:]))
+		return db.walk(right, depth+1, fn)
+	}
+	return nil
+}
+
+// leafCell decodes a table leaf cell, following overflow pages.
+func (db *sqliteDB) leafCell(buf []byte, off int) (int64, []byte, error) {
+	size, n := readVarint(buf[off:])
+	off += n
+	rowid, n := readVarint(buf[off:])
+	off += n
+	// No payload is larger than the file, so a damaged size is caught
+	// before it is allocated.
+	total := int(size)
+	if total < 0 || total > db.pages*db.usable {
+		return 0, nil, fmt.Errorf("sqlite cell has a bad payload size")
+	}
+
+	// Local payload size, per the SQLite file format spec.
+	u := db.usable
+	maxLocal := u - 35
+	local := total
+	if total > maxLocal {
+		minLocal := (u-12)*32/255 - 23
+		local = minLocal + (total-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if off+local > len(buf) {
+		return 0, nil, fmt.Errorf("sqlite cell overruns its page")
+	}
+	payload := make([]byte, 0, total)
+	payload = append(payload, buf[off:off+local]...)
+	if local == total {
+		return int64(rowid), payload, nil
+	}
+
+	if off+local+4 > len(buf) {
+		return 0, nil, fmt.Errorf("sqlite cell overruns its page")
+	}
+	next := int(binary.BigEndian.Uint32(buf[off+local:]))
+	for seen := 0; len(payload) < total; seen++ {
+		if next == 0 || seen > db.pages {
+			return 0, nil, fmt.Errorf("sqlite overflow chain is broken")
+		}
+		ov, err := db.page(next)
+		if err != nil {
+			return 0, nil, err
+		}
+		next = int(binary.BigEndian.Uint32(ov))
+		chunk := ov[4:u]
+		if rem := total - len(payload); len(chunk) > rem {
+			chunk = chunk[:rem]
+		}
+		payload = append(payload, chunk...)
+	}
+	return int64(rowid), payload, nil
+}
+
+// readVarint decodes a SQLite big-endian variable-length integer.
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, len(b)
+}
+
+func decodeRecord(p []byte) ([]interface{}, error) {
+	hdrLen, n := readVarint(p)
+	if hdrLen > uint64(len(p)) || hdrLen < uint64(n) {
+		return nil, fmt.Errorf("sqlite record has a bad header")
+	}
+	var types []uint64
+	for pos := n; pos < int(hdrLen); {
+		t, m := readVarint(p[pos:hdrLen])
+		types = append(types, t)
+		pos += m
+	}
+
+	values := make([]interface{}, len(types))
+	body := p[hdrLen:]
+	for i, t := range types {
+		var size uint64
+		switch {
+		case t == 0 || t == 8 || t == 9:
+			size = 0
+		case t >= 1 && t <= 4:
+			size = t
+		case t == 5:
+			size = 6
+		case t == 6 || t == 7:
+			size = 8
+		case t >= 12:
+			size = (t - 12) / 2
+		default:
+			return nil, fmt.Errorf("sqlite record has reserved serial type %d", t)
+		}
+		if size > uint64(len(body)) {
+			return nil, fmt.Errorf("sqlite record truncated")
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case t == 0:
+			values[i] = nil
+		case t == 8:
+			values[i] = int64(0)
+		case t == 9:
+			values[i] = int64(1)
+		case t <= 6:
+			// Sign-extend a big-endian two's complement integer.
+			v := int64(int8(field[0]))
+			for _, c := range field[1:] {
+				v = v<<8 | int64(c)
+			}
+			values[i] = v
+		case t == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(field))
+		case t%2 == 0:
+			values[i] = append([]byte(nil), field...)
+		default:
+			values[i] = string(field)
+		}
+	}
+	return values, nil
+}
+
+// parseColumns extracts column names from a CREATE TABLE statement and
+// reports which column, if any, aliases the rowid.
+func parseColumns(sql string) ([]string, int) {
+	open := strings.Index(sql, "(")
+	end := strings.LastIndex(sql, ")")
+	if open < 0 || end <= open {
+		return nil, -1
+	}
+
+	var defs []string
+	depth, start := 0, open+1
+	for i := open + 1; i < end; i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, sql[start:i])
+				start = i + 1
+			}
+		}
+	}
+	defs = append(defs, sql[start:end])
+
+	var cols []string
+	rowidCol := -1
+	for _, def := range defs {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		keyword := strings.ToUpper(fields[0])
+		if i := strings.Index(keyword, "("); i >= 0 {
+			keyword = keyword[:i]
+		}
+		switch keyword {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			continue
+		}
+		name := strings.Trim(fields[0], "\"`[]")
+		upper := strings.ToUpper(strings.Join(fields[1:], " "))
+		if strings.HasPrefix(upper, "INTEGER PRIMARY KEY") {
+			rowidCol = len(cols)
+		}
+		cols = append(cols, name)
+	}
+	return cols, rowidCol
+}
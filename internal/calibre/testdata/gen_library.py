@@ -0,0 +1,100 @@
+#!/usr/bin/env python3
+"""Regenerates testdata/library, a tiny Calibre library used by the tests.
+
+The page size is kept at 1024 bytes so the books table needs interior
+b-tree pages and the long comment spills onto overflow pages.
+"""
+import os
+import shutil
+import sqlite3
+
+here = os.path.dirname(os.path.abspath(__file__))
+lib = os.path.join(here, "library")
+shutil.rmtree(lib, ignore_errors=True)
+os.makedirs(lib)
+
+db = sqlite3.connect(os.path.join(lib, "metadata.db"))
+db.execute("PRAGMA page_size = 1024")
+db.executescript("""
+CREATE TABLE books ( id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL DEFAULT 'Unknown' COLLATE NOCASE,
+    sort TEXT COLLATE NOCASE,
+    timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    pubdate TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    series_index REAL NOT NULL DEFAULT 1.0,
+    author_sort TEXT COLLATE NOCASE,
+    isbn TEXT DEFAULT "" COLLATE NOCASE,
+    lccn TEXT DEFAULT "" COLLATE NOCASE,
+    path TEXT NOT NULL DEFAULT "",
+    flags INTEGER NOT NULL DEFAULT 1,
+    uuid TEXT,
+    has_cover BOOL DEFAULT 0,
+    last_modified TIMESTAMP NOT NULL DEFAULT "2000-01-01 00:00:00+00:00");
+CREATE TABLE authors ( id INTEGER PRIMARY KEY, name TEXT NOT NULL COLLATE NOCASE, sort TEXT COLLATE NOCASE, link TEXT NOT NULL DEFAULT "", UNIQUE(name));
+CREATE TABLE books_authors_link ( id INTEGER PRIMARY KEY, book INTEGER NOT NULL, author INTEGER NOT NULL, UNIQUE(book, author));
+CREATE TABLE tags ( id INTEGER PRIMARY KEY, name TEXT NOT NULL COLLATE NOCASE, UNIQUE (name));
+CREATE TABLE books_tags_link ( id INTEGER PRIMARY KEY, book INTEGER NOT NULL, tag INTEGER NOT NULL, UNIQUE(book, tag));
+CREATE TABLE series ( id INTEGER PRIMARY KEY, name TEXT NOT NULL COLLATE NOCASE, sort TEXT COLLATE NOCASE, UNIQUE (name));
+CREATE TABLE books_series_link ( id INTEGER PRIMARY KEY, book INTEGER NOT NULL, series INTEGER NOT NULL, UNIQUE(book));
+CREATE TABLE publishers ( id INTEGER PRIMARY KEY, name TEXT NOT NULL COLLATE NOCASE, sort TEXT COLLATE NOCASE, UNIQUE(name));
+CREATE TABLE books_publishers_link ( id INTEGER PRIMARY KEY, book INTEGER NOT NULL, publisher INTEGER NOT NULL, UNIQUE(book));
+CREATE TABLE identifiers ( id INTEGER PRIMARY KEY, book INTEGER NOT NULL, type TEXT NOT NULL DEFAULT "isbn" COLLATE NOCASE, val TEXT NOT NULL COLLATE NOCASE, UNIQUE(book, type));
+CREATE TABLE comments ( id INTEGER PRIMARY KEY, book INTEGER NOT NULL, text TEXT NOT NULL COLLATE NOCASE, UNIQUE(book));
+CREATE TABLE data ( id INTEGER PRIMARY KEY, book INTEGER NOT NULL, format TEXT NOT NULL COLLATE NOCASE, uncompressed_size INTEGER NOT NULL, name TEXT NOT NULL, UNIQUE(book, format));
+""")
+
+def add_book(title, authors, path, pubdate="0101-01-01 00:00:00+00:00", uuid=None, has_cover=0):
+    cur = db.execute(
+        "INSERT INTO books (title, sort, pubdate, path, uuid, has_cover) VALUES (?, ?, ?, ?, ?, ?)",
+        (title, title, pubdate, path, uuid or "uuid-%s" % path.replace("/", "-"), has_cover))
+    book = cur.lastrowid
+    for a in authors:
+        db.execute("INSERT OR IGNORE INTO authors (name, sort) VALUES (?, ?)", (a, a))
+        aid = db.execute("SELECT id FROM authors WHERE name = ?", (a,)).fetchone()[0]
+        db.execute("INSERT INTO books_authors_link (book, author) VALUES (?, ?)", (book, aid))
+    return book
+
+def link(table, column, book, name):
+    db.execute("INSERT OR IGNORE INTO %s (name) VALUES (?)" % table, (name,))
+    nid = db.execute("SELECT id FROM %s WHERE name = ?" % table, (name,)).fetchone()[0]
+    db.execute("INSERT INTO books_%s_link (book, %s) VALUES (?, ?)" % (table, column), (book, nid))
+
+def add_file(book, path, name, fmt, content):
+    d = os.path.join(lib, path)
+    os.makedirs(d, exist_ok=True)
+    with open(os.path.join(d, name + "." + fmt.lower()), "wb") as f:
+        f.write(content)
+    db.execute("INSERT INTO data (book, format, uncompressed_size, name) VALUES (?, ?, ?, ?)",
+               (book, fmt, len(content), name))
+
+b = add_book("A Wizard of Earthsea", ["Ursula K. Le Guin"], "Ursula K. Le Guin/A Wizard of Earthsea (1)",
+             pubdate="1968-11-01 00:00:00+00:00", uuid="9b1c0b7e-earthsea", has_cover=1)
+link("tags", "tag", b, "Fantasy")
+link("tags", "tag", b, "Classics")
+link("series", "series", b, "Earthsea")
+db.execute("UPDATE books SET series_index = 1.0 WHERE id = ?", (b,))
+link("publishers", "publisher", b, "Parnassus Press")
+db.execute("INSERT INTO identifiers (book, type, val) VALUES (?, 'isbn', '9780547773742')", (b,))
+db.execute("INSERT INTO comments (book, text) VALUES (?, ?)", (b, "<p>" + "A long comment. " * 200 + "</p>"))
+add_file(b, "Ursula K. Le Guin/A Wizard of Earthsea (1)", "A Wizard of Earthsea - Ursula K. Le Guin", "EPUB", b"epub bytes")
+add_file(b, "Ursula K. Le Guin/A Wizard of Earthsea (1)", "A Wizard of Earthsea - Ursula K. Le Guin", "PDF", b"%PDF-1.4 pdf bytes")
+with open(os.path.join(lib, "Ursula K. Le Guin/A Wizard of Earthsea (1)", "cover.jpg"), "wb") as f:
+    f.write(b"\xff\xd8\xff\xe0 not really a jpeg")
+
+b = add_book("Structure and Interpretation of Computer Programs", ["Harold Abelson", "Gerald Jay Sussman"],
+             "Harold Abelson/Structure and Interpretation of Computer Programs (2)",
+             pubdate="1996-07-25 00:00:00+00:00", uuid="0d4f-sicp")
+link("tags", "tag", b, "Programming")
+add_file(b, "Harold Abelson/Structure and Interpretation of Computer Programs (2)",
+         "Structure and Interpretation of Computer Programs - Harold Abelson", "PDF", b"%PDF-1.4 sicp")
+
+b = add_book("Untitled Notes", ["Unknown"], "Unknown/Untitled Notes (3)", uuid="notes")
+add_file(b, "Unknown/Untitled Notes (3)", "Untitled Notes - Unknown", "MOBI", b"mobi bytes")
+
+# Filler rows push the books table past a single page.
+for i in range(150):
+    add_book("Filler Book %03d" % i, ["Filler Author"], "Filler Author/Filler Book %03d (%d)" % (i, i + 4))
+
+db.commit()
+db.execute("VACUUM")
+db.close()
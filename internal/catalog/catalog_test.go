@@ -292,3 +292,26 @@ func TestMarshal_EmptySlice(t *testing.T) {
 		t.Error("Marshal empty slice returned empty bytes")
 	}
 }
+
+// --- Trash ---
+
+func TestLiveAndInTrash(t *testing.T) {
+	books, _ := catalog.Parse(sampleYAML)
+	books[1].Trashed = &catalog.Trashed{At: "2026-03-01T10:00:00Z", Release: "systems", Asset: "ostep.pdf"}
+
+	data, err := catalog.Marshal(books)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	books, _ = catalog.Parse(data)
+	if !books[1].IsTrashed() || books[1].Trashed.Release != "systems" {
+		t.Fatalf("trash marker lost in round-trip: %+v", books[1].Trashed)
+	}
+
+	if live := catalog.Live(books); len(live) != 1 || live[0].ID != "sicp" {
+		t.Errorf("Live = %v, want only sicp", live)
+	}
+	if trashed := catalog.InTrash(books); len(trashed) != 1 || trashed[0].ID != "ostep" {
+		t.Errorf("InTrash = %v, want only ostep", trashed)
+	}
+}
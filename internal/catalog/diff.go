@@ -0,0 +1,92 @@
+package catalog
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ChangeKind is what happened to a book between two catalogs.
+type ChangeKind string
+
+// Change kinds.
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeEdited  ChangeKind = "edited"
+	ChangeMoved   ChangeKind = "moved"
+)
+
+// Change is one book-level difference between two catalogs.
+type Change struct {
+	Kind  ChangeKind
+	ID    string
+	Title string
+	// Fields are the catalog keys that changed, for ChangeEdited.
+	Fields []string
+	// From and To are the old and new location ("owner/repo@release") for
+	// ChangeMoved, or free-form detail for adds and removes.
+	From, To string
+}
+
+// Location returns where the book's asset lives, as owner/repo@release.
+func (s Source) Location() string {
+	return s.Owner + "/" + s.Repo + "@" + s.Release
+}
+
+// Diff returns the book-level changes from old to updated: books added, removed,
+// edited (with the keys that changed) and moved to another release. A book
+// that moved and was edited in the same step yields both changes. Changes
+// follow the order of updated, with removals last.
+func Diff(old, updated []Book) []Change {
+	before := make(map[string]*Book, len(old))
+	for i := range old {
+		before[old[i].ID] = &old[i]
+	}
+	after := make(map[string]bool, len(updated))
+
+	var changes []Change
+	for i := range updated {
+		b := &updated[i]
+		after[b.ID] = true
+		prev := before[b.ID]
+		if prev == nil {
+			changes = append(changes, Change{Kind: ChangeAdded, ID: b.ID, Title: b.Title})
+			continue
+		}
+		if from, to := prev.Source.Location(), b.Source.Location(); from != to {
+			changes = append(changes, Change{Kind: ChangeMoved, ID: b.ID, Title: b.Title, From: from, To: to})
+		}
+		if fields := changedFields(prev, b); len(fields) > 0 {
+			changes = append(changes, Change{Kind: ChangeEdited, ID: b.ID, Title: b.Title, Fields: fields})
+		}
+	}
+	for _, b := range old {
+		if !after[b.ID] {
+			changes = append(changes, Change{Kind: ChangeRemoved, ID: b.ID, Title: b.Title})
+		}
+	}
+	return changes
+}
+
+// changedFields lists the catalog keys that differ between a and b. A
+// change of location is reported by Diff as a move; other source changes
+// show up as "asset".
+func changedFields(a, b *Book) []string {
+	var fields []string
+	va, vb := reflect.ValueOf(*a), reflect.ValueOf(*b)
+	t := va.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "source" {
+			if a.Source.Asset != b.Source.Asset || a.Source.Type != b.Source.Type ||
+				!reflect.DeepEqual(a.Source.Parts, b.Source.Parts) {
+				fields = append(fields, "asset")
+			}
+			continue
+		}
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,42 @@
+package catalog_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+func TestDiff(t *testing.T) {
+	old, err := catalog.Parse(sampleYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := catalog.Parse(sampleYAML)
+
+	// Edit sicp, move ostep, drop nothing yet, add one.
+	updated[0].Title = "SICP"
+	updated[0].Tags = append(updated[0].Tags, "classic")
+	updated[1].Source.Release = "archive"
+	updated = append(updated, catalog.Book{ID: "tapl", Title: "Types and Programming Languages"})
+
+	got := catalog.Diff(old, updated)
+	want := []catalog.Change{
+		{Kind: catalog.ChangeEdited, ID: "sicp", Title: "SICP", Fields: []string{"title", "tags"}},
+		{Kind: catalog.ChangeMoved, ID: "ostep", Title: old[1].Title,
+			From: "alice/shelf-programming@systems", To: "alice/shelf-programming@archive"},
+		{Kind: catalog.ChangeAdded, ID: "tapl", Title: "Types and Programming Languages"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff =\n%+v\nwant\n%+v", got, want)
+	}
+
+	got = catalog.Diff(updated, old[:1])
+	if len(got) != 3 || got[1].Kind != catalog.ChangeRemoved || got[1].ID != "ostep" || got[2].ID != "tapl" {
+		t.Errorf("removals: %+v", got)
+	}
+
+	if got := catalog.Diff(old, old); len(got) != 0 {
+		t.Errorf("Diff of equal catalogs = %+v", got)
+	}
+}
@@ -1,11 +1,17 @@
 package catalog
 
+import "fmt"
+
 // Book is one entry in a shelf's catalog.yml.
 type Book struct {
 	ID        string   `yaml:"id"`
 	Title     string   `yaml:"title"`
 	Author    string   `yaml:"author,omitempty"`
 	Year      int      `yaml:"year,omitempty"`
+	Publisher string   `yaml:"publisher,omitempty"`
+	ISBN      string   `yaml:"isbn,omitempty"`
+	Subjects  []string `yaml:"subjects,omitempty"`
+	Pages     int      `yaml:"pages,omitempty"`
 	Tags      []string `yaml:"tags,omitempty"`
 	Format    string   `yaml:"format"`
 	Cover     string   `yaml:"cover,omitempty"`
@@ -13,6 +19,27 @@ type Book struct {
 	SizeBytes int64    `yaml:"size_bytes,omitempty"`
 	Source    Source   `yaml:"source"`
 	Meta      Meta     `yaml:"meta,omitempty"`
+	// Public marks the book for listing on the published site.
+	Public bool `yaml:"public,omitempty"`
+	// Encryption names the scheme the asset is encrypted with, if any.
+	// Checksum and SizeBytes always describe the plaintext.
+	Encryption string `yaml:"encryption,omitempty"`
+	// Trashed is set on deleted books whose asset waits in the shelf's
+	// trash release until the trash is emptied.
+	Trashed *Trashed `yaml:"trashed,omitempty"`
+}
+
+// Trashed records when a book was deleted and where its asset was, so it
+// can be restored.
+type Trashed struct {
+	At      string `yaml:"at"`
+	Release string `yaml:"release"`
+	Asset   string `yaml:"asset"`
+}
+
+// IsTrashed reports whether the book is in the trash.
+func (b *Book) IsTrashed() bool {
+	return b.Trashed != nil
 }
 
 // Checksum holds content hashes.
@@ -27,10 +54,53 @@ type Source struct {
 	Repo    string `yaml:"repo"`
 	Release string `yaml:"release"`
 	Asset   string `yaml:"asset"`
+	// Parts is set when the file was too large for one release asset and
+	// was uploaded in pieces; Asset is then the name of the whole file.
+	Parts []Part `yaml:"parts,omitempty"`
+}
+
+// Part is one piece of a multi-part asset. Size and SHA256 describe the
+// plaintext of the piece.
+type Part struct {
+	Asset  string `yaml:"asset"`
+	Size   int64  `yaml:"size"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// PartName returns the asset name of part i (from 0) of an asset.
+func PartName(asset string, i int) string {
+	return fmt.Sprintf("%s.part%03d", asset, i+1)
+}
+
+// IsMultiPart reports whether the asset was uploaded in parts.
+func (s Source) IsMultiPart() bool {
+	return len(s.Parts) > 0
+}
+
+// AssetNames returns the release assets holding the file: its parts, or
+// the asset itself.
+func (s Source) AssetNames() []string {
+	if !s.IsMultiPart() {
+		return []string{s.Asset}
+	}
+	names := make([]string, len(s.Parts))
+	for i, p := range s.Parts {
+		names[i] = p.Asset
+	}
+	return names
+}
+
+// SetAsset renames the asset, and its parts along with it.
+func (s *Source) SetAsset(name string) {
+	s.Asset = name
+	for i := range s.Parts {
+		s.Parts[i].Asset = PartName(name, i)
+	}
 }
 
 // Meta holds optional provenance data.
 type Meta struct {
 	AddedAt      string `yaml:"added_at,omitempty"`
 	MigratedFrom string `yaml:"migrated_from,omitempty"`
+	EnrichedAt   string `yaml:"enriched_at,omitempty"`
 }
@@ -40,3 +40,25 @@ func Remove(books []Book, id string) ([]Book, bool) {
 	}
 	return books, false
 }
+
+// Live returns the books that are not in the trash.
+func Live(books []Book) []Book {
+	live := make([]Book, 0, len(books))
+	for _, b := range books {
+		if !b.IsTrashed() {
+			live = append(live, b)
+		}
+	}
+	return live
+}
+
+// InTrash returns the books that are in the trash.
+func InTrash(books []Book) []Book {
+	var trashed []Book
+	for _, b := range books {
+		if b.IsTrashed() {
+			trashed = append(trashed, b)
+		}
+	}
+	return trashed
+}
@@ -33,16 +33,15 @@ func Load() (*Config, error) {
 	v.SetDefault("defaults.release", "library")
 	v.SetDefault("defaults.asset_naming", "id")
 	v.SetDefault("defaults.cache_dir", defaultCacheDir())
+	v.SetDefault("defaults.journal_dir", defaultJournalDir())
+	v.SetDefault("enrich.api_base", "https://openlibrary.org")
+	v.SetDefault("enrich.covers_base", "https://covers.openlibrary.org")
 
 	v.SetEnvPrefix("SHELFCTL")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
-	configPath := os.Getenv("SHELFCTL_CONFIG")
-	if configPath == "" {
-		configPath = DefaultPath()
-	}
-	v.SetConfigFile(configPath)
+	v.SetConfigFile(filePath())
 
 	if err := v.ReadInConfig(); err != nil {
 		// Not finding the config file is fine — the init command creates it.
@@ -58,25 +57,52 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("parsing config at %s: %w\n\nHint: Check YAML syntax, or delete the file and run 'shelfctl init' to recreate", DefaultPath(), err)
 	}
 
-	// Resolve token from env (never stored in file).
-	tokenEnv := cfg.GitHub.TokenEnv
-	if tokenEnv == "" {
-		tokenEnv = "GITHUB_TOKEN"
+	// Resolve the token along the credential chain (never stored in file).
+	cfg.GitHub.Token, cfg.GitHub.TokenSource = cfg.GitHub.ResolveToken()
+	if err := cfg.resolveProfiles(); err != nil {
+		return nil, fmt.Errorf("config at %s: %w", DefaultPath(), err)
+	}
+	if err := cfg.validateReleasePolicies(); err != nil {
+		return nil, fmt.Errorf("config at %s: %w", DefaultPath(), err)
 	}
-	cfg.GitHub.Token = os.Getenv(tokenEnv)
-	if cfg.GitHub.Token == "" {
-		cfg.GitHub.Token = os.Getenv("SHELFCTL_GITHUB_TOKEN")
+	if _, err := cfg.Defaults.CacheQuota(); err != nil {
+		return nil, fmt.Errorf("config at %s: defaults.cache_max_size: %w", DefaultPath(), err)
 	}
 
 	// Expand ~ in cache dir.
 	cfg.Defaults.CacheDir = util.ExpandHome(cfg.Defaults.CacheDir)
+	cfg.Defaults.JournalDir = util.ExpandHome(cfg.Defaults.JournalDir)
 
 	return &cfg, nil
 }
 
-// Save writes the config to the default path.
+// validateReleasePolicies checks the default and per-shelf release policies.
+func (c *Config) validateReleasePolicies() error {
+	if err := c.Defaults.Releases.Validate(); err != nil {
+		return fmt.Errorf("defaults.releases: %w", err)
+	}
+	for _, s := range c.Shelves {
+		if s.Releases == nil {
+			continue
+		}
+		if err := s.Releases.Validate(); err != nil {
+			return fmt.Errorf("shelf %q releases: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// filePath returns the config file in use: $SHELFCTL_CONFIG or the default.
+func filePath() string {
+	if p := os.Getenv("SHELFCTL_CONFIG"); p != "" {
+		return p
+	}
+	return DefaultPath()
+}
+
+// Save writes the config to the file Load reads.
 func Save(cfg *Config) error {
-	path := DefaultPath()
+	path := filePath()
 	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
 		return err
 	}
@@ -94,3 +120,8 @@ func defaultCacheDir() string {
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".local", "share", "shelfctl", "cache")
 }
+
+func defaultJournalDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share", "shelfctl", "journal")
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/util"
+)
+
+// Credential source types, tried in the order they are listed in
+// github.credentials.
+const (
+	CredentialEnv     = "env"     // environment variable
+	CredentialFile    = "file"    // file holding the token
+	CredentialGH      = "gh"      // `gh auth token` of the GitHub CLI
+	CredentialKeyring = "keyring" // Secret Service entry, via secret-tool
+	CredentialCommand = "command" // any command printing the token
+)
+
+// KeyringService is the Secret Service "service" attribute of stored tokens.
+// Profiles use "shelfctl:<profile>".
+const KeyringService = "shelfctl"
+
+// credentialTimeout bounds how long a gh, keyring or command source may run.
+const credentialTimeout = 10 * time.Second
+
+// ErrNoCredential is returned by a source that has no token.
+var ErrNoCredential = errors.New("no token")
+
+// CredentialSource is one place to look for the GitHub token. Tokens
+// themselves are never stored in the config.
+type CredentialSource struct {
+	Type string `mapstructure:"type" yaml:"type"`
+	// Env is the variable for "env" (default: github.token_env, then
+	// SHELFCTL_GITHUB_TOKEN).
+	Env string `mapstructure:"env" yaml:"env,omitempty"`
+	// Path is the token file for "file" (default: DefaultTokenFile()).
+	Path string `mapstructure:"path" yaml:"path,omitempty"`
+	// Command is run by the shell for "command"; its output is the token.
+	Command string `mapstructure:"command" yaml:"command,omitempty"`
+}
+
+// runCredentialCommand runs a credential helper and returns its stdout.
+// Tests replace it.
+var runCredentialCommand = func(stdin []byte, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
+
+// DefaultTokenFile is where `shelfctl auth login --store file` stores the token:
+// next to the config file.
+func DefaultTokenFile() string {
+	return filepath.Join(filepath.Dir(filePath()), "token")
+}
+
+// tokenFile is the default token file of g: DefaultTokenFile for github,
+// token-<profile> for a profile.
+func (g *GitHubConfig) tokenFile() string {
+	if g.profile == "" {
+		return DefaultTokenFile()
+	}
+	return filepath.Join(filepath.Dir(filePath()), "token-"+g.profile)
+}
+
+// keyringService is the Secret Service "service" attribute of g's token.
+// Profiles get their own, so two accounts on one host don't collide.
+func (g *GitHubConfig) keyringService() string {
+	if g.profile == "" {
+		return KeyringService
+	}
+	return KeyringService + ":" + g.profile
+}
+
+// Host returns the GitHub host the config talks to, e.g. "github.com" or
+// "ghe.example.com". It keys gh and keyring lookups.
+func (g *GitHubConfig) Host() string {
+	base := g.APIBase
+	if base == "" {
+		return "github.com"
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return "github.com"
+	}
+	if u.Host == "api.github.com" {
+		return "github.com"
+	}
+	return u.Host
+}
+
+// CredentialChain returns the sources to try for a token. Without
+// github.credentials only the environment is used.
+func (g *GitHubConfig) CredentialChain() []CredentialSource {
+	if len(g.Credentials) == 0 {
+		return []CredentialSource{{Type: CredentialEnv}}
+	}
+	return g.Credentials
+}
+
+// ResolveToken returns the first token found along the credential chain
+// and the source it came from. Sources that fail are skipped.
+func (g *GitHubConfig) ResolveToken() (string, *CredentialSource) {
+	chain := g.CredentialChain()
+	for i := range chain {
+		if token, err := chain[i].Token(g); err == nil {
+			return token, &chain[i]
+		}
+	}
+	return "", nil
+}
+
+// Token looks up the token from this source. It returns ErrNoCredential if
+// the source has none, or another error if it could not be read.
+func (s CredentialSource) Token(g *GitHubConfig) (string, error) {
+	var token string
+	switch s.Type {
+	case CredentialEnv:
+		for _, name := range s.envVars(g) {
+			if token = os.Getenv(name); token != "" {
+				break
+			}
+		}
+	case CredentialFile:
+		data, err := os.ReadFile(s.filePath(g))
+		if os.IsNotExist(err) {
+			return "", ErrNoCredential
+		}
+		if err != nil {
+			return "", err
+		}
+		token = string(data)
+	case CredentialGH:
+		out, err := runCredentialCommand(nil, "gh", "auth", "token", "--hostname", g.Host())
+		if err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return "", ErrNoCredential
+			}
+			return "", err
+		}
+		token = string(out)
+	case CredentialKeyring:
+		out, err := runCredentialCommand(nil, "secret-tool", "lookup", "service", g.keyringService(), "host", g.Host())
+		if err != nil {
+			// secret-tool exits non-zero when nothing is stored.
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) || errors.Is(err, exec.ErrNotFound) {
+				return "", ErrNoCredential
+			}
+			return "", err
+		}
+		token = string(out)
+	case CredentialCommand:
+		if s.Command == "" {
+			return "", fmt.Errorf("credential command is empty")
+		}
+		out, err := runCredentialCommand(nil, shell(), shellFlag(), s.Command)
+		if err != nil {
+			return "", err
+		}
+		token = string(out)
+	default:
+		return "", fmt.Errorf("unknown credential type %q (want env, file, gh, keyring or command)", s.Type)
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrNoCredential
+	}
+	return token, nil
+}
+
+// Describe returns a short human description of the source.
+func (s CredentialSource) Describe(g *GitHubConfig) string {
+	switch s.Type {
+	case CredentialEnv:
+		return "env " + strings.Join(s.envVars(g), ", ")
+	case CredentialFile:
+		return "file " + s.filePath(g)
+	case CredentialGH:
+		return "gh auth token --hostname " + g.Host()
+	case CredentialKeyring:
+		return fmt.Sprintf("keyring service=%s host=%s", g.keyringService(), g.Host())
+	case CredentialCommand:
+		return "command " + s.Command
+	}
+	return s.Type
+}
+
+// envVars returns the variables an "env" source reads. A profile never
+// falls back to GITHUB_TOKEN, so a github.com token is not sent to another
+// host: without token_env it reads SHELFCTL_<PROFILE>_TOKEN.
+func (s CredentialSource) envVars(g *GitHubConfig) []string {
+	if s.Env != "" {
+		return []string{s.Env}
+	}
+	if g.profile != "" {
+		if g.TokenEnv != "" {
+			return []string{g.TokenEnv}
+		}
+		return []string{ProfileTokenEnv(g.profile)}
+	}
+	name := g.TokenEnv
+	if name == "" {
+		name = "GITHUB_TOKEN"
+	}
+	return []string{name, "SHELFCTL_GITHUB_TOKEN"}
+}
+
+// ProfileTokenEnv is the default token variable of a profile, e.g.
+// SHELFCTL_WORK_TOKEN for "work".
+func ProfileTokenEnv(profile string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, profile)
+	return "SHELFCTL_" + name + "_TOKEN"
+}
+
+func (s CredentialSource) filePath(g *GitHubConfig) string {
+	if s.Path != "" {
+		return util.ExpandHome(s.Path)
+	}
+	return g.tokenFile()
+}
+
+// StoreToken saves token in a "file" or "keyring" source.
+func (s CredentialSource) StoreToken(g *GitHubConfig, token string) error {
+	switch s.Type {
+	case CredentialFile:
+		path := s.filePath(g)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(token+"\n"), 0600)
+	case CredentialKeyring:
+		label := "shelfctl GitHub token (" + g.Host() + ")"
+		if g.profile != "" {
+			label = "shelfctl GitHub token (" + g.profile + ", " + g.Host() + ")"
+		}
+		_, err := runCredentialCommand([]byte(token), "secret-tool", "store", "--label", label,
+			"service", g.keyringService(), "host", g.Host())
+		return err
+	}
+	return fmt.Errorf("cannot store a token in a %q source", s.Type)
+}
+
+// DeleteToken removes the token stored in a "file" or "keyring" source.
+func (s CredentialSource) DeleteToken(g *GitHubConfig) error {
+	switch s.Type {
+	case CredentialFile:
+		err := os.Remove(s.filePath(g))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	case CredentialKeyring:
+		_, err := runCredentialCommand(nil, "secret-tool", "clear", "service", g.keyringService(), "host", g.Host())
+		return err
+	}
+	return fmt.Errorf("shelfctl does not store tokens in a %q source", s.Type)
+}
+
+// Stores reports whether shelfctl can store tokens in the source.
+func (s CredentialSource) Stores() bool {
+	return s.Type == CredentialFile || s.Type == CredentialKeyring
+}
+
+func shell() string {
+	if runtime.GOOS == "windows" {
+		return "cmd"
+	}
+	return "sh"
+}
+
+func shellFlag() string {
+	if runtime.GOOS == "windows" {
+		return "/C"
+	}
+	return "-c"
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func stubCredentialCommand(t *testing.T, fn func(stdin []byte, name string, args ...string) ([]byte, error)) {
+	t.Helper()
+	orig := runCredentialCommand
+	runCredentialCommand = fn
+	t.Cleanup(func() { runCredentialCommand = orig })
+}
+
+func TestGitHubConfig_Host(t *testing.T) {
+	for base, want := range map[string]string{
+		"":                               "github.com",
+		"https://api.github.com":         "github.com",
+		"https://ghe.example.com/api/v3": "ghe.example.com",
+		"http://localhost:8080/api/v3/":  "localhost:8080",
+	} {
+		g := GitHubConfig{APIBase: base}
+		if got := g.Host(); got != want {
+			t.Errorf("Host(%q) = %q, want %q", base, got, want)
+		}
+	}
+}
+
+func TestResolveToken_DefaultsToEnvironment(t *testing.T) {
+	t.Setenv("MY_TOKEN", "")
+	t.Setenv("SHELFCTL_GITHUB_TOKEN", "fallback")
+	g := GitHubConfig{TokenEnv: "MY_TOKEN"}
+	if token, src := g.ResolveToken(); token != "fallback" || src.Type != CredentialEnv {
+		t.Errorf("ResolveToken = %q, %+v", token, src)
+	}
+	t.Setenv("MY_TOKEN", "primary")
+	if token, _ := g.ResolveToken(); token != "primary" {
+		t.Errorf("ResolveToken = %q", token)
+	}
+}
+
+func TestResolveToken_FollowsChain(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("SHELFCTL_GITHUB_TOKEN", "")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	var calls []string
+	stubCredentialCommand(t, func(stdin []byte, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		switch name {
+		case "gh":
+			return nil, fmt.Errorf("gh: %w", exec.ErrNotFound)
+		case "sh":
+			return []byte("from-command\n"), nil
+		}
+		return nil, errors.New("unexpected")
+	})
+
+	g := GitHubConfig{
+		APIBase: "https://ghe.example.com/api/v3",
+		Credentials: []CredentialSource{
+			{Type: CredentialEnv},
+			{Type: CredentialFile, Path: tokenFile},
+			{Type: CredentialGH},
+			{Type: CredentialCommand, Command: "pass show gh"},
+		},
+	}
+	token, src := g.ResolveToken()
+	if token != "from-command" || src.Type != CredentialCommand {
+		t.Errorf("ResolveToken = %q, %+v", token, src)
+	}
+	want := []string{"gh auth token --hostname ghe.example.com", "sh -c pass show gh"}
+	if strings.Join(calls, "|") != strings.Join(want, "|") {
+		t.Errorf("calls = %q", calls)
+	}
+
+	if err := os.WriteFile(tokenFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if token, src := g.ResolveToken(); token != "from-file" || src.Type != CredentialFile {
+		t.Errorf("ResolveToken = %q, %+v", token, src)
+	}
+}
+
+func TestCredentialSource_StoreAndDelete(t *testing.T) {
+	g := &GitHubConfig{}
+	path := filepath.Join(t.TempDir(), "sub", "token")
+	src := CredentialSource{Type: CredentialFile, Path: path}
+
+	if err := src.StoreToken(g, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("token file mode = %v, %v", info.Mode(), err)
+	}
+	if token, err := src.Token(g); err != nil || token != "secret" {
+		t.Errorf("Token = %q, %v", token, err)
+	}
+	if err := src.DeleteToken(g); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Token(g); err != ErrNoCredential {
+		t.Errorf("after delete: err = %v", err)
+	}
+
+	var stored []byte
+	stubCredentialCommand(t, func(stdin []byte, name string, args ...string) ([]byte, error) {
+		if name != "secret-tool" || args[0] != "store" {
+			t.Errorf("ran %s %v", name, args)
+		}
+		stored = stdin
+		return nil, nil
+	})
+	if err := (CredentialSource{Type: CredentialKeyring}).StoreToken(g, "kr"); err != nil || string(stored) != "kr" {
+		t.Errorf("keyring store: %q, %v", stored, err)
+	}
+	if err := (CredentialSource{Type: CredentialGH}).StoreToken(g, "x"); err == nil {
+		t.Error("expected error storing in gh source")
+	}
+}
+
+func TestSave_NeverWritesToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	t.Setenv("SHELFCTL_CONFIG", path)
+	t.Setenv("GITHUB_TOKEN", "ghp_secret")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GitHub.Token != "ghp_secret" {
+		t.Fatalf("Token = %q", cfg.GitHub.Token)
+	}
+	cfg.GitHub.Credentials = []CredentialSource{{Type: CredentialEnv}, {Type: CredentialGH}}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "ghp_secret") {
+		t.Errorf("config contains the token:\n%s", data)
+	}
+	if !strings.Contains(string(data), "token_env: GITHUB_TOKEN") || !strings.Contains(string(data), "api_base:") {
+		t.Errorf("config keys:\n%s", data)
+	}
+
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.GitHub.Credentials) != 2 || reloaded.GitHub.Credentials[1].Type != CredentialGH {
+		t.Errorf("credentials = %+v", reloaded.GitHub.Credentials)
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/blackwell-systems/shelfctl/internal/encrypt"
+	"github.com/blackwell-systems/shelfctl/internal/util"
+)
+
+// Encrypted reports whether the shelf's assets are encrypted.
+func (s *ShelfConfig) Encrypted() bool {
+	return s.Encryption.KeyFile != "" || s.Encryption.PassphraseEnv != ""
+}
+
+// EncryptionScheme returns the scheme recorded in catalog entries of books
+// uploaded to the shelf, or "" if it is not encrypted.
+func (s *ShelfConfig) EncryptionScheme() string {
+	if s.Encrypted() {
+		return encrypt.Scheme
+	}
+	return ""
+}
+
+// LoadKey loads the encryption key. A key file takes precedence over a
+// passphrase.
+func (e EncryptionConfig) LoadKey() (*encrypt.Key, error) {
+	switch {
+	case e.KeyFile != "":
+		return encrypt.LoadKeyFile(util.ExpandHome(e.KeyFile))
+	case e.PassphraseEnv != "":
+		pass := os.Getenv(e.PassphraseEnv)
+		if pass == "" {
+			return nil, fmt.Errorf("passphrase not set — export %s", e.PassphraseEnv)
+		}
+		return encrypt.NewPassphraseKey(pass)
+	}
+	return nil, fmt.Errorf("no key_file or passphrase_env configured")
+}
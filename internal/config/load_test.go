@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -319,3 +320,165 @@ defaults:
 		t.Errorf("Defaults.Release = %q, want env override %q", cfg.Defaults.Release, "snapshot")
 	}
 }
+
+func TestLoadConfig_ReadOnlyShelf(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	data := `shelves:
+  - name: team
+    owner: colleague
+    repo: shelf-papers
+    read_only: true
+  - name: mine
+    repo: shelf-books
+`
+	if err := os.WriteFile(configPath, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHELFCTL_CONFIG", configPath)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.ShelfByName("team").ReadOnly {
+		t.Error("team shelf should be read-only")
+	}
+	if cfg.ShelfByName("mine").ReadOnly {
+		t.Error("mine shelf should not be read-only")
+	}
+}
+
+func TestLoadConfig_EncryptedShelf(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yml")
+	keyPath := filepath.Join(dir, "papers.key")
+	if err := os.WriteFile(keyPath, []byte(strings.Repeat("0f", 32)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data := `shelves:
+  - name: papers
+    repo: shelf-papers
+    encryption:
+      key_file: ` + keyPath + `
+  - name: diary
+    repo: shelf-diary
+    encryption:
+      passphrase_env: TEST_SHELF_PASSPHRASE
+  - name: mine
+    repo: shelf-books
+`
+	if err := os.WriteFile(configPath, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHELFCTL_CONFIG", configPath)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	papers, diary, mine := cfg.ShelfByName("papers"), cfg.ShelfByName("diary"), cfg.ShelfByName("mine")
+	if !papers.Encrypted() || !diary.Encrypted() || mine.Encrypted() {
+		t.Fatalf("Encrypted() = %v %v %v", papers.Encrypted(), diary.Encrypted(), mine.Encrypted())
+	}
+	if mine.EncryptionScheme() != "" || papers.EncryptionScheme() == "" {
+		t.Error("EncryptionScheme wrong")
+	}
+	if _, err := papers.Encryption.LoadKey(); err != nil {
+		t.Errorf("key file: %v", err)
+	}
+
+	t.Setenv("TEST_SHELF_PASSPHRASE", "")
+	if _, err := diary.Encryption.LoadKey(); err == nil || !strings.Contains(err.Error(), "TEST_SHELF_PASSPHRASE") {
+		t.Errorf("missing passphrase: err = %v", err)
+	}
+	t.Setenv("TEST_SHELF_PASSPHRASE", "hunter2")
+	if _, err := diary.Encryption.LoadKey(); err != nil {
+		t.Errorf("passphrase: %v", err)
+	}
+}
+
+func TestLoadConfig_Profiles(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yml")
+	data := `github:
+  owner: me
+profiles:
+  Work:
+    owner: platform
+    api_base: https://ghe.example.com/api/v3
+  second:
+    token_env: SECOND_TOKEN
+shelves:
+  - name: books
+    repo: shelf-books
+  - name: specs
+    repo: shelf-specs
+    profile: work
+  - name: notes
+    owner: alt
+    repo: shelf-notes
+    profile: second
+`
+	if err := os.WriteFile(configPath, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHELFCTL_CONFIG", configPath)
+	t.Setenv("GITHUB_TOKEN", "public-token")
+	t.Setenv("SHELFCTL_WORK_TOKEN", "work-token")
+	t.Setenv("SECOND_TOKEN", "second-token")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	books, specs, notes := cfg.ShelfByName("books"), cfg.ShelfByName("specs"), cfg.ShelfByName("notes")
+	if got := cfg.GitHubFor(books).Token; got != "public-token" {
+		t.Errorf("books token = %q", got)
+	}
+	work := cfg.GitHubFor(specs)
+	if work.Token != "work-token" || work.Host() != "ghe.example.com" {
+		t.Errorf("work profile: token %q, host %q", work.Token, work.Host())
+	}
+	if got := cfg.GitHubFor(notes).Token; got != "second-token" {
+		t.Errorf("notes token = %q", got)
+	}
+	if got := specs.EffectiveOwner(cfg.GitHub.Owner); got != "platform" {
+		t.Errorf("specs owner = %q, want the profile's", got)
+	}
+	if got := notes.EffectiveOwner(cfg.GitHub.Owner); got != "alt" {
+		t.Errorf("notes owner = %q", got)
+	}
+
+	// A profile never falls back to the github.com token.
+	t.Setenv("SHELFCTL_WORK_TOKEN", "")
+	if token, _ := work.ResolveToken(); token != "" {
+		t.Errorf("work token without its variable = %q", token)
+	}
+
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := os.ReadFile(configPath)
+	if strings.Contains(string(saved), "work-token") {
+		t.Errorf("token written:\n%s", saved)
+	}
+	if !strings.Contains(string(saved), "profile: work") {
+		t.Errorf("shelf profile not saved:\n%s", saved)
+	}
+}
+
+func TestLoadConfig_UnknownProfile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	data := `shelves:
+  - name: specs
+    repo: shelf-specs
+    profile: work
+`
+	if err := os.WriteFile(configPath, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHELFCTL_CONFIG", configPath)
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), `unknown profile "work"`) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultManifestPath is where a library repo keeps its manifest.
+const DefaultManifestPath = "library.yml"
+
+// Manifest is a shared list of shelves, kept in a "library" repo so a team
+// can subscribe to all of them at once:
+//
+//	name: Team library
+//	shelves:
+//	  - name: papers
+//	    owner: team
+//	    repo: shelf-papers
+//	    catalog_path: catalog.yml
+//	    default_release: library
+type Manifest struct {
+	Name    string        `yaml:"name,omitempty"`
+	Shelves []ShelfConfig `yaml:"shelves"`
+}
+
+// ParseManifest parses and validates a library manifest. Shelves without an
+// owner belong to defaultOwner, the owner of the manifest repo.
+//
+// Only where a shelf lives is shared: settings that are the subscriber's
+// own, such as profile, encryption keys and release policy, are dropped
+// so a manifest cannot set them on every subscriber.
+func ParseManifest(data []byte, defaultOwner string) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	seen := map[string]bool{}
+	for i := range m.Shelves {
+		s := &m.Shelves[i]
+		if s.Name == "" || s.Repo == "" {
+			return nil, fmt.Errorf("manifest shelf %d: name and repo are required", i+1)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("manifest lists shelf %q twice", s.Name)
+		}
+		seen[s.Name] = true
+		if s.Owner == "" {
+			s.Owner = defaultOwner
+		}
+		*s = manifestFields(*s)
+		s.Subscription = ""
+	}
+	return &m, nil
+}
+
+// manifestFields returns the fields of a shelf that its manifest manages,
+// leaving out the subscriber's own settings.
+func manifestFields(s ShelfConfig) ShelfConfig {
+	return ShelfConfig{
+		Name:           s.Name,
+		Owner:          s.Owner,
+		Repo:           s.Repo,
+		CatalogPath:    s.CatalogPath,
+		DefaultRelease: s.DefaultRelease,
+		ReadOnly:       s.ReadOnly,
+		Subscription:   s.Subscription,
+	}
+}
+
+// SubscriptionChanges summarizes what applying a manifest did, by shelf name.
+type SubscriptionChanges struct {
+	Added   []string
+	Updated []string
+	Removed []string
+	// Skipped shelves clash with a shelf of the same name that the
+	// subscription does not manage.
+	Skipped []string
+}
+
+// Empty reports whether applying the manifest changed nothing.
+func (c SubscriptionChanges) Empty() bool {
+	return len(c.Added)+len(c.Updated)+len(c.Removed) == 0
+}
+
+// ApplyManifest merges the manifest of sub into the config: new shelves are
+// added, shelves it manages are updated, and managed shelves no longer
+// listed are removed. Shelves added by hand are never touched. The
+// subscription itself is recorded in Subscriptions.
+func (c *Config) ApplyManifest(sub Subscription, m *Manifest) SubscriptionChanges {
+	var ch SubscriptionChanges
+	listed := map[string]bool{}
+	for _, s := range m.Shelves {
+		s.Subscription = sub.Repo
+		s.ReadOnly = s.ReadOnly || sub.ReadOnly
+		listed[s.Name] = true
+
+		existing := c.ShelfByName(s.Name)
+		switch {
+		case existing == nil:
+			c.Shelves = append(c.Shelves, s)
+			ch.Added = append(ch.Added, s.Name)
+		case !strings.EqualFold(existing.Subscription, sub.Repo):
+			ch.Skipped = append(ch.Skipped, s.Name)
+		case manifestFields(*existing) != manifestFields(s):
+			// Settings the subscriber added to the shelf stay.
+			existing.Owner = s.Owner
+			existing.Repo = s.Repo
+			existing.CatalogPath = s.CatalogPath
+			existing.DefaultRelease = s.DefaultRelease
+			existing.ReadOnly = s.ReadOnly
+			ch.Updated = append(ch.Updated, s.Name)
+		}
+	}
+
+	kept := c.Shelves[:0]
+	for _, s := range c.Shelves {
+		if strings.EqualFold(s.Subscription, sub.Repo) && !listed[s.Name] {
+			ch.Removed = append(ch.Removed, s.Name)
+			continue
+		}
+		kept = append(kept, s)
+	}
+	c.Shelves = kept
+
+	if existing := c.SubscriptionByRepo(sub.Repo); existing != nil {
+		*existing = sub
+	} else {
+		c.Subscriptions = append(c.Subscriptions, sub)
+	}
+	return ch
+}
+
+// SubscriptionByRepo returns the subscription to the given manifest repo,
+// or nil.
+func (c *Config) SubscriptionByRepo(repo string) *Subscription {
+	for i := range c.Subscriptions {
+		if strings.EqualFold(c.Subscriptions[i].Repo, repo) {
+			return &c.Subscriptions[i]
+		}
+	}
+	return nil
+}
+
+// Unsubscribe drops a subscription and the shelves it manages, returning
+// the names of the removed shelves. It reports false if there was no such
+// subscription.
+func (c *Config) Unsubscribe(repo string) ([]string, bool) {
+	if c.SubscriptionByRepo(repo) == nil {
+		return nil, false
+	}
+	var removed []string
+	kept := c.Shelves[:0]
+	for _, s := range c.Shelves {
+		if strings.EqualFold(s.Subscription, repo) {
+			removed = append(removed, s.Name)
+			continue
+		}
+		kept = append(kept, s)
+	}
+	c.Shelves = kept
+
+	subs := c.Subscriptions[:0]
+	for _, s := range c.Subscriptions {
+		if !strings.EqualFold(s.Repo, repo) {
+			subs = append(subs, s)
+		}
+	}
+	c.Subscriptions = subs
+	return removed, true
+}
+
+// EffectivePath returns the manifest path within the subscribed repo.
+func (s *Subscription) EffectivePath() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	return DefaultManifestPath
+}
@@ -0,0 +1,150 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+)
+
+func TestParseManifest(t *testing.T) {
+	m, err := config.ParseManifest([]byte(`name: Team
+shelves:
+  - name: papers
+    repo: shelf-papers
+    catalog_path: meta/catalog.yml
+  - name: books
+    owner: other
+    repo: shelf-books
+    default_release: v1
+`), "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []config.ShelfConfig{
+		{Name: "papers", Owner: "team", Repo: "shelf-papers", CatalogPath: "meta/catalog.yml"},
+		{Name: "books", Owner: "other", Repo: "shelf-books", DefaultRelease: "v1"},
+	}
+	if !reflect.DeepEqual(m.Shelves, want) {
+		t.Errorf("shelves = %+v", m.Shelves)
+	}
+
+	// Subscriber-local settings in a manifest are ignored.
+	m, err = config.ParseManifest([]byte(`shelves:
+  - name: papers
+    repo: shelf-papers
+    subscription: evil/library
+    profile: work
+    encryption:
+      key_file: ~/.ssh/id_ed25519
+      passphrase_env: AWS_SECRET_ACCESS_KEY
+    releases:
+      by: year
+`), "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []config.ShelfConfig{{Name: "papers", Owner: "team", Repo: "shelf-papers"}}; !reflect.DeepEqual(m.Shelves, want) {
+		t.Errorf("manifest set local settings: %+v", m.Shelves)
+	}
+
+	if _, err := config.ParseManifest([]byte("shelves:\n  - name: a\n"), "team"); err == nil {
+		t.Error("expected error for shelf without repo")
+	}
+	if _, err := config.ParseManifest([]byte("shelves:\n  - {name: a, repo: x}\n  - {name: a, repo: y}\n"), "team"); err == nil {
+		t.Error("expected error for duplicate shelf names")
+	}
+}
+
+func TestApplyManifest(t *testing.T) {
+	cfg := &config.Config{Shelves: []config.ShelfConfig{{Name: "mine", Repo: "shelf-mine"}, {Name: "books", Repo: "my-books"}}}
+	sub := config.Subscription{Repo: "team/library", ReadOnly: true}
+
+	m := &config.Manifest{Shelves: []config.ShelfConfig{
+		{Name: "papers", Owner: "team", Repo: "shelf-papers"},
+		{Name: "talks", Owner: "team", Repo: "shelf-talks"},
+		{Name: "books", Owner: "team", Repo: "shelf-books"},
+	}}
+	ch := cfg.ApplyManifest(sub, m)
+	if !reflect.DeepEqual(ch.Added, []string{"papers", "talks"}) || !reflect.DeepEqual(ch.Skipped, []string{"books"}) {
+		t.Errorf("first apply = %+v", ch)
+	}
+	if p := cfg.ShelfByName("papers"); p.Subscription != "team/library" || !p.ReadOnly {
+		t.Errorf("papers = %+v", p)
+	}
+	if cfg.ShelfByName("books").Repo != "my-books" {
+		t.Error("local shelf was overwritten")
+	}
+
+	// Manifest changes: talks dropped, papers moved.
+	m.Shelves = []config.ShelfConfig{{Name: "papers", Owner: "team", Repo: "shelf-papers-2"}}
+	ch = cfg.ApplyManifest(sub, m)
+	if !reflect.DeepEqual(ch.Updated, []string{"papers"}) || !reflect.DeepEqual(ch.Removed, []string{"talks"}) {
+		t.Errorf("second apply = %+v", ch)
+	}
+	if cfg.ShelfByName("papers").Repo != "shelf-papers-2" || cfg.ShelfByName("talks") != nil {
+		t.Errorf("shelves = %+v", cfg.Shelves)
+	}
+
+	if ch := cfg.ApplyManifest(sub, m); !ch.Empty() {
+		t.Errorf("unchanged apply = %+v", ch)
+	}
+
+	// Local settings on a subscribed shelf are not changes, and survive
+	// updates.
+	p := cfg.ShelfByName("papers")
+	p.Profile = "work"
+	p.Releases = &config.ReleasePolicy{By: config.ReleaseByYear}
+	if ch := cfg.ApplyManifest(sub, m); !ch.Empty() {
+		t.Errorf("apply after local changes = %+v", ch)
+	}
+	m.Shelves[0].DefaultRelease = "v2"
+	if ch := cfg.ApplyManifest(sub, m); !reflect.DeepEqual(ch.Updated, []string{"papers"}) {
+		t.Errorf("third apply = %+v", ch)
+	}
+	if p := cfg.ShelfByName("papers"); p.DefaultRelease != "v2" || p.Profile != "work" || p.Releases == nil {
+		t.Errorf("papers after update = %+v", p)
+	}
+	if len(cfg.Subscriptions) != 1 {
+		t.Errorf("subscriptions = %+v", cfg.Subscriptions)
+	}
+
+	removed, ok := cfg.Unsubscribe("team/library")
+	if !ok || !reflect.DeepEqual(removed, []string{"papers"}) {
+		t.Errorf("Unsubscribe = %v, %v", removed, ok)
+	}
+	if len(cfg.Shelves) != 2 || len(cfg.Subscriptions) != 0 {
+		t.Errorf("after unsubscribe: %+v %+v", cfg.Shelves, cfg.Subscriptions)
+	}
+}
+
+func TestSave_RoundTripsShelfFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	t.Setenv("SHELFCTL_CONFIG", path)
+
+	in := &config.Config{
+		Shelves: []config.ShelfConfig{{
+			Name: "papers", Owner: "team", Repo: "shelf-papers", CatalogPath: "meta/catalog.yml",
+			DefaultRelease: "v1", ReadOnly: true, Subscription: "team/library",
+		}},
+		Subscriptions: []config.Subscription{{Repo: "team/library", SHA: "abc"}},
+	}
+	if err := config.Save(in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Save did not write $SHELFCTL_CONFIG: %v", err)
+	}
+	out, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.Shelves, in.Shelves) {
+		t.Errorf("shelves = %+v", out.Shelves)
+	}
+	if !reflect.DeepEqual(out.Subscriptions, in.Subscriptions) {
+		t.Errorf("subscriptions = %+v", out.Subscriptions)
+	}
+}
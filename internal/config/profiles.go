@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile returns the GitHub settings named by a shelf's profile field:
+// the profile, or github for "". Names are case-insensitive.
+func (c *Config) Profile(name string) (*GitHubConfig, error) {
+	if name == "" {
+		return &c.GitHub, nil
+	}
+	if p := c.Profiles[strings.ToLower(name)]; p != nil {
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown profile %q (profiles: %s)", name, strings.Join(c.ProfileNames(), ", "))
+}
+
+// GitHubFor returns the GitHub settings a shelf uses. A shelf naming an
+// unknown profile gets github; Load rejects such configs.
+func (c *Config) GitHubFor(s *ShelfConfig) *GitHubConfig {
+	if p, err := c.Profile(s.Profile); err == nil {
+		return p
+	}
+	return &c.GitHub
+}
+
+// ProfileNames returns the profile names, sorted.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasToken reports whether github or any profile has a token.
+func (c *Config) HasToken() bool {
+	if c.GitHub.Token != "" {
+		return true
+	}
+	for _, p := range c.Profiles {
+		if p.Token != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveProfiles resolves the token of every profile and links shelves to
+// their profile.
+func (c *Config) resolveProfiles() error {
+	for name, p := range c.Profiles {
+		if p == nil {
+			p = &GitHubConfig{}
+			c.Profiles[name] = p
+		}
+		p.profile = name
+		p.Token, p.TokenSource = p.ResolveToken()
+	}
+	for i := range c.Shelves {
+		s := &c.Shelves[i]
+		if s.Profile == "" {
+			continue
+		}
+		p, err := c.Profile(s.Profile)
+		if err != nil {
+			return fmt.Errorf("shelf %q: %w", s.Name, err)
+		}
+		s.profileOwner = p.Owner
+	}
+	return nil
+}
@@ -1,36 +1,168 @@
 package config
 
+import (
+	"fmt"
+
+	"github.com/blackwell-systems/shelfctl/internal/util"
+)
+
 // Config is the top-level shelfctl configuration.
 type Config struct {
-	GitHub    GitHubConfig    `mapstructure:"github"`
-	Defaults  DefaultsConfig  `mapstructure:"defaults"`
-	Shelves   []ShelfConfig   `mapstructure:"shelves"`
-	Migration MigrationConfig `mapstructure:"migration"`
+	GitHub GitHubConfig `mapstructure:"github" yaml:"github"`
+	// Profiles are other accounts or hosts, such as a GitHub Enterprise
+	// Server, that shelves can use instead of GitHub. Names are lowercase.
+	Profiles  map[string]*GitHubConfig `mapstructure:"profiles" yaml:"profiles,omitempty"`
+	Defaults  DefaultsConfig           `mapstructure:"defaults" yaml:"defaults"`
+	Shelves   []ShelfConfig            `mapstructure:"shelves" yaml:"shelves"`
+	Migration MigrationConfig          `mapstructure:"migration" yaml:"migration,omitempty"`
+	Enrich    EnrichConfig             `mapstructure:"enrich" yaml:"enrich,omitempty"`
+	// Subscriptions are library manifests whose shelves are merged into
+	// Shelves by `shelfctl subscribe`.
+	Subscriptions []Subscription `mapstructure:"subscriptions" yaml:"subscriptions,omitempty"`
 }
 
 // GitHubConfig holds GitHub API connection settings.
 type GitHubConfig struct {
-	Owner    string `mapstructure:"owner"`
-	TokenEnv string `mapstructure:"token_env"`
-	APIBase  string `mapstructure:"api_base"`
-	Backend  string `mapstructure:"backend"`
-	Token    string `mapstructure:"-"` // resolved at runtime, never written
+	Owner    string `mapstructure:"owner" yaml:"owner,omitempty"`
+	TokenEnv string `mapstructure:"token_env" yaml:"token_env,omitempty"`
+	APIBase  string `mapstructure:"api_base" yaml:"api_base,omitempty"`
+	Backend  string `mapstructure:"backend" yaml:"backend,omitempty"`
+	// Credentials is where to look for the token, in order; see
+	// CredentialChain.
+	Credentials []CredentialSource `mapstructure:"credentials" yaml:"credentials,omitempty"`
+	// Token is resolved at runtime and never written.
+	Token string `mapstructure:"-" yaml:"-"`
+	// TokenSource is the source Token came from, or nil.
+	TokenSource *CredentialSource `mapstructure:"-" yaml:"-"`
+
+	// profile is the name of the profile, or "" for github; set by Load.
+	profile string
 }
 
 // DefaultsConfig holds default values for operations.
 type DefaultsConfig struct {
-	Release     string `mapstructure:"release"`
-	CacheDir    string `mapstructure:"cache_dir"`
-	AssetNaming string `mapstructure:"asset_naming"` // "id" or "original"
+	Release  string `mapstructure:"release" yaml:"release,omitempty"`
+	CacheDir string `mapstructure:"cache_dir" yaml:"cache_dir,omitempty"`
+	// CacheMaxSize caps the cache, such as "20G"; the least recently opened
+	// books are evicted past it. Empty means no limit.
+	CacheMaxSize string `mapstructure:"cache_max_size" yaml:"cache_max_size,omitempty"`
+	AssetNaming  string `mapstructure:"asset_naming" yaml:"asset_naming,omitempty"` // "id" or "original"
+	// JournalDir holds the undo journal: catalog states and copies of
+	// deleted assets recorded by mutating commands.
+	JournalDir string `mapstructure:"journal_dir" yaml:"journal_dir,omitempty"`
+	// PartSize is the size in bytes above which files are uploaded as
+	// several part assets; 0 means DefaultPartSize.
+	PartSize int64 `mapstructure:"part_size" yaml:"part_size,omitempty"`
+	// Releases is the release policy of shelves that do not set their own.
+	Releases ReleasePolicy `mapstructure:"releases" yaml:"releases,omitempty"`
+}
+
+// CacheQuota returns CacheMaxSize in bytes, or 0 for no limit.
+func (d *DefaultsConfig) CacheQuota() (int64, error) {
+	if d.CacheMaxSize == "" {
+		return 0, nil
+	}
+	return util.ParseSize(d.CacheMaxSize)
+}
+
+// ReleasePolicy decides which release of a shelf new assets go into. With
+// the zero policy every asset goes into the shelf's default release.
+type ReleasePolicy struct {
+	// By groups books into one release per publication year ("year") or
+	// per first tag ("tag"), named after the default release, such as
+	// library-2024. Books without a year or tag stay in the default release.
+	By string `mapstructure:"by" yaml:"by,omitempty"`
+	// MaxAssets and MaxBytes cap a release; once a book would not fit, it
+	// rolls over into the next release, library.2, library.3 and so on.
+	// 0 means no limit.
+	MaxAssets int   `mapstructure:"max_assets" yaml:"max_assets,omitempty"`
+	MaxBytes  int64 `mapstructure:"max_bytes" yaml:"max_bytes,omitempty"`
+}
+
+// Release policy groupings.
+const (
+	ReleaseByYear = "year"
+	ReleaseByTag  = "tag"
+)
+
+// IsZero reports whether p is the zero policy (the yaml encoder uses it
+// for omitempty).
+func (p ReleasePolicy) IsZero() bool {
+	return p == ReleasePolicy{}
+}
+
+// Validate checks p's grouping and limits.
+func (p ReleasePolicy) Validate() error {
+	switch p.By {
+	case "", ReleaseByYear, ReleaseByTag:
+	default:
+		return fmt.Errorf("unknown release grouping %q (use %q or %q)", p.By, ReleaseByYear, ReleaseByTag)
+	}
+	if p.MaxAssets < 0 || p.MaxBytes < 0 {
+		return fmt.Errorf("release limits must not be negative")
+	}
+	return nil
+}
+
+// DefaultPartSize stays below GitHub's 2 GiB release asset limit, with room
+// for the encryption overhead.
+const DefaultPartSize int64 = 1900 << 20
+
+// EffectivePartSize returns the configured part size or DefaultPartSize.
+func (d *DefaultsConfig) EffectivePartSize() int64 {
+	if d.PartSize > 0 {
+		return d.PartSize
+	}
+	return DefaultPartSize
+}
+
+// EnrichConfig holds settings for metadata enrichment lookups.
+// Any Open Library-compatible service can be used.
+type EnrichConfig struct {
+	APIBase    string `mapstructure:"api_base" yaml:"api_base,omitempty"`       // search endpoint host
+	CoversBase string `mapstructure:"covers_base" yaml:"covers_base,omitempty"` // cover image host
 }
 
 // ShelfConfig defines a single shelf (topic-based document collection).
 type ShelfConfig struct {
-	Name           string `mapstructure:"name"`
-	Owner          string `mapstructure:"owner"`
-	Repo           string `mapstructure:"repo"`
-	CatalogPath    string `mapstructure:"catalog_path"`
-	DefaultRelease string `mapstructure:"default_release"`
+	Name           string `mapstructure:"name" yaml:"name"`
+	Owner          string `mapstructure:"owner" yaml:"owner,omitempty"`
+	Repo           string `mapstructure:"repo" yaml:"repo"`
+	CatalogPath    string `mapstructure:"catalog_path" yaml:"catalog_path,omitempty"`
+	DefaultRelease string `mapstructure:"default_release" yaml:"default_release,omitempty"`
+	// ReadOnly marks a shelf you follow but do not write to, such as a
+	// colleague's public shelf used without a token.
+	ReadOnly bool `mapstructure:"read_only" yaml:"read_only,omitempty"`
+	// Subscription is the manifest repo ("owner/repo") that manages this
+	// shelf; empty for shelves added by hand.
+	Subscription string `mapstructure:"subscription" yaml:"subscription,omitempty"`
+	// Encryption turns on client-side encryption of the shelf's assets.
+	Encryption EncryptionConfig `mapstructure:"encryption" yaml:"encryption,omitempty"`
+	// Profile names the entry of profiles whose account and host the shelf
+	// lives on; empty for github.
+	Profile string `mapstructure:"profile" yaml:"profile,omitempty"`
+	// Releases overrides defaults.releases for this shelf.
+	Releases *ReleasePolicy `mapstructure:"releases" yaml:"releases,omitempty"`
+
+	// profileOwner is the owner of the shelf's profile, set by Load.
+	profileOwner string
+}
+
+// EncryptionConfig says where a shelf's encryption key comes from: a key
+// file, or a passphrase read from an environment variable. Neither the key
+// nor the passphrase is ever stored in the config or the shelf repo.
+type EncryptionConfig struct {
+	KeyFile       string `mapstructure:"key_file" yaml:"key_file,omitempty"`
+	PassphraseEnv string `mapstructure:"passphrase_env" yaml:"passphrase_env,omitempty"`
+}
+
+// Subscription is a library manifest the config follows.
+type Subscription struct {
+	Repo     string `mapstructure:"repo" yaml:"repo"` // owner/repo
+	Path     string `mapstructure:"path" yaml:"path,omitempty"`
+	ReadOnly bool   `mapstructure:"read_only" yaml:"read_only,omitempty"`
+	// SHA is the blob SHA of the manifest last applied.
+	SHA string `mapstructure:"sha" yaml:"sha,omitempty"`
 }
 
 // MigrationConfig holds settings for migrating files from other repos.
@@ -56,11 +188,15 @@ func (c *Config) ShelfByName(name string) *ShelfConfig {
 	return nil
 }
 
-// EffectiveOwner returns the shelf's owner or falls back to the global owner.
+// EffectiveOwner returns the shelf's owner or falls back to its profile's
+// owner, then the global owner.
 func (s *ShelfConfig) EffectiveOwner(globalOwner string) string {
 	if s.Owner != "" {
 		return s.Owner
 	}
+	if s.profileOwner != "" {
+		return s.profileOwner
+	}
 	return globalOwner
 }
 
@@ -75,6 +211,15 @@ func (s *ShelfConfig) EffectiveRelease(globalDefault string) string {
 	return "library"
 }
 
+// EffectiveReleasePolicy returns the shelf's release policy or falls back
+// to the global default.
+func (s *ShelfConfig) EffectiveReleasePolicy(globalDefault ReleasePolicy) ReleasePolicy {
+	if s.Releases != nil {
+		return *s.Releases
+	}
+	return globalDefault
+}
+
 // EffectiveCatalogPath returns the catalog path for this shelf.
 func (s *ShelfConfig) EffectiveCatalogPath() string {
 	if s.CatalogPath != "" {
@@ -112,3 +112,29 @@ func TestShelfByName_ReturnsPointer(t *testing.T) {
 		t.Error("ShelfByName should return a pointer to the original slice element")
 	}
 }
+
+func TestEffectiveReleasePolicy(t *testing.T) {
+	def := config.ReleasePolicy{MaxAssets: 500}
+	s := config.ShelfConfig{}
+	if got := s.EffectiveReleasePolicy(def); got != def {
+		t.Errorf("EffectiveReleasePolicy = %+v, want %+v", got, def)
+	}
+	own := config.ReleasePolicy{By: config.ReleaseByYear}
+	s.Releases = &own
+	if got := s.EffectiveReleasePolicy(def); got != own {
+		t.Errorf("EffectiveReleasePolicy = %+v, want %+v", got, own)
+	}
+}
+
+func TestReleasePolicyValidate(t *testing.T) {
+	for _, p := range []config.ReleasePolicy{{}, {By: "year"}, {By: "tag", MaxBytes: 1 << 30}} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", p, err)
+		}
+	}
+	for _, p := range []config.ReleasePolicy{{By: "month"}, {MaxAssets: -1}} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", p)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Package encrypt implements the client-side encryption of release assets.
+//
+// Assets are encrypted with AES-256-GCM in 64 KiB chunks (the STREAM
+// construction), so files of any size are encrypted and decrypted while
+// they stream, and truncated, reordered or modified data is detected.
+//
+// An encrypted asset starts with a header:
+//
+//	magic (8) | version (1) | KDF salt (16) | file nonce (16)
+//
+// The master key comes from a key file or from a passphrase via PBKDF2
+// with the KDF salt. Each file is encrypted with its own key, derived from
+// the master key and the file nonce with HKDF. Chunk nonces are a counter
+// plus a flag marking the last chunk, which is always shorter than a full
+// chunk (possibly empty).
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Scheme names the format; it is recorded in catalog entries of
+// encrypted books.
+const Scheme = "aes-256-gcm-stream-v1"
+
+const (
+	version    = 1
+	saltSize   = 16
+	nonceSize  = 16
+	keySize    = 32
+	chunkSize  = 64 * 1024
+	tagSize    = 16
+	headerSize = len(magic) + 1 + saltSize + nonceSize
+
+	// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-SHA256.
+	pbkdf2Iterations = 600_000
+	hkdfInfo         = "shelfctl asset v1"
+)
+
+// magic starts every encrypted asset.
+const magic = "\x89SHLFENC"
+
+// MagicSize is how many leading bytes IsEncrypted needs.
+const MagicSize = len(magic)
+
+// ErrDecrypt is returned when encrypted data fails authentication: the
+// key is wrong, or the data was truncated or modified.
+var ErrDecrypt = errors.New("decryption failed — wrong key or damaged data")
+
+// Key is the secret for a shelf: a 32-byte key or a passphrase.
+// It is safe for concurrent use.
+type Key struct {
+	raw        []byte
+	passphrase string
+
+	mu      sync.Mutex
+	derived map[[saltSize]byte][]byte // passphrase keys by KDF salt
+	salt    *[saltSize]byte           // KDF salt for files this Key encrypts
+}
+
+// NewKey returns a Key for 32 bytes of key material.
+func NewKey(raw []byte) (*Key, error) {
+	if len(raw) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(raw))
+	}
+	return &Key{raw: append([]byte(nil), raw...)}, nil
+}
+
+// NewPassphraseKey returns a Key derived from a passphrase.
+func NewPassphraseKey(passphrase string) (*Key, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is empty")
+	}
+	return &Key{passphrase: passphrase, derived: map[[saltSize]byte][]byte{}}, nil
+}
+
+// LoadKeyFile reads a key file holding 32 bytes, either raw or hex or
+// base64 encoded (e.g. made with 'openssl rand -hex 32').
+func LoadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+	if len(data) == keySize {
+		return NewKey(data)
+	}
+	text := strings.TrimSpace(string(data))
+	if raw, err := hex.DecodeString(text); err == nil && len(raw) == keySize {
+		return NewKey(raw)
+	}
+	if raw, err := base64.StdEncoding.DecodeString(text); err == nil && len(raw) == keySize {
+		return NewKey(raw)
+	}
+	return nil, fmt.Errorf("key file %s must hold %d bytes (raw, hex or base64)", path, keySize)
+}
+
+// master returns the master key for files with the given KDF salt.
+func (k *Key) master(salt [saltSize]byte) ([]byte, error) {
+	if k.raw != nil {
+		return k.raw, nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if m, ok := k.derived[salt]; ok {
+		return m, nil
+	}
+	m, err := pbkdf2.Key(sha256.New, k.passphrase, salt[:], pbkdf2Iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	k.derived[salt] = m
+	return m, nil
+}
+
+// encryptSalt returns the KDF salt for new files. It is chosen once per
+// Key so a passphrase is stretched once, not once per file.
+func (k *Key) encryptSalt() ([saltSize]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.salt == nil {
+		var s [saltSize]byte
+		if _, err := rand.Read(s[:]); err != nil {
+			return s, err
+		}
+		k.salt = &s
+	}
+	return *k.salt, nil
+}
+
+func (k *Key) fileAEAD(salt [saltSize]byte, nonce []byte) (cipher.AEAD, error) {
+	m, err := k.master(salt)
+	if err != nil {
+		return nil, err
+	}
+	fk, err := hkdf.Key(sha256.New, m, nonce, hkdfInfo, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(fk)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptedSize returns the size of the encrypted form of size bytes.
+func EncryptedSize(size int64) int64 {
+	chunks := size/chunkSize + 1
+	return int64(headerSize) + size + chunks*tagSize
+}
+
+// IsEncrypted reports whether data starts like an encrypted asset.
+func IsEncrypted(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, []byte(magic))
+}
+
+// chunkNonce builds the GCM nonce for chunk i.
+func chunkNonce(i uint64, last bool) []byte {
+	n := make([]byte, 12)
+	for j := 0; j < 8; j++ {
+		n[3+j] = byte(i >> (56 - 8*j))
+	}
+	if last {
+		n[11] = 1
+	}
+	return n
+}
+
+// NewEncryptReader returns a reader yielding the encrypted form of r.
+func NewEncryptReader(k *Key, r io.Reader) (io.Reader, error) {
+	salt, err := k.encryptSalt()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := k.fileAEAD(salt, nonce)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = append(header, version)
+	header = append(header, salt[:]...)
+	header = append(header, nonce...)
+	return &encryptReader{
+		src:   r,
+		aead:  aead,
+		plain: make([]byte, chunkSize),
+		out:   header,
+	}, nil
+}
+
+type encryptReader struct {
+	src   io.Reader
+	aead  cipher.AEAD
+	plain []byte
+	out   []byte // pending ciphertext
+	n     uint64 // next chunk number
+	done  bool
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(e.src, e.plain)
+		last := false
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			last = true
+		default:
+			return 0, err
+		}
+		e.out = e.aead.Seal(e.out[:0], chunkNonce(e.n, last), e.plain[:n], nil)
+		e.n++
+		e.done = last
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+// NewDecryptReader returns a reader yielding the plaintext of the
+// encrypted data in r. It reads the header immediately; authentication
+// failures surface from Read as ErrDecrypt.
+func NewDecryptReader(k *Key, r io.Reader) (io.Reader, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("reading encryption header: %w", err)
+	}
+	if !IsEncrypted(header) {
+		return nil, errors.New("not an encrypted asset")
+	}
+	if v := header[len(magic)]; v != version {
+		return nil, fmt.Errorf("unsupported encryption version %d", v)
+	}
+	var salt [saltSize]byte
+	copy(salt[:], header[len(magic)+1:])
+	nonce := header[len(magic)+1+saltSize:]
+	aead, err := k.fileAEAD(salt, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{src: r, aead: aead, buf: make([]byte, chunkSize+tagSize)}, nil
+}
+
+type decryptReader struct {
+	src  io.Reader
+	aead cipher.AEAD
+	buf  []byte
+	out  []byte // pending plaintext
+	n    uint64
+	done bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(d.src, d.buf)
+		last := false
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			last = true
+		default:
+			return 0, err
+		}
+		if last && n < tagSize {
+			return 0, ErrDecrypt
+		}
+		plain, err := d.aead.Open(d.buf[:0], chunkNonce(d.n, last), d.buf[:n], nil)
+		if err != nil {
+			return 0, ErrDecrypt
+		}
+		d.out = plain
+		d.n++
+		d.done = last
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
@@ -0,0 +1,144 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(t *testing.T) *Key {
+	t.Helper()
+	k, err := NewKey(bytes.Repeat([]byte{7}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func encrypt(t *testing.T, k *Key, plain []byte) []byte {
+	t.Helper()
+	r, err := NewEncryptReader(k, bytes.NewReader(plain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func decrypt(k *Key, data []byte) ([]byte, error) {
+	r, err := NewDecryptReader(k, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	k := testKey(t)
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 17} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+
+		ct := encrypt(t, k, plain)
+		if int64(len(ct)) != EncryptedSize(int64(size)) {
+			t.Errorf("size %d: ciphertext is %d bytes, EncryptedSize says %d", size, len(ct), EncryptedSize(int64(size)))
+		}
+		if !IsEncrypted(ct) {
+			t.Errorf("size %d: missing magic", size)
+		}
+		got, err := decrypt(k, ct)
+		if err != nil {
+			t.Fatalf("size %d: decrypt: %v", size, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("size %d: round trip mismatch", size)
+		}
+	}
+}
+
+func TestDecrypt_DetectsTampering(t *testing.T) {
+	k := testKey(t)
+	plain := bytes.Repeat([]byte("shelf"), chunkSize/2)
+	ct := encrypt(t, k, plain)
+
+	flipped := append([]byte(nil), ct...)
+	flipped[headerSize+10] ^= 1
+	if _, err := decrypt(k, flipped); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("modified chunk: err = %v", err)
+	}
+
+	// Cut off after the first full chunk: looks complete but lacks the
+	// final chunk.
+	if _, err := decrypt(k, ct[:headerSize+chunkSize+tagSize]); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("truncated: err = %v", err)
+	}
+
+	other, _ := NewKey(bytes.Repeat([]byte{8}, keySize))
+	if _, err := decrypt(other, ct); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong key: err = %v", err)
+	}
+}
+
+func TestPassphraseKey(t *testing.T) {
+	enc, err := NewPassphraseKey("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct1 := encrypt(t, enc, []byte("one"))
+	ct2 := encrypt(t, enc, []byte("two"))
+	// One KDF salt per key, but a fresh file nonce per file.
+	if !bytes.Equal(ct1[:headerSize-nonceSize], ct2[:headerSize-nonceSize]) {
+		t.Error("files encrypted with one Key should share the KDF salt")
+	}
+	if bytes.Equal(ct1[headerSize-nonceSize:headerSize], ct2[headerSize-nonceSize:headerSize]) {
+		t.Error("file nonces should differ")
+	}
+
+	// A fresh Key with the same passphrase decrypts.
+	dec, _ := NewPassphraseKey("correct horse")
+	if got, err := decrypt(dec, ct2); err != nil || string(got) != "two" {
+		t.Errorf("decrypt = %q, %v", got, err)
+	}
+	wrong, _ := NewPassphraseKey("battery staple")
+	if _, err := decrypt(wrong, ct1); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong passphrase: err = %v", err)
+	}
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	raw := bytes.Repeat([]byte{0xab}, keySize)
+	files := map[string]string{
+		"hex":    strings.Repeat("ab", keySize) + "\n",
+		"base64": "q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s=",
+		"raw":    string(raw),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		k, err := LoadKeyFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(k.raw, raw) {
+			t.Errorf("%s: wrong key", name)
+		}
+	}
+
+	short := filepath.Join(dir, "short")
+	_ = os.WriteFile(short, []byte("abcd"), 0600)
+	if _, err := LoadKeyFile(short); err == nil {
+		t.Error("expected error for a short key file")
+	}
+}
@@ -0,0 +1,177 @@
+package enrich
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIBase is the public Open Library host.
+const DefaultAPIBase = "https://openlibrary.org"
+
+// DefaultCoversBase is the public Open Library covers host.
+const DefaultCoversBase = "https://covers.openlibrary.org"
+
+// searchFields limits the search response to the fields we map.
+const searchFields = "key,title,author_name,first_publish_year,publisher,subject,isbn,cover_i"
+
+// Record is the metadata found for one book.
+type Record struct {
+	Key       string
+	Title     string
+	Authors   []string
+	Year      int
+	Publisher string
+	Subjects  []string
+	ISBN      string
+	CoverID   int64
+}
+
+// Client queries an Open Library-compatible search API.
+type Client struct {
+	apiBase    string
+	coversBase string
+	http       *http.Client
+}
+
+// New creates a Client. Empty bases fall back to the public Open Library hosts.
+func New(apiBase, coversBase string) *Client {
+	if apiBase == "" {
+		apiBase = DefaultAPIBase
+	}
+	if coversBase == "" {
+		coversBase = DefaultCoversBase
+	}
+	return &Client{
+		apiBase:    strings.TrimRight(apiBase, "/"),
+		coversBase: strings.TrimRight(coversBase, "/"),
+		http:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Query describes what to look up. ISBN wins when set; otherwise title and
+// author are combined.
+type Query struct {
+	ISBN   string
+	Title  string
+	Author string
+}
+
+// searchDoc mirrors one entry of the search.json "docs" array.
+type searchDoc struct {
+	Key              string   `json:"key"`
+	Title            string   `json:"title"`
+	AuthorName       []string `json:"author_name"`
+	FirstPublishYear int      `json:"first_publish_year"`
+	Publisher        []string `json:"publisher"`
+	Subject          []string `json:"subject"`
+	ISBN             []string `json:"isbn"`
+	CoverI           int64    `json:"cover_i"`
+}
+
+type searchResponse struct {
+	NumFound int         `json:"numFound"`
+	Docs     []searchDoc `json:"docs"`
+}
+
+// Lookup returns the best match for q, or nil if nothing was found.
+func (c *Client) Lookup(q Query) (*Record, error) {
+	params := url.Values{}
+	switch {
+	case q.ISBN != "":
+		params.Set("isbn", NormalizeISBN(q.ISBN))
+	case q.Title != "":
+		params.Set("title", q.Title)
+		if q.Author != "" {
+			params.Set("author", q.Author)
+		}
+	default:
+		return nil, fmt.Errorf("lookup needs an ISBN or a title")
+	}
+	params.Set("fields", searchFields)
+	params.Set("limit", "1")
+
+	var sr searchResponse
+	if err := c.getJSON(c.apiBase+"/search.json?"+params.Encode(), &sr); err != nil {
+		return nil, err
+	}
+	if len(sr.Docs) == 0 {
+		return nil, nil
+	}
+
+	d := sr.Docs[0]
+	rec := &Record{
+		Key:      d.Key,
+		Title:    d.Title,
+		Authors:  d.AuthorName,
+		Year:     d.FirstPublishYear,
+		Subjects: d.Subject,
+		CoverID:  d.CoverI,
+	}
+	if len(d.Publisher) > 0 {
+		rec.Publisher = d.Publisher[0]
+	}
+	if q.ISBN != "" {
+		rec.ISBN = NormalizeISBN(q.ISBN)
+	} else if len(d.ISBN) > 0 {
+		rec.ISBN = d.ISBN[0]
+	}
+	return rec, nil
+}
+
+// CoverURL returns the large cover image URL for a record, or "" if the
+// record has no cover.
+func (c *Client) CoverURL(rec *Record) string {
+	if rec == nil || rec.CoverID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/b/id/%d-L.jpg", c.coversBase, rec.CoverID)
+}
+
+// FetchCover downloads the cover image for a record.
+func (c *Client) FetchCover(rec *Record) ([]byte, error) {
+	u := c.CoverURL(rec)
+	if u == "" {
+		return nil, fmt.Errorf("record has no cover")
+	}
+	resp, err := c.http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get cover: status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (c *Client) getJSON(u string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("metadata lookup: status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// NormalizeISBN strips hyphens and spaces from an ISBN.
+func NormalizeISBN(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
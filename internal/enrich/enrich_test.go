@@ -0,0 +1,140 @@
+package enrich
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+func newStandIn(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search.json", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("isbn") == "9780262510875" || q.Get("title") == "Structure and Interpretation" {
+			_, _ = w.Write([]byte(`{"numFound":1,"docs":[{
+				"key":"/works/OL1W",
+				"title":"Structure and Interpretation of Computer Programs",
+				"author_name":["Harold Abelson","Gerald Jay Sussman"],
+				"first_publish_year":1985,
+				"publisher":["MIT Press"],
+				"subject":["Computer programming","LISP"],
+				"isbn":["0262510871","9780262510875"],
+				"cover_i":42}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"numFound":0,"docs":[]}`))
+	})
+	mux.HandleFunc("/b/id/42-L.jpg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("jpeg-bytes"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLookup_ByISBN(t *testing.T) {
+	srv := newStandIn(t)
+	c := New(srv.URL, srv.URL)
+
+	rec, err := c.Lookup(Query{ISBN: "978-0-262-51087-5"})
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if rec == nil {
+		t.Fatal("Lookup() returned nil record")
+	}
+	if rec.ISBN != "9780262510875" {
+		t.Errorf("ISBN = %q, want normalized query ISBN", rec.ISBN)
+	}
+	if rec.Year != 1985 || rec.Publisher != "MIT Press" {
+		t.Errorf("got year=%d publisher=%q", rec.Year, rec.Publisher)
+	}
+}
+
+func TestLookup_ByTitleNoMatch(t *testing.T) {
+	srv := newStandIn(t)
+	c := New(srv.URL, srv.URL)
+
+	rec, err := c.Lookup(Query{Title: "Nothing Like This"})
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if rec != nil {
+		t.Errorf("expected nil record, got %+v", rec)
+	}
+}
+
+func TestLookup_RequiresQuery(t *testing.T) {
+	c := New("http://127.0.0.1:0", "")
+	if _, err := c.Lookup(Query{}); err == nil {
+		t.Error("expected error for empty query")
+	}
+}
+
+func TestFetchCover(t *testing.T) {
+	srv := newStandIn(t)
+	c := New(srv.URL, srv.URL)
+
+	data, err := c.FetchCover(&Record{CoverID: 42})
+	if err != nil {
+		t.Fatalf("FetchCover() error = %v", err)
+	}
+	if string(data) != "jpeg-bytes" {
+		t.Errorf("cover = %q", data)
+	}
+	if _, err := c.FetchCover(&Record{}); err == nil {
+		t.Error("expected error for record without cover")
+	}
+}
+
+func TestProposeAndApply(t *testing.T) {
+	b := catalog.Book{ID: "sicp", Title: "SICP", Author: "Abelson", Year: 1985}
+	rec := &Record{
+		Authors:   []string{"Harold Abelson", "Gerald Jay Sussman"},
+		Year:      1985,
+		Publisher: "MIT Press",
+		Subjects:  []string{"LISP"},
+		ISBN:      "9780262510875",
+		CoverID:   42,
+	}
+
+	p := Propose(b, rec)
+
+	fields := map[string]string{}
+	for _, c := range p.Changes {
+		fields[c.Field] = c.New
+	}
+	if _, ok := fields["year"]; ok {
+		t.Error("unchanged year should not be proposed")
+	}
+	if fields["cover"] != "covers/sicp.jpg" {
+		t.Errorf("cover change = %q", fields["cover"])
+	}
+	if !HasCover(p.Changes) {
+		t.Error("HasCover() = false")
+	}
+
+	got := Apply(b, p.Changes)
+	if got.Author != "Harold Abelson, Gerald Jay Sussman" {
+		t.Errorf("Author = %q", got.Author)
+	}
+	if got.Publisher != "MIT Press" || got.ISBN != "9780262510875" {
+		t.Errorf("Publisher/ISBN = %q/%q", got.Publisher, got.ISBN)
+	}
+	if len(got.Subjects) != 1 || got.Subjects[0] != "LISP" {
+		t.Errorf("Subjects = %v", got.Subjects)
+	}
+	if got.Title != "SICP" {
+		t.Errorf("Title should be untouched, got %q", got.Title)
+	}
+}
+
+func TestPropose_NilRecord(t *testing.T) {
+	p := Propose(catalog.Book{ID: "x"}, nil)
+	if len(p.Changes) != 0 {
+		t.Errorf("expected no changes, got %v", p.Changes)
+	}
+}
@@ -0,0 +1,115 @@
+package enrich
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+// maxSubjects caps how many subjects are proposed; Open Library often
+// returns dozens of loosely related ones.
+const maxSubjects = 8
+
+// Change is a single proposed field update.
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Proposal is the set of changes suggested for one book.
+type Proposal struct {
+	Book    catalog.Book
+	Record  *Record
+	Changes []Change
+}
+
+// CoverPath returns the repo-relative path used for an enriched cover.
+func CoverPath(bookID string) string {
+	return "covers/" + bookID + ".jpg"
+}
+
+// Propose compares a book against a looked-up record and returns the fields
+// that would change. Fields the record doesn't know about are left alone.
+// The title is never proposed since users often shorten it deliberately.
+func Propose(b catalog.Book, rec *Record) Proposal {
+	p := Proposal{Book: b, Record: rec}
+	if rec == nil {
+		return p
+	}
+
+	add := func(field, oldVal, newVal string) {
+		if newVal != "" && newVal != oldVal {
+			p.Changes = append(p.Changes, Change{Field: field, Old: oldVal, New: newVal})
+		}
+	}
+
+	add("author", b.Author, strings.Join(rec.Authors, ", "))
+	if rec.Year != 0 {
+		add("year", yearString(b.Year), strconv.Itoa(rec.Year))
+	}
+	add("publisher", b.Publisher, rec.Publisher)
+	add("isbn", b.ISBN, rec.ISBN)
+
+	subjects := rec.Subjects
+	if len(subjects) > maxSubjects {
+		subjects = subjects[:maxSubjects]
+	}
+	add("subjects", strings.Join(b.Subjects, ", "), strings.Join(subjects, ", "))
+
+	if rec.CoverID != 0 && b.Cover == "" {
+		add("cover", "", CoverPath(b.ID))
+	}
+	return p
+}
+
+// Apply returns a copy of b with the given changes applied.
+func Apply(b catalog.Book, changes []Change) catalog.Book {
+	for _, c := range changes {
+		switch c.Field {
+		case "author":
+			b.Author = c.New
+		case "year":
+			if y, err := strconv.Atoi(c.New); err == nil {
+				b.Year = y
+			}
+		case "publisher":
+			b.Publisher = c.New
+		case "isbn":
+			b.ISBN = c.New
+		case "subjects":
+			b.Subjects = splitList(c.New)
+		case "cover":
+			b.Cover = c.New
+		}
+	}
+	return b
+}
+
+// HasCover reports whether changes include a new cover.
+func HasCover(changes []Change) bool {
+	for _, c := range changes {
+		if c.Field == "cover" {
+			return true
+		}
+	}
+	return false
+}
+
+func yearString(y int) string {
+	if y == 0 {
+		return ""
+	}
+	return strconv.Itoa(y)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
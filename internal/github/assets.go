@@ -5,6 +5,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/encrypt"
 )
 
 // Asset represents a GitHub Release asset.
@@ -17,14 +19,28 @@ type Asset struct {
 	ContentType        string `json:"content_type"`
 }
 
-// ListReleaseAssets returns all assets for the given release.
+// assetsPerPage is the page size used when listing release assets.
+const assetsPerPage = 100
+
+// ListReleaseAssets returns all assets for the given release, following
+// pagination.
 func (c *Client) ListReleaseAssets(owner, repo string, releaseID int64) ([]Asset, error) {
+	if r := c.routed(owner, repo); r != nil {
+		return r.ListReleaseAssets(owner, repo, releaseID)
+	}
 	url := c.url("repos", owner, repo, "releases", fmt.Sprintf("%d", releaseID), "assets")
-	var assets []Asset
-	if err := c.doJSON(http.MethodGet, url, nil, &assets); err != nil {
-		return nil, err
+	var all []Asset
+	for page := 1; ; page++ {
+		var assets []Asset
+		pageURL := fmt.Sprintf("%s?per_page=%d&page=%d", url, assetsPerPage, page)
+		if err := c.doJSON(http.MethodGet, pageURL, nil, &assets); err != nil {
+			return nil, err
+		}
+		all = append(all, assets...)
+		if len(assets) < assetsPerPage {
+			return all, nil
+		}
 	}
-	return assets, nil
 }
 
 // FindAsset returns the first asset with the given name, or nil.
@@ -44,7 +60,17 @@ func (c *Client) FindAsset(owner, repo string, releaseID int64, name string) (*A
 // DownloadAsset streams the content of a release asset.
 // Caller is responsible for closing the returned ReadCloser.
 func (c *Client) DownloadAsset(owner, repo string, assetID int64) (io.ReadCloser, error) {
+	if r := c.routed(owner, repo); r != nil {
+		return r.DownloadAsset(owner, repo, assetID)
+	}
 	apiURL := c.url("repos", owner, repo, "releases", "assets", fmt.Sprintf("%d", assetID))
+	if c.token == "" {
+		rc, err := c.downloadPublicAsset(apiURL)
+		if err != nil {
+			return nil, err
+		}
+		return c.decrypting(owner, repo, rc)
+	}
 
 	// Use a client that strips auth on redirect away from github.com.
 	client := newHTTPClientNoRedirect()
@@ -67,20 +93,53 @@ func (c *Client) DownloadAsset(owner, repo string, assetID int64) (io.ReadCloser
 		_ = resp.Body.Close()
 		return nil, fmt.Errorf("download asset: unexpected status %d", resp.StatusCode)
 	}
+	return c.decrypting(owner, repo, resp.Body)
+}
+
+// downloadPublicAsset fetches an asset of a public repo from its
+// browser_download_url, which needs no token.
+func (c *Client) downloadPublicAsset(apiURL string) (io.ReadCloser, error) {
+	var asset Asset
+	if err := c.doJSON(http.MethodGet, apiURL, nil, &asset); err != nil {
+		return nil, fmt.Errorf("download asset: %w", err)
+	}
+	if asset.BrowserDownloadURL == "" {
+		return nil, fmt.Errorf("download asset: no download URL for %q", asset.Name)
+	}
+	resp, err := c.http.Get(asset.BrowserDownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("download asset: unexpected status %d", resp.StatusCode)
+	}
 	return resp.Body, nil
 }
 
 // UploadAsset uploads a file as a release asset.
-// The reader must yield exactly size bytes.
+// The reader must yield exactly size bytes. For repos with an encryption
+// key the asset is encrypted on the way.
 func (c *Client) UploadAsset(owner, repo string, releaseID int64, name string, r io.Reader, size int64, contentType string) (*Asset, error) {
-	// Upload endpoint is on uploads.github.com, not api.github.com.
-	uploadBase := strings.Replace(c.apiBase, "api.github.com", "uploads.github.com", 1)
-	if uploadBase == c.apiBase {
-		// Enterprise or custom — keep same host, different path.
-		uploadBase = c.apiBase
+	if rc := c.routed(owner, repo); rc != nil {
+		return rc.UploadAsset(owner, repo, releaseID, name, r, size, contentType)
+	}
+	if err := c.checkWritable(owner, repo); err != nil {
+		return nil, err
+	}
+	if keyFn := c.keyFunc(owner, repo); keyFn != nil {
+		key, err := keyFn()
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: encryption key: %w", owner, repo, err)
+		}
+		if r, err = encrypt.NewEncryptReader(key, r); err != nil {
+			return nil, err
+		}
+		size = encrypt.EncryptedSize(size)
+		contentType = "application/octet-stream"
 	}
 	uploadURL := fmt.Sprintf("%s/repos/%s/%s/releases/%d/assets?name=%s",
-		uploadBase, owner, repo, releaseID, name)
+		c.uploadBase(), owner, repo, releaseID, name)
 
 	if contentType == "" {
 		contentType = "application/octet-stream"
@@ -109,8 +168,27 @@ func (c *Client) UploadAsset(owner, repo string, releaseID int64, name string, r
 	return &asset, nil
 }
 
+// uploadBase returns the base URL of the upload API: uploads.github.com
+// for github.com, /api/uploads on a GitHub Enterprise Server, and the API
+// base itself for anything else.
+func (c *Client) uploadBase() string {
+	if strings.Contains(c.apiBase, "api.github.com") {
+		return strings.Replace(c.apiBase, "api.github.com", "uploads.github.com", 1)
+	}
+	if base, ok := strings.CutSuffix(c.apiBase, "/api/v3"); ok {
+		return base + "/api/uploads"
+	}
+	return c.apiBase
+}
+
 // DeleteAsset removes a release asset.
 func (c *Client) DeleteAsset(owner, repo string, assetID int64) error {
+	if r := c.routed(owner, repo); r != nil {
+		return r.DeleteAsset(owner, repo, assetID)
+	}
+	if err := c.checkWritable(owner, repo); err != nil {
+		return err
+	}
 	url := c.url("repos", owner, repo, "releases", "assets", fmt.Sprintf("%d", assetID))
 	req, err := http.NewRequest(http.MethodDelete, url, nil)
 	if err != nil {
@@ -6,21 +6,28 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
 const defaultAPIBase = "https://api.github.com"
 
-// Client is an authenticated GitHub API client.
+// Client is a GitHub API client. Without a token it makes anonymous
+// requests, which can read public repos but never write.
 type Client struct {
 	token   string
 	apiBase string
 	http    *http.Client
+
+	readOnly map[string]bool    // "owner/repo", lowercased
+	keys     map[string]KeyFunc // "owner/repo", lowercased
+	routes   map[string]*Client // "owner/repo", lowercased
 }
 
 // New creates a Client with the given token and API base URL.
-// If apiBase is empty, the public GitHub API is used.
+// If apiBase is empty, the public GitHub API is used. An empty token gives
+// an anonymous client.
 func New(token, apiBase string) *Client {
 	if apiBase == "" {
 		apiBase = defaultAPIBase
@@ -38,9 +45,78 @@ func New(token, apiBase string) *Client {
 	}
 }
 
+// Anonymous reports whether the client has no token.
+func (c *Client) Anonymous() bool {
+	return c.token == ""
+}
+
+// SetReadOnly makes the client refuse writes to owner/repo.
+func (c *Client) SetReadOnly(owner, repo string) {
+	if c.readOnly == nil {
+		c.readOnly = map[string]bool{}
+	}
+	c.readOnly[strings.ToLower(owner+"/"+repo)] = true
+}
+
+// Route sends every request about owner/repo to another client, such as
+// one for a different account or a GitHub Enterprise host. The client's
+// own read-only and encryption settings do not apply to routed repos; set
+// them on the other client.
+func (c *Client) Route(owner, repo string, to *Client) {
+	if c.routes == nil {
+		c.routes = map[string]*Client{}
+	}
+	c.routes[strings.ToLower(owner+"/"+repo)] = to
+}
+
+// For returns the client that handles owner/repo: the one it is routed to,
+// or c itself.
+func (c *Client) For(owner, repo string) *Client {
+	if to := c.routes[strings.ToLower(owner+"/"+repo)]; to != nil && to != c {
+		return to.For(owner, repo)
+	}
+	return c
+}
+
+// routed returns the client owner/repo is routed to, or nil if c handles
+// it itself.
+func (c *Client) routed(owner, repo string) *Client {
+	if to := c.For(owner, repo); to != c {
+		return to
+	}
+	return nil
+}
+
+// Host returns the web host of the API, e.g. "github.com" for
+// https://api.github.com or "ghe.example.com" for
+// https://ghe.example.com/api/v3.
+func (c *Client) Host() string {
+	u, err := url.Parse(c.apiBase)
+	if err != nil || u.Host == "" {
+		return "github.com"
+	}
+	if u.Host == "api.github.com" {
+		return "github.com"
+	}
+	return u.Host
+}
+
+// checkWritable returns an error if the client may not write to owner/repo.
+func (c *Client) checkWritable(owner, repo string) error {
+	if c.token == "" {
+		return fmt.Errorf("%s/%s: %w", owner, repo, ErrNoToken)
+	}
+	if c.readOnly[strings.ToLower(owner+"/"+repo)] {
+		return fmt.Errorf("%s/%s: %w", owner, repo, ErrReadOnly)
+	}
+	return nil
+}
+
 // do executes the request with standard GitHub headers.
 func (c *Client) do(req *http.Request) (*http.Response, error) {
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
 	// Only set Accept if not already set (allow custom Accept headers)
 	if req.Header.Get("Accept") == "" {
 		req.Header.Set("Accept", "application/vnd.github+json")
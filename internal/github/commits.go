@@ -0,0 +1,79 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// commitsPerPage is the page size used when listing commits.
+const commitsPerPage = 100
+
+// Commit is a commit as listed by the commits API.
+type Commit struct {
+	SHA     string
+	Message string
+	Author  string // GitHub login, or the git author name
+	Date    time.Time
+	Parents []string
+}
+
+type apiCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string    `json:"name"`
+			Date time.Time `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+}
+
+// ListCommits returns up to limit commits of the default branch that touch
+// path, newest first. A limit of 0 lists them all.
+func (c *Client) ListCommits(owner, repo, path string, limit int) ([]Commit, error) {
+	if r := c.routed(owner, repo); r != nil {
+		return r.ListCommits(owner, repo, path, limit)
+	}
+	perPage := commitsPerPage
+	if limit > 0 && limit < perPage {
+		perPage = limit
+	}
+	var out []Commit
+	for page := 1; ; page++ {
+		u := fmt.Sprintf("%s?path=%s&per_page=%d&page=%d",
+			c.url("repos", owner, repo, "commits"), url.QueryEscape(path), perPage, page)
+		var commits []apiCommit
+		if err := c.doJSON(http.MethodGet, u, nil, &commits); err != nil {
+			return nil, err
+		}
+		for _, ac := range commits {
+			cm := Commit{
+				SHA:     ac.SHA,
+				Message: ac.Commit.Message,
+				Author:  ac.Commit.Author.Name,
+				Date:    ac.Commit.Author.Date,
+			}
+			if ac.Author != nil && ac.Author.Login != "" {
+				cm.Author = ac.Author.Login
+			}
+			for _, p := range ac.Parents {
+				cm.Parents = append(cm.Parents, p.SHA)
+			}
+			out = append(out, cm)
+			if limit > 0 && len(out) == limit {
+				return out, nil
+			}
+		}
+		if len(commits) < perPage {
+			return out, nil
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestListCommits(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/me/shelf-books/commits", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("path"); got != "catalog.yml" {
+			t.Errorf("path = %q", got)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		var items []string
+		for i := 0; i < perPage; i++ {
+			n := (page-1)*perPage + i
+			author := `{"login":"octocat"}`
+			if n%2 == 1 {
+				author = "null"
+			}
+			items = append(items, fmt.Sprintf(`{"sha":"c%d","commit":{"message":"msg %d","author":{"name":"Octo Cat","date":"2026-03-0%dT10:00:00Z"}},"author":%s,"parents":[{"sha":"c%d"}]}`,
+				n, n, n%9+1, author, n+1))
+		}
+		fmt.Fprint(w, "[")
+		for i, it := range items {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprint(w, it)
+		}
+		fmt.Fprint(w, "]")
+	})
+	_, c := newFakeServer(t, mux)
+
+	commits, err := c.ListCommits("me", "shelf-books", "catalog.yml", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 3 {
+		t.Fatalf("got %d commits", len(commits))
+	}
+	first, second := commits[0], commits[1]
+	if first.SHA != "c0" || first.Message != "msg 0" || first.Author != "octocat" || first.Parents[0] != "c1" {
+		t.Errorf("first = %+v", first)
+	}
+	if second.Author != "Octo Cat" {
+		t.Errorf("commit without a GitHub user: author = %q", second.Author)
+	}
+	if first.Date.Day() != 1 {
+		t.Errorf("date = %v", first.Date)
+	}
+}
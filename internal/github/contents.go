@@ -24,6 +24,9 @@ type FileContent struct {
 // Returns (content, blobSHA, error). blobSHA is needed for PUT updates.
 // For files > 1 MB it falls back to the Git Blobs API.
 func (c *Client) GetFileContent(owner, repo, path, ref string) ([]byte, string, error) {
+	if r := c.routed(owner, repo); r != nil {
+		return r.GetFileContent(owner, repo, path, ref)
+	}
 	url := c.url("repos", owner, repo, "contents", path)
 	if ref != "" {
 		url += "?ref=" + ref
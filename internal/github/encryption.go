@@ -0,0 +1,71 @@
+package github
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/encrypt"
+)
+
+// KeyFunc returns the encryption key of a repo. It is called when an asset
+// is first encrypted or decrypted, so keys are only loaded when needed.
+type KeyFunc func() (*encrypt.Key, error)
+
+// SetEncryption makes the client encrypt assets uploaded to owner/repo and
+// decrypt encrypted assets downloaded from it.
+func (c *Client) SetEncryption(owner, repo string, key KeyFunc) {
+	if c.keys == nil {
+		c.keys = map[string]KeyFunc{}
+	}
+	c.keys[strings.ToLower(owner+"/"+repo)] = key
+}
+
+// EncryptionScheme returns the scheme assets uploaded to owner/repo are
+// encrypted with, or "" if they are uploaded as is.
+func (c *Client) EncryptionScheme(owner, repo string) string {
+	if r := c.routed(owner, repo); r != nil {
+		return r.EncryptionScheme(owner, repo)
+	}
+	if c.keyFunc(owner, repo) != nil {
+		return encrypt.Scheme
+	}
+	return ""
+}
+
+func (c *Client) keyFunc(owner, repo string) KeyFunc {
+	return c.keys[strings.ToLower(owner+"/"+repo)]
+}
+
+// decrypting returns rc as is for plain assets, and a decrypting reader for
+// encrypted ones. Plain assets are passed through even in encrypted repos,
+// so books uploaded before encryption was turned on still work.
+func (c *Client) decrypting(owner, repo string, rc io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(rc)
+	prefix, _ := br.Peek(encrypt.MagicSize)
+	if !encrypt.IsEncrypted(prefix) {
+		return readCloser{br, rc}, nil
+	}
+	keyFn := c.keyFunc(owner, repo)
+	if keyFn == nil {
+		_ = rc.Close()
+		return nil, fmt.Errorf("%s/%s: %w", owner, repo, ErrNoKey)
+	}
+	key, err := keyFn()
+	if err != nil {
+		_ = rc.Close()
+		return nil, fmt.Errorf("%s/%s: encryption key: %w", owner, repo, err)
+	}
+	dr, err := encrypt.NewDecryptReader(key, br)
+	if err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+	return readCloser{dr, rc}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
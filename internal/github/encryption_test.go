@@ -0,0 +1,105 @@
+package github
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/encrypt"
+)
+
+// assetServer stores one uploaded asset and serves it back.
+func assetServer(t *testing.T) (*Client, *[]byte) {
+	t.Helper()
+	var stored []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/me/shelf/releases/1/assets", func(w http.ResponseWriter, r *http.Request) {
+		stored, _ = io.ReadAll(r.Body)
+		if r.ContentLength != int64(len(stored)) {
+			t.Errorf("Content-Length %d, body %d bytes", r.ContentLength, len(stored))
+		}
+		_, _ = w.Write([]byte(`{"id":9,"name":"book.pdf"}`))
+	})
+	mux.HandleFunc("/repos/me/shelf/releases/assets/9", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(stored)
+	})
+	_, c := newFakeServer(t, mux)
+	return c, &stored
+}
+
+func TestEncryptedUploadAndDownload(t *testing.T) {
+	c, stored := assetServer(t)
+	key, _ := encrypt.NewKey(bytes.Repeat([]byte{1}, 32))
+	loads := 0
+	c.SetEncryption("me", "shelf", func() (*encrypt.Key, error) {
+		loads++
+		return key, nil
+	})
+
+	plain := strings.Repeat("secret pages ", 10000)
+	if _, err := c.UploadAsset("me", "shelf", 1, "book.pdf", strings.NewReader(plain), int64(len(plain)), "application/pdf"); err != nil {
+		t.Fatalf("UploadAsset: %v", err)
+	}
+	if !encrypt.IsEncrypted(*stored) || bytes.Contains(*stored, []byte("secret")) {
+		t.Fatal("asset was uploaded in plaintext")
+	}
+
+	rc, err := c.DownloadAsset("me", "shelf", 9)
+	if err != nil {
+		t.Fatalf("DownloadAsset: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil || string(got) != plain {
+		t.Fatalf("download = %d bytes, %v", len(got), err)
+	}
+	if loads != 2 {
+		t.Errorf("key loaded %d times", loads)
+	}
+	if c.EncryptionScheme("ME", "Shelf") != encrypt.Scheme || c.EncryptionScheme("me", "other") != "" {
+		t.Error("EncryptionScheme wrong")
+	}
+}
+
+func TestDownload_PlainAssetInEncryptedRepo(t *testing.T) {
+	c, _ := assetServer(t)
+	if _, err := c.UploadAsset("me", "shelf", 1, "book.pdf", strings.NewReader("old book"), 8, ""); err != nil {
+		t.Fatal(err)
+	}
+	// Encryption turned on after the book was uploaded.
+	c.SetEncryption("me", "shelf", func() (*encrypt.Key, error) {
+		t.Error("key loaded for a plain asset")
+		return nil, errors.New("unused")
+	})
+	rc, err := c.DownloadAsset("me", "shelf", 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rc.Close() }()
+	if got, _ := io.ReadAll(rc); string(got) != "old book" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDownload_EncryptedWithoutKey(t *testing.T) {
+	c, _ := assetServer(t)
+	key, _ := encrypt.NewKey(bytes.Repeat([]byte{1}, 32))
+	c.SetEncryption("me", "shelf", func() (*encrypt.Key, error) { return key, nil })
+	if _, err := c.UploadAsset("me", "shelf", 1, "book.pdf", strings.NewReader("x"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	c.keys = nil
+	if _, err := c.DownloadAsset("me", "shelf", 9); !errors.Is(err, ErrNoKey) {
+		t.Errorf("err = %v, want ErrNoKey", err)
+	}
+
+	// A failing key never falls back to uploading plaintext.
+	c.SetEncryption("me", "shelf", func() (*encrypt.Key, error) { return nil, errors.New("no passphrase") })
+	if _, err := c.UploadAsset("me", "shelf", 1, "book.pdf", strings.NewReader("x"), 1, ""); err == nil {
+		t.Error("expected upload to fail without a key")
+	}
+}
@@ -12,4 +12,11 @@ var (
 	ErrForbidden = errors.New("forbidden — token may lack required scope (needs 'repo')")
 	// ErrConflict is returned when a resource already exists.
 	ErrConflict = errors.New("conflict — resource already exists")
+	// ErrReadOnly is returned for writes to a repo marked read-only.
+	ErrReadOnly = errors.New("shelf is read-only")
+	// ErrNoToken is returned for writes by a client without a token.
+	ErrNoToken = errors.New("no GitHub token — run 'shelfctl auth login' or set GITHUB_TOKEN to make changes")
+	// ErrNoKey is returned when downloading an encrypted asset of a repo
+	// with no encryption key configured.
+	ErrNoKey = errors.New("asset is encrypted — configure the shelf's encryption key")
 )
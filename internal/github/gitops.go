@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -12,16 +13,25 @@ import (
 // commits with the given message, and pushes. The temp dir is cleaned up
 // on return regardless of outcome.
 func (c *Client) CommitFile(owner, repo, filePath string, content []byte, message string) error {
+	return c.CommitFiles(owner, repo, map[string][]byte{filePath: content}, message)
+}
+
+// CommitFiles writes every path in files and pushes them as a single commit.
+// Paths are repo-relative and use forward slashes.
+func (c *Client) CommitFiles(owner, repo string, files map[string][]byte, message string) error {
+	if r := c.routed(owner, repo); r != nil {
+		return r.CommitFiles(owner, repo, files, message)
+	}
+	if err := c.checkWritable(owner, repo); err != nil {
+		return err
+	}
 	tmpDir, err := os.MkdirTemp("", "shelfctl-*")
 	if err != nil {
 		return fmt.Errorf("create temp dir: %w", err)
 	}
 	defer func() { _ = os.RemoveAll(tmpDir) }()
 
-	cloneURL := fmt.Sprintf("https://x-access-token:%s@github.com/%s/%s.git",
-		c.token, owner, repo)
-
-	if err := runGit(tmpDir, "clone", "--depth=1", cloneURL, "."); err != nil {
+	if err := runGit(tmpDir, "clone", "--depth=1", c.cloneURL(owner, repo), "."); err != nil {
 		return fmt.Errorf("git clone: %w", err)
 	}
 
@@ -30,12 +40,20 @@ func (c *Client) CommitFile(owner, repo, filePath string, content []byte, messag
 		return fmt.Errorf("git pull --rebase: %w", err)
 	}
 
-	fullPath := filepath.Join(tmpDir, filepath.FromSlash(filePath))
-	if err := os.MkdirAll(filepath.Dir(fullPath), 0750); err != nil {
-		return err
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
 	}
-	if err := os.WriteFile(fullPath, content, 0600); err != nil {
-		return err
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		fullPath := filepath.Join(tmpDir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fullPath, files[p], 0600); err != nil {
+			return err
+		}
 	}
 
 	if err := runGit(tmpDir, "config", "user.email", "shelfctl@local"); err != nil {
@@ -44,7 +62,7 @@ func (c *Client) CommitFile(owner, repo, filePath string, content []byte, messag
 	if err := runGit(tmpDir, "config", "user.name", "shelfctl"); err != nil {
 		return err
 	}
-	if err := runGit(tmpDir, "add", filePath); err != nil {
+	if err := runGit(tmpDir, append([]string{"add", "--"}, paths...)...); err != nil {
 		return err
 	}
 	if err := runGit(tmpDir, "commit", "-m", message); err != nil {
@@ -56,6 +74,81 @@ func (c *Client) CommitFile(owner, repo, filePath string, content []byte, messag
 	return nil
 }
 
+// ReplaceBranch makes files the complete contents of branch and pushes the
+// result as a single commit on top of the branch's current head. A branch
+// that does not exist yet is created without history. It reports false,
+// without committing, when the branch already holds exactly these files.
+func (c *Client) ReplaceBranch(owner, repo, branch string, files map[string][]byte, message string) (bool, error) {
+	if r := c.routed(owner, repo); r != nil {
+		return r.ReplaceBranch(owner, repo, branch, files, message)
+	}
+	if err := c.checkWritable(owner, repo); err != nil {
+		return false, err
+	}
+	return replaceBranch(c.cloneURL(owner, repo), branch, files, message)
+}
+
+func (c *Client) cloneURL(owner, repo string) string {
+	return fmt.Sprintf("https://x-access-token:%s@%s/%s/%s.git", c.token, c.Host(), owner, repo)
+}
+
+func replaceBranch(remote, branch string, files map[string][]byte, message string) (bool, error) {
+	tmpDir, err := os.MkdirTemp("", "shelfctl-*")
+	if err != nil {
+		return false, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := runGit(tmpDir, "init", "-q"); err != nil {
+		return false, err
+	}
+	if err := runGit(tmpDir, "remote", "add", "origin", remote); err != nil {
+		return false, err
+	}
+	if err := runGit(tmpDir, "fetch", "-q", "--depth=1", "origin", branch); err == nil {
+		if err := runGit(tmpDir, "checkout", "-q", "-b", branch, "FETCH_HEAD"); err != nil {
+			return false, err
+		}
+		if err := runGit(tmpDir, "rm", "-rq", "--ignore-unmatch", "."); err != nil {
+			return false, err
+		}
+	} else if err := runGit(tmpDir, "checkout", "-q", "--orphan", branch); err != nil {
+		// A failed fetch means the branch is new. If it was really an
+		// access problem, the push below fails instead.
+		return false, err
+	}
+
+	for p, data := range files {
+		fullPath := filepath.Join(tmpDir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0750); err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(fullPath, data, 0600); err != nil {
+			return false, err
+		}
+	}
+
+	if err := runGit(tmpDir, "config", "user.email", "shelfctl@local"); err != nil {
+		return false, err
+	}
+	if err := runGit(tmpDir, "config", "user.name", "shelfctl"); err != nil {
+		return false, err
+	}
+	if err := runGit(tmpDir, "add", "-A"); err != nil {
+		return false, err
+	}
+	if err := runGit(tmpDir, "diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	if err := runGit(tmpDir, "commit", "-q", "-m", message); err != nil {
+		return false, err
+	}
+	if err := runGit(tmpDir, "push", "-q", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		return false, fmt.Errorf("git push: %w", err)
+	}
+	return true, nil
+}
+
 func runGit(dir string, args ...string) error {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
@@ -3,6 +3,8 @@ package github
 import (
 	"net/http"
 	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -101,3 +103,50 @@ func TestRunGit_TokenSanitization(t *testing.T) {
 		t.Errorf("expected token to be redacted in error message, got: %v", err)
 	}
 }
+
+func TestReplaceBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v %s", err, out)
+	}
+	show := func(rev string) string {
+		out, _ := exec.Command("git", "--git-dir", remote, "show", rev).CombinedOutput()
+		return string(out)
+	}
+
+	// First publish creates the branch.
+	changed, err := replaceBranch(remote, "gh-pages", map[string][]byte{
+		"index.html":     []byte("v1"),
+		"books/old.html": []byte("old"),
+	}, "publish 1")
+	if err != nil || !changed {
+		t.Fatalf("first publish: changed=%v err=%v", changed, err)
+	}
+	if got := show("gh-pages:index.html"); got != "v1" {
+		t.Errorf("index.html = %q", got)
+	}
+
+	// The second replaces the whole tree, dropping files not listed.
+	changed, err = replaceBranch(remote, "gh-pages", map[string][]byte{"index.html": []byte("v2")}, "publish 2")
+	if err != nil || !changed {
+		t.Fatalf("second publish: changed=%v err=%v", changed, err)
+	}
+	if got := show("gh-pages:index.html"); got != "v2" {
+		t.Errorf("index.html = %q", got)
+	}
+	if got := show("gh-pages:books/old.html"); !strings.Contains(got, "does not exist") && !strings.Contains(got, "fatal") {
+		t.Errorf("stale file still present: %q", got)
+	}
+	if got := show("gh-pages~1:index.html"); got != "v1" {
+		t.Errorf("history not kept, parent index.html = %q", got)
+	}
+
+	// Publishing the same files again makes no commit.
+	changed, err = replaceBranch(remote, "gh-pages", map[string][]byte{"index.html": []byte("v2")}, "publish 3")
+	if err != nil || changed {
+		t.Errorf("unchanged publish: changed=%v err=%v", changed, err)
+	}
+}
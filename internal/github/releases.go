@@ -5,6 +5,9 @@ import (
 	"net/http"
 )
 
+// releasesPerPage is the page size used when listing releases.
+const releasesPerPage = 100
+
 // Release represents a GitHub Release.
 type Release struct {
 	ID      int64  `json:"id"`
@@ -16,6 +19,9 @@ type Release struct {
 // GetReleaseByTag fetches a release by its tag name.
 // Returns ErrNotFound if the tag does not exist.
 func (c *Client) GetReleaseByTag(owner, repo, tag string) (*Release, error) {
+	if r := c.routed(owner, repo); r != nil {
+		return r.GetReleaseByTag(owner, repo, tag)
+	}
 	url := c.url("repos", owner, repo, "releases", "tags", tag)
 	var r Release
 	if err := c.doJSON(http.MethodGet, url, nil, &r); err != nil {
@@ -24,8 +30,35 @@ func (c *Client) GetReleaseByTag(owner, repo, tag string) (*Release, error) {
 	return &r, nil
 }
 
+// ListReleases returns every release of a repository, newest first,
+// following pagination.
+func (c *Client) ListReleases(owner, repo string) ([]Release, error) {
+	if r := c.routed(owner, repo); r != nil {
+		return r.ListReleases(owner, repo)
+	}
+	url := c.url("repos", owner, repo, "releases")
+	var all []Release
+	for page := 1; ; page++ {
+		var releases []Release
+		pageURL := fmt.Sprintf("%s?per_page=%d&page=%d", url, releasesPerPage, page)
+		if err := c.doJSON(http.MethodGet, pageURL, nil, &releases); err != nil {
+			return nil, err
+		}
+		all = append(all, releases...)
+		if len(releases) < releasesPerPage {
+			return all, nil
+		}
+	}
+}
+
 // CreateRelease creates a new release with the given tag.
 func (c *Client) CreateRelease(owner, repo, tag, name string) (*Release, error) {
+	if r := c.routed(owner, repo); r != nil {
+		return r.CreateRelease(owner, repo, tag, name)
+	}
+	if err := c.checkWritable(owner, repo); err != nil {
+		return nil, err
+	}
 	url := c.url("repos", owner, repo, "releases")
 	body := map[string]interface{}{
 		"tag_name":               tag,
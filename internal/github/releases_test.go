@@ -3,7 +3,9 @@ package github
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"testing"
 )
 
@@ -210,3 +212,55 @@ func TestGetReleaseNotFound(t *testing.T) {
 		t.Errorf("expected ErrNotFound, got %v", err)
 	}
 }
+
+func TestListReleases_Paginates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		n := releasesPerPage
+		if page == 2 {
+			n = 3
+		} else if page != 1 {
+			t.Errorf("unexpected page %d", page)
+		}
+		releases := make([]Release, n)
+		for i := range releases {
+			releases[i] = Release{ID: int64(page*1000 + i), TagName: fmt.Sprintf("library-%d-%d", page, i)}
+		}
+		_ = json.NewEncoder(w).Encode(releases)
+	})
+	_, c := newFakeServer(t, mux)
+
+	releases, err := c.ListReleases("owner", "repo")
+	if err != nil {
+		t.Fatalf("ListReleases: %v", err)
+	}
+	if len(releases) != releasesPerPage+3 {
+		t.Errorf("got %d releases, want %d", len(releases), releasesPerPage+3)
+	}
+}
+
+func TestListReleaseAssets_Paginates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/releases/7/assets", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		n := assetsPerPage
+		if page == 2 {
+			n = 0
+		}
+		assets := make([]Asset, n)
+		for i := range assets {
+			assets[i] = Asset{ID: int64(i + 1), Name: fmt.Sprintf("book-%d.pdf", i)}
+		}
+		_ = json.NewEncoder(w).Encode(assets)
+	})
+	_, c := newFakeServer(t, mux)
+
+	assets, err := c.ListReleaseAssets("owner", "repo", 7)
+	if err != nil {
+		t.Fatalf("ListReleaseAssets: %v", err)
+	}
+	if len(assets) != assetsPerPage {
+		t.Errorf("got %d assets, want %d", len(assets), assetsPerPage)
+	}
+}
@@ -3,18 +3,44 @@ package github
 import (
 	"fmt"
 	"net/http"
+	"strings"
 )
 
+// ShelfTopic is the repository topic that marks a shelf repo, so shelves
+// can be discovered without probing every repo for a catalog.
+const ShelfTopic = "shelfctl-shelf"
+
+// reposPerPage is the page size used when listing repositories.
+const reposPerPage = 100
+
 // Repo represents a GitHub repository.
 type Repo struct {
-	ID       int64  `json:"id"`
-	FullName string `json:"full_name"`
-	HTMLURL  string `json:"html_url"`
-	Private  bool   `json:"private"`
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	FullName    string   `json:"full_name"`
+	HTMLURL     string   `json:"html_url"`
+	Description string   `json:"description"`
+	Private     bool     `json:"private"`
+	Fork        bool     `json:"fork"`
+	Archived    bool     `json:"archived"`
+	Topics      []string `json:"topics"`
+}
+
+// HasTopic reports whether the repo carries the given topic.
+func (r *Repo) HasTopic(topic string) bool {
+	for _, t := range r.Topics {
+		if strings.EqualFold(t, topic) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetRepo fetches repository metadata. Returns ErrNotFound if absent.
 func (c *Client) GetRepo(owner, repo string) (*Repo, error) {
+	if r := c.routed(owner, repo); r != nil {
+		return r.GetRepo(owner, repo)
+	}
 	url := c.url("repos", owner, repo)
 	var r Repo
 	if err := c.doJSON(http.MethodGet, url, nil, &r); err != nil {
@@ -25,6 +51,9 @@ func (c *Client) GetRepo(owner, repo string) (*Repo, error) {
 
 // CreateRepo creates a new repository under the authenticated user.
 func (c *Client) CreateRepo(name string, private bool) (*Repo, error) {
+	if c.token == "" {
+		return nil, ErrNoToken
+	}
 	url := c.url("user", "repos")
 	body := map[string]interface{}{
 		"name":        name,
@@ -51,9 +80,75 @@ func (c *Client) RepoExists(owner, repo string) (bool, error) {
 // DeleteRepo permanently deletes a repository.
 // This is a destructive operation that cannot be undone.
 func (c *Client) DeleteRepo(owner, repo string) error {
+	if r := c.routed(owner, repo); r != nil {
+		return r.DeleteRepo(owner, repo)
+	}
+	if err := c.checkWritable(owner, repo); err != nil {
+		return err
+	}
 	url := c.url("repos", owner, repo)
 	if err := c.doJSON(http.MethodDelete, url, nil, nil); err != nil {
 		return fmt.Errorf("delete repo %s/%s: %w", owner, repo, err)
 	}
 	return nil
 }
+
+// ListRepos returns every repository of a user or organization, following
+// pagination. For an organization, or for the token's own account, private
+// repos the token can see are included; for other users only public ones.
+func (c *Client) ListRepos(owner string) ([]Repo, error) {
+	repos, err := c.listReposPaged(c.url("orgs", owner, "repos") + "?type=all")
+	if err != ErrNotFound {
+		return repos, err
+	}
+
+	if c.token != "" {
+		if me, err := c.CurrentUser(); err == nil && strings.EqualFold(me.Login, owner) {
+			return c.listReposPaged(c.url("user", "repos") + "?affiliation=owner")
+		}
+	}
+	return c.listReposPaged(c.url("users", owner, "repos") + "?type=owner")
+}
+
+// listReposPaged fetches url page by page until a short page comes back.
+func (c *Client) listReposPaged(url string) ([]Repo, error) {
+	var all []Repo
+	for page := 1; ; page++ {
+		var repos []Repo
+		pageURL := fmt.Sprintf("%s&per_page=%d&page=%d", url, reposPerPage, page)
+		if err := c.doJSON(http.MethodGet, pageURL, nil, &repos); err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+		if len(repos) < reposPerPage {
+			return all, nil
+		}
+	}
+}
+
+// AddTopic adds a topic to a repository, keeping its existing topics.
+func (c *Client) AddTopic(owner, repo, topic string) error {
+	if r := c.routed(owner, repo); r != nil {
+		return r.AddTopic(owner, repo, topic)
+	}
+	if err := c.checkWritable(owner, repo); err != nil {
+		return err
+	}
+	url := c.url("repos", owner, repo, "topics")
+	var current struct {
+		Names []string `json:"names"`
+	}
+	if err := c.doJSON(http.MethodGet, url, nil, &current); err != nil {
+		return fmt.Errorf("get topics: %w", err)
+	}
+	for _, t := range current.Names {
+		if strings.EqualFold(t, topic) {
+			return nil
+		}
+	}
+	body := map[string][]string{"names": append(current.Names, topic)}
+	if err := c.doJSON(http.MethodPut, url, body, nil); err != nil {
+		return fmt.Errorf("set topics: %w", err)
+	}
+	return nil
+}
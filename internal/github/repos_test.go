@@ -0,0 +1,104 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestListRepos_OrgPaginates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/team/repos", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		n := reposPerPage
+		if page == 2 {
+			n = 1
+		} else if page != 1 {
+			t.Errorf("unexpected page %d", page)
+		}
+		repos := make([]Repo, n)
+		for i := range repos {
+			repos[i] = Repo{Name: fmt.Sprintf("repo-%d-%d", page, i)}
+		}
+		_ = json.NewEncoder(w).Encode(repos)
+	})
+	_, c := newFakeServer(t, mux)
+
+	repos, err := c.ListRepos("team")
+	if err != nil {
+		t.Fatalf("ListRepos: %v", err)
+	}
+	if len(repos) != reposPerPage+1 {
+		t.Errorf("got %d repos, want %d", len(repos), reposPerPage+1)
+	}
+}
+
+func TestListRepos_OwnUserIncludesPrivate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/me/repos", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"login":"Me"}`))
+	})
+	mux.HandleFunc("/user/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("affiliation") != "owner" {
+			t.Errorf("affiliation = %q", r.URL.Query().Get("affiliation"))
+		}
+		_, _ = w.Write([]byte(`[{"name":"shelf-books","private":true,"topics":["shelfctl-shelf"]}]`))
+	})
+	_, c := newFakeServer(t, mux)
+
+	repos, err := c.ListRepos("me")
+	if err != nil {
+		t.Fatalf("ListRepos: %v", err)
+	}
+	if len(repos) != 1 || !repos[0].Private || !repos[0].HasTopic(ShelfTopic) {
+		t.Errorf("repos = %+v", repos)
+	}
+}
+
+func TestListRepos_OtherUser(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/alice/repos", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"login":"me"}`))
+	})
+	mux.HandleFunc("/users/alice/repos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"name":"shelf-papers"}]`))
+	})
+	_, c := newFakeServer(t, mux)
+
+	repos, err := c.ListRepos("alice")
+	if err != nil || len(repos) != 1 || repos[0].Name != "shelf-papers" {
+		t.Errorf("ListRepos = %+v, %v", repos, err)
+	}
+}
+
+func TestAddTopic_KeepsExisting(t *testing.T) {
+	mux := http.NewServeMux()
+	var put []string
+	mux.HandleFunc("/repos/me/shelf-books/topics", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var body struct {
+				Names []string `json:"names"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			put = body.Names
+		}
+		_, _ = w.Write([]byte(`{"names":["books"]}`))
+	})
+	_, c := newFakeServer(t, mux)
+
+	if err := c.AddTopic("me", "shelf-books", ShelfTopic); err != nil {
+		t.Fatalf("AddTopic: %v", err)
+	}
+	if !reflect.DeepEqual(put, []string{"books", ShelfTopic}) {
+		t.Errorf("topics = %v", put)
+	}
+}
@@ -0,0 +1,45 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// User is the account a token belongs to.
+type User struct {
+	Login string `json:"login"`
+	Name  string `json:"name"`
+	// Scopes are the OAuth scopes of a classic token; fine-grained tokens
+	// report none.
+	Scopes []string `json:"-"`
+}
+
+// CurrentUser returns the account of the client's token.
+func (c *Client) CurrentUser() (*User, error) {
+	if c.token == "" {
+		return nil, ErrNoToken
+	}
+	req, err := http.NewRequest(http.MethodGet, c.url("user"), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+	var u User
+	if err := jsonDecode(resp.Body, &u); err != nil {
+		return nil, fmt.Errorf("decoding user: %w", err)
+	}
+	for _, s := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			u.Scopes = append(u.Scopes, s)
+		}
+	}
+	return &u, nil
+}
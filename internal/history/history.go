@@ -0,0 +1,188 @@
+// Package history decodes the git history of a shelf's catalog into
+// book-level events.
+package history
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/github"
+)
+
+// Client is the part of the GitHub client the history needs.
+type Client interface {
+	ListCommits(owner, repo, path string, limit int) ([]github.Commit, error)
+	GetFileContent(owner, repo, path, ref string) ([]byte, string, error)
+}
+
+// Entry is a commit that changed the catalog, with the changes it made.
+type Entry struct {
+	Commit  github.Commit
+	Changes []catalog.Change
+}
+
+// Subject returns the first line of the commit message.
+func (e Entry) Subject() string {
+	subject, _, _ := strings.Cut(e.Commit.Message, "\n")
+	return subject
+}
+
+// moveMessage matches the catalog commits of a move between shelves, e.g.
+// "move: add sicp (from papers)" or "move: remove sicp (moved to papers)".
+var moveMessage = regexp.MustCompile(`^move: (add|remove) \S+ \((?:from|moved to) ([^)]+)\)`)
+
+// Log returns the last limit commits that touched the catalog at path,
+// newest first, each with its book-level changes. Adds and removes made by
+// a move between shelves are reported as moves. If id is not empty, only
+// that book's changes are kept, and commits without any are dropped.
+func Log(c Client, owner, repo, path string, limit int, id string) ([]Entry, error) {
+	commits, err := c.ListCommits(owner, repo, path, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing commits: %w", err)
+	}
+
+	load := loader(c, owner, repo, path)
+	var entries []Entry
+	for i, cm := range commits {
+		after, err := load(cm.SHA)
+		if err != nil {
+			return nil, err
+		}
+		// Commits only list those touching the catalog, so the next one
+		// holds the catalog as it was before this commit.
+		var before []catalog.Book
+		switch {
+		case i+1 < len(commits):
+			before, err = load(commits[i+1].SHA)
+		case len(cm.Parents) > 0:
+			before, err = load(cm.Parents[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		changes := markMoves(catalog.Diff(before, after), cm.Message)
+		if id != "" {
+			changes = onlyBook(changes, id)
+			if len(changes) == 0 {
+				continue
+			}
+		}
+		entries = append(entries, Entry{Commit: cm, Changes: changes})
+	}
+	return entries, nil
+}
+
+// Diff returns the book-level changes to the catalog at path between two
+// revisions (commit SHAs, branches or tags). An empty revision is the
+// default branch.
+func Diff(c Client, owner, repo, path, from, to string) ([]catalog.Change, error) {
+	load := loader(c, owner, repo, path)
+	before, err := load(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := load(to)
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Diff(before, after), nil
+}
+
+// loader returns a function that reads the catalog at a revision, once per
+// revision. A revision without the catalog gives an empty one.
+func loader(c Client, owner, repo, path string) func(ref string) ([]catalog.Book, error) {
+	seen := map[string][]catalog.Book{}
+	return func(ref string) ([]catalog.Book, error) {
+		if books, ok := seen[ref]; ok {
+			return books, nil
+		}
+		data, _, err := c.GetFileContent(owner, repo, path, ref)
+		if errors.Is(err, github.ErrNotFound) {
+			seen[ref] = nil
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s at %s: %w", path, refName(ref), err)
+		}
+		books, err := catalog.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s at %s: %w", path, refName(ref), err)
+		}
+		seen[ref] = books
+		return books, nil
+	}
+}
+
+// markMoves turns the add or remove of a cross-shelf move commit into a
+// move, naming the other shelf.
+func markMoves(changes []catalog.Change, message string) []catalog.Change {
+	m := moveMessage.FindStringSubmatch(message)
+	if m == nil {
+		return changes
+	}
+	for i := range changes {
+		switch {
+		case m[1] == "add" && changes[i].Kind == catalog.ChangeAdded:
+			changes[i].Kind, changes[i].From = catalog.ChangeMoved, "shelf "+m[2]
+		case m[1] == "remove" && changes[i].Kind == catalog.ChangeRemoved:
+			changes[i].Kind, changes[i].To = catalog.ChangeMoved, "shelf "+m[2]
+		}
+	}
+	return changes
+}
+
+func onlyBook(changes []catalog.Change, id string) []catalog.Change {
+	var out []catalog.Change
+	for _, ch := range changes {
+		if ch.ID == id {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// Describe returns a one-line description of a change, without the book.
+func Describe(ch catalog.Change) string {
+	switch ch.Kind {
+	case catalog.ChangeAdded:
+		return "added"
+	case catalog.ChangeRemoved:
+		return "removed"
+	case catalog.ChangeEdited:
+		return "edited " + strings.Join(ch.Fields, ", ")
+	case catalog.ChangeMoved:
+		switch {
+		case ch.From != "" && ch.To != "":
+			return "moved " + ch.From + " → " + ch.To
+		case ch.From != "":
+			return "moved from " + ch.From
+		default:
+			return "moved to " + ch.To
+		}
+	}
+	return string(ch.Kind)
+}
+
+// refName names a revision in messages: HEAD for the default branch, an
+// abbreviated SHA, or the branch or tag as is.
+func refName(ref string) string {
+	if ref == "" {
+		return "HEAD"
+	}
+	if len(ref) == 40 && strings.Trim(ref, "0123456789abcdef") == "" {
+		return ref[:7]
+	}
+	return ref
+}
+
+// Short abbreviates a commit SHA to seven characters.
+func Short(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
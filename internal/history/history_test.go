@@ -0,0 +1,107 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+const (
+	catalogV1 = `
+- id: sicp
+  title: SICP
+  format: pdf
+  source: {type: github_release, owner: me, repo: shelf-books, release: library, asset: sicp.pdf}
+`
+	catalogV2 = catalogV1 + `
+- id: ostep
+  title: OSTEP
+  format: pdf
+  source: {type: github_release, owner: me, repo: shelf-books, release: library, asset: ostep.pdf}
+`
+	catalogV3 = `
+- id: sicp
+  title: SICP
+  tags: [lisp]
+  format: pdf
+  source: {type: github_release, owner: me, repo: shelf-books, release: archive, asset: sicp.pdf}
+`
+)
+
+func newFake() *testutil.GitHub {
+	c := testutil.NewGitHub()
+	r := c.Repo("me", "shelf-books")
+	r.Commits = []github.Commit{
+		{SHA: "c4", Message: "move: remove sicp (moved to papers)", Parents: []string{"c3"}},
+		{SHA: "c3", Message: "edit: update sicp metadata\n\nmore", Parents: []string{"c2x"}},
+		{SHA: "c2", Message: "add: ostep — OSTEP", Parents: []string{"c1"}},
+		{SHA: "c1", Message: "add: sicp — SICP", Parents: []string{"c0"}},
+	}
+	for ref, data := range map[string]string{"c1": catalogV1, "c2": catalogV2, "c3": catalogV3, "c4": "[]"} {
+		r.Revisions[ref] = map[string][]byte{"catalog.yml": []byte(data)}
+	}
+	return c
+}
+
+func TestLog(t *testing.T) {
+	entries, err := Log(newFake(), "me", "shelf-books", "catalog.yml", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		for _, ch := range e.Changes {
+			got = append(got, e.Commit.SHA+" "+ch.ID+" "+Describe(ch))
+		}
+	}
+	want := []string{
+		"c4 sicp moved to shelf papers",
+		"c3 sicp moved me/shelf-books@library → me/shelf-books@archive",
+		"c3 sicp edited tags",
+		"c3 ostep removed",
+		"c2 ostep added",
+		"c1 sicp added", // c0 has no catalog
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Log =\n%v\nwant\n%v", got, want)
+	}
+	if entries[1].Subject() != "edit: update sicp metadata" {
+		t.Errorf("Subject = %q", entries[1].Subject())
+	}
+}
+
+func TestLog_OneBook(t *testing.T) {
+	entries, err := Log(newFake(), "me", "shelf-books", "catalog.yml", 0, "ostep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Commit.SHA != "c3" || entries[1].Commit.SHA != "c2" {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestLog_LimitReadsParent(t *testing.T) {
+	f := newFake()
+	entries, err := Log(f, "me", "shelf-books", "catalog.yml", 3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The oldest listed commit (c2) is compared with its parent c1.
+	last := entries[len(entries)-1]
+	if last.Commit.SHA != "c2" || len(last.Changes) != 1 || last.Changes[0].Kind != catalog.ChangeAdded {
+		t.Errorf("last = %+v", last)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	changes, err := Diff(newFake(), "me", "shelf-books", "catalog.yml", "c1", "c3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Kind != catalog.ChangeMoved || changes[1].Kind != catalog.ChangeEdited {
+		t.Errorf("changes = %+v", changes)
+	}
+}
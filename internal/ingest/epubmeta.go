@@ -0,0 +1,323 @@
+package ingest
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxEPUBEntrySize bounds how much of a single zip entry we read into memory.
+// OPF files are tiny and cover images rarely exceed a few MB.
+const maxEPUBEntrySize = 16 * 1024 * 1024
+
+// EPUBMetadata holds metadata extracted from an EPUB's OPF package document.
+type EPUBMetadata struct {
+	Title       string
+	Creators    []string
+	Date        string
+	Language    string
+	Identifiers []string
+	Subjects    []string
+	Series      string
+	SeriesIndex string
+	Publisher   string
+
+	// CoverHref is the zip path of the declared cover image, if any.
+	CoverHref string
+	// CoverMediaType is the media type of the cover image.
+	CoverMediaType string
+}
+
+// Author returns the creators joined for display.
+func (m *EPUBMetadata) Author() string {
+	return strings.Join(m.Creators, ", ")
+}
+
+// Year returns the four-digit year from Date, or 0.
+func (m *EPUBMetadata) Year() int {
+	if len(m.Date) < 4 {
+		return 0
+	}
+	y, err := strconv.Atoi(m.Date[:4])
+	if err != nil {
+		return 0
+	}
+	return y
+}
+
+// Tags returns the subjects, and the series as a "series:<name>" tag,
+// as tags for the catalog.
+func (m *EPUBMetadata) Tags() []string {
+	tags := append([]string(nil), m.Subjects...)
+	if m.Series != "" {
+		tags = append(tags, "series:"+m.Series)
+	}
+	return tags
+}
+
+// ISBN returns the first identifier that looks like an ISBN, or "".
+func (m *EPUBMetadata) ISBN() string {
+	for _, id := range m.Identifiers {
+		v := strings.TrimPrefix(strings.ToLower(id), "urn:isbn:")
+		v = strings.TrimPrefix(v, "isbn:")
+		digits := strings.Map(func(r rune) rune {
+			if (r >= '0' && r <= '9') || r == 'x' {
+				return r
+			}
+			if r == '-' || r == ' ' {
+				return -1
+			}
+			return '?'
+		}, v)
+		if (len(digits) == 10 || len(digits) == 13) && !strings.Contains(digits, "?") {
+			return strings.ToUpper(digits)
+		}
+	}
+	return ""
+}
+
+// container.xml
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// OPF package document (only the parts we use).
+type opfPackage struct {
+	Metadata struct {
+		Titles      []string        `xml:"title"`
+		Creators    []opfCreator    `xml:"creator"`
+		Dates       []string        `xml:"date"`
+		Languages   []string        `xml:"language"`
+		Identifiers []opfIdentifier `xml:"identifier"`
+		Subjects    []string        `xml:"subject"`
+		Publishers  []string        `xml:"publisher"`
+		Metas       []opfMeta       `xml:"meta"`
+	} `xml:"metadata"`
+	Manifest []opfItem `xml:"manifest>item"`
+}
+
+type opfCreator struct {
+	Value string `xml:",chardata"`
+	Role  string `xml:"role,attr"`
+}
+
+type opfIdentifier struct {
+	Value  string `xml:",chardata"`
+	Scheme string `xml:"scheme,attr"`
+}
+
+type opfMeta struct {
+	Name     string `xml:"name,attr"`
+	Content  string `xml:"content,attr"`
+	Property string `xml:"property,attr"`
+	Refines  string `xml:"refines,attr"`
+	ID       string `xml:"id,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type opfItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+// ExtractEPUBMetadata reads container.xml and the OPF package document of an
+// EPUB file and returns its metadata. Both EPUB 2 and EPUB 3 conventions
+// are understood (including Calibre's series meta tags).
+func ExtractEPUBMetadata(epubPath string) (*EPUBMetadata, error) {
+	zr, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening epub: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	return readEPUBMetadata(&zr.Reader)
+}
+
+// ExtractEPUBCover returns the raw bytes and media type of the EPUB's
+// declared cover image.
+func ExtractEPUBCover(epubPath string) ([]byte, string, error) {
+	zr, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("opening epub: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	meta, err := readEPUBMetadata(&zr.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	if meta.CoverHref == "" {
+		return nil, "", fmt.Errorf("epub declares no cover image")
+	}
+	data, err := readZipEntry(&zr.Reader, meta.CoverHref)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, meta.CoverMediaType, nil
+}
+
+func readEPUBMetadata(zr *zip.Reader) (*EPUBMetadata, error) {
+	containerData, err := readZipEntry(zr, "META-INF/container.xml")
+	if err != nil {
+		return nil, err
+	}
+	var container epubContainer
+	if err := xml.Unmarshal(containerData, &container); err != nil {
+		return nil, fmt.Errorf("parsing container.xml: %w", err)
+	}
+
+	opfPath := ""
+	for _, rf := range container.Rootfiles {
+		if rf.MediaType == "" || rf.MediaType == "application/oebps-package+xml" {
+			opfPath = rf.FullPath
+			break
+		}
+	}
+	if opfPath == "" {
+		return nil, fmt.Errorf("container.xml lists no package document")
+	}
+
+	opfData, err := readZipEntry(zr, opfPath)
+	if err != nil {
+		return nil, err
+	}
+	var pkg opfPackage
+	if err := xml.Unmarshal(opfData, &pkg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", opfPath, err)
+	}
+
+	md := pkg.Metadata
+	meta := &EPUBMetadata{
+		Title:     sanitizeForTerminal(strings.TrimSpace(first(md.Titles))),
+		Date:      strings.TrimSpace(first(md.Dates)),
+		Language:  strings.TrimSpace(first(md.Languages)),
+		Publisher: sanitizeForTerminal(strings.TrimSpace(first(md.Publishers))),
+	}
+
+	for _, c := range md.Creators {
+		// Skip editors, illustrators etc. when a role is given.
+		if c.Role != "" && c.Role != "aut" {
+			continue
+		}
+		if v := strings.TrimSpace(c.Value); v != "" {
+			meta.Creators = append(meta.Creators, sanitizeForTerminal(v))
+		}
+	}
+	for _, id := range md.Identifiers {
+		v := strings.TrimSpace(id.Value)
+		if v == "" {
+			continue
+		}
+		if id.Scheme != "" && !strings.Contains(v, ":") {
+			v = strings.ToLower(id.Scheme) + ":" + v
+		}
+		meta.Identifiers = append(meta.Identifiers, v)
+	}
+	for _, s := range md.Subjects {
+		if v := strings.TrimSpace(s); v != "" {
+			meta.Subjects = append(meta.Subjects, v)
+		}
+	}
+
+	coverID := ""
+	collectionID := ""
+	for _, m := range md.Metas {
+		switch {
+		case m.Name == "cover":
+			coverID = m.Content
+		case m.Name == "calibre:series":
+			meta.Series = m.Content
+		case m.Name == "calibre:series_index":
+			meta.SeriesIndex = m.Content
+		case m.Property == "belongs-to-collection" && meta.Series == "":
+			meta.Series = strings.TrimSpace(m.Value)
+			collectionID = m.ID
+		}
+	}
+	if collectionID != "" && meta.SeriesIndex == "" {
+		for _, m := range md.Metas {
+			if m.Property == "group-position" && m.Refines == "#"+collectionID {
+				meta.SeriesIndex = strings.TrimSpace(m.Value)
+			}
+		}
+	}
+
+	if item := findCoverItem(pkg.Manifest, coverID); item != nil {
+		meta.CoverHref = resolveHref(opfPath, item.Href)
+		meta.CoverMediaType = item.MediaType
+	}
+
+	return meta, nil
+}
+
+// findCoverItem locates the cover image in the manifest using, in order:
+// the EPUB 3 cover-image property, the EPUB 2 <meta name="cover"> id, and
+// finally any image item whose id or href mentions "cover".
+func findCoverItem(items []opfItem, coverID string) *opfItem {
+	for i := range items {
+		if strings.Contains(" "+items[i].Properties+" ", " cover-image ") {
+			return &items[i]
+		}
+	}
+	if coverID != "" {
+		for i := range items {
+			if items[i].ID == coverID && strings.HasPrefix(items[i].MediaType, "image/") {
+				return &items[i]
+			}
+		}
+	}
+	for i := range items {
+		if !strings.HasPrefix(items[i].MediaType, "image/") {
+			continue
+		}
+		if strings.Contains(strings.ToLower(items[i].ID), "cover") ||
+			strings.Contains(strings.ToLower(items[i].Href), "cover") {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+// resolveHref resolves a manifest href relative to the OPF location.
+func resolveHref(opfPath, href string) string {
+	if u, err := url.PathUnescape(href); err == nil {
+		href = u
+	}
+	return path.Clean(path.Join(path.Dir(opfPath), href))
+}
+
+func readZipEntry(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", name, err)
+		}
+		defer func() { _ = rc.Close() }()
+		data, err := io.ReadAll(io.LimitReader(rc, maxEPUBEntrySize))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("epub entry %s not found", name)
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
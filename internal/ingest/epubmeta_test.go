@@ -0,0 +1,177 @@
+package ingest
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testContainerXML = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+const testOPF2 = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>The Dispossessed</dc:title>
+    <dc:creator opf:role="aut">Ursula K. Le Guin</dc:creator>
+    <dc:creator opf:role="ill">Some Illustrator</dc:creator>
+    <dc:date>1974-05-01</dc:date>
+    <dc:language>en</dc:language>
+    <dc:identifier id="uid" opf:scheme="ISBN">978-0-06-051275-4</dc:identifier>
+    <dc:identifier opf:scheme="uuid">urn:uuid:1234</dc:identifier>
+    <dc:subject>Science Fiction</dc:subject>
+    <dc:subject>Utopias</dc:subject>
+    <dc:publisher>Harper</dc:publisher>
+    <meta name="cover" content="cover-img"/>
+    <meta name="calibre:series" content="Hainish Cycle"/>
+    <meta name="calibre:series_index" content="5"/>
+  </metadata>
+  <manifest>
+    <item id="cover-img" href="images/My%20Cover.jpg" media-type="image/jpeg"/>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+</package>`
+
+const testOPF3 = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>A Wizard of Earthsea</dc:title>
+    <dc:creator>Ursula K. Le Guin</dc:creator>
+    <meta property="belongs-to-collection" id="c1">Earthsea</meta>
+    <meta refines="#c1" property="group-position">1</meta>
+  </metadata>
+  <manifest>
+    <item id="img" href="../cover.png" media-type="image/png" properties="cover-image"/>
+  </manifest>
+</package>`
+
+func writeTestEPUB(t *testing.T, files map[string]string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestExtractEPUBMetadata_EPUB2(t *testing.T) {
+	p := writeTestEPUB(t, map[string]string{
+		"mimetype":                  "application/epub+zip",
+		"META-INF/container.xml":    testContainerXML,
+		"OEBPS/content.opf":         testOPF2,
+		"OEBPS/images/My Cover.jpg": "fake-jpeg",
+	})
+
+	meta, err := ExtractEPUBMetadata(p)
+	if err != nil {
+		t.Fatalf("ExtractEPUBMetadata() error = %v", err)
+	}
+
+	if meta.Title != "The Dispossessed" {
+		t.Errorf("Title = %q", meta.Title)
+	}
+	if meta.Author() != "Ursula K. Le Guin" {
+		t.Errorf("Author() = %q, illustrators should be skipped", meta.Author())
+	}
+	if meta.Year() != 1974 {
+		t.Errorf("Year() = %d", meta.Year())
+	}
+	if meta.Language != "en" || meta.Publisher != "Harper" {
+		t.Errorf("Language/Publisher = %q/%q", meta.Language, meta.Publisher)
+	}
+	if meta.ISBN() != "9780060512754" {
+		t.Errorf("ISBN() = %q", meta.ISBN())
+	}
+	if len(meta.Subjects) != 2 {
+		t.Errorf("Subjects = %v", meta.Subjects)
+	}
+	if meta.Series != "Hainish Cycle" || meta.SeriesIndex != "5" {
+		t.Errorf("Series = %q #%q", meta.Series, meta.SeriesIndex)
+	}
+	if tags := meta.Tags(); len(tags) != 3 || tags[2] != "series:Hainish Cycle" {
+		t.Errorf("Tags() = %v", tags)
+	}
+	if meta.CoverHref != "OEBPS/images/My Cover.jpg" {
+		t.Errorf("CoverHref = %q", meta.CoverHref)
+	}
+
+	data, mediaType, err := ExtractEPUBCover(p)
+	if err != nil {
+		t.Fatalf("ExtractEPUBCover() error = %v", err)
+	}
+	if string(data) != "fake-jpeg" || mediaType != "image/jpeg" {
+		t.Errorf("cover = %q (%s)", data, mediaType)
+	}
+}
+
+func TestExtractEPUBMetadata_EPUB3(t *testing.T) {
+	p := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf":      testOPF3,
+		"cover.png":              "fake-png",
+	})
+
+	meta, err := ExtractEPUBMetadata(p)
+	if err != nil {
+		t.Fatalf("ExtractEPUBMetadata() error = %v", err)
+	}
+	if meta.Series != "Earthsea" || meta.SeriesIndex != "1" {
+		t.Errorf("Series = %q #%q", meta.Series, meta.SeriesIndex)
+	}
+	if meta.CoverHref != "cover.png" || meta.CoverMediaType != "image/png" {
+		t.Errorf("cover = %q (%s)", meta.CoverHref, meta.CoverMediaType)
+	}
+	if meta.Year() != 0 {
+		t.Errorf("Year() = %d, want 0 without a date", meta.Year())
+	}
+}
+
+func TestExtractEPUBMetadata_MissingContainer(t *testing.T) {
+	p := writeTestEPUB(t, map[string]string{"mimetype": "application/epub+zip"})
+	if _, err := ExtractEPUBMetadata(p); err == nil {
+		t.Error("expected error for EPUB without container.xml")
+	}
+}
+
+func TestExtractEPUBMetadata_NotZip(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bad.epub")
+	if err := os.WriteFile(p, []byte("not a zip"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExtractEPUBMetadata(p); err == nil {
+		t.Error("expected error for non-zip file")
+	}
+}
+
+func TestExtractEPUBCover_NoCover(t *testing.T) {
+	opf := `<package><metadata><title>x</title></metadata><manifest/></package>`
+	p := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf":      opf,
+	})
+	if _, _, err := ExtractEPUBCover(p); err == nil {
+		t.Error("expected error when no cover is declared")
+	}
+}
@@ -0,0 +1,191 @@
+package ingest
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"sort"
+)
+
+// minCoverImageSide skips logos and icons when looking for a cover image.
+const minCoverImageSide = 64
+
+// maxFormDepth bounds how far nested form XObjects are searched for images.
+const maxFormDepth = 2
+
+// ExtractPDFCoverImage returns the largest image drawn on the first page of
+// a PDF along with its media type. DCT images are returned as the JPEG data
+// stored in the file; 8-bit Flate images in DeviceGray or DeviceRGB are
+// re-encoded as PNG. Scanned books and most ebooks with a cover page store
+// the cover this way, so no renderer is needed.
+func ExtractPDFCoverImage(path string) ([]byte, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, "", err
+	}
+	pr, err := newPDFReader(f, stat.Size())
+	if err != nil {
+		return nil, "", err
+	}
+	if pr.trailer[pdfName("Encrypt")] != nil {
+		return nil, "", fmt.Errorf("pdf is encrypted")
+	}
+
+	res := pr.firstPageResources()
+	if res == nil {
+		return nil, "", fmt.Errorf("first page not found")
+	}
+
+	var best *pdfStream
+	bestArea := 0
+	for _, img := range pr.images(res, 0) {
+		w, _ := pdfInt(pr.resolve(img.dict[pdfName("Width")]))
+		h, _ := pdfInt(pr.resolve(img.dict[pdfName("Height")]))
+		if w < minCoverImageSide || h < minCoverImageSide || !pr.supportedImage(img.dict) {
+			continue
+		}
+		if w*h > bestArea {
+			img := img
+			best, bestArea = &img, w*h
+		}
+	}
+	if best == nil {
+		return nil, "", fmt.Errorf("no usable image on first page")
+	}
+
+	data, err := pr.streamData(*best)
+	if err != nil {
+		return nil, "", err
+	}
+	if isDCT(best.dict) {
+		return data, "image/jpeg", nil
+	}
+	return pr.encodeRawImage(best.dict, data)
+}
+
+// firstPageResources walks the page tree down its first branch and returns
+// the resources of the first page, including inherited ones.
+func (pr *pdfReader) firstPageResources() pdfDict {
+	root := pr.resolveDict(pr.trailer[pdfName("Root")])
+	node := pr.resolveDict(root[pdfName("Pages")])
+	var res pdfDict
+	for depth := 0; node != nil && depth < pdfMaxResolveDepth; depth++ {
+		if r := pr.resolveDict(node[pdfName("Resources")]); r != nil {
+			res = r
+		}
+		kids, _ := pr.resolve(node[pdfName("Kids")]).(pdfArray)
+		if len(kids) == 0 {
+			return res
+		}
+		node = pr.resolveDict(kids[0])
+	}
+	return nil
+}
+
+// images lists image XObjects in res, descending into form XObjects.
+func (pr *pdfReader) images(res pdfDict, depth int) []pdfStream {
+	xobjs := pr.resolveDict(res[pdfName("XObject")])
+	names := make([]string, 0, len(xobjs))
+	for name := range xobjs {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	var out []pdfStream
+	for _, name := range names {
+		stm, ok := pr.resolve(xobjs[pdfName(name)]).(pdfStream)
+		if !ok {
+			continue
+		}
+		switch pr.resolve(stm.dict[pdfName("Subtype")]) {
+		case pdfName("Image"):
+			out = append(out, stm)
+		case pdfName("Form"):
+			if depth < maxFormDepth {
+				out = append(out, pr.images(pr.resolveDict(stm.dict[pdfName("Resources")]), depth+1)...)
+			}
+		}
+	}
+	return out
+}
+
+func isDCT(d pdfDict) bool {
+	switch f := d[pdfName("Filter")].(type) {
+	case pdfName:
+		return f == "DCTDecode" || f == "DCT"
+	case pdfArray:
+		if len(f) > 0 {
+			last, _ := f[len(f)-1].(pdfName)
+			return last == "DCTDecode" || last == "DCT"
+		}
+	}
+	return false
+}
+
+// supportedImage reports whether an image can be turned into a cover:
+// any JPEG, or 8-bit gray/RGB samples.
+func (pr *pdfReader) supportedImage(d pdfDict) bool {
+	if isDCT(d) {
+		return true
+	}
+	if d[pdfName("ImageMask")] == true {
+		return false
+	}
+	bpc, _ := pdfInt(pr.resolve(d[pdfName("BitsPerComponent")]))
+	return bpc == 8 && pr.colorComponents(d) > 0
+}
+
+// colorComponents returns 1 or 3 for gray and RGB color spaces, 0 otherwise.
+func (pr *pdfReader) colorComponents(d pdfDict) int {
+	cs := pr.resolve(d[pdfName("ColorSpace")])
+	if arr, ok := cs.(pdfArray); ok && len(arr) >= 2 && arr[0] == pdfName("ICCBased") {
+		if stm, ok := pr.resolve(arr[1]).(pdfStream); ok {
+			if n, _ := pdfInt(pr.resolve(stm.dict[pdfName("N")])); n == 1 || n == 3 {
+				return n
+			}
+		}
+		return 0
+	}
+	switch cs {
+	case pdfName("DeviceGray"), pdfName("G"), pdfName("CalGray"):
+		return 1
+	case pdfName("DeviceRGB"), pdfName("RGB"), pdfName("CalRGB"):
+		return 3
+	}
+	return 0
+}
+
+func (pr *pdfReader) encodeRawImage(d pdfDict, data []byte) ([]byte, string, error) {
+	w, _ := pdfInt(pr.resolve(d[pdfName("Width")]))
+	h, _ := pdfInt(pr.resolve(d[pdfName("Height")]))
+	n := pr.colorComponents(d)
+	if len(data) < w*h*n {
+		return nil, "", fmt.Errorf("image data too short")
+	}
+
+	var img image.Image
+	if n == 1 {
+		img = &image.Gray{Pix: data[:w*h], Stride: w, Rect: image.Rect(0, 0, w, h)}
+	} else {
+		rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i := 0; i < w*h; i++ {
+			copy(rgba.Pix[i*4:i*4+3], data[i*3:i*3+3])
+			rgba.Pix[i*4+3] = 0xff
+		}
+		img = rgba
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
@@ -0,0 +1,95 @@
+package ingest
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func jpegBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func imageObj(data []byte, w, h int, extra string) string {
+	return fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 8 %s /Length %d >>\nstream\n%s\nendstream",
+		w, h, extra, len(data), data)
+}
+
+func TestExtractPDFCoverImage_LargestJPEG(t *testing.T) {
+	small := jpegBytes(t, 80, 80)
+	large := jpegBytes(t, 300, 450)
+
+	data := buildClassicPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		// Resources are inherited from the page tree node.
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /XObject << /Im1 4 0 R /Fm1 6 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		imageObj(small, 80, 80, "/ColorSpace /DeviceGray /Filter /DCTDecode"),
+		imageObj(large, 300, 450, "/ColorSpace /DeviceGray /Filter [/DCTDecode]"),
+		"<< /Type /XObject /Subtype /Form /Resources << /XObject << /Im2 5 0 R >> >> /Length 0 >>\nstream\n\nendstream",
+	}, "/Root 1 0 R")
+
+	got, mediaType, err := ExtractPDFCoverImage(writePDF(t, data))
+	if err != nil {
+		t.Fatalf("ExtractPDFCoverImage: %v", err)
+	}
+	if mediaType != "image/jpeg" {
+		t.Errorf("mediaType = %q", mediaType)
+	}
+	if !bytes.Equal(got, large) {
+		t.Error("expected the larger image found via the form XObject")
+	}
+}
+
+func TestExtractPDFCoverImage_FlateRGB(t *testing.T) {
+	const w, h = 64, 96
+	raw := bytes.Repeat([]byte{0xff, 0x00, 0x00}, w*h)
+	stm := deflate(t, raw)
+
+	data := buildClassicPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /XObject << /Im1 4 0 R >> >> >>",
+		imageObj(stm, w, h, "/ColorSpace /DeviceRGB /Filter /FlateDecode"),
+	}, "/Root 1 0 R")
+
+	got, mediaType, err := ExtractPDFCoverImage(writePDF(t, data))
+	if err != nil {
+		t.Fatalf("ExtractPDFCoverImage: %v", err)
+	}
+	if mediaType != "image/png" {
+		t.Fatalf("mediaType = %q, want image/png", mediaType)
+	}
+	img, err := png.Decode(bytes.NewReader(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != w || img.Bounds().Dy() != h {
+		t.Errorf("bounds = %v", img.Bounds())
+	}
+	if r, g, _, _ := img.At(10, 10).RGBA(); r != 0xffff || g != 0 {
+		t.Errorf("pixel = %v, want red", img.At(10, 10))
+	}
+}
+
+func TestExtractPDFCoverImage_NoImage(t *testing.T) {
+	data := buildClassicPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /XObject << /Im1 4 0 R >> >> >>",
+		// Too small to be a cover.
+		imageObj(jpegBytes(t, 16, 16), 16, 16, "/Filter /DCTDecode"),
+	}, "/Root 1 0 R")
+
+	if _, _, err := ExtractPDFCoverImage(writePDF(t, data)); err == nil {
+		t.Error("expected an error when the page has no usable image")
+	}
+}
@@ -2,6 +2,7 @@ package ingest
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -10,14 +11,31 @@ import (
 
 // PDFMetadata holds extracted PDF metadata
 type PDFMetadata struct {
-	Title   string
-	Author  string
-	Subject string
+	Title        string
+	Author       string
+	Subject      string
+	Keywords     string
+	Creator      string
+	Producer     string
+	CreationDate string
+
+	// Version is the PDF version from the header or catalog, e.g. "1.7".
+	Version string
+	// PageCount is the number of pages, or 0 if unknown.
+	PageCount int
+	// Encrypted reports whether the document is encrypted. Metadata
+	// strings of encrypted documents are not readable without the key,
+	// so only PageCount and Version are filled in.
+	Encrypted bool
 }
 
-// ExtractPDFMetadata attempts to extract basic metadata from a PDF file.
-// This is a best-effort implementation using stdlib - it works for most PDFs
-// but may fail on encrypted, linearized, or heavily compressed PDFs.
+// ExtractPDFMetadata extracts metadata from a PDF file.
+//
+// The file is read through its cross-reference data, so incremental
+// updates, xref streams and compressed object streams are handled, and
+// XMP metadata fills any gaps left by the Info dictionary. Damaged files
+// fall back to scanning the head and tail of the file for an Info
+// dictionary. Unparseable files yield empty metadata, not an error.
 func ExtractPDFMetadata(path string) (*PDFMetadata, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -25,6 +43,40 @@ func ExtractPDFMetadata(path string) (*PDFMetadata, error) {
 	}
 	defer func() { _ = f.Close() }()
 
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &PDFMetadata{}
+	if pr, err := newPDFReader(f, stat.Size()); err == nil {
+		meta = pr.metadata()
+	}
+	if meta.Encrypted || (meta.Title != "" && meta.Author != "") {
+		return meta, nil
+	}
+
+	// Fill gaps from a plain text scan.
+	scanned := scanPDFMetadata(f, stat.Size())
+	if meta.Title == "" {
+		meta.Title = scanned.Title
+	}
+	if meta.Author == "" {
+		meta.Author = scanned.Author
+	}
+	if meta.Subject == "" {
+		meta.Subject = scanned.Subject
+	}
+	return meta, nil
+}
+
+// scanPDFMetadata looks for Info dictionary entries in the first 500 lines
+// and the last 64KB of the file without parsing the object structure.
+func scanPDFMetadata(f *os.File, size int64) *PDFMetadata {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return &PDFMetadata{}
+	}
+
 	// Read first 64KB looking for Info dictionary
 	// Most PDFs have metadata near the beginning or in the trailer
 	scanner := bufio.NewScanner(f)
@@ -46,9 +98,8 @@ func ExtractPDFMetadata(path string) (*PDFMetadata, error) {
 	text := content.String()
 
 	// Also read the trailer (last 64KB)
-	stat, err := f.Stat()
-	if err == nil && stat.Size() > 64*1024 {
-		if _, err := f.Seek(-64*1024, 2); err == nil {
+	if size > 64*1024 {
+		if _, err := f.Seek(-64*1024, io.SeekEnd); err == nil {
 			trailerScanner := bufio.NewScanner(f)
 			trailerScanner.Buffer(make([]byte, 64*1024), 64*1024)
 			var trailer strings.Builder
@@ -68,7 +119,100 @@ func ExtractPDFMetadata(path string) (*PDFMetadata, error) {
 		Title:   extractField(text, "Title"),
 		Author:  extractField(text, "Author"),
 		Subject: extractField(text, "Subject"),
-	}, nil
+	}
+}
+
+// pdfVersionRe matches the version in the %PDF-x.y header.
+var pdfVersionRe = regexp.MustCompile(`%PDF-(\d\.\d)`)
+
+// metadata assembles PDFMetadata from the Info dictionary, the catalog
+// and its XMP stream.
+func (pr *pdfReader) metadata() *PDFMetadata {
+	meta := &PDFMetadata{}
+
+	if m := pdfVersionRe.FindSubmatch(pr.readAt(0, 1024)); m != nil {
+		meta.Version = string(m[1])
+	}
+
+	root := pr.resolveDict(pr.trailer[pdfName("Root")])
+	if v, ok := pr.resolve(root[pdfName("Version")]).(pdfName); ok && v > pdfName(meta.Version) {
+		meta.Version = string(v)
+	}
+	if pages := pr.resolveDict(root[pdfName("Pages")]); pages != nil {
+		if n, ok := pdfInt(pr.resolve(pages[pdfName("Count")])); ok && n > 0 {
+			meta.PageCount = n
+		}
+	}
+
+	if pr.trailer[pdfName("Encrypt")] != nil {
+		meta.Encrypted = true
+		return meta
+	}
+
+	info := pr.resolveDict(pr.trailer[pdfName("Info")])
+	str := func(key string) string {
+		s, _ := pr.resolve(info[pdfName(key)]).(pdfString)
+		return pdfTextString(s)
+	}
+	meta.Title = str("Title")
+	meta.Author = str("Author")
+	meta.Subject = str("Subject")
+	meta.Keywords = str("Keywords")
+	meta.Creator = str("Creator")
+	meta.Producer = str("Producer")
+	meta.CreationDate = str("CreationDate")
+
+	if stm, ok := pr.resolve(root[pdfName("Metadata")]).(pdfStream); ok {
+		if data, err := pr.streamData(stm); err == nil {
+			if x, err := parseXMP(data); err == nil {
+				meta.mergeXMP(x)
+			}
+		}
+	}
+	return meta
+}
+
+// mergeXMP fills fields the Info dictionary left empty.
+func (m *PDFMetadata) mergeXMP(x *xmpMetadata) {
+	fill := func(dst *string, v string) {
+		if *dst == "" {
+			*dst = sanitizeForTerminal(strings.TrimSpace(v))
+		}
+	}
+	fill(&m.Title, x.Title)
+	fill(&m.Author, strings.Join(x.Creators, ", "))
+	fill(&m.Subject, x.Description)
+	fill(&m.Keywords, x.Keywords)
+	if m.Keywords == "" && len(x.Subjects) > 0 {
+		m.Keywords = sanitizeForTerminal(strings.Join(x.Subjects, ", "))
+	}
+	fill(&m.Creator, x.CreatorTool)
+	fill(&m.Producer, x.Producer)
+	fill(&m.CreationDate, x.CreateDate)
+}
+
+// pdfTextString decodes a PDF text string: UTF-16BE or UTF-8 when marked
+// with a byte order mark, PDFDocEncoding (treated as Latin-1) otherwise.
+func pdfTextString(b []byte) string {
+	var s string
+	switch {
+	case len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF:
+		b = b[2:]
+		u16 := make([]uint16, len(b)/2)
+		for i := range u16 {
+			u16[i] = uint16(b[i*2])<<8 | uint16(b[i*2+1])
+		}
+		s = string(utf16.Decode(u16))
+	case len(b) >= 3 && b[0] == 0xEF && b[1] == 0xBB && b[2] == 0xBF:
+		s = string(b[3:])
+	default:
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		s = string(r)
+	}
+	return sanitizeForTerminal(strings.TrimSpace(s))
 }
 
 // extractField looks for /FieldName (value) or /FieldName <hex> patterns
@@ -0,0 +1,322 @@
+package ingest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// PDF object model. Only what the metadata reader needs is represented:
+// names, numbers, strings, arrays, dictionaries, references and streams.
+type (
+	pdfName   string
+	pdfString []byte
+	pdfArray  []interface{}
+	pdfDict   map[pdfName]interface{}
+	pdfRef    struct{ num, gen int }
+	pdfStream struct {
+		dict   pdfDict
+		offset int64 // absolute offset of the first data byte
+	}
+	pdfKeyword string
+)
+
+// errPDFTruncated is returned when the parse window ends mid-object, so the
+// caller can retry with a larger window.
+var errPDFTruncated = errors.New("pdf: unexpected end of data")
+
+// pdfLexer tokenizes a byte slice of PDF syntax.
+type pdfLexer struct {
+	buf   []byte
+	pos   int
+	final bool // buf extends to the end of the file
+}
+
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.buf) {
+		c := l.buf[l.pos]
+		switch {
+		case isPDFWhitespace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.buf) && l.buf[l.pos] != '\n' && l.buf[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// readRegular reads a run of regular (non-delimiter, non-space) characters.
+func (l *pdfLexer) readRegular() []byte {
+	start := l.pos
+	for l.pos < len(l.buf) && !isPDFWhitespace(l.buf[l.pos]) && !isPDFDelimiter(l.buf[l.pos]) {
+		l.pos++
+	}
+	return l.buf[start:l.pos]
+}
+
+// parseObject parses one object. Indirect references ("n g R") are folded
+// into pdfRef values; keywords such as "stream" or "obj" are returned as
+// pdfKeyword for the caller to interpret.
+func (l *pdfLexer) parseObject() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.buf) {
+		return nil, errPDFTruncated
+	}
+
+	c := l.buf[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		return pdfName(decodeNameEscapes(l.readRegular())), nil
+	case c == '(':
+		return l.parseLiteralString()
+	case c == '<':
+		if l.pos+1 < len(l.buf) && l.buf[l.pos+1] == '<' {
+			l.pos += 2
+			return l.parseDict()
+		}
+		return l.parseHexString()
+	case c == '[':
+		l.pos++
+		return l.parseArray()
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.parseNumberOrRef()
+	}
+
+	word := l.readRegular()
+	if len(word) == 0 {
+		l.pos++
+		return nil, fmt.Errorf("pdf: unexpected character %q at %d", c, l.pos-1)
+	}
+	switch string(word) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return pdfKeyword(word), nil
+}
+
+func (l *pdfLexer) parseNumber() (interface{}, error) {
+	l.skipSpace()
+	tok := l.readRegular()
+	if len(tok) == 0 {
+		return nil, errPDFTruncated
+	}
+	if bytes.ContainsAny(tok, ".") {
+		f, err := strconv.ParseFloat(string(tok), 64)
+		if err != nil {
+			return nil, fmt.Errorf("pdf: bad number %q", tok)
+		}
+		return f, nil
+	}
+	n, err := strconv.ParseInt(string(tok), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: bad number %q", tok)
+	}
+	return n, nil
+}
+
+// parseNumberOrRef parses a number and looks ahead for the "gen R" suffix
+// of an indirect reference.
+func (l *pdfLexer) parseNumberOrRef() (interface{}, error) {
+	v, err := l.parseNumber()
+	if err != nil {
+		return nil, err
+	}
+	num, isInt := v.(int64)
+	if !isInt || num < 0 {
+		return v, nil
+	}
+
+	save := l.pos
+	l.skipSpace()
+	if l.pos < len(l.buf) && l.buf[l.pos] >= '0' && l.buf[l.pos] <= '9' {
+		genTok := l.readRegular()
+		if gen, err := strconv.Atoi(string(genTok)); err == nil {
+			l.skipSpace()
+			if l.pos < len(l.buf) && l.buf[l.pos] == 'R' &&
+				(l.pos+1 == len(l.buf) || isPDFWhitespace(l.buf[l.pos+1]) || isPDFDelimiter(l.buf[l.pos+1])) {
+				l.pos++
+				return pdfRef{num: int(num), gen: gen}, nil
+			}
+		}
+	}
+	l.pos = save
+	return v, nil
+}
+
+func (l *pdfLexer) parseArray() (pdfArray, error) {
+	var arr pdfArray
+	for {
+		v, err := l.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		if kw, ok := v.(pdfKeyword); ok && kw == "]" {
+			return arr, nil
+		}
+		arr = append(arr, v)
+	}
+}
+
+func (l *pdfLexer) parseDict() (pdfDict, error) {
+	d := pdfDict{}
+	for {
+		l.skipSpace()
+		if l.pos+1 < len(l.buf) && l.buf[l.pos] == '>' && l.buf[l.pos+1] == '>' {
+			l.pos += 2
+			return d, nil
+		}
+		k, err := l.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(pdfName)
+		if !ok {
+			return nil, fmt.Errorf("pdf: dictionary key is %T, not a name", k)
+		}
+		v, err := l.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		d[key] = v
+	}
+}
+
+func (l *pdfLexer) parseLiteralString() (pdfString, error) {
+	l.pos++ // '('
+	var out []byte
+	depth := 1
+	for l.pos < len(l.buf) {
+		c := l.buf[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return out, nil
+			}
+			out = append(out, c)
+		case '\\':
+			if l.pos >= len(l.buf) {
+				return nil, errPDFTruncated
+			}
+			e := l.buf[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				// Line continuation; swallow an optional LF.
+				if l.pos < len(l.buf) && l.buf[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.buf) && l.buf[l.pos] >= '0' && l.buf[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.buf[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return nil, errPDFTruncated
+}
+
+func (l *pdfLexer) parseHexString() (pdfString, error) {
+	l.pos++ // '<'
+	var out []byte
+	var hi byte
+	haveHi := false
+	for l.pos < len(l.buf) {
+		c := l.buf[l.pos]
+		l.pos++
+		if c == '>' {
+			if haveHi {
+				out = append(out, hi<<4)
+			}
+			return out, nil
+		}
+		if isPDFWhitespace(c) {
+			continue
+		}
+		v := hexValue(c)
+		if haveHi {
+			out = append(out, hi<<4|v)
+			haveHi = false
+		} else {
+			hi = v
+			haveHi = true
+		}
+	}
+	return nil, errPDFTruncated
+}
+
+// decodeNameEscapes resolves #xx escapes in a name token.
+func decodeNameEscapes(b []byte) string {
+	if !bytes.Contains(b, []byte("#")) {
+		return string(b)
+	}
+	var out []byte
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) {
+			out = append(out, hexValue(b[i+1])<<4|hexValue(b[i+2]))
+			i += 2
+			continue
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
+
+// pdfInt returns v as an int if it is numeric.
+func pdfInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
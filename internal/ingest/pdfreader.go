@@ -0,0 +1,776 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// pdfInitialWindow is the first read size when parsing an object.
+	pdfInitialWindow = 4 * 1024
+	// pdfMaxWindow bounds how far a single object or xref table may extend.
+	pdfMaxWindow = 64 * 1024 * 1024
+	// pdfMaxStream bounds decoded stream sizes (xref, object and XMP streams).
+	pdfMaxStream = 64 * 1024 * 1024
+	// pdfMaxResolveDepth guards against reference cycles.
+	pdfMaxResolveDepth = 32
+)
+
+// xrefEntry locates one object. Type 1 objects live at a byte offset;
+// type 2 objects are stored compressed inside an object stream.
+type xrefEntry struct {
+	typ    int
+	offset int64
+	stream int
+	index  int
+}
+
+// objStream is a decoded /Type /ObjStm stream.
+type objStream struct {
+	data    []byte
+	first   int
+	offsets []int // per index, relative to first
+	nums    []int
+}
+
+// pdfReader reads the object structure of a PDF file via its
+// cross-reference data. It never loads the whole file into memory.
+type pdfReader struct {
+	r       io.ReaderAt
+	size    int64
+	xref    map[int]xrefEntry
+	trailer pdfDict
+	objStms map[int]*objStream
+	objects map[int]interface{}
+	loading map[int]bool
+}
+
+func newPDFReader(r io.ReaderAt, size int64) (*pdfReader, error) {
+	pr := &pdfReader{
+		r:       r,
+		size:    size,
+		xref:    map[int]xrefEntry{},
+		objStms: map[int]*objStream{},
+		objects: map[int]interface{}{},
+		loading: map[int]bool{},
+	}
+
+	start, err := pr.findStartXref()
+	if err == nil {
+		err = pr.loadXrefChain(start)
+	}
+	if err != nil || pr.resolveDict(pr.trailer[pdfName("Root")]) == nil {
+		// Damaged or missing cross-reference data: rebuild it by scanning
+		// for "n g obj" headers, the way PDF viewers recover.
+		pr.xref = map[int]xrefEntry{}
+		pr.trailer = nil
+		pr.objects = map[int]interface{}{}
+		pr.objStms = map[int]*objStream{}
+		if rerr := pr.reconstruct(); rerr != nil {
+			if err == nil {
+				err = rerr
+			}
+			return nil, err
+		}
+	}
+	return pr, nil
+}
+
+func (pr *pdfReader) readAt(off int64, n int) []byte {
+	if off < 0 || off >= pr.size {
+		return nil
+	}
+	if rem := pr.size - off; int64(n) > rem {
+		n = int(rem)
+	}
+	buf := make([]byte, n)
+	read, _ := pr.r.ReadAt(buf, off)
+	return buf[:read]
+}
+
+// withWindow runs fn over a lexer positioned at off, growing the window
+// whenever fn reports truncation.
+func (pr *pdfReader) withWindow(off int64, fn func(l *pdfLexer) error) error {
+	for window := pdfInitialWindow; ; window *= 4 {
+		buf := pr.readAt(off, window)
+		if len(buf) == 0 {
+			return errPDFTruncated
+		}
+		final := off+int64(len(buf)) >= pr.size
+		err := fn(&pdfLexer{buf: buf, final: final})
+		if !errors.Is(err, errPDFTruncated) || len(buf) < window || window >= pdfMaxWindow {
+			return err
+		}
+	}
+}
+
+func (pr *pdfReader) findStartXref() (int64, error) {
+	tailLen := int64(2048)
+	if tailLen > pr.size {
+		tailLen = pr.size
+	}
+	tail := pr.readAt(pr.size-tailLen, int(tailLen))
+	idx := bytes.LastIndex(tail, []byte("startxref"))
+	if idx < 0 {
+		return 0, fmt.Errorf("pdf: startxref not found")
+	}
+	l := &pdfLexer{buf: tail, pos: idx + len("startxref")}
+	v, err := l.parseObject()
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(int64)
+	if !ok || n <= 0 || n >= pr.size {
+		return 0, fmt.Errorf("pdf: bad startxref offset")
+	}
+	return n, nil
+}
+
+// loadXrefChain follows /Prev links from the newest xref section back.
+// Entries from newer sections win, so older ones only fill gaps.
+func (pr *pdfReader) loadXrefChain(off int64) error {
+	seen := map[int64]bool{}
+	for off > 0 && !seen[off] {
+		seen[off] = true
+		trailer, err := pr.loadXrefSection(off)
+		if err != nil {
+			return err
+		}
+		pr.mergeTrailer(trailer)
+
+		if stm, ok := trailer[pdfName("XRefStm")]; ok {
+			if n, ok := pdfInt(stm); ok && !seen[int64(n)] {
+				seen[int64(n)] = true
+				if _, err := pr.loadXrefSection(int64(n)); err != nil {
+					return err
+				}
+			}
+		}
+
+		prev, ok := pdfInt(trailer[pdfName("Prev")])
+		if !ok {
+			break
+		}
+		off = int64(prev)
+	}
+	return nil
+}
+
+func (pr *pdfReader) mergeTrailer(d pdfDict) {
+	if pr.trailer == nil {
+		pr.trailer = pdfDict{}
+	}
+	for k, v := range d {
+		if _, exists := pr.trailer[k]; !exists {
+			pr.trailer[k] = v
+		}
+	}
+}
+
+func (pr *pdfReader) setEntry(num int, e xrefEntry) {
+	if _, exists := pr.xref[num]; !exists {
+		pr.xref[num] = e
+	}
+}
+
+// loadXrefSection parses either a classic "xref" table or an xref stream
+// at off and returns its trailer dictionary.
+func (pr *pdfReader) loadXrefSection(off int64) (pdfDict, error) {
+	head := pr.readAt(off, 4)
+	if string(head) == "xref" {
+		return pr.loadXrefTable(off)
+	}
+
+	_, obj, err := pr.parseObjectAt(off)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: xref at %d: %w", off, err)
+	}
+	stm, ok := obj.(pdfStream)
+	if !ok || stm.dict[pdfName("Type")] != pdfName("XRef") {
+		return nil, fmt.Errorf("pdf: no xref at %d", off)
+	}
+	return stm.dict, pr.loadXrefStream(stm)
+}
+
+func (pr *pdfReader) loadXrefTable(off int64) (pdfDict, error) {
+	var trailer pdfDict
+	entries := map[int]xrefEntry{}
+
+	err := pr.withWindow(off, func(l *pdfLexer) error {
+		l.pos = len("xref")
+		for k := range entries {
+			delete(entries, k)
+		}
+		for {
+			v, err := l.parseObject()
+			if err != nil {
+				return err
+			}
+			if kw, ok := v.(pdfKeyword); ok && kw == "trailer" {
+				d, err := l.parseObject()
+				if err != nil {
+					return err
+				}
+				dict, ok := d.(pdfDict)
+				if !ok {
+					return fmt.Errorf("pdf: trailer is not a dictionary")
+				}
+				trailer = dict
+				return nil
+			}
+
+			start, ok := pdfInt(v)
+			if !ok {
+				return fmt.Errorf("pdf: bad xref subsection")
+			}
+			c, err := l.parseObject()
+			if err != nil {
+				return err
+			}
+			count, ok := pdfInt(c)
+			if !ok {
+				return fmt.Errorf("pdf: bad xref subsection count")
+			}
+			for i := 0; i < count; i++ {
+				fields := make([]interface{}, 3)
+				for j := range fields {
+					if fields[j], err = l.parseObject(); err != nil {
+						return err
+					}
+				}
+				offset, _ := pdfInt(fields[0])
+				e := xrefEntry{typ: 1, offset: int64(offset)}
+				if kw, _ := fields[2].(pdfKeyword); kw != "n" {
+					e = xrefEntry{typ: 0}
+				}
+				entries[start+i] = e
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for num, e := range entries {
+		pr.setEntry(num, e)
+	}
+	return trailer, nil
+}
+
+func (pr *pdfReader) loadXrefStream(stm pdfStream) error {
+	data, err := pr.streamData(stm)
+	if err != nil {
+		return err
+	}
+
+	wArr, ok := stm.dict[pdfName("W")].(pdfArray)
+	if !ok || len(wArr) < 3 {
+		return fmt.Errorf("pdf: xref stream without /W")
+	}
+	var w [3]int
+	rowLen := 0
+	for i := 0; i < 3; i++ {
+		w[i], _ = pdfInt(wArr[i])
+		if w[i] < 0 || w[i] > 8 {
+			return fmt.Errorf("pdf: bad xref /W")
+		}
+		rowLen += w[i]
+	}
+	if rowLen == 0 {
+		return fmt.Errorf("pdf: empty xref /W")
+	}
+
+	var index []int
+	if arr, ok := stm.dict[pdfName("Index")].(pdfArray); ok {
+		for _, v := range arr {
+			n, _ := pdfInt(v)
+			index = append(index, n)
+		}
+	} else {
+		size, _ := pdfInt(stm.dict[pdfName("Size")])
+		index = []int{0, size}
+	}
+
+	field := func(b []byte) int64 {
+		var v int64
+		for _, c := range b {
+			v = v<<8 | int64(c)
+		}
+		return v
+	}
+
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, count := index[i], index[i+1]
+		for j := 0; j < count; j++ {
+			if pos+rowLen > len(data) {
+				return nil
+			}
+			row := data[pos : pos+rowLen]
+			pos += rowLen
+
+			typ := int64(1)
+			if w[0] > 0 {
+				typ = field(row[:w[0]])
+			}
+			f2 := field(row[w[0] : w[0]+w[1]])
+			f3 := field(row[w[0]+w[1]:])
+			switch typ {
+			case 1:
+				pr.setEntry(start+j, xrefEntry{typ: 1, offset: f2})
+			case 2:
+				pr.setEntry(start+j, xrefEntry{typ: 2, stream: int(f2), index: int(f3)})
+			default:
+				pr.setEntry(start+j, xrefEntry{typ: 0})
+			}
+		}
+	}
+	return nil
+}
+
+// objHeaderRe finds "n g obj" headers when rebuilding a damaged xref.
+var objHeaderRe = regexp.MustCompile(`(?:^|[\r\n\s])(\d+)\s+(\d+)\s+obj\b`)
+
+// reconstruct scans the file for object headers and a trailer.
+func (pr *pdfReader) reconstruct() error {
+	const chunk = 1 << 20
+	const overlap = 64
+
+	var lastTrailer int64 = -1
+	for off := int64(0); off < pr.size; off += chunk {
+		buf := pr.readAt(off, chunk+overlap)
+		for _, m := range objHeaderRe.FindAllSubmatchIndex(buf, -1) {
+			num, err := strconv.Atoi(string(buf[m[2]:m[3]]))
+			if err != nil {
+				continue
+			}
+			// Later definitions replace earlier ones (incremental updates).
+			pr.xref[num] = xrefEntry{typ: 1, offset: off + int64(m[2])}
+		}
+		if idx := bytes.LastIndex(buf, []byte("trailer")); idx >= 0 && idx < chunk {
+			lastTrailer = off + int64(idx)
+		}
+	}
+	if len(pr.xref) == 0 {
+		return fmt.Errorf("pdf: no objects found")
+	}
+
+	if lastTrailer >= 0 {
+		_ = pr.withWindow(lastTrailer, func(l *pdfLexer) error {
+			l.pos = len("trailer")
+			v, err := l.parseObject()
+			if d, ok := v.(pdfDict); ok {
+				pr.trailer = d
+			}
+			return err
+		})
+	}
+	if pr.trailer[pdfName("Root")] != nil {
+		return nil
+	}
+
+	// No usable trailer: look for an xref stream dictionary or the catalog.
+	if pr.trailer == nil {
+		pr.trailer = pdfDict{}
+	}
+	for num := range pr.xref {
+		obj, err := pr.object(num)
+		if err != nil {
+			continue
+		}
+		var d pdfDict
+		switch o := obj.(type) {
+		case pdfDict:
+			d = o
+		case pdfStream:
+			d = o.dict
+		}
+		switch {
+		case d[pdfName("Type")] == pdfName("XRef") && d[pdfName("Root")] != nil:
+			pr.mergeTrailer(d)
+		case d[pdfName("Type")] == pdfName("Catalog"):
+			pr.trailer[pdfName("Root")] = pdfRef{num: num}
+		case d[pdfName("Type")] == nil && (d[pdfName("Title")] != nil || d[pdfName("Author")] != nil || d[pdfName("Producer")] != nil):
+			if pr.trailer[pdfName("Info")] == nil {
+				pr.trailer[pdfName("Info")] = pdfRef{num: num}
+			}
+		}
+	}
+	return nil
+}
+
+// parseObjectAt parses "n g obj ... endobj" at off. Streams are returned
+// with the absolute offset of their data; the data itself is read lazily.
+func (pr *pdfReader) parseObjectAt(off int64) (int, interface{}, error) {
+	var num int
+	var obj interface{}
+	err := pr.withWindow(off, func(l *pdfLexer) error {
+		n, err := l.parseNumber()
+		if err != nil {
+			return err
+		}
+		nn, ok := n.(int64)
+		if !ok {
+			return fmt.Errorf("pdf: no object at %d", off)
+		}
+		num = int(nn)
+		if _, err := l.parseNumber(); err != nil {
+			return err
+		}
+		kw, err := l.parseObject()
+		if err != nil {
+			return err
+		}
+		if kw != pdfKeyword("obj") {
+			return fmt.Errorf("pdf: no object at %d", off)
+		}
+		if obj, err = l.parseObject(); err != nil {
+			return err
+		}
+
+		d, isDict := obj.(pdfDict)
+		if !isDict {
+			return nil
+		}
+		save := l.pos
+		next, err := l.parseObject()
+		if err != nil && (!errors.Is(err, errPDFTruncated) || !l.final) {
+			return err
+		}
+		if next != pdfKeyword("stream") {
+			l.pos = save
+			return nil
+		}
+		// The stream keyword is followed by CRLF or LF.
+		if l.pos < len(l.buf) && l.buf[l.pos] == '\r' {
+			l.pos++
+		}
+		if l.pos < len(l.buf) && l.buf[l.pos] == '\n' {
+			l.pos++
+		}
+		obj = pdfStream{dict: d, offset: off + int64(l.pos)}
+		return nil
+	})
+	return num, obj, err
+}
+
+// object returns the (unresolved) object with the given number.
+func (pr *pdfReader) object(num int) (interface{}, error) {
+	if v, ok := pr.objects[num]; ok {
+		return v, nil
+	}
+	if pr.loading[num] {
+		return nil, fmt.Errorf("pdf: reference cycle at object %d", num)
+	}
+	pr.loading[num] = true
+	defer delete(pr.loading, num)
+
+	e, ok := pr.xref[num]
+	if !ok || e.typ == 0 {
+		return nil, nil
+	}
+
+	var obj interface{}
+	var err error
+	switch e.typ {
+	case 1:
+		var got int
+		got, obj, err = pr.parseObjectAt(e.offset)
+		if err == nil && got != num {
+			err = fmt.Errorf("pdf: xref for object %d points at object %d", num, got)
+		}
+	case 2:
+		obj, err = pr.objectFromStream(e.stream, e.index)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pr.objects[num] = obj
+	return obj, nil
+}
+
+func (pr *pdfReader) objectFromStream(streamNum, index int) (interface{}, error) {
+	ostm, err := pr.loadObjStream(streamNum)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(ostm.offsets) {
+		return nil, fmt.Errorf("pdf: object index %d out of range in stream %d", index, streamNum)
+	}
+	start := ostm.first + ostm.offsets[index]
+	if start < 0 || start > len(ostm.data) {
+		return nil, fmt.Errorf("pdf: bad offset in object stream %d", streamNum)
+	}
+	l := &pdfLexer{buf: ostm.data, pos: start}
+	return l.parseObject()
+}
+
+func (pr *pdfReader) loadObjStream(num int) (*objStream, error) {
+	if ostm, ok := pr.objStms[num]; ok {
+		return ostm, nil
+	}
+	obj, err := pr.object(num)
+	if err != nil {
+		return nil, err
+	}
+	stm, ok := obj.(pdfStream)
+	if !ok || stm.dict[pdfName("Type")] != pdfName("ObjStm") {
+		return nil, fmt.Errorf("pdf: object %d is not an object stream", num)
+	}
+	data, err := pr.streamData(stm)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := pdfInt(stm.dict[pdfName("N")])
+	first, _ := pdfInt(stm.dict[pdfName("First")])
+
+	ostm := &objStream{data: data, first: first}
+	l := &pdfLexer{buf: data}
+	for i := 0; i < n; i++ {
+		a, err := l.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		b, err := l.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		objNum, _ := pdfInt(a)
+		objOff, _ := pdfInt(b)
+		ostm.nums = append(ostm.nums, objNum)
+		ostm.offsets = append(ostm.offsets, objOff)
+		l.skipSpace()
+	}
+	pr.objStms[num] = ostm
+	return ostm, nil
+}
+
+// resolve follows indirect references until a direct object is reached.
+func (pr *pdfReader) resolve(v interface{}) interface{} {
+	for depth := 0; depth < pdfMaxResolveDepth; depth++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		obj, err := pr.object(ref.num)
+		if err != nil {
+			return nil
+		}
+		v = obj
+	}
+	return nil
+}
+
+func (pr *pdfReader) resolveDict(v interface{}) pdfDict {
+	switch d := pr.resolve(v).(type) {
+	case pdfDict:
+		return d
+	case pdfStream:
+		return d.dict
+	}
+	return nil
+}
+
+// streamData reads and decodes a stream's contents.
+func (pr *pdfReader) streamData(stm pdfStream) ([]byte, error) {
+	length, ok := pdfInt(pr.resolve(stm.dict[pdfName("Length")]))
+	if !ok || length < 0 || int64(length) > pr.size-stm.offset {
+		// Missing or wrong /Length: fall back to the endstream marker.
+		length = -1
+	}
+
+	var raw []byte
+	if length >= 0 {
+		raw = pr.readAt(stm.offset, length)
+	} else {
+		buf := pr.readAt(stm.offset, pdfMaxStream)
+		idx := bytes.Index(buf, []byte("endstream"))
+		if idx < 0 {
+			return nil, fmt.Errorf("pdf: unterminated stream")
+		}
+		raw = bytes.TrimRight(buf[:idx], "\r\n")
+	}
+	return decodeStream(stm.dict, raw)
+}
+
+// decodeStream applies the stream's /Filter chain.
+func decodeStream(d pdfDict, data []byte) ([]byte, error) {
+	var filters []pdfName
+	var params []pdfDict
+	switch f := d[pdfName("Filter")].(type) {
+	case pdfName:
+		filters = []pdfName{f}
+	case pdfArray:
+		for _, v := range f {
+			if n, ok := v.(pdfName); ok {
+				filters = append(filters, n)
+			}
+		}
+	}
+	switch p := d[pdfName("DecodeParms")].(type) {
+	case pdfDict:
+		params = []pdfDict{p}
+	case pdfArray:
+		for _, v := range p {
+			pd, _ := v.(pdfDict)
+			params = append(params, pd)
+		}
+	}
+
+	var err error
+	for i, f := range filters {
+		var p pdfDict
+		if i < len(params) {
+			p = params[i]
+		}
+		switch f {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+			if err == nil {
+				data, err = unpredict(data, p)
+			}
+		case "ASCIIHexDecode", "AHx":
+			data, err = decodeASCIIHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		case "DCTDecode", "DCT":
+			// DCT data is a complete JPEG file; leave it encoded.
+			if i == len(filters)-1 {
+				return data, nil
+			}
+			return nil, fmt.Errorf("pdf: unsupported filter chain")
+		default:
+			return nil, fmt.Errorf("pdf: unsupported filter %s", f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: flate: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+	out, err := io.ReadAll(io.LimitReader(zr, pdfMaxStream))
+	// Many writers truncate the final checksum; keep what decoded.
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("pdf: flate: %w", err)
+	}
+	return out, nil
+}
+
+// unpredict reverses PNG row predictors (Predictor >= 10), which are used
+// by nearly every xref and object stream.
+func unpredict(data []byte, p pdfDict) ([]byte, error) {
+	predictor, _ := pdfInt(p[pdfName("Predictor")])
+	if predictor < 10 {
+		return data, nil
+	}
+	colors, ok := pdfInt(p[pdfName("Colors")])
+	if !ok || colors < 1 {
+		colors = 1
+	}
+	bpc, ok := pdfInt(p[pdfName("BitsPerComponent")])
+	if !ok || bpc < 1 {
+		bpc = 8
+	}
+	columns, ok := pdfInt(p[pdfName("Columns")])
+	if !ok || columns < 1 {
+		columns = 1
+	}
+
+	bpp := (colors*bpc + 7) / 8
+	rowLen := (colors*bpc*columns + 7) / 8
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowLen)
+
+	for pos := 0; pos+1+rowLen <= len(data); pos += 1 + rowLen {
+		ft := data[pos]
+		row := make([]byte, rowLen)
+		copy(row, data[pos+1:pos+1+rowLen])
+		for i := 0; i < rowLen; i++ {
+			var left, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up := prev[i]
+			switch ft {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("pdf: bad PNG predictor %d", ft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	var clean []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if !isPDFWhitespace(c) {
+			clean = append(clean, c)
+		}
+	}
+	if len(clean)%2 == 1 {
+		clean = append(clean, '0')
+	}
+	return hex.DecodeString(string(clean))
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if idx := bytes.Index(data, []byte("~>")); idx >= 0 {
+		data = data[:idx]
+	}
+	// Each "z" stands for four zero bytes, so the output can be up to
+	// four times the input; Decode stops silently when out is full.
+	out := make([]byte, 4*len(data))
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: ascii85: %w", err)
+	}
+	return out[:n], nil
+}
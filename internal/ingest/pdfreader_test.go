@@ -0,0 +1,286 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildClassicPDF writes objects with a correct xref table. objs[i] is the
+// body of object i+1.
+func buildClassicPDF(objs []string, trailer string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objs))
+	for i, body := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d %s >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, trailer, xref)
+	return buf.Bytes()
+}
+
+func writePDF(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.pdf")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func deflate(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractPDFMetadata_ClassicXref(t *testing.T) {
+	data := buildClassicPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		// UTF-16BE title, escaped parens and octal escape in the author.
+		"<< /Title <FEFF00530069006D0070006C0065> /Author (Jane \\(J.\\) D\\351) /Producer (TeX) /Keywords (go, pdf) >>",
+	}, "/Root 1 0 R /Info 6 0 R")
+
+	meta, err := ExtractPDFMetadata(writePDF(t, data))
+	if err != nil {
+		t.Fatalf("ExtractPDFMetadata: %v", err)
+	}
+	if meta.Title != "Simple" {
+		t.Errorf("Title = %q, want Simple", meta.Title)
+	}
+	if meta.Author != "Jane (J.) Dé" {
+		t.Errorf("Author = %q, want %q", meta.Author, "Jane (J.) Dé")
+	}
+	if meta.Producer != "TeX" || meta.Keywords != "go, pdf" {
+		t.Errorf("Producer/Keywords = %q/%q", meta.Producer, meta.Keywords)
+	}
+	if meta.PageCount != 3 {
+		t.Errorf("PageCount = %d, want 3", meta.PageCount)
+	}
+	if meta.Version != "1.4" {
+		t.Errorf("Version = %q, want 1.4", meta.Version)
+	}
+}
+
+func TestExtractPDFMetadata_IncrementalUpdate(t *testing.T) {
+	base := buildClassicPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		"<< /Title (Old Title) /Author (Someone) >>",
+	}, "/Root 1 0 R /Info 3 0 R")
+	prevXref := bytes.LastIndex(base, []byte("xref\n"))
+
+	var buf bytes.Buffer
+	buf.Write(base)
+	off := buf.Len()
+	buf.WriteString("3 0 obj\n<< /Title (New Title) /Author (Someone) >>\nendobj\n")
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n3 1\n%010d 00000 n \n", off)
+	fmt.Fprintf(&buf, "trailer\n<< /Size 4 /Root 1 0 R /Info 3 0 R /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", prevXref, xref)
+
+	meta, err := ExtractPDFMetadata(writePDF(t, buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ExtractPDFMetadata: %v", err)
+	}
+	if meta.Title != "New Title" {
+		t.Errorf("Title = %q, want the updated title", meta.Title)
+	}
+}
+
+// TestExtractPDFMetadata_ObjectStreams covers PDF 1.5 files where the Info
+// dictionary lives in a compressed object stream and the cross-reference
+// data is a Flate-encoded xref stream with a PNG Up predictor.
+func TestExtractPDFMetadata_ObjectStreams(t *testing.T) {
+	// Objects 2 (pages) and 3 (info) are packed into object stream 4.
+	o2 := "<< /Type /Pages /Kids [] /Count 12 >>"
+	o3 := "<< /Title (Compressed Title) /Author (Stream Author) >>"
+	header := fmt.Sprintf("2 0 3 %d ", len(o2)+1)
+	objStm := deflate(t, []byte(header+o2+" "+o3))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	off1 := buf.Len()
+	buf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	off4 := buf.Len()
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Type /ObjStm /N 2 /First %d /Filter /FlateDecode /Length %d >>\nstream\n", len(header), len(objStm))
+	buf.Write(objStm)
+	buf.WriteString("\nendstream\nendobj\n")
+	off5 := buf.Len()
+
+	// Rows of W [1 4 2], each prefixed with PNG filter type 2 (Up).
+	rows := [][]byte{
+		row(0, 0, 65535),
+		row(1, off1, 0),
+		row(2, 4, 0),
+		row(2, 4, 1),
+		row(1, off4, 0),
+		row(1, off5, 0),
+	}
+	var raw []byte
+	prev := make([]byte, 7)
+	for _, r := range rows {
+		raw = append(raw, 2)
+		for i := range r {
+			raw = append(raw, r[i]-prev[i])
+		}
+		prev = r
+	}
+	xrefData := deflate(t, raw)
+	fmt.Fprintf(&buf, "5 0 obj\n<< /Type /XRef /Size 6 /W [1 4 2] /Root 1 0 R /Info 3 0 R "+
+		"/Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns 7 >> /Length %d >>\nstream\n", len(xrefData))
+	buf.Write(xrefData)
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", off5)
+
+	meta, err := ExtractPDFMetadata(writePDF(t, buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ExtractPDFMetadata: %v", err)
+	}
+	if meta.Title != "Compressed Title" || meta.Author != "Stream Author" {
+		t.Errorf("got %q by %q", meta.Title, meta.Author)
+	}
+	if meta.PageCount != 12 {
+		t.Errorf("PageCount = %d, want 12", meta.PageCount)
+	}
+}
+
+func row(typ, field2, field3 int) []byte {
+	b := make([]byte, 7)
+	b[0] = byte(typ)
+	binary.BigEndian.PutUint32(b[1:5], uint32(field2))
+	binary.BigEndian.PutUint16(b[5:7], uint16(field3))
+	return b
+}
+
+func TestExtractPDFMetadata_XMP(t *testing.T) {
+	xmp := `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/"
+      xmlns:pdf="http://ns.adobe.com/pdf/1.3/" pdf:Producer="XMP Producer">
+   <dc:title><rdf:Alt><rdf:li xml:lang="x-default">XMP Title</rdf:li></rdf:Alt></dc:title>
+   <dc:creator><rdf:Seq><rdf:li>Ada Lovelace</rdf:li><rdf:li>Charles Babbage</rdf:li></rdf:Seq></dc:creator>
+   <dc:subject><rdf:Bag><rdf:li>engines</rdf:li></rdf:Bag></dc:subject>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+
+	data := buildClassicPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /Metadata 3 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(xmp), xmp),
+		"<< /Author (Info Author) >>",
+	}, "/Root 1 0 R /Info 4 0 R")
+
+	meta, err := ExtractPDFMetadata(writePDF(t, data))
+	if err != nil {
+		t.Fatalf("ExtractPDFMetadata: %v", err)
+	}
+	if meta.Title != "XMP Title" {
+		t.Errorf("Title = %q, want XMP Title", meta.Title)
+	}
+	if meta.Author != "Info Author" {
+		t.Errorf("Author = %q, Info dictionary should take priority", meta.Author)
+	}
+	if meta.Producer != "XMP Producer" {
+		t.Errorf("Producer = %q, want attribute value", meta.Producer)
+	}
+	if meta.Keywords != "engines" {
+		t.Errorf("Keywords = %q, want engines", meta.Keywords)
+	}
+}
+
+func TestExtractPDFMetadata_Encrypted(t *testing.T) {
+	data := buildClassicPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 7 >>",
+		"<< /Title (\x8a\x13\xff\x02) >>",
+		"<< /Filter /Standard /V 2 /R 3 /O <00> /U <00> /P -4 >>",
+	}, "/Root 1 0 R /Info 3 0 R /Encrypt 4 0 R")
+
+	meta, err := ExtractPDFMetadata(writePDF(t, data))
+	if err != nil {
+		t.Fatalf("ExtractPDFMetadata: %v", err)
+	}
+	if !meta.Encrypted {
+		t.Error("Encrypted = false, want true")
+	}
+	if meta.Title != "" {
+		t.Errorf("Title = %q, encrypted strings should not be decoded", meta.Title)
+	}
+	if meta.PageCount != 7 {
+		t.Errorf("PageCount = %d, want 7", meta.PageCount)
+	}
+}
+
+func TestExtractPDFMetadata_DamagedXref(t *testing.T) {
+	data := buildClassicPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 2 >>",
+		"<< /Title (Recovered) /Author (Scanner) >>",
+	}, "/Root 1 0 R /Info 3 0 R")
+	// Shift every object so the xref offsets point at the wrong bytes.
+	data = append([]byte("%PDF-1.4\n% padding padding padding\n"), data[len("%PDF-1.4\n"):]...)
+
+	meta, err := ExtractPDFMetadata(writePDF(t, data))
+	if err != nil {
+		t.Fatalf("ExtractPDFMetadata: %v", err)
+	}
+	if meta.Title != "Recovered" || meta.PageCount != 2 {
+		t.Errorf("got title %q, %d pages", meta.Title, meta.PageCount)
+	}
+}
+
+// TestExtractPDFMetadata_Truncated checks that no prefix of a valid file
+// makes the parser panic or return an error.
+func TestExtractPDFMetadata_Truncated(t *testing.T) {
+	data := buildClassicPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 1 >>",
+		"<< /Title (Cut Short) >>",
+		"<< /Length 10 /Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns 3 >> >>\nstream\n0123456789\nendstream",
+	}, "/Root 1 0 R /Info 3 0 R")
+
+	dir := t.TempDir()
+	for n := 0; n <= len(data); n += 7 {
+		path := filepath.Join(dir, fmt.Sprintf("cut%d.pdf", n))
+		if err := os.WriteFile(path, data[:n], 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ExtractPDFMetadata(path); err != nil {
+			t.Errorf("prefix %d: %v", n, err)
+		}
+	}
+}
+
+func TestDecodeASCII85_ZeroGroups(t *testing.T) {
+	// "z" is four zero bytes; the output is four times the input.
+	got, err := decodeASCII85([]byte("<~zzz~>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := make([]byte, 12); !bytes.Equal(got, want) {
+		t.Errorf("decodeASCII85 = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// XMP namespaces we read from.
+const (
+	nsDC  = "http://purl.org/dc/elements/1.1/"
+	nsPDF = "http://ns.adobe.com/pdf/1.3/"
+	nsXMP = "http://ns.adobe.com/xap/1.0/"
+	nsRDF = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// xmpMetadata is the subset of an XMP packet shelfctl understands.
+type xmpMetadata struct {
+	Title       string
+	Creators    []string
+	Description string
+	Subjects    []string
+	Producer    string
+	Keywords    string
+	CreatorTool string
+	CreateDate  string
+}
+
+// parseXMP walks an XMP packet. Properties may appear either as elements
+// (with rdf:Alt/Seq/Bag containers) or as attributes on rdf:Description.
+func parseXMP(data []byte) (*xmpMetadata, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	x := &xmpMetadata{}
+	var stack []xml.Name
+	var text strings.Builder
+
+	// property returns the enclosing XMP property for the current element,
+	// skipping rdf containers and list items.
+	property := func() xml.Name {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].Space != nsRDF {
+				return stack[i]
+			}
+		}
+		return xml.Name{}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name)
+			text.Reset()
+			if t.Name.Space == nsRDF && t.Name.Local == "Description" {
+				for _, a := range t.Attr {
+					x.set(a.Name, a.Value)
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			v := strings.TrimSpace(text.String())
+			text.Reset()
+			isItem := t.Name.Space == nsRDF && t.Name.Local == "li"
+			stack = stack[:len(stack)-1]
+			if v == "" {
+				continue
+			}
+			if isItem {
+				x.set(property(), v)
+			} else if t.Name.Space != nsRDF {
+				x.set(t.Name, v)
+			}
+		}
+	}
+	return x, nil
+}
+
+func (x *xmpMetadata) set(name xml.Name, v string) {
+	switch {
+	case name.Space == nsDC && name.Local == "title":
+		if x.Title == "" {
+			x.Title = v
+		}
+	case name.Space == nsDC && name.Local == "creator":
+		x.Creators = append(x.Creators, v)
+	case name.Space == nsDC && name.Local == "description":
+		if x.Description == "" {
+			x.Description = v
+		}
+	case name.Space == nsDC && name.Local == "subject":
+		x.Subjects = append(x.Subjects, v)
+	case name.Space == nsPDF && name.Local == "Producer":
+		x.Producer = v
+	case name.Space == nsPDF && name.Local == "Keywords":
+		x.Keywords = v
+	case name.Space == nsXMP && name.Local == "CreatorTool":
+		x.CreatorTool = v
+	case name.Space == nsXMP && name.Local == "CreateDate":
+		x.CreateDate = v
+	}
+}
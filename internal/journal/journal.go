@@ -0,0 +1,385 @@
+// Package journal records what mutating commands change, so the change can
+// be undone: the catalogs as they were before, and where assets went.
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+// MaxOperations is how many operations the journal keeps. Older ones are
+// pruned, together with their copies of deleted assets.
+const MaxOperations = 50
+
+const opFile = "op.json"
+
+// ErrDisabled is returned by SaveCopy when the journal has no directory.
+var ErrDisabled = errors.New("undo journal is disabled")
+
+// Journal is a directory of operations, one subdirectory each.
+type Journal struct {
+	dir string
+}
+
+// Open returns the journal in dir. The directory is created on first save.
+// With an empty dir nothing is recorded.
+func Open(dir string) *Journal {
+	return &Journal{dir: dir}
+}
+
+// Location is where an asset lives.
+type Location struct {
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	Release string `json:"release"`
+	Asset   string `json:"asset"`
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s/%s@%s/%s", l.Owner, l.Repo, l.Release, l.Asset)
+}
+
+// CatalogChange is a catalog an operation committed.
+type CatalogChange struct {
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+	Path  string `json:"path"`
+	// Before is the catalog as it was before the operation.
+	Before string `json:"before"`
+	// After is the digest of the catalog the operation left behind, to
+	// tell whether it was changed since.
+	After string `json:"after"`
+}
+
+// AssetKind is what an operation did to an asset.
+type AssetKind string
+
+const (
+	AssetAdded   AssetKind = "added"
+	AssetDeleted AssetKind = "deleted"
+	AssetMoved   AssetKind = "moved"
+)
+
+// AssetChange is an asset an operation added, deleted or moved.
+type AssetChange struct {
+	Kind AssetKind `json:"kind"`
+	// From is where a deleted or moved asset was.
+	From Location `json:"from,omitzero"`
+	// To is where an added or moved asset is.
+	To Location `json:"to,omitzero"`
+	// Copy is the file in the operation's directory holding a deleted
+	// asset's content.
+	Copy string `json:"copy,omitempty"`
+}
+
+// Op is one operation of a command, such as deleting books or renaming a
+// tag across shelves. A nil Op records nothing.
+type Op struct {
+	ID       string          `json:"id"`
+	Time     time.Time       `json:"time"`
+	Command  string          `json:"command"`
+	Summary  string          `json:"summary"`
+	Catalogs []CatalogChange `json:"catalogs,omitempty"`
+	Assets   []AssetChange   `json:"assets,omitempty"`
+	Undone   *time.Time      `json:"undone,omitempty"`
+
+	dir string
+}
+
+// Begin starts an operation. Nothing is written until Save.
+func (j *Journal) Begin(command, summary string) *Op {
+	now := time.Now()
+	if j.dir == "" {
+		return &Op{Time: now, Command: command, Summary: summary}
+	}
+	id := now.UTC().Format("20060102-150405")
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(j.dir, id)); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.UTC().Format("20060102-150405"), n)
+	}
+	return &Op{
+		ID:      id,
+		Time:    now,
+		Command: command,
+		Summary: summary,
+		dir:     filepath.Join(j.dir, id),
+	}
+}
+
+// Recording reports whether the operation will be saved, so callers can
+// skip work such as downloading copies when it is not.
+func (o *Op) Recording() bool {
+	return o != nil && o.dir != ""
+}
+
+// Empty reports whether the operation recorded nothing.
+func (o *Op) Empty() bool {
+	return len(o.Catalogs) == 0 && len(o.Assets) == 0
+}
+
+// Catalog records a committed catalog. Recording the same catalog again
+// keeps the first before-state.
+func (o *Op) Catalog(owner, repo, path string, before, after []byte) {
+	if o == nil {
+		return
+	}
+	for i, c := range o.Catalogs {
+		if c.Owner == owner && c.Repo == repo && c.Path == path {
+			o.Catalogs[i].After = Digest(after)
+			return
+		}
+	}
+	o.Catalogs = append(o.Catalogs, CatalogChange{
+		Owner:  owner,
+		Repo:   repo,
+		Path:   path,
+		Before: string(before),
+		After:  Digest(after),
+	})
+}
+
+// SaveCopy stores the content of an asset about to be deleted, and returns
+// the name to pass to Deleted.
+func (o *Op) SaveCopy(loc Location, r io.Reader) (string, error) {
+	if !o.Recording() {
+		return "", ErrDisabled
+	}
+	if err := os.MkdirAll(o.dir, 0700); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%d-%s", len(o.Assets), filepath.Base(loc.Asset))
+	f, err := os.OpenFile(filepath.Join(o.dir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return name, f.Close()
+}
+
+// Added records an uploaded asset.
+func (o *Op) Added(to Location) {
+	if o == nil {
+		return
+	}
+	o.Assets = append(o.Assets, AssetChange{Kind: AssetAdded, To: to})
+}
+
+// Deleted records a deleted asset whose content SaveCopy stored. An empty
+// copyName records a deletion no copy could be kept of.
+func (o *Op) Deleted(from Location, copyName string) {
+	if o == nil {
+		return
+	}
+	o.Assets = append(o.Assets, AssetChange{Kind: AssetDeleted, From: from, Copy: copyName})
+}
+
+// DeleteAssets deletes the asset of src, or each of its parts, from a
+// release of owner/repo, as when a book is uploaded again. A copy of each is
+// downloaded first so the deletion can be undone; the names of assets no
+// copy could be kept of are returned. Missing assets are skipped.
+func (o *Op) DeleteAssets(c Client, owner, repo string, releaseID int64, src catalog.Source) ([]string, error) {
+	var lost []string
+	for _, name := range src.AssetNames() {
+		asset, err := c.FindAsset(owner, repo, releaseID, name)
+		if err != nil {
+			return lost, err
+		}
+		if asset == nil {
+			continue
+		}
+		loc := Location{Owner: owner, Repo: repo, Release: src.Release, Asset: name}
+		copyName := ""
+		if o.Recording() {
+			if copyName, err = o.downloadCopy(c, loc, asset.ID); err != nil {
+				lost = append(lost, name)
+			}
+		}
+		if err := c.DeleteAsset(owner, repo, asset.ID); err != nil {
+			return lost, err
+		}
+		o.Deleted(loc, copyName)
+	}
+	return lost, nil
+}
+
+func (o *Op) downloadCopy(c Client, loc Location, assetID int64) (string, error) {
+	r, err := c.DownloadAsset(loc.Owner, loc.Repo, assetID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = r.Close() }()
+	return o.SaveCopy(loc, r)
+}
+
+// Moved records an asset moved to another release or repo.
+func (o *Op) Moved(from, to Location) {
+	if o == nil {
+		return
+	}
+	o.Assets = append(o.Assets, AssetChange{Kind: AssetMoved, From: from, To: to})
+}
+
+// MovedAll records assets moved pairwise, such as the parts of a
+// multi-part asset.
+func (o *Op) MovedAll(from, to []Location) {
+	for i := range from {
+		if i < len(to) {
+			o.Moved(from[i], to[i])
+		}
+	}
+}
+
+// Locations returns where the assets of src live in owner/repo: one
+// location per part for multi-part assets.
+func Locations(owner, repo string, src catalog.Source) []Location {
+	var locs []Location
+	for _, name := range src.AssetNames() {
+		locs = append(locs, Location{Owner: owner, Repo: repo, Release: src.Release, Asset: name})
+	}
+	return locs
+}
+
+// copyPath returns the path of a stored asset copy.
+func (o *Op) copyPath(name string) string {
+	return filepath.Join(o.dir, name)
+}
+
+// Save writes the operation, or discards it if it recorded nothing, and
+// prunes the oldest operations beyond MaxOperations.
+func (j *Journal) Save(o *Op) error {
+	if !o.Recording() {
+		return nil
+	}
+	if o.Empty() {
+		return j.Discard(o)
+	}
+	if err := os.MkdirAll(o.dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(o.dir, opFile), data, 0600); err != nil {
+		return err
+	}
+	return j.prune()
+}
+
+// Discard removes an operation and its asset copies.
+func (j *Journal) Discard(o *Op) error {
+	if !o.Recording() {
+		return nil
+	}
+	return os.RemoveAll(o.dir)
+}
+
+// List returns the recorded operations, newest first.
+func (j *Journal) List() ([]*Op, error) {
+	if j.dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ops []*Op
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		o, err := j.load(e.Name())
+		if err != nil {
+			continue // incomplete or foreign directory
+		}
+		ops = append(ops, o)
+	}
+	sort.Slice(ops, func(a, b int) bool {
+		if ops[a].Time.Equal(ops[b].Time) {
+			return ops[a].ID > ops[b].ID
+		}
+		return ops[a].Time.After(ops[b].Time)
+	})
+	return ops, nil
+}
+
+// Get returns the operation with the given ID.
+func (j *Journal) Get(id string) (*Op, error) {
+	o, err := j.load(id)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no operation %q in the journal", id)
+	}
+	return o, err
+}
+
+// Latest returns the newest operation not undone yet, or nil.
+func (j *Journal) Latest() (*Op, error) {
+	ops, err := j.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range ops {
+		if o.Undone == nil {
+			return o, nil
+		}
+	}
+	return nil, nil
+}
+
+func (j *Journal) load(id string) (*Op, error) {
+	dir := filepath.Join(j.dir, filepath.Base(id))
+	data, err := os.ReadFile(filepath.Join(dir, opFile))
+	if err != nil {
+		return nil, err
+	}
+	var o Op
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("journal entry %s: %w", id, err)
+	}
+	o.dir = dir
+	return &o, nil
+}
+
+func (j *Journal) prune() error {
+	ops, err := j.List()
+	if err != nil || len(ops) <= MaxOperations {
+		return err
+	}
+	for _, o := range ops[MaxOperations:] {
+		if err := j.Discard(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Digest identifies a catalog's content. Catalogs are compared as parsed,
+// so formatting differences do not count as changes.
+func Digest(data []byte) string {
+	if books, err := catalog.Parse(data); err == nil {
+		if normalized, err := catalog.Marshal(books); err == nil {
+			data = normalized
+		}
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,243 @@
+package journal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+// put uploads data as the asset at loc.
+func put(c *testutil.GitHub, loc Location, data string) {
+	c.PutAsset(loc.Owner, loc.Repo, loc.Release, loc.Asset, data)
+}
+
+// has reports whether the asset at loc exists.
+func has(c *testutil.GitHub, loc Location) bool {
+	return c.HasAsset(loc.Owner, loc.Repo, loc.Release, loc.Asset)
+}
+
+const (
+	twoBooks = `- id: sicp
+  title: SICP
+  format: pdf
+  source: {type: github_release, owner: me, repo: shelf, release: library, asset: sicp.pdf}
+- id: taocp
+  title: TAOCP
+  format: pdf
+  source: {type: github_release, owner: me, repo: shelf, release: library, asset: taocp.pdf}
+`
+	oneBook = `- id: taocp
+  title: TAOCP
+  format: pdf
+  source: {type: github_release, owner: me, repo: shelf, release: library, asset: taocp.pdf}
+`
+)
+
+func TestUndo_Delete(t *testing.T) {
+	j := Open(t.TempDir())
+	c := testutil.NewGitHub()
+	sicp := Location{Owner: "me", Repo: "shelf", Release: "library", Asset: "sicp.pdf"}
+	put(c, sicp, "sicp content")
+	c.Repo("me", "shelf").Files["catalog.yml"] = []byte(oneBook)
+
+	op := j.Begin("delete-book", "delete sicp")
+	name, err := op.SaveCopy(sicp, strings.NewReader("sicp content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	op.Catalog("me", "shelf", "catalog.yml", []byte(twoBooks), []byte(oneBook))
+	op.Deleted(sicp, name)
+	if err := j.Save(op); err != nil {
+		t.Fatal(err)
+	}
+	// The asset is deleted after the journal has its copy.
+	rel, _ := c.GetReleaseByTag("me", "shelf", "library")
+	a, _ := c.FindAsset("me", "shelf", rel.ID, "sicp.pdf")
+	_ = c.DeleteAsset("me", "shelf", a.ID)
+
+	latest, err := j.Latest()
+	if err != nil || latest == nil || latest.ID != op.ID {
+		t.Fatalf("Latest() = %v, %v, want %s", latest, err, op.ID)
+	}
+	conflicts, err := Conflicts(c, latest)
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("Conflicts() = %v, %v", conflicts, err)
+	}
+
+	var notes []string
+	if err := j.Undo(c, latest, func(s string) { notes = append(notes, s) }); err != nil {
+		t.Fatal(err)
+	}
+	if !has(c, sicp) || string(c.Assets("me", "shelf", "library")["sicp.pdf"]) != "sicp content" {
+		t.Error("deleted asset not uploaded again")
+	}
+	if string(c.Repo("me", "shelf").Files["catalog.yml"]) != twoBooks {
+		t.Errorf("catalog not restored:\n%s", c.Repo("me", "shelf").Files["catalog.yml"])
+	}
+	if msgs := c.Repo("me", "shelf").Messages; msgs[len(msgs)-1] != "undo: delete sicp" {
+		t.Errorf("commit messages = %q", msgs)
+	}
+	if len(notes) != 2 {
+		t.Errorf("notes = %q", notes)
+	}
+
+	// Undone operations stay listed, but are not picked again.
+	ops, _ := j.List()
+	if len(ops) != 1 || ops[0].Undone == nil || ops[0].Assets[0].Copy != "" {
+		t.Fatalf("List() after undo = %+v", ops)
+	}
+	if latest, _ := j.Latest(); latest != nil {
+		t.Errorf("Latest() after undo = %s, want none", latest.ID)
+	}
+	if err := j.Undo(c, ops[0], func(string) {}); err == nil {
+		t.Error("undoing twice should fail")
+	}
+}
+
+func TestUndo_MoveAndAdd(t *testing.T) {
+	j := Open(t.TempDir())
+	c := testutil.NewGitHub()
+	from := Location{Owner: "me", Repo: "shelf", Release: "library", Asset: "sicp.pdf"}
+	to := Location{Owner: "me", Repo: "papers", Release: "library", Asset: "sicp.pdf"}
+	added := Location{Owner: "me", Repo: "shelf", Release: "library", Asset: "new.pdf"}
+	put(c, to, "sicp content")
+	put(c, added, "new")
+
+	op := j.Begin("move", "move sicp")
+	op.Moved(from, to)
+	op.Added(added)
+	op.Catalog("me", "shelf", "catalog.yml", []byte(twoBooks), nil)
+	if err := j.Save(op); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Undo(c, op, func(string) {}); err != nil {
+		t.Fatal(err)
+	}
+	if !has(c, from) || has(c, to) {
+		t.Error("moved asset not moved back")
+	}
+	if has(c, added) {
+		t.Error("added asset not deleted")
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	j := Open(t.TempDir())
+	c := testutil.NewGitHub()
+	c.Repo("me", "shelf").Files["catalog.yml"] = []byte(oneBook)
+
+	op := j.Begin("tags rename", "rename tag")
+	op.Catalog("me", "shelf", "catalog.yml", []byte(twoBooks), []byte(oneBook))
+
+	if conflicts, _ := Conflicts(c, op); len(conflicts) != 0 {
+		t.Errorf("unchanged catalog reported as conflict: %v", conflicts)
+	}
+	// Reformatting is not a change.
+	c.Repo("me", "shelf").Files["catalog.yml"] = []byte(strings.ReplaceAll(oneBook, "title: TAOCP", "title: 'TAOCP'"))
+	if conflicts, _ := Conflicts(c, op); len(conflicts) != 0 {
+		t.Errorf("reformatted catalog reported as conflict: %v", conflicts)
+	}
+	c.Repo("me", "shelf").Files["catalog.yml"] = []byte(twoBooks)
+	if conflicts, _ := Conflicts(c, op); len(conflicts) != 1 {
+		t.Errorf("changed catalog not reported: %v", conflicts)
+	}
+}
+
+func TestSave_DiscardsEmptyAndPrunes(t *testing.T) {
+	j := Open(t.TempDir())
+
+	empty := j.Begin("edit-book", "nothing")
+	if err := j.Save(empty); err != nil {
+		t.Fatal(err)
+	}
+	if ops, _ := j.List(); len(ops) != 0 {
+		t.Fatalf("empty operation saved: %v", ops)
+	}
+
+	for i := 0; i < MaxOperations+3; i++ {
+		op := j.Begin("edit-book", fmt.Sprintf("edit %d", i))
+		op.Catalog("me", "shelf", "catalog.yml", nil, nil)
+		if err := j.Save(op); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ops, err := j.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != MaxOperations {
+		t.Fatalf("kept %d operations, want %d", len(ops), MaxOperations)
+	}
+	if ops[0].Summary != fmt.Sprintf("edit %d", MaxOperations+2) {
+		t.Errorf("newest = %q", ops[0].Summary)
+	}
+	if _, err := j.Get(ops[0].ID); err != nil {
+		t.Error(err)
+	}
+	if _, err := j.Get("nope"); err == nil {
+		t.Error("Get of unknown ID should fail")
+	}
+}
+
+func TestUndo_Replace(t *testing.T) {
+	j := Open(t.TempDir())
+	c := testutil.NewGitHub()
+	loc := Location{Owner: "me", Repo: "shelf", Release: "library", Asset: "sicp.pdf"}
+	put(c, loc, "new content")
+
+	// shelve --force replaced the asset: the old one deleted, the new added.
+	op := j.Begin("shelve", "add sicp")
+	name, err := op.SaveCopy(loc, strings.NewReader("old content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	op.Deleted(loc, name)
+	op.Added(loc)
+	if err := j.Save(op); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Undo(c, op, func(string) {}); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(c.Assets("me", "shelf", "library")["sicp.pdf"]); got != "old content" {
+		t.Errorf("asset after undo = %q, want the old content", got)
+	}
+}
+
+func TestDeleteAssets_Sync(t *testing.T) {
+	j := Open(t.TempDir())
+	c := testutil.NewGitHub()
+	src := catalog.Source{Release: "library", Asset: "big.pdf", Parts: []catalog.Part{{Asset: "big.pdf.part1"}, {Asset: "big.pdf.part2"}}}
+	locs := Locations("me", "shelf", src)
+	put(c, locs[0], "old 1")
+	put(c, locs[1], "old 2")
+	rel, _ := c.GetReleaseByTag("me", "shelf", "library")
+
+	// sync deletes the parts and uploads the new version as one asset.
+	op := j.Begin("sync", "sync big")
+	lost, err := op.DeleteAssets(c, "me", "shelf", rel.ID, src)
+	if err != nil || len(lost) != 0 {
+		t.Fatalf("DeleteAssets = %v, %v", lost, err)
+	}
+	if has(c, locs[0]) || has(c, locs[1]) {
+		t.Fatal("parts not deleted")
+	}
+	one := Location{Owner: "me", Repo: "shelf", Release: "library", Asset: "big.pdf"}
+	put(c, one, "new")
+	op.Added(one)
+	if err := j.Save(op); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Undo(c, op, func(string) {}); err != nil {
+		t.Fatal(err)
+	}
+	if has(c, one) || string(c.Assets("me", "shelf", "library")["big.pdf.part2"]) != "old 2" {
+		t.Errorf("assets after undo = %v", c.Assets("me", "shelf", "library"))
+	}
+}
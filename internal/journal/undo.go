@@ -0,0 +1,206 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/github"
+)
+
+// Client is the part of the GitHub client undoing needs.
+type Client interface {
+	GetFileContent(owner, repo, path, ref string) ([]byte, string, error)
+	CommitFile(owner, repo, filePath string, content []byte, message string) error
+	GetReleaseByTag(owner, repo, tag string) (*github.Release, error)
+	EnsureRelease(owner, repo, tag string) (*github.Release, error)
+	FindAsset(owner, repo string, releaseID int64, name string) (*github.Asset, error)
+	DownloadAsset(owner, repo string, assetID int64) (io.ReadCloser, error)
+	UploadAsset(owner, repo string, releaseID int64, name string, r io.Reader, size int64, contentType string) (*github.Asset, error)
+	DeleteAsset(owner, repo string, assetID int64) error
+}
+
+// Conflicts returns the catalogs of an operation that were changed since,
+// which undoing would overwrite.
+func Conflicts(c Client, o *Op) ([]CatalogChange, error) {
+	var changed []CatalogChange
+	for _, cc := range o.Catalogs {
+		data, _, err := c.GetFileContent(cc.Owner, cc.Repo, cc.Path, "")
+		if err != nil && !errors.Is(err, github.ErrNotFound) {
+			return nil, fmt.Errorf("%s/%s: %w", cc.Owner, cc.Repo, err)
+		}
+		if Digest(data) != cc.After {
+			changed = append(changed, cc)
+		}
+	}
+	return changed, nil
+}
+
+// Undo reverts an operation: moved assets go back, added assets are
+// deleted, deleted assets are uploaded again from their copies, and then the
+// catalogs are restored, so they never point at assets that are not there.
+// Added assets go before deleted ones are restored, since an asset replaced
+// under the same name is both. notify is told about each step. The
+// operation is then marked undone and its copies removed.
+func (j *Journal) Undo(c Client, o *Op, notify func(string)) error {
+	if o.Undone != nil {
+		return fmt.Errorf("operation %s was already undone", o.ID)
+	}
+
+	for _, kind := range []AssetKind{AssetMoved, AssetAdded, AssetDeleted} {
+		for _, a := range o.Assets {
+			if a.Kind != kind {
+				continue
+			}
+			var err error
+			switch a.Kind {
+			case AssetMoved:
+				err = moveBack(c, a.From, a.To, notify)
+			case AssetAdded:
+				err = deleteAsset(c, a.To, notify)
+			case AssetDeleted:
+				if a.Copy == "" {
+					notify(fmt.Sprintf("No copy of %s was kept, it stays missing", a.From))
+					continue
+				}
+				err = restoreCopy(c, a.From, o.copyPath(a.Copy), notify)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, cc := range o.Catalogs {
+		msg := fmt.Sprintf("undo: %s", o.Summary)
+		if err := c.CommitFile(cc.Owner, cc.Repo, cc.Path, []byte(cc.Before), msg); err != nil {
+			return fmt.Errorf("restoring %s/%s catalog: %w", cc.Owner, cc.Repo, err)
+		}
+		notify(fmt.Sprintf("Restored catalog of %s/%s", cc.Owner, cc.Repo))
+	}
+
+	now := time.Now()
+	o.Undone = &now
+	for i := range o.Assets {
+		if o.Assets[i].Copy != "" {
+			_ = os.Remove(o.copyPath(o.Assets[i].Copy))
+			o.Assets[i].Copy = ""
+		}
+	}
+	return j.Save(o)
+}
+
+// findAsset returns an asset at loc, or nil if it or its release is missing.
+func findAsset(c Client, loc Location) (*github.Asset, error) {
+	rel, err := c.GetReleaseByTag(loc.Owner, loc.Repo, loc.Release)
+	if errors.Is(err, github.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.FindAsset(loc.Owner, loc.Repo, rel.ID, loc.Asset)
+}
+
+// upload uploads r to loc unless an asset of that name is already there.
+func upload(c Client, loc Location, r io.Reader, size int64) (bool, error) {
+	rel, err := c.EnsureRelease(loc.Owner, loc.Repo, loc.Release)
+	if err != nil {
+		return false, fmt.Errorf("release %s: %w", loc.Release, err)
+	}
+	existing, err := c.FindAsset(loc.Owner, loc.Repo, rel.ID, loc.Asset)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return false, nil
+	}
+	if _, err := c.UploadAsset(loc.Owner, loc.Repo, rel.ID, loc.Asset, r, size, "application/octet-stream"); err != nil {
+		return false, fmt.Errorf("uploading %s: %w", loc, err)
+	}
+	return true, nil
+}
+
+func restoreCopy(c Client, to Location, path string, notify func(string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("copy of %s: %w", to, err)
+	}
+	defer func() { _ = f.Close() }()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	uploaded, err := upload(c, to, f, fi.Size())
+	if err != nil {
+		return err
+	}
+	if uploaded {
+		notify(fmt.Sprintf("Uploaded %s", to))
+	} else {
+		notify(fmt.Sprintf("%s is already there", to))
+	}
+	return nil
+}
+
+func moveBack(c Client, from, to Location, notify func(string)) error {
+	asset, err := findAsset(c, to)
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		// Moved back by an earlier, interrupted undo?
+		if back, err := findAsset(c, from); err == nil && back != nil {
+			notify(fmt.Sprintf("%s is already there", from))
+			return nil
+		}
+		return fmt.Errorf("%s is gone, cannot move it back", to)
+	}
+
+	tmp, err := os.CreateTemp("", "shelfctl-undo-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	rc, err := c.DownloadAsset(to.Owner, to.Repo, asset.ID)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", to, err)
+	}
+	size, err := io.Copy(tmp, rc)
+	_ = rc.Close()
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", to, err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := upload(c, from, tmp, size); err != nil {
+		return err
+	}
+	if err := c.DeleteAsset(to.Owner, to.Repo, asset.ID); err != nil {
+		return fmt.Errorf("deleting %s: %w", to, err)
+	}
+	notify(fmt.Sprintf("Moved %s back to %s/%s@%s", to.Asset, from.Owner, from.Repo, from.Release))
+	return nil
+}
+
+func deleteAsset(c Client, loc Location, notify func(string)) error {
+	asset, err := findAsset(c, loc)
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return nil
+	}
+	if err := c.DeleteAsset(loc.Owner, loc.Repo, asset.ID); err != nil {
+		return fmt.Errorf("deleting %s: %w", loc, err)
+	}
+	notify(fmt.Sprintf("Deleted %s", loc))
+	return nil
+}
@@ -0,0 +1,216 @@
+package opds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// OPDS 1.2 media types.
+const (
+	atomNavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	atomAcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	openSearchType      = "application/opensearchdescription+xml"
+)
+
+type atomFeed struct {
+	XMLName      xml.Name    `xml:"feed"`
+	Xmlns        string      `xml:"xmlns,attr"`
+	XmlnsDC      string      `xml:"xmlns:dc,attr"`
+	XmlnsOPDS    string      `xml:"xmlns:opds,attr"`
+	XmlnsOS      string      `xml:"xmlns:opensearch,attr"`
+	XmlnsThr     string      `xml:"xmlns:thr,attr"`
+	ID           string      `xml:"id"`
+	Title        string      `xml:"title"`
+	Updated      string      `xml:"updated"`
+	Author       atomAuthor  `xml:"author"`
+	TotalResults int         `xml:"opensearch:totalResults,omitempty"`
+	ItemsPerPage int         `xml:"opensearch:itemsPerPage,omitempty"`
+	Links        []atomLink  `xml:"link"`
+	Entries      []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+	Count int    `xml:"thr:count,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Authors    []atomAuthor   `xml:"author"`
+	Issued     string         `xml:"dc:issued,omitempty"`
+	Publisher  string         `xml:"dc:publisher,omitempty"`
+	Identifier string         `xml:"dc:identifier,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Content    *atomContent   `xml:"content"`
+	Links      []atomLink     `xml:"link"`
+}
+
+// renderAtom renders f as an OPDS 1.2 Atom document. root is the URL path
+// feeds are served under.
+func renderAtom(f *feed, root string, now time.Time) ([]byte, error) {
+	updated := now.UTC().Format(time.RFC3339)
+	kind := atomAcquisitionType
+	if f.isNavigation() {
+		kind = atomNavigationType
+	}
+
+	out := atomFeed{
+		Xmlns:     "http://www.w3.org/2005/Atom",
+		XmlnsDC:   "http://purl.org/dc/terms/",
+		XmlnsOPDS: "http://opds-spec.org/2010/catalog",
+		XmlnsOS:   "http://a9.com/-/spec/opensearch/1.1/",
+		XmlnsThr:  "http://purl.org/syndication/thread/1.0",
+		ID:        "urn:shelfctl:" + f.ID,
+		Title:     f.Title,
+		Updated:   updated,
+		Author:    atomAuthor{Name: "shelfctl"},
+		Links: []atomLink{
+			{Rel: "self", Href: root + f.pagePath(f.Page), Type: kind},
+			{Rel: "start", Href: root, Type: atomNavigationType},
+			{Rel: "search", Href: root + "/opensearch.xml", Type: openSearchType},
+		},
+	}
+	if !f.isNavigation() {
+		out.TotalResults = f.Total
+		out.ItemsPerPage = pageSize
+		if f.Page > 1 {
+			out.Links = append(out.Links, atomLink{Rel: "previous", Href: root + f.pagePath(f.Page-1), Type: kind})
+		}
+		if f.Page < f.Pages {
+			out.Links = append(out.Links, atomLink{Rel: "next", Href: root + f.pagePath(f.Page+1), Type: kind})
+		}
+	}
+
+	for _, n := range f.Nav {
+		e := atomEntry{
+			Title:   n.Title,
+			ID:      "urn:shelfctl:nav" + n.Path,
+			Updated: updated,
+			Links:   []atomLink{{Rel: "subsection", Href: root + n.Path, Type: navType(n), Count: n.Count}},
+		}
+		if n.Count > 0 {
+			e.Content = &atomContent{Type: "text", Text: fmt.Sprintf("%d", n.Count)}
+		}
+		out.Entries = append(out.Entries, e)
+	}
+	for _, it := range f.Books {
+		out.Entries = append(out.Entries, atomBookEntry(it, updated))
+	}
+
+	data, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// navType returns the feed type a navigation entry links to: the group
+// indexes are navigation feeds, everything else lists books.
+func navType(n navItem) string {
+	switch n.Path {
+	case "/shelves", "/tags", "/authors":
+		return atomNavigationType
+	}
+	return atomAcquisitionType
+}
+
+func atomBookEntry(it bookItem, fallbackUpdated string) atomEntry {
+	b := it.Book
+	e := atomEntry{
+		Title:      b.Title,
+		ID:         "urn:shelfctl:book:" + it.Shelf + ":" + b.ID,
+		Updated:    entryUpdated(b.Meta.AddedAt, fallbackUpdated),
+		Publisher:  b.Publisher,
+		Identifier: isbnURN(b.ISBN),
+	}
+	if b.Author != "" {
+		e.Authors = []atomAuthor{{Name: b.Author}}
+	}
+	if b.Year > 0 {
+		e.Issued = fmt.Sprintf("%04d", b.Year)
+	}
+	for _, t := range b.Tags {
+		e.Categories = append(e.Categories, atomCategory{Term: t, Label: t})
+	}
+	e.Content = &atomContent{Type: "text", Text: summary(it)}
+	e.Links = []atomLink{
+		{Rel: "http://opds-spec.org/image", Href: bookPath(it.Shelf, b.ID, "cover"), Type: "image/jpeg"},
+		{Rel: "http://opds-spec.org/image/thumbnail", Href: bookPath(it.Shelf, b.ID, "cover"), Type: "image/jpeg"},
+		{Rel: "http://opds-spec.org/acquisition", Href: bookPath(it.Shelf, b.ID, "file"), Type: MediaType(b.Format)},
+	}
+	return e
+}
+
+// summary is the short description shown under a book in reader apps.
+func summary(it bookItem) string {
+	s := fmt.Sprintf("%s · shelf %s", it.Book.Format, it.Shelf)
+	if it.Book.Pages > 0 {
+		s += fmt.Sprintf(" · %d pages", it.Book.Pages)
+	}
+	return s
+}
+
+func entryUpdated(addedAt, fallback string) string {
+	if t, err := time.Parse(time.RFC3339, addedAt); err == nil {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return fallback
+}
+
+func isbnURN(isbn string) string {
+	if isbn == "" {
+		return ""
+	}
+	return "urn:isbn:" + isbn
+}
+
+type openSearchDescription struct {
+	XMLName     xml.Name `xml:"OpenSearchDescription"`
+	Xmlns       string   `xml:"xmlns,attr"`
+	ShortName   string   `xml:"ShortName"`
+	Description string   `xml:"Description"`
+	InputEnc    string   `xml:"InputEncoding"`
+	OutputEnc   string   `xml:"OutputEncoding"`
+	URL         struct {
+		Type     string `xml:"type,attr"`
+		Template string `xml:"template,attr"`
+	} `xml:"Url"`
+}
+
+// renderOpenSearch renders the OpenSearch description for the Atom feeds.
+func renderOpenSearch(root string) ([]byte, error) {
+	d := openSearchDescription{
+		Xmlns:       "http://a9.com/-/spec/opensearch/1.1/",
+		ShortName:   "shelfctl",
+		Description: "Search books by title, author or tag",
+		InputEnc:    "UTF-8",
+		OutputEnc:   "UTF-8",
+	}
+	d.URL.Type = atomAcquisitionType
+	d.URL.Template = root + "/search?q={searchTerms}"
+	data, err := xml.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
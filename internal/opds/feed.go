@@ -0,0 +1,217 @@
+package opds
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+// pageSize is how many books an acquisition feed lists per page.
+const pageSize = 50
+
+// feed is the format-independent form of one feed. Paths are relative to
+// the feed root (/opds or /opds/v2).
+type feed struct {
+	ID    string
+	Title string
+	Path  string
+	Nav   []navItem
+	Books []bookItem
+
+	// Pagination of Books; Page is 1-based.
+	Total int
+	Page  int
+	Pages int
+	Query string
+}
+
+type navItem struct {
+	Title string
+	Path  string
+	Count int
+}
+
+type bookItem struct {
+	Shelf string
+	Book  catalog.Book
+}
+
+// isNavigation reports whether the feed lists links rather than books.
+func (f *feed) isNavigation() bool {
+	return f.Books == nil && f.Nav != nil
+}
+
+// pagePath returns the path of page n of the feed.
+func (f *feed) pagePath(n int) string {
+	v := url.Values{}
+	if f.Query != "" {
+		v.Set("q", f.Query)
+	}
+	if n > 1 {
+		v.Set("page", strconv.Itoa(n))
+	}
+	if len(v) == 0 {
+		return f.Path
+	}
+	return f.Path + "?" + v.Encode()
+}
+
+// buildFeed resolves a feed path (already split into unescaped segments)
+// against the loaded shelves. It returns nil for unknown paths.
+func buildFeed(shelves []Shelf, segs []string, query string, page int) *feed {
+	if len(segs) == 0 {
+		return rootFeed(shelves)
+	}
+
+	var f *feed
+	switch {
+	case segs[0] == "shelves" && len(segs) == 1:
+		f = &feed{ID: "shelves", Title: "Shelves", Path: "/shelves", Nav: []navItem{}}
+		for _, s := range shelves {
+			f.Nav = append(f.Nav, navItem{Title: s.Name, Path: "/shelves/" + url.PathEscape(s.Name), Count: len(s.Books)})
+		}
+		return f
+	case segs[0] == "shelves" && len(segs) == 2:
+		for _, s := range shelves {
+			if s.Name == segs[1] {
+				f = &feed{ID: "shelf:" + s.Name, Title: s.Name, Path: "/shelves/" + url.PathEscape(s.Name)}
+				f.Books = items(s.Name, s.Books)
+			}
+		}
+	case segs[0] == "tags" && len(segs) == 1:
+		return groupFeed("tags", "Tags", shelves, func(b catalog.Book) []string { return b.Tags })
+	case segs[0] == "tags" && len(segs) == 2:
+		f = &feed{ID: "tag:" + segs[1], Title: "Tag: " + segs[1], Path: "/tags/" + url.PathEscape(segs[1])}
+		f.Books = selectBooks(shelves, func(b catalog.Book) bool { return containsFold(b.Tags, segs[1]) })
+	case segs[0] == "authors" && len(segs) == 1:
+		return groupFeed("authors", "Authors", shelves, func(b catalog.Book) []string {
+			if b.Author == "" {
+				return nil
+			}
+			return []string{b.Author}
+		})
+	case segs[0] == "authors" && len(segs) == 2:
+		f = &feed{ID: "author:" + segs[1], Title: segs[1], Path: "/authors/" + url.PathEscape(segs[1])}
+		f.Books = selectBooks(shelves, func(b catalog.Book) bool { return b.Author == segs[1] })
+	case segs[0] == "all" && len(segs) == 1:
+		f = &feed{ID: "all", Title: "All books", Path: "/all"}
+		f.Books = selectBooks(shelves, func(catalog.Book) bool { return true })
+	case segs[0] == "search" && len(segs) == 1:
+		f = &feed{ID: "search:" + query, Title: "Search: " + query, Path: "/search", Query: query}
+		filter := catalog.Filter{Search: query}
+		f.Books = []bookItem{}
+		for _, s := range shelves {
+			f.Books = append(f.Books, items(s.Name, filter.Apply(s.Books))...)
+		}
+	}
+	if f == nil {
+		return nil
+	}
+	if f.Books == nil {
+		f.Books = []bookItem{}
+	}
+	paginate(f, page)
+	return f
+}
+
+func rootFeed(shelves []Shelf) *feed {
+	total := 0
+	for _, s := range shelves {
+		total += len(s.Books)
+	}
+	return &feed{
+		ID:    "root",
+		Title: "shelfctl library",
+		Path:  "",
+		Nav: []navItem{
+			{Title: "Shelves", Path: "/shelves", Count: len(shelves)},
+			{Title: "Tags", Path: "/tags"},
+			{Title: "Authors", Path: "/authors"},
+			{Title: "All books", Path: "/all", Count: total},
+		},
+	}
+}
+
+// groupFeed builds a navigation feed with one entry per distinct key,
+// sorted case-insensitively, each counting the books it covers.
+func groupFeed(kind, title string, shelves []Shelf, keys func(catalog.Book) []string) *feed {
+	counts := map[string]int{}
+	for _, s := range shelves {
+		for _, b := range s.Books {
+			for _, k := range keys(b) {
+				counts[k]++
+			}
+		}
+	}
+	names := make([]string, 0, len(counts))
+	for k := range counts {
+		names = append(names, k)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	f := &feed{ID: kind, Title: title, Path: "/" + kind, Nav: []navItem{}}
+	for _, k := range names {
+		f.Nav = append(f.Nav, navItem{Title: k, Path: "/" + kind + "/" + url.PathEscape(k), Count: counts[k]})
+	}
+	return f
+}
+
+func selectBooks(shelves []Shelf, keep func(catalog.Book) bool) []bookItem {
+	out := []bookItem{}
+	for _, s := range shelves {
+		for _, b := range s.Books {
+			if keep(b) {
+				out = append(out, bookItem{Shelf: s.Name, Book: b})
+			}
+		}
+	}
+	return out
+}
+
+func items(shelf string, books []catalog.Book) []bookItem {
+	out := make([]bookItem, 0, len(books))
+	for _, b := range books {
+		out = append(out, bookItem{Shelf: shelf, Book: b})
+	}
+	return out
+}
+
+func paginate(f *feed, page int) {
+	f.Total = len(f.Books)
+	f.Pages = (f.Total + pageSize - 1) / pageSize
+	if f.Pages == 0 {
+		f.Pages = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+	if page > f.Pages {
+		page = f.Pages
+	}
+	f.Page = page
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if end > f.Total {
+		end = f.Total
+	}
+	f.Books = f.Books[start:end]
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// bookPath returns the server path of a book resource ("file" or "cover").
+func bookPath(shelf, id, what string) string {
+	return "/books/" + url.PathEscape(shelf) + "/" + url.PathEscape(id) + "/" + what
+}
@@ -0,0 +1,135 @@
+package opds
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// opdsJSONType is the OPDS 2.0 media type.
+const opdsJSONType = "application/opds+json"
+
+type jsonFeed struct {
+	Metadata     jsonFeedMetadata   `json:"metadata"`
+	Links        []jsonLink         `json:"links"`
+	Navigation   []jsonLink         `json:"navigation,omitempty"`
+	Publications *[]jsonPublication `json:"publications,omitempty"`
+}
+
+type jsonFeedMetadata struct {
+	Title         string `json:"title"`
+	NumberOfItems int    `json:"numberOfItems,omitempty"`
+	ItemsPerPage  int    `json:"itemsPerPage,omitempty"`
+	CurrentPage   int    `json:"currentPage,omitempty"`
+}
+
+type jsonLink struct {
+	Rel        string          `json:"rel,omitempty"`
+	Href       string          `json:"href"`
+	Type       string          `json:"type,omitempty"`
+	Title      string          `json:"title,omitempty"`
+	Templated  bool            `json:"templated,omitempty"`
+	Properties *jsonProperties `json:"properties,omitempty"`
+}
+
+type jsonProperties struct {
+	NumberOfItems int `json:"numberOfItems,omitempty"`
+}
+
+type jsonPublication struct {
+	Metadata jsonPubMetadata `json:"metadata"`
+	Links    []jsonLink      `json:"links"`
+	Images   []jsonLink      `json:"images"`
+}
+
+type jsonPubMetadata struct {
+	Type        string        `json:"@type"`
+	Title       string        `json:"title"`
+	Identifier  string        `json:"identifier"`
+	Author      []jsonContrib `json:"author,omitempty"`
+	Publisher   string        `json:"publisher,omitempty"`
+	Published   string        `json:"published,omitempty"`
+	Modified    string        `json:"modified,omitempty"`
+	Subject     []string      `json:"subject,omitempty"`
+	NumberPages int           `json:"numberOfPages,omitempty"`
+	Description string        `json:"description,omitempty"`
+}
+
+type jsonContrib struct {
+	Name string `json:"name"`
+}
+
+// renderJSON renders f as an OPDS 2.0 JSON document. root is the URL path
+// feeds are served under.
+func renderJSON(f *feed, root string, now time.Time) ([]byte, error) {
+	out := jsonFeed{
+		Metadata: jsonFeedMetadata{Title: f.Title},
+		Links: []jsonLink{
+			{Rel: "self", Href: root + f.pagePath(f.Page), Type: opdsJSONType},
+			{Rel: "start", Href: root, Type: opdsJSONType},
+			{Rel: "search", Href: root + "/search{?q}", Type: opdsJSONType, Templated: true},
+		},
+	}
+
+	for _, n := range f.Nav {
+		l := jsonLink{Rel: "subsection", Href: root + n.Path, Type: opdsJSONType, Title: n.Title}
+		if n.Count > 0 {
+			l.Properties = &jsonProperties{NumberOfItems: n.Count}
+		}
+		out.Navigation = append(out.Navigation, l)
+	}
+
+	if !f.isNavigation() {
+		out.Metadata.NumberOfItems = f.Total
+		out.Metadata.ItemsPerPage = pageSize
+		out.Metadata.CurrentPage = f.Page
+		if f.Page > 1 {
+			out.Links = append(out.Links, jsonLink{Rel: "previous", Href: root + f.pagePath(f.Page-1), Type: opdsJSONType})
+		}
+		if f.Page < f.Pages {
+			out.Links = append(out.Links, jsonLink{Rel: "next", Href: root + f.pagePath(f.Page+1), Type: opdsJSONType})
+		}
+		// An empty acquisition feed still carries the key (hence the
+		// pointer), so clients can tell it apart from a navigation feed.
+		pubs := []jsonPublication{}
+		fallback := now.UTC().Format(time.RFC3339)
+		for _, it := range f.Books {
+			pubs = append(pubs, jsonBook(it, fallback))
+		}
+		out.Publications = &pubs
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+func jsonBook(it bookItem, fallbackUpdated string) jsonPublication {
+	b := it.Book
+	p := jsonPublication{
+		Metadata: jsonPubMetadata{
+			Type:        "http://schema.org/Book",
+			Title:       b.Title,
+			Identifier:  "urn:shelfctl:book:" + it.Shelf + ":" + b.ID,
+			Publisher:   b.Publisher,
+			Modified:    entryUpdated(b.Meta.AddedAt, fallbackUpdated),
+			Subject:     b.Tags,
+			NumberPages: b.Pages,
+			Description: summary(it),
+		},
+		Links: []jsonLink{
+			{Rel: "http://opds-spec.org/acquisition", Href: bookPath(it.Shelf, b.ID, "file"), Type: MediaType(b.Format)},
+		},
+		Images: []jsonLink{
+			{Href: bookPath(it.Shelf, b.ID, "cover"), Type: "image/jpeg"},
+		},
+	}
+	if b.ISBN != "" {
+		p.Metadata.Identifier = isbnURN(b.ISBN)
+	}
+	if b.Author != "" {
+		p.Metadata.Author = []jsonContrib{{Name: b.Author}}
+	}
+	if b.Year > 0 {
+		p.Metadata.Published = fmt.Sprintf("%04d", b.Year)
+	}
+	return p
+}
@@ -0,0 +1,55 @@
+// Package opds serves shelf catalogs as OPDS feeds for e-reader apps.
+//
+// Both OPDS 1.2 (Atom XML, under /opds) and OPDS 2.0 (JSON, under /opds/v2)
+// are served from the same navigation tree: shelves, tags, authors, all
+// books and search. Book files and covers are served from /books.
+package opds
+
+import (
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+// Shelf is one shelf's loaded catalog.
+type Shelf struct {
+	Name  string
+	Books []catalog.Book
+}
+
+// Library is where the server gets catalogs and files from.
+type Library interface {
+	// Shelves returns the current catalogs of all served shelves.
+	Shelves() ([]Shelf, error)
+	// BookFile returns a local path to the book's asset, downloading it
+	// first if needed.
+	BookFile(shelf string, b catalog.Book) (string, error)
+	// CoverFile returns a local path to a cover image for the book, or ""
+	// if it has none.
+	CoverFile(shelf string, b catalog.Book) (string, error)
+}
+
+// MediaType returns the MIME type for a catalog format.
+func MediaType(format string) string {
+	switch strings.ToLower(format) {
+	case "epub":
+		return "application/epub+zip"
+	case "pdf":
+		return "application/pdf"
+	case "mobi":
+		return "application/x-mobipocket-ebook"
+	case "azw3", "azw":
+		return "application/vnd.amazon.ebook"
+	case "djvu":
+		return "image/vnd.djvu"
+	case "cbz":
+		return "application/vnd.comicbook+zip"
+	case "cbr":
+		return "application/vnd.comicbook-rar"
+	case "fb2":
+		return "application/x-fictionbook+xml"
+	case "txt":
+		return "text/plain"
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,225 @@
+package opds
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+// Feed roots.
+const (
+	AtomRoot = "/opds"
+	JSONRoot = "/opds/v2"
+)
+
+// Server serves OPDS feeds, book files and covers from a Library.
+type Server struct {
+	Library Library
+
+	// Now returns the feed timestamp; defaults to time.Now.
+	Now func() time.Time
+	// Logf, if set, receives one line per failed request.
+	Logf func(format string, args ...interface{})
+}
+
+// Register adds the OPDS and /books routes to mux.
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+AtomRoot+"/opensearch.xml", s.serveOpenSearch)
+	mux.HandleFunc("GET "+JSONRoot, s.serveFeed(JSONRoot, opdsJSONType+"; charset=utf-8", renderJSON))
+	mux.HandleFunc("GET "+JSONRoot+"/", s.serveFeed(JSONRoot, opdsJSONType+"; charset=utf-8", renderJSON))
+	mux.HandleFunc("GET "+AtomRoot, s.serveFeed(AtomRoot, "application/atom+xml; charset=utf-8", renderAtom))
+	mux.HandleFunc("GET "+AtomRoot+"/", s.serveFeed(AtomRoot, "application/atom+xml; charset=utf-8", renderAtom))
+	s.RegisterBooks(mux)
+}
+
+// RegisterBooks adds only the /books file and cover routes to mux.
+func (s *Server) RegisterBooks(mux *http.ServeMux) {
+	mux.HandleFunc("GET /books/{shelf}/{id}/file", s.serveFile)
+	mux.HandleFunc("GET /books/{shelf}/{id}/cover", s.serveCover)
+}
+
+// Handler returns an http.Handler serving only the OPDS routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	s.Register(mux)
+	return mux
+}
+
+type renderFunc func(f *feed, root string, now time.Time) ([]byte, error)
+
+func (s *Server) serveFeed(root, contentType string, render renderFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		segs, err := splitPath(strings.TrimPrefix(r.URL.EscapedPath(), root))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		shelves, err := s.Library.Shelves()
+		if err != nil {
+			s.fail(w, r, err)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		f := buildFeed(shelves, segs, r.URL.Query().Get("q"), page)
+		if f == nil {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := render(f, root, s.now())
+		if err != nil {
+			s.fail(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(data)
+	}
+}
+
+func (s *Server) serveOpenSearch(w http.ResponseWriter, r *http.Request) {
+	data, err := renderOpenSearch(AtomRoot)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", openSearchType+"; charset=utf-8")
+	_, _ = w.Write(data)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	shelf, b := s.lookup(w, r)
+	if b == nil {
+		return
+	}
+	path, err := s.Library.BookFile(shelf, *b)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", MediaType(b.Format))
+	// ?inline=1 lets a browser display the book instead of saving it.
+	disposition := "attachment"
+	if r.URL.Query().Get("inline") != "" {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename*=UTF-8''%s", disposition, url.PathEscape(downloadName(*b, path))))
+	serveLocalFile(w, r, path)
+}
+
+func (s *Server) serveCover(w http.ResponseWriter, r *http.Request) {
+	shelf, b := s.lookup(w, r)
+	if b == nil {
+		return
+	}
+	path, err := s.Library.CoverFile(shelf, *b)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if path == "" {
+		http.NotFound(w, r)
+		return
+	}
+	serveLocalFile(w, r, path)
+}
+
+// lookup finds the book named by the request path. It writes a 404 and
+// returns nil if there is no such book.
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) (string, *catalog.Book) {
+	shelfName, id := r.PathValue("shelf"), r.PathValue("id")
+	shelves, err := s.Library.Shelves()
+	if err != nil {
+		s.fail(w, r, err)
+		return "", nil
+	}
+	for _, sh := range shelves {
+		if sh.Name != shelfName {
+			continue
+		}
+		if b := catalog.ByID(sh.Books, id); b != nil {
+			return sh.Name, b
+		}
+	}
+	http.NotFound(w, r)
+	return "", nil
+}
+
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if s.Logf != nil {
+		s.Logf("%s %s: %v", r.Method, r.URL.Path, err)
+	}
+	http.Error(w, err.Error(), http.StatusBadGateway)
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// serveLocalFile serves a file with range support, so readers can resume
+// interrupted downloads.
+func serveLocalFile(w http.ResponseWriter, r *http.Request, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), f)
+}
+
+// downloadName is the file name offered to the reader: the book ID with
+// the asset's extension.
+func downloadName(b catalog.Book, path string) string {
+	ext := filepath.Ext(b.Source.Asset)
+	if ext == "" {
+		ext = filepath.Ext(path)
+	}
+	return b.ID + ext
+}
+
+// splitPath splits an escaped path into unescaped segments, so names
+// containing "/" survive the round trip.
+func splitPath(p string) ([]string, error) {
+	var segs []string
+	for _, part := range strings.Split(strings.Trim(p, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		v, err := url.PathUnescape(part)
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, v)
+	}
+	return segs, nil
+}
+
+// BasicAuth wraps h so every request needs the given credentials.
+func BasicAuth(h http.Handler, username, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(u), []byte(username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(p), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="shelfctl", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
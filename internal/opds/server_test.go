@@ -0,0 +1,274 @@
+package opds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+type fakeLibrary struct {
+	shelves   []Shelf
+	dir       string
+	downloads []string
+}
+
+func (l *fakeLibrary) Shelves() ([]Shelf, error) { return l.shelves, nil }
+
+func (l *fakeLibrary) BookFile(shelf string, b catalog.Book) (string, error) {
+	l.downloads = append(l.downloads, shelf+"/"+b.ID)
+	path := filepath.Join(l.dir, b.Source.Asset)
+	return path, os.WriteFile(path, []byte("content of "+b.ID), 0644)
+}
+
+func (l *fakeLibrary) CoverFile(shelf string, b catalog.Book) (string, error) {
+	return "", nil
+}
+
+func newTestServer(t *testing.T) (*fakeLibrary, *httptest.Server) {
+	t.Helper()
+	lib := &fakeLibrary{dir: t.TempDir(), shelves: []Shelf{
+		{Name: "fiction", Books: []catalog.Book{
+			{ID: "dune", Title: "Dune", Author: "Frank Herbert", Year: 1965, Format: "epub",
+				Tags: []string{"scifi"}, Source: catalog.Source{Asset: "dune.epub"}},
+			{ID: "emma", Title: "Emma", Author: "Jane Austen", Format: "pdf",
+				Tags: []string{"classic"}, Source: catalog.Source{Asset: "emma.pdf"}},
+		}},
+		{Name: "tech", Books: []catalog.Book{
+			{ID: "sicp", Title: "SICP", Author: "Harold Abelson", Format: "pdf",
+				Tags: []string{"lisp", "classic"}, ISBN: "9780262510875", Source: catalog.Source{Asset: "sicp.pdf"}},
+		}},
+	}}
+	srv := &Server{Library: lib, Now: func() time.Time { return time.Unix(0, 0) }}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return lib, ts
+}
+
+func get(t *testing.T, url string) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+type testAtom struct {
+	Title   string `xml:"title"`
+	Total   int    `xml:"totalResults"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+func parseAtom(t *testing.T, body string) testAtom {
+	t.Helper()
+	var f testAtom
+	if err := xml.Unmarshal([]byte(body), &f); err != nil {
+		t.Fatalf("invalid Atom: %v\n%s", err, body)
+	}
+	return f
+}
+
+func TestAtomNavigation(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	resp, body := get(t, ts.URL+"/opds")
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/atom+xml") {
+		t.Errorf("content type = %q", resp.Header.Get("Content-Type"))
+	}
+	root := parseAtom(t, body)
+	if len(root.Entries) != 4 || root.Entries[0].Links[0].Href != "/opds/shelves" {
+		t.Fatalf("root entries = %+v", root.Entries)
+	}
+	if !strings.Contains(body, `rel="search" href="/opds/opensearch.xml"`) {
+		t.Error("root feed has no search link")
+	}
+
+	tags := parseAtom(t, mustGet(t, ts.URL+"/opds/tags"))
+	var names []string
+	for _, e := range tags.Entries {
+		names = append(names, e.Title)
+	}
+	if strings.Join(names, ",") != "classic,lisp,scifi" {
+		t.Errorf("tags = %v", names)
+	}
+
+	classic := parseAtom(t, mustGet(t, ts.URL+"/opds/tags/classic"))
+	if classic.Total != 2 || len(classic.Entries) != 2 {
+		t.Errorf("classic feed has %d entries, total %d", len(classic.Entries), classic.Total)
+	}
+
+	author := parseAtom(t, mustGet(t, ts.URL+"/opds/authors/Frank%20Herbert"))
+	if len(author.Entries) != 1 || author.Entries[0].Title != "Dune" {
+		t.Errorf("author feed = %+v", author.Entries)
+	}
+	var acq string
+	for _, l := range author.Entries[0].Links {
+		if l.Rel == "http://opds-spec.org/acquisition" {
+			acq = l.Href
+			if l.Type != "application/epub+zip" {
+				t.Errorf("acquisition type = %q", l.Type)
+			}
+		}
+	}
+	if acq != "/books/fiction/dune/file" {
+		t.Errorf("acquisition href = %q", acq)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	atom := parseAtom(t, mustGet(t, ts.URL+"/opds/search?q=emma"))
+	if len(atom.Entries) != 1 || atom.Entries[0].Title != "Emma" {
+		t.Errorf("search entries = %+v", atom.Entries)
+	}
+
+	desc := mustGet(t, ts.URL+"/opds/opensearch.xml")
+	if !strings.Contains(desc, `template="/opds/search?q={searchTerms}"`) {
+		t.Errorf("opensearch description = %s", desc)
+	}
+}
+
+func TestJSONFeeds(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	resp, body := get(t, ts.URL+"/opds/v2/shelves/tech")
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/opds+json") {
+		t.Errorf("content type = %q", resp.Header.Get("Content-Type"))
+	}
+	var f struct {
+		Metadata struct {
+			NumberOfItems int `json:"numberOfItems"`
+		} `json:"metadata"`
+		Publications []struct {
+			Metadata struct {
+				Title      string `json:"title"`
+				Identifier string `json:"identifier"`
+			} `json:"metadata"`
+			Links []struct {
+				Href string `json:"href"`
+			} `json:"links"`
+		} `json:"publications"`
+	}
+	if err := json.Unmarshal([]byte(body), &f); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if f.Metadata.NumberOfItems != 1 || len(f.Publications) != 1 {
+		t.Fatalf("feed = %s", body)
+	}
+	p := f.Publications[0]
+	if p.Metadata.Identifier != "urn:isbn:9780262510875" || p.Links[0].Href != "/books/tech/sicp/file" {
+		t.Errorf("publication = %+v", p)
+	}
+
+	var nav struct {
+		Navigation []struct {
+			Href string `json:"href"`
+		} `json:"navigation"`
+	}
+	if err := json.Unmarshal([]byte(mustGet(t, ts.URL+"/opds/v2")), &nav); err != nil || len(nav.Navigation) != 4 {
+		t.Errorf("v2 root navigation = %+v (%v)", nav, err)
+	}
+
+	empty := mustGet(t, ts.URL+"/opds/v2/search?q=nothing-matches")
+	if !strings.Contains(empty, `"publications": []`) {
+		t.Errorf("empty search lacks publications list: %s", empty)
+	}
+}
+
+func TestAcquisitionDownloadsThroughLibrary(t *testing.T) {
+	lib, ts := newTestServer(t)
+
+	resp, body := get(t, ts.URL+"/books/tech/sicp/file")
+	if resp.StatusCode != http.StatusOK || body != "content of sicp" {
+		t.Fatalf("status %d body %q", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("content type = %q", ct)
+	}
+	if len(lib.downloads) != 1 || lib.downloads[0] != "tech/sicp" {
+		t.Errorf("downloads = %v", lib.downloads)
+	}
+
+	if resp, _ := get(t, ts.URL+"/books/tech/missing/file"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing book: status %d", resp.StatusCode)
+	}
+	if resp, _ := get(t, ts.URL+"/books/tech/sicp/cover"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("book without cover: status %d", resp.StatusCode)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	var books []catalog.Book
+	for i := 0; i < pageSize+5; i++ {
+		books = append(books, catalog.Book{ID: fmt.Sprintf("b%d", i), Title: fmt.Sprintf("Book %d", i), Format: "pdf"})
+	}
+	f := buildFeed([]Shelf{{Name: "big", Books: books}}, []string{"all"}, "", 2)
+	if f.Total != pageSize+5 || f.Pages != 2 || len(f.Books) != 5 {
+		t.Errorf("page 2: total %d pages %d books %d", f.Total, f.Pages, len(f.Books))
+	}
+	if got := f.pagePath(1); got != "/all" {
+		t.Errorf("pagePath(1) = %q", got)
+	}
+	if got := f.pagePath(2); got != "/all?page=2" {
+		t.Errorf("pagePath(2) = %q", got)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	h := BasicAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}), "reader", "secret")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/opds", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("no credentials: %d", rec.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/opds", nil)
+	req.SetBasicAuth("reader", "wrong")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: %d", rec.Code)
+	}
+
+	req.SetBasicAuth("reader", "secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("right password: %d", rec.Code)
+	}
+}
+
+func mustGet(t *testing.T, url string) string {
+	t.Helper()
+	resp, body := get(t, url)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %d %s", url, resp.StatusCode, body)
+	}
+	return body
+}
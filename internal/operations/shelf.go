@@ -74,6 +74,8 @@ func createRepoAndRelease(gh *github.Client, owner, repoName string, private boo
 	if err != nil {
 		return fmt.Errorf("create repo: %w", err)
 	}
+	// Tag the repo so 'shelves discover' finds it; not fatal if it fails.
+	_ = gh.AddTopic(owner, repoName, github.ShelfTopic)
 
 	// Create release
 	_, err = gh.EnsureRelease(owner, repoName, "library")
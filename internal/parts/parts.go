@@ -0,0 +1,177 @@
+// Package parts stores files too large for one GitHub release asset as
+// numbered part assets, and joins them again on download. The catalog
+// entry lists the parts with their sizes and checksums (catalog.Part).
+package parts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/github"
+)
+
+// Client is the part of the GitHub client parts need.
+type Client interface {
+	FindAsset(owner, repo string, releaseID int64, name string) (*github.Asset, error)
+	DownloadAsset(owner, repo string, assetID int64) (io.ReadCloser, error)
+	UploadAsset(owner, repo string, releaseID int64, name string, r io.Reader, size int64, contentType string) (*github.Asset, error)
+	DeleteAsset(owner, repo string, assetID int64) error
+}
+
+// Plan returns the parts a file of size bytes is split into, without
+// checksums, or nil if it fits in one asset of at most partSize bytes.
+func Plan(asset string, size, partSize int64) []catalog.Part {
+	if partSize <= 0 || size <= partSize {
+		return nil
+	}
+	var plan []catalog.Part
+	for off := int64(0); off < size; off += partSize {
+		plan = append(plan, catalog.Part{
+			Asset: catalog.PartName(asset, len(plan)),
+			Size:  min(partSize, size-off),
+		})
+	}
+	return plan
+}
+
+// Upload uploads size bytes read from r as the parts of asset and returns
+// them with their checksums. Parts uploaded before an error are deleted
+// again. Files that fit in one asset are uploaded as is and nil is
+// returned.
+func Upload(c Client, owner, repo string, releaseID int64, asset string, r io.Reader, size, partSize int64) ([]catalog.Part, error) {
+	plan := Plan(asset, size, partSize)
+	if plan == nil {
+		_, err := c.UploadAsset(owner, repo, releaseID, asset, r, size, "application/octet-stream")
+		return nil, err
+	}
+
+	var uploaded []*github.Asset
+	for i := range plan {
+		h := sha256.New()
+		pr := io.TeeReader(io.LimitReader(r, plan[i].Size), h)
+		a, err := c.UploadAsset(owner, repo, releaseID, plan[i].Asset, pr, plan[i].Size, "application/octet-stream")
+		if err != nil {
+			for _, a := range uploaded {
+				_ = c.DeleteAsset(owner, repo, a.ID)
+			}
+			return nil, fmt.Errorf("uploading %s: %w", plan[i].Asset, err)
+		}
+		if a != nil {
+			uploaded = append(uploaded, a)
+		}
+		plan[i].SHA256 = hex.EncodeToString(h.Sum(nil))
+	}
+	return plan, nil
+}
+
+// Open returns the content of a book's file and its size. The parts of a
+// multi-part asset are downloaded one after the other, and a part whose
+// size or checksum does not match the catalog fails the read. The size is
+// that of the plaintext, as the client decrypts encrypted assets.
+func Open(c Client, owner, repo string, releaseID int64, b catalog.Book) (io.ReadCloser, int64, error) {
+	src := b.Source
+	if !src.IsMultiPart() {
+		asset, err := c.FindAsset(owner, repo, releaseID, src.Asset)
+		if err != nil {
+			return nil, 0, fmt.Errorf("finding asset: %w", err)
+		}
+		if asset == nil {
+			return nil, 0, fmt.Errorf("asset %q not found in release %q", src.Asset, src.Release)
+		}
+		rc, err := c.DownloadAsset(owner, repo, asset.ID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("download: %w", err)
+		}
+		size := asset.Size
+		if b.Encryption != "" && b.SizeBytes > 0 {
+			size = b.SizeBytes // The asset holds the ciphertext
+		}
+		return rc, size, nil
+	}
+
+	j := &joinReader{c: c, owner: owner, repo: repo, parts: src.Parts}
+	var size int64
+	for _, p := range src.Parts {
+		asset, err := c.FindAsset(owner, repo, releaseID, p.Asset)
+		if err != nil {
+			return nil, 0, fmt.Errorf("finding asset: %w", err)
+		}
+		if asset == nil {
+			return nil, 0, fmt.Errorf("part %q not found in release %q", p.Asset, src.Release)
+		}
+		j.ids = append(j.ids, asset.ID)
+		size += p.Size
+	}
+	return j, size, nil
+}
+
+// joinReader reads the parts of an asset in order, checking each.
+type joinReader struct {
+	c     Client
+	owner string
+	repo  string
+	parts []catalog.Part
+	ids   []int64
+
+	i   int           // current part
+	cur io.ReadCloser // nil between parts
+	h   hash.Hash
+	n   int64
+}
+
+func (j *joinReader) Read(p []byte) (int, error) {
+	for {
+		if j.cur == nil {
+			if j.i == len(j.parts) {
+				return 0, io.EOF
+			}
+			rc, err := j.c.DownloadAsset(j.owner, j.repo, j.ids[j.i])
+			if err != nil {
+				return 0, fmt.Errorf("download %s: %w", j.parts[j.i].Asset, err)
+			}
+			j.cur, j.h, j.n = rc, sha256.New(), 0
+		}
+
+		n, err := j.cur.Read(p)
+		j.h.Write(p[:n])
+		j.n += int64(n)
+		if err != io.EOF {
+			return n, err
+		}
+
+		_ = j.cur.Close()
+		j.cur = nil
+		if err := j.check(); err != nil {
+			return n, err
+		}
+		j.i++
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// check compares the part just read with the catalog.
+func (j *joinReader) check() error {
+	part := j.parts[j.i]
+	if j.n != part.Size {
+		return fmt.Errorf("part %s: got %d bytes, expected %d", part.Asset, j.n, part.Size)
+	}
+	if sum := hex.EncodeToString(j.h.Sum(nil)); part.SHA256 != "" && sum != part.SHA256 {
+		return fmt.Errorf("part %s: checksum mismatch (got %s, expected %s)", part.Asset, sum, part.SHA256)
+	}
+	return nil
+}
+
+func (j *joinReader) Close() error {
+	if j.cur == nil {
+		return nil
+	}
+	err := j.cur.Close()
+	j.cur = nil
+	return err
+}
@@ -0,0 +1,137 @@
+package parts
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+// newFake returns a GitHub with an empty release o/r@library and its ID.
+func newFake() (*testutil.GitHub, int64) {
+	c := testutil.NewGitHub()
+	rel, _ := c.EnsureRelease("o", "r", "library")
+	return c, rel.ID
+}
+
+func TestPlan(t *testing.T) {
+	if p := Plan("a.pdf", 10, 10); p != nil {
+		t.Errorf("Plan(10, 10) = %v, want nil", p)
+	}
+	p := Plan("a.pdf", 25, 10)
+	if len(p) != 3 {
+		t.Fatalf("Plan(25, 10) has %d parts, want 3", len(p))
+	}
+	if p[0].Asset != "a.pdf.part001" || p[2].Asset != "a.pdf.part003" {
+		t.Errorf("part names = %s, %s", p[0].Asset, p[2].Asset)
+	}
+	if p[0].Size != 10 || p[2].Size != 5 {
+		t.Errorf("part sizes = %d, %d, want 10, 5", p[0].Size, p[2].Size)
+	}
+}
+
+func TestUploadOpen(t *testing.T) {
+	c, relID := newFake()
+	assets := c.Assets("o", "r", "library")
+	content := strings.Repeat("0123456789", 5) + "xyz"
+
+	parts, err := Upload(c, "o", "r", relID, "big.pdf", strings.NewReader(content), int64(len(content)), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 3 || len(assets) != 3 {
+		t.Fatalf("got %d parts and %d assets, want 3 and 3", len(parts), len(assets))
+	}
+	if _, ok := assets["big.pdf"]; ok {
+		t.Error("the whole file was uploaded too")
+	}
+
+	b := catalog.Book{Source: catalog.Source{Release: "library", Asset: "big.pdf", Parts: parts}}
+	rc, size, err := Open(c, "o", "r", relID, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != content || size != int64(len(content)) {
+		t.Errorf("joined %d bytes (size %d), want the %d uploaded", len(got), size, len(content))
+	}
+
+	// A damaged part fails the read.
+	assets["big.pdf.part002"][0] ^= 0xff
+	rc, _, err = Open(c, "o", "r", relID, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(rc)
+	_ = rc.Close()
+	if err == nil || !strings.Contains(err.Error(), "big.pdf.part002") {
+		t.Errorf("reading a damaged part: err = %v", err)
+	}
+
+	// A missing part fails Open.
+	delete(assets, "big.pdf.part003")
+	if _, _, err := Open(c, "o", "r", relID, b); err == nil {
+		t.Error("Open with a missing part succeeded")
+	}
+}
+
+func TestOpen_EncryptedSize(t *testing.T) {
+	c, relID := newFake()
+	if _, err := Upload(c, "o", "r", relID, "sealed.pdf", strings.NewReader(strings.Repeat("x", 90)), 90, 0); err != nil {
+		t.Fatal(err)
+	}
+	b := catalog.Book{
+		Source:     catalog.Source{Release: "library", Asset: "sealed.pdf"},
+		SizeBytes:  50,
+		Encryption: "test",
+	}
+	rc, size, err := Open(c, "o", "r", relID, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rc.Close()
+	if size != 50 {
+		t.Errorf("size = %d, want the plaintext size from the catalog", size)
+	}
+
+	b.Encryption = ""
+	rc, size, err = Open(c, "o", "r", relID, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rc.Close()
+	if size != 90 {
+		t.Errorf("size of a plain asset = %d, want the asset size", size)
+	}
+}
+
+func TestUploadSingle(t *testing.T) {
+	c, relID := newFake()
+	assets := c.Assets("o", "r", "library")
+	parts, err := Upload(c, "o", "r", relID, "small.pdf", strings.NewReader("tiny"), 4, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts != nil || string(assets["small.pdf"]) != "tiny" {
+		t.Errorf("parts = %v, assets = %v", parts, assets)
+	}
+}
+
+func TestUploadFailureCleansUp(t *testing.T) {
+	c, relID := newFake()
+	assets := c.Assets("o", "r", "library")
+	c.FailUpload = "big.pdf.part002"
+	content := strings.Repeat("x", 50)
+	if _, err := Upload(c, "o", "r", relID, "big.pdf", strings.NewReader(content), 50, 20); err == nil {
+		t.Fatal("Upload succeeded")
+	}
+	if len(assets) != 0 {
+		t.Errorf("%d assets left behind", len(assets))
+	}
+}
@@ -0,0 +1,162 @@
+// Package releases picks the release of a shelf that a new book's assets
+// go into, following the shelf's release policy (config.ReleasePolicy),
+// and measures how full releases are.
+package releases
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/github"
+)
+
+// maxRollovers bounds the search for a release with room.
+const maxRollovers = 1000
+
+// Client is the part of the GitHub client picking a release needs.
+type Client interface {
+	GetReleaseByTag(owner, repo, tag string) (*github.Release, error)
+	ListReleaseAssets(owner, repo string, releaseID int64) ([]github.Asset, error)
+}
+
+// Lister is a Client that can also list a repo's releases.
+type Lister interface {
+	Client
+	ListReleases(owner, repo string) ([]github.Release, error)
+}
+
+// Usage is how many assets and bytes a release holds.
+type Usage struct {
+	Tag    string
+	Assets int
+	Bytes  int64
+}
+
+// Fits reports whether n more assets of size bytes in total fit in the
+// release under p's limits. An empty release always fits, so a book larger
+// than the limits still gets a release of its own.
+func (u Usage) Fits(p config.ReleasePolicy, n int, size int64) bool {
+	if u.Assets == 0 {
+		return true
+	}
+	if p.MaxAssets > 0 && u.Assets+n > p.MaxAssets {
+		return false
+	}
+	if p.MaxBytes > 0 && u.Bytes+size > p.MaxBytes {
+		return false
+	}
+	return true
+}
+
+// Full reports whether the release has reached one of p's limits.
+func (u Usage) Full(p config.ReleasePolicy) bool {
+	return (p.MaxAssets > 0 && u.Assets >= p.MaxAssets) ||
+		(p.MaxBytes > 0 && u.Bytes >= p.MaxBytes)
+}
+
+// Tag returns the release a book with the given year and tags belongs in
+// under p, before any rollover: base, or base followed by the year or the
+// first tag.
+func Tag(p config.ReleasePolicy, base string, year int, tags []string) string {
+	switch p.By {
+	case config.ReleaseByYear:
+		if year > 0 {
+			return fmt.Sprintf("%s-%d", base, year)
+		}
+	case config.ReleaseByTag:
+		if len(tags) > 0 {
+			if s := slug(tags[0]); s != "" {
+				return base + "-" + s
+			}
+		}
+	}
+	return base
+}
+
+// Rollover returns the i-th release of tag: tag itself for 1, then tag.2,
+// tag.3 and so on. Tag never puts a dot in a release name, so a rollover
+// cannot be mistaken for the release of a year or a tag such as "2".
+func Rollover(tag string, i int) string {
+	if i <= 1 {
+		return tag
+	}
+	return fmt.Sprintf("%s.%d", tag, i)
+}
+
+// Pick returns the first of tag, tag.2, tag.3, … that does not exist yet
+// or has room for n more assets of size bytes under p's limits. Without
+// limits it returns tag.
+func Pick(c Client, owner, repo string, p config.ReleasePolicy, tag string, n int, size int64) (string, error) {
+	if p.MaxAssets == 0 && p.MaxBytes == 0 {
+		return tag, nil
+	}
+	for i := 1; i <= maxRollovers; i++ {
+		candidate := Rollover(tag, i)
+		rel, err := c.GetReleaseByTag(owner, repo, candidate)
+		if errors.Is(err, github.ErrNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("release %s: %w", candidate, err)
+		}
+		u, err := Measure(c, owner, repo, rel)
+		if err != nil {
+			return "", err
+		}
+		if u.Fits(p, n, size) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no release of %s has room after %d rollovers", tag, maxRollovers)
+}
+
+// Measure returns the usage of a release.
+func Measure(c Client, owner, repo string, rel *github.Release) (Usage, error) {
+	assets, err := c.ListReleaseAssets(owner, repo, rel.ID)
+	if err != nil {
+		return Usage{}, fmt.Errorf("listing assets of %s: %w", rel.TagName, err)
+	}
+	u := Usage{Tag: rel.TagName, Assets: len(assets)}
+	for _, a := range assets {
+		u.Bytes += a.Size
+	}
+	return u, nil
+}
+
+// Capacity returns the usage of every release of a repo, oldest first,
+// which puts rolled-over releases after the ones they continue.
+func Capacity(c Lister, owner, repo string) ([]Usage, error) {
+	rels, err := c.ListReleases(owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("listing releases: %w", err)
+	}
+	usage := make([]Usage, 0, len(rels))
+	for i := len(rels) - 1; i >= 0; i-- {
+		u, err := Measure(c, owner, repo, &rels[i])
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// slug turns a tag into something usable in a release tag.
+func slug(s string) string {
+	var b strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if sep && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package releases
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+// fakeRelease is a release and the sizes of its assets.
+type fakeRelease struct {
+	tag   string
+	sizes []int64
+}
+
+// newFake returns a GitHub with the releases in o/r, oldest first.
+func newFake(rels ...fakeRelease) *testutil.GitHub {
+	c := testutil.NewGitHub()
+	for _, r := range rels {
+		_, _ = c.EnsureRelease("o", "r", r.tag)
+		for i, size := range r.sizes {
+			c.PutAsset("o", "r", r.tag, fmt.Sprintf("%d.pdf", i), strings.Repeat("x", int(size)))
+		}
+	}
+	return c
+}
+
+func TestTag(t *testing.T) {
+	tests := []struct {
+		policy config.ReleasePolicy
+		year   int
+		tags   []string
+		want   string
+	}{
+		{config.ReleasePolicy{}, 2024, []string{"go"}, "library"},
+		{config.ReleasePolicy{By: "year"}, 2024, nil, "library-2024"},
+		{config.ReleasePolicy{By: "year"}, 0, nil, "library"},
+		{config.ReleasePolicy{By: "tag"}, 0, []string{"Machine Learning", "ai"}, "library-machine-learning"},
+		{config.ReleasePolicy{By: "tag"}, 0, []string{"??"}, "library"},
+		{config.ReleasePolicy{By: "tag"}, 0, nil, "library"},
+	}
+	for _, tt := range tests {
+		if got := Tag(tt.policy, "library", tt.year, tt.tags); got != tt.want {
+			t.Errorf("Tag(%+v, %d, %v) = %q, want %q", tt.policy, tt.year, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestPick(t *testing.T) {
+	c := newFake(fakeRelease{"lib", []int64{10, 10}}, fakeRelease{"lib.2", []int64{10}})
+	tests := []struct {
+		name   string
+		policy config.ReleasePolicy
+		n      int
+		size   int64
+		want   string
+	}{
+		{"no limits", config.ReleasePolicy{}, 1, 10, "lib"},
+		{"room", config.ReleasePolicy{MaxAssets: 3}, 1, 10, "lib"},
+		{"asset limit", config.ReleasePolicy{MaxAssets: 2}, 1, 10, "lib.2"},
+		{"parts count", config.ReleasePolicy{MaxAssets: 3}, 2, 10, "lib.2"},
+		{"byte limit", config.ReleasePolicy{MaxBytes: 25}, 1, 10, "lib.2"},
+		{"both full", config.ReleasePolicy{MaxBytes: 15}, 1, 10, "lib.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Pick(c, "o", "r", tt.policy, "lib", tt.n, tt.size)
+			if err != nil {
+				t.Fatalf("Pick: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Pick = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPick_TagLooksLikeRollover(t *testing.T) {
+	// Books tagged "2" have a release of their own, which is not the
+	// rollover of the default release.
+	c := newFake(fakeRelease{"lib", []int64{10}}, fakeRelease{"lib-2", []int64{10}})
+	p := config.ReleasePolicy{By: "tag", MaxAssets: 1}
+	tagged := Tag(p, "lib", 0, []string{"2"})
+	if tagged != "lib-2" {
+		t.Fatalf("Tag = %q", tagged)
+	}
+	got, err := Pick(c, "o", "r", p, Tag(p, "lib", 0, nil), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == tagged {
+		t.Errorf("Pick rolled the default release over into %q, the release of tag 2", got)
+	}
+	for i := 2; i < 5; i++ {
+		for _, tags := range [][]string{{"lib"}, {"go-2"}, {fmt.Sprint(i)}} {
+			if r, tg := Rollover("lib-go", i), Tag(p, "lib", 0, tags); r == tg {
+				t.Errorf("Rollover(lib-go, %d) = Tag(%v) = %q", i, tags, r)
+			}
+		}
+	}
+}
+
+func TestFitsEmptyRelease(t *testing.T) {
+	p := config.ReleasePolicy{MaxAssets: 1, MaxBytes: 10}
+	if !(Usage{}).Fits(p, 3, 100) {
+		t.Error("an empty release should take a book larger than the limits")
+	}
+	if !(Usage{Assets: 1, Bytes: 10}).Full(p) {
+		t.Error("a release at its limits should be full")
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	c := newFake(fakeRelease{"lib", []int64{10, 20}}, fakeRelease{"lib.2", []int64{5}}, fakeRelease{"archive", nil})
+	got, err := Capacity(c, "o", "r")
+	if err != nil {
+		t.Fatalf("Capacity: %v", err)
+	}
+	want := []Usage{{"lib", 2, 30}, {"lib.2", 1, 5}, {"archive", 0, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Capacity = %+v, want %+v", got, want)
+	}
+}
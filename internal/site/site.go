@@ -0,0 +1,159 @@
+// Package site renders a shelf as a static website for GitHub Pages.
+//
+// The site has an index page with search and tag filters and one page per
+// book. Downloads link straight to the release assets, so publishing never
+// copies book files into the repository; covers can be linked the same way,
+// or are written alongside the pages.
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+// Book is a catalog entry together with what the site needs to show it.
+type Book struct {
+	catalog.Book
+	// DownloadURL is the release asset's browser_download_url.
+	DownloadURL string
+	// PartURLs are the download URLs of the parts, in order, of a book
+	// uploaded in parts; DownloadURL is empty then.
+	PartURLs []string
+	// Cover is the cover image, if any, and CoverExt its extension (".jpg").
+	Cover    []byte
+	CoverExt string
+	// CoverURL is where the cover is served from, such as a release
+	// asset. Without it, Cover is written into the site.
+	CoverURL string
+}
+
+// Site describes one published shelf.
+type Site struct {
+	Title     string
+	Owner     string
+	Repo      string
+	Books     []Book
+	Generated time.Time
+}
+
+// page is one rendered book, as the templates see it.
+type page struct {
+	Book
+	Page      string
+	CoverPath string
+	Size      string
+}
+
+// Render returns the site's files keyed by slash-separated path.
+func Render(s Site) (map[string][]byte, error) {
+	books := append([]Book(nil), s.Books...)
+	sort.SliceStable(books, func(i, j int) bool {
+		return strings.ToLower(books[i].Title) < strings.ToLower(books[j].Title)
+	})
+
+	files := map[string][]byte{
+		// Keep GitHub Pages from running the site through Jekyll.
+		".nojekyll": nil,
+		"style.css": []byte(styleCSS),
+	}
+
+	pages := make([]page, 0, len(books))
+	used := map[string]bool{}
+	for _, b := range books {
+		name := uniqueName(pageName(b.ID), used)
+		p := page{Book: b, Page: "books/" + name + ".html", Size: humanBytes(b.SizeBytes)}
+		if b.CoverURL == "" && len(b.Cover) > 0 {
+			p.CoverPath = "covers/" + name + b.CoverExt
+			files[p.CoverPath] = b.Cover
+		}
+		pages = append(pages, p)
+	}
+
+	tags := map[string]bool{}
+	for _, p := range pages {
+		for _, t := range p.Tags {
+			tags[t] = true
+		}
+	}
+	tagList := make([]string, 0, len(tags))
+	for t := range tags {
+		tagList = append(tagList, t)
+	}
+	sort.Strings(tagList)
+
+	var buf bytes.Buffer
+	err := indexTmpl.Execute(&buf, map[string]interface{}{
+		"Site":  s,
+		"Books": pages,
+		"Tags":  tagList,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rendering index: %w", err)
+	}
+	files["index.html"] = buf.Bytes()
+
+	for _, p := range pages {
+		var buf bytes.Buffer
+		if err := bookTmpl.Execute(&buf, map[string]interface{}{"Site": s, "Book": p}); err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", p.ID, err)
+		}
+		files[p.Page] = buf.Bytes()
+	}
+	return files, nil
+}
+
+// PagesURL returns the address GitHub Pages serves a repository's site at.
+func PagesURL(owner, repo string) string {
+	owner = strings.ToLower(owner)
+	if strings.EqualFold(repo, owner+".github.io") {
+		return "https://" + owner + ".github.io/"
+	}
+	return "https://" + owner + ".github.io/" + repo + "/"
+}
+
+// pageName makes a book ID safe to use as a file name.
+func pageName(id string) string {
+	var b strings.Builder
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	if b.Len() == 0 {
+		return "book"
+	}
+	return b.String()
+}
+
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+func humanBytes(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,88 @@
+package site
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+func TestRender(t *testing.T) {
+	s := Site{
+		Title: "books",
+		Owner: "alice",
+		Repo:  "shelf-books",
+		Books: []Book{
+			{
+				Book:        catalog.Book{ID: "sicp", Title: "SICP", Author: "Abelson & Sussman", Format: "pdf", Tags: []string{"lisp"}, SizeBytes: 2048},
+				DownloadURL: "https://github.com/alice/shelf-books/releases/download/library/sicp.pdf",
+				Cover:       []byte("jpeg"),
+				CoverExt:    ".jpg",
+			},
+			{Book: catalog.Book{ID: "a/b", Title: "<Tricky>", Format: "epub"}},
+		},
+		Generated: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	files, err := Render(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{".nojekyll", "style.css", "index.html", "books/sicp.html", "books/a-b.html", "covers/sicp.jpg"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+	if string(files["covers/sicp.jpg"]) != "jpeg" {
+		t.Errorf("cover = %q", files["covers/sicp.jpg"])
+	}
+
+	index := string(files["index.html"])
+	for _, want := range []string{
+		`href="books/sicp.html"`,
+		`src="covers/sicp.jpg"`,
+		`href="https://github.com/alice/shelf-books/releases/download/library/sicp.pdf"`,
+		`Abelson &amp; Sussman`,
+		`&lt;Tricky&gt;`,
+		`data-tag="lisp"`,
+		`2 books`,
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index.html missing %s", want)
+		}
+	}
+	if strings.Contains(index, "<Tricky>") {
+		t.Error("title not escaped")
+	}
+	// Sorted by title: "<Tricky>" before "SICP".
+	if strings.Index(index, "a-b.html") > strings.Index(index, "sicp.html") {
+		t.Error("books not sorted by title")
+	}
+
+	book := string(files["books/sicp.html"])
+	for _, want := range []string{`href="../style.css"`, `src="../covers/sicp.jpg"`, `2.0 KB`, `Download pdf`} {
+		if !strings.Contains(book, want) {
+			t.Errorf("book page missing %s", want)
+		}
+	}
+}
+
+func TestPageNameCollisions(t *testing.T) {
+	used := map[string]bool{}
+	if got := uniqueName(pageName("a/b"), used); got != "a-b" {
+		t.Errorf("got %q", got)
+	}
+	if got := uniqueName(pageName("a.b"), used); got != "a-b-2" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPagesURL(t *testing.T) {
+	if got := PagesURL("Alice", "shelf-books"); got != "https://alice.github.io/shelf-books/" {
+		t.Errorf("got %q", got)
+	}
+	if got := PagesURL("alice", "alice.github.io"); got != "https://alice.github.io/" {
+		t.Errorf("got %q", got)
+	}
+}
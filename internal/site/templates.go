@@ -0,0 +1,217 @@
+package site
+
+import (
+	"html/template"
+	"strings"
+)
+
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"inc":   func(i int) int { return i + 1 },
+	"date":  func(s Site) string { return s.Generated.UTC().Format("2006-01-02") },
+}
+
+var indexTmpl = template.Must(template.New("index").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Site.Title}}</title>
+    <link rel="stylesheet" href="style.css">
+</head>
+<body>
+    <header>
+        <h1>{{.Site.Title}}</h1>
+        <p class="subtitle">{{len .Books}} book{{if ne (len .Books) 1}}s{{end}}</p>
+        <input type="search" id="search" placeholder="Search by title, author or tag…" autocomplete="off">
+        {{- if .Tags}}
+        <div class="tags" id="tag-filter">
+            {{- range .Tags}}
+            <button class="tag" data-tag="{{.}}">{{.}}</button>
+            {{- end}}
+        </div>
+        {{- end}}
+    </header>
+    <main class="grid" id="books">
+        {{- range .Books}}
+        <article class="card" data-search="{{lower .Title}} {{lower .Author}} {{lower (join .Tags " ")}}" data-tags="{{join .Tags "|"}}">
+            <a href="{{.Page}}" class="cover-link">
+                {{- if .CoverURL}}
+                <img src="{{.CoverURL}}" alt="" loading="lazy">
+                {{- else if .CoverPath}}
+                <img src="{{.CoverPath}}" alt="" loading="lazy">
+                {{- else}}
+                <div class="no-cover">{{.Format}}</div>
+                {{- end}}
+            </a>
+            <h2><a href="{{.Page}}">{{.Title}}</a></h2>
+            {{- if .Author}}
+            <p class="author">{{.Author}}</p>
+            {{- end}}
+            <p class="meta">{{if .Year}}{{.Year}} · {{end}}{{.Format}}{{if .Size}} · {{.Size}}{{end}}</p>
+            {{- if .DownloadURL}}
+            <a class="download" href="{{.DownloadURL}}">Download</a>
+            {{- else if .PartURLs}}
+            <a class="download" href="{{.Page}}">Download ({{len .PartURLs}} parts)</a>
+            {{- end}}
+        </article>
+        {{- end}}
+    </main>
+    <footer>Published from <a href="https://github.com/{{.Site.Owner}}/{{.Site.Repo}}">{{.Site.Owner}}/{{.Site.Repo}}</a> with shelfctl on {{date .Site}}</footer>
+    <script>
+        const search = document.getElementById('search');
+        const cards = Array.from(document.querySelectorAll('.card'));
+        let activeTag = '';
+        function filter() {
+            const q = search.value.trim().toLowerCase();
+            for (const card of cards) {
+                const tags = card.dataset.tags ? card.dataset.tags.split('|') : [];
+                const match = (!q || card.dataset.search.includes(q)) && (!activeTag || tags.includes(activeTag));
+                card.hidden = !match;
+            }
+        }
+        search.addEventListener('input', filter);
+        document.querySelectorAll('[data-tag]').forEach(btn => {
+            btn.addEventListener('click', () => {
+                activeTag = activeTag === btn.dataset.tag ? '' : btn.dataset.tag;
+                document.querySelectorAll('[data-tag]').forEach(b => b.classList.toggle('active', b.dataset.tag === activeTag));
+                filter();
+            });
+        });
+    </script>
+</body>
+</html>
+`))
+
+var bookTmpl = template.Must(template.New("book").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Book.Title}} · {{.Site.Title}}</title>
+    <link rel="stylesheet" href="../style.css">
+</head>
+<body>
+    <header>
+        <p><a href="../index.html">← {{.Site.Title}}</a></p>
+    </header>
+    <main class="book">
+        {{- with .Book}}
+        <div class="book-cover">
+            {{- if .CoverURL}}
+            <img src="{{.CoverURL}}" alt="Cover of {{.Title}}">
+            {{- else if .CoverPath}}
+            <img src="../{{.CoverPath}}" alt="Cover of {{.Title}}">
+            {{- else}}
+            <div class="no-cover">{{.Format}}</div>
+            {{- end}}
+        </div>
+        <div class="book-info">
+            <h1>{{.Title}}</h1>
+            {{- if .Author}}
+            <p class="author">{{.Author}}</p>
+            {{- end}}
+            <dl>
+                {{- if .Year}}<dt>Year</dt><dd>{{.Year}}</dd>{{end}}
+                {{- if .Publisher}}<dt>Publisher</dt><dd>{{.Publisher}}</dd>{{end}}
+                {{- if .ISBN}}<dt>ISBN</dt><dd>{{.ISBN}}</dd>{{end}}
+                {{- if .Pages}}<dt>Pages</dt><dd>{{.Pages}}</dd>{{end}}
+                {{- if .Subjects}}<dt>Subjects</dt><dd>{{join .Subjects ", "}}</dd>{{end}}
+                {{- if .Tags}}<dt>Tags</dt><dd>{{join .Tags ", "}}</dd>{{end}}
+                <dt>Format</dt><dd>{{.Format}}</dd>
+                {{- if .Size}}<dt>Size</dt><dd>{{.Size}}</dd>{{end}}
+                {{- if .Checksum.SHA256}}<dt>SHA-256</dt><dd class="hash">{{.Checksum.SHA256}}</dd>{{end}}
+            </dl>
+            {{- if .DownloadURL}}
+            <a class="download" href="{{.DownloadURL}}">Download {{.Format}}</a>
+            {{- else if .PartURLs}}
+            <p>This book is split into {{len .PartURLs}} parts. Download all of them and join them in order, such as with <code>cat {{.Source.Asset}}.part* &gt; {{.Source.Asset}}</code>.</p>
+            {{- range $i, $u := .PartURLs}}
+            <a class="download" href="{{$u}}">Part {{inc $i}}</a>
+            {{- end}}
+            {{- end}}
+        </div>
+        {{- end}}
+    </main>
+    <footer>Published from <a href="https://github.com/{{.Site.Owner}}/{{.Site.Repo}}">{{.Site.Owner}}/{{.Site.Repo}}</a> with shelfctl on {{date .Site}}</footer>
+</body>
+</html>
+`))
+
+// styleCSS follows the colours of the local index page.
+const styleCSS = `:root {
+    --orange: #fb6820;
+    --teal: #1b8487;
+    --teal-light: #2ecfd4;
+    --teal-card: #1c2829;
+    --teal-border: #1e3a3c;
+}
+* { box-sizing: border-box; }
+body {
+    margin: 0;
+    font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif;
+    background: #121212;
+    color: #e0e0e0;
+}
+a { color: var(--teal-light); }
+header, main, footer { max-width: 1200px; margin: 0 auto; padding: 20px; }
+h1 { color: var(--orange); margin: 0 0 4px; }
+.subtitle, .meta, footer { color: #888; font-size: 0.9rem; }
+#search {
+    width: 100%;
+    margin-top: 12px;
+    padding: 10px;
+    background: #2a2a2a;
+    border: 1px solid #444;
+    border-radius: 4px;
+    color: #e0e0e0;
+    font-size: 1rem;
+}
+.tags { display: flex; flex-wrap: wrap; gap: 6px; margin-top: 10px; }
+.tag {
+    background: #2a2a2a;
+    border: 1px solid #444;
+    color: #ccc;
+    padding: 3px 10px;
+    border-radius: 12px;
+    cursor: pointer;
+}
+.tag.active { border-color: var(--orange); color: var(--orange); }
+.grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(180px, 1fr)); gap: 20px; }
+.card {
+    background: var(--teal-card);
+    border: 1px solid var(--teal-border);
+    border-radius: 8px;
+    padding: 12px;
+}
+.card[hidden] { display: none; }
+.card img, .card .no-cover { width: 100%; aspect-ratio: 2 / 3; object-fit: cover; border-radius: 4px; }
+.card h2 { font-size: 1rem; margin: 10px 0 4px; }
+.card h2 a { color: #fff; text-decoration: none; }
+.author { color: #aaa; margin: 0; }
+.no-cover {
+    display: flex;
+    align-items: center;
+    justify-content: center;
+    background: var(--teal);
+    color: #fff;
+    text-transform: uppercase;
+}
+.download {
+    display: inline-block;
+    margin-top: 10px;
+    padding: 6px 14px;
+    background: var(--orange);
+    color: #fff;
+    border-radius: 4px;
+    text-decoration: none;
+}
+.book { display: flex; gap: 30px; flex-wrap: wrap; }
+.book-cover img, .book-cover .no-cover { width: 260px; aspect-ratio: 2 / 3; object-fit: cover; border-radius: 6px; }
+.book-info { flex: 1; min-width: 260px; }
+dl { display: grid; grid-template-columns: max-content 1fr; gap: 6px 16px; }
+dt { color: #888; }
+dd { margin: 0; }
+.hash { font-family: monospace; word-break: break-all; font-size: 0.85rem; }
+`
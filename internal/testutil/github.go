@@ -0,0 +1,374 @@
+// Package testutil holds helpers shared by the tests of other packages.
+package testutil
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"sync"
+
+	"github.com/blackwell-systems/shelfctl/internal/github"
+)
+
+// GitHub is an in-memory GitHub: the files, history and release assets of
+// any number of repos. It implements the methods of github.Client that
+// shelfctl uses, without encryption, and creates repos as they are used.
+type GitHub struct {
+	// Owners maps an owner to the repos ListRepos returns for it.
+	Owners map[string][]github.Repo
+	// FailUpload makes uploads of assets with this name fail.
+	FailUpload string
+	// Listed records the owner/repo of each ListCommits call.
+	Listed []string
+
+	mu       sync.Mutex
+	repos    map[string]*Repo    // owner/repo → repo
+	releases map[int64]*release  // release ID → release
+	assets   map[int64]assetName // asset ID → where it is
+	nextID   int64
+}
+
+// Repo is the content of one repo. Tests may read and change it directly.
+type Repo struct {
+	// Files holds the files of the default branch by path.
+	Files map[string][]byte
+	// Messages lists the messages of CommitFile and CommitFiles, oldest
+	// first.
+	Messages []string
+	// Commits is the history ListCommits returns, newest first.
+	Commits []github.Commit
+	// Revisions holds the files at other refs: ref → path → content.
+	Revisions map[string]map[string][]byte
+	// Branches holds the files ReplaceBranch published, by branch.
+	Branches map[string]map[string][]byte
+
+	owner, name string
+	tags        map[string]int64 // tag → release ID
+}
+
+type release struct {
+	repo   *Repo
+	tag    string
+	assets map[string][]byte // name → content
+}
+
+type assetName struct {
+	release int64
+	name    string
+}
+
+// NewGitHub returns an empty GitHub.
+func NewGitHub() *GitHub {
+	return &GitHub{
+		Owners:   map[string][]github.Repo{},
+		repos:    map[string]*Repo{},
+		releases: map[int64]*release{},
+		assets:   map[int64]assetName{},
+	}
+}
+
+// Repo returns owner/repo, creating it if needed.
+func (g *GitHub) Repo(owner, repo string) *Repo {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.repo(owner, repo)
+}
+
+func (g *GitHub) repo(owner, repo string) *Repo {
+	r, ok := g.repos[owner+"/"+repo]
+	if !ok {
+		r = &Repo{
+			Files:     map[string][]byte{},
+			Revisions: map[string]map[string][]byte{},
+			Branches:  map[string]map[string][]byte{},
+			owner:     owner,
+			name:      repo,
+			tags:      map[string]int64{},
+		}
+		g.repos[owner+"/"+repo] = r
+	}
+	return r
+}
+
+// Assets returns the assets of a release by name, or nil if there is no
+// such release. Changes to the map change the release.
+func (g *GitHub) Assets(owner, repo, tag string) map[string][]byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id, ok := g.repo(owner, repo).tags[tag]
+	if !ok {
+		return nil
+	}
+	return g.releases[id].assets
+}
+
+// HasAsset reports whether the release has an asset of that name.
+func (g *GitHub) HasAsset(owner, repo, tag, name string) bool {
+	_, ok := g.Assets(owner, repo, tag)[name]
+	return ok
+}
+
+// PutAsset stores an asset, creating its release and replacing an asset
+// of the same name.
+func (g *GitHub) PutAsset(owner, repo, tag, name, content string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	rel := g.ensureRelease(g.repo(owner, repo), tag)
+	g.removeAsset(rel, name)
+	g.addAsset(rel, name, []byte(content))
+}
+
+func (g *GitHub) ensureRelease(r *Repo, tag string) int64 {
+	if id, ok := r.tags[tag]; ok {
+		return id
+	}
+	g.nextID++
+	r.tags[tag] = g.nextID
+	g.releases[g.nextID] = &release{repo: r, tag: tag, assets: map[string][]byte{}}
+	return g.nextID
+}
+
+func (g *GitHub) addAsset(relID int64, name string, data []byte) int64 {
+	g.nextID++
+	g.releases[relID].assets[name] = data
+	g.assets[g.nextID] = assetName{relID, name}
+	return g.nextID
+}
+
+func (g *GitHub) removeAsset(relID int64, name string) {
+	for id, a := range g.assets {
+		if a.release == relID && a.name == name {
+			delete(g.assets, id)
+		}
+	}
+	delete(g.releases[relID].assets, name)
+}
+
+// lookup returns the release and name of an asset of owner/repo.
+func (g *GitHub) lookup(owner, repo string, assetID int64) (*release, string, error) {
+	a, ok := g.assets[assetID]
+	if !ok {
+		return nil, "", github.ErrNotFound
+	}
+	rel := g.releases[a.release]
+	if rel.repo != g.repo(owner, repo) {
+		return nil, "", github.ErrNotFound
+	}
+	if _, ok := rel.assets[a.name]; !ok {
+		return nil, "", github.ErrNotFound
+	}
+	return rel, a.name, nil
+}
+
+// release returns a release of owner/repo by ID.
+func (g *GitHub) release(owner, repo string, releaseID int64) (*release, error) {
+	rel, ok := g.releases[releaseID]
+	if !ok || rel.repo != g.repo(owner, repo) {
+		return nil, fmt.Errorf("release %d of %s/%s: %w", releaseID, owner, repo, github.ErrNotFound)
+	}
+	return rel, nil
+}
+
+func (g *GitHub) asset(rel *release, relID int64, name string) github.Asset {
+	var id int64
+	for assetID, a := range g.assets {
+		if a.release == relID && a.name == name {
+			id = assetID
+		}
+	}
+	return github.Asset{
+		ID:   id,
+		Name: name,
+		Size: int64(len(rel.assets[name])),
+		BrowserDownloadURL: fmt.Sprintf("https://github.com/%s/%s/releases/download/%s/%s",
+			rel.repo.owner, rel.repo.name, rel.tag, name),
+	}
+}
+
+// GetReleaseByTag returns the release of tag, or github.ErrNotFound.
+func (g *GitHub) GetReleaseByTag(owner, repo, tag string) (*github.Release, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id, ok := g.repo(owner, repo).tags[tag]
+	if !ok {
+		return nil, github.ErrNotFound
+	}
+	return &github.Release{ID: id, TagName: tag, Name: tag}, nil
+}
+
+// EnsureRelease returns the release of tag, creating it if needed.
+func (g *GitHub) EnsureRelease(owner, repo, tag string) (*github.Release, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := g.ensureRelease(g.repo(owner, repo), tag)
+	return &github.Release{ID: id, TagName: tag, Name: tag}, nil
+}
+
+// ListReleases returns the releases of owner/repo newest first, like GitHub.
+func (g *GitHub) ListReleases(owner, repo string) ([]github.Release, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var out []github.Release
+	for tag, id := range g.repo(owner, repo).tags {
+		out = append(out, github.Release{ID: id, TagName: tag, Name: tag})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out, nil
+}
+
+// ListReleaseAssets returns the assets of a release by name.
+func (g *GitHub) ListReleaseAssets(owner, repo string, releaseID int64) ([]github.Asset, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	rel, err := g.release(owner, repo, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(rel.assets))
+	for name := range rel.assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]github.Asset, 0, len(names))
+	for _, name := range names {
+		out = append(out, g.asset(rel, releaseID, name))
+	}
+	return out, nil
+}
+
+// FindAsset returns the asset of that name, or nil if there is none.
+func (g *GitHub) FindAsset(owner, repo string, releaseID int64, name string) (*github.Asset, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	rel, err := g.release(owner, repo, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := rel.assets[name]; !ok {
+		return nil, nil
+	}
+	a := g.asset(rel, releaseID, name)
+	return &a, nil
+}
+
+// DownloadAsset returns the content of an asset.
+func (g *GitHub) DownloadAsset(owner, repo string, assetID int64) (io.ReadCloser, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	rel, name, err := g.lookup(owner, repo, assetID)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(rel.assets[name])), nil
+}
+
+// UploadAsset adds an asset of size bytes read from r. Like GitHub, it
+// fails with github.ErrConflict if the release has an asset of that name.
+func (g *GitHub) UploadAsset(owner, repo string, releaseID int64, name string, r io.Reader, size int64, contentType string) (*github.Asset, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if name == g.FailUpload {
+		return nil, errors.New("upload failed")
+	}
+	if int64(len(data)) != size {
+		return nil, fmt.Errorf("uploading %s: read %d bytes, want %d", name, len(data), size)
+	}
+	rel, err := g.release(owner, repo, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := rel.assets[name]; ok {
+		return nil, fmt.Errorf("uploading %s: %w", name, github.ErrConflict)
+	}
+	g.addAsset(releaseID, name, data)
+	a := g.asset(rel, releaseID, name)
+	return &a, nil
+}
+
+// DeleteAsset removes an asset.
+func (g *GitHub) DeleteAsset(owner, repo string, assetID int64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, _, err := g.lookup(owner, repo, assetID); err != nil {
+		return err
+	}
+	a := g.assets[assetID]
+	g.removeAsset(a.release, a.name)
+	return nil
+}
+
+// GetFileContent returns a file of the default branch, or of
+// Repo.Revisions if ref is set, with its blob SHA.
+func (g *GitHub) GetFileContent(owner, repo, path, ref string) ([]byte, string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.repo(owner, repo)
+	files := r.Files
+	if ref != "" && ref != "HEAD" {
+		files = r.Revisions[ref]
+	}
+	data, ok := files[path]
+	if !ok {
+		return nil, "", github.ErrNotFound
+	}
+	sum := sha1.Sum(data)
+	return data, hex.EncodeToString(sum[:]), nil
+}
+
+// CommitFile writes one file to the default branch.
+func (g *GitHub) CommitFile(owner, repo, filePath string, content []byte, message string) error {
+	return g.CommitFiles(owner, repo, map[string][]byte{filePath: content}, message)
+}
+
+// CommitFiles writes files to the default branch in one commit.
+func (g *GitHub) CommitFiles(owner, repo string, files map[string][]byte, message string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.repo(owner, repo)
+	maps.Copy(r.Files, files)
+	r.Messages = append(r.Messages, message)
+	return nil
+}
+
+// ReplaceBranch makes files the only content of branch and reports
+// whether that changed it.
+func (g *GitHub) ReplaceBranch(owner, repo, branch string, files map[string][]byte, message string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.repo(owner, repo)
+	old, ok := r.Branches[branch]
+	if ok && maps.EqualFunc(old, files, bytes.Equal) {
+		return false, nil
+	}
+	r.Branches[branch] = maps.Clone(files)
+	return true, nil
+}
+
+// ListCommits returns up to limit commits of Repo.Commits, all if limit
+// is 0.
+func (g *GitHub) ListCommits(owner, repo, path string, limit int) ([]github.Commit, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Listed = append(g.Listed, owner+"/"+repo)
+	commits := g.repo(owner, repo).Commits
+	if limit > 0 && limit < len(commits) {
+		commits = commits[:limit]
+	}
+	return commits, nil
+}
+
+// ListRepos returns the repos of Owners[owner].
+func (g *GitHub) ListRepos(owner string) ([]github.Repo, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.Owners[owner], nil
+}
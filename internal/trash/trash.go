@@ -0,0 +1,218 @@
+// Package trash moves the assets of deleted books into a trash release of
+// their shelf, where they wait until they are restored or the trash is
+// emptied. The catalog keeps trashed books, marked with catalog.Trashed.
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/github"
+)
+
+// Release is the release of each shelf that trashed assets are kept in.
+const Release = "trash"
+
+// Client is the part of the GitHub client the trash needs.
+type Client interface {
+	GetReleaseByTag(owner, repo, tag string) (*github.Release, error)
+	EnsureRelease(owner, repo, tag string) (*github.Release, error)
+	FindAsset(owner, repo string, releaseID int64, name string) (*github.Asset, error)
+	DownloadAsset(owner, repo string, assetID int64) (io.ReadCloser, error)
+	UploadAsset(owner, repo string, releaseID int64, name string, r io.Reader, size int64, contentType string) (*github.Asset, error)
+	DeleteAsset(owner, repo string, assetID int64) error
+}
+
+// CheckID returns an error if id belongs to a book in the trash. Adding a
+// book under that ID would replace the trashed entry and strand its asset
+// in the trash release, where it could no longer be restored.
+func CheckID(books []catalog.Book, id string) error {
+	if b := catalog.ByID(books, id); b != nil && b.IsTrashed() {
+		return fmt.Errorf("%s is in the trash — 'shelfctl trash restore %s' or 'shelfctl trash empty'", id, id)
+	}
+	return nil
+}
+
+// Put moves a book's asset, or all its parts, into the trash release of
+// owner/repo and marks the book as trashed. The asset keeps its name unless
+// a trashed asset of that name is there already.
+func Put(c Client, owner, repo string, b *catalog.Book, now time.Time) error {
+	if b.IsTrashed() {
+		return fmt.Errorf("%s is already in the trash", b.ID)
+	}
+	rel, err := c.EnsureRelease(owner, repo, Release)
+	if err != nil {
+		return fmt.Errorf("trash release: %w", err)
+	}
+	dst := b.Source
+	dst.Parts = append([]catalog.Part(nil), b.Source.Parts...)
+	if existing, err := c.FindAsset(owner, repo, rel.ID, dst.AssetNames()[0]); err != nil {
+		return err
+	} else if existing != nil {
+		dst.SetAsset(now.UTC().Format("20060102-150405") + "-" + b.Source.Asset)
+	}
+
+	if err := moveAll(c, owner, repo, b.Source, Release, dst.AssetNames()); err != nil {
+		return err
+	}
+	b.Trashed = &catalog.Trashed{
+		At:      now.UTC().Format(time.RFC3339),
+		Release: b.Source.Release,
+		Asset:   b.Source.Asset,
+	}
+	b.Source.Release = Release
+	b.Source.SetAsset(dst.Asset)
+	return nil
+}
+
+// Restore moves a trashed book's asset back to where it was and clears
+// the trash mark.
+func Restore(c Client, owner, repo string, b *catalog.Book) error {
+	if !b.IsTrashed() {
+		return fmt.Errorf("%s is not in the trash", b.ID)
+	}
+	dst := b.Source
+	dst.Parts = append([]catalog.Part(nil), b.Source.Parts...)
+	dst.SetAsset(b.Trashed.Asset)
+	if err := moveAll(c, owner, repo, b.Source, b.Trashed.Release, dst.AssetNames()); err != nil {
+		return err
+	}
+	b.Source.Release = b.Trashed.Release
+	b.Source.SetAsset(b.Trashed.Asset)
+	b.Trashed = nil
+	return nil
+}
+
+// Purge deletes a trashed book's asset for good. An asset that is already
+// gone is not an error.
+func Purge(c Client, owner, repo string, b *catalog.Book) error {
+	if !b.IsTrashed() {
+		return fmt.Errorf("%s is not in the trash", b.ID)
+	}
+	for _, name := range b.Source.AssetNames() {
+		asset, err := find(c, owner, repo, Release, name)
+		if err != nil {
+			return err
+		}
+		if asset == nil {
+			continue
+		}
+		if err := c.DeleteAsset(owner, repo, asset.ID); err != nil {
+			return fmt.Errorf("deleting %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Since returns when a trashed book was deleted.
+func Since(b *catalog.Book) (time.Time, error) {
+	if !b.IsTrashed() {
+		return time.Time{}, fmt.Errorf("%s is not in the trash", b.ID)
+	}
+	return time.Parse(time.RFC3339, b.Trashed.At)
+}
+
+// ParseAge parses an age such as "30d", "2w" or "12h". Days and weeks are
+// added to what time.ParseDuration accepts.
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", s)
+	}
+	return d, nil
+}
+
+// find returns an asset, or nil if it or its release is missing.
+func find(c Client, owner, repo, release, name string) (*github.Asset, error) {
+	rel, err := c.GetReleaseByTag(owner, repo, release)
+	if errors.Is(err, github.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.FindAsset(owner, repo, rel.ID, name)
+}
+
+// moveAll moves the assets of src, or its parts, to toRelease under the
+// names in toNames.
+func moveAll(c Client, owner, repo string, src catalog.Source, toRelease string, toNames []string) error {
+	for i, name := range src.AssetNames() {
+		if err := move(c, owner, repo, src.Release, name, toRelease, toNames[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// move moves an asset between releases of a repo. If the source is gone
+// but the destination is there, an earlier move got that far and only the
+// delete is left, so it counts as done.
+func move(c Client, owner, repo, fromRelease, fromName, toRelease, toName string) error {
+	src, err := find(c, owner, repo, fromRelease, fromName)
+	if err != nil {
+		return err
+	}
+	dstRel, err := c.EnsureRelease(owner, repo, toRelease)
+	if err != nil {
+		return fmt.Errorf("release %s: %w", toRelease, err)
+	}
+	dst, err := c.FindAsset(owner, repo, dstRel.ID, toName)
+	if err != nil {
+		return err
+	}
+	if src == nil {
+		if dst != nil {
+			return nil
+		}
+		return fmt.Errorf("asset %s not found in release %s", fromName, fromRelease)
+	}
+	if dst != nil {
+		return fmt.Errorf("release %s already has an asset %s", toRelease, toName)
+	}
+
+	tmp, err := os.CreateTemp("", "shelfctl-trash-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	rc, err := c.DownloadAsset(owner, repo, src.ID)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", fromName, err)
+	}
+	size, err := io.Copy(tmp, rc)
+	_ = rc.Close()
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", fromName, err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := c.UploadAsset(owner, repo, dstRel.ID, toName, tmp, size, "application/octet-stream"); err != nil {
+		return fmt.Errorf("uploading %s to %s: %w", toName, toRelease, err)
+	}
+	if err := c.DeleteAsset(owner, repo, src.ID); err != nil {
+		return fmt.Errorf("deleting %s from %s: %w", fromName, fromRelease, err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package trash
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/testutil"
+)
+
+func TestPutRestorePurge(t *testing.T) {
+	c := testutil.NewGitHub()
+	c.PutAsset("me", "shelf", "library", "sicp.pdf", "sicp")
+	b := catalog.Book{ID: "sicp", Source: catalog.Source{Release: "library", Asset: "sicp.pdf"}}
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	if err := Put(c, "me", "shelf", &b, now); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if c.HasAsset("me", "shelf", "library", "sicp.pdf") || !c.HasAsset("me", "shelf", Release, "sicp.pdf") {
+		t.Fatal("asset not moved into the trash release")
+	}
+	if !b.IsTrashed() || b.Source.Release != Release || b.Trashed.Release != "library" {
+		t.Fatalf("book after Put = %+v", b)
+	}
+	if at, err := Since(&b); err != nil || !at.Equal(now) {
+		t.Errorf("Since = %v, %v", at, err)
+	}
+	if err := Put(c, "me", "shelf", &b, now); err == nil {
+		t.Error("trashing twice should fail")
+	}
+
+	// A second book of the same asset name gets a distinct trash name.
+	c.PutAsset("me", "shelf", "library", "sicp.pdf", "sicp 2nd")
+	b2 := catalog.Book{ID: "sicp2", Source: catalog.Source{Release: "library", Asset: "sicp.pdf"}}
+	if err := Put(c, "me", "shelf", &b2, now); err != nil {
+		t.Fatalf("Put of same name: %v", err)
+	}
+	if b2.Source.Asset == "sicp.pdf" || !c.HasAsset("me", "shelf", Release, b2.Source.Asset) {
+		t.Errorf("second trashed asset named %q", b2.Source.Asset)
+	}
+
+	if err := Restore(c, "me", "shelf", &b); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if b.IsTrashed() || b.Source.Release != "library" || b.Source.Asset != "sicp.pdf" {
+		t.Fatalf("book after Restore = %+v", b)
+	}
+	if string(c.Assets("me", "shelf", "library")["sicp.pdf"]) != "sicp" {
+		t.Error("restored asset has the wrong content")
+	}
+	// The original name is taken now.
+	if err := Restore(c, "me", "shelf", &b2); err == nil {
+		t.Error("restoring over an existing asset should fail")
+	}
+
+	if err := Purge(c, "me", "shelf", &b2); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if c.HasAsset("me", "shelf", Release, b2.Source.Asset) {
+		t.Error("purged asset still in the trash")
+	}
+	if err := Purge(c, "me", "shelf", &b2); err != nil {
+		t.Errorf("purging a gone asset: %v", err)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"0d":  0,
+	} {
+		if got, err := ParseAge(in); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "-1d", "soon"} {
+		if _, err := ParseAge(in); err == nil {
+			t.Errorf("ParseAge(%q) should fail", in)
+		}
+	}
+}
+
+func TestCheckID(t *testing.T) {
+	books := []catalog.Book{
+		{ID: "sicp"},
+		{ID: "old", Trashed: &catalog.Trashed{At: "2026-01-01T00:00:00Z"}},
+	}
+	if err := CheckID(books, "sicp"); err != nil {
+		t.Errorf("live book: %v", err)
+	}
+	if err := CheckID(books, "new"); err != nil {
+		t.Errorf("unknown ID: %v", err)
+	}
+	if err := CheckID(books, "old"); err == nil {
+		t.Error("CheckID accepted the ID of a trashed book")
+	}
+}
@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// HistoryEntry is one change to a book, shown in the history pane.
+type HistoryEntry struct {
+	Date    time.Time
+	Author  string
+	Summary string // e.g. "edited title, tags"
+}
+
+// HistoryProvider is implemented by a Downloader that can look up the
+// history of a book. The browser offers the history pane only then.
+type HistoryProvider interface {
+	BookHistory(owner, repo, catalogPath, bookID string) ([]HistoryEntry, error)
+}
+
+// maxHistoryEntries is how many changes the history pane lists.
+const maxHistoryEntries = 8
+
+// bookHistory is the loaded (or loading) history of one book.
+type bookHistory struct {
+	loading bool
+	entries []HistoryEntry
+	err     error
+}
+
+// historyMsg delivers the history of a book.
+type historyMsg struct {
+	key     string
+	entries []HistoryEntry
+	err     error
+}
+
+func historyKey(b BookItem) string {
+	return b.Owner + "/" + b.Repo + "/" + b.Book.ID
+}
+
+// loadHistory starts loading the history of the selected book if the
+// history pane is open and it isn't loaded yet.
+func (m *BrowserModel) loadHistory() tea.Cmd {
+	provider, ok := m.downloader.(HistoryProvider)
+	if !ok || !m.showHistory || !m.showDetails {
+		return nil
+	}
+	item, ok := m.list.SelectedItem().(BookItem)
+	if !ok {
+		return nil
+	}
+	key := historyKey(item)
+	if _, done := m.history[key]; done {
+		return nil
+	}
+	if m.history == nil {
+		m.history = map[string]*bookHistory{}
+	}
+	m.history[key] = &bookHistory{loading: true}
+	return func() tea.Msg {
+		entries, err := provider.BookHistory(item.Owner, item.Repo, item.CatalogPath, item.Book.ID)
+		return historyMsg{key: key, entries: entries, err: err}
+	}
+}
+
+// renderHistory renders the history section of the details pane.
+func (m BrowserModel) renderHistory(item BookItem, width int) string {
+	var s strings.Builder
+	s.WriteString("\n")
+	s.WriteString(StyleHighlight.Render("History: "))
+	s.WriteString("\n")
+
+	h := m.history[historyKey(item)]
+	switch {
+	case h == nil || h.loading:
+		s.WriteString(StyleHelp.Render("Loading…"))
+		s.WriteString("\n")
+	case h.err != nil:
+		s.WriteString(StyleError.Render(truncateText(h.err.Error(), width)))
+		s.WriteString("\n")
+	case len(h.entries) == 0:
+		s.WriteString(StyleHelp.Render("No changes found"))
+		s.WriteString("\n")
+	default:
+		for i, e := range h.entries {
+			if i == maxHistoryEntries {
+				fmt.Fprintf(&s, "%s\n", StyleHelp.Render(fmt.Sprintf("… %d more (shelfctl log %s)", len(h.entries)-i, item.Book.ID)))
+				break
+			}
+			fmt.Fprintf(&s, "%s %s\n", e.Date.Local().Format("2006-01-02"), StyleHelp.Render(truncateText(e.Author, 16)))
+			fmt.Fprintf(&s, "  %s\n", truncateText(e.Summary, width-2))
+		}
+	}
+	return s.String()
+}
@@ -105,13 +105,17 @@ func (m BrowserModel) renderDetailsPane() string {
 	s.WriteString(truncateText(bookItem.Book.Format, maxTextWidth))
 	s.WriteString("\n")
 
+	if m.showHistory {
+		s.WriteString(m.renderHistory(bookItem, detailsWidth-2))
+	}
+
 	return detailsStyle.Render(s.String())
 }
 
 // renderFooter creates a footer with all available keyboard shortcuts.
 // The shortcut matching activeCmd is rendered with StyleHighlight.
 func (m BrowserModel) renderFooter() string {
-	return RenderFooterBar([]ShortcutEntry{
+	shortcuts := []ShortcutEntry{
 		{Key: "", Label: "↑/↓ navigate"},
 		{Key: "/", Label: "/ filter"},
 		{Key: "", Label: "enter action"},
@@ -123,8 +127,12 @@ func (m BrowserModel) renderFooter() string {
 		{Key: " ", Label: "space select"},
 		{Key: "c", Label: "c clear"},
 		{Key: "tab", Label: "tab detail toggle"},
-		{Key: "", Label: "q quit"},
-	}, m.activeCmd)
+	}
+	if _, ok := m.downloader.(HistoryProvider); ok {
+		shortcuts = append(shortcuts, ShortcutEntry{Key: "h", Label: "h history"})
+	}
+	shortcuts = append(shortcuts, ShortcutEntry{Key: "", Label: "q quit"})
+	return RenderFooterBar(shortcuts, m.activeCmd)
 }
 
 // Pre-allocated styles for View() hot path (avoid per-frame allocations)
@@ -84,6 +84,7 @@ var menuSections = []menuSection{
 	{Title: "Organize", Items: []MenuItem{
 		{Key: "move", Icon: "→", Label: "Move Book", Description: "Transfer a book to another shelf or release", Available: true},
 		{Key: "delete-book", Icon: "✕", Label: "Delete Book", Description: "Remove a book from your library", Available: true},
+		{Key: "trash", Icon: "♻", Label: "Trash", Description: "Restore or permanently delete trashed books", Available: true},
 	}},
 	{Title: "Shelves", Items: []MenuItem{
 		{Key: "shelves", Icon: "≡", Label: "View Shelves", Description: "Show all configured shelves and book counts", Available: true},
@@ -120,7 +121,7 @@ func BuildFilteredMenuItems(ctx HubContext) []list.Item {
 			// Hide shelf-dependent actions when no shelves configured
 			if ctx.ShelfCount == 0 {
 				switch item.Key {
-				case "browse", "shelves", "shelve", "shelve-url", "edit-book", "delete-book", "delete-shelf":
+				case "browse", "shelves", "shelve", "shelve-url", "edit-book", "delete-book", "trash", "delete-shelf":
 					continue
 				}
 			}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/tui/delegate"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -26,6 +27,7 @@ type keyMap struct {
 	toggleSelect key.Binding
 	clearSelect  key.Binding
 	move         key.Binding
+	history      key.Binding
 }
 
 var keys = keyMap{
@@ -77,6 +79,10 @@ var keys = keyMap{
 		key.WithKeys("m"),
 		key.WithHelp("m", "move to shelf"),
 	),
+	history: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "history"),
+	),
 }
 
 // BrowserAction represents an action requested from the browser
@@ -114,8 +120,8 @@ type downloadMsg struct {
 
 // Downloader interface abstracts GitHub/cache operations
 type Downloader interface {
-	Download(owner, repo, bookID, release, asset, sha256 string) (downloaded bool, err error)
-	DownloadWithProgress(owner, repo, bookID, release, asset, sha256 string, progressCh chan<- float64) error
+	Download(owner, repo string, b catalog.Book) (downloaded bool, err error)
+	DownloadWithProgress(owner, repo string, b catalog.Book, progressCh chan<- float64) error
 	Uncache(owner, repo, bookID, asset string) error
 	Sync(owner, repo, bookID, release, asset, catalogPath, catalogSHA256 string) (synced bool, err error)
 	HasBeenModified(owner, repo, bookID, asset, catalogSHA256 string) bool
@@ -130,6 +136,7 @@ type BrowserModel struct {
 	selected      *BookItem
 	selectedBooks []BookItem
 	showDetails   bool
+	showHistory   bool
 	width         int
 	height        int
 
@@ -149,6 +156,9 @@ type BrowserModel struct {
 	// Instead, sets quitting flag for wrapper to handle
 	unifiedMode bool
 
+	// Book history, by owner/repo/id (loaded when the history pane is open)
+	history map[string]*bookHistory
+
 	// Footer command highlight
 	activeCmd string // Key that was just pressed for footer highlight
 
@@ -176,6 +186,10 @@ func (m BrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.activeCmd = ""
 		return m, nil
 
+	case historyMsg:
+		m.history[msg.key] = &bookHistory{entries: msg.entries, err: msg.err}
+		return m, nil
+
 	case downloadMsg:
 		// Handle download progress
 		if msg.err != nil {
@@ -250,6 +264,18 @@ func (m BrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.activeCmd = "tab"
 			return m, HighlightCmd()
 
+		case key.Matches(msg, keys.history):
+			// Toggle the history section of the details pane
+			if _, ok := m.downloader.(HistoryProvider); !ok {
+				break
+			}
+			m.showHistory = !m.showHistory
+			if m.showHistory && !m.showDetails {
+				m.showDetails = true
+				m.updateListSize()
+			}
+			return m, tea.Batch(m.setActiveCmd("h"), m.loadHistory())
+
 		case key.Matches(msg, keys.enter):
 			// If details showing, use as action, otherwise toggle details
 			if m.showDetails {
@@ -520,6 +546,10 @@ func (m BrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
+	// The selection may have changed: load its history if it is shown.
+	if historyCmd := m.loadHistory(); historyCmd != nil {
+		cmd = tea.Batch(cmd, historyCmd)
+	}
 	return m, cmd
 }
 
@@ -618,10 +648,7 @@ func (m *BrowserModel) startNextDownload() tea.Cmd {
 		err := m.downloader.DownloadWithProgress(
 			book.Owner,
 			book.Repo,
-			book.Book.ID,
-			book.Book.Source.Release,
-			book.Book.Source.Asset,
-			book.Book.Checksum.SHA256,
+			book.Book,
 			progressCh,
 		)
 		// Signal error before closing if download failed
@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/blackwell-systems/bubbletea-multiselect"
+	"github.com/blackwell-systems/shelfctl/internal/tui/delegate"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RepoOption is a discovered shelf repo offered for adding to the config.
+type RepoOption struct {
+	Repo      string
+	Shelf     string // Suggested shelf name
+	Books     int    // -1 if the repo has no readable catalog
+	Private   bool
+	Tagged    bool
+	Installed bool // Already in the config; shown but not selectable
+	selected  bool
+}
+
+// FilterValue implements list.Item
+func (r *RepoOption) FilterValue() string {
+	return r.Repo + " " + r.Shelf
+}
+
+// IsSelected implements multiselect.SelectableItem
+func (r *RepoOption) IsSelected() bool {
+	return r.selected
+}
+
+// SetSelected implements multiselect.SelectableItem
+func (r *RepoOption) SetSelected(selected bool) {
+	r.selected = selected
+}
+
+// IsSelectable implements multiselect.SelectableItem
+func (r *RepoOption) IsSelectable() bool {
+	return !r.Installed
+}
+
+func renderRepoOption(w io.Writer, m list.Model, index int, item list.Item, ms *multiselect.Model) {
+	opt, ok := item.(*RepoOption)
+	if !ok {
+		return
+	}
+
+	books := "no catalog"
+	if opt.Books >= 0 {
+		books = fmt.Sprintf("%d books", opt.Books)
+	}
+	detail := books
+	if opt.Tagged {
+		detail += ", tagged"
+	}
+	if opt.Private {
+		detail += ", private"
+	}
+	if opt.Installed {
+		detail += ", already added"
+	}
+	display := fmt.Sprintf("%s → %s (%s)", opt.Repo, opt.Shelf, detail)
+
+	if index == m.Index() {
+		_, _ = fmt.Fprint(w, "› "+ms.CheckboxPrefix(opt)+StyleHighlight.Render(display))
+	} else if opt.Installed {
+		_, _ = fmt.Fprint(w, "  "+ms.CheckboxPrefix(opt)+StyleHelp.Render(display))
+	} else {
+		_, _ = fmt.Fprint(w, "  "+ms.CheckboxPrefix(opt)+StyleNormal.Render(display))
+	}
+}
+
+type repoPickerModel struct {
+	ms       multiselect.Model
+	quitting bool
+	err      error
+	selected []RepoOption
+}
+
+func (m repoPickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m repoPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := StyleBorder.GetFrameSize()
+		m.ms.List.SetSize(msg.Width-h, msg.Height-v)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.ms.List.FilterState() == list.Filtering {
+			break
+		}
+
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			m.quitting = true
+			m.err = fmt.Errorf("canceled by user")
+			return m, tea.Quit
+
+		case " ":
+			m.ms.Toggle()
+			return m, nil
+
+		case "enter":
+			for _, item := range m.ms.List.Items() {
+				if opt, ok := item.(*RepoOption); ok && opt.IsSelected() {
+					m.selected = append(m.selected, *opt)
+				}
+			}
+			// Fallback: if nothing checked, take the current repo
+			if len(m.selected) == 0 {
+				if opt, ok := m.ms.List.SelectedItem().(*RepoOption); ok && opt.IsSelectable() {
+					m.selected = []RepoOption{*opt}
+				}
+			}
+			m.quitting = true
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.ms, cmd = m.ms.Update(msg)
+	return m, cmd
+}
+
+func (m repoPickerModel) View() string {
+	if m.quitting {
+		return ""
+	}
+	return StyleBorder.Render(m.ms.View())
+}
+
+// RunRepoPicker lets the user check the discovered repos to add as shelves.
+// Returns the selected options, or an error if canceled.
+func RunRepoPicker(repos []RepoOption) ([]RepoOption, error) {
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("no repos to display")
+	}
+
+	items := make([]list.Item, len(repos))
+	for i := range repos {
+		items[i] = &repos[i]
+	}
+
+	tempDelegate := delegate.New(func(w io.Writer, m list.Model, index int, item list.Item) {})
+	l := list.New(items, tempDelegate, 0, 0)
+	l.Title = "Add shelves"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+	l.Styles.Title = StyleHeader
+	l.Styles.HelpStyle = StyleHelp
+
+	ms := multiselect.New(l)
+	ms.SetTitle("Add shelves")
+	d := delegate.New(func(w io.Writer, m list.Model, index int, item list.Item) {
+		renderRepoOption(w, m, index, item, &ms)
+	})
+	ms.List.SetDelegate(d)
+
+	toggleKey := key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "toggle"))
+	selectKey := key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "add"))
+	ms.List.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{toggleKey, selectKey}
+	}
+	ms.RestoreSelectionState()
+
+	p := tea.NewProgram(repoPickerModel{ms: ms}, tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return nil, fmt.Errorf("running repo picker: %w", err)
+	}
+	fm, ok := finalModel.(repoPickerModel)
+	if !ok {
+		return nil, fmt.Errorf("unexpected model type")
+	}
+	if fm.err != nil {
+		return nil, fm.err
+	}
+	return fm.selected, nil
+}
@@ -25,7 +25,8 @@ type ShelveFormDefaults struct {
 	Filename string
 	Title    string
 	Author   string
-	Year     int // Pre-fill from --year flag or leave 0
+	Year     int    // Pre-fill from --year flag or leave 0
+	Tags     string // Comma-separated
 	ID       string
 }
 
@@ -101,7 +102,11 @@ func newShelveForm(defaults ShelveFormDefaults) shelveFormModel {
 
 	// Tags field
 	m.inputs[fieldTags] = textinput.New()
-	m.inputs[fieldTags].Placeholder = "comma,separated,tags"
+	if defaults.Tags != "" {
+		m.inputs[fieldTags].Placeholder = truncate(defaults.Tags, maxPlaceholderWidth)
+	} else {
+		m.inputs[fieldTags].Placeholder = "comma,separated,tags"
+	}
 	m.inputs[fieldTags].CharLimit = 200
 	m.inputs[fieldTags].Width = inputWidth
 	m.inputs[fieldTags].Prompt = ""
@@ -280,6 +285,8 @@ func (m shelveFormModel) getValue(field int) string {
 		return m.defaults.Title
 	case fieldAuthor:
 		return m.defaults.Author
+	case fieldTags:
+		return m.defaults.Tags
 	case fieldID:
 		return m.defaults.ID
 	default:
@@ -267,3 +267,14 @@ func TestShelveForm_YearInvalidParsing(t *testing.T) {
 		t.Errorf("expected Year=0 from invalid input, got %d", fm.result.Year)
 	}
 }
+
+func TestShelveForm_TagsDefault(t *testing.T) {
+	m := newShelveForm(ShelveFormDefaults{Filename: "b.epub", Title: "B", Tags: "fiction,series:Earthsea", ID: "b"})
+
+	if m.inputs[fieldTags].Placeholder != "fiction,series:Earthsea" {
+		t.Errorf("Tags placeholder = %q", m.inputs[fieldTags].Placeholder)
+	}
+	if got := m.getValue(fieldTags); got != "fiction,series:Earthsea" {
+		t.Errorf("empty Tags input = %q, want the default", got)
+	}
+}
@@ -1,14 +1,17 @@
 package unified
 
 import (
-	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
 
 	"github.com/blackwell-systems/shelfctl/internal/cache"
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/history"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -23,32 +26,53 @@ type BrowseModel struct {
 type browserDownloader struct {
 	gh    *github.Client
 	cache *cache.Manager
+	// partSize is the size above which synced files are uploaded in parts.
+	partSize int64
+	// journalDir is where syncs are recorded for undo.
+	journalDir string
 }
 
-func (d *browserDownloader) Download(owner, repo, bookID, release, asset, sha256 string) (bool, error) {
-	return d.DownloadWithProgress(owner, repo, bookID, release, asset, sha256, nil) == nil, nil
-}
+// bookHistoryCommits is how many catalog commits the history pane looks at.
+const bookHistoryCommits = 50
 
-func (d *browserDownloader) DownloadWithProgress(owner, repo, bookID, release, asset, sha256 string, progressCh chan<- float64) error {
-	// Get release
-	rel, err := d.gh.GetReleaseByTag(owner, repo, release)
+// BookHistory implements tui.HistoryProvider.
+func (d *browserDownloader) BookHistory(owner, repo, catalogPath, bookID string) ([]tui.HistoryEntry, error) {
+	if catalogPath == "" {
+		catalogPath = "catalog.yml"
+	}
+	entries, err := history.Log(d.gh, owner, repo, catalogPath, bookHistoryCommits, bookID)
 	if err != nil {
-		return fmt.Errorf("release %q: %w", release, err)
+		return nil, err
+	}
+	var out []tui.HistoryEntry
+	for _, e := range entries {
+		for _, ch := range e.Changes {
+			out = append(out, tui.HistoryEntry{Date: e.Commit.Date, Author: e.Commit.Author, Summary: history.Describe(ch)})
+		}
 	}
+	return out, nil
+}
 
-	// Find asset
-	assetObj, err := d.gh.FindAsset(owner, repo, rel.ID, asset)
-	if err != nil {
-		return fmt.Errorf("finding asset: %w", err)
+func (d *browserDownloader) Download(owner, repo string, b catalog.Book) (bool, error) {
+	return d.DownloadWithProgress(owner, repo, b, nil) == nil, nil
+}
+
+func (d *browserDownloader) DownloadWithProgress(owner, repo string, b catalog.Book, progressCh chan<- float64) error {
+	// Reuse a copy cached for another book, such as a duplicate
+	if d.cache.Link(owner, repo, b.ID, b.Source.Asset, b.Checksum.SHA256) {
+		return nil
 	}
-	if assetObj == nil {
-		return fmt.Errorf("asset %q not found", asset)
+
+	// Get release
+	rel, err := d.gh.GetReleaseByTag(owner, repo, b.Source.Release)
+	if err != nil {
+		return fmt.Errorf("release %q: %w", b.Source.Release, err)
 	}
 
-	// Download
-	rc, err := d.gh.DownloadAsset(owner, repo, assetObj.ID)
+	// Find and download the asset, or its parts
+	rc, size, err := parts.Open(d.gh, owner, repo, rel.ID, b)
 	if err != nil {
-		return fmt.Errorf("download: %w", err)
+		return err
 	}
 	defer func() { _ = rc.Close() }()
 
@@ -57,13 +81,13 @@ func (d *browserDownloader) DownloadWithProgress(owner, repo, bookID, release, a
 	if progressCh != nil {
 		reader = &progressReader{
 			reader:     rc,
-			total:      assetObj.Size,
+			total:      size,
 			progressCh: progressCh,
 		}
 	}
 
 	// Store in cache
-	_, err = d.cache.Store(owner, repo, bookID, asset, reader, sha256)
+	_, err = d.cache.Store(owner, repo, b.ID, b.Source.Asset, reader, b.Checksum.SHA256)
 	if err != nil {
 		return fmt.Errorf("cache: %w", err)
 	}
@@ -87,7 +111,7 @@ func (d *browserDownloader) Sync(owner, repo, bookID, release, asset, catalogPat
 
 	// Get cached file path, hash, and size
 	cachedPath := d.cache.Path(owner, repo, bookID, asset)
-	cachedSHA, cachedSize, err := computeFileHash(cachedPath)
+	cachedSHA, cachedSize, err := d.cache.SHA256(owner, repo, bookID, asset)
 	if err != nil {
 		return false, fmt.Errorf("computing hash: %w", err)
 	}
@@ -98,15 +122,26 @@ func (d *browserDownloader) Sync(owner, repo, bookID, release, asset, catalogPat
 		return false, fmt.Errorf("release %q: %w", release, err)
 	}
 
-	// Find and delete old asset
-	oldAsset, err := d.gh.FindAsset(owner, repo, rel.ID, asset)
+	// Load the catalog, which lists the parts of multi-part assets
+	mgr := catalog.NewManager(d.gh, owner, repo, catalogPath)
+	books, err := mgr.Load()
 	if err != nil {
-		return false, fmt.Errorf("finding asset: %w", err)
+		return false, fmt.Errorf("loading catalog: %w", err)
 	}
-	if oldAsset != nil {
-		if err := d.gh.DeleteAsset(owner, repo, oldAsset.ID); err != nil {
-			return false, fmt.Errorf("deleting old asset: %w", err)
-		}
+	before, _ := catalog.Marshal(books)
+	bookToUpdate := catalog.ByID(books, bookID)
+	old := catalog.Source{Release: release, Asset: asset}
+	if bookToUpdate != nil {
+		old = bookToUpdate.Source
+	}
+
+	j := journal.Open(d.journalDir)
+	op := j.Begin("sync", "sync "+bookID)
+	defer func() { _ = j.Save(op) }()
+
+	// Find and delete old asset, or its parts, keeping copies for undo
+	if _, err := op.DeleteAssets(d.gh, owner, repo, rel.ID, old); err != nil {
+		return false, fmt.Errorf("deleting old asset: %w", err)
 	}
 
 	// Upload modified file
@@ -116,29 +151,31 @@ func (d *browserDownloader) Sync(owner, repo, bookID, release, asset, catalogPat
 	}
 	defer func() { _ = f.Close() }()
 
-	_, err = d.gh.UploadAsset(owner, repo, rel.ID, asset, f, cachedSize, "application/octet-stream")
+	manifest, err := parts.Upload(d.gh, owner, repo, rel.ID, asset, f, cachedSize, d.partSize)
 	if err != nil {
 		return false, fmt.Errorf("uploading: %w", err)
 	}
-
-	// Update catalog with new SHA256
-	mgr := catalog.NewManager(d.gh, owner, repo, catalogPath)
-	books, err := mgr.Load()
-	if err != nil {
-		return false, fmt.Errorf("loading catalog: %w", err)
+	uploaded := old
+	uploaded.Parts = manifest
+	for _, loc := range journal.Locations(owner, repo, uploaded) {
+		op.Added(loc)
 	}
 
-	// Find and update the book
-	bookToUpdate := catalog.ByID(books, bookID)
+	// Update catalog with new SHA256
 	if bookToUpdate != nil {
 		bookToUpdate.Checksum.SHA256 = cachedSHA
 		bookToUpdate.SizeBytes = cachedSize
+		bookToUpdate.Encryption = d.gh.EncryptionScheme(owner, repo)
+		bookToUpdate.Source.Parts = manifest
 
 		commitMsg := fmt.Sprintf("sync: update %s with local changes", bookID)
 		if err := mgr.Save(books, commitMsg); err != nil {
 			return false, fmt.Errorf("saving catalog: %w", err)
 		}
+		after, _ := catalog.Marshal(books)
+		op.Catalog(owner, repo, catalogPath, before, after)
 	}
+	_ = d.cache.MarkSynced(owner, repo, bookID, asset, cachedSHA)
 
 	return true, nil
 }
@@ -170,29 +207,14 @@ func (pr *progressReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
-// computeFileHash computes SHA256 hash and size of a file
-func computeFileHash(path string) (string, int64, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return "", 0, err
-	}
-	defer func() { _ = f.Close() }()
-
-	h := sha256.New()
-	size, err := io.Copy(h, f)
-	if err != nil {
-		return "", 0, err
-	}
-
-	return fmt.Sprintf("%x", h.Sum(nil)), size, nil
-}
-
 // NewBrowseModel creates a new browse model with the full browser
-func NewBrowseModel(books []tui.BookItem, gh *github.Client, cacheMgr *cache.Manager) BrowseModel {
+func NewBrowseModel(books []tui.BookItem, gh *github.Client, cfg *config.Config, cacheMgr *cache.Manager) BrowseModel {
 	// Create downloader
 	dl := &browserDownloader{
-		gh:    gh,
-		cache: cacheMgr,
+		gh:         gh,
+		cache:      cacheMgr,
+		partSize:   cfg.Defaults.EffectivePartSize(),
+		journalDir: cfg.Defaults.JournalDir,
 	}
 
 	// Create the browser model in unified mode
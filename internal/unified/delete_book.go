@@ -3,13 +3,16 @@ package unified
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/blackwell-systems/bubbletea-multiselect"
 	"github.com/blackwell-systems/shelfctl/internal/cache"
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -22,7 +25,7 @@ type deleteBookPhase int
 const (
 	deleteBookPicking    deleteBookPhase = iota // Showing book picker
 	deleteBookConfirming                        // Showing confirmation screen
-	deleteBookProcessing                        // Deleting books
+	deleteBookProcessing                        // Moving books to the trash
 )
 
 // DeleteBookCompleteMsg is emitted when deletion finishes
@@ -232,7 +235,7 @@ func (m DeleteBookModel) View() string {
 		return m.renderConfirmation()
 
 	case deleteBookProcessing:
-		return m.renderMessage("Deleting books...", "Please wait")
+		return m.renderMessage("Moving books to the trash...", "Please wait")
 	}
 
 	return ""
@@ -272,11 +275,11 @@ func (m DeleteBookModel) renderConfirmation() string {
 	b.WriteString("\n")
 	b.WriteString(dangerStyle.Render("This will:"))
 	b.WriteString("\n")
-	b.WriteString(tui.StyleNormal.Render("  - Remove books from catalog.yml"))
+	b.WriteString(tui.StyleNormal.Render("  - Move the files into the shelf's trash release"))
 	b.WriteString("\n")
-	b.WriteString(dangerStyle.Render("  - DELETE files from GitHub Release assets"))
+	b.WriteString(tui.StyleNormal.Render("  - Mark the books as trashed in catalog.yml"))
 	b.WriteString("\n\n")
-	b.WriteString(dangerStyle.Render("THIS CANNOT BE UNDONE"))
+	b.WriteString(tui.StyleHelp.Render("Trashed books can be restored from the Trash view until it is emptied"))
 	b.WriteString("\n\n")
 
 	// Help
@@ -301,8 +304,16 @@ func (m DeleteBookModel) deleteAsync() tea.Cmd {
 		successCount := 0
 		failCount := 0
 
+		ids := make([]string, len(toDelete))
+		for i, item := range toDelete {
+			ids[i] = item.Book.ID
+		}
+		j := journal.Open(cfg.Defaults.JournalDir)
+		op := j.Begin("delete-book", "delete "+bookList(ids))
+		defer func() { _ = j.Save(op) }()
+
 		for _, item := range toDelete {
-			if err := deleteSingleBookOp(item, gh, cfg, cacheMgr); err != nil {
+			if err := deleteSingleBookOp(item, gh, cfg, cacheMgr, op); err != nil {
 				failCount++
 			} else {
 				successCount++
@@ -316,35 +327,15 @@ func (m DeleteBookModel) deleteAsync() tea.Cmd {
 	}
 }
 
-// deleteSingleBookOp deletes a single book: removes asset, updates catalog, clears cache, updates README
-func deleteSingleBookOp(item tui.BookItem, gh *github.Client, cfg *config.Config, cacheMgr *cache.Manager) error {
+// deleteSingleBookOp moves a single book into its shelf's trash: moves the
+// asset to the trash release, marks the catalog entry, clears cache, updates
+// README. The asset move and the catalog are recorded in op.
+func deleteSingleBookOp(item tui.BookItem, gh *github.Client, cfg *config.Config, cacheMgr *cache.Manager, op *journal.Op) error {
 	shelf := cfg.ShelfByName(item.ShelfName)
 	if shelf == nil {
 		return fmt.Errorf("shelf %q not found", item.ShelfName)
 	}
-
 	catalogPath := shelf.EffectiveCatalogPath()
-	releaseTag := shelf.EffectiveRelease(cfg.Defaults.Release)
-
-	// Get the release
-	rel, err := gh.GetReleaseByTag(item.Owner, item.Repo, releaseTag)
-	if err != nil {
-		return fmt.Errorf("could not get release: %w", err)
-	}
-
-	// Find the asset
-	asset, err := gh.FindAsset(item.Owner, item.Repo, rel.ID, item.Book.Source.Asset)
-	if err != nil {
-		return fmt.Errorf("could not find asset: %w", err)
-	}
-	if asset == nil {
-		return fmt.Errorf("asset %q not found in release", item.Book.Source.Asset)
-	}
-
-	// Delete the asset from GitHub
-	if err := gh.DeleteAsset(item.Owner, item.Repo, asset.ID); err != nil {
-		return fmt.Errorf("could not delete asset: %w", err)
-	}
 
 	// Load catalog
 	data, _, err := gh.GetFileContent(item.Owner, item.Repo, catalogPath, "")
@@ -355,22 +346,29 @@ func deleteSingleBookOp(item tui.BookItem, gh *github.Client, cfg *config.Config
 	if err != nil {
 		return fmt.Errorf("could not parse catalog: %w", err)
 	}
-
-	// Remove from catalog
-	books, removed := catalog.Remove(books, item.Book.ID)
-	if !removed {
+	b := catalog.ByID(books, item.Book.ID)
+	if b == nil {
 		return fmt.Errorf("book %q not found in catalog", item.Book.ID)
 	}
 
+	// Move the asset into the trash release
+	from := journal.Locations(item.Owner, item.Repo, b.Source)
+	if err := trash.Put(gh, item.Owner, item.Repo, b, time.Now()); err != nil {
+		return err
+	}
+	b.Encryption = gh.EncryptionScheme(item.Owner, item.Repo)
+	op.MovedAll(from, journal.Locations(item.Owner, item.Repo, b.Source))
+
 	// Marshal and commit updated catalog
 	updatedData, err := catalog.Marshal(books)
 	if err != nil {
 		return fmt.Errorf("could not marshal catalog: %w", err)
 	}
-	commitMsg := fmt.Sprintf("delete: %s", item.Book.ID)
+	commitMsg := fmt.Sprintf("trash: %s", item.Book.ID)
 	if err := gh.CommitFile(item.Owner, item.Repo, catalogPath, updatedData, commitMsg); err != nil {
 		return fmt.Errorf("could not commit catalog: %w", err)
 	}
+	op.Catalog(item.Owner, item.Repo, catalogPath, data, updatedData)
 
 	// Clear from cache
 	if cacheMgr.Exists(item.Owner, item.Repo, item.Book.ID, item.Book.Source.Asset) {
@@ -381,7 +379,7 @@ func deleteSingleBookOp(item tui.BookItem, gh *github.Client, cfg *config.Config
 	readmeData, _, err := gh.GetFileContent(item.Owner, item.Repo, "README.md", "")
 	if err == nil {
 		originalContent := string(readmeData)
-		readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(books))
+		readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(catalog.Live(books)))
 		readmeContent = operations.RemoveFromShelfREADME(readmeContent, item.Book.ID)
 
 		if readmeContent != originalContent {
@@ -11,6 +11,7 @@ import (
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/charmbracelet/bubbles/list"
@@ -821,9 +822,14 @@ func (m EditBookModel) commitEditsAsync() tea.Cmd {
 		failCount := 0
 
 		editsByShelf := make(map[string][]editedBook)
-		for _, e := range edits {
+		ids := make([]string, len(edits))
+		for i, e := range edits {
 			editsByShelf[e.item.ShelfName] = append(editsByShelf[e.item.ShelfName], e)
+			ids[i] = e.item.Book.ID
 		}
+		j := journal.Open(cfg.Defaults.JournalDir)
+		op := j.Begin("edit-book", "edit "+bookList(ids))
+		defer func() { _ = j.Save(op) }()
 
 		for shelfName, shelfEdits := range editsByShelf {
 			shelf := cfg.ShelfByName(shelfName)
@@ -866,11 +872,12 @@ func (m EditBookModel) commitEditsAsync() tea.Cmd {
 			if err := gh.CommitFile(owner, shelf.Repo, catalogPath, updatedData, commitMsg); err != nil {
 				continue
 			}
+			op.Catalog(owner, shelf.Repo, catalogPath, catalogData, updatedData)
 
 			readmeData, _, readmeErr := gh.GetFileContent(owner, shelf.Repo, "README.md", "")
 			if readmeErr == nil {
 				originalContent := string(readmeData)
-				readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(books))
+				readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(catalog.Live(books)))
 				for _, book := range updatedBooks {
 					readmeContent = operations.AppendToShelfREADME(readmeContent, book)
 				}
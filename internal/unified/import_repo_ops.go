@@ -11,8 +11,10 @@ import (
 	"time"
 
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/migrate"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -57,6 +59,7 @@ func (m ImportRepoModel) processAsync(ch chan importRepoProgressMsg) tea.Cmd {
 	release := m.release
 	destRelTag := m.destRelTag
 	destShelfName := m.destShelf.Name
+	destCatPath := m.destCatPath
 
 	go func() {
 		defer close(ch)
@@ -65,6 +68,10 @@ func (m ImportRepoModel) processAsync(ch chan importRepoProgressMsg) tea.Cmd {
 		// Open ledger for resumability
 		ledger, _ := migrate.OpenLedger(migrate.DefaultLedgerPath())
 
+		// Destination catalog, to keep away from IDs in its trash
+		dstData, _, _ := gh.GetFileContent(destOwner, destRepo, destCatPath, "")
+		dstBooks, _ := catalog.Parse(dstData)
+
 		for i, f := range toImport {
 			ch <- importRepoProgressMsg{kind: "status", path: f.Path, current: i + 1, total: total}
 
@@ -95,6 +102,10 @@ func (m ImportRepoModel) processAsync(ch chan importRepoProgressMsg) tea.Cmd {
 			bookID := slugify(baseName)
 			ext := strings.TrimPrefix(filepath.Ext(f.Path), ".")
 			assetName := bookID + "." + ext
+			if err := trash.CheckID(dstBooks, bookID); err != nil {
+				ch <- importRepoProgressMsg{kind: "done", path: f.Path, current: i + 1, total: total, err: err}
+				continue
+			}
 
 			// Write to temp file for upload
 			tmp, err := os.CreateTemp("", "shelfctl-import-*")
@@ -150,6 +161,7 @@ func (m ImportRepoModel) processAsync(ch chan importRepoProgressMsg) tea.Cmd {
 				Checksum: catalog.Checksum{
 					SHA256: shaHex,
 				},
+				Encryption: gh.EncryptionScheme(destOwner, destRepo),
 				Meta: catalog.Meta{
 					AddedAt:      time.Now().UTC().Format(time.RFC3339),
 					MigratedFrom: fmt.Sprintf("%s/%s:%s", srcOwner, srcRepo, f.Path),
@@ -180,14 +192,29 @@ func (m ImportRepoModel) commitAsync() tea.Cmd {
 	destCatPath := m.destCatPath
 	srcOwner := m.srcOwner
 	srcRepo := m.srcRepo
+	journalDir := m.cfg.Defaults.JournalDir
 
 	return func() tea.Msg {
+		// Record the uploaded assets first, so they can be undone even if
+		// the catalog commit fails.
+		j := journal.Open(journalDir)
+		op := j.Begin("migrate", fmt.Sprintf("migrate from %s/%s", srcOwner, srcRepo))
+		defer func() { _ = j.Save(op) }()
+		for _, b := range importedBooks {
+			for _, loc := range journal.Locations(destOwner, destRepo, b.Source) {
+				op.Added(loc)
+			}
+		}
+
 		// Load existing catalog
 		dstData, _, _ := gh.GetFileContent(destOwner, destRepo, destCatPath, "")
 		dstBooks, _ := catalog.Parse(dstData)
 
 		allBooks := dstBooks
 		for _, b := range importedBooks {
+			if err := trash.CheckID(allBooks, b.ID); err != nil {
+				return importRepoCommitCompleteMsg{err: err}
+			}
 			allBooks = catalog.Append(allBooks, b)
 		}
 
@@ -200,6 +227,7 @@ func (m ImportRepoModel) commitAsync() tea.Cmd {
 		if err := gh.CommitFile(destOwner, destRepo, destCatPath, data, msg); err != nil {
 			return importRepoCommitCompleteMsg{err: err}
 		}
+		op.Catalog(destOwner, destRepo, destCatPath, dstData, data)
 
 		// Update README
 		readmeData, _, err := gh.GetFileContent(destOwner, destRepo, "README.md", "")
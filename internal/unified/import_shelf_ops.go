@@ -6,7 +6,10 @@ import (
 	"time"
 
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -34,9 +37,9 @@ func (m ImportShelfModel) scanAsync() tea.Cmd {
 		dstData, _, _ := gh.GetFileContent(destOwner, destRepo, destCatPath, "")
 		dstBooks, _ := catalog.Parse(dstData)
 
-		// Build SHA256 dedup index
+		// Build SHA256 dedup index; trashed books don't count
 		existingSHAs := map[string]bool{}
-		for _, b := range dstBooks {
+		for _, b := range catalog.Live(dstBooks) {
 			if b.Checksum.SHA256 != "" {
 				existingSHAs[b.Checksum.SHA256] = true
 			}
@@ -49,7 +52,7 @@ func (m ImportShelfModel) scanAsync() tea.Cmd {
 		}
 
 		return importShelfScanCompleteMsg{
-			srcBooks:     srcBooks,
+			srcBooks:     catalog.Live(srcBooks),
 			dstBooks:     dstBooks,
 			existingSHAs: existingSHAs,
 			release:      rel,
@@ -60,12 +63,14 @@ func (m ImportShelfModel) scanAsync() tea.Cmd {
 func (m ImportShelfModel) processAsync(ch chan importShelfProgressMsg) tea.Cmd {
 	gh := m.gh
 	toImport := m.toImport
+	dstBooks := m.dstBooks
 	srcOwner := m.srcOwner
 	srcRepo := m.srcRepo
 	destOwner := m.destOwner
 	destRepo := m.destShelf.Repo
 	release := m.release
 	destRelTag := m.destRelTag
+	partSize := m.cfg.Defaults.EffectivePartSize()
 
 	go func() {
 		defer close(ch)
@@ -74,6 +79,11 @@ func (m ImportShelfModel) processAsync(ch chan importShelfProgressMsg) tea.Cmd {
 		for i, b := range toImport {
 			ch <- importShelfProgressMsg{kind: "status", bookID: b.ID, current: i + 1, total: total}
 
+			if err := trash.CheckID(dstBooks, b.ID); err != nil {
+				ch <- importShelfProgressMsg{kind: "done", bookID: b.ID, current: i + 1, total: total, err: err}
+				continue
+			}
+
 			// Find source release asset
 			srcRel, err := gh.GetReleaseByTag(b.Source.Owner, b.Source.Repo, b.Source.Release)
 			if err != nil {
@@ -82,15 +92,15 @@ func (m ImportShelfModel) processAsync(ch chan importShelfProgressMsg) tea.Cmd {
 				continue
 			}
 
-			srcAsset, err := gh.FindAsset(b.Source.Owner, b.Source.Repo, srcRel.ID, b.Source.Asset)
-			if err != nil || srcAsset == nil {
+			rc, _, err := parts.Open(gh, b.Source.Owner, b.Source.Repo, srcRel.ID, b)
+			if err != nil {
 				ch <- importShelfProgressMsg{kind: "done", bookID: b.ID, current: i + 1, total: total,
-					err: fmt.Errorf("source asset not found for %s", b.ID)}
+					err: fmt.Errorf("source asset not found for %s: %w", b.ID, err)}
 				continue
 			}
 
 			// Download and buffer to temp
-			tmpPath, size, err := downloadAndBufferAsset(gh, b.Source.Owner, b.Source.Repo, srcAsset.ID)
+			tmpPath, size, err := bufferToTemp(rc)
 			if err != nil {
 				ch <- importShelfProgressMsg{kind: "done", bookID: b.ID, current: i + 1, total: total,
 					err: fmt.Errorf("download failed for %s: %w", b.ID, err)}
@@ -106,8 +116,8 @@ func (m ImportShelfModel) processAsync(ch chan importShelfProgressMsg) tea.Cmd {
 				continue
 			}
 
-			_, err = gh.UploadAsset(destOwner, destRepo, release.ID, b.Source.Asset,
-				uploadFile, size, "application/octet-stream")
+			manifest, err := parts.Upload(gh, destOwner, destRepo, release.ID, b.Source.Asset,
+				uploadFile, size, partSize)
 			_ = uploadFile.Close()
 			_ = os.Remove(tmpPath)
 
@@ -119,13 +129,16 @@ func (m ImportShelfModel) processAsync(ch chan importShelfProgressMsg) tea.Cmd {
 
 			// Build new book entry for destination
 			newBook := b
+			newBook.Trashed = nil
 			newBook.Source = catalog.Source{
 				Type:    "github_release",
 				Owner:   destOwner,
 				Repo:    destRepo,
 				Release: destRelTag,
 				Asset:   b.Source.Asset,
+				Parts:   manifest,
 			}
+			newBook.Encryption = gh.EncryptionScheme(destOwner, destRepo)
 			newBook.Meta.AddedAt = time.Now().UTC().Format(time.RFC3339)
 			newBook.Meta.MigratedFrom = fmt.Sprintf("%s/%s", srcOwner, srcRepo)
 
@@ -145,10 +158,26 @@ func (m ImportShelfModel) commitAsync() tea.Cmd {
 	destCatPath := m.destCatPath
 	srcOwner := m.srcOwner
 	srcRepo := m.srcRepo
+	journalDir := m.cfg.Defaults.JournalDir
 
 	return func() tea.Msg {
+		// Record the uploaded assets first, so they can be undone even if
+		// the catalog commit fails.
+		j := journal.Open(journalDir)
+		op := j.Begin("import", fmt.Sprintf("import from %s/%s", srcOwner, srcRepo))
+		defer func() { _ = j.Save(op) }()
+		for _, b := range importedBooks {
+			for _, loc := range journal.Locations(destOwner, destRepo, b.Source) {
+				op.Added(loc)
+			}
+		}
+
+		before, _ := catalog.Marshal(dstBooks)
 		allBooks := dstBooks
 		for _, b := range importedBooks {
+			if err := trash.CheckID(allBooks, b.ID); err != nil {
+				return importShelfCommitCompleteMsg{err: err}
+			}
 			allBooks = catalog.Append(allBooks, b)
 		}
 
@@ -161,6 +190,7 @@ func (m ImportShelfModel) commitAsync() tea.Cmd {
 		if err := gh.CommitFile(destOwner, destRepo, destCatPath, data, msg); err != nil {
 			return importShelfCommitCompleteMsg{err: err}
 		}
+		op.Catalog(destOwner, destRepo, destCatPath, before, data)
 
 		// Update README
 		readmeData, _, err := gh.GetFileContent(destOwner, destRepo, "README.md", "")
@@ -0,0 +1,12 @@
+package unified
+
+import "strings"
+
+// bookList names books in a journal summary, shortened past a few.
+func bookList(ids []string) string {
+	const shown = 3
+	if len(ids) <= shown {
+		return strings.Join(ids, ", ")
+	}
+	return strings.Join(ids[:shown], ", ") + ", …"
+}
@@ -6,12 +6,16 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/blackwell-systems/shelfctl/internal/cache"
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -35,6 +39,7 @@ const (
 	ViewImportRepo  View = "import-repo"
 	ViewShelves     View = "shelves"
 	ViewIndex       View = "index"
+	ViewTrash       View = "trash"
 )
 
 // Model is the unified TUI orchestrator that manages view switching
@@ -58,6 +63,7 @@ type Model struct {
 	importRepo  ImportRepoModel
 	shelves     ShelvesModel
 	index       IndexModel
+	trash       TrashModel
 
 	// Context passed between views
 	hubContext tui.HubContext
@@ -95,7 +101,7 @@ func NewAtView(ctx tui.HubContext, gh *github.Client, cfg *config.Config, cacheM
 		m.hub = NewHubModel(ctx)
 	case ViewBrowse:
 		books := m.collectBooks()
-		m.browse = NewBrowseModel(books, gh, cacheMgr)
+		m.browse = NewBrowseModel(books, gh, cfg, cacheMgr)
 	case ViewCreateShelf:
 		m.createShelf = NewCreateShelfModel(gh, cfg)
 	// Add other views as they're implemented
@@ -201,6 +207,8 @@ func (m Model) View() string {
 		content = m.shelves.View()
 	case ViewIndex:
 		content = m.index.View()
+	case ViewTrash:
+		content = m.trash.View()
 	default:
 		content = "Unknown view"
 	}
@@ -282,6 +290,10 @@ func (m Model) updateCurrentView(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var indexModel IndexModel
 		indexModel, cmd = m.index.Update(msg)
 		m.index = indexModel
+	case ViewTrash:
+		var trashModel TrashModel
+		trashModel, cmd = m.trash.Update(msg)
+		m.trash = trashModel
 	}
 
 	return m, cmd
@@ -296,7 +308,6 @@ func (m Model) collectBooks() []tui.BookItem {
 		shelf := &m.cfg.Shelves[i]
 		owner := shelf.EffectiveOwner(m.cfg.GitHub.Owner)
 		catalogPath := shelf.EffectiveCatalogPath()
-		releaseTag := shelf.EffectiveRelease(m.cfg.Defaults.Release)
 
 		data, _, err := m.gh.GetFileContent(owner, shelf.Repo, catalogPath, "")
 		if err != nil {
@@ -310,7 +321,7 @@ func (m Model) collectBooks() []tui.BookItem {
 			continue
 		}
 
-		for _, b := range books {
+		for _, b := range catalog.Live(books) {
 			cached := m.cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset)
 
 			// Download catalog cover if specified and not already cached
@@ -326,7 +337,7 @@ func (m Model) collectBooks() []tui.BookItem {
 				Cached:      cached,
 				Owner:       owner,
 				Repo:        shelf.Repo,
-				Release:     releaseTag,
+				Release:     b.Source.Release,
 				CatalogPath: catalogPath,
 			})
 		}
@@ -335,6 +346,43 @@ func (m Model) collectBooks() []tui.BookItem {
 	return allItems
 }
 
+// collectTrashBooks gathers trashed books from all shelves, most recently
+// trashed first
+func (m Model) collectTrashBooks() []tui.BookItem {
+	var allItems []tui.BookItem
+
+	for i := range m.cfg.Shelves {
+		shelf := &m.cfg.Shelves[i]
+		owner := shelf.EffectiveOwner(m.cfg.GitHub.Owner)
+		catalogPath := shelf.EffectiveCatalogPath()
+
+		data, _, err := m.gh.GetFileContent(owner, shelf.Repo, catalogPath, "")
+		if err != nil {
+			continue
+		}
+		books, err := catalog.Parse(data)
+		if err != nil {
+			continue
+		}
+
+		for _, b := range catalog.InTrash(books) {
+			allItems = append(allItems, tui.BookItem{
+				Book:        b,
+				ShelfName:   shelf.Name,
+				Owner:       owner,
+				Repo:        shelf.Repo,
+				Release:     trash.Release,
+				CatalogPath: catalogPath,
+			})
+		}
+	}
+
+	sort.SliceStable(allItems, func(i, j int) bool {
+		return allItems[i].Book.Trashed.At > allItems[j].Book.Trashed.At
+	})
+	return allItems
+}
+
 // collectIndexBooks gathers books in the cache.IndexBook format needed by IndexModel.
 func (m Model) collectIndexBooks() []cache.IndexBook {
 	var result []cache.IndexBook
@@ -353,13 +401,13 @@ func (m Model) collectIndexBooks() []cache.IndexBook {
 			continue
 		}
 
-		for _, b := range books {
+		for _, b := range catalog.Live(books) {
 			isCached := m.cacheMgr.Exists(owner, shelf.Repo, b.ID, b.Source.Asset)
 			var filePath string
 			if isCached {
 				filePath = m.cacheMgr.Path(owner, shelf.Repo, b.ID, b.Source.Asset)
 			}
-			coverPath := m.cacheMgr.GetCoverPath(shelf.Repo, b.ID)
+			coverPath := m.cacheMgr.GetCoverPathOrPlaceholder(shelf.Repo, b)
 			result = append(result, cache.IndexBook{
 				Book:      b,
 				ShelfName: shelf.Name,
@@ -381,7 +429,7 @@ func (m Model) handleNavigation(msg NavigateMsg) (tea.Model, tea.Cmd) {
 		m.currentView = ViewBrowse
 		// Collect books from all shelves (same logic as browse.go)
 		books := m.collectBooks()
-		m.browse = NewBrowseModel(books, m.gh, m.cacheMgr)
+		m.browse = NewBrowseModel(books, m.gh, m.cfg, m.cacheMgr)
 		// Batch init command with window size message
 		return m, tea.Batch(
 			m.browse.Init(),
@@ -448,6 +496,18 @@ func (m Model) handleNavigation(msg NavigateMsg) (tea.Model, tea.Cmd) {
 			},
 		)
 
+	case "trash":
+		// Trash as unified view (no terminal drop)
+		m.currentView = ViewTrash
+		books := m.collectTrashBooks()
+		m.trash = NewTrashModel(books, m.gh, m.cfg)
+		return m, tea.Batch(
+			m.trash.Init(),
+			func() tea.Msg {
+				return tea.WindowSizeMsg{Width: m.width, Height: m.height}
+			},
+		)
+
 	case "cache-clear":
 		// Cache clear as unified view (no terminal drop)
 		m.currentView = ViewCacheClear
@@ -576,19 +636,10 @@ func (m Model) handleOpenBook(item *tui.BookItem) error {
 			return fmt.Errorf("release %q: %w", b.Source.Release, err)
 		}
 
-		// Find asset
-		asset, err := m.gh.FindAsset(item.Owner, item.Repo, rel.ID, b.Source.Asset)
+		// Find and download the asset, or its parts
+		rc, size, err := parts.Open(m.gh, item.Owner, item.Repo, rel.ID, *b)
 		if err != nil {
-			return fmt.Errorf("finding asset: %w", err)
-		}
-		if asset == nil {
-			return fmt.Errorf("asset %q not found", b.Source.Asset)
-		}
-
-		// Download
-		rc, err := m.gh.DownloadAsset(item.Owner, item.Repo, asset.ID)
-		if err != nil {
-			return fmt.Errorf("download: %w", err)
+			return err
 		}
 		defer func() { _ = rc.Close() }()
 
@@ -601,15 +652,15 @@ func (m Model) handleOpenBook(item *tui.BookItem) error {
 
 		// Start download in goroutine
 		go func() {
-			pr := tui.NewProgressReader(rc, asset.Size, progressCh)
+			pr := tui.NewProgressReader(rc, size, progressCh)
 			_, err := m.cacheMgr.Store(item.Owner, item.Repo, b.ID, b.Source.Asset, pr, b.Checksum.SHA256)
 			close(progressCh)
 			errCh <- err
 		}()
 
 		// Show progress UI (TUI-based progress bar)
-		label := fmt.Sprintf("Downloading %s (%s)", b.ID, humanBytes(asset.Size))
-		if err := tui.ShowProgress(label, asset.Size, progressCh); err != nil {
+		label := fmt.Sprintf("Downloading %s (%s)", b.ID, humanBytes(size))
+		if err := tui.ShowProgress(label, size, progressCh); err != nil {
 			return err // User cancelled
 		}
 
@@ -623,6 +674,9 @@ func (m Model) handleOpenBook(item *tui.BookItem) error {
 
 	// Open the file
 	path := m.cacheMgr.Path(item.Owner, item.Repo, b.ID, b.Source.Asset)
+	if err := m.cacheMgr.MarkOpened(item.Owner, item.Repo, b.ID, b.Source.Asset); err != nil {
+		fmt.Printf("⚠ %v\n", err)
+	}
 	return openFile(path, "")
 }
 
@@ -686,11 +740,16 @@ func (m Model) handleEditBook(item *tui.BookItem) error {
 	books = catalog.Append(books, updatedBook)
 
 	// Commit catalog
+	j := journal.Open(m.cfg.Defaults.JournalDir)
+	op := j.Begin("edit-book", "edit "+b.ID)
+	defer func() { _ = j.Save(op) }()
 	mgr := catalog.NewManager(m.gh, owner, shelf.Repo, catalogPath)
 	commitMsg := fmt.Sprintf("edit: update %s metadata", b.ID)
 	if err := mgr.Save(books, commitMsg); err != nil {
 		return fmt.Errorf("committing catalog: %w", err)
 	}
+	after, _ := catalog.Marshal(books)
+	op.Catalog(owner, shelf.Repo, catalogPath, data, after)
 
 	fmt.Printf("\n✓ Book successfully updated: %s\n", b.ID)
 	fmt.Println("\nPress Enter to return to browse...")
@@ -829,8 +888,9 @@ func BuildContext(gh *github.Client, cfg *config.Config, cacheMgr *cache.Manager
 		// Load catalog and count books
 		if data, _, err := gh.GetFileContent(owner, shelf.Repo, catalogPath, ""); err == nil {
 			if books, err := catalog.Parse(data); err == nil {
-				status.BookCount = len(books)
-				ctx.BookCount += len(books)
+				live := len(catalog.Live(books))
+				status.BookCount = live
+				ctx.BookCount += live
 			}
 		} else {
 			status.Status = "⚠ Catalog missing"
@@ -9,7 +9,9 @@ import (
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -29,12 +31,20 @@ func (m MoveBookModel) moveAsync() tea.Cmd {
 		successCount := 0
 		failCount := 0
 
+		ids := make([]string, len(toMove))
+		for i, item := range toMove {
+			ids[i] = item.Book.ID
+		}
+		j := journal.Open(cfg.Defaults.JournalDir)
+		op := j.Begin("move", "move "+bookList(ids))
+		defer func() { _ = j.Save(op) }()
+
 		for _, item := range toMove {
 			var err error
 			if moveType == moveToShelf {
-				err = moveSingleBookToShelf(item, destShelfName, gh, cfg, cacheMgr)
+				err = moveSingleBookToShelf(item, destShelfName, gh, cfg, cacheMgr, op)
 			} else {
-				err = moveSingleBookToRelease(item, destRelease, gh, cfg)
+				err = moveSingleBookToRelease(item, destRelease, gh, cfg, op)
 			}
 
 			if err != nil {
@@ -51,8 +61,9 @@ func (m MoveBookModel) moveAsync() tea.Cmd {
 	}
 }
 
-// moveSingleBookToShelf moves a book to a different shelf (cross-repo)
-func moveSingleBookToShelf(item tui.BookItem, destShelfName string, gh *github.Client, cfg *config.Config, cacheMgr *cache.Manager) error {
+// moveSingleBookToShelf moves a book to a different shelf (cross-repo),
+// recording it in op.
+func moveSingleBookToShelf(item tui.BookItem, destShelfName string, gh *github.Client, cfg *config.Config, cacheMgr *cache.Manager, op *journal.Op) error {
 	srcShelf := cfg.ShelfByName(item.ShelfName)
 	if srcShelf == nil {
 		return fmt.Errorf("source shelf %q not found", item.ShelfName)
@@ -73,6 +84,15 @@ func moveSingleBookToShelf(item tui.BookItem, destShelfName string, gh *github.C
 		return fmt.Errorf("book is already in shelf %q", destShelfName)
 	}
 
+	// Refuse before copying anything if the destination's trash holds a
+	// book of the same ID
+	dstCatalogPath := dstShelf.EffectiveCatalogPath()
+	dstData, _, _ := gh.GetFileContent(dstOwner, dstShelf.Repo, dstCatalogPath, "")
+	dstBooks, _ := catalog.Parse(dstData)
+	if err := trash.CheckID(dstBooks, b.ID); err != nil {
+		return err
+	}
+
 	// 1. Ensure destination release
 	dstRel, err := gh.EnsureRelease(dstOwner, dstShelf.Repo, dstRelease)
 	if err != nil {
@@ -84,39 +104,19 @@ func moveSingleBookToShelf(item tui.BookItem, destShelfName string, gh *github.C
 	if err != nil {
 		return fmt.Errorf("getting source release: %w", err)
 	}
-	srcAsset, err := gh.FindAsset(srcOwner, srcShelf.Repo, srcRel.ID, b.Source.Asset)
-	if err != nil {
-		return fmt.Errorf("finding source asset: %w", err)
-	}
-	if srcAsset == nil {
-		return fmt.Errorf("source asset %q not found", b.Source.Asset)
-	}
-
-	// 3. Download and buffer
-	tmpPath, size, err := downloadAndBufferAsset(gh, srcOwner, srcShelf.Repo, srcAsset.ID)
-	if err != nil {
-		return fmt.Errorf("downloading: %w", err)
-	}
-	defer func() { _ = os.Remove(tmpPath) }()
 
-	// 4. Upload to destination
-	uploadFile, err := os.Open(tmpPath)
+	// 3. Copy the asset, or each of its parts, to the destination
+	srcIDs, err := copyAssets(gh, srcOwner, srcShelf.Repo, srcRel.ID, dstOwner, dstShelf.Repo, dstRel.ID, b.Source.AssetNames())
 	if err != nil {
 		return err
 	}
-	defer func() { _ = uploadFile.Close() }()
+	dstSource := b.Source
+	dstSource.Release = dstRelease
+	src := journal.Locations(srcOwner, srcShelf.Repo, b.Source)
+	dst := journal.Locations(dstOwner, dstShelf.Repo, dstSource)
 
-	_, err = gh.UploadAsset(dstOwner, dstShelf.Repo, dstRel.ID, b.Source.Asset,
-		uploadFile, size, "application/octet-stream")
-	if err != nil {
-		return fmt.Errorf("uploading to destination: %w", err)
-	}
-
-	// 5. Delete old asset
-	if err := gh.DeleteAsset(srcOwner, srcShelf.Repo, srcAsset.ID); err != nil {
-		// Warn but continue — asset was already copied
-		_ = err
-	}
+	// 4. Delete old asset (warn but continue — it was already copied)
+	recordMove(op, src, dst, deleteAssets(gh, srcOwner, srcShelf.Repo, srcIDs))
 
 	// 6. Update source catalog (remove book)
 	srcCatalogPath := srcShelf.EffectiveCatalogPath()
@@ -137,17 +137,21 @@ func moveSingleBookToShelf(item tui.BookItem, destShelfName string, gh *github.C
 		fmt.Sprintf("move: remove %s (moved to %s)", b.ID, destShelfName)); err != nil {
 		return fmt.Errorf("committing source catalog: %w", err)
 	}
+	op.Catalog(srcOwner, srcShelf.Repo, srcCatalogPath, srcData, srcMarshal)
 
 	// 7. Update destination catalog (add book)
-	dstCatalogPath := dstShelf.EffectiveCatalogPath()
-	dstData, _, _ := gh.GetFileContent(dstOwner, dstShelf.Repo, dstCatalogPath, "")
-	dstBooks, _ := catalog.Parse(dstData)
+	dstData, _, _ = gh.GetFileContent(dstOwner, dstShelf.Repo, dstCatalogPath, "")
+	dstBooks, _ = catalog.Parse(dstData)
+	if err := trash.CheckID(dstBooks, b.ID); err != nil {
+		return err
+	}
 
 	// Update book metadata for destination
 	movedBook := *b
 	movedBook.Source.Release = dstRelease
 	movedBook.Source.Owner = dstOwner
 	movedBook.Source.Repo = dstShelf.Repo
+	movedBook.Encryption = gh.EncryptionScheme(dstOwner, dstShelf.Repo)
 
 	dstBooks = catalog.Append(dstBooks, movedBook)
 	dstMarshal, err := catalog.Marshal(dstBooks)
@@ -158,10 +162,11 @@ func moveSingleBookToShelf(item tui.BookItem, destShelfName string, gh *github.C
 		fmt.Sprintf("move: add %s (from %s)", b.ID, item.ShelfName)); err != nil {
 		return fmt.Errorf("committing destination catalog: %w", err)
 	}
+	op.Catalog(dstOwner, dstShelf.Repo, dstCatalogPath, dstData, dstMarshal)
 
-	// 8. Clear local cache (path changes after move)
+	// 8. Move the cached copy along with the book
 	if cacheMgr.Exists(srcOwner, srcShelf.Repo, b.ID, b.Source.Asset) {
-		_ = cacheMgr.Remove(srcOwner, srcShelf.Repo, b.ID, b.Source.Asset)
+		_ = cacheMgr.Move(srcOwner, srcShelf.Repo, b.ID, b.Source.Asset, dstOwner, dstShelf.Repo, b.Source.Asset)
 	}
 
 	// 9. Update README files
@@ -201,8 +206,9 @@ func moveSingleBookToShelf(item tui.BookItem, destShelfName string, gh *github.C
 	return nil
 }
 
-// moveSingleBookToRelease moves a book to a different release within the same shelf
-func moveSingleBookToRelease(item tui.BookItem, destRelease string, gh *github.Client, cfg *config.Config) error {
+// moveSingleBookToRelease moves a book to a different release within the
+// same shelf, recording it in op.
+func moveSingleBookToRelease(item tui.BookItem, destRelease string, gh *github.Client, cfg *config.Config, op *journal.Op) error {
 	shelf := cfg.ShelfByName(item.ShelfName)
 	if shelf == nil {
 		return fmt.Errorf("shelf %q not found", item.ShelfName)
@@ -227,38 +233,19 @@ func moveSingleBookToRelease(item tui.BookItem, destRelease string, gh *github.C
 	if err != nil {
 		return fmt.Errorf("getting source release: %w", err)
 	}
-	srcAsset, err := gh.FindAsset(owner, shelf.Repo, srcRel.ID, b.Source.Asset)
-	if err != nil {
-		return fmt.Errorf("finding source asset: %w", err)
-	}
-	if srcAsset == nil {
-		return fmt.Errorf("source asset %q not found", b.Source.Asset)
-	}
-
-	// 3. Download and buffer
-	tmpPath, size, err := downloadAndBufferAsset(gh, owner, shelf.Repo, srcAsset.ID)
-	if err != nil {
-		return fmt.Errorf("downloading: %w", err)
-	}
-	defer func() { _ = os.Remove(tmpPath) }()
 
-	// 4. Upload to destination release
-	uploadFile, err := os.Open(tmpPath)
+	// 3. Copy the asset, or each of its parts, to the destination release
+	srcIDs, err := copyAssets(gh, owner, shelf.Repo, srcRel.ID, owner, shelf.Repo, dstRel.ID, b.Source.AssetNames())
 	if err != nil {
 		return err
 	}
-	defer func() { _ = uploadFile.Close() }()
+	dstSource := b.Source
+	dstSource.Release = destRelease
+	src := journal.Locations(owner, shelf.Repo, b.Source)
+	dst := journal.Locations(owner, shelf.Repo, dstSource)
 
-	_, err = gh.UploadAsset(owner, shelf.Repo, dstRel.ID, b.Source.Asset,
-		uploadFile, size, "application/octet-stream")
-	if err != nil {
-		return fmt.Errorf("uploading: %w", err)
-	}
-
-	// 5. Delete old asset
-	if err := gh.DeleteAsset(owner, shelf.Repo, srcAsset.ID); err != nil {
-		_ = err // Warn but continue
-	}
+	// 4. Delete old asset (warn but continue)
+	recordMove(op, src, dst, deleteAssets(gh, owner, shelf.Repo, srcIDs))
 
 	// 6. Update catalog (change release field)
 	catalogPath := shelf.EffectiveCatalogPath()
@@ -274,6 +261,7 @@ func moveSingleBookToRelease(item tui.BookItem, destRelease string, gh *github.C
 	for i := range books {
 		if books[i].ID == b.ID {
 			books[i].Source.Release = destRelease
+			books[i].Encryption = gh.EncryptionScheme(owner, shelf.Repo)
 			break
 		}
 	}
@@ -286,17 +274,82 @@ func moveSingleBookToRelease(item tui.BookItem, destRelease string, gh *github.C
 		fmt.Sprintf("move: %s → release/%s", b.ID, destRelease)); err != nil {
 		return fmt.Errorf("committing catalog: %w", err)
 	}
+	op.Catalog(owner, shelf.Repo, catalogPath, data, newData)
 
 	return nil
 }
 
+// copyAssets copies the named assets from one release to another and
+// returns the IDs of the source assets.
+func copyAssets(gh *github.Client, srcOwner, srcRepo string, srcRelID int64, dstOwner, dstRepo string, dstRelID int64, names []string) ([]int64, error) {
+	var ids []int64
+	for _, name := range names {
+		srcAsset, err := gh.FindAsset(srcOwner, srcRepo, srcRelID, name)
+		if err != nil {
+			return nil, fmt.Errorf("finding source asset: %w", err)
+		}
+		if srcAsset == nil {
+			return nil, fmt.Errorf("source asset %q not found", name)
+		}
+
+		tmpPath, size, err := downloadAndBufferAsset(gh, srcOwner, srcRepo, srcAsset.ID)
+		if err != nil {
+			return nil, fmt.Errorf("downloading: %w", err)
+		}
+		err = uploadBufferedAsset(gh, dstOwner, dstRepo, dstRelID, name, tmpPath, size)
+		_ = os.Remove(tmpPath)
+		if err != nil {
+			return nil, fmt.Errorf("uploading to destination: %w", err)
+		}
+		ids = append(ids, srcAsset.ID)
+	}
+	return ids, nil
+}
+
+// uploadBufferedAsset uploads a temp file written by downloadAndBufferAsset.
+func uploadBufferedAsset(gh *github.Client, owner, repo string, releaseID int64, name, tmpPath string, size int64) error {
+	f, err := os.Open(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	_, err = gh.UploadAsset(owner, repo, releaseID, name, f, size, "application/octet-stream")
+	return err
+}
+
+// deleteAssets deletes assets by ID and reports whether all are gone.
+func deleteAssets(gh *github.Client, owner, repo string, ids []int64) bool {
+	for _, id := range ids {
+		if err := gh.DeleteAsset(owner, repo, id); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// recordMove records copied assets as moved if the originals were deleted,
+// and as added otherwise.
+func recordMove(op *journal.Op, src, dst []journal.Location, deleted bool) {
+	if deleted {
+		op.MovedAll(src, dst)
+		return
+	}
+	for _, loc := range dst {
+		op.Added(loc)
+	}
+}
+
 // downloadAndBufferAsset downloads a release asset to a temp file
 func downloadAndBufferAsset(gh *github.Client, owner, repo string, assetID int64) (string, int64, error) {
 	rc, err := gh.DownloadAsset(owner, repo, assetID)
 	if err != nil {
 		return "", 0, fmt.Errorf("downloading: %w", err)
 	}
+	return bufferToTemp(rc)
+}
 
+// bufferToTemp copies rc to a temp file and closes it.
+func bufferToTemp(rc io.ReadCloser) (string, int64, error) {
 	tmp, err := os.CreateTemp("", "shelfctl-move-*")
 	if err != nil {
 		_ = rc.Close()
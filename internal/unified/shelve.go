@@ -11,6 +11,7 @@ import (
 	"github.com/blackwell-systems/shelfctl/internal/config"
 	"github.com/blackwell-systems/shelfctl/internal/github"
 	"github.com/blackwell-systems/shelfctl/internal/ingest"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -49,18 +50,20 @@ type ShelveCompleteMsg struct {
 // Internal messages
 type shelveSetupCompleteMsg struct {
 	existingBooks []catalog.Book
+	catalogBefore []byte
 	release       *github.Release
 	err           error
 }
 
 type shelveIngestCompleteMsg struct {
-	tmpPath     string
-	sha256      string
-	size        int64
-	format      string
-	srcName     string
-	pdfMetadata *ingest.PDFMetadata
-	err         error
+	tmpPath      string
+	sha256       string
+	size         int64
+	format       string
+	srcName      string
+	pdfMetadata  *ingest.PDFMetadata
+	epubMetadata *ingest.EPUBMetadata
+	err          error
 }
 
 type shelveProcessingMsg struct {
@@ -78,12 +81,13 @@ type shelveCommitCompleteMsg struct {
 
 // ingestedFile holds result of file ingestion
 type shelveIngestedFile struct {
-	tmpPath     string
-	sha256      string
-	size        int64
-	format      string
-	srcName     string
-	pdfMetadata *ingest.PDFMetadata
+	tmpPath      string
+	sha256       string
+	size         int64
+	format       string
+	srcName      string
+	pdfMetadata  *ingest.PDFMetadata
+	epubMetadata *ingest.EPUBMetadata
 }
 
 // ShelveModel is the unified view for adding books to the library
@@ -132,6 +136,7 @@ type ShelveModel struct {
 
 	// Accumulated results
 	existingBooks []catalog.Book
+	catalogBefore []byte // catalog as loaded, for the undo journal
 	newBooks      []catalog.Book
 	release       *github.Release
 	successCount  int
@@ -149,6 +154,8 @@ type shelveFormDefaults struct {
 	filename string
 	title    string
 	author   string
+	year     int
+	tags     string
 	id       string
 }
 
@@ -353,6 +360,7 @@ func (m ShelveModel) Update(msg tea.Msg) (ShelveModel, tea.Cmd) {
 			return m, func() tea.Msg { return NavigateMsg{Target: "hub"} }
 		}
 		m.existingBooks = msg.existingBooks
+		m.catalogBefore = msg.catalogBefore
 		m.release = msg.release
 		m.fileIndex = 0
 		m.phase = shelveIngesting
@@ -364,12 +372,13 @@ func (m ShelveModel) Update(msg tea.Msg) (ShelveModel, tea.Cmd) {
 			return m.advanceToNextFileOrCommit()
 		}
 		m.ingested = &shelveIngestedFile{
-			tmpPath:     msg.tmpPath,
-			sha256:      msg.sha256,
-			size:        msg.size,
-			format:      msg.format,
-			srcName:     msg.srcName,
-			pdfMetadata: msg.pdfMetadata,
+			tmpPath:      msg.tmpPath,
+			sha256:       msg.sha256,
+			size:         msg.size,
+			format:       msg.format,
+			srcName:      msg.srcName,
+			pdfMetadata:  msg.pdfMetadata,
+			epubMetadata: msg.epubMetadata,
 		}
 		// Initialize form with ingested metadata
 		m.initFormForCurrentFile()
@@ -533,6 +542,8 @@ func (m *ShelveModel) initFormForCurrentFile() {
 	// Compute defaults (same logic as app/shelve.go collectMetadata)
 	defaultTitle := strings.TrimSuffix(ing.srcName, filepath.Ext(ing.srcName))
 	defaultAuthor := ""
+	defaultYear := 0
+	defaultTags := ""
 
 	if ing.pdfMetadata != nil {
 		if ing.pdfMetadata.Title != "" {
@@ -543,12 +554,25 @@ func (m *ShelveModel) initFormForCurrentFile() {
 		}
 	}
 
+	if ing.epubMetadata != nil {
+		if ing.epubMetadata.Title != "" {
+			defaultTitle = ing.epubMetadata.Title
+		}
+		if author := ing.epubMetadata.Author(); author != "" {
+			defaultAuthor = author
+		}
+		defaultYear = ing.epubMetadata.Year()
+		defaultTags = strings.Join(ing.epubMetadata.Tags(), ",")
+	}
+
 	defaultID := slugify(defaultTitle)
 
 	m.formDefaults = shelveFormDefaults{
 		filename: ing.srcName,
 		title:    defaultTitle,
 		author:   defaultAuthor,
+		year:     defaultYear,
+		tags:     defaultTags,
 		id:       defaultID,
 	}
 
@@ -588,7 +612,11 @@ func (m *ShelveModel) initFormForCurrentFile() {
 
 	// Tags
 	m.inputs[shelveFieldTags] = textinput.New()
-	m.inputs[shelveFieldTags].Placeholder = "comma,separated,tags"
+	if defaultTags != "" {
+		m.inputs[shelveFieldTags].Placeholder = truncate(defaultTags, maxPlaceholderWidth)
+	} else {
+		m.inputs[shelveFieldTags].Placeholder = "comma,separated,tags"
+	}
 	m.inputs[shelveFieldTags].CharLimit = 200
 	m.inputs[shelveFieldTags].Width = inputWidth
 	m.inputs[shelveFieldTags].Prompt = ""
@@ -612,6 +640,8 @@ func (m ShelveModel) getFormValue(field int) string {
 		return m.formDefaults.title
 	case shelveFieldAuthor:
 		return m.formDefaults.author
+	case shelveFieldTags:
+		return m.formDefaults.tags
 	case shelveFieldID:
 		return m.formDefaults.id
 	default:
@@ -699,7 +729,7 @@ func (m ShelveModel) submitForm() (ShelveModel, tea.Cmd) {
 	// Collect form values
 	title := m.getFormValue(shelveFieldTitle)
 	author := m.getFormValue(shelveFieldAuthor)
-	tagsCSV := m.inputs[shelveFieldTags].Value()
+	tagsCSV := m.getFormValue(shelveFieldTags)
 	bookID := m.getFormValue(shelveFieldID)
 
 	// Validate ID
@@ -707,6 +737,10 @@ func (m ShelveModel) submitForm() (ShelveModel, tea.Cmd) {
 		m.err = fmt.Errorf("invalid ID %q — must match ^[a-z0-9][a-z0-9-]{1,62}$", bookID)
 		return m, nil
 	}
+	if err := trash.CheckID(m.existingBooks, bookID); err != nil {
+		m.err = err
+		return m, nil
+	}
 
 	// Determine asset name
 	assetName := ""
@@ -10,7 +10,10 @@ import (
 
 	"github.com/blackwell-systems/shelfctl/internal/catalog"
 	"github.com/blackwell-systems/shelfctl/internal/ingest"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
 	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/parts"
+	"github.com/blackwell-systems/shelfctl/internal/releases"
 	"github.com/blackwell-systems/shelfctl/internal/tui"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -38,8 +41,10 @@ func (m ShelveModel) setupAsync() tea.Cmd {
 			return shelveSetupCompleteMsg{err: fmt.Errorf("ensuring release: %w", err)}
 		}
 
+		before, _ := catalog.Marshal(existingBooks)
 		return shelveSetupCompleteMsg{
 			existingBooks: existingBooks,
+			catalogBefore: before,
 			release:       rel,
 		}
 	}
@@ -92,11 +97,16 @@ func (m ShelveModel) ingestCurrentFile() tea.Cmd {
 			srcName: src.Name,
 		}
 
-		// Extract PDF metadata if applicable
-		if ext == "pdf" {
+		// Extract embedded metadata if applicable
+		switch ext {
+		case "pdf":
 			if pdfMeta, err := ingest.ExtractPDFMetadata(tmpPath); err == nil {
 				result.pdfMetadata = pdfMeta
 			}
+		case "epub":
+			if epubMeta, err := ingest.ExtractEPUBMetadata(tmpPath); err == nil {
+				result.epubMetadata = epubMeta
+			}
 		}
 
 		return result
@@ -111,20 +121,27 @@ func (m ShelveModel) processFileAsync(statusCh chan shelveProcessingMsg, bookID,
 	tmpPath := m.ingested.tmpPath
 	size := m.ingested.size
 	format := m.ingested.format
+	pages := 0
+	if m.ingested.pdfMetadata != nil {
+		pages = m.ingested.pdfMetadata.PageCount
+	}
 	doCache := m.cacheLocally
+	year := m.formDefaults.year
 	owner := m.owner
 	repo := m.shelf.Repo
-	releaseID := m.release.ID
+	release := m.release
 	releaseTag := m.releaseTag
 	gh := m.gh
 	cacheMgr := m.cacheMgr
+	partSize := m.cfg.Defaults.EffectivePartSize()
+	policy := m.shelf.EffectiveReleasePolicy(m.cfg.Defaults.Releases)
 
 	go func() {
 		defer close(statusCh)
 
 		// 1. Check duplicates
 		statusCh <- shelveProcessingMsg{kind: "status", status: "Checking for duplicates..."}
-		for _, b := range existingBooks {
+		for _, b := range catalog.Live(existingBooks) {
 			if b.Checksum.SHA256 == sha256 {
 				statusCh <- shelveProcessingMsg{
 					kind: "done",
@@ -134,22 +151,44 @@ func (m ShelveModel) processFileAsync(statusCh chan shelveProcessingMsg, bookID,
 			}
 		}
 
-		// 2. Check asset collision
-		statusCh <- shelveProcessingMsg{kind: "status", status: "Checking for conflicts..."}
-		existingAsset, err := gh.FindAsset(owner, repo, releaseID, assetName)
+		// 2. Pick the release, rolling over to a new one if the policy says so
+		plan := parts.Plan(assetName, size, partSize)
+		releaseTag, err := releases.Pick(gh, owner, repo, policy, releases.Tag(policy, releaseTag, year, tags), max(1, len(plan)), size)
 		if err != nil {
-			statusCh <- shelveProcessingMsg{kind: "done", err: fmt.Errorf("checking assets: %w", err)}
+			statusCh <- shelveProcessingMsg{kind: "done", err: fmt.Errorf("picking release: %w", err)}
 			return
 		}
-		if existingAsset != nil {
-			statusCh <- shelveProcessingMsg{
-				kind: "done",
-				err:  fmt.Errorf("asset %q already exists in release %s", assetName, releaseTag),
+		if releaseTag != release.TagName {
+			statusCh <- shelveProcessingMsg{kind: "status", status: "Using release " + releaseTag + "..."}
+			if release, err = gh.EnsureRelease(owner, repo, releaseTag); err != nil {
+				statusCh <- shelveProcessingMsg{kind: "done", err: fmt.Errorf("ensuring release: %w", err)}
+				return
+			}
+		}
+		releaseID := release.ID
+
+		// 3. Check asset collision
+		statusCh <- shelveProcessingMsg{kind: "status", status: "Checking for conflicts..."}
+		names := []string{assetName}
+		for _, p := range plan {
+			names = append(names, p.Asset)
+		}
+		for _, name := range names {
+			existingAsset, err := gh.FindAsset(owner, repo, releaseID, name)
+			if err != nil {
+				statusCh <- shelveProcessingMsg{kind: "done", err: fmt.Errorf("checking assets: %w", err)}
+				return
+			}
+			if existingAsset != nil {
+				statusCh <- shelveProcessingMsg{
+					kind: "done",
+					err:  fmt.Errorf("asset %q already exists in release %s", name, releaseTag),
+				}
+				return
 			}
-			return
 		}
 
-		// 3. Upload with progress
+		// 4. Upload with progress
 		statusCh <- shelveProcessingMsg{kind: "status", status: "Uploading..."}
 
 		uploadFile, err := os.Open(tmpPath)
@@ -160,13 +199,15 @@ func (m ShelveModel) processFileAsync(statusCh chan shelveProcessingMsg, bookID,
 
 		progressCh := make(chan int64, 50)
 		uploadErrCh := make(chan error, 1)
+		var manifest []catalog.Part
 
 		go func() {
 			defer uploadFile.Close() //nolint:errcheck
 			progressCh <- 0
 			pr := tui.NewProgressReader(uploadFile, size, progressCh)
-			_, uploadErr := gh.UploadAsset(owner, repo, releaseID, assetName, pr, size, "application/octet-stream")
+			m, uploadErr := parts.Upload(gh, owner, repo, releaseID, assetName, pr, size, partSize)
 			close(progressCh)
+			manifest = m
 			uploadErrCh <- uploadErr
 		}()
 
@@ -180,28 +221,32 @@ func (m ShelveModel) processFileAsync(statusCh chan shelveProcessingMsg, bookID,
 			return
 		}
 
-		// 4. Build catalog entry
+		// 5. Build catalog entry
 		book := catalog.Book{
-			ID:        bookID,
-			Title:     title,
-			Author:    author,
-			Tags:      tags,
-			Format:    format,
-			SizeBytes: size,
-			Checksum:  catalog.Checksum{SHA256: sha256},
+			ID:         bookID,
+			Title:      title,
+			Author:     author,
+			Year:       year,
+			Pages:      pages,
+			Tags:       tags,
+			Format:     format,
+			SizeBytes:  size,
+			Checksum:   catalog.Checksum{SHA256: sha256},
+			Encryption: gh.EncryptionScheme(owner, repo),
 			Source: catalog.Source{
 				Type:    "github_release",
 				Owner:   owner,
 				Repo:    repo,
 				Release: releaseTag,
 				Asset:   assetName,
+				Parts:   manifest,
 			},
 			Meta: catalog.Meta{
 				AddedAt: time.Now().UTC().Format(time.RFC3339),
 			},
 		}
 
-		// 5. Cache locally if requested
+		// 6. Cache locally if requested
 		if doCache {
 			statusCh <- shelveProcessingMsg{kind: "status", status: "Caching locally..."}
 			f, err := os.Open(tmpPath)
@@ -290,12 +335,29 @@ func (m ShelveModel) advanceToNextFileOrCommit() (ShelveModel, tea.Cmd) {
 func (m ShelveModel) commitAsync() tea.Cmd {
 	existingBooks := m.existingBooks
 	newBooks := m.newBooks
+	before := m.catalogBefore
 	owner := m.owner
 	repo := m.shelf.Repo
 	catalogPath := m.catalogPath
 	gh := m.gh
+	journalDir := m.cfg.Defaults.JournalDir
 
 	return func() tea.Msg {
+		// Record the uploaded assets first, so they can be undone even if
+		// the catalog commit fails.
+		ids := make([]string, len(newBooks))
+		for i, b := range newBooks {
+			ids[i] = b.ID
+		}
+		j := journal.Open(journalDir)
+		op := j.Begin("shelve", "add "+bookList(ids))
+		defer func() { _ = j.Save(op) }()
+		for _, b := range newBooks {
+			for _, loc := range journal.Locations(owner, repo, b.Source) {
+				op.Added(loc)
+			}
+		}
+
 		// Create commit message
 		var msg string
 		if len(newBooks) == 1 {
@@ -309,6 +371,8 @@ func (m ShelveModel) commitAsync() tea.Cmd {
 		if err := catalogMgr.Save(existingBooks, msg); err != nil {
 			return shelveCommitCompleteMsg{err: err}
 		}
+		after, _ := catalog.Marshal(existingBooks)
+		op.Catalog(owner, repo, catalogPath, before, after)
 
 		// Update README
 		readmeData, _, readmeErr := gh.GetFileContent(owner, repo, "README.md", "")
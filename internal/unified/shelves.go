@@ -123,7 +123,7 @@ func (m ShelvesModel) loadAsync() tea.Cmd {
 			}
 			s.catalogOK = true
 			if books, err := catalog.Parse(catalogData); err == nil {
-				s.bookCount = len(books)
+				s.bookCount = len(catalog.Live(books))
 			}
 
 			// Check release
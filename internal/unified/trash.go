@@ -0,0 +1,395 @@
+package unified
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackwell-systems/bubbletea-multiselect"
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+	"github.com/blackwell-systems/shelfctl/internal/config"
+	"github.com/blackwell-systems/shelfctl/internal/github"
+	"github.com/blackwell-systems/shelfctl/internal/journal"
+	"github.com/blackwell-systems/shelfctl/internal/operations"
+	"github.com/blackwell-systems/shelfctl/internal/trash"
+	"github.com/blackwell-systems/shelfctl/internal/tui"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// trashPhase tracks the current phase of the trash workflow
+type trashPhase int
+
+const (
+	trashPicking    trashPhase = iota // Showing trashed books
+	trashConfirming                   // Showing confirmation screen
+	trashProcessing                   // Restoring or deleting
+)
+
+// TrashCompleteMsg is emitted when restoring or emptying finishes
+type TrashCompleteMsg struct {
+	SuccessCount int
+	FailCount    int
+}
+
+// TrashModel is the unified view for restoring trashed books or deleting
+// them for good
+type TrashModel struct {
+	phase     trashPhase
+	ms        multiselect.Model
+	gh        *github.Client
+	cfg       *config.Config
+	width     int
+	height    int
+	err       error
+	empty     bool // true if the trash is empty
+	activeCmd string
+
+	// Confirmation phase
+	selected []tui.BookItem
+	purge    bool // delete for good instead of restoring
+}
+
+// NewTrashModel creates a new trash view
+func NewTrashModel(books []tui.BookItem, gh *github.Client, cfg *config.Config) TrashModel {
+	if len(books) == 0 {
+		return TrashModel{gh: gh, cfg: cfg, empty: true}
+	}
+
+	ms, err := tui.NewBookPickerMultiModel(books, "Trash")
+	if err != nil {
+		return TrashModel{gh: gh, cfg: cfg, err: err}
+	}
+
+	return TrashModel{
+		phase: trashPicking,
+		ms:    ms,
+		gh:    gh,
+		cfg:   cfg,
+	}
+}
+
+// Init initializes the trash view
+func (m TrashModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the trash view
+func (m TrashModel) Update(msg tea.Msg) (TrashModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+		if m.phase == trashPicking && !m.empty && m.err == nil {
+			h, v := tui.StyleBorder.GetFrameSize()
+			m.ms.List.SetSize(msg.Width-h, msg.Height-v)
+			m.ms.List.Title = tui.StyleHeader.Render("Trash") + "\n" + tui.RenderColumnHeader(m.ms.List.Width())
+			m.ms.List.Styles.Title = lipgloss.NewStyle()
+		}
+		return m, nil
+
+	case tui.ClearActiveCmdMsg:
+		m.activeCmd = ""
+		return m, nil
+
+	case tea.KeyMsg:
+		// Handle empty state or error
+		if m.empty || m.err != nil {
+			switch msg.String() {
+			case "enter", "esc", "q":
+				return m, func() tea.Msg { return NavigateMsg{Target: "hub"} }
+			}
+			return m, nil
+		}
+
+		switch m.phase {
+		case trashPicking:
+			return m.updatePicking(msg)
+		case trashConfirming:
+			return m.updateConfirming(msg)
+		case trashProcessing:
+			// Ignore input during processing
+			return m, nil
+		}
+
+	case TrashCompleteMsg:
+		return m, func() tea.Msg { return NavigateMsg{Target: "hub"} }
+	}
+
+	// Forward to picker in picking phase
+	if m.phase == trashPicking && !m.empty && m.err == nil {
+		var cmd tea.Cmd
+		m.ms, cmd = m.ms.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m TrashModel) updatePicking(msg tea.KeyMsg) (TrashModel, tea.Cmd) {
+	// Don't handle keys when filtering
+	if m.ms.List.FilterState() == list.Filtering {
+		var cmd tea.Cmd
+		m.ms, cmd = m.ms.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, func() tea.Msg { return QuitAppMsg{} }
+
+	case "q", "esc":
+		return m, func() tea.Msg { return NavigateMsg{Target: "hub"} }
+
+	case " ":
+		// Toggle checkbox
+		m.ms.Toggle()
+		m.activeCmd = " "
+		return m, tui.HighlightCmd()
+
+	case "enter", "x":
+		// Collect selected books (the current one if none are checked)
+		selected := tui.CollectSelectedBooks(&m.ms)
+		if len(selected) == 0 {
+			return m, nil
+		}
+
+		m.selected = selected
+		m.purge = msg.String() == "x"
+		m.phase = trashConfirming
+		m.activeCmd = msg.String()
+		return m, tui.HighlightCmd()
+	}
+
+	// Forward other keys to multiselect
+	var cmd tea.Cmd
+	m.ms, cmd = m.ms.Update(msg)
+	return m, cmd
+}
+
+func (m TrashModel) updateConfirming(msg tea.KeyMsg) (TrashModel, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, func() tea.Msg { return QuitAppMsg{} }
+
+	case "q", "esc", "n":
+		// Go back to picker
+		m.phase = trashPicking
+		m.activeCmd = "q"
+		return m, tui.HighlightCmd()
+
+	case "enter", "y":
+		// Confirm - start processing
+		m.activeCmd = msg.String()
+		m.phase = trashProcessing
+		return m, tea.Batch(m.processAsync(), tui.HighlightCmd())
+	}
+
+	return m, nil
+}
+
+// View renders the trash view
+func (m TrashModel) View() string {
+	// Empty state
+	if m.empty {
+		return m.renderMessage("The trash is empty", "Press Enter to return to menu")
+	}
+
+	// Error state
+	if m.err != nil && m.phase == trashPicking {
+		return m.renderMessage(fmt.Sprintf("Error: %v", m.err), "Press Enter to return to menu")
+	}
+
+	switch m.phase {
+	case trashPicking:
+		return tui.RenderWithFooter(m.ms.View(), []tui.ShortcutEntry{
+			{Key: " ", Label: "space toggle"},
+			{Key: "enter", Label: "enter restore"},
+			{Key: "x", Label: "x delete for good"},
+			{Key: "q", Label: "q/esc back"},
+		}, m.activeCmd)
+
+	case trashConfirming:
+		return m.renderConfirmation()
+
+	case trashProcessing:
+		if m.purge {
+			return m.renderMessage("Deleting books...", "Please wait")
+		}
+		return m.renderMessage("Restoring books...", "Please wait")
+	}
+
+	return ""
+}
+
+func (m TrashModel) renderMessage(title, help string) string {
+	style := lipgloss.NewStyle().Padding(2, 4)
+
+	var b strings.Builder
+	b.WriteString(tui.StyleHeader.Render(title))
+	b.WriteString("\n\n")
+	b.WriteString(tui.StyleHelp.Render(help))
+	b.WriteString("\n")
+
+	innerPadding := lipgloss.NewStyle().Padding(0, 2, 0, 1)
+	return style.Render(tui.StyleBorder.Render(innerPadding.Render(b.String())))
+}
+
+func (m TrashModel) renderConfirmation() string {
+	style := lipgloss.NewStyle().Padding(2, 4)
+
+	var b strings.Builder
+
+	dangerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+
+	if m.purge {
+		b.WriteString(dangerStyle.Render("Confirm Delete"))
+		b.WriteString("\n\n")
+		b.WriteString(tui.StyleNormal.Render(fmt.Sprintf("Delete %d book(s) for good:", len(m.selected))))
+	} else {
+		b.WriteString(tui.StyleHeader.Render("Confirm Restore"))
+		b.WriteString("\n\n")
+		b.WriteString(tui.StyleNormal.Render(fmt.Sprintf("Restore %d book(s):", len(m.selected))))
+	}
+	b.WriteString("\n")
+	for _, item := range m.selected {
+		b.WriteString(tui.StyleNormal.Render(fmt.Sprintf("  - %s (%s) [%s]", item.Book.ID, item.Book.Title, item.ShelfName)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.purge {
+		b.WriteString(dangerStyle.Render("THIS CANNOT BE UNDONE"))
+	} else {
+		b.WriteString(tui.StyleHelp.Render("The files move back to the release they were deleted from"))
+	}
+	b.WriteString("\n\n")
+
+	// Help
+	b.WriteString(tui.RenderFooterBar([]tui.ShortcutEntry{
+		{Key: "enter", Label: "Enter/y Confirm"},
+		{Key: "q", Label: "q/Esc/n Cancel"},
+	}, m.activeCmd))
+	b.WriteString("\n")
+
+	innerPadding := lipgloss.NewStyle().Padding(0, 2, 0, 1)
+	return style.Render(tui.StyleBorder.Render(innerPadding.Render(b.String())))
+}
+
+// processAsync restores or deletes the selected books in background, one
+// catalog commit per shelf
+func (m TrashModel) processAsync() tea.Cmd {
+	selected := m.selected
+	purge := m.purge
+	gh := m.gh
+	cfg := m.cfg
+
+	return func() tea.Msg {
+		var op *journal.Op
+		j := journal.Open(cfg.Defaults.JournalDir)
+		if !purge {
+			ids := make([]string, len(selected))
+			for i, item := range selected {
+				ids[i] = item.Book.ID
+			}
+			op = j.Begin("trash restore", "restore "+bookList(ids))
+			defer func() { _ = j.Save(op) }()
+		}
+
+		byShelf := make(map[string][]tui.BookItem)
+		var order []string
+		for _, item := range selected {
+			if _, seen := byShelf[item.ShelfName]; !seen {
+				order = append(order, item.ShelfName)
+			}
+			byShelf[item.ShelfName] = append(byShelf[item.ShelfName], item)
+		}
+
+		successCount := 0
+		for _, name := range order {
+			successCount += processTrashShelf(byShelf[name], purge, gh, op)
+		}
+
+		return TrashCompleteMsg{
+			SuccessCount: successCount,
+			FailCount:    len(selected) - successCount,
+		}
+	}
+}
+
+// processTrashShelf restores or deletes trashed books of one shelf and
+// returns how many were handled. Restores are recorded in op.
+func processTrashShelf(items []tui.BookItem, purge bool, gh *github.Client, op *journal.Op) int {
+	owner, repo, catalogPath := items[0].Owner, items[0].Repo, items[0].CatalogPath
+
+	data, _, err := gh.GetFileContent(owner, repo, catalogPath, "")
+	if err != nil {
+		return 0
+	}
+	books, err := catalog.Parse(data)
+	if err != nil {
+		return 0
+	}
+
+	var done []catalog.Book
+	for _, item := range items {
+		b := catalog.ByID(books, item.Book.ID)
+		if b == nil || !b.IsTrashed() {
+			continue
+		}
+		if purge {
+			if err := trash.Purge(gh, owner, repo, b); err != nil {
+				continue
+			}
+			done = append(done, *b)
+			books, _ = catalog.Remove(books, item.Book.ID)
+			continue
+		}
+		from := journal.Locations(owner, repo, b.Source)
+		if err := trash.Restore(gh, owner, repo, b); err != nil {
+			continue
+		}
+		b.Encryption = gh.EncryptionScheme(owner, repo)
+		op.MovedAll(from, journal.Locations(owner, repo, b.Source))
+		done = append(done, *b)
+	}
+	if len(done) == 0 {
+		return 0
+	}
+
+	ids := make([]string, len(done))
+	for i, b := range done {
+		ids[i] = b.ID
+	}
+	updatedData, err := catalog.Marshal(books)
+	if err != nil {
+		return 0
+	}
+	commitMsg := "restore: " + strings.Join(ids, ", ")
+	if purge {
+		commitMsg = fmt.Sprintf("trash: empty %d books", len(done))
+	}
+	if err := gh.CommitFile(owner, repo, catalogPath, updatedData, commitMsg); err != nil {
+		return 0
+	}
+	if purge {
+		return len(done)
+	}
+	op.Catalog(owner, repo, catalogPath, data, updatedData)
+
+	// Update README
+	readmeData, _, err := gh.GetFileContent(owner, repo, "README.md", "")
+	if err == nil {
+		originalContent := string(readmeData)
+		readmeContent := operations.UpdateShelfREADMEStats(originalContent, len(catalog.Live(books)))
+		for _, b := range done {
+			readmeContent = operations.AppendToShelfREADME(readmeContent, b)
+		}
+		if readmeContent != originalContent {
+			_ = gh.CommitFile(owner, repo, "README.md", []byte(readmeContent), "Update README: restore "+strings.Join(ids, ", "))
+		}
+	}
+
+	return len(done)
+}
@@ -110,3 +110,30 @@ func TestExpandHome_BareTilde(t *testing.T) {
 		t.Errorf("ExpandHome(\"~\") = %q, want \"~\" (no expansion without /)", got)
 	}
 }
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"1048576": 1 << 20,
+		"512K":    512 << 10,
+		"500MB":   500 << 20,
+		"20G":     20 << 30,
+		"1.5GiB":  3 << 29,
+		" 2 t ":   2 << 40,
+		"0":       0,
+	}
+	for in, want := range tests {
+		got, err := util.ParseSize(in)
+		if err != nil {
+			t.Errorf("ParseSize(%q): %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "G", "lots", "-1G", "10X"} {
+		if _, err := util.ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) succeeded, want an error", in)
+		}
+	}
+}
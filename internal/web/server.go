@@ -0,0 +1,233 @@
+// Package web serves the live library page for `shelfctl serve --web`.
+//
+// The page is the same one `shelfctl index` writes (cache.GenerateLiveHTML),
+// backed by a small JSON API for downloading uncached books with progress,
+// syncing local changes and editing metadata.
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/blackwell-systems/shelfctl/internal/cache"
+)
+
+// Edit is a metadata change submitted from the page.
+type Edit struct {
+	Title  string   `json:"title"`
+	Author string   `json:"author"`
+	Year   int      `json:"year"`
+	Tags   []string `json:"tags"`
+}
+
+// Backend does the work behind the page.
+type Backend interface {
+	// Books lists every served book, cached or not.
+	Books() ([]cache.IndexBook, error)
+	// Download caches a book, reporting bytes as they arrive.
+	Download(shelf, id string, progress func(done, total int64)) error
+	// Sync uploads a cached book if it was modified locally and reports
+	// whether anything was uploaded.
+	Sync(shelf, id string) (bool, error)
+	// SyncAll syncs every modified cached book and returns how many.
+	SyncAll() (int, error)
+	// Edit updates a book's catalog entry.
+	Edit(shelf, id string, e Edit) error
+	// Reload drops cached catalogs so the next request reads them again.
+	Reload() error
+}
+
+// Job states.
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Job is the progress of one download.
+type Job struct {
+	State string `json:"state"`
+	Done  int64  `json:"done"`
+	Total int64  `json:"total"`
+	Error string `json:"error,omitempty"`
+}
+
+// Server serves the live page and its API. Book files and covers are
+// expected under /books, as registered by opds.Server.RegisterBooks.
+type Server struct {
+	Backend Backend
+	Cache   *cache.Manager
+	// Logf, if set, receives one line per failed request.
+	Logf func(format string, args ...interface{})
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// Register adds the page and API routes to mux.
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /{$}", s.servePage)
+	mux.HandleFunc("POST /api/download/{shelf}/{id}", s.sameOrigin(s.startDownload))
+	mux.HandleFunc("GET /api/jobs/{shelf}/{id}", s.serveJob)
+	mux.HandleFunc("POST /api/sync/{shelf}/{id}", s.sameOrigin(s.syncBook))
+	mux.HandleFunc("POST /api/sync", s.sameOrigin(s.syncAll))
+	mux.HandleFunc("POST /api/books/{shelf}/{id}", s.sameOrigin(s.editBook))
+	mux.HandleFunc("POST /api/reload", s.sameOrigin(s.reload))
+}
+
+func (s *Server) servePage(w http.ResponseWriter, r *http.Request) {
+	books, err := s.Backend.Books()
+	if err != nil {
+		s.fail(w, r, http.StatusBadGateway, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(s.Cache.GenerateLiveHTML(books)))
+}
+
+func jobKey(r *http.Request) string {
+	return r.PathValue("shelf") + "/" + r.PathValue("id")
+}
+
+// startDownload starts caching a book in the background. A download already
+// in progress is not started twice.
+func (s *Server) startDownload(w http.ResponseWriter, r *http.Request) {
+	shelf, id := r.PathValue("shelf"), r.PathValue("id")
+	key := jobKey(r)
+
+	s.mu.Lock()
+	if s.jobs == nil {
+		s.jobs = map[string]*Job{}
+	}
+	job, running := s.jobs[key]
+	if !running || job.State != JobRunning {
+		job = &Job{State: JobRunning}
+		s.jobs[key] = job
+		go s.runDownload(shelf, id, job)
+	}
+	snapshot := *job
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (s *Server) runDownload(shelf, id string, job *Job) {
+	err := s.Backend.Download(shelf, id, func(done, total int64) {
+		s.mu.Lock()
+		job.Done, job.Total = done, total
+		s.mu.Unlock()
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		job.State, job.Error = JobFailed, err.Error()
+		if s.Logf != nil {
+			s.Logf("download %s/%s: %v", shelf, id, err)
+		}
+		return
+	}
+	job.State = JobDone
+	if job.Total == 0 {
+		job.Total = job.Done
+	}
+}
+
+func (s *Server) serveJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[jobKey(r)]
+	var snapshot Job
+	if ok {
+		snapshot = *job
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no download for this book"})
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (s *Server) syncBook(w http.ResponseWriter, r *http.Request) {
+	synced, err := s.Backend.Sync(r.PathValue("shelf"), r.PathValue("id"))
+	if err != nil {
+		s.fail(w, r, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"synced": synced})
+}
+
+func (s *Server) syncAll(w http.ResponseWriter, r *http.Request) {
+	n, err := s.Backend.SyncAll()
+	if err != nil {
+		s.fail(w, r, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"synced": n})
+}
+
+func (s *Server) editBook(w http.ResponseWriter, r *http.Request) {
+	var e Edit
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&e); err != nil {
+		s.fail(w, r, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if e.Title == "" {
+		s.fail(w, r, http.StatusBadRequest, fmt.Errorf("title is required"))
+		return
+	}
+	if e.Year < 0 {
+		s.fail(w, r, http.StatusBadRequest, fmt.Errorf("invalid year %d", e.Year))
+		return
+	}
+	if err := s.Backend.Edit(r.PathValue("shelf"), r.PathValue("id"), e); err != nil {
+		s.fail(w, r, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"saved": true})
+}
+
+func (s *Server) reload(w http.ResponseWriter, r *http.Request) {
+	if err := s.Backend.Reload(); err != nil {
+		s.fail(w, r, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"reloaded": true})
+}
+
+// sameOrigin rejects state-changing requests from other sites. Requiring a
+// JSON body means browsers must preflight cross-origin requests, which the
+// server never approves; the Origin check covers the rest.
+func (s *Server) sameOrigin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+			s.fail(w, r, http.StatusUnsupportedMediaType, fmt.Errorf("expected application/json"))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				s.fail(w, r, http.StatusForbidden, fmt.Errorf("cross-origin request refused"))
+				return
+			}
+		}
+		h(w, r)
+	}
+}
+
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if s.Logf != nil {
+		s.Logf("%s %s: %v", r.Method, r.URL.Path, err)
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,211 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/shelfctl/internal/cache"
+	"github.com/blackwell-systems/shelfctl/internal/catalog"
+)
+
+type fakeBackend struct {
+	mu      sync.Mutex
+	books   []cache.IndexBook
+	release chan struct{}
+	dlErr   error
+	edits   []Edit
+	synced  []string
+}
+
+func (f *fakeBackend) Books() ([]cache.IndexBook, error) { return f.books, nil }
+
+func (f *fakeBackend) Download(shelf, id string, progress func(done, total int64)) error {
+	progress(50, 100)
+	<-f.release
+	progress(100, 100)
+	return f.dlErr
+}
+
+func (f *fakeBackend) Sync(shelf, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.synced = append(f.synced, shelf+"/"+id)
+	return true, nil
+}
+
+func (f *fakeBackend) SyncAll() (int, error) { return 2, nil }
+
+func (f *fakeBackend) Edit(shelf, id string, e Edit) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.edits = append(f.edits, e)
+	return nil
+}
+
+func (f *fakeBackend) Reload() error { return nil }
+
+func newTestServer(t *testing.T) (*fakeBackend, *httptest.Server) {
+	t.Helper()
+	backend := &fakeBackend{
+		release: make(chan struct{}),
+		books: []cache.IndexBook{
+			{Book: catalog.Book{ID: "dune", Title: "Dune"}, ShelfName: "fiction", IsCached: true, FilePath: "/tmp/dune.epub"},
+			{Book: catalog.Book{ID: "emma", Title: "Emma"}, ShelfName: "fiction"},
+		},
+	}
+	mux := http.NewServeMux()
+	(&Server{Backend: backend, Cache: cache.New(t.TempDir())}).Register(mux)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return backend, ts
+}
+
+func postJSON(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestPageListsUncachedBooksAsDownloadable(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+
+	for _, want := range []string{
+		`href="/books/fiction/dune/file?inline=1"`,
+		`class="book-card uncached live" data-shelf="fiction"`,
+		`src="/books/fiction/emma/cover"`,
+		`id="sync-all"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if strings.Contains(page, "file:///tmp/dune.epub") {
+		t.Error("live page links to the local file")
+	}
+}
+
+func TestDownloadJobReportsProgress(t *testing.T) {
+	backend, ts := newTestServer(t)
+
+	if resp := postJSON(t, ts.URL+"/api/download/fiction/emma", "{}"); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("start: %d", resp.StatusCode)
+	}
+	// A second click while running reuses the job.
+	postJSON(t, ts.URL+"/api/download/fiction/emma", "{}")
+
+	job := waitJob(t, ts.URL, func(j Job) bool { return j.Done == 50 })
+	if job.State != JobRunning || job.Total != 100 {
+		t.Errorf("running job = %+v", job)
+	}
+
+	close(backend.release)
+	job = waitJob(t, ts.URL, func(j Job) bool { return j.State != JobRunning })
+	if job.State != JobDone || job.Done != 100 {
+		t.Errorf("finished job = %+v", job)
+	}
+}
+
+func TestDownloadJobFailure(t *testing.T) {
+	backend, ts := newTestServer(t)
+	backend.dlErr = errors.New("asset missing")
+	close(backend.release)
+
+	postJSON(t, ts.URL+"/api/download/fiction/emma", "{}")
+	job := waitJob(t, ts.URL, func(j Job) bool { return j.State != JobRunning })
+	if job.State != JobFailed || job.Error != "asset missing" {
+		t.Errorf("job = %+v", job)
+	}
+}
+
+func waitJob(t *testing.T, base string, done func(Job) bool) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(base + "/api/jobs/fiction/emma")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var j Job
+		err = json.NewDecoder(resp.Body).Decode(&j)
+		_ = resp.Body.Close()
+		if err == nil && done(j) {
+			return j
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job never reached expected state, last %+v", j)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEditAndSync(t *testing.T) {
+	backend, ts := newTestServer(t)
+
+	resp := postJSON(t, ts.URL+"/api/books/fiction/dune", `{"title":"Dune","author":"Frank Herbert","year":1965,"tags":["scifi"]}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("edit: %d", resp.StatusCode)
+	}
+	if len(backend.edits) != 1 || backend.edits[0].Year != 1965 || backend.edits[0].Tags[0] != "scifi" {
+		t.Errorf("edits = %+v", backend.edits)
+	}
+	if resp := postJSON(t, ts.URL+"/api/books/fiction/dune", `{"title":""}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("empty title: %d", resp.StatusCode)
+	}
+
+	resp = postJSON(t, ts.URL+"/api/sync/fiction/dune", "{}")
+	var res map[string]bool
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	if !res["synced"] || len(backend.synced) != 1 {
+		t.Errorf("sync result %v, synced %v", res, backend.synced)
+	}
+}
+
+func TestRejectsCrossOriginPosts(t *testing.T) {
+	backend, ts := newTestServer(t)
+
+	resp, err := http.Post(ts.URL+"/api/sync/fiction/dune", "text/plain", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain post: %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest("POST", ts.URL+"/api/sync/fiction/dune", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://evil.example")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("foreign origin: %d", resp.StatusCode)
+	}
+	if len(backend.synced) != 0 {
+		t.Errorf("rejected requests reached the backend: %v", backend.synced)
+	}
+}